@@ -0,0 +1,112 @@
+// Package dedup implements an Acceptor that rejects commands that were already committed or proposed,
+// such that a command that a client sent to several replicas, or that a faulty leader replays,
+// is not committed in more than one block.
+//
+// A proposed block is rejected if its command is in one of the uncommitted ancestors of the block,
+// or if it is one of the last Window committed commands. The decision only depends on the block chain,
+// so all correct replicas that committed the same blocks agree on it. A command whose block was abandoned
+// is not an ancestor of later blocks, so it may be proposed again.
+package dedup
+
+import (
+	"github.com/relab/hotstuff/consensus"
+)
+
+// DefaultWindow is the number of committed commands that are remembered if Config.Window is zero.
+const DefaultWindow = 10000
+
+// Config configures the Acceptor.
+type Config struct {
+	// Window is the number of committed commands that are remembered.
+	// A command that was committed before the last Window commands is accepted again.
+	Window int
+}
+
+// Acceptor rejects commands that are in the uncommitted ancestors of the block that carries them,
+// or that were recently committed. It must only be used from the event loop.
+type Acceptor struct {
+	mods *consensus.Modules
+	cfg  Config
+
+	committed map[consensus.Hash]struct{}
+	window    []consensus.Hash // the committed hashes, used as a ring buffer
+	next      int              // the position of the oldest committed hash in window, once it is full
+}
+
+// New returns a new Acceptor.
+func New(cfg Config) *Acceptor {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+	return &Acceptor{
+		cfg:       cfg,
+		committed: make(map[consensus.Hash]struct{}),
+	}
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (a *Acceptor) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	a.mods = mods
+}
+
+// Accept returns true if the command was not recently committed.
+func (a *Acceptor) Accept(cmd consensus.Command) bool {
+	_, ok := a.committed[consensus.PayloadHash(cmd)]
+	return !ok
+}
+
+// AcceptBlock returns true if the command of the block was not recently committed,
+// and is not the command of any of the block's ancestors after the committed block.
+func (a *Acceptor) AcceptBlock(block *consensus.Block) bool {
+	hash := consensus.PayloadHash(block.Command())
+	if _, ok := a.committed[hash]; ok {
+		return false
+	}
+	committed := a.mods.Consensus().CommittedBlock()
+	for parent := block.Parent(); parent != committed.Hash(); {
+		ancestor, ok := a.mods.BlockChain().LocalGet(parent)
+		if !ok || ancestor.View() <= committed.View() {
+			// the block does not extend the committed block, so the consensus rules reject it.
+			break
+		}
+		if !ancestor.IsDummy() && payloadHash(ancestor) == hash {
+			return false
+		}
+		parent = ancestor.Parent()
+	}
+	return true
+}
+
+// Proposed does nothing, as the commands of uncommitted blocks are found in the ancestors of later blocks.
+func (a *Acceptor) Proposed(_ consensus.Command) {}
+
+// Committed tells the acceptor that the command was committed, such that it is rejected from now on.
+func (a *Acceptor) Committed(cmd consensus.Command) {
+	hash := consensus.PayloadHash(cmd)
+	if _, ok := a.committed[hash]; ok {
+		return
+	}
+	if len(a.window) < a.cfg.Window {
+		a.window = append(a.window, hash)
+	} else {
+		delete(a.committed, a.window[a.next])
+		a.window[a.next] = hash
+		a.next = (a.next + 1) % len(a.window)
+	}
+	a.committed[hash] = struct{}{}
+}
+
+// payloadHash returns the hash of the command of the block, which is known even if the payload was not resolved.
+func payloadHash(block *consensus.Block) consensus.Hash {
+	if ref, ok := block.PayloadRef(); ok {
+		return ref
+	}
+	return consensus.PayloadHash(block.Command())
+}
+
+var (
+	_ consensus.Acceptor      = (*Acceptor)(nil)
+	_ consensus.BlockAcceptor = (*Acceptor)(nil)
+	_ consensus.CommitTracker = (*Acceptor)(nil)
+)
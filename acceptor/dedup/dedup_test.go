@@ -0,0 +1,83 @@
+package dedup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/relab/hotstuff/acceptor/dedup"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/testutil"
+)
+
+func TestAcceptor(t *testing.T) {
+	a := dedup.New(dedup.Config{Window: 2})
+
+	if !a.Accept("a") {
+		t.Fatal("expected a new command to be accepted")
+	}
+	a.Proposed("a")
+	if !a.Accept("a") {
+		t.Error("expected a command that was proposed, but not committed, to be accepted again")
+	}
+
+	a.Committed("a")
+	if a.Accept("a") {
+		t.Error("expected a committed command to be rejected")
+	}
+
+	// "a" falls out of the window after two more commits.
+	a.Committed("b")
+	a.Committed("c")
+	if a.Accept("b") || a.Accept("c") {
+		t.Error("expected the commands in the window to be rejected")
+	}
+	if !a.Accept("a") {
+		t.Error("expected a command that fell out of the window to be accepted")
+	}
+}
+
+func TestAcceptorProposals(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders[0].Options().SetShouldEmitVoteEvents()
+	builders[0].Register(dedup.New(dedup.Config{}))
+	hl := builders.Build()
+	hs := network.Node(1).Modules()
+
+	var votes []consensus.VoteSentEvent
+	hs.MetricsEventLoop().RegisterObserver(consensus.VoteSentEvent{}, func(event interface{}) {
+		votes = append(votes, event.(consensus.VoteSentEvent))
+	})
+	drained, cancel := context.WithCancel(context.Background())
+	cancel()
+	propose := func(block *consensus.Block) {
+		hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: block.Proposer(), Block: block})
+		hs.EventLoop().Run(drained)
+		hs.MetricsEventLoop().Run(drained)
+	}
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "cmd", 1, hs.LeaderRotation().GetLeader(1))
+	propose(b1)
+	if len(votes) != 1 {
+		t.Fatalf("got %d votes, want a vote for the first block with the command", len(votes))
+	}
+
+	qc := testutil.CreateQC(t, b1, hl.Signers())
+	propose(consensus.NewBlock(b1.Hash(), qc, "cmd", 2, hs.LeaderRotation().GetLeader(2)))
+	if len(votes) != 1 {
+		t.Fatalf("voted for block %.8s, which replays the command of its parent", votes[1].BlockHash)
+	}
+
+	b2 := consensus.NewBlock(b1.Hash(), qc, "other", 2, hs.LeaderRotation().GetLeader(2))
+	propose(b2)
+	if len(votes) != 2 || votes[1].BlockHash != b2.Hash() {
+		t.Fatalf("got votes %v, want a vote for the block with a new command", votes)
+	}
+
+	// the block with the other command is abandoned, so the command is proposed again on a fork.
+	b3 := consensus.NewBlock(b1.Hash(), qc, "other", 3, hs.LeaderRotation().GetLeader(3))
+	propose(b3)
+	if len(votes) != 3 || votes[2].BlockHash != b3.Hash() {
+		t.Errorf("got votes %v, want a vote for the block that retries the command of an abandoned block", votes)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"net"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -13,13 +14,21 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/relab/gorums"
 	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/blockchain"
 	"github.com/relab/hotstuff/config"
 	"github.com/relab/hotstuff/consensus"
 	"github.com/relab/hotstuff/crypto/keygen"
 	"github.com/relab/hotstuff/eventloop"
+	"github.com/relab/hotstuff/internal/mocks"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
 	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 func TestConnect(t *testing.T) {
@@ -231,3 +240,354 @@ func createConfig(t *testing.T, td testData, ctrl *gomock.Controller) (cfg *Conf
 		serverTeardown()
 	}
 }
+
+// setupAdmission returns a server for a replica that has committed a block in the given view, and whose leader is always replica 1.
+// It also returns a valid proposal from replica 1 for the view after the committed view.
+func setupAdmission(t testing.TB, ctrl *gomock.Controller, committed consensus.View, filter bool) (*Server, *consensus.Modules, consensus.ProposeMsg) {
+	t.Helper()
+	builders := testutil.CreateBuilders(t, ctrl, 4)
+	genesisQC := consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash())
+	committedBlock := consensus.NewBlock(consensus.GetGenesis().Hash(), genesisQC, "committed", committed, 1)
+	cs := mocks.NewMockConsensus(ctrl)
+	cs.EXPECT().CommittedBlock().AnyTimes().Return(committedBlock)
+	srv := NewServer()
+	builders[0].Register(cs, srv, leaderrotation.NewFixed(1))
+	if filter {
+		builders[0].Options().SetShouldFilterProposals()
+	}
+	builders[0].Options().SetProposalLimits(0, 64)
+	hl := builders.Build()
+
+	qc := testutil.CreateQC(t, committedBlock, hl.Signers()[:3])
+	proposal := consensus.ProposeMsg{
+		ID:    1,
+		Block: consensus.NewBlock(committedBlock.Hash(), qc, "foo", committed+1, 1),
+	}
+	return srv, hl[0], proposal
+}
+
+func TestProposalAdmission(t *testing.T) {
+	const committed = 10
+	ctrl := gomock.NewController(t)
+	srv, mods, valid := setupAdmission(t, ctrl, committed, true)
+
+	var delivered []consensus.ProposeMsg
+	mods.EventLoop().RegisterHandler(consensus.ProposeMsg{}, func(event interface{}) {
+		delivered = append(delivered, event.(consensus.ProposeMsg))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mods.EventLoop().Run(ctx)
+
+	block := valid.Block
+	tests := []struct {
+		name  string
+		id    hotstuff.ID
+		block *consensus.Block
+	}{
+		{"StaleView", 1, consensus.NewBlock(block.Parent(), block.QuorumCert(), "foo", committed, 1)},
+		{"Oversized", 1, consensus.NewBlock(block.Parent(), block.QuorumCert(), consensus.Command(make([]byte, 65)), committed+1, 1)},
+		{"NotLeader", 2, block},
+	}
+	for _, test := range tests {
+		pb := hotstuffpb.ProposalToProto(consensus.ProposeMsg{ID: test.id, Block: test.block})
+		if err := srv.admitProposal(test.id, pb); err == nil {
+			t.Errorf("%s: expected the proposal to be rejected", test.name)
+		}
+		srv.onPropose(test.id, pb)
+	}
+	if err := srv.admitProposal(1, &hotstuffpb.Proposal{}); err == nil {
+		t.Error("expected a proposal without a block to be rejected")
+	}
+	srv.onPropose(valid.ID, hotstuffpb.ProposalToProto(valid))
+
+	done := make(chan struct{})
+	mods.EventLoop().AddEvent(func() { close(done) })
+	<-done
+
+	if len(delivered) != 1 {
+		t.Fatalf("expected only the valid proposal to be delivered, got %d proposals", len(delivered))
+	}
+	if got := delivered[0]; got.ID != valid.ID || got.Block.Hash() != valid.Block.Hash() || !got.Block.QuorumCert().Equals(valid.Block.QuorumCert()) {
+		t.Errorf("the valid proposal was changed by the filter: got %v, want %v", got.Block, valid.Block)
+	}
+}
+
+// BenchmarkProposalFlood measures the cost of handling a proposal from a replica that is not the leader,
+// with and without the admission filter.
+func BenchmarkProposalFlood(b *testing.B) {
+	run := func(b *testing.B, filter bool) {
+		ctrl := gomock.NewController(b)
+		srv, mods, proposal := setupAdmission(b, ctrl, 10, filter)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go mods.EventLoop().Run(ctx)
+
+		pb := hotstuffpb.ProposalToProto(proposal)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			srv.onPropose(2, pb)
+		}
+	}
+	b.Run("WithoutFilter", func(b *testing.B) { run(b, false) })
+	b.Run("WithFilter", func(b *testing.B) { run(b, true) })
+}
+
+// TestDrain checks that a draining server rejects proposals and fetch requests without delivering them to the event loop.
+func TestDrain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv, mods, proposal := setupAdmission(t, ctrl, 10, false)
+
+	var delivered int
+	mods.EventLoop().RegisterHandler(consensus.ProposeMsg{}, func(_ interface{}) {
+		delivered++
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mods.EventLoop().Run(ctx)
+
+	// the ID of the sender is read from the metadata when TLS is not used.
+	peerCtx := peer.NewContext(ctx, &peer.Peer{})
+	srvCtx := gorums.ServerCtx{Context: metadata.NewIncomingContext(peerCtx, metadata.Pairs("id", "1"))}
+	srv.Propose(srvCtx, hotstuffpb.ProposalToProto(proposal))
+	srv.Drain()
+	if !srv.Draining() {
+		t.Error("expected the server to be draining")
+	}
+	srv.Propose(srvCtx, hotstuffpb.ProposalToProto(proposal))
+	hash := proposal.Block.Hash()
+	_, err := srv.Fetch(srvCtx, &hotstuffpb.BlockHash{Hash: hash[:]})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected the fetch request to be rejected with codes.Unavailable, got: %v", err)
+	}
+
+	done := make(chan struct{})
+	mods.EventLoop().AddEvent(func() { close(done) })
+	<-done
+	if delivered != 1 {
+		t.Errorf("expected only the proposal that arrived before draining to be delivered, got %d proposals", delivered)
+	}
+}
+
+// TestReachable checks that the server counts the replicas in the configuration that it has received messages from
+// within the QuorumLossTimeout.
+func TestReachable(t *testing.T) {
+	const timeout = time.Second
+	ctrl := gomock.NewController(t)
+	builders := testutil.CreateBuilders(t, ctrl, 4)
+	srv := NewServer()
+	builders[0].Register(srv)
+	builders[0].Options().SetQuorumLossTimeout(timeout)
+	mods := builders.Build()[0]
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mods.EventLoop().Run(ctx)
+
+	if got := srv.Reachable(); got != 1 {
+		t.Errorf("before any messages: got %d reachable replicas, want 1", got)
+	}
+	// replica 7 is not in the configuration, and replica 1 is the local replica.
+	for _, id := range []hotstuff.ID{1, 2, 3, 7} {
+		peerCtx := peer.NewContext(ctx, &peer.Peer{})
+		srvCtx := gorums.ServerCtx{Context: metadata.NewIncomingContext(peerCtx, metadata.Pairs("id", strconv.Itoa(int(id))))}
+		srv.NewView(srvCtx, &hotstuffpb.SyncInfo{})
+	}
+	if got := srv.Reachable(); got != 3 {
+		t.Errorf("after messages from replicas 2 and 3: got %d reachable replicas, want 3", got)
+	}
+
+	srv.mut.Lock()
+	srv.lastSeen[3] = time.Now().Add(-2 * timeout)
+	srv.mut.Unlock()
+	if got := srv.Reachable(); got != 2 {
+		t.Errorf("after replica 3 went silent: got %d reachable replicas, want 2", got)
+	}
+}
+
+// TestMessageRPCs checks that decisions, heartbeats, and relinquish messages are delivered through their own RPCs,
+// and that a proposal without a block is dropped.
+func TestMessageRPCs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv, mods, proposal := setupAdmission(t, ctrl, 10, false)
+
+	var delivered []interface{}
+	for _, event := range []interface{}{consensus.ProposeMsg{}, consensus.DecideMsg{}, consensus.HeartbeatMsg{}, consensus.RelinquishMsg{}} {
+		mods.EventLoop().RegisterHandler(event, func(event interface{}) {
+			delivered = append(delivered, event)
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go mods.EventLoop().Run(ctx)
+
+	peerCtx := peer.NewContext(ctx, &peer.Peer{})
+	srvCtx := gorums.ServerCtx{Context: metadata.NewIncomingContext(peerCtx, metadata.Pairs("id", "1"))}
+	qc := proposal.Block.QuorumCert()
+	relinquish := consensus.RelinquishMsg{SyncInfo: consensus.NewSyncInfo().WithQC(qc)}
+
+	srv.Propose(srvCtx, &hotstuffpb.Proposal{})
+	srv.Decide(srvCtx, hotstuffpb.QuorumCertToProto(qc))
+	srv.Heartbeat(srvCtx, &hotstuffpb.HeartbeatMsg{View: 12, HighQCView: 10})
+	srv.Relinquish(srvCtx, hotstuffpb.RelinquishMsgToProto(relinquish))
+	// a relinquish message for a view that does not follow from its certificates is dropped.
+	forged := hotstuffpb.RelinquishMsgToProto(relinquish)
+	forged.View++
+	srv.Relinquish(srvCtx, forged)
+
+	done := make(chan struct{})
+	mods.EventLoop().AddEvent(func() { close(done) })
+	<-done
+
+	if len(delivered) != 3 {
+		t.Fatalf("expected 3 messages to be delivered, got %d: %v", len(delivered), delivered)
+	}
+	if msg, ok := delivered[0].(consensus.DecideMsg); !ok || msg.ID != 1 || !msg.QC.Equals(qc) {
+		t.Errorf("expected the decision first, got %v", delivered[0])
+	}
+	if msg, ok := delivered[1].(consensus.HeartbeatMsg); !ok || msg.ID != 1 || msg.View != 12 || msg.HighQCView != 10 {
+		t.Errorf("expected the heartbeat second, got %v", delivered[1])
+	}
+	if msg, ok := delivered[2].(consensus.RelinquishMsg); !ok || msg.ID != 1 || msg.View() != relinquish.View() {
+		t.Errorf("expected the relinquish message last, got %v", delivered[2])
+	}
+}
+
+// slowChain is a block chain that takes a while to look up one particular block, like a large block that takes a while
+// to transfer.
+type slowChain struct {
+	consensus.BlockChain
+	slow  consensus.Hash
+	delay time.Duration
+}
+
+func (c *slowChain) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	if m, ok := c.BlockChain.(consensus.Module); ok {
+		m.InitConsensusModule(mods, opts)
+	}
+}
+
+func (c *slowChain) LocalGet(hash consensus.Hash) (*consensus.Block, bool) {
+	if hash == c.slow {
+		time.Sleep(c.delay)
+	}
+	return c.BlockChain.LocalGet(hash)
+}
+
+// TestFetchIsolation checks that a proposal that is sent while a slow fetch request is being served is delayed
+// by the fetch when they share connections, but not when fetches are isolated.
+func TestFetchIsolation(t *testing.T) {
+	const (
+		n     = 4
+		delay = 500 * time.Millisecond
+	)
+	genesis := consensus.GetGenesis()
+	large := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "large", 1, 2)
+	proposal := consensus.ProposeMsg{
+		ID:    1,
+		Block: consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "foo", 1, 1),
+	}
+
+	// latency returns how long it takes for the proposal to reach all replicas while they serve the large block.
+	latency := func(t *testing.T, isolate bool) time.Duration {
+		ctrl := gomock.NewController(t)
+		td := setupReplicas(t, ctrl, n)
+		chains := make([]*slowChain, n)
+		for i := range td.builders {
+			chains[i] = &slowChain{BlockChain: blockchain.New(), slow: large.Hash(), delay: delay}
+			td.builders[i].Register(chains[i])
+		}
+		teardown := createServers(t, td, ctrl)
+		defer teardown()
+		cfg := NewConfig(td.cfg.ID, td.cfg.Creds, gorums.WithDialTimeout(time.Second))
+		td.builders[0].Register(cfg)
+		if isolate {
+			td.builders[0].Options().SetShouldIsolateFetches()
+		}
+		hl := td.builders.Build()
+		if err := cfg.Connect(&td.cfg); err != nil {
+			t.Fatal(err)
+		}
+		defer cfg.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		received := make(chan time.Time, n)
+		for _, hs := range hl[1:] {
+			hs.BlockChain().Store(large)
+			hs.EventLoop().RegisterHandler(consensus.ProposeMsg{}, func(_ interface{}) {
+				received <- time.Now()
+			})
+			go hs.Run(ctx)
+		}
+
+		fetched := make(chan struct{})
+		go func() {
+			if _, _, ok := cfg.Fetch(ctx, large.Hash()); !ok {
+				t.Error("failed to fetch the large block")
+			}
+			close(fetched)
+		}()
+		// give the fetch request a head start, such that the replicas are serving it when the proposal arrives.
+		time.Sleep(50 * time.Millisecond)
+
+		start := time.Now()
+		cfg.Propose(proposal)
+		var last time.Time
+		for i := 1; i < n; i++ {
+			last = <-received
+		}
+		<-fetched
+		return last.Sub(start)
+	}
+
+	if shared := latency(t, false); shared < delay/2 {
+		t.Errorf("expected the fetch to delay the proposal on shared connections, but the proposal arrived after %v", shared)
+	}
+	if isolated := latency(t, true); isolated >= delay/4 {
+		t.Errorf("the proposal arrived after %v, although fetches are isolated", isolated)
+	}
+}
+
+// TestFetchSnapshot checks that a replica can request a snapshot of the committed blocks from the other replicas.
+func TestFetchSnapshot(t *testing.T) {
+	run := func(t *testing.T, setup setupFunc) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		td := setup(t, ctrl, n)
+		genesis := consensus.GetGenesis()
+		committed := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "committed", 1, 2)
+		for _, builder := range td.builders[1:] {
+			cs := mocks.NewMockConsensus(ctrl)
+			cs.EXPECT().CommittedBlock().AnyTimes().Return(committed)
+			builder.Register(cs, blockchain.New())
+		}
+		cfg, teardown := createConfig(t, td, ctrl)
+		defer teardown()
+		td.builders[0].Register(cfg)
+		hl := td.builders.Build()
+		for _, hs := range hl[1:] {
+			hs.BlockChain().Store(committed)
+		}
+		want, err := hl[1].BlockChain().Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		snapshot, ok := cfg.FetchSnapshot(ctx)
+		if !ok {
+			t.Fatal("failed to fetch a snapshot")
+		}
+		if len(snapshot) != len(want) {
+			t.Fatalf("got a snapshot of %d blocks, want %d", len(snapshot), len(want))
+		}
+		for i := range want {
+			if snapshot[i].Block.Hash() != want[i].Block.Hash() {
+				t.Errorf("block %d: got %v, want %v", i, snapshot[i].Block, want[i].Block)
+			}
+		}
+	}
+	runBoth(t, run)
+}
@@ -17,12 +17,15 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type gorumsReplica struct {
+	cfg           *Config
 	node          *hotstuffpb.Node
 	id            hotstuff.ID
 	pubKey        consensus.PublicKey
+	region        string
 	voteCancel    context.CancelFunc
 	newviewCancel context.CancelFunc
 	reputation    float64
@@ -47,6 +50,36 @@ func (r *gorumsReplica) Vote(cert consensus.PartialCert) {
 	r.voteCancel()
 	ctx, r.voteCancel = context.WithCancel(context.Background())
 	pCert := hotstuffpb.PartialCertToProto(cert)
+	pCert.Congested = r.cfg.mods.Congested()
+	if cc := r.cfg.mods.CommitAttestation(); cc != nil {
+		pCert.CommitCert = hotstuffpb.CommitCertToProto(*cc)
+	}
+	r.node.Vote(ctx, pCert, gorums.WithNoSendWaiting())
+}
+
+// Region returns the region that the replica is located in.
+func (r *gorumsReplica) Region() string {
+	return r.region
+}
+
+// RelayVotes sends the votes collected by this replica in its region to the other replica.
+func (r *gorumsReplica) RelayVotes(votes []consensus.VoteMsg) {
+	if r.node == nil {
+		return
+	}
+	var ctx context.Context
+	r.voteCancel()
+	ctx, r.voteCancel = context.WithCancel(context.Background())
+	// the relayed votes are carried by an otherwise empty partial certificate.
+	pCert := &hotstuffpb.PartialCert{}
+	for _, vote := range votes {
+		relayed := hotstuffpb.PartialCertToProto(vote.PartialCert)
+		relayed.Congested = vote.Congested
+		if vote.CommitCert != nil {
+			relayed.CommitCert = hotstuffpb.CommitCertToProto(*vote.CommitCert)
+		}
+		pCert.Relayed = append(pCert.Relayed, relayed)
+	}
 	r.node.Vote(ctx, pCert, gorums.WithNoSendWaiting())
 }
 
@@ -78,9 +111,16 @@ type Config struct {
 
 	mgr           *hotstuffpb.Manager
 	cfg           *hotstuffpb.Configuration
+	mgrOpts       []gorums.ManagerOption
+	fetchMgr      *hotstuffpb.Manager       // if fetches are isolated, the manager of the connections used for fetching
+	fetchCfg      *hotstuffpb.Configuration // if fetches are isolated, the configuration used for fetching
 	replicas      map[hotstuff.ID]consensus.Replica
 	proposeCancel context.CancelFunc
 	timeoutCancel context.CancelFunc
+	decideCancel  context.CancelFunc
+	beatCancel    context.CancelFunc
+
+	relinquishCancel context.CancelFunc
 }
 
 // InitConsensusModule gives the module a reference to the Modules object.
@@ -95,6 +135,10 @@ func NewConfig(id hotstuff.ID, creds credentials.TransportCredentials, opts ...g
 		replicas:      make(map[hotstuff.ID]consensus.Replica),
 		proposeCancel: func() {},
 		timeoutCancel: func() {},
+		decideCancel:  func() {},
+		beatCancel:    func() {},
+
+		relinquishCancel: func() {},
 	}
 	// embed own ID to allow other replicas to identify messages from this replica
 	md := metadata.New(map[string]string{
@@ -115,6 +159,7 @@ func NewConfig(id hotstuff.ID, creds credentials.TransportCredentials, opts ...g
 
 	opts = append(opts, gorums.WithGrpcDialOptions(grpcOpts...))
 
+	cfg.mgrOpts = opts
 	cfg.mgr = hotstuffpb.NewManager(opts...)
 	return cfg
 }
@@ -124,18 +169,20 @@ func (cfg *Config) Connect(replicaCfg *config.ReplicaConfig) (err error) {
 	idMapping := make(map[string]uint32, len(replicaCfg.Replicas)-1)
 	for _, replica := range replicaCfg.Replicas {
 		cfg.replicas[replica.ID] = &gorumsReplica{
+			cfg:           cfg,
 			id:            replica.ID,
 			pubKey:        replica.PubKey,
+			region:        replica.Region,
 			newviewCancel: func() {},
 			voteCancel:    func() {},
-			reputation: 	float64(replica.ID),
+			reputation:    float64(replica.ID),
 		}
 		if replica.ID != replicaCfg.ID {
 			idMapping[replica.Address] = uint32(replica.ID)
 		}
 	}
 
-	cfg.cfg, err = cfg.mgr.NewConfiguration(qspec{}, gorums.WithNodeMap(idMapping))
+	cfg.cfg, err = cfg.mgr.NewConfiguration(qspec{cfg: cfg}, gorums.WithNodeMap(idMapping))
 	if err != nil {
 		return fmt.Errorf("failed to create configuration: %w", err)
 	}
@@ -146,9 +193,27 @@ func (cfg *Config) Connect(replicaCfg *config.ReplicaConfig) (err error) {
 		replica.node = node
 	}
 
+	if cfg.mods != nil && cfg.mods.Options().ShouldIsolateFetches() {
+		// a separate manager dials separate connections, so fetches get their own gorums streams.
+		// The streams handle their requests in order, so a slow fetch would otherwise hold back the messages behind it.
+		cfg.fetchMgr = hotstuffpb.NewManager(cfg.mgrOpts...)
+		cfg.fetchCfg, err = cfg.fetchMgr.NewConfiguration(qspec{cfg: cfg}, gorums.WithNodeMap(idMapping))
+		if err != nil {
+			return fmt.Errorf("failed to create fetch configuration: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// fetcher returns the configuration that fetch requests are sent with.
+func (cfg *Config) fetcher() *hotstuffpb.Configuration {
+	if cfg.fetchCfg != nil {
+		return cfg.fetchCfg
+	}
+	return cfg.cfg
+}
+
 // Replicas returns all of the replicas in the configuration.
 func (cfg *Config) Replicas() map[hotstuff.ID]consensus.Replica {
 	return cfg.replicas
@@ -193,35 +258,199 @@ func (cfg *Config) Timeout(msg consensus.TimeoutMsg) {
 	cfg.cfg.Timeout(ctx, hotstuffpb.TimeoutMsgToProto(msg), gorums.WithNoSendWaiting())
 }
 
+// Decide sends the decision to all replicas.
+func (cfg *Config) Decide(msg consensus.DecideMsg) {
+	if cfg.cfg == nil {
+		return
+	}
+	var ctx context.Context
+	cfg.decideCancel()
+	ctx, cfg.decideCancel = context.WithCancel(context.Background())
+	cfg.cfg.Decide(ctx, hotstuffpb.QuorumCertToProto(msg.QC), gorums.WithNoSendWaiting())
+}
+
+// Heartbeat sends the heartbeat to all replicas.
+func (cfg *Config) Heartbeat(msg consensus.HeartbeatMsg) {
+	if cfg.cfg == nil {
+		return
+	}
+	var ctx context.Context
+	cfg.beatCancel()
+	ctx, cfg.beatCancel = context.WithCancel(context.Background())
+	hb := &hotstuffpb.HeartbeatMsg{View: uint64(msg.View), HighQCView: uint64(msg.HighQCView)}
+	cfg.cfg.Heartbeat(ctx, hb, gorums.WithNoSendWaiting())
+}
+
+// Relinquish sends the relinquish message to all replicas.
+func (cfg *Config) Relinquish(msg consensus.RelinquishMsg) {
+	if cfg.cfg == nil {
+		return
+	}
+	var ctx context.Context
+	cfg.relinquishCancel()
+	ctx, cfg.relinquishCancel = context.WithCancel(context.Background())
+	cfg.cfg.Relinquish(ctx, hotstuffpb.RelinquishMsgToProto(msg), gorums.WithNoSendWaiting())
+}
+
 // Fetch requests a block from all the replicas in the configuration
-func (cfg *Config) Fetch(ctx context.Context, hash consensus.Hash) (*consensus.Block, bool) {
-	protoBlock, err := cfg.cfg.Fetch(ctx, &hotstuffpb.BlockHash{Hash: hash[:]})
+func (cfg *Config) Fetch(ctx context.Context, hash consensus.Hash) (*consensus.Block, consensus.InclusionProof, bool) {
+	reply, err := cfg.fetcher().Fetch(ctx, &hotstuffpb.BlockHash{Hash: hash[:]})
+	if err != nil {
+		// a cancelled fetch has no reply, so it must not be mistaken for a fetched block.
+		if !errors.Is(err, context.Canceled) {
+			cfg.mods.Logger().Infof("Failed to fetch block: %v", err)
+		}
+		return nil, consensus.InclusionProof{}, false
+	}
+	return hotstuffpb.BlockFromProto(reply.GetBlock()), hotstuffpb.InclusionProofFromProto(reply.GetProof()), true
+}
+
+// FetchBatch requests several blocks from all the replicas in the configuration using a single quorum call.
+// The blocks that were found are returned even if some of the blocks could not be found.
+func (cfg *Config) FetchBatch(ctx context.Context, hashes []consensus.Hash) []consensus.FetchedBlock {
+	in := &hotstuffpb.BlockHash{Hashes: make([][]byte, len(hashes))}
+	for i := range hashes {
+		in.Hashes[i] = hashes[i][:]
+	}
+	// the reply contains the blocks that were found, even if the call was incomplete.
+	reply, err := cfg.fetcher().Fetch(ctx, in)
 	if err != nil && !errors.Is(err, context.Canceled) {
-		cfg.mods.Logger().Infof("Failed to fetch block: %v", err)
+		cfg.mods.Logger().Debugf("Batched fetch was incomplete: %v", err)
+	}
+	fetched := make([]consensus.FetchedBlock, 0, len(reply.GetBlocks()))
+	for _, b := range reply.GetBlocks() {
+		fetched = append(fetched, consensus.FetchedBlock{
+			Block: hotstuffpb.BlockFromProto(b.GetBlock()),
+			Proof: hotstuffpb.InclusionProofFromProto(b.GetProof()),
+		})
+	}
+	return fetched
+}
+
+// FetchPayload requests the command with the given reference from all the replicas in the configuration.
+// Payloads are fetched using the same quorum call as blocks.
+func (cfg *Config) FetchPayload(ctx context.Context, ref consensus.Hash) (consensus.Command, bool) {
+	reply, err := cfg.fetcher().Fetch(ctx, &hotstuffpb.BlockHash{Hash: ref[:]})
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			cfg.mods.Logger().Infof("Failed to fetch payload: %v", err)
+		}
+		return "", false
+	}
+	return consensus.Command(reply.GetPayload()), true
+}
+
+// FetchSnapshot requests a snapshot of the committed blocks from all the replicas in the configuration,
+// and returns the first snapshot that is received.
+func (cfg *Config) FetchSnapshot(ctx context.Context) ([]consensus.FetchedBlock, bool) {
+	reply, err := cfg.fetcher().FetchSnapshot(ctx, &emptypb.Empty{})
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			cfg.mods.Logger().Infof("Failed to fetch snapshot: %v", err)
+		}
 		return nil, false
 	}
-	return hotstuffpb.BlockFromProto(protoBlock), true
+	snapshot := make([]consensus.FetchedBlock, 0, len(reply.GetBlocks()))
+	for _, b := range reply.GetBlocks() {
+		snapshot = append(snapshot, consensus.FetchedBlock{
+			Block: hotstuffpb.BlockFromProto(b.GetBlock()),
+			Proof: hotstuffpb.InclusionProofFromProto(b.GetProof()),
+		})
+	}
+	return snapshot, true
 }
 
 // Close closes all connections made by this configuration.
 func (cfg *Config) Close() {
 	cfg.mgr.Close()
+	if cfg.fetchMgr != nil {
+		cfg.fetchMgr.Close()
+	}
 }
 
-var _ consensus.Configuration = (*Config)(nil)
+var (
+	_ consensus.Configuration   = (*Config)(nil)
+	_ consensus.BatchFetcher    = (*Config)(nil)
+	_ consensus.SnapshotFetcher = (*Config)(nil)
+	_ consensus.Decider         = (*Config)(nil)
+	_ consensus.Heartbeater     = (*Config)(nil)
+	_ consensus.Relinquisher    = (*Config)(nil)
+)
 
-type qspec struct{}
+type qspec struct {
+	cfg *Config
+}
 
 // FetchQF is the quorum function for the Fetch quorum call method.
-// It simply returns true if one of the replies matches the requested block.
-func (q qspec) FetchQF(in *hotstuffpb.BlockHash, replies map[uint32]*hotstuffpb.Block) (*hotstuffpb.Block, bool) {
+// It simply returns true if one of the replies matches the requested block or payload.
+// If fetch proofs are enabled, the reply must also contain a valid inclusion proof for the block.
+// A request for several blocks is handled by fetchBatchQF.
+func (q qspec) FetchQF(in *hotstuffpb.BlockHash, replies map[uint32]*hotstuffpb.FetchedBlock) (*hotstuffpb.FetchedBlock, bool) {
+	if len(in.GetHashes()) > 0 {
+		return q.fetchBatchQF(in, replies)
+	}
 	var h consensus.Hash
 	copy(h[:], in.GetHash())
-	for _, b := range replies {
-		block := hotstuffpb.BlockFromProto(b)
-		if h == block.Hash() {
-			return b, true
+	for _, reply := range replies {
+		if reply.GetBlock() == nil {
+			if len(reply.GetPayload()) > 0 && consensus.PayloadHash(consensus.Command(reply.GetPayload())) == h {
+				return reply, true
+			}
+			continue
+		}
+		block := hotstuffpb.BlockFromProto(reply.GetBlock())
+		if h != block.Hash() {
+			continue
+		}
+		mods := q.cfg.mods
+		if mods.Options().ShouldUseFetchProofs() &&
+			!consensus.VerifyInclusionProof(mods.Crypto(), block, hotstuffpb.InclusionProofFromProto(reply.GetProof())) {
+			continue
+		}
+		return reply, true
+	}
+	return nil, false
+}
+
+// FetchSnapshotQF is the quorum function for the FetchSnapshot quorum call method.
+// It returns the first snapshot that is not empty. The snapshot is validated when it is restored.
+func (q qspec) FetchSnapshotQF(_ *emptypb.Empty, replies map[uint32]*hotstuffpb.Snapshot) (*hotstuffpb.Snapshot, bool) {
+	for _, reply := range replies {
+		if len(reply.GetBlocks()) > 0 {
+			return reply, true
 		}
 	}
 	return nil, false
 }
+
+// fetchBatchQF combines the blocks of all replies into a single reply.
+// It returns true once every requested block has been found. Otherwise, the combined reply is returned
+// when the quorum call completes, such that the caller can request the remaining blocks again.
+func (q qspec) fetchBatchQF(in *hotstuffpb.BlockHash, replies map[uint32]*hotstuffpb.FetchedBlock) (*hotstuffpb.FetchedBlock, bool) {
+	requested := make(map[consensus.Hash]bool, len(in.GetHashes()))
+	for _, b := range in.GetHashes() {
+		var h consensus.Hash
+		copy(h[:], b)
+		requested[h] = true
+	}
+	mods := q.cfg.mods
+	combined := &hotstuffpb.FetchedBlock{}
+	for _, reply := range replies {
+		for _, fetched := range reply.GetBlocks() {
+			if fetched.GetBlock() == nil {
+				continue
+			}
+			block := hotstuffpb.BlockFromProto(fetched.GetBlock())
+			if !requested[block.Hash()] {
+				continue
+			}
+			if mods.Options().ShouldUseFetchProofs() &&
+				!consensus.VerifyInclusionProof(mods.Crypto(), block, hotstuffpb.InclusionProofFromProto(fetched.GetProof())) {
+				continue
+			}
+			delete(requested, block.Hash())
+			combined.Blocks = append(combined.Blocks, fetched)
+		}
+	}
+	return combined, len(requested) == 0
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/relab/gorums"
 	"github.com/relab/hotstuff"
@@ -16,13 +18,20 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 // Server is the server-side of the gorums backend.
 // It is responsible for calling handler methods on the consensus instance.
+// It also tracks which replicas it receives messages from, such that it can report the connectivity of the replica.
 type Server struct {
 	mods      *consensus.Modules
 	gorumsSrv *gorums.Server
+
+	mut      sync.Mutex
+	draining bool
+	inflight sync.WaitGroup            // the requests that are being handled
+	lastSeen map[hotstuff.ID]time.Time // when each replica last sent a message
 }
 
 // InitConsensusModule gives the module a reference to the Modules object.
@@ -33,7 +42,7 @@ func (srv *Server) InitConsensusModule(mods *consensus.Modules, _ *consensus.Opt
 
 // NewServer creates a new Server.
 func NewServer(opts ...gorums.ServerOption) *Server {
-	srv := &Server{}
+	srv := &Server{lastSeen: make(map[hotstuff.ID]time.Time)}
 
 	grpcServerOpts := []grpc.ServerOption{}
 
@@ -106,18 +115,101 @@ func (srv *Server) getClientID(ctx context.Context) (hotstuff.ID, error) {
 	return hotstuff.ID(id), nil
 }
 
-// Stop stops the server.
+// Stop stops the server. Requests that arrive while the server is stopping are rejected as if it was draining,
+// but Stop does not wait for the requests that are being handled, see Drain.
 func (srv *Server) Stop() {
+	srv.mut.Lock()
+	srv.draining = true
+	srv.mut.Unlock()
 	srv.gorumsSrv.Stop()
 }
 
+// Drain makes the server reject new requests from the other replicas, and waits until the requests that are
+// being handled have been delivered to the event loop. Proposals, votes, and other one-way messages that arrive
+// while the server is draining are dropped, and fetch requests fail with codes.Unavailable,
+// such that the sender can fetch the block from another replica instead.
+// Drain must be called before the event loop is stopped, since a request may be waiting for room in the event queue.
+func (srv *Server) Drain() {
+	srv.mut.Lock()
+	srv.draining = true
+	srv.mut.Unlock()
+	srv.inflight.Wait()
+}
+
+// Draining returns true if the server rejects new requests because it is shutting down.
+func (srv *Server) Draining() bool {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	return srv.draining
+}
+
+// enter registers a request that is about to be handled. It returns false if the server is draining,
+// in which case the request must be rejected. Otherwise, the caller must call srv.inflight.Done when the request is handled.
+func (srv *Server) enter(request string) bool {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	if srv.draining {
+		srv.mods.Logger().Debugf("Rejected %s: the replica is shutting down", request)
+		return false
+	}
+	srv.inflight.Add(1)
+	return true
+}
+
+// seen records that a message was received from the given replica.
+func (srv *Server) seen(id hotstuff.ID) {
+	srv.mut.Lock()
+	srv.lastSeen[id] = time.Now()
+	srv.mut.Unlock()
+}
+
+// Reachable returns the number of replicas in the configuration that have sent a message within the QuorumLossTimeout,
+// including the local replica. A replica that is idle only sends messages regularly if the HeartbeatInterval option
+// is shorter than the QuorumLossTimeout, so heartbeats should be enabled along with the QuorumLossTimeout.
+func (srv *Server) Reachable() int {
+	window := srv.mods.Options().QuorumLossTimeout()
+	replicas := srv.mods.Configuration().Replicas()
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	reachable := 1
+	for id, seen := range srv.lastSeen {
+		if _, ok := replicas[id]; ok && id != srv.mods.ID() && time.Since(seen) < window {
+			reachable++
+		}
+	}
+	return reachable
+}
+
 // Propose handles a replica's response to the Propose QC from the leader.
 func (srv *Server) Propose(ctx gorums.ServerCtx, proposal *hotstuffpb.Proposal) {
+	if !srv.enter("proposal") {
+		return
+	}
+	defer srv.inflight.Done()
+
 	id, err := srv.getClientID(ctx)
 	if err != nil {
 		srv.mods.Logger().Infof("Failed to get client ID: %v", err)
 		return
 	}
+	srv.seen(id)
+
+	if proposal.GetBlock() == nil {
+		srv.mods.Logger().Infof("Dropped a proposal without a block from replica %d", id)
+		return
+	}
+	srv.onPropose(id, proposal)
+}
+
+// onPropose delivers a proposal from the given replica to the event loop.
+// If the ShouldFilterProposals option is set, proposals that fail the admission checks are dropped before they are converted.
+func (srv *Server) onPropose(id hotstuff.ID, proposal *hotstuffpb.Proposal) {
+	if srv.mods.Options().ShouldFilterProposals() {
+		if err := srv.admitProposal(id, proposal); err != nil {
+			srv.mods.Logger().Debugf("Rejected proposal from replica %d: %v", id, err)
+			return
+		}
+	}
 
 	proposal.Block.Proposer = uint32(id)
 	proposeMsg := hotstuffpb.ProposalFromProto(proposal)
@@ -126,27 +218,86 @@ func (srv *Server) Propose(ctx gorums.ServerCtx, proposal *hotstuffpb.Proposal)
 	srv.mods.EventLoop().AddEvent(proposeMsg)
 }
 
+// admitProposal checks the raw fields of a proposal from the given replica,
+// and returns an error if the proposal would certainly be rejected by the consensus module.
+// The checks are cheap compared to converting the proposal and verifying its certificates.
+func (srv *Server) admitProposal(id hotstuff.ID, proposal *hotstuffpb.Proposal) error {
+	block := proposal.GetBlock()
+	if block == nil {
+		return fmt.Errorf("proposal has no block")
+	}
+	view := consensus.View(block.GetView())
+	if committed := srv.mods.Consensus().CommittedBlock().View(); view <= committed {
+		return fmt.Errorf("view %d is already decided (committed view %d)", view, committed)
+	}
+	if max := srv.mods.Options().MaxProposalBytes(); max > 0 && len(block.GetCommand()) > max {
+		return fmt.Errorf("command of %d bytes exceeds the limit of %d bytes", len(block.GetCommand()), max)
+	}
+	if leader := srv.mods.LeaderRotation().GetLeader(view); leader != id {
+		return fmt.Errorf("replica %d is not the leader of view %d", id, view)
+	}
+	return nil
+}
+
 // Vote handles an incoming vote message.
 func (srv *Server) Vote(ctx gorums.ServerCtx, cert *hotstuffpb.PartialCert) {
+	if !srv.enter("vote") {
+		return
+	}
+	defer srv.inflight.Done()
+
 	id, err := srv.getClientID(ctx)
 	if err != nil {
 		srv.mods.Logger().Infof("Failed to get client ID: %v", err)
 		return
 	}
+	srv.seen(id)
+
+	if relayed := cert.GetRelayed(); len(relayed) > 0 {
+		msg := consensus.RelayedVotesMsg{ID: id, Votes: make([]consensus.VoteMsg, 0, len(relayed))}
+		for _, c := range relayed {
+			vote := voteFromProto(c)
+			if vote.PartialCert.Signature() == nil {
+				continue
+			}
+			vote.ID = vote.PartialCert.Signature().Signer()
+			msg.Votes = append(msg.Votes, vote)
+		}
+		srv.mods.EventLoop().AddEvent(msg)
+		return
+	}
+
+	vote := voteFromProto(cert)
+	vote.ID = id
+	srv.mods.EventLoop().AddEvent(vote)
+}
 
-	srv.mods.EventLoop().AddEvent(consensus.VoteMsg{
-		ID:          id,
+// voteFromProto converts a partial certificate and the fields that accompany it into a vote.
+func voteFromProto(cert *hotstuffpb.PartialCert) consensus.VoteMsg {
+	vote := consensus.VoteMsg{
 		PartialCert: hotstuffpb.PartialCertFromProto(cert),
-	})
+		Congested:   cert.GetCongested(),
+	}
+	if cert.GetCommitCert() != nil {
+		cc := hotstuffpb.CommitCertFromProto(cert.GetCommitCert())
+		vote.CommitCert = &cc
+	}
+	return vote
 }
 
 // NewView handles the leader's response to receiving a NewView rpc from a replica.
 func (srv *Server) NewView(ctx gorums.ServerCtx, msg *hotstuffpb.SyncInfo) {
+	if !srv.enter("new view message") {
+		return
+	}
+	defer srv.inflight.Done()
+
 	id, err := srv.getClientID(ctx)
 	if err != nil {
 		srv.mods.Logger().Infof("Failed to get client ID: %v", err)
 		return
 	}
+	srv.seen(id)
 
 	srv.mods.EventLoop().AddEvent(consensus.NewViewMsg{
 		ID:       id,
@@ -155,27 +306,159 @@ func (srv *Server) NewView(ctx gorums.ServerCtx, msg *hotstuffpb.SyncInfo) {
 }
 
 // Fetch handles an incoming fetch request.
-func (srv *Server) Fetch(ctx gorums.ServerCtx, pb *hotstuffpb.BlockHash) (*hotstuffpb.Block, error) {
+// The hash may also refer to the payload of a block, in which case the payload is returned instead.
+// A request for several hashes is answered with the blocks that are known, and their inclusion proofs.
+func (srv *Server) Fetch(ctx gorums.ServerCtx, pb *hotstuffpb.BlockHash) (*hotstuffpb.FetchedBlock, error) {
+	if !srv.enter("fetch request") {
+		return nil, status.Errorf(codes.Unavailable, "replica is shutting down")
+	}
+	defer srv.inflight.Done()
+
+	if len(pb.GetHashes()) > 0 {
+		return srv.fetchBatch(pb)
+	}
 	var hash consensus.Hash
 	copy(hash[:], pb.GetHash())
 
 	block, ok := srv.mods.BlockChain().LocalGet(hash)
 	if !ok {
+		if cmd, ok := srv.mods.BlockChain().LocalGetPayload(hash); ok {
+			srv.mods.Logger().Debugf("OnFetch: payload %.8s", hash)
+			return &hotstuffpb.FetchedBlock{Payload: []byte(cmd)}, nil
+		}
 		return nil, status.Errorf(codes.NotFound, "requested block was not found")
 	}
 
 	srv.mods.Logger().Debugf("OnFetch: %.8s", hash)
 
-	return hotstuffpb.BlockToProto(block), nil
+	reply := &hotstuffpb.FetchedBlock{Block: hotstuffpb.BlockToProto(block)}
+	if proof, ok := srv.mods.BlockChain().Proof(hash); ok {
+		reply.Proof = hotstuffpb.InclusionProofToProto(proof)
+	}
+	return reply, nil
+}
+
+func (srv *Server) fetchBatch(pb *hotstuffpb.BlockHash) (*hotstuffpb.FetchedBlock, error) {
+	reply := &hotstuffpb.FetchedBlock{}
+	for _, b := range pb.GetHashes() {
+		var hash consensus.Hash
+		copy(hash[:], b)
+		block, ok := srv.mods.BlockChain().LocalGet(hash)
+		if !ok {
+			continue
+		}
+		fetched := &hotstuffpb.FetchedBlock{Block: hotstuffpb.BlockToProto(block)}
+		if proof, ok := srv.mods.BlockChain().Proof(hash); ok {
+			fetched.Proof = hotstuffpb.InclusionProofToProto(proof)
+		}
+		reply.Blocks = append(reply.Blocks, fetched)
+	}
+	if len(reply.Blocks) == 0 {
+		return nil, status.Errorf(codes.NotFound, "none of the requested blocks were found")
+	}
+	srv.mods.Logger().Debugf("OnFetch: %d of %d blocks", len(reply.Blocks), len(pb.GetHashes()))
+	return reply, nil
+}
+
+// FetchSnapshot handles an incoming snapshot request, and answers it with a snapshot of the committed blocks.
+func (srv *Server) FetchSnapshot(_ gorums.ServerCtx, _ *emptypb.Empty) (*hotstuffpb.Snapshot, error) {
+	if !srv.enter("snapshot request") {
+		return nil, status.Errorf(codes.Unavailable, "replica is shutting down")
+	}
+	defer srv.inflight.Done()
+
+	snapshot, err := srv.mods.BlockChain().Snapshot()
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	reply := &hotstuffpb.Snapshot{Blocks: make([]*hotstuffpb.FetchedBlock, 0, len(snapshot))}
+	for _, fetched := range snapshot {
+		reply.Blocks = append(reply.Blocks, &hotstuffpb.FetchedBlock{
+			Block: hotstuffpb.BlockToProto(fetched.Block),
+			Proof: hotstuffpb.InclusionProofToProto(fetched.Proof),
+		})
+	}
+	srv.mods.Logger().Debugf("OnFetchSnapshot: %d blocks", len(reply.Blocks))
+	return reply, nil
+}
+
+// Decide handles an incoming decision from the leader in the ExplicitDecision commit mode.
+func (srv *Server) Decide(ctx gorums.ServerCtx, qc *hotstuffpb.QuorumCert) {
+	if !srv.enter("decision") {
+		return
+	}
+	defer srv.inflight.Done()
+
+	id, err := srv.getClientID(ctx)
+	if err != nil {
+		srv.mods.Logger().Infof("Failed to get client ID: %v", err)
+		return
+	}
+	srv.seen(id)
+
+	srv.mods.EventLoop().AddEvent(consensus.DecideMsg{ID: id, QC: hotstuffpb.QuorumCertFromProto(qc)})
+}
+
+// Heartbeat handles an incoming heartbeat.
+func (srv *Server) Heartbeat(ctx gorums.ServerCtx, hb *hotstuffpb.HeartbeatMsg) {
+	if !srv.enter("heartbeat") {
+		return
+	}
+	defer srv.inflight.Done()
+
+	id, err := srv.getClientID(ctx)
+	if err != nil {
+		srv.mods.Logger().Infof("Failed to get client ID: %v", err)
+		return
+	}
+	srv.seen(id)
+
+	srv.mods.EventLoop().AddEvent(consensus.HeartbeatMsg{
+		ID:         id,
+		View:       consensus.View(hb.GetView()),
+		HighQCView: consensus.View(hb.GetHighQCView()),
+	})
+}
+
+// Relinquish handles an incoming relinquish message from a leader that steps down.
+func (srv *Server) Relinquish(ctx gorums.ServerCtx, msg *hotstuffpb.RelinquishMsg) {
+	if !srv.enter("relinquish message") {
+		return
+	}
+	defer srv.inflight.Done()
+
+	id, err := srv.getClientID(ctx)
+	if err != nil {
+		srv.mods.Logger().Infof("Failed to get client ID: %v", err)
+		return
+	}
+	srv.seen(id)
+
+	relinquishMsg, ok := hotstuffpb.RelinquishMsgFromProto(msg)
+	if !ok {
+		srv.mods.Logger().Infof("Dropped a relinquish message from replica %d: view %d does not follow its certificates", id, msg.GetView())
+		return
+	}
+	relinquishMsg.ID = id
+	srv.mods.EventLoop().AddEvent(relinquishMsg)
 }
 
 // Timeout handles an incoming TimeoutMsg.
 func (srv *Server) Timeout(ctx gorums.ServerCtx, msg *hotstuffpb.TimeoutMsg) {
+	if !srv.enter("timeout message") {
+		return
+	}
+	defer srv.inflight.Done()
+
 	var err error
 	timeoutMsg := hotstuffpb.TimeoutMsgFromProto(msg)
 	timeoutMsg.ID, err = srv.getClientID(ctx)
 	if err != nil {
 		srv.mods.Logger().Infof("Could not get ID of replica: %v", err)
+	} else {
+		srv.seen(timeoutMsg.ID)
 	}
 	srv.mods.EventLoop().AddEvent(timeoutMsg)
 }
+
+var _ consensus.ConnectivityMonitor = (*Server)(nil)
@@ -0,0 +1,295 @@
+// Package backend implements the Configuration and Replica interfaces on top of a pluggable Transport.
+//
+// The gorums backend in the backend/gorums package is the default networking backend,
+// and implements the Configuration and Replica interfaces directly, along with the same optional interfaces
+// as the Config of this package, such as consensus.Decider and consensus.SnapshotFetcher.
+// To use a different transport, such as QUIC, an in-process channel, or a message queue,
+// implement the Transport interface and register the Config returned by NewConfig with the consensus builder.
+package backend
+
+import (
+	"context"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/config"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// Transport sends protocol messages to the other replicas, and delivers inbound messages to a Receiver.
+// Outbound messages have their ID field set to the ID of the local replica.
+// The transport must set the ID field of inbound messages to the authenticated ID of the sender.
+type Transport interface {
+	// Propose sends the proposal to all other replicas.
+	Propose(msg consensus.ProposeMsg)
+	// Timeout sends the timeout message to all other replicas.
+	Timeout(msg consensus.TimeoutMsg)
+	// Vote sends the vote to the replica with the given ID.
+	Vote(to hotstuff.ID, msg consensus.VoteMsg)
+	// NewView sends the new view message to the replica with the given ID.
+	NewView(to hotstuff.ID, msg consensus.NewViewMsg)
+	// Fetch requests a block from the other replicas.
+	// It returns the first block with the requested hash, along with its inclusion proof, if one was sent.
+	Fetch(ctx context.Context, hash consensus.Hash) (block *consensus.Block, proof consensus.InclusionProof, ok bool)
+	// FetchPayload requests the command with the given reference from the other replicas.
+	FetchPayload(ctx context.Context, ref consensus.Hash) (cmd consensus.Command, ok bool)
+	// FetchBatch requests the blocks with the given hashes from the other replicas, and returns the blocks that were found.
+	FetchBatch(ctx context.Context, hashes []consensus.Hash) []consensus.FetchedBlock
+	// FetchSnapshot requests a snapshot of the committed blocks from the other replicas,
+	// and returns the first snapshot that it receives.
+	FetchSnapshot(ctx context.Context) (snapshot []consensus.FetchedBlock, ok bool)
+	// Decide sends the decision to all other replicas.
+	Decide(msg consensus.DecideMsg)
+	// Heartbeat sends the heartbeat to all other replicas.
+	Heartbeat(msg consensus.HeartbeatMsg)
+	// Relinquish sends the relinquish message to all other replicas.
+	Relinquish(msg consensus.RelinquishMsg)
+	// Receive registers the receiver that handles inbound messages and fetch requests.
+	Receive(receiver Receiver)
+}
+
+// Receiver handles the inbound messages and fetch requests of a Transport.
+type Receiver interface {
+	// Deliver handles an inbound ProposeMsg, VoteMsg, TimeoutMsg, NewViewMsg, DecideMsg, HeartbeatMsg, or RelinquishMsg.
+	Deliver(msg interface{})
+	// HandleFetch returns the requested block and its inclusion proof, if the block is known.
+	HandleFetch(hash consensus.Hash) (block *consensus.Block, proof consensus.InclusionProof, ok bool)
+	// HandleFetchPayload returns the command with the given reference, if the command is known.
+	HandleFetchPayload(ref consensus.Hash) (cmd consensus.Command, ok bool)
+	// HandleFetchBatch returns the requested blocks that are known, along with their inclusion proofs.
+	HandleFetchBatch(hashes []consensus.Hash) []consensus.FetchedBlock
+	// HandleFetchSnapshot returns a snapshot of the committed blocks, if any blocks have been committed.
+	HandleFetchSnapshot() (snapshot []consensus.FetchedBlock, ok bool)
+}
+
+type replica struct {
+	cfg        *Config
+	id         hotstuff.ID
+	pubKey     consensus.PublicKey
+	region     string
+	reputation float64
+}
+
+// ID returns the replica's ID.
+func (r *replica) ID() hotstuff.ID {
+	return r.id
+}
+
+// PublicKey returns the replica's public key.
+func (r *replica) PublicKey() consensus.PublicKey {
+	return r.pubKey
+}
+
+// Region returns the region that the replica is located in.
+func (r *replica) Region() string {
+	return r.region
+}
+
+// Vote sends the partial certificate to the other replica.
+func (r *replica) Vote(cert consensus.PartialCert) {
+	r.cfg.transport.Vote(r.id, consensus.VoteMsg{
+		ID:          r.cfg.mods.ID(),
+		PartialCert: cert,
+		Congested:   r.cfg.mods.Congested(),
+		CommitCert:  r.cfg.mods.CommitAttestation(),
+	})
+}
+
+// NewView sends the quorum certificate to the other replica.
+func (r *replica) NewView(msg consensus.SyncInfo) {
+	r.cfg.transport.NewView(r.id, consensus.NewViewMsg{ID: r.cfg.mods.ID(), SyncInfo: msg})
+}
+
+func (r *replica) UpdateRep(rep float64) {
+	r.reputation += rep
+}
+
+func (r *replica) GetRep() float64 {
+	return r.reputation
+}
+
+// Config implements the Configuration interface using a Transport.
+// It also acts as the Receiver of the transport, posting inbound messages to the event loop
+// and serving fetch requests from the local block chain.
+type Config struct {
+	mods      *consensus.Modules
+	transport Transport
+	replicas  map[hotstuff.ID]consensus.Replica
+}
+
+// NewConfig returns a new configuration that uses the given transport.
+func NewConfig(transport Transport) *Config {
+	return &Config{
+		transport: transport,
+		replicas:  make(map[hotstuff.ID]consensus.Replica),
+	}
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (cfg *Config) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	cfg.mods = mods
+	cfg.transport.Receive(cfg)
+}
+
+// AddReplica adds a replica to the configuration. All replicas must be added before the replica is started.
+func (cfg *Config) AddReplica(info *config.ReplicaInfo) {
+	cfg.replicas[info.ID] = &replica{
+		cfg:        cfg,
+		id:         info.ID,
+		pubKey:     info.PubKey,
+		region:     info.Region,
+		reputation: float64(info.ID),
+	}
+}
+
+// Replicas returns all of the replicas in the configuration.
+func (cfg *Config) Replicas() map[hotstuff.ID]consensus.Replica {
+	return cfg.replicas
+}
+
+// Replica returns a replica if it is present in the configuration.
+func (cfg *Config) Replica(id hotstuff.ID) (replica consensus.Replica, ok bool) {
+	replica, ok = cfg.replicas[id]
+	return
+}
+
+// Len returns the number of replicas in the configuration.
+func (cfg *Config) Len() int {
+	return len(cfg.replicas)
+}
+
+// QuorumSize returns the size of a quorum.
+func (cfg *Config) QuorumSize() int {
+	return hotstuff.QuorumSize(cfg.Len())
+}
+
+// Propose sends the block to all replicas in the configuration.
+func (cfg *Config) Propose(proposal consensus.ProposeMsg) {
+	cfg.transport.Propose(proposal)
+}
+
+// Timeout sends the timeout message to all replicas.
+func (cfg *Config) Timeout(msg consensus.TimeoutMsg) {
+	cfg.transport.Timeout(msg)
+}
+
+// Fetch requests a block from all the replicas in the configuration.
+// If fetch proofs are enabled, the block is only returned along with a valid inclusion proof.
+func (cfg *Config) Fetch(ctx context.Context, hash consensus.Hash) (*consensus.Block, consensus.InclusionProof, bool) {
+	block, proof, ok := cfg.transport.Fetch(ctx, hash)
+	if !ok || block.Hash() != hash {
+		return nil, consensus.InclusionProof{}, false
+	}
+	if cfg.mods.Options().ShouldUseFetchProofs() && !consensus.VerifyInclusionProof(cfg.mods.Crypto(), block, proof) {
+		cfg.mods.Logger().Infof("Fetched block %.8s without a valid inclusion proof", hash)
+		return nil, consensus.InclusionProof{}, false
+	}
+	return block, proof, true
+}
+
+// FetchPayload requests the command with the given reference from all the replicas in the configuration.
+func (cfg *Config) FetchPayload(ctx context.Context, ref consensus.Hash) (consensus.Command, bool) {
+	cmd, ok := cfg.transport.FetchPayload(ctx, ref)
+	if !ok || consensus.PayloadHash(cmd) != ref {
+		return "", false
+	}
+	return cmd, true
+}
+
+// FetchBatch requests several blocks from all the replicas in the configuration.
+// Blocks that were not requested are dropped, and so are blocks without a valid inclusion proof if fetch proofs are enabled.
+func (cfg *Config) FetchBatch(ctx context.Context, hashes []consensus.Hash) []consensus.FetchedBlock {
+	requested := make(map[consensus.Hash]bool, len(hashes))
+	for _, hash := range hashes {
+		requested[hash] = true
+	}
+	var fetched []consensus.FetchedBlock
+	for _, f := range cfg.transport.FetchBatch(ctx, hashes) {
+		if f.Block == nil || !requested[f.Block.Hash()] {
+			continue
+		}
+		if cfg.mods.Options().ShouldUseFetchProofs() && !consensus.VerifyInclusionProof(cfg.mods.Crypto(), f.Block, f.Proof) {
+			cfg.mods.Logger().Infof("Fetched block %.8s without a valid inclusion proof", f.Block.Hash())
+			continue
+		}
+		fetched = append(fetched, f)
+	}
+	return fetched
+}
+
+// FetchSnapshot requests a snapshot of the committed blocks from all the replicas in the configuration.
+// The snapshot is validated when it is restored.
+func (cfg *Config) FetchSnapshot(ctx context.Context) ([]consensus.FetchedBlock, bool) {
+	return cfg.transport.FetchSnapshot(ctx)
+}
+
+// Decide sends the decision to all replicas.
+func (cfg *Config) Decide(msg consensus.DecideMsg) {
+	cfg.transport.Decide(msg)
+}
+
+// Heartbeat sends the heartbeat to all replicas.
+func (cfg *Config) Heartbeat(msg consensus.HeartbeatMsg) {
+	cfg.transport.Heartbeat(msg)
+}
+
+// Relinquish sends the relinquish message to all replicas.
+func (cfg *Config) Relinquish(msg consensus.RelinquishMsg) {
+	cfg.transport.Relinquish(msg)
+}
+
+// Deliver posts an inbound message to the event loop.
+func (cfg *Config) Deliver(msg interface{}) {
+	switch msg.(type) {
+	case consensus.ProposeMsg, consensus.VoteMsg, consensus.TimeoutMsg, consensus.NewViewMsg,
+		consensus.DecideMsg, consensus.HeartbeatMsg, consensus.RelinquishMsg:
+		cfg.mods.EventLoop().AddEvent(msg)
+	default:
+		cfg.mods.Logger().Infof("Deliver: unexpected message type: %T", msg)
+	}
+}
+
+// HandleFetch returns the requested block from the local block chain, along with its inclusion proof if it has one.
+func (cfg *Config) HandleFetch(hash consensus.Hash) (*consensus.Block, consensus.InclusionProof, bool) {
+	block, ok := cfg.mods.BlockChain().LocalGet(hash)
+	if !ok {
+		return nil, consensus.InclusionProof{}, false
+	}
+	proof, _ := cfg.mods.BlockChain().Proof(hash)
+	return block, proof, true
+}
+
+// HandleFetchPayload returns the command with the given reference from the local block chain.
+func (cfg *Config) HandleFetchPayload(ref consensus.Hash) (consensus.Command, bool) {
+	return cfg.mods.BlockChain().LocalGetPayload(ref)
+}
+
+// HandleFetchBatch returns the requested blocks that are found in the local block chain, along with their inclusion proofs.
+func (cfg *Config) HandleFetchBatch(hashes []consensus.Hash) []consensus.FetchedBlock {
+	var fetched []consensus.FetchedBlock
+	for _, hash := range hashes {
+		if block, proof, ok := cfg.HandleFetch(hash); ok {
+			fetched = append(fetched, consensus.FetchedBlock{Block: block, Proof: proof})
+		}
+	}
+	return fetched
+}
+
+// HandleFetchSnapshot returns a snapshot of the committed blocks in the local block chain.
+func (cfg *Config) HandleFetchSnapshot() ([]consensus.FetchedBlock, bool) {
+	snapshot, err := cfg.mods.BlockChain().Snapshot()
+	if err != nil {
+		return nil, false
+	}
+	return snapshot, true
+}
+
+var (
+	_ consensus.Configuration   = (*Config)(nil)
+	_ consensus.BatchFetcher    = (*Config)(nil)
+	_ consensus.SnapshotFetcher = (*Config)(nil)
+	_ consensus.Decider         = (*Config)(nil)
+	_ consensus.Heartbeater     = (*Config)(nil)
+	_ consensus.Relinquisher    = (*Config)(nil)
+	_ Receiver                  = (*Config)(nil)
+)
@@ -0,0 +1,228 @@
+package backend_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/backend"
+	"github.com/relab/hotstuff/config"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/testutil"
+)
+
+// hub connects in-process transports.
+type hub struct {
+	mut       sync.RWMutex
+	receivers map[hotstuff.ID]backend.Receiver
+
+	decisions  int64 // the number of delivered decisions, updated atomically
+	heartbeats int64 // the number of delivered heartbeats, updated atomically
+}
+
+func (h *hub) receiver(id hotstuff.ID) backend.Receiver {
+	h.mut.RLock()
+	defer h.mut.RUnlock()
+	return h.receivers[id]
+}
+
+func (h *hub) others(id hotstuff.ID) (receivers []backend.Receiver) {
+	h.mut.RLock()
+	defer h.mut.RUnlock()
+	for other, receiver := range h.receivers {
+		if other != id {
+			receivers = append(receivers, receiver)
+		}
+	}
+	return receivers
+}
+
+// chanTransport is a trivial Transport that delivers messages to the receivers of other replicas in the same process.
+type chanTransport struct {
+	id  hotstuff.ID
+	hub *hub
+}
+
+func (t *chanTransport) send(to backend.Receiver, msg interface{}) {
+	switch msg.(type) {
+	case consensus.DecideMsg:
+		atomic.AddInt64(&t.hub.decisions, 1)
+	case consensus.HeartbeatMsg:
+		atomic.AddInt64(&t.hub.heartbeats, 1)
+	}
+	// a goroutine is needed because the sender may be running on its own event loop.
+	go to.Deliver(msg)
+}
+
+func (t *chanTransport) Propose(msg consensus.ProposeMsg) {
+	msg.ID = t.id
+	for _, receiver := range t.hub.others(t.id) {
+		t.send(receiver, msg)
+	}
+}
+
+func (t *chanTransport) Timeout(msg consensus.TimeoutMsg) {
+	msg.ID = t.id
+	for _, receiver := range t.hub.others(t.id) {
+		t.send(receiver, msg)
+	}
+}
+
+func (t *chanTransport) Vote(to hotstuff.ID, msg consensus.VoteMsg) {
+	msg.ID = t.id
+	if receiver := t.hub.receiver(to); receiver != nil {
+		t.send(receiver, msg)
+	}
+}
+
+func (t *chanTransport) NewView(to hotstuff.ID, msg consensus.NewViewMsg) {
+	msg.ID = t.id
+	if receiver := t.hub.receiver(to); receiver != nil {
+		t.send(receiver, msg)
+	}
+}
+
+func (t *chanTransport) Fetch(_ context.Context, hash consensus.Hash) (*consensus.Block, consensus.InclusionProof, bool) {
+	for _, receiver := range t.hub.others(t.id) {
+		if block, proof, ok := receiver.HandleFetch(hash); ok {
+			return block, proof, true
+		}
+	}
+	return nil, consensus.InclusionProof{}, false
+}
+
+func (t *chanTransport) FetchPayload(_ context.Context, ref consensus.Hash) (consensus.Command, bool) {
+	for _, receiver := range t.hub.others(t.id) {
+		if cmd, ok := receiver.HandleFetchPayload(ref); ok {
+			return cmd, true
+		}
+	}
+	return "", false
+}
+
+func (t *chanTransport) FetchBatch(_ context.Context, hashes []consensus.Hash) []consensus.FetchedBlock {
+	for _, receiver := range t.hub.others(t.id) {
+		if fetched := receiver.HandleFetchBatch(hashes); len(fetched) == len(hashes) {
+			return fetched
+		}
+	}
+	return nil
+}
+
+func (t *chanTransport) FetchSnapshot(_ context.Context) ([]consensus.FetchedBlock, bool) {
+	for _, receiver := range t.hub.others(t.id) {
+		if snapshot, ok := receiver.HandleFetchSnapshot(); ok {
+			return snapshot, true
+		}
+	}
+	return nil, false
+}
+
+func (t *chanTransport) Decide(msg consensus.DecideMsg) {
+	msg.ID = t.id
+	for _, receiver := range t.hub.others(t.id) {
+		t.send(receiver, msg)
+	}
+}
+
+func (t *chanTransport) Heartbeat(msg consensus.HeartbeatMsg) {
+	msg.ID = t.id
+	for _, receiver := range t.hub.others(t.id) {
+		t.send(receiver, msg)
+	}
+}
+
+func (t *chanTransport) Relinquish(msg consensus.RelinquishMsg) {
+	msg.ID = t.id
+	for _, receiver := range t.hub.others(t.id) {
+		t.send(receiver, msg)
+	}
+}
+
+func (t *chanTransport) Receive(receiver backend.Receiver) {
+	t.hub.mut.Lock()
+	defer t.hub.mut.Unlock()
+	t.hub.receivers[t.id] = receiver
+}
+
+// TestTransport runs the protocol end-to-end over an in-process transport,
+// both in the default commit mode, and in the ExplicitDecision commit mode with heartbeats.
+func TestTransport(t *testing.T) {
+	t.Run("Default", func(t *testing.T) { testTransport(t, false) })
+	t.Run("ExplicitDecision", func(t *testing.T) { testTransport(t, true) })
+}
+
+func testTransport(t *testing.T, explicit bool) {
+	const n = 4
+	network, builders := testutil.CreateNetwork(t, n)
+	h := &hub{receivers: make(map[hotstuff.ID]backend.Receiver)}
+	configs := make([]*backend.Config, n)
+	for i, builder := range builders {
+		configs[i] = backend.NewConfig(&chanTransport{id: hotstuff.ID(i + 1), hub: h})
+		builder.Register(configs[i]) // replaces the in-memory network's configuration
+		if explicit {
+			builder.Options().SetCommitMode(consensus.ExplicitDecision)
+			builder.Options().SetHeartbeatInterval(10 * time.Millisecond)
+		}
+	}
+	hl := builders.Build()
+	for _, cfg := range configs {
+		for _, mods := range hl {
+			cfg.AddReplica(&config.ReplicaInfo{ID: mods.ID(), PubKey: mods.PrivateKey().Public()})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			done := true
+			for _, node := range network.Nodes() {
+				if len(node.Executed()) < 10 {
+					done = false
+				}
+			}
+			if done {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	reference := network.Node(1).Executed()
+	for _, node := range network.Nodes() {
+		executed := node.Executed()
+		if len(executed) < 10 {
+			t.Fatalf("replica %d executed %d blocks, expected at least 10", node.ID(), len(executed))
+		}
+		for i := 0; i < 10; i++ {
+			if executed[i].Hash() != reference[i].Hash() {
+				t.Errorf("replica %d executed a different block at height %d", node.ID(), i)
+			}
+		}
+	}
+
+	if explicit && (atomic.LoadInt64(&h.decisions) == 0 || atomic.LoadInt64(&h.heartbeats) == 0) {
+		t.Errorf("expected decisions and heartbeats to be sent over the transport, got %d decisions and %d heartbeats",
+			atomic.LoadInt64(&h.decisions), atomic.LoadInt64(&h.heartbeats))
+	}
+
+	// the blocks and the snapshot are served by the receivers of the other replicas.
+	hashes := make([]consensus.Hash, 10)
+	for i := range hashes {
+		hashes[i] = reference[i].Hash()
+	}
+	fetchCtx, fetchCancel := context.WithTimeout(context.Background(), time.Second)
+	defer fetchCancel()
+	if fetched := configs[0].FetchBatch(fetchCtx, hashes); len(fetched) != len(hashes) {
+		t.Errorf("FetchBatch returned %d blocks, want %d", len(fetched), len(hashes))
+	}
+	if snapshot, ok := configs[0].FetchSnapshot(fetchCtx); !ok || len(snapshot) == 0 {
+		t.Error("FetchSnapshot did not return a snapshot")
+	}
+}
@@ -3,6 +3,7 @@ package blockchain
 
 import (
 	"context"
+	"sort"
 	"sync"
 
 	"github.com/relab/hotstuff/consensus"
@@ -16,7 +17,11 @@ type blockChain struct {
 	pruneHeight   consensus.View
 	blocks        map[consensus.Hash]*consensus.Block
 	blockAtHeight map[consensus.View]*consensus.Block
+	proofs        map[consensus.Hash]consensus.InclusionProof
+	payloads      map[consensus.Hash]consensus.Command  // commands that blocks refer to instead of including them
 	pendingFetch  map[consensus.Hash]context.CancelFunc // allows a pending fetch operation to be cancelled
+	maxBlocks     int                                   // the maximum number of blocks to retain, or 0 for no limit
+	prunedBelow   consensus.View                        // the blocks below this view have been pruned
 }
 
 // InitConsensusModule gives the module a reference to the Modules object.
@@ -31,12 +36,25 @@ func New() consensus.BlockChain {
 	bc := &blockChain{
 		blocks:        make(map[consensus.Hash]*consensus.Block),
 		blockAtHeight: make(map[consensus.View]*consensus.Block),
+		proofs:        make(map[consensus.Hash]consensus.InclusionProof),
+		payloads:      make(map[consensus.Hash]consensus.Command),
 		pendingFetch:  make(map[consensus.Hash]context.CancelFunc),
 	}
 	bc.Store(consensus.GetGenesis())
 	return bc
 }
 
+// NewBounded creates a new blockChain that retains at most maxBlocks blocks.
+// When the limit is exceeded, the oldest blocks below the committed height are evicted.
+// Blocks at or above the committed height are never evicted, as they may still be needed by the consensus rules,
+// so the limit may be exceeded temporarily if there are many uncommitted blocks.
+// Get returns not-found for evicted blocks. A maxBlocks of 0 means that there is no limit.
+func NewBounded(maxBlocks int) consensus.BlockChain {
+	bc := New().(*blockChain)
+	bc.maxBlocks = maxBlocks
+	return bc
+}
+
 // Store stores a block in the blockchain
 func (chain *blockChain) Store(block *consensus.Block) {
 	chain.mut.Lock()
@@ -44,11 +62,27 @@ func (chain *blockChain) Store(block *consensus.Block) {
 
 	chain.blocks[block.Hash()] = block
 	chain.blockAtHeight[block.View()] = block
+	// the block's QC certifies its parent
+	if block.View() > 0 {
+		chain.proofs[block.QuorumCert().BlockHash()] = consensus.NewQCProof(block.QuorumCert())
+	}
 
 	// cancel any pending fetch operations
 	if cancel, ok := chain.pendingFetch[block.Hash()]; ok {
 		cancel()
 	}
+
+	chain.evict()
+	chain.release(block.Hash())
+}
+
+// release releases the messages that are waiting for the block with the given hash.
+func (chain *blockChain) release(hash consensus.Hash) {
+	// the genesis block is stored before the module is initialized.
+	if chain.mods == nil {
+		return
+	}
+	chain.mods.WaitingRoom().Release(hash)
 }
 
 // Get retrieves a block given its hash. It will only try the local cache.
@@ -71,6 +105,7 @@ func (chain *blockChain) Get(hash consensus.Hash) (block *consensus.Block, ok bo
 	var (
 		ctx    context.Context
 		cancel context.CancelFunc
+		proof  consensus.InclusionProof
 	)
 
 	chain.mut.Lock()
@@ -83,8 +118,12 @@ func (chain *blockChain) Get(hash consensus.Hash) (block *consensus.Block, ok bo
 	chain.pendingFetch[hash] = cancel
 
 	chain.mut.Unlock()
-	chain.mods.Logger().Debugf("Attempting to fetch block: %.8s", hash)
-	block, ok = chain.mods.Configuration().Fetch(ctx, hash)
+	block, proof, ok = chain.fetch(ctx, hash)
+	cancel()
+	if ok && chain.mods.Options().ShouldUseFetchProofs() && !consensus.VerifyInclusionProof(chain.mods.Crypto(), block, proof) {
+		chain.mods.Logger().Infof("Fetched block %.8s does not have a valid inclusion proof", hash)
+		block, ok = nil, false
+	}
 	chain.mut.Lock()
 
 	delete(chain.pendingFetch, hash)
@@ -96,8 +135,19 @@ func (chain *blockChain) Get(hash consensus.Hash) (block *consensus.Block, ok bo
 
 	chain.mods.Logger().Debugf("Successfully fetched block: %.8s", hash)
 
+	if chain.evicted(block) {
+		// the block is below the eviction frontier, so we should not store it again
+		block, ok = nil, false
+		goto done
+	}
+
 	chain.blocks[hash] = block
 	chain.blockAtHeight[block.View()] = block
+	if !proof.IsEmpty() {
+		chain.storeProof(hash, proof)
+	}
+	chain.evict()
+	chain.release(hash)
 
 done:
 	defer chain.mut.Unlock()
@@ -109,6 +159,180 @@ done:
 	return block, true
 }
 
+// fetch requests the block with the given hash from the other replicas until the context is cancelled.
+// If the FetchTimeout option is set, each request is abandoned after the timeout,
+// and the block is requested again up to FetchRetries times.
+func (chain *blockChain) fetch(ctx context.Context, hash consensus.Hash) (block *consensus.Block, proof consensus.InclusionProof, ok bool) {
+	timeout := chain.mods.Options().FetchTimeout()
+	for attempt := 0; ; attempt++ {
+		chain.mods.Logger().Debugf("Attempting to fetch block: %.8s", hash)
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		block, proof, ok = chain.mods.Configuration().Fetch(attemptCtx, hash)
+		cancel()
+		if ok && block.Hash() != hash {
+			chain.mods.Logger().Infof("Fetched block %.8s does not match the requested block %.8s", block.Hash(), hash)
+			ok = false
+		}
+		if ok || timeout == 0 || ctx.Err() != nil {
+			return block, proof, ok
+		}
+		if attempt >= chain.mods.Options().FetchRetries() {
+			chain.mods.Logger().Infof("Giving up on fetching block %.8s after %d attempts", hash, attempt+1)
+			return nil, consensus.InclusionProof{}, false
+		}
+	}
+}
+
+// GetBatch retrieves the blocks with the given hashes. The blocks that are not available locally
+// are fetched together if the configuration implements consensus.BatchFetcher, and one by one otherwise.
+// Hashes that are missing from the response are requested again for as long as each request makes progress.
+func (chain *blockChain) GetBatch(hashes []consensus.Hash) map[consensus.Hash]*consensus.Block {
+	found := make(map[consensus.Hash]*consensus.Block, len(hashes))
+	missing := make(map[consensus.Hash]bool)
+
+	chain.mut.Lock()
+	for _, hash := range hashes {
+		if block, ok := chain.blocks[hash]; ok {
+			found[hash] = block
+		} else {
+			missing[hash] = true
+		}
+	}
+	chain.mut.Unlock()
+
+	fetcher, ok := chain.mods.Configuration().(consensus.BatchFetcher)
+	if !ok {
+		for hash := range missing {
+			if block, ok := chain.Get(hash); ok {
+				found[hash] = block
+			}
+		}
+		return found
+	}
+
+	ctx := chain.mods.Synchronizer().ViewContext()
+	for len(missing) > 0 && ctx.Err() == nil {
+		request := make([]consensus.Hash, 0, len(missing))
+		for hash := range missing {
+			request = append(request, hash)
+		}
+		chain.mods.Logger().Debugf("Attempting to fetch %d blocks", len(request))
+		fetched := fetcher.FetchBatch(ctx, request)
+
+		progress := false
+		chain.mut.Lock()
+		for _, f := range fetched {
+			if f.Block == nil {
+				continue
+			}
+			hash := f.Block.Hash()
+			if !missing[hash] {
+				continue
+			}
+			if chain.mods.Options().ShouldUseFetchProofs() && !consensus.VerifyInclusionProof(chain.mods.Crypto(), f.Block, f.Proof) {
+				chain.mods.Logger().Infof("Fetched block %.8s does not have a valid inclusion proof", hash)
+				continue
+			}
+			progress = true
+			delete(missing, hash)
+			if chain.evicted(f.Block) {
+				continue
+			}
+			chain.blocks[hash] = f.Block
+			chain.blockAtHeight[f.Block.View()] = f.Block
+			if !f.Proof.IsEmpty() {
+				chain.storeProof(hash, f.Proof)
+			}
+			found[hash] = f.Block
+			chain.release(hash)
+		}
+		// check again in case some of the blocks arrived while we were fetching
+		for hash := range missing {
+			if block, ok := chain.blocks[hash]; ok {
+				found[hash] = block
+				delete(missing, hash)
+			}
+		}
+		chain.evict()
+		chain.mut.Unlock()
+
+		if !progress {
+			break
+		}
+	}
+	if len(missing) > 0 {
+		chain.mods.Logger().Debugf("Could not fetch %d blocks", len(missing))
+	}
+	return found
+}
+
+// StoreProof stores an inclusion proof for the block with the given hash.
+// A proof containing a QC is never replaced by a proof containing only the proposer's signature.
+func (chain *blockChain) StoreProof(hash consensus.Hash, proof consensus.InclusionProof) {
+	chain.mut.Lock()
+	defer chain.mut.Unlock()
+	chain.storeProof(hash, proof)
+}
+
+// storeProof stores an inclusion proof. chain.mut must be held when calling storeProof.
+func (chain *blockChain) storeProof(hash consensus.Hash, proof consensus.InclusionProof) {
+	if old, ok := chain.proofs[hash]; ok {
+		if _, ok := old.QC(); ok {
+			return
+		}
+	}
+	chain.proofs[hash] = proof
+}
+
+// Proof returns the inclusion proof for the block with the given hash, if one is known.
+func (chain *blockChain) Proof(hash consensus.Hash) (proof consensus.InclusionProof, ok bool) {
+	chain.mut.Lock()
+	defer chain.mut.Unlock()
+	proof, ok = chain.proofs[hash]
+	return proof, ok
+}
+
+// StorePayload stores a command that blocks may refer to, and returns its reference.
+func (chain *blockChain) StorePayload(cmd consensus.Command) consensus.Hash {
+	chain.mut.Lock()
+	defer chain.mut.Unlock()
+	ref := consensus.PayloadHash(cmd)
+	chain.payloads[ref] = cmd
+	return ref
+}
+
+// LocalGetPayload retrieves the command with the given reference. It will only try the local cache.
+func (chain *blockChain) LocalGetPayload(ref consensus.Hash) (consensus.Command, bool) {
+	chain.mut.Lock()
+	defer chain.mut.Unlock()
+	cmd, ok := chain.payloads[ref]
+	return cmd, ok
+}
+
+// GetPayload retrieves the command with the given reference. GetPayload will try to find the command locally.
+// If it is not available locally, it will try to fetch the command.
+func (chain *blockChain) GetPayload(ref consensus.Hash) (consensus.Command, bool) {
+	if cmd, ok := chain.LocalGetPayload(ref); ok {
+		return cmd, true
+	}
+
+	chain.mods.Logger().Debugf("Attempting to fetch payload: %.8s", ref)
+	cmd, ok := chain.mods.Configuration().FetchPayload(chain.mods.Synchronizer().ViewContext(), ref)
+	if !ok {
+		return "", false
+	}
+	if consensus.PayloadHash(cmd) != ref {
+		chain.mods.Logger().Infof("Fetched payload does not match its reference %.8s", ref)
+		return "", false
+	}
+	chain.mods.Logger().Debugf("Successfully fetched payload: %.8s", ref)
+	chain.StorePayload(cmd)
+	return cmd, true
+}
+
 // Extends checks if the given block extends the branch of the target block.
 func (chain *blockChain) Extends(block, target *consensus.Block) bool {
 	current := block
@@ -150,7 +374,71 @@ func (chain *blockChain) PruneToHeight(height consensus.View) (forkedBlocks []*c
 		delete(chain.blockAtHeight, h)
 	}
 	chain.pruneHeight = height
+	chain.evict()
 	return forkedBlocks
 }
 
+// Prune removes the blocks below the given view, along with their proofs and payloads.
+// Blocks below the view that are fetched later are not stored again.
+func (chain *blockChain) Prune(belowView consensus.View) {
+	chain.mut.Lock()
+	defer chain.mut.Unlock()
+
+	if belowView <= chain.prunedBelow {
+		return
+	}
+	chain.prunedBelow = belowView
+	for hash, block := range chain.blocks {
+		if block.View() > 0 && block.View() < belowView {
+			chain.remove(hash, block)
+		}
+	}
+}
+
+// evicted returns true if the block has been pruned, or would have been evicted from a bounded blockchain.
+// chain.mut must be held when calling evicted.
+func (chain *blockChain) evicted(block *consensus.Block) bool {
+	if block.View() == 0 {
+		return false
+	}
+	return block.View() < chain.prunedBelow || (chain.maxBlocks > 0 && block.View() < chain.pruneHeight)
+}
+
+// remove removes a block, its proof, and its payload. chain.mut must be held when calling remove.
+func (chain *blockChain) remove(hash consensus.Hash, block *consensus.Block) {
+	delete(chain.blocks, hash)
+	delete(chain.proofs, hash)
+	if ref, ok := block.PayloadRef(); ok {
+		delete(chain.payloads, ref)
+	}
+	if b, ok := chain.blockAtHeight[block.View()]; ok && b.Hash() == hash {
+		delete(chain.blockAtHeight, block.View())
+	}
+}
+
+// evict removes the oldest blocks below the prune height until the number of blocks is within the limit.
+// The genesis block is never evicted. chain.mut must be held when calling evict.
+func (chain *blockChain) evict() {
+	if chain.maxBlocks <= 0 || len(chain.blocks) <= chain.maxBlocks {
+		return
+	}
+
+	candidates := make([]*consensus.Block, 0, len(chain.blocks)-chain.maxBlocks)
+	for _, block := range chain.blocks {
+		if chain.evicted(block) {
+			candidates = append(candidates, block)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].View() < candidates[j].View()
+	})
+
+	for _, block := range candidates {
+		if len(chain.blocks) <= chain.maxBlocks {
+			break
+		}
+		chain.remove(block.Hash(), block)
+	}
+}
+
 var _ consensus.BlockChain = (*blockChain)(nil)
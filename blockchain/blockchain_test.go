@@ -0,0 +1,360 @@
+package blockchain_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/blockchain"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/mocks"
+	"github.com/relab/hotstuff/internal/testutil"
+)
+
+func TestBoundedBlockChain(t *testing.T) {
+	const (
+		maxBlocks = 10
+		minCommit = 10 * maxBlocks
+	)
+
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Register(blockchain.NewBounded(maxBlocks))
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	node := network.Node(1)
+	chain := node.Modules().BlockChain().(interface{ Blocks() []*consensus.Block })
+	// the stored blocks are counted on the event loop between commits, such that the committed block is the prune height.
+	// the blocks at or above the committed block are never evicted, nor is the genesis block,
+	// so they are allowed in addition to maxBlocks.
+	var samples int32
+	count := func() {
+		committed := node.Modules().Consensus().CommittedBlock().View()
+		blocks := chain.Blocks()
+		slack := 1
+		for _, block := range blocks {
+			if block.View() >= committed {
+				slack++
+			}
+		}
+		if len(blocks) > maxBlocks+slack {
+			t.Errorf("%d blocks stored at committed view %d, want at most %d", len(blocks), committed, maxBlocks+slack)
+		}
+		atomic.AddInt32(&samples, 1)
+	}
+	go func() {
+		for ctx.Err() == nil {
+			if len(node.Executed()) >= minCommit {
+				cancel()
+				return
+			}
+			node.Modules().EventLoop().AddEvent(count)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	executed := node.Executed()
+	if len(executed) < minCommit {
+		t.Fatalf("expected at least %d committed blocks, got %d", minCommit, len(executed))
+	}
+	if atomic.LoadInt32(&samples) == 0 {
+		t.Error("expected the stored blocks to be counted during the run")
+	}
+
+	retained := 0
+	for _, block := range executed {
+		if _, ok := node.Modules().BlockChain().LocalGet(block.Hash()); ok {
+			retained++
+		}
+	}
+	if retained > maxBlocks {
+		t.Errorf("expected at most %d committed blocks to be retained, got %d", maxBlocks, retained)
+	}
+
+	// the oldest committed block should have been evicted
+	if _, ok := node.Modules().BlockChain().Get(executed[0].Hash()); ok {
+		t.Error("expected Get to return not-found for an evicted block")
+	}
+
+	// the latest committed block must not be evicted
+	last := executed[len(executed)-1]
+	if _, ok := node.Modules().BlockChain().LocalGet(last.Hash()); !ok {
+		t.Error("expected the latest committed block to be retained")
+	}
+}
+
+func TestRetentionWindow(t *testing.T) {
+	const (
+		window    = 10
+		minCommit = 10 * window
+	)
+
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetRetentionWindow(window)
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	node := network.Node(1)
+	go func() {
+		for ctx.Err() == nil {
+			if len(node.Executed()) >= minCommit {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	executed := node.Executed()
+	if len(executed) < minCommit {
+		t.Fatalf("expected at least %d committed blocks, got %d", minCommit, len(executed))
+	}
+
+	committed := node.Modules().Consensus().CommittedBlock().View()
+	retained := 0
+	for _, block := range executed {
+		_, ok := node.Modules().BlockChain().LocalGet(block.Hash())
+		if ok {
+			retained++
+		}
+		if pruned := block.View()+window < committed; ok == pruned {
+			t.Errorf("block in view %d: retained %v, want %v (committed view %d)", block.View(), ok, !pruned, committed)
+		}
+	}
+	if retained > window+1 {
+		t.Errorf("expected at most %d committed blocks to be retained, got %d", window+1, retained)
+	}
+
+	// pruned blocks are not stored again when they are fetched.
+	if _, ok := node.Modules().BlockChain().Get(executed[0].Hash()); ok {
+		t.Error("expected Get to return not-found for a pruned block")
+	}
+}
+
+// TestSnapshot checks that a replica that did not take part in the protocol can restore the committed chain
+// from a snapshot of another replica, and agrees with that replica on the blocks that it restores.
+func TestSnapshot(t *testing.T) {
+	const minCommit = 20
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	source := network.Node(1)
+	go func() {
+		for ctx.Err() == nil {
+			if len(source.Executed()) >= minCommit {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	// replica 4 is left behind.
+	network.Run(ctx, 1, 2, 3)
+
+	executed := source.Executed()
+	if len(executed) < minCommit {
+		t.Fatalf("expected at least %d committed blocks, got %d", minCommit, len(executed))
+	}
+	snapshot, err := source.Modules().BlockChain().Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := network.Node(4)
+	// the restored blocks are committed on the event loop of the target replica.
+	restoreCtx, done := context.WithCancel(context.Background())
+	var restoreErr error
+	go func() {
+		restoreErr = target.Modules().BlockChain().Restore(context.Background(), snapshot)
+		done()
+	}()
+	target.Modules().EventLoop().Run(restoreCtx)
+	if restoreErr != nil {
+		t.Fatal(restoreErr)
+	}
+
+	// the newest blocks of the snapshot are not restored, since no QCs that prove them committed are included.
+	restored := target.Executed()
+	if len(restored) == 0 || len(restored) > len(executed) {
+		t.Fatalf("the restored replica executed %d blocks, want at most %d", len(restored), len(executed))
+	}
+	for i := range restored {
+		if restored[i].Hash() != executed[i].Hash() {
+			t.Fatalf("block %d: the restored replica executed %v, want %v", i, restored[i], executed[i])
+		}
+	}
+	if got, want := target.Modules().Consensus().CommittedBlock(), restored[len(restored)-1]; got.Hash() != want.Hash() {
+		t.Errorf("the restored replica committed block %v, want %v", got, want)
+	}
+
+	reversed := make([]consensus.FetchedBlock, 0, len(snapshot))
+	for i := len(snapshot) - 1; i >= 0; i-- {
+		reversed = append(reversed, snapshot[i])
+	}
+	if err := target.Modules().BlockChain().Restore(context.Background(), reversed); err == nil {
+		t.Error("expected a snapshot with blocks out of order to be rejected")
+	}
+}
+
+func TestDOTHandler(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	hs := network.Node(1).Modules()
+	signers := hl.Signers()
+
+	// genesis <- b1 <- b2 <- b3
+	//             ^--- fork
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 1)
+	qc1 := testutil.CreateQC(t, b1, signers)
+	b2 := consensus.NewBlock(b1.Hash(), qc1, "b2", 2, 2)
+	fork := consensus.NewBlock(b1.Hash(), qc1, "fork", 3, 3)
+	b3 := consensus.NewBlock(b2.Hash(), testutil.CreateQC(t, b2, signers), "b3", 4, 4)
+	for _, block := range []*consensus.Block{b1, b2, fork, b3} {
+		hs.BlockChain().Store(block)
+	}
+	if err := testutil.ForceCommit(hs, qc1); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hs.Run(ctx)
+
+	rec := httptest.NewRecorder()
+	blockchain.NewDOTHandler(hs).ServeHTTP(rec, httptest.NewRequest("GET", "/debug/chain", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body.String())
+	}
+	dot := rec.Body.String()
+
+	id := func(block *consensus.Block) string {
+		h := block.Hash()
+		return fmt.Sprintf("\"%x\"", h[:8])
+	}
+	want := []string{
+		"digraph BlockChain {",
+		// nodes
+		id(genesis) + " [label=", id(b1) + " [label=", id(b2) + " [label=", id(fork) + " [label=", id(b3) + " [label=",
+		// parent edges, including the fork
+		id(b1) + " -> " + id(genesis) + ";",
+		id(b2) + " -> " + id(b1) + ";",
+		id(fork) + " -> " + id(b1) + ";",
+		id(b3) + " -> " + id(b2) + ";",
+		// QC edge
+		id(b3) + " -> " + id(b2) + " [style=dashed];",
+		// commit pointers
+		"bExec -> " + id(b1) + " [color=red];",
+		"bLock -> " + id(b1) + " [color=red];",
+		"bLeaf -> " + id(b1) + " [color=red];",
+	}
+	for _, s := range want {
+		if !strings.Contains(dot, s) {
+			t.Errorf("DOT output does not contain %q:\n%s", s, dot)
+		}
+	}
+}
+
+func TestFetchInclusionProof(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetShouldUseFetchProofs()
+	}
+	hl := builders.Build()
+	signers := hl.Signers()
+	requester := network.Node(1).Modules()
+
+	genesis := consensus.GetGenesis()
+	proposerSig := func(block *consensus.Block, id hotstuff.ID) consensus.Signature {
+		sig, err := hl[id-1].Crypto().Sign(block.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+
+	// b1 is certified by the QC in b2, and b2 has not been certified yet.
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 2)
+	b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "b2", 2, 3)
+	network.Node(2).Modules().BlockChain().Store(b1)
+	network.Node(2).Modules().BlockChain().Store(b2)
+	network.Node(2).Modules().BlockChain().StoreProof(b2.Hash(), consensus.NewProposerProof(proposerSig(b2, 3)))
+
+	// b3 is served without a proof, and b4 is signed by a replica that did not propose it.
+	b3 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b3", 3, 4)
+	b4 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b4", 4, 4)
+	network.Node(3).Modules().BlockChain().Store(b3)
+	network.Node(3).Modules().BlockChain().Store(b4)
+	network.Node(3).Modules().BlockChain().StoreProof(b4.Hash(), consensus.NewProposerProof(proposerSig(b4, 3)))
+
+	if _, ok := requester.BlockChain().Get(b1.Hash()); !ok {
+		t.Error("failed to fetch certified block")
+	} else if proof, ok := requester.BlockChain().Proof(b1.Hash()); !ok {
+		t.Error("expected the QC of the certified block to be stored")
+	} else if qc, ok := proof.QC(); !ok || qc.BlockHash() != b1.Hash() {
+		t.Errorf("expected the proof to contain the QC of the fetched block, got %v", qc)
+	}
+
+	if _, ok := requester.BlockChain().Get(b2.Hash()); !ok {
+		t.Error("failed to fetch uncertified block with a valid proposer signature")
+	} else if proof, ok := requester.BlockChain().Proof(b2.Hash()); !ok || proof.ProposerSignature() == nil {
+		t.Error("expected the proposer signature of the uncertified block to be stored")
+	}
+
+	if _, ok := requester.BlockChain().Get(b3.Hash()); ok {
+		t.Error("expected a block without an inclusion proof to be rejected")
+	}
+	if _, ok := requester.BlockChain().Get(b4.Hash()); ok {
+		t.Error("expected a block with an invalid proposer signature to be rejected")
+	}
+}
+
+// TestFetchTimeout checks that a fetch from replicas that never deliver the block is repeated FetchRetries times,
+// and then gives up, even though the view does not end.
+func TestFetchTimeout(t *testing.T) {
+	const (
+		timeout = 20 * time.Millisecond
+		retries = 2
+	)
+	ctrl := gomock.NewController(t)
+	builder := testutil.TestModules(t, ctrl, 1, testutil.GenerateECDSAKey(t))
+	builder.Options().SetFetchTimeout(timeout, retries)
+
+	genesis := consensus.GetGenesis()
+	missing := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "missing", 1, 2)
+	cfg := mocks.NewMockConfiguration(ctrl)
+	cfg.EXPECT().Fetch(gomock.Any(), missing.Hash()).Times(retries + 1).DoAndReturn(
+		func(ctx context.Context, _ consensus.Hash) (*consensus.Block, consensus.InclusionProof, bool) {
+			<-ctx.Done()
+			return nil, consensus.InclusionProof{}, false
+		})
+	builder.Register(cfg)
+	mods := builder.Build()
+
+	start := time.Now()
+	if _, ok := mods.BlockChain().Get(missing.Hash()); ok {
+		t.Fatal("expected the fetch to fail")
+	}
+	if elapsed, want := time.Since(start), (retries+1)*timeout; elapsed < want || elapsed > 10*want {
+		t.Errorf("the fetch gave up after %v, want about %v", elapsed, want)
+	}
+}
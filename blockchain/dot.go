@@ -0,0 +1,115 @@
+package blockchain
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// Blocks returns all blocks that are stored in the chain, ordered by view.
+func (chain *blockChain) Blocks() []*consensus.Block {
+	chain.mut.Lock()
+	defer chain.mut.Unlock()
+
+	blocks := make([]*consensus.Block, 0, len(chain.blocks))
+	for _, block := range chain.blocks {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		if blocks[i].View() == blocks[j].View() {
+			a, b := blocks[i].Hash(), blocks[j].Hash()
+			return bytes.Compare(a[:], b[:]) < 0
+		}
+		return blocks[i].View() < blocks[j].View()
+	})
+	return blocks
+}
+
+// WriteDOT writes the blocks that are stored in the replica's block chain as a Graphviz DOT graph.
+// Solid edges point to the parent block, and dashed edges point to the block referenced by the block's QC.
+// The locked block (bLock), the committed block (bExec), and the leaf block (bLeaf) are highlighted.
+// WriteDOT must be called from the event loop.
+func WriteDOT(w io.Writer, mods *consensus.Modules) error {
+	chain, ok := mods.BlockChain().(interface{ Blocks() []*consensus.Block })
+	if !ok {
+		return fmt.Errorf("block chain of type %T cannot list its blocks", mods.BlockChain())
+	}
+
+	pointers := []struct {
+		name  string
+		block *consensus.Block
+	}{
+		{"bLock", mods.Consensus().LockedBlock()},
+		{"bExec", mods.Consensus().CommittedBlock()},
+		{"bLeaf", mods.Synchronizer().LeafBlock()},
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph BlockChain {")
+	fmt.Fprintln(bw, "\trankdir=RL;")
+	fmt.Fprintln(bw, "\tnode [shape=box];")
+
+	blocks := chain.Blocks()
+	stored := make(map[consensus.Hash]bool, len(blocks))
+	for _, block := range blocks {
+		stored[block.Hash()] = true
+		fmt.Fprintf(bw, "\t%s [label=\"view %d\\n%.8s\\nproposer %d\"];\n",
+			dotID(block.Hash()), block.View(), block.Hash(), block.Proposer())
+	}
+	for _, block := range blocks {
+		if block.View() == 0 {
+			continue
+		}
+		if stored[block.Parent()] {
+			fmt.Fprintf(bw, "\t%s -> %s;\n", dotID(block.Hash()), dotID(block.Parent()))
+		}
+		if qcHash := block.QuorumCert().BlockHash(); stored[qcHash] {
+			fmt.Fprintf(bw, "\t%s -> %s [style=dashed];\n", dotID(block.Hash()), dotID(qcHash))
+		}
+	}
+	for _, p := range pointers {
+		if p.block == nil || !stored[p.block.Hash()] {
+			continue
+		}
+		fmt.Fprintf(bw, "\t%s [shape=plaintext];\n", p.name)
+		fmt.Fprintf(bw, "\t%s -> %s [color=red];\n", p.name, dotID(p.block.Hash()))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func dotID(hash consensus.Hash) string {
+	return fmt.Sprintf("\"%x\"", hash[:8])
+}
+
+// NewDOTHandler returns an HTTP handler that serves the replica's block chain as a Graphviz DOT graph.
+func NewDOTHandler(mods *consensus.Modules) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			buf  bytes.Buffer
+			err  error
+			done = make(chan struct{})
+		)
+		// the protocol state is owned by the event loop, so we must read it from there.
+		go mods.EventLoop().AddEvent(func() {
+			err = WriteDOT(&buf, mods)
+			close(done)
+		})
+		select {
+		case <-done:
+		case <-r.Context().Done():
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
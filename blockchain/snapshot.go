@@ -0,0 +1,64 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// Snapshot returns the committed chain, from the oldest committed block that is still stored to the committed block.
+// The proof of the committed block contains its QC if it is known, such that the restoring replica can commit it.
+func (chain *blockChain) Snapshot() ([]consensus.FetchedBlock, error) {
+	committed := chain.mods.Consensus().CommittedBlock()
+
+	chain.mut.Lock()
+	var snapshot []consensus.FetchedBlock
+	for block, ok := committed, true; ok && block.View() > 0; block, ok = chain.blocks[block.Parent()] {
+		snapshot = append(snapshot, consensus.FetchedBlock{Block: block, Proof: chain.proofs[block.Hash()]})
+	}
+	chain.mut.Unlock()
+
+	if len(snapshot) == 0 {
+		return nil, fmt.Errorf("snapshot: no blocks have been committed")
+	}
+	// the blocks are ordered from the oldest to the committed block.
+	for i, j := 0, len(snapshot)-1; i < j; i, j = i+1, j-1 {
+		snapshot[i], snapshot[j] = snapshot[j], snapshot[i]
+	}
+	return snapshot, nil
+}
+
+// Restore stores the blocks of the snapshot, and commits them up to the newest block that the QCs of the snapshot
+// prove committed, see Consensus.CommitProven. The QC of the newest block is taken from its inclusion proof, if it has one.
+// Since the commit rule needs QCs for the blocks after a committed block, the newest blocks of the snapshot
+// may be left for the replica to commit as it catches up.
+// The snapshot must reach back to the committed block of this replica, or to a block that can still be fetched.
+func (chain *blockChain) Restore(ctx context.Context, snapshot []consensus.FetchedBlock) error {
+	if len(snapshot) == 0 {
+		return fmt.Errorf("restore: the snapshot is empty")
+	}
+	var head *consensus.Block
+	for _, fetched := range snapshot {
+		if fetched.Block == nil {
+			return fmt.Errorf("restore: the snapshot contains an empty block")
+		}
+		if head != nil && fetched.Block.Parent() != head.Hash() {
+			return fmt.Errorf("restore: block %.8s does not extend block %.8s", fetched.Block.Hash(), head.Hash())
+		}
+		head = fetched.Block
+	}
+	for _, fetched := range snapshot {
+		chain.Store(fetched.Block)
+	}
+
+	highQC := head.QuorumCert()
+	if qc, ok := snapshot[len(snapshot)-1].Proof.QC(); ok && qc.BlockHash() == head.Hash() {
+		highQC = qc
+	}
+	if err := chain.mods.Consensus().CommitProven(ctx, highQC); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	chain.mods.Logger().Infof("Restored %d blocks from a snapshot", len(snapshot))
+	return nil
+}
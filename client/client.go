@@ -5,6 +5,7 @@ package client
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/x509"
 	"errors"
 	"io"
@@ -23,10 +24,12 @@ import (
 	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type qspec struct {
 	faulty int
+	quorum int
 }
 
 func (q *qspec) ExecCommandQF(_ *clientpb.Command, signatures map[uint32]*empty.Empty) (*empty.Empty, bool) {
@@ -36,6 +39,24 @@ func (q *qspec) ExecCommandQF(_ *clientpb.Command, signatures map[uint32]*empty.
 	return &empty.Empty{}, true
 }
 
+// GetReceiptQF returns the receipts of a quorum of replicas that agree on the block that the command was committed in,
+// and on the result of the command. The signatures of the receipts are not verified.
+func (q *qspec) GetReceiptQF(_ *clientpb.CommandID, replies map[uint32]*clientpb.Receipt) (*clientpb.Receipts, bool) {
+	type outcome struct {
+		blockHash, resultDigest string
+		view                    uint64
+	}
+	agreeing := make(map[outcome][]*clientpb.Receipt)
+	for _, receipt := range replies {
+		o := outcome{string(receipt.GetBlockHash()), string(receipt.GetResultDigest()), receipt.GetView()}
+		agreeing[o] = append(agreeing[o], receipt)
+		if len(agreeing[o]) >= q.quorum {
+			return &clientpb.Receipts{Receipts: agreeing[o]}, true
+		}
+	}
+	return nil, false
+}
+
 type pendingCmd struct {
 	sequenceNumber uint64
 	sendTime       time.Time
@@ -51,9 +72,10 @@ type Config struct {
 	PayloadSize      uint32
 	Input            io.ReadCloser
 	ManagerOptions   []gorums.ManagerOption
-	RateLimit        float64       // initial rate limit
-	RateStep         float64       // rate limit step up
-	RateStepInterval time.Duration // step up interval
+	RateLimit        float64           // initial rate limit
+	RateStep         float64           // rate limit step up
+	RateStepInterval time.Duration     // step up interval
+	PrivateKey       *ecdsa.PrivateKey // if set, the client signs its commands with this key
 }
 
 // Client is a hotstuff client.
@@ -72,6 +94,7 @@ type Client struct {
 	limiter          *rate.Limiter
 	stepUp           float64
 	stepUpInterval   time.Duration
+	privateKey       *ecdsa.PrivateKey
 }
 
 // New returns a new Client.
@@ -89,6 +112,7 @@ func New(conf Config, builder modules.Builder) (client *Client) {
 		limiter:          rate.NewLimiter(rate.Limit(conf.RateLimit), 1),
 		stepUp:           conf.RateStep,
 		stepUpInterval:   conf.RateStepInterval,
+		privateKey:       conf.PrivateKey,
 	}
 
 	grpcOpts := []grpc.DialOption{grpc.WithBlock()}
@@ -113,7 +137,10 @@ func (c *Client) Connect(replicaConfig *config.ReplicaConfig) (err error) {
 	for _, r := range replicaConfig.Replicas {
 		nodes[r.Address] = uint32(r.ID)
 	}
-	c.gorumsConfig, err = c.mgr.NewConfiguration(&qspec{faulty: hotstuff.NumFaulty(len(replicaConfig.Replicas))}, gorums.WithNodeMap(nodes))
+	c.gorumsConfig, err = c.mgr.NewConfiguration(&qspec{
+		faulty: hotstuff.NumFaulty(len(replicaConfig.Replicas)),
+		quorum: hotstuff.QuorumSize(len(replicaConfig.Replicas)),
+	}, gorums.WithNodeMap(nodes))
 	if err != nil {
 		c.mgr.Close()
 		return err
@@ -121,6 +148,16 @@ func (c *Client) Connect(replicaConfig *config.ReplicaConfig) (err error) {
 	return nil
 }
 
+// Receipts returns the signed receipts of a quorum of replicas that agree on the commit and the result of the command
+// with the given sequence number. The replicas must be configured to sign receipts, see replica.Config.
+func (c *Client) Receipts(ctx context.Context, sequenceNumber uint64) ([]*clientpb.Receipt, error) {
+	receipts, err := c.gorumsConfig.GetReceipt(ctx, &clientpb.CommandID{ClientID: uint32(c.id), SequenceNumber: sequenceNumber})
+	if err != nil {
+		return nil, err
+	}
+	return receipts.GetReceipts(), nil
+}
+
 // Run runs the client until the context is closed.
 func (c *Client) Run(ctx context.Context) {
 	eventLoopDone := make(chan struct{})
@@ -220,6 +257,13 @@ func (c *Client) sendCommands(ctx context.Context) error {
 			ClientID:       uint32(c.id),
 			SequenceNumber: num,
 			Data:           data[:n],
+			SubmitTime:     timestamppb.Now(),
+		}
+
+		if c.privateKey != nil {
+			if err := clientpb.SignCommand(cmd, c.privateKey); err != nil {
+				return err
+			}
 		}
 
 		promise := c.gorumsConfig.ExecCommand(ctx, cmd)
@@ -12,10 +12,12 @@ import (
 
 // ReplicaInfo holds information about a replica.
 type ReplicaInfo struct {
-	ID      hotstuff.ID
-	Address string
-	PubKey  consensus.PublicKey
+	ID         hotstuff.ID
+	Address    string
+	PubKey     consensus.PublicKey
 	Reputation uint64
+	Region     string // the region that the replica is located in, used to relay votes within a region
+	Zone       string // the zone within the region, which is not used by the protocol
 }
 
 // ReplicaConfig holds information needed by a replica.
@@ -0,0 +1,127 @@
+package consensus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/modules"
+)
+
+// startupBarrier delays the start of the first view until a quorum of replicas are ready, see the StartupBarrierTimeout option.
+// A replica announces that it is ready by broadcasting a heartbeat when it starts, and announces it again whenever
+// it hears from a replica that it has not heard from before, such that replicas that start late learn about
+// the replicas that started before them.
+type startupBarrier struct {
+	mods    *Modules
+	mut     sync.Mutex
+	ready   idSetMap
+	started bool
+	passed  bool
+	waiters []func()
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (b *startupBarrier) InitConsensusModule(mods *Modules, _ *OptionsBuilder) {
+	b.mods = mods
+	b.ready = make(idSetMap)
+}
+
+// InitModule starts listening for the readiness of the other replicas, if the StartupBarrierTimeout option is set.
+// This must happen after the options have been set.
+func (b *startupBarrier) InitModule(_ *modules.Modules) {
+	if b.mods.Options().StartupBarrierTimeout() <= 0 {
+		return
+	}
+	b.mods.EventLoop().RegisterObserver(HeartbeatMsg{}, func(event interface{}) {
+		b.onReady(event.(HeartbeatMsg).ID)
+	})
+}
+
+// await calls f on the event loop once a quorum of replicas are ready, or the StartupBarrierTimeout has expired.
+// The first call announces that this replica is ready.
+func (b *startupBarrier) await(f func()) {
+	timeout := b.mods.Options().StartupBarrierTimeout()
+	b.mut.Lock()
+	if b.passed {
+		b.mut.Unlock()
+		go b.mods.EventLoop().AddEvent(f)
+		return
+	}
+	b.waiters = append(b.waiters, f)
+	start := !b.started
+	b.started = true
+	b.ready.Add(b.mods.ID())
+	b.mut.Unlock()
+
+	if !start {
+		return
+	}
+	if _, ok := b.mods.Configuration().(Heartbeater); !ok {
+		b.mods.Logger().Warn("StartupBarrierTimeout is set, but the configuration cannot announce that the replica is ready")
+		b.pass()
+		return
+	}
+	time.AfterFunc(timeout, func() {
+		b.mut.Lock()
+		passed := b.passed
+		b.mut.Unlock()
+		if !passed {
+			b.mods.Logger().Warnf("Only %d replicas were ready after %v, starting anyway", b.readyCount(), timeout)
+			b.pass()
+		}
+	})
+	b.mods.heartbeats.beat()
+	b.check()
+}
+
+// onReady records that the replica with the given ID is ready.
+func (b *startupBarrier) onReady(id hotstuff.ID) {
+	b.mut.Lock()
+	known := b.ready.Contains(id)
+	b.ready.Add(id)
+	started := b.started
+	b.mut.Unlock()
+
+	if known || !started {
+		return
+	}
+	// the replica may have started after our announcement, so it needs to hear from us again.
+	b.mods.heartbeats.beat()
+	b.check()
+}
+
+// check passes the barrier if a quorum of replicas are ready.
+func (b *startupBarrier) check() {
+	if b.readyCount() >= b.mods.Configuration().QuorumSize() {
+		b.pass()
+	}
+}
+
+func (b *startupBarrier) readyCount() int {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	return len(b.ready)
+}
+
+// pass releases the functions that are waiting for the barrier.
+func (b *startupBarrier) pass() {
+	b.mut.Lock()
+	if b.passed {
+		b.mut.Unlock()
+		return
+	}
+	b.passed = true
+	waiters := b.waiters
+	b.waiters = nil
+	b.mut.Unlock()
+
+	b.mods.Logger().Debugf("Startup barrier passed with %d ready replicas", b.readyCount())
+	// must use a goroutine, because pass may be called from the event loop.
+	go func() {
+		for _, f := range waiters {
+			b.mods.EventLoop().AddEvent(f)
+		}
+	}()
+}
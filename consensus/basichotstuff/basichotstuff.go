@@ -0,0 +1,207 @@
+// Package basichotstuff implements the basic, non-pipelined HotStuff protocol.
+package basichotstuff
+
+import (
+	"fmt"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// Phase is a phase of basic HotStuff.
+type Phase int
+
+const (
+	// Prepare is the phase in which the leader proposes a command, extending the block of its highQC.
+	Prepare Phase = iota
+	// PreCommit is the phase in which the leader sends the prepareQC for the command.
+	PreCommit
+	// Commit is the phase in which the leader sends the precommitQC for the command, and the replicas lock on the command.
+	Commit
+	// Decide is the phase in which the leader sends the commitQC for the command, and the replicas execute the command.
+	Decide
+)
+
+func (p Phase) String() string {
+	switch p {
+	case Prepare:
+		return "prepare"
+	case PreCommit:
+		return "pre-commit"
+	case Commit:
+		return "commit"
+	case Decide:
+		return "decide"
+	}
+	return fmt.Sprintf("Phase(%d)", int(p))
+}
+
+// BasicHotStuff implements the basic HotStuff algorithm, where each command goes through the prepare, pre-commit,
+// commit, and decide phases before the next command is proposed.
+//
+// Each phase is a view of its own. The prepare block carries the command, and each of the three following blocks is
+// an empty block that carries the QC of the previous phase, such that the votes for a block are the votes of its phase.
+// The vote for the decide block plays the part of the new-view message, and its QC is extended by the next command.
+// If a phase fails, the next leader continues with the phase after the one certified by its highQC.
+//
+// Based on the algorithm described in the paper
+// "HotStuff: BFT Consensus with Linearity and Responsiveness" by Yin et al.
+type BasicHotStuff struct {
+	mods *consensus.Modules
+
+	locked *consensus.Block
+}
+
+// New returns a new BasicHotStuff instance.
+func New() consensus.Rules {
+	return &BasicHotStuff{
+		locked: consensus.GetGenesis(),
+	}
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (hs *BasicHotStuff) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	hs.mods = mods
+	// the blocks of the pre-commit, commit, and decide phases do not carry a command.
+	opts.SetShouldSkipEmptyBlocks()
+}
+
+// parent returns the parent of the block, which must be the block certified by the QC of the block.
+func (hs *BasicHotStuff) parent(block *consensus.Block) (*consensus.Block, bool) {
+	if block.Parent() != block.QuorumCert().BlockHash() {
+		return nil, false
+	}
+	return hs.mods.BlockChain().Get(block.Parent())
+}
+
+// phase returns the phase of the block. A block with a command is a prepare block, and each empty block is
+// in the phase after its parent. The genesis block counts as a decide block, so the first block must carry a command.
+func (hs *BasicHotStuff) phase(block *consensus.Block) (Phase, bool) {
+	switch {
+	case block.View() == 0:
+		return Decide, true
+	case block.Command() != "":
+		return Prepare, true
+	}
+	parent, ok := hs.parent(block)
+	if !ok {
+		return 0, false
+	}
+	phase, ok := hs.phase(parent)
+	if !ok || phase == Decide {
+		// a new command must be proposed after the decide phase.
+		return 0, false
+	}
+	return phase + 1, true
+}
+
+// NeedsCommand returns true if the proposal that extends the sync info is a prepare block.
+// The blocks of the other phases are proposed without a command.
+func (hs *BasicHotStuff) NeedsCommand(cert consensus.SyncInfo) bool {
+	qc, ok := cert.QC()
+	if !ok {
+		return false
+	}
+	block, ok := hs.mods.BlockChain().Get(qc.BlockHash())
+	if !ok {
+		return false
+	}
+	phase, ok := hs.phase(block)
+	return ok && phase == Decide
+}
+
+// ProposeRule creates the block of the phase after the block certified by the highQC.
+func (hs *BasicHotStuff) ProposeRule(cert consensus.SyncInfo, cmd consensus.Command) (proposal consensus.ProposeMsg, ok bool) {
+	qc, ok := cert.QC()
+	if !ok {
+		return proposal, false
+	}
+	parent, ok := hs.mods.BlockChain().Get(qc.BlockHash())
+	if !ok {
+		return proposal, false
+	}
+	phase, ok := hs.phase(parent)
+	if !ok || (phase == Decide) != (cmd != "") {
+		hs.mods.Logger().Info("ProposeRule: the command does not fit the phase of the highQC")
+		return proposal, false
+	}
+	block := consensus.NewBlock(parent.Hash(), qc, cmd, hs.mods.Synchronizer().View(), hs.mods.ID())
+	return consensus.ProposeMsg{ID: hs.mods.ID(), Block: block}, true
+}
+
+// VoteRule decides whether to vote for the proposal or not.
+func (hs *BasicHotStuff) VoteRule(proposal consensus.ProposeMsg) bool {
+	block := proposal.Block
+
+	parent, ok := hs.parent(block)
+	if !ok {
+		hs.mods.Logger().Info("VoteRule: the block does not extend the block certified by its QC")
+		return false
+	}
+	phase, ok := hs.phase(parent)
+	if !ok {
+		hs.mods.Logger().Info("VoteRule: the phase of the parent block is unknown")
+		return false
+	}
+	// a command can only be proposed after the previous command was decided, and must be proposed then.
+	if (phase == Decide) != (block.Command() != "") {
+		hs.mods.Logger().Infof("VoteRule: the block does not fit the phase after the %s phase", phase)
+		return false
+	}
+
+	// the same safety and liveness rules as chained HotStuff.
+	if parent.View() > hs.locked.View() {
+		return true
+	}
+	if hs.mods.BlockChain().Extends(block, hs.locked) {
+		return true
+	}
+	hs.mods.Logger().Info("VoteRule: the block does not extend the locked block")
+	return false
+}
+
+// CommitRule locks on the command when a block carries its precommitQC, and decides the command when a block carries its commitQC.
+func (hs *BasicHotStuff) CommitRule(block *consensus.Block) *consensus.Block {
+	certified, ok := hs.parent(block)
+	if !ok {
+		return nil
+	}
+	phase, ok := hs.phase(certified)
+	if !ok || (phase != PreCommit && phase != Commit) {
+		return nil
+	}
+	prepare := certified
+	for i := Prepare; i < phase; i++ {
+		if prepare, ok = hs.parent(prepare); !ok {
+			return nil
+		}
+	}
+	if prepare.View() > hs.locked.View() {
+		hs.mods.Logger().Debug("LOCK: ", prepare)
+		hs.locked = prepare
+	}
+	if phase == Commit {
+		hs.mods.Logger().Debug("DECIDE: ", prepare)
+		return prepare
+	}
+	return nil
+}
+
+// Checkpoint updates the locked block after the replica was forced to commit up to a checkpoint.
+func (hs *BasicHotStuff) Checkpoint(block *consensus.Block) {
+	if block.View() > hs.locked.View() {
+		hs.locked = block
+	}
+}
+
+// RestoreLock restores the locked block after the replica restarted.
+func (hs *BasicHotStuff) RestoreLock(block *consensus.Block) {
+	if block.View() > hs.locked.View() {
+		hs.locked = block
+	}
+}
+
+// LockedBlock returns the currently locked block.
+func (hs *BasicHotStuff) LockedBlock() *consensus.Block {
+	return hs.locked
+}
@@ -0,0 +1,79 @@
+package basichotstuff_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/consensus/basichotstuff"
+	"github.com/relab/hotstuff/internal/testutil"
+)
+
+// TestBasicHotStuff checks that every command goes through the four phases before it is executed,
+// and that each replica executes every command exactly once.
+func TestBasicHotStuff(t *testing.T) {
+	const (
+		n         = 4
+		minCommit = 10
+	)
+	network, builders := testutil.CreateNetwork(t, n)
+	for _, builder := range builders {
+		builder.Register(consensus.New(basichotstuff.New()))
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			done := true
+			for _, node := range network.Nodes() {
+				done = done && len(node.Executed()) >= minCommit
+			}
+			if done {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	for _, node := range network.Nodes() {
+		executed := node.Executed()
+		if len(executed) < minCommit {
+			t.Fatalf("replica %d executed %d blocks, want at least %d", node.ID(), len(executed), minCommit)
+		}
+		seen := make(map[consensus.Command]bool)
+		for _, block := range executed {
+			if block.Command() == "" {
+				t.Errorf("replica %d executed an empty block in view %d", node.ID(), block.View())
+			}
+			if seen[block.Command()] {
+				t.Errorf("replica %d executed command %q more than once", node.ID(), block.Command())
+			}
+			seen[block.Command()] = true
+		}
+
+		// walking back from the committed block, each command is followed by the blocks of the three other phases.
+		mods := node.Modules()
+		block := mods.Consensus().CommittedBlock()
+		for i := 0; block.View() > 0; i++ {
+			if got, want := block.Command() != "", i%4 == 0; got != want {
+				t.Fatalf("replica %d: block %d before the committed block has a command: %v, want %v", node.ID(), i, got, want)
+			}
+			parent, ok := mods.BlockChain().LocalGet(block.Parent())
+			if !ok {
+				t.Fatalf("replica %d: missing block %.8s", node.ID(), block.Parent())
+			}
+			if block.QuorumCert().BlockHash() != parent.Hash() {
+				t.Fatalf("replica %d: the QC of block %.8s does not certify its parent", node.ID(), block.Hash())
+			}
+			if parent.View() == 0 && i%4 != 0 {
+				t.Fatalf("replica %d: the first command is not in the first block", node.ID())
+			}
+			block = parent
+		}
+	}
+}
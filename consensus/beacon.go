@@ -0,0 +1,60 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// BeaconValue is the output of a randomness beacon for a round.
+type BeaconValue struct {
+	Round uint64 // The round of the beacon.
+	Value []byte // The randomness, along with any proof that the Beacon needs to verify it.
+}
+
+// IsZero returns true if the value is empty.
+func (v BeaconValue) IsZero() bool {
+	return v.Round == 0 && len(v.Value) == 0
+}
+
+// Equals returns true if the values are equal.
+func (v BeaconValue) Equals(other BeaconValue) bool {
+	return v.Round == other.Round && bytes.Equal(v.Value, other.Value)
+}
+
+// ToBytes returns the raw byte form of the value.
+func (v BeaconValue) ToBytes() []byte {
+	var roundBuf [8]byte
+	binary.LittleEndian.PutUint64(roundBuf[:], v.Round)
+	return append(roundBuf[:], v.Value...)
+}
+
+// CommittedRandomness returns the beacon value of the most recently committed block that includes one.
+// Leader rotations and other modules that sample replicas should use the committed randomness,
+// since the replicas agree on it once they have committed the same blocks.
+func CommittedRandomness(mods *Modules) (value BeaconValue, ok bool) {
+	block := mods.Consensus().CommittedBlock()
+	for block.View() > 0 {
+		if value, ok := block.Beacon(); ok {
+			return value, true
+		}
+		if block, ok = mods.BlockChain().LocalGet(block.Parent()); !ok {
+			break
+		}
+	}
+	return BeaconValue{}, false
+}
+
+// verifyBeacon returns true if the block includes a valid beacon value that is not older than the beacon value of its parent.
+func (cs *consensusBase) verifyBeacon(block *Block) bool {
+	value, ok := block.Beacon()
+	if !ok || !cs.mods.Beacon().VerifyRandomness(value) {
+		return false
+	}
+	// dummy blocks do not include beacon values, so the nearest ancestor with a beacon value is checked.
+	for parent, ok := cs.mods.BlockChain().LocalGet(block.Parent()); ok && parent.View() > 0; parent, ok = cs.mods.BlockChain().LocalGet(parent.Parent()) {
+		if prev, ok := parent.Beacon(); ok {
+			return value.Round >= prev.Round
+		}
+	}
+	return true
+}
@@ -11,12 +11,15 @@ import (
 // Block contains a propsed "command", metadata for the protocol, and a link to the "parent" block.
 type Block struct {
 	// keep a copy of the hash to avoid hashing multiple times
-	hash     Hash
-	parent   Hash
-	proposer hotstuff.ID
-	cmd      Command
-	cert     QuorumCert
-	view     View
+	hash       Hash
+	parent     Hash
+	proposer   hotstuff.ID
+	cmd        Command
+	payloadRef Hash // the hash of the command, if the block refers to the command instead of including it
+	cert       QuorumCert
+	view       View
+	beacon     BeaconValue // the output of the randomness beacon, if the block includes one
+	cmdRoot    Hash        // the Merkle root of the client commands in the command, if the block commits to one
 }
 
 // NewBlock creates a new Block
@@ -33,6 +36,46 @@ func NewBlock(parent Hash, cert QuorumCert, cmd Command, view View, proposer hot
 	return b
 }
 
+// NewPayloadRefBlock creates a new Block that refers to its command by the hash of the command, instead of including it.
+// The command is disseminated separately, and must be resolved using WithPayload before the block can be executed.
+// Because the hash of the block includes the reference, the block still binds the command.
+func NewPayloadRefBlock(parent Hash, cert QuorumCert, ref Hash, view View, proposer hotstuff.ID) *Block {
+	b := &Block{
+		parent:     parent,
+		cert:       cert,
+		payloadRef: ref,
+		view:       view,
+		proposer:   proposer,
+	}
+	b.hash = sha256.Sum256(b.ToBytes())
+	return b
+}
+
+// PayloadHash returns the hash that is used to refer to the command as a block payload.
+func PayloadHash(cmd Command) Hash {
+	return sha256.Sum256([]byte(cmd))
+}
+
+// NewDummyBlock creates an empty block in the given view that extends the parent block.
+// The parent is either the block certified by the QC, or another dummy block that extends it.
+// Dummy blocks fill gaps in the views of the chain. They have no proposer and no command,
+// and they are never voted on or executed. Because the block is derived only from the parent, the QC, and the view,
+// every replica can recreate it locally.
+func NewDummyBlock(parent Hash, qc QuorumCert, view View) *Block {
+	return NewBlock(parent, qc, "", view, 0)
+}
+
+// IsDummy returns true if the block was created by NewDummyBlock.
+func (b *Block) IsDummy() bool {
+	return b.view > 0 && b.proposer == 0
+}
+
+// IsEmpty returns true if the block was proposed with an empty command, such as a filler proposal.
+// Unlike dummy blocks, empty blocks have a proposer and are voted on like any other proposal.
+func (b *Block) IsEmpty() bool {
+	return b.view > 0 && !b.IsDummy() && b.IsResolved() && b.cmd == ""
+}
+
 func (b *Block) String() string {
 	return fmt.Sprintf(
 		"Block{ hash: %.6s parent: %.6s, proposer: %d, view: %d , cert: %v }",
@@ -59,11 +102,74 @@ func (b *Block) Parent() Hash {
 	return b.parent
 }
 
-// Command returns the command
+// Command returns the command.
+// If the block refers to its command, the command is empty until the payload has been resolved.
 func (b *Block) Command() Command {
 	return b.cmd
 }
 
+// PayloadRef returns the hash of the command, if the block refers to the command instead of including it.
+func (b *Block) PayloadRef() (ref Hash, ok bool) {
+	return b.payloadRef, b.payloadRef != Hash{}
+}
+
+// IsResolved returns false if the block refers to a command that has not yet been resolved.
+func (b *Block) IsResolved() bool {
+	_, ok := b.PayloadRef()
+	return !ok || b.cmd != ""
+}
+
+// WithPayload returns a copy of the block that includes the command that the block refers to.
+// The hash of the block is unchanged. WithPayload returns false if the command does not match the reference.
+func (b *Block) WithPayload(cmd Command) (*Block, bool) {
+	if ref, ok := b.PayloadRef(); !ok || PayloadHash(cmd) != ref {
+		return nil, false
+	}
+	resolved := *b
+	resolved.cmd = cmd
+	return &resolved, true
+}
+
+// Beacon returns the output of the randomness beacon that is included in the block, if any.
+func (b *Block) Beacon() (value BeaconValue, ok bool) {
+	return b.beacon, !b.beacon.IsZero()
+}
+
+// WithBeacon returns a copy of the block that includes the given output of the randomness beacon.
+// Unlike WithPayload, the hash of the copy differs from the hash of the block, since it covers the beacon value.
+func (b *Block) WithBeacon(value BeaconValue) *Block {
+	withBeacon := *b
+	withBeacon.beacon = value
+	withBeacon.hash = sha256.Sum256(withBeacon.ToBytes())
+	return &withBeacon
+}
+
+// CommandRoot returns the Merkle root of the client commands in the command of the block, if the block commits to one.
+func (b *Block) CommandRoot() (root Hash, ok bool) {
+	return b.cmdRoot, b.cmdRoot != Hash{}
+}
+
+// WithCommandRoot returns a copy of the block that commits to the given Merkle root of its client commands.
+// The hash of the copy covers the root and the hash of the command, instead of the command itself,
+// such that the hash can be recomputed from the header of the block, without the command. See CommandProof.
+func (b *Block) WithCommandRoot(root Hash) *Block {
+	withRoot := *b
+	withRoot.cmdRoot = root
+	withRoot.hash = sha256.Sum256(withRoot.ToBytes())
+	return &withRoot
+}
+
+// header returns a copy of a block that commits to a command root, which refers to its command by hash instead of including it.
+// The hash of the header is the same as the hash of the block.
+func (b *Block) header() *Block {
+	header := *b
+	if _, ok := header.PayloadRef(); !ok {
+		header.payloadRef = PayloadHash(b.cmd)
+	}
+	header.cmd = ""
+	return &header
+}
+
 // QuorumCert returns the quorum certificate in the block
 func (b *Block) QuorumCert() QuorumCert {
 	return b.cert
@@ -83,7 +189,20 @@ func (b *Block) ToBytes() []byte {
 	var viewBuf [8]byte
 	binary.LittleEndian.PutUint64(viewBuf[:], uint64(b.view))
 	buf = append(buf, viewBuf[:]...)
-	buf = append(buf, []byte(b.cmd)...)
+	if ref, ok := b.PayloadRef(); ok {
+		buf = append(buf, ref[:]...)
+	} else if _, ok := b.CommandRoot(); ok {
+		ref := PayloadHash(b.cmd)
+		buf = append(buf, ref[:]...)
+	} else {
+		buf = append(buf, []byte(b.cmd)...)
+	}
+	if root, ok := b.CommandRoot(); ok {
+		buf = append(buf, root[:]...)
+	}
 	buf = append(buf, b.cert.ToBytes()...)
+	if !b.beacon.IsZero() {
+		buf = append(buf, b.beacon.ToBytes()...)
+	}
 	return buf
 }
@@ -1,4 +1,4 @@
-// Package chainedhotstuff implements the pipelined three-chain version of the HotStuff protocol.
+// Package chainedhotstuff implements the pipelined version of the HotStuff protocol, with a three-chain or two-chain commit rule.
 package chainedhotstuff
 
 import (
@@ -7,18 +7,47 @@ import (
 
 // ChainedHotStuff implements the pipelined three-phase HotStuff protocol.
 type ChainedHotStuff struct {
-	mods *consensus.Modules
+	mods       *consensus.Modules
+	commitRule CommitRule
 
 	// protocol variables
 
 	bLock *consensus.Block // the currently locked block
 }
 
+// CommitRule selects how many blocks must be chained by direct parent links before the first of them is committed.
+type CommitRule int
+
+const (
+	// ThreeChain commits a block once it is the first of three blocks that are linked by their parent hashes,
+	// and the last of them is certified. This is the rule of the HotStuff paper.
+	ThreeChain CommitRule = iota
+	// TwoChain commits a block once it is the first of two blocks that are linked by their parent hashes,
+	// and the last of them is certified. It commits one view earlier than the three-chain rule,
+	// and locks on the highest certified block instead of its parent.
+	TwoChain
+)
+
+// Option configures a chainedhotstuff instance.
+type Option func(hs *ChainedHotStuff)
+
+// WithCommitRule selects the commit rule. The default is ThreeChain.
+func WithCommitRule(rule CommitRule) Option {
+	return func(hs *ChainedHotStuff) {
+		hs.commitRule = rule
+	}
+}
+
 // New returns a new chainedhotstuff instance.
-func New() consensus.Rules {
-	return &ChainedHotStuff{
-		bLock: consensus.GetGenesis(),
+func New(opts ...Option) consensus.Rules {
+	hs := &ChainedHotStuff{
+		commitRule: ThreeChain,
+		bLock:      consensus.GetGenesis(),
+	}
+	for _, opt := range opts {
+		opt(hs)
 	}
+	return hs
 }
 
 // InitConsensusModule gives the module a reference to the Modules object.
@@ -35,11 +64,22 @@ func (hs *ChainedHotStuff) qcRef(qc consensus.QuorumCert) (*consensus.Block, boo
 }
 
 // CommitRule decides whether an ancestor of the block should be committed.
+//
+// The three-chain rule requires that the three blocks are linked by their parent hashes,
+// and the two-chain rule requires the same of the two blocks, see CommitRule.
+// Dummy blocks inserted to fill view gaps (see consensus.DummyPolicy) are skipped when checking these links,
+// such that a certified block extending a run of dummy blocks is treated as a direct child of the block before them.
+// This is safe because dummy blocks are never certified, so no conflicting block can be certified between the two.
+// Dummy blocks are only committed as ancestors of a committed block, in which case they are skipped by the executor.
 func (hs *ChainedHotStuff) CommitRule(block *consensus.Block) *consensus.Block {
 	hs.mods.Synchronizer().UpdateHighQC(block.QuorumCert())
 
 	block1, ok := hs.qcRef(block.QuorumCert())
 	if !ok {
+		if (consensus.Hash{}) != block.QuorumCert().BlockHash() {
+			hs.mods.InvariantViolation("the QC of block %.8s at view %d references a missing block %.8s",
+				block.Hash(), block.View(), block.QuorumCert().BlockHash())
+		}
 		return nil
 	}
 
@@ -50,6 +90,18 @@ func (hs *ChainedHotStuff) CommitRule(block *consensus.Block) *consensus.Block {
 		return nil
 	}
 
+	if hs.commitRule == TwoChain {
+		if block1.View() > hs.bLock.View() {
+			hs.mods.Logger().Debug("COMMIT: ", block1)
+			hs.bLock = block1
+		}
+		if hs.isParent(block2, block1) {
+			hs.mods.Logger().Debug("DECIDE: ", block2)
+			return block2
+		}
+		return nil
+	}
+
 	if block2.View() > hs.bLock.View() {
 		hs.mods.Logger().Debug("COMMIT: ", block2)
 		hs.bLock = block2
@@ -60,7 +112,7 @@ func (hs *ChainedHotStuff) CommitRule(block *consensus.Block) *consensus.Block {
 		return nil
 	}
 
-	if block1.Parent() == block2.Hash() && block2.Parent() == block3.Hash() {
+	if hs.isParent(block2, block1) && hs.isParent(block3, block2) {
 		hs.mods.Logger().Debug("DECIDE: ", block3)
 		return block3
 	}
@@ -68,6 +120,19 @@ func (hs *ChainedHotStuff) CommitRule(block *consensus.Block) *consensus.Block {
 	return nil
 }
 
+// isParent returns true if the block extends the parent, either directly or through dummy blocks only.
+func (hs *ChainedHotStuff) isParent(parent, block *consensus.Block) bool {
+	hash := block.Parent()
+	for hash != parent.Hash() {
+		ancestor, ok := hs.mods.BlockChain().LocalGet(hash)
+		if !ok || !ancestor.IsDummy() {
+			return false
+		}
+		hash = ancestor.Parent()
+	}
+	return true
+}
+
 // VoteRule decides whether to vote for the proposal or not.
 func (hs *ChainedHotStuff) VoteRule(proposal consensus.ProposeMsg) bool {
 	block := proposal.Block
@@ -89,3 +154,22 @@ func (hs *ChainedHotStuff) VoteRule(proposal consensus.ProposeMsg) bool {
 
 	return safe
 }
+
+// Checkpoint updates the locked block after the replica was forced to commit up to a checkpoint.
+func (hs *ChainedHotStuff) Checkpoint(block *consensus.Block) {
+	if block.View() > hs.bLock.View() {
+		hs.bLock = block
+	}
+}
+
+// RestoreLock restores the locked block after the replica restarted.
+func (hs *ChainedHotStuff) RestoreLock(block *consensus.Block) {
+	if block.View() > hs.bLock.View() {
+		hs.bLock = block
+	}
+}
+
+// LockedBlock returns the currently locked block.
+func (hs *ChainedHotStuff) LockedBlock() *consensus.Block {
+	return hs.bLock
+}
@@ -0,0 +1,54 @@
+package chainedhotstuff_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"github.com/relab/hotstuff/internal/testutil"
+)
+
+// TestCommitRule delivers the proposals of a chain of four blocks to a replica,
+// and checks which of the blocks it executes under each commit rule.
+func TestCommitRule(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []chainedhotstuff.Option
+		want []string
+	}{
+		{"Default", nil, []string{"b1"}},
+		{"ThreeChain", []chainedhotstuff.Option{chainedhotstuff.WithCommitRule(chainedhotstuff.ThreeChain)}, []string{"b1"}},
+		{"TwoChain", []chainedhotstuff.Option{chainedhotstuff.WithCommitRule(chainedhotstuff.TwoChain)}, []string{"b1", "b2"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			network, builders := testutil.CreateNetwork(t, 4)
+			builders[0].Register(consensus.New(chainedhotstuff.New(tt.opts...)))
+			hl := builders.Build()
+			signers := hl.Signers()
+			hs := hl[0]
+
+			parent := consensus.GetGenesis()
+			qc := consensus.NewQuorumCert(nil, 0, parent.Hash())
+			for view := consensus.View(1); view <= 4; view++ {
+				block := consensus.NewBlock(parent.Hash(), qc, consensus.Command(fmt.Sprintf("b%d", view)), view, hs.LeaderRotation().GetLeader(view))
+				hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: block.Proposer(), Block: block})
+				parent, qc = block, testutil.CreateQC(t, block, signers)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			hs.EventLoop().Run(ctx)
+
+			var got []string
+			for _, block := range network.Node(1).Executed() {
+				got = append(got, string(block.Command()))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("executed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package consensus
+
+import "fmt"
+
+// PinnedCheckpoint is a committed block that a replica refuses to contradict.
+// Any chain that does not include the block at its view is rejected, which bounds how far back a fork can rewrite history,
+// even if the replica loses the rest of its state. Checkpoints are pinned by the normal commit process,
+// see the CheckpointInterval option.
+type PinnedCheckpoint struct {
+	View View
+	Hash Hash
+}
+
+// CheckpointStore durably stores the pinned checkpoints of a replica.
+// If no store is registered, the checkpoints are only kept in memory.
+type CheckpointStore interface {
+	// SaveCheckpoints durably stores the pinned checkpoints, in ascending order of view.
+	SaveCheckpoints(checkpoints []PinnedCheckpoint) error
+	// LoadCheckpoints returns the stored checkpoints, in ascending order of view.
+	LoadCheckpoints() ([]PinnedCheckpoint, error)
+}
+
+// Checkpoints returns the pinned checkpoints, in ascending order of view.
+func (cs *consensusBase) Checkpoints() []PinnedCheckpoint {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+	return append([]PinnedCheckpoint(nil), cs.pins...)
+}
+
+// loadCheckpoints restores the pinned checkpoints from the CheckpointStore, if one is registered.
+func (cs *consensusBase) loadCheckpoints() {
+	store := cs.mods.CheckpointStore()
+	if store == nil {
+		return
+	}
+	pins, err := store.LoadCheckpoints()
+	if err != nil {
+		cs.mods.Logger().Warnf("Failed to load pinned checkpoints: %v", err)
+		return
+	}
+	cs.pins = pins
+}
+
+// pinCheckpoint pins the committed block if at least CheckpointInterval views have passed since the latest checkpoint.
+// The caller must hold the lock.
+func (cs *consensusBase) pinCheckpoint(block *Block) {
+	interval := cs.mods.Options().CheckpointInterval()
+	if interval == 0 {
+		return
+	}
+	var last View // the genesis block is an implicit checkpoint
+	if n := len(cs.pins); n > 0 {
+		last = cs.pins[n-1].View
+	}
+	if block.View() < last+interval {
+		return
+	}
+	cs.pins = append(cs.pins, PinnedCheckpoint{View: block.View(), Hash: block.Hash()})
+	cs.mods.Logger().Debugf("Pinned checkpoint %.8s at view %d", block.Hash(), block.View())
+	if store := cs.mods.CheckpointStore(); store != nil {
+		if err := store.SaveCheckpoints(cs.pins); err != nil {
+			cs.mods.Logger().Warnf("Failed to save pinned checkpoints: %v", err)
+		}
+	}
+}
+
+// checkPinned returns an error if the chain that ends with the given block does not include the latest pinned checkpoint.
+// Because the latest checkpoint extends the earlier ones, only the latest checkpoint needs to be checked.
+func (cs *consensusBase) checkPinned(block *Block) error {
+	cs.mut.Lock()
+	if len(cs.pins) == 0 {
+		cs.mut.Unlock()
+		return nil
+	}
+	pin := cs.pins[len(cs.pins)-1]
+	committed := cs.bExec
+	cs.mut.Unlock()
+
+	for block.View() > pin.View {
+		if block.Hash() == committed.Hash() {
+			// the committed chain was checked before it was committed.
+			return nil
+		}
+		parent, ok := cs.mods.BlockChain().Get(block.Parent())
+		if !ok {
+			return fmt.Errorf("block %.8s not found", block.Parent())
+		}
+		block = parent
+	}
+	if block.Hash() != pin.Hash {
+		return fmt.Errorf("chain includes block %.8s at view %d instead of the pinned checkpoint %.8s at view %d",
+			block.Hash(), block.View(), pin.Hash, pin.View)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package consensus
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/relab/hotstuff"
+)
+
+// CommitCert is a statement signed by a single replica that it has committed a block.
+// Commit certificates can be collected by external auditors to verify that a quorum of replicas committed the same block.
+type CommitCert struct {
+	id        hotstuff.ID
+	view      View
+	blockHash Hash
+	signature Signature
+}
+
+// NewCommitCert returns a new commit certificate.
+func NewCommitCert(id hotstuff.ID, view View, blockHash Hash, signature Signature) CommitCert {
+	return CommitCert{id, view, blockHash, signature}
+}
+
+// ID returns the ID of the replica that committed the block.
+func (cc CommitCert) ID() hotstuff.ID {
+	return cc.id
+}
+
+// View returns the view of the committed block.
+func (cc CommitCert) View() View {
+	return cc.view
+}
+
+// BlockHash returns the hash of the committed block.
+func (cc CommitCert) BlockHash() Hash {
+	return cc.blockHash
+}
+
+// Signature returns the signature of the replica.
+func (cc CommitCert) Signature() Signature {
+	return cc.signature
+}
+
+// ToBytes returns a byte representation of the commit certificate.
+func (cc CommitCert) ToBytes() []byte {
+	b := cc.view.ToBytes()
+	b = append(b, cc.blockHash[:]...)
+	if cc.signature != nil {
+		b = append(b, cc.signature.ToBytes()...)
+	}
+	return b
+}
+
+func (cc CommitCert) String() string {
+	return fmt.Sprintf("CommitCert{ id: %d, view: %d, hash: %.6s }", cc.id, cc.view, cc.blockHash)
+}
+
+// commitDigest returns the hash that is signed by a commit certificate.
+// The digest is separated from the block hash so that a commit certificate cannot be mistaken for a vote.
+func commitDigest(view View, blockHash Hash) Hash {
+	h := sha256.New()
+	_, _ = h.Write([]byte("commit"))
+	_, _ = h.Write(view.ToBytes())
+	_, _ = h.Write(blockHash[:])
+	var digest Hash
+	h.Sum(digest[:0])
+	return digest
+}
+
+// CreateCommitCert signs a commit certificate for the given block.
+func CreateCommitCert(signer CryptoImpl, id hotstuff.ID, block *Block) (cert CommitCert, err error) {
+	sig, err := signer.Sign(commitDigest(block.View(), block.Hash()))
+	if err != nil {
+		return CommitCert{}, err
+	}
+	return NewCommitCert(id, block.View(), block.Hash(), sig), nil
+}
+
+// VerifyCommitCert verifies the signature of a single commit certificate.
+func VerifyCommitCert(verifier CryptoImpl, cert CommitCert) bool {
+	if cert.signature == nil || cert.signature.Signer() != cert.id {
+		return false
+	}
+	return verifier.Verify(cert.signature, commitDigest(cert.view, cert.blockHash))
+}
+
+// VerifyCommitCerts verifies that the commit certificates were signed by at least quorumSize distinct replicas,
+// and that they all attest to the same committed block. It returns the hash of the committed block.
+func VerifyCommitCerts(verifier CryptoImpl, certs []CommitCert, quorumSize int) (blockHash Hash, err error) {
+	if len(certs) == 0 {
+		return Hash{}, fmt.Errorf("no commit certificates")
+	}
+	signers := make(map[hotstuff.ID]struct{})
+	for _, cert := range certs {
+		if cert.blockHash != certs[0].blockHash || cert.view != certs[0].view {
+			return Hash{}, fmt.Errorf("commit certificate from replica %d attests to a different block: %v", cert.id, cert)
+		}
+		if !VerifyCommitCert(verifier, cert) {
+			return Hash{}, fmt.Errorf("invalid commit certificate from replica %d", cert.id)
+		}
+		signers[cert.id] = struct{}{}
+	}
+	if len(signers) < quorumSize {
+		return Hash{}, fmt.Errorf("commit certificates from %d replicas, need %d", len(signers), quorumSize)
+	}
+	return certs[0].blockHash, nil
+}
+
+// FinalityCert proves that a quorum of replicas committed a block.
+// It consists of the commit certificates of the replicas, and is a portable proof of finality
+// that can be verified by an external party using VerifyFinalityCert.
+type FinalityCert struct {
+	certs []CommitCert
+}
+
+// NewFinalityCert returns a new finality certificate consisting of the given commit certificates.
+func NewFinalityCert(certs []CommitCert) FinalityCert {
+	return FinalityCert{certs}
+}
+
+// CommitCerts returns the commit certificates of the finality certificate.
+func (fc FinalityCert) CommitCerts() []CommitCert {
+	return fc.certs
+}
+
+// View returns the view of the finalized block.
+func (fc FinalityCert) View() View {
+	if len(fc.certs) == 0 {
+		return 0
+	}
+	return fc.certs[0].view
+}
+
+// BlockHash returns the hash of the finalized block.
+func (fc FinalityCert) BlockHash() Hash {
+	if len(fc.certs) == 0 {
+		return Hash{}
+	}
+	return fc.certs[0].blockHash
+}
+
+func (fc FinalityCert) String() string {
+	return fmt.Sprintf("FinalityCert{ view: %d, hash: %.6s, signers: %d }", fc.View(), fc.BlockHash(), len(fc.certs))
+}
+
+// VerifyFinalityCert verifies that the finality certificate consists of valid commit certificates
+// from at least quorumSize distinct replicas, all attesting to the same committed block.
+func VerifyFinalityCert(verifier CryptoImpl, fc FinalityCert, quorumSize int) error {
+	_, err := VerifyCommitCerts(verifier, fc.certs, quorumSize)
+	return err
+}
@@ -1,8 +1,12 @@
 package consensus
 
 import (
+	"context"
 	"fmt"
-	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/modules"
 )
 
 // Rules is the minimum interface that a consensus implementations must implement.
@@ -25,24 +29,69 @@ type ProposeRuler interface {
 	ProposeRule(cert SyncInfo, cmd Command) (proposal ProposeMsg, ok bool)
 }
 
+// CommandRuler is an optional interface that adds a NeedsCommand method.
+// This allows implementors to propose blocks without a command, such as the phases of a command that has been prepared.
+type CommandRuler interface {
+	// NeedsCommand returns false if the proposal that extends the sync info must not carry a command.
+	// The proposal is then created with an empty command, without taking a command from the command queue.
+	NeedsCommand(cert SyncInfo) bool
+}
+
+// Checkpointer is an optional interface that adds a Checkpoint method.
+// This allows implementors to update their protocol state, such as the locked block,
+// when the replica is forced to commit up to a checkpoint.
+type Checkpointer interface {
+	// Checkpoint is called with the checkpoint block after it has been committed.
+	Checkpoint(block *Block)
+}
+
+// Locker is an optional interface that adds a LockedBlock method.
+// This allows implementors to expose their locked block for diagnostics.
+type Locker interface {
+	// LockedBlock returns the currently locked block.
+	LockedBlock() *Block
+}
+
 // consensusBase provides a default implementation of the Consensus interface
 // for implementations of the ConsensusImpl interface.
 type consensusBase struct {
 	impl Rules
 	mods *Modules
 
-	lastVote View
+	lastVote     View
+	lastVoteCert PartialCert            // the vote sent in the lastVote view, resent if the leader resends its proposal
+	saved        SafetyState            // the safety state that was last stored in the StateStore
+	lastProposal time.Time              // the time of this replica's latest proposal
+	unsigned     []Hash                 // the proposals that this replica has made since it last signed a proposal
+	proposals    map[View]*Block        // the first proposal received in each view after the committed block
+	unsignedFrom map[hotstuff.ID][]Hash // the unsigned proposals received from each replica since its last signed proposal
+	voted        map[Hash]View          // the blocks that this replica has voted for after the committed block
+
+	attestations  map[Hash][]CommitCert // commit certificates received in votes, by committed block
+	finality      FinalityCert          // the newest finality certificate known to this replica
+	finalizedView View                  // the view of the newest block for which a FinalizedEvent was emitted
 
-	mut   sync.Mutex
-	bExec *Block
+	mut        timedMutex
+	bExec      *Block
+	execErrors map[Hash]execError // the errors of committed blocks that failed execution, within the retention window
+	pins       []PinnedCheckpoint // the pinned checkpoints, in ascending order of view
+
+	speculation *speculation      // the proposal that is held back until a quorum of NewView messages has arrived
+	newViews    map[View]idSetMap // the senders of NewView messages for the current and later views
 }
 
 // New returns a new Consensus instance based on the given Rules implementation.
 func New(impl Rules) Consensus {
 	return &consensusBase{
-		impl:     impl,
-		lastVote: 0,
-		bExec:    GetGenesis(),
+		impl:         impl,
+		lastVote:     0,
+		proposals:    make(map[View]*Block),
+		unsignedFrom: make(map[hotstuff.ID][]Hash),
+		voted:        make(map[Hash]View),
+		attestations: make(map[Hash][]CommitCert),
+		bExec:        GetGenesis(),
+		execErrors:   make(map[Hash]execError),
+		newViews:     make(map[View]idSetMap),
 	}
 }
 
@@ -52,6 +101,48 @@ func (cs *consensusBase) CommittedBlock() *Block {
 	return cs.bExec
 }
 
+// LockedBlock returns the block that is locked by the Rules implementation,
+// or nil if the implementation does not have a locked block.
+func (cs *consensusBase) LockedBlock() *Block {
+	if locker, ok := cs.impl.(Locker); ok {
+		return locker.LockedBlock()
+	}
+	return nil
+}
+
+// execError is the error of a committed block that failed execution.
+type execError struct {
+	view View
+	err  error
+}
+
+// ExecError returns the error that was recorded when the command of the block failed execution.
+func (cs *consensusBase) ExecError(block Hash) error {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+	return cs.execErrors[block].err
+}
+
+// pruneExecErrors removes the errors of the blocks below the given view.
+func (cs *consensusBase) pruneExecErrors(view View) {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+	for hash, e := range cs.execErrors {
+		if e.view < view {
+			delete(cs.execErrors, hash)
+		}
+	}
+}
+
+// CommitCert returns a commit certificate for the most recently committed block, signed by this replica.
+func (cs *consensusBase) CommitCert() (CommitCert, error) {
+	committed := cs.CommittedBlock()
+	if committed.View() == 0 {
+		return CommitCert{}, fmt.Errorf("no block has been committed")
+	}
+	return CreateCommitCert(cs.mods.Crypto(), cs.mods.ID(), committed)
+}
+
 func (cs *consensusBase) InitConsensusModule(mods *Modules, opts *OptionsBuilder) {
 	cs.mods = mods
 	if mod, ok := cs.impl.(Module); ok {
@@ -60,19 +151,80 @@ func (cs *consensusBase) InitConsensusModule(mods *Modules, opts *OptionsBuilder
 	cs.mods.EventLoop().RegisterHandler(ProposeMsg{}, func(event interface{}) {
 		cs.OnPropose(event.(ProposeMsg))
 	})
+	cs.mods.EventLoop().RegisterObserver(VoteMsg{}, func(event interface{}) {
+		cs.onAttestation(event.(VoteMsg))
+	})
+	cs.mods.EventLoop().RegisterObserver(NewViewMsg{}, func(event interface{}) {
+		cs.onNewView(event.(NewViewMsg))
+	})
+	cs.mods.EventLoop().RegisterHandler(DecideMsg{}, func(event interface{}) {
+		cs.onDecide(event.(DecideMsg))
+	})
+}
+
+// InitModule enables the instrumentation of the state lock if the ShouldInstrumentLocks option is set.
+// This must happen after the options have been set, and before the lock is used.
+func (cs *consensusBase) InitModule(_ *modules.Modules) {
+	cs.mut.enabled = cs.mods.Options().ShouldInstrumentLocks()
+	cs.loadCheckpoints()
+	cs.loadState()
+}
+
+// LockStats returns the contention measurements of the lock that guards the committed state,
+// recorded since the previous call.
+func (cs *consensusBase) LockStats() LockStats {
+	return cs.mut.reset()
 }
 
 // StopVoting ensures that no voting happens in a view earlier than `view`.
 func (cs *consensusBase) StopVoting(view View) {
 	if cs.lastVote < view {
 		cs.lastVote = view
+		if err := cs.saveState(); err != nil {
+			cs.mods.Logger().Warnf("Failed to save the safety state: %v", err)
+		}
 	}
 }
 
 // Propose creates a new proposal.
+// If a quorum of replicas are congested, the proposal is delayed by the CongestionDelay setting.
+// The proposal is also delayed until the MinProposalInterval has passed since the previous proposal.
+// A delayed proposal is dropped if the view changes in the meantime, so the delay never holds back a view change.
 func (cs *consensusBase) Propose(cert SyncInfo) {
+	var delay time.Duration
+	if d := cs.mods.Options().CongestionDelay(); d > 0 && cs.mods.votingMachine.Congested() {
+		cs.mods.Logger().Debugf("Propose: replicas are congested, delaying proposal by %v", d)
+		delay = d
+	}
+	if interval := cs.mods.Options().MinProposalInterval(); interval > 0 && !cs.lastProposal.IsZero() {
+		if wait := interval - time.Since(cs.lastProposal); wait > delay {
+			delay = wait
+		}
+	}
+	if delay <= 0 {
+		cs.propose(cert)
+		return
+	}
+
+	view := cs.mods.Synchronizer().View()
+	time.AfterFunc(delay, func() {
+		cs.mods.EventLoop().AddEvent(func() {
+			// only propose if we are still in the same view
+			if cs.mods.Synchronizer().View() == view {
+				cs.propose(cert)
+			}
+		})
+	})
+}
+
+func (cs *consensusBase) propose(cert SyncInfo) {
 	cs.mods.Logger().Debug("Propose")
 
+	if err := cs.checkState(); err != nil {
+		cs.mods.Logger().Debugf("Propose: %v", err)
+		return
+	}
+
 	qc, ok := cert.QC()
 	if ok {
 		// tell the acceptor that the previous proposal succeeded.
@@ -81,33 +233,62 @@ func (cs *consensusBase) Propose(cert SyncInfo) {
 			cs.mods.Logger().Errorf("Could not find block for QC: %s", qc)
 			return
 		}
-		cs.mods.Acceptor().Proposed(qcBlock.Command())
+		cs.proposed(qcBlock)
+		cs.mods.EmitEvent(CertifiedEvent{Block: qcBlock})
 	}
 
-	cmd, ok := cs.mods.CommandQueue().Get(cs.mods.Synchronizer().ViewContext())
-	//fmt.Println("Command", cmd, "Bool", ok)
+	var cmd Command
+	if ruler, ok := cs.impl.(CommandRuler); !ok || ruler.NeedsCommand(cert) {
+		cmd, ok = cs.mods.CommandQueue().Get(cs.mods.Synchronizer().ViewContext())
+		//fmt.Println("Command", cmd, "Bool", ok)
+		if !ok {
+			cs.mods.Logger().Debug("Propose: No command")
+			return
+		}
+	}
+
+	proposal, ok := cs.createProposal(cert, cmd)
 	if !ok {
-		cs.mods.Logger().Debug("Propose: No command")
 		return
 	}
 
-	var proposal ProposeMsg
+	if cs.shouldSpeculate(cert) {
+		cs.mods.Logger().Debugf("Propose: holding speculative proposal %.8s until a quorum of NewView messages has arrived", proposal.Block.Hash())
+		cs.speculation = &speculation{cert: cert, cmd: cmd, proposal: proposal}
+		cs.resolveSpeculation()
+		return
+	}
+
+	cs.sendProposal(proposal)
+}
+
+// createProposal creates a proposal for the command that extends the QC of the sync info.
+func (cs *consensusBase) createProposal(cert SyncInfo, cmd Command) (proposal ProposeMsg, ok bool) {
+	qc, _ := cert.QC()
 	if proposer, ok := cs.impl.(ProposeRuler); ok {
 		proposal, ok = proposer.ProposeRule(cert, cmd)
 		if !ok {
 			cs.mods.Logger().Debug("Propose: No block")
-			return
+			return proposal, false
 		}
 	} else {
+		parent := cs.mods.Synchronizer().LeafBlock()
+		for _, dummy := range cs.dummyBlocks(qc, cs.mods.Synchronizer().View()) {
+			cs.mods.Logger().Debugf("Propose: inserting dummy block: %v", dummy)
+			cs.mods.BlockChain().Store(dummy)
+			parent = dummy
+		}
+		var block *Block
+		if threshold := cs.mods.Options().PayloadRefThreshold(); threshold > 0 && len(cmd) > threshold {
+			// the replicas fetch the command from the leader when they need it.
+			ref := cs.mods.BlockChain().StorePayload(cmd)
+			block = NewPayloadRefBlock(parent.Hash(), qc, ref, cs.mods.Synchronizer().View(), cs.mods.ID())
+		} else {
+			block = NewBlock(parent.Hash(), qc, cmd, cs.mods.Synchronizer().View(), cs.mods.ID())
+		}
 		proposal = ProposeMsg{
-			ID: cs.mods.ID(),
-			Block: NewBlock(
-				cs.mods.Synchronizer().LeafBlock().Hash(),
-				qc,
-				cmd,
-				cs.mods.Synchronizer().View(),
-				cs.mods.ID(),
-			),
+			ID:    cs.mods.ID(),
+			Block: block,
 		}
 
 		if aggQC, ok := cert.AggQC(); ok && cs.mods.Options().ShouldUseAggQC() {
@@ -115,20 +296,110 @@ func (cs *consensusBase) Propose(cert SyncInfo) {
 		}
 	}
 
+	if beacon := cs.mods.Beacon(); beacon != nil {
+		value, ok := beacon.Randomness(cs.mods.Synchronizer().ViewContext())
+		if !ok {
+			cs.mods.Logger().Debug("Propose: no beacon value")
+			return proposal, false
+		}
+		proposal.Block = proposal.Block.WithBeacon(value)
+	}
+
+	if cs.mods.Options().ShouldCommitCommandRoot() {
+		cmds, ok := cs.mods.splitCommands(cmd)
+		if !ok {
+			cs.mods.Logger().Debug("Propose: malformed command")
+			return proposal, false
+		}
+		proposal.Block = proposal.Block.WithCommandRoot(CommandRoot(cmds))
+	}
+
+	if cs.mods.Options().ShouldIncludeQCSigners() {
+		proposal.Signers = proposal.Block.QuorumCert().Signers()
+	}
+
+	if cs.mods.Options().ShouldUseFetchProofs() {
+		hash := proposal.Block.Hash()
+		if len(cs.unsigned)+1 >= cs.mods.Options().ProposalSignInterval() {
+			// the signature also covers the unsigned proposals since the last signed proposal.
+			sig, err := cs.mods.Crypto().Sign(ProposerRunDigest(append(cs.unsigned, hash)))
+			if err != nil {
+				cs.mods.Logger().Errorf("Propose: failed to sign block: %v", err)
+				return proposal, false
+			}
+			proposal.ProposerSig = sig
+			proposal.ProposerRun = cs.unsigned
+			cs.unsigned = nil
+		} else {
+			cs.unsigned = append(cs.unsigned, hash)
+		}
+	}
+
+	if fc := cs.finality; cs.mods.Options().ShouldFinalizeCommits() && fc.View() > 0 {
+		proposal.FinalityCert = &fc
+	}
+
+	if cs.bundlesViewChanges() {
+		proposal.ViewChange, _ = bundleViewChange(cert, proposal.Block)
+	}
+	return proposal, true
+}
+
+// sendProposal sends the proposal to the other replicas, and votes for it.
+func (cs *consensusBase) sendProposal(proposal ProposeMsg) {
 	fmt.Println("The proposal: ", proposal)
 
 	cs.mods.BlockChain().Store(proposal.Block)
 
+	cs.lastProposal = time.Now()
 	cs.mods.Configuration().Propose(proposal)
+	if retry := cs.mods.Options().LeaderRetryTimeout(); retry > 0 {
+		cs.scheduleResend(proposal, retry)
+	}
 	// self vote
 	cs.OnPropose(proposal)
 }
 
+// scheduleResend resends the proposal after the retry timeout if it has not been certified by then,
+// and the leader is still in the view of the proposal.
+func (cs *consensusBase) scheduleResend(proposal ProposeMsg, retry time.Duration) {
+	time.AfterFunc(retry, func() {
+		cs.mods.EventLoop().AddEvent(func() {
+			block := proposal.Block
+			if cs.mods.Synchronizer().View() != block.View() || cs.mods.Synchronizer().HighQC().View() >= block.View() {
+				return
+			}
+			cs.mods.Logger().Debugf("Propose: no quorum of votes for %.8s within %v, resending proposal", block.Hash(), retry)
+			cs.mods.Configuration().Propose(proposal)
+			cs.scheduleResend(proposal, retry)
+		})
+	})
+}
+
 func (cs *consensusBase) OnPropose(proposal ProposeMsg) {
 	cs.mods.Logger().Debugf("OnPropose: %v", proposal.Block)
 
 	block := proposal.Block
 
+	// a proposal for a view that is already decided, such as a replayed or stale proposal,
+	// can never be voted for or committed, so it is rejected before any verification is done.
+	if committed := cs.CommittedBlock(); block.View() <= committed.View() {
+		cs.mods.Logger().Debugf("OnPropose: view %d is already decided (committed view %d)", block.View(), committed.View())
+		return
+	}
+
+	if cs.lastVote == block.View() && cs.lastVoteCert.BlockHash() == block.Hash() && proposal.ID != cs.mods.ID() {
+		// the leader resent its proposal because it did not receive a quorum of votes, so our vote may have been lost.
+		cs.mods.Logger().Debugf("OnPropose: resending vote for %.8s", block.Hash())
+		cs.sendVote(block.View(), cs.lastVoteCert)
+		return
+	}
+
+	if cs.mods.Options().ShouldDedupProposals() && cs.isDuplicate(block) {
+		cs.mods.Logger().Debugf("OnPropose: ignoring duplicate proposal %.8s", block.Hash())
+		return
+	}
+
 	if cs.mods.Options().ShouldUseAggQC() && proposal.AggregateQC != nil {
 		ok, highQC := cs.mods.Crypto().VerifyAggregateQC(*proposal.AggregateQC)
 		if !ok {
@@ -147,7 +418,32 @@ func (cs *consensusBase) OnPropose(proposal ProposeMsg) {
 		return
 	}
 
+	if proposal.Signers != nil && !equalSigners(proposal.Signers, block.QuorumCert().Signers()) {
+		cs.mods.Logger().Info("OnPropose: signer set does not match QC")
+		return
+	}
+
+	if cs.mods.Options().ShouldUseFetchProofs() && !cs.verifyProposerSig(proposal) {
+		cs.mods.Logger().Info("OnPropose: invalid proposer signature")
+		return
+	}
+
+	if cs.bundlesViewChanges() {
+		if err := cs.verifyViewChange(proposal); err != nil {
+			cs.mods.Logger().Infof("OnPropose: %v", err)
+			return
+		}
+	}
+
+	if !proposal.Deferred && cs.deferProposal(proposal) {
+		return
+	}
+
 	defer cs.mods.Synchronizer().AdvanceView(NewSyncInfo().WithQC(block.QuorumCert()))
+	if bundle := proposal.ViewChange; bundle != nil && cs.bundlesViewChanges() {
+		// a replica that missed the view change enters the view of the proposal before voting.
+		cs.mods.Synchronizer().AdvanceView(NewSyncInfo().WithTC(bundle.TC))
+	}
 	// ensure the block came from the leader.
 	if proposal.ID != cs.mods.LeaderRotation().GetLeader(block.View()) {
 		fmt.Println("proposal.ID", proposal.ID, "cs.GetLeader(block.View())", cs.mods.LeaderRotation().GetLeader(block.View()))
@@ -155,6 +451,42 @@ func (cs *consensusBase) OnPropose(proposal ProposeMsg) {
 		return
 	}
 
+	if cs.mods.Beacon() != nil && !cs.verifyBeacon(block) {
+		cs.mods.Logger().Info("OnPropose: invalid beacon value")
+		return
+	}
+
+	cs.mods.EmitEvent(ProposalReceivedEvent{ID: proposal.ID, Block: block})
+	cs.detectEquivocation(proposal)
+
+	if fc := proposal.FinalityCert; fc != nil && cs.mods.Options().ShouldFinalizeCommits() && fc.View() > cs.finality.View() {
+		// an invalid finality certificate does not make the proposal itself invalid.
+		if err := VerifyFinalityCert(cs.mods.Crypto(), *fc, cs.mods.Configuration().QuorumSize()); err != nil {
+			cs.mods.Logger().Infof("OnPropose: invalid finality certificate: %v", err)
+		} else {
+			cs.finalize(*fc)
+		}
+	}
+
+	// recreate the dummy blocks that the leader inserted between the proposed block and its QC block, if any.
+	// When dummy blocks are enabled, a block that does not extend its QC block must extend exactly
+	// the dummy blocks required by the policy; any other parent could hide a conflicting block.
+	if block.Parent() != block.QuorumCert().BlockHash() && cs.mods.Options().DummyPolicy() != NoDummies {
+		dummies := cs.dummyBlocks(block.QuorumCert(), block.View())
+		if len(dummies) == 0 || dummies[len(dummies)-1].Hash() != block.Parent() {
+			cs.mods.Logger().Info("OnPropose: block does not extend its QC block through the expected dummy blocks")
+			return
+		}
+		for _, dummy := range dummies {
+			cs.mods.BlockChain().Store(dummy)
+		}
+	}
+
+	if err := cs.checkPinned(block); err != nil {
+		cs.mods.Logger().Infof("OnPropose: %v", err)
+		return
+	}
+
 	if !cs.impl.VoteRule(proposal) {
 		cs.mods.Logger().Info("OnPropose: Block not voted for")
 		return
@@ -164,19 +496,54 @@ func (cs *consensusBase) OnPropose(proposal ProposeMsg) {
 		if !ok {
 			cs.mods.Logger().Info("OnPropose: Failed fetching blockhash")
 		}
-		cs.mods.Acceptor().Proposed(qcBlock.Command())
+		cs.proposed(qcBlock)
+		cs.mods.EmitEvent(CertifiedEvent{Block: qcBlock})
+	}
+
+	// the command must be known before it can be accepted.
+	resolved, ok := cs.resolve(block)
+	if !ok {
+		cs.mods.Logger().Info("OnPropose: failed to resolve the payload of the block")
+		return
 	}
+	block = resolved
 
-	if !cs.mods.Acceptor().Accept(block.Command()) {
+	if !cs.withinLimits(block) {
+		cs.mods.Logger().Info("OnPropose: block exceeds the proposal limits")
+		return
+	}
+
+	if !cs.checkCommandRoot(block) {
+		cs.mods.Logger().Info("OnPropose: command root does not match the command")
+		return
+	}
+
+	if !cs.accept(block) {
 		cs.mods.Logger().Info("OnPropose: command not accepted")
 		return
 	}
 
 	cs.mods.BlockChain().Store(block)
+	if cs.mods.Options().ShouldUseFetchProofs() {
+		cs.trackProposerRun(proposal)
+	}
 
 	defer func() {
-		if b := cs.impl.CommitRule(block); b != nil {
+		b := cs.impl.CommitRule(block)
+		// the commit rule may have moved the locked block.
+		if err := cs.saveState(); err != nil {
+			cs.mods.Logger().Warnf("OnPropose: failed to save the safety state: %v", err)
+		}
+		if b != nil {
 			fmt.Println("Block was committed")
+			if locked := cs.LockedBlock(); locked != nil && b.View() > locked.View() {
+				cs.mods.InvariantViolation("committing block %.8s at view %d, which is newer than the locked block %.8s at view %d",
+					b.Hash(), b.View(), locked.Hash(), locked.View())
+			}
+			if cs.explicitDecisions() {
+				// the block is committed when the decision of the leader arrives.
+				return
+			}
 			cs.commit(b)
 		}
 	}()
@@ -186,6 +553,11 @@ func (cs *consensusBase) OnPropose(proposal ProposeMsg) {
 		return
 	}
 
+	if cs.mods.ReadOnly() {
+		cs.mods.Logger().Debug("OnPropose: replica is read-only, not voting")
+		return
+	}
+
 	pc, err := cs.mods.Crypto().CreatePartialCert(block)
 	if err != nil {
 		cs.mods.Logger().Error("OnPropose: failed to sign vote: ", err)
@@ -193,45 +565,594 @@ func (cs *consensusBase) OnPropose(proposal ProposeMsg) {
 	}
 
 	cs.lastVote = block.View()
+	cs.lastVoteCert = pc
+	if cs.mods.Options().ShouldDedupProposals() {
+		cs.voted[block.Hash()] = block.View()
+	}
+
+	if cs.mods.Options().ShouldEmitVoteEvents() {
+		leaderID := cs.mods.LeaderRotation().GetLeader(block.View())
+		cs.mods.EmitEvent(VoteSentEvent{ID: cs.mods.ID(), Leader: leaderID, View: block.View(), BlockHash: block.Hash()})
+	}
+
+	// the vote must not be sent unless it is remembered after a restart, or the replica could vote twice in the view.
+	if err := cs.saveState(); err != nil {
+		cs.mods.Logger().Errorf("OnPropose: failed to save the safety state, not voting: %v", err)
+		return
+	}
+	cs.sendVote(block.View(), pc)
+}
 
-	leaderID := cs.mods.LeaderRotation().GetLeader(cs.lastVote) //removed +1, no difference. Added -1
-	if leaderID == cs.mods.ID() {
-		go cs.mods.EventLoop().AddEvent(VoteMsg{ID: cs.mods.ID(), PartialCert: pc})
+// sendVote sends the vote to the leader of the view.
+func (cs *consensusBase) sendVote(view View, pc PartialCert) {
+	// the vote is sent to the leader, or to the relay of this replica's region if votes are relayed.
+	targetID := cs.mods.VotingMachine().voteTarget(view)
+	if targetID == cs.mods.ID() {
+		go cs.mods.EventLoop().AddEvent(VoteMsg{
+			ID:          cs.mods.ID(),
+			PartialCert: pc,
+			Congested:   cs.mods.Congested(),
+			CommitCert:  cs.mods.CommitAttestation(),
+		})
 		return
-	} /* else {
-		cs.mods.Logger().Info("LeaderID is NOT cs.mods.ID")
-	} */
+	}
 
-	leader, ok := cs.mods.Configuration().Replica(leaderID)
+	target, ok := cs.mods.Configuration().Replica(targetID)
 	if !ok {
-		cs.mods.Logger().Warnf("Replica with ID %d was not found!", leaderID)
+		cs.mods.Logger().Warnf("Replica with ID %d was not found!", targetID)
 		return
 	}
 
-	leader.Vote(pc)
+	target.Vote(pc)
 }
 
 func (cs *consensusBase) commit(block *Block) {
+	if !cs.hasDistinctProposers(block) {
+		cs.mods.Logger().Infof("Postponing commit of block %.8s: too few distinct proposers in the last %d blocks",
+			block.Hash(), cs.mods.Options().ProposerWindow())
+		return
+	}
+
 	cs.mut.Lock()
 	// can't recurse due to requiring the mutex, so we use a helper instead.
-	cs.commitInner(block)
+	ok := cs.commitInner(block, time.Now())
+	if ok {
+		cs.pinCheckpoint(block)
+	}
 	cs.mut.Unlock()
+	if !ok {
+		// the blocks that were not executed must not be pruned.
+		return
+	}
 
 	// prune the blockchain and handle forked blocks
 	forkedBlocks := cs.mods.BlockChain().PruneToHeight(block.View())
 	for _, block := range forkedBlocks {
+		if block.IsDummy() {
+			continue
+		}
 		cs.mods.ForkHandler().Fork(block)
 	}
+	if window := cs.mods.Options().RetentionWindow(); window > 0 && block.View() > window {
+		cs.mods.BlockChain().Prune(block.View() - window)
+		cs.pruneExecErrors(block.View() - window)
+	}
+
+	if cs.mods.Options().ShouldFinalizeCommits() {
+		cs.emitFinalized()
+	}
+}
+
+// hasDistinctProposers returns true if the last blocks of the chain that ends with the given block
+// were proposed by at least MinDistinctProposers distinct replicas, or if the policy is disabled.
+// Dummy blocks and the genesis block are not counted.
+func (cs *consensusBase) hasDistinctProposers(block *Block) bool {
+	min := cs.mods.Options().MinDistinctProposers()
+	if min <= 0 {
+		return true
+	}
+	proposers := make(map[hotstuff.ID]struct{})
+	for i := 0; i < cs.mods.Options().ProposerWindow() && block.View() > 0; {
+		if !block.IsDummy() {
+			proposers[block.Proposer()] = struct{}{}
+			i++
+		}
+		parent, ok := cs.mods.BlockChain().LocalGet(block.Parent())
+		if !ok {
+			break
+		}
+		block = parent
+	}
+	return len(proposers) >= min
+}
+
+// verifyProposerSig verifies the proposer's signature of the proposed block, and of the proposer's unsigned blocks
+// that the signature also covers. If the leaders only sign some of their proposals, a proposal without a signature
+// is accepted unless the proposer has already made ProposalSignInterval-1 unsigned proposals since its last signed one.
+func (cs *consensusBase) verifyProposerSig(proposal ProposeMsg) bool {
+	interval := cs.mods.Options().ProposalSignInterval()
+	if proposal.ProposerSig == nil {
+		unsigned := cs.unsignedFrom[proposal.Block.Proposer()]
+		for _, hash := range unsigned {
+			if hash == proposal.Block.Hash() {
+				// the proposal was resent.
+				return true
+			}
+		}
+		return len(unsigned)+1 < interval
+	}
+	if len(proposal.ProposerRun) > 0 && len(proposal.ProposerRun)+1 > interval {
+		return false
+	}
+	return VerifyInclusionProof(cs.mods.Crypto(), proposal.Block, proposerProof(proposal))
+}
+
+// trackProposerRun stores the inclusion proofs of the proposed block and of the unsigned blocks that its signature
+// covers, or remembers the block as unsigned if the proposal was not signed.
+func (cs *consensusBase) trackProposerRun(proposal ProposeMsg) {
+	block := proposal.Block
+	if proposal.ProposerSig == nil {
+		unsigned := cs.unsignedFrom[block.Proposer()]
+		for _, hash := range unsigned {
+			if hash == block.Hash() {
+				return
+			}
+		}
+		cs.unsignedFrom[block.Proposer()] = append(unsigned, block.Hash())
+		return
+	}
+	proof := proposerProof(proposal)
+	// the unsigned blocks can now be fetched by other replicas before they are certified.
+	for _, hash := range proposal.ProposerRun {
+		cs.mods.BlockChain().StoreProof(hash, proof)
+	}
+	cs.mods.BlockChain().StoreProof(block.Hash(), proof)
+	delete(cs.unsignedFrom, block.Proposer())
 }
 
-// recursive helper for commit
-func (cs *consensusBase) commitInner(block *Block) {
+// proposerProof returns the inclusion proof that the proposer's signature of the proposal provides.
+func proposerProof(proposal ProposeMsg) InclusionProof {
+	if len(proposal.ProposerRun) == 0 {
+		return NewProposerProof(proposal.ProposerSig)
+	}
+	run := append(append([]Hash(nil), proposal.ProposerRun...), proposal.Block.Hash())
+	return NewProposerRunProof(proposal.ProposerSig, run)
+}
+
+// resolve returns the block with its command included, fetching the command if the block refers to it.
+func (cs *consensusBase) resolve(block *Block) (*Block, bool) {
+	if block.IsResolved() {
+		return block, true
+	}
+	ref, _ := block.PayloadRef()
+	cmd, ok := cs.mods.BlockChain().GetPayload(ref)
+	if !ok {
+		return nil, false
+	}
+	return block.WithPayload(cmd)
+}
+
+// accept asks the acceptor whether the command in the block should be accepted.
+func (cs *consensusBase) accept(block *Block) bool {
+	if change, ok := cs.parameterChange(block); ok {
+		// the change must not affect views that may already have started.
+		return change.View > block.View()
+	}
+	if acceptor, ok := cs.mods.Acceptor().(BlockAcceptor); ok {
+		return acceptor.AcceptBlock(block)
+	}
+	if acceptor, ok := cs.mods.Acceptor().(ViewAcceptor); ok {
+		return acceptor.AcceptInView(block.Command(), block.View())
+	}
+	return cs.mods.Acceptor().Accept(block.Command())
+}
+
+// checkCommandRoot returns true if the Merkle root that the block commits to matches the client commands of the block.
+// If the ShouldCommitCommandRoot option is set, blocks without a root are rejected.
+func (cs *consensusBase) checkCommandRoot(block *Block) bool {
+	root, ok := block.CommandRoot()
+	if !ok {
+		return !cs.mods.Options().ShouldCommitCommandRoot()
+	}
+	cmds, ok := cs.mods.splitCommands(block.Command())
+	return ok && CommandRoot(cmds) == root
+}
+
+// withinLimits returns true if the command of the block does not exceed the MaxProposalBytes and MaxProposalCommands settings.
+func (cs *consensusBase) withinLimits(block *Block) bool {
+	if max := cs.mods.Options().MaxProposalBytes(); max > 0 && len(block.Command()) > max {
+		return false
+	}
+	if max := cs.mods.Options().MaxProposalCommands(); max > 0 {
+		counter, ok := cs.mods.Acceptor().(CommandCounter)
+		if !ok {
+			cs.mods.Logger().Warn("MaxProposalCommands is set, but the acceptor cannot count commands")
+			return true
+		}
+		n, ok := counter.CountCommands(block.Command())
+		return ok && n <= max
+	}
+	return true
+}
+
+// deferProposal puts the proposal in the waiting room if the block certified by its QC has not arrived yet.
+// The proposal is released when the block is stored, which happens when the proposal for the block is reordered
+// behind this one. If the block has not arrived by the time the messages that are already queued have been handled,
+// the proposal is released anyway, and the block is fetched.
+// It returns false if the proposal should be handled immediately.
+func (cs *consensusBase) deferProposal(proposal ProposeMsg) bool {
+	hash := proposal.Block.QuorumCert().BlockHash()
+	if _, ok := cs.mods.BlockChain().LocalGet(hash); ok {
+		return false
+	}
+	if !cs.mods.WaitingRoom().Wait(hash, proposal.ID, proposal) {
+		// the proposal cannot wait, so the block is fetched right away.
+		return false
+	}
+	cs.mods.Logger().Debugf("OnPropose: waiting for QC block %.8s", hash)
+	go cs.mods.EventLoop().AddEvent(func() { cs.mods.WaitingRoom().Release(hash) })
+	return true
+}
+
+// isDuplicate returns true if the replica has already voted for a block that is identical to the given block.
+// Blocks that were received but not voted for are not duplicates, so that they can still be voted for.
+func (cs *consensusBase) isDuplicate(block *Block) bool {
+	committed := cs.CommittedBlock().View()
+	for hash, view := range cs.voted {
+		if view <= committed {
+			delete(cs.voted, hash)
+		}
+	}
+	// the hash of a block is computed from its contents when the block is created,
+	// so a block with the same hash as a block we voted for is identical to it.
+	_, ok := cs.voted[block.Hash()]
+	return ok
+}
+
+// detectEquivocation emits an EquivocationDetectedEvent if the leader has already proposed a different block in the same view.
+func (cs *consensusBase) detectEquivocation(proposal ProposeMsg) {
+	committed := cs.CommittedBlock().View()
+	for view := range cs.proposals {
+		if view <= committed {
+			delete(cs.proposals, view)
+		}
+	}
+	block := proposal.Block
+	if block.View() <= committed {
+		return
+	}
+	first, ok := cs.proposals[block.View()]
+	if !ok {
+		cs.proposals[block.View()] = block
+		return
+	}
+	if first.Hash() != block.Hash() {
+		cs.mods.Logger().Warnf("OnPropose: replica %d proposed two blocks in view %d", proposal.ID, block.View())
+		cs.mods.EmitEvent(EquivocationDetectedEvent{ID: proposal.ID, View: block.View(), First: first, Second: block})
+	}
+}
+
+// onAttestation collects the commit certificates attached to votes,
+// and forms a finality certificate once a quorum of replicas have attested to committing the same block.
+func (cs *consensusBase) onAttestation(vote VoteMsg) {
+	if !cs.mods.Options().ShouldFinalizeCommits() || vote.Deferred || vote.CommitCert == nil {
+		return
+	}
+	cert := *vote.CommitCert
+	if cert.ID() != vote.ID || cert.View() <= cs.finality.View() {
+		return
+	}
+	certs := cs.attestations[cert.BlockHash()]
+	for _, c := range certs {
+		if c.ID() == cert.ID() {
+			return
+		}
+	}
+	if !VerifyCommitCert(cs.mods.Crypto(), cert) {
+		cs.mods.Logger().Infof("OnVote(%d): invalid commit certificate: %v", vote.ID, cert)
+		return
+	}
+	certs = append(certs, cert)
+	cs.attestations[cert.BlockHash()] = certs
+	if len(certs) >= cs.mods.Configuration().QuorumSize() {
+		cs.finalize(NewFinalityCert(certs))
+	}
+}
+
+// finalize records a finality certificate that is newer than the current one.
+func (cs *consensusBase) finalize(fc FinalityCert) {
+	if fc.View() <= cs.finality.View() {
+		return
+	}
+	cs.finality = fc
+	for hash, certs := range cs.attestations {
+		if certs[0].View() <= fc.View() {
+			delete(cs.attestations, hash)
+		}
+	}
+	cs.emitFinalized()
+}
+
+// emitFinalized emits a FinalizedEvent for the newest finality certificate,
+// once the finalized block has been committed by this replica.
+func (cs *consensusBase) emitFinalized() {
+	fc := cs.finality
+	if fc.View() <= cs.finalizedView || fc.View() > cs.CommittedBlock().View() {
+		return
+	}
+	block, ok := cs.mods.BlockChain().LocalGet(fc.BlockHash())
+	if !ok {
+		return
+	}
+	cs.finalizedView = fc.View()
+	cs.mods.EmitEvent(FinalizedEvent{Block: block, Cert: fc})
+}
+
+// dummyBlocks returns the dummy blocks that should be inserted before a block proposed in the given view, if any,
+// ordered from the oldest to the newest. The first dummy block extends the block certified by the QC,
+// and the proposed block should extend the last one.
+// According to the FillViewGaps policy, a single dummy block is inserted in the preceding view
+// if the block certified by the QC is older than that. According to the FillEveryView policy,
+// a dummy block is inserted in every view between the certified block and the proposed block.
+// The certified block is never a dummy block, as dummy blocks are never voted on.
+func (cs *consensusBase) dummyBlocks(qc QuorumCert, view View) []*Block {
+	policy := cs.mods.Options().DummyPolicy()
+	if policy == NoDummies || view < 2 {
+		return nil
+	}
+	qcBlock, ok := cs.mods.BlockChain().Get(qc.BlockHash())
+	if !ok || qcBlock.IsDummy() || qcBlock.View() >= view-1 {
+		return nil
+	}
+	first := view - 1
+	if policy == FillEveryView {
+		first = qcBlock.View() + 1
+	}
+	var dummies []*Block
+	parent := qc.BlockHash()
+	for v := first; v < view; v++ {
+		dummy := NewDummyBlock(parent, qc, v)
+		dummies = append(dummies, dummy)
+		parent = dummy.Hash()
+	}
+	return dummies
+}
+
+// ForceCommit commits all blocks up to and including the block certified by the checkpoint.
+// The checkpoint and the blocks leading up to it are fetched and validated before anything is executed.
+// The blocks are then committed on the event loop, and ForceCommit waits until they have been executed,
+// or until the context is canceled.
+func (cs *consensusBase) ForceCommit(ctx context.Context, checkpoint QuorumCert) error {
+	if !cs.mods.Crypto().VerifyQuorumCert(checkpoint) {
+		return fmt.Errorf("ForceCommit: invalid checkpoint QC: %v", checkpoint)
+	}
+
+	block, ok := cs.mods.BlockChain().Get(checkpoint.BlockHash())
+	if !ok {
+		return fmt.Errorf("ForceCommit: checkpoint block %.8s not found", checkpoint.BlockHash())
+	}
+
+	newer, err := cs.extendsCommitted(block)
+	if err != nil {
+		return fmt.Errorf("ForceCommit: %w", err)
+	}
+	if !newer {
+		return nil
+	}
+	if err := cs.checkPinned(block); err != nil {
+		return fmt.Errorf("ForceCommit: %w", err)
+	}
+
+	// the blocks are committed on the event loop, since committing updates state that the event loop uses.
+	result := make(chan error, 1)
+	cs.mods.EventLoop().AddEvent(func() { result <- cs.forceCommit(block, checkpoint) })
+	return waitCommit(ctx, result)
+}
+
+// waitCommit waits for the result of a commit that was queued on the event loop.
+// If the context is canceled first, the error of the context is returned, but the commit is still
+// performed if the event loop handles it later.
+func waitCommit(ctx context.Context, result <-chan error) error {
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// extendsCommitted validates the chain from the block down to the committed block.
+// It returns false if the block is the committed block, and an error if the block does not extend the committed block,
+// or if a block of the chain has an invalid QC.
+func (cs *consensusBase) extendsCommitted(block *Block) (newer bool, err error) {
+	committed := cs.CommittedBlock()
+	if block.View() <= committed.View() {
+		if block.Hash() == committed.Hash() {
+			return false, nil
+		}
+		return false, fmt.Errorf("block at view %d is not newer than the committed block at view %d",
+			block.View(), committed.View())
+	}
+
+	current := block
+	for current.View() > committed.View() {
+		if !cs.mods.Crypto().VerifyQuorumCert(current.QuorumCert()) {
+			return false, fmt.Errorf("block %.8s has an invalid QC", current.Hash())
+		}
+		parent, ok := cs.mods.BlockChain().Get(current.Parent())
+		if !ok {
+			return false, fmt.Errorf("block %.8s not found", current.Parent())
+		}
+		current = parent
+	}
+	if current.Hash() != committed.Hash() {
+		return false, fmt.Errorf("block %.8s does not extend the committed block at view %d", block.Hash(), committed.View())
+	}
+	return true, nil
+}
+
+// CommitProven commits the blocks up to and including the newest block that the commit rule decides,
+// given the chain of blocks that ends with the block certified by the QC.
+// The QCs of the chain are verified down to the committed block before the commit rule is applied.
+// Like ForceCommit, it waits until the blocks have been executed, or until the context is canceled.
+func (cs *consensusBase) CommitProven(ctx context.Context, qc QuorumCert) error {
+	if !cs.mods.Crypto().VerifyQuorumCert(qc) {
+		return fmt.Errorf("CommitProven: invalid QC: %v", qc)
+	}
+	certified, ok := cs.mods.BlockChain().Get(qc.BlockHash())
+	if !ok {
+		return fmt.Errorf("CommitProven: block %.8s not found", qc.BlockHash())
+	}
+	newer, err := cs.extendsCommitted(certified)
+	if err != nil {
+		return fmt.Errorf("CommitProven: %w", err)
+	}
+	if !newer {
+		return nil
+	}
+
+	result := make(chan error, 1)
+	cs.mods.EventLoop().AddEvent(func() { result <- cs.commitProven(certified, qc) })
+	return waitCommit(ctx, result)
+}
+
+// commitProven applies the commit rule to the verified QCs of the chain, starting with the newest QC,
+// and commits the newest block that is decided.
+func (cs *consensusBase) commitProven(certified *Block, highQC QuorumCert) error {
+	qc := highQC
+	for certified.View() > cs.CommittedBlock().View() {
+		// as in OnDecide, the QC is applied through a block that extends the certified block.
+		carrier := NewBlock(certified.Hash(), qc, "", certified.View()+1, cs.mods.ID())
+		if decided := cs.impl.CommitRule(carrier); decided != nil {
+			if decided.View() <= cs.CommittedBlock().View() {
+				return nil
+			}
+			if err := cs.checkPinned(decided); err != nil {
+				return fmt.Errorf("CommitProven: %w", err)
+			}
+			return cs.forceCommit(decided, highQC)
+		}
+		qc = certified.QuorumCert()
+		parent, ok := cs.mods.BlockChain().Get(qc.BlockHash())
+		if !ok {
+			break
+		}
+		certified = parent
+	}
+	return fmt.Errorf("CommitProven: the chain certified by the QC for %.8s does not decide a block", highQC.BlockHash())
+}
+
+// forceCommit commits the validated checkpoint block, and updates the protocol state.
+// It returns an error if the block was not executed, for example because the commit was postponed.
+func (cs *consensusBase) forceCommit(block *Block, checkpoint QuorumCert) error {
+	if committed := cs.CommittedBlock(); block.View() <= committed.View() {
+		if block.Hash() == committed.Hash() {
+			return nil
+		}
+		return fmt.Errorf("ForceCommit: the block at view %d was committed before the checkpoint", committed.View())
+	}
+	cs.commit(block)
+	if committed := cs.CommittedBlock(); committed.Hash() != block.Hash() {
+		return fmt.Errorf("ForceCommit: checkpoint block %.8s was not executed, the committed block is at view %d",
+			block.Hash(), committed.View())
+	}
+
+	if checkpointer, ok := cs.impl.(Checkpointer); ok {
+		checkpointer.Checkpoint(block)
+	}
+	cs.StopVoting(block.View())
+	if err := cs.saveState(); err != nil {
+		cs.mods.Logger().Warnf("Failed to save the safety state: %v", err)
+	}
+	cs.mods.Synchronizer().AdvanceView(NewSyncInfo().WithQC(checkpoint))
+	return nil
+}
+
+func equalSigners(a, b []hotstuff.ID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyContinuity reports a committed block that skips views after its parent without justification.
+// A leader only proposes a block in a later view than the view after its parent if the views in between timed out,
+// in which case the block extends the highQC, and its QC certifies the parent.
+func (cs *consensusBase) verifyContinuity(parent, block *Block) {
+	if block.View() == parent.View()+1 || block.QuorumCert().BlockHash() == parent.Hash() {
+		return
+	}
+	cs.mods.InvariantViolation("committed block %.8s at view %d skips views after its parent %.8s at view %d, but its QC certifies block %.8s",
+		block.Hash(), block.View(), parent.Hash(), parent.View(), block.QuorumCert().BlockHash())
+}
+
+// recursive helper for commit.
+// It returns false if a block could not be executed because its payload could not be resolved,
+// or because it would violate the order of the committed chain, in which case its descendants are not executed either.
+func (cs *consensusBase) commitInner(block *Block, commitTime time.Time) bool {
 	if cs.bExec.View() < block.View() {
 		if parent, ok := cs.mods.BlockChain().Get(block.Parent()); ok {
-			cs.commitInner(parent)
+			if !cs.commitInner(parent, commitTime) {
+				return false
+			}
+		}
+		if block.View() <= cs.bExec.View() {
+			// the views along the committed chain must strictly increase, so this indicates a safety bug.
+			cs.mods.InvariantViolation("refusing to commit block %.8s at view %d after block %.8s at view %d",
+				block.Hash(), block.View(), cs.bExec.Hash(), cs.bExec.View())
+			return false
+		}
+		if cs.bExec.Hash() != block.Parent() {
+			cs.mods.InvariantViolation("refusing to commit block %.8s at view %d, which does not extend the committed block %.8s at view %d",
+				block.Hash(), block.View(), cs.bExec.Hash(), cs.bExec.View())
+			return false
+		} else if cs.mods.Options().ShouldVerifyViewContinuity() {
+			cs.verifyContinuity(cs.bExec, block)
+		}
+		if block.IsDummy() {
+			// dummy blocks only fill gaps in the chain, so there is nothing to execute.
+			cs.mods.Logger().Debug("SKIP: ", block)
+		} else {
+			resolved, ok := cs.resolve(block)
+			if !ok {
+				cs.mods.Logger().Warnf("Failed to resolve the payload of block %.8s, postponing its execution", block.Hash())
+				return false
+			}
+			block = resolved
+			cs.mods.BlockChain().Store(block)
+			commands := 0
+			if change, ok := cs.parameterChange(block); ok {
+				cs.mods.Logger().Infof("Parameter change from view %d: %+v", change.View, change.Parameters)
+				cs.mods.parameters.add(change)
+			} else if block.IsEmpty() && cs.mods.Options().ShouldSkipEmptyBlocks() {
+				cs.mods.Logger().Debug("SKIP EMPTY: ", block)
+			} else {
+				cs.mods.Logger().Debug("EXEC: ", block)
+				if cmds, ok := cs.mods.splitCommands(block.Command()); ok {
+					commands = len(cmds)
+				}
+				if err := cs.exec(block); err != nil {
+					cs.mods.Logger().Infof("Failed to execute command in block %.8s: %v", block.Hash(), err)
+					cs.execErrors[block.Hash()] = execError{view: block.View(), err: err}
+				}
+				if tracker, ok := cs.mods.Acceptor().(CommitTracker); ok {
+					tracker.Committed(block.Command())
+				}
+			}
+			if sink := cs.mods.CommitSink(); sink != nil {
+				sink.Committed(block)
+			}
+			if creditor, ok := cs.mods.LeaderRotation().(ProposerCreditor); ok {
+				creditor.CreditProposer(block)
+			}
+			cs.mods.EmitEvent(BlockCommittedEvent{Block: block, Commands: commands, CommitTime: commitTime, ExecTime: time.Now()})
 		}
-		cs.mods.Logger().Debug("EXEC: ", block)
-		cs.mods.Executor().Exec(block)
 		cs.bExec = block
 	}
+	return true
 }
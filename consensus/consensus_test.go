@@ -2,12 +2,27 @@ package consensus_test
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"github.com/relab/hotstuff/crypto"
+	"github.com/relab/hotstuff/crypto/bls12"
+	"github.com/relab/hotstuff/crypto/ecdsa"
 	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
+	"github.com/relab/hotstuff/store"
 	"github.com/relab/hotstuff/synchronizer"
 )
 
@@ -51,3 +66,3853 @@ func TestVote(t *testing.T) {
 		t.Error("No new view event happened")
 	}
 }
+
+// TestForceCommit checks that a replica that is far behind can catch up by committing a checkpoint.
+func TestForceCommit(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders.Build()
+
+	// run the protocol without replica 4, so that it falls behind.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	leader := network.Node(1)
+	go func() {
+		for ctx.Err() == nil {
+			if len(leader.Executed()) >= 20 {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx, 1, 2, 3)
+
+	committed := leader.Executed()
+	if len(committed) < 20 {
+		t.Fatalf("expected at least 20 committed blocks, got %d", len(committed))
+	}
+
+	checkpoint := leader.Modules().Synchronizer().HighQC()
+	replica := network.Node(4)
+	if err := testutil.ForceCommit(replica.Modules(), checkpoint); err != nil {
+		t.Fatalf("ForceCommit failed: %v", err)
+	}
+
+	executed := replica.Executed()
+	if len(executed) < len(committed) {
+		t.Fatalf("expected at least %d executed blocks, got %d", len(committed), len(executed))
+	}
+	seen := make(map[consensus.Hash]bool)
+	for i, block := range executed {
+		if seen[block.Hash()] {
+			t.Errorf("block %v was executed more than once", block)
+		}
+		seen[block.Hash()] = true
+		if i < len(committed) && block.Hash() != committed[i].Hash() {
+			t.Errorf("executed block %d: got %v, want %v", i, block, committed[i])
+		}
+	}
+	if last := executed[len(executed)-1]; last.Hash() != checkpoint.BlockHash() {
+		t.Errorf("expected the checkpoint block to be executed last, got %v", last)
+	}
+
+	// committing the same checkpoint again should not execute anything.
+	if err := testutil.ForceCommit(replica.Modules(), checkpoint); err != nil {
+		t.Errorf("ForceCommit of the committed block failed: %v", err)
+	}
+	// an older checkpoint must be refused.
+	old := committed[len(committed)/2].QuorumCert()
+	if err := testutil.ForceCommit(replica.Modules(), old); err == nil {
+		t.Error("expected ForceCommit to refuse an older checkpoint")
+	}
+	if len(replica.Executed()) != len(executed) {
+		t.Error("expected no blocks to be executed after the first ForceCommit")
+	}
+}
+
+// TestForceCommitPostponed checks that ForceCommit returns an error if the commit of the checkpoint is postponed.
+func TestForceCommitPostponed(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders[0].Options().SetMinDistinctProposers(2, 5)
+	hl := builders.Build()
+	hs := hl[0]
+	signers := hl.Signers()
+
+	// replica 2 proposed every block, so the commit is postponed.
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 2)
+	b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "b2", 2, 2)
+	hs.BlockChain().Store(b1)
+	hs.BlockChain().Store(b2)
+	if err := testutil.ForceCommit(hs, testutil.CreateQC(t, b2, signers)); err == nil {
+		t.Error("expected ForceCommit to fail when the commit is postponed")
+	}
+	if executed := network.Node(1).Executed(); len(executed) > 0 {
+		t.Errorf("expected no blocks to be executed, got %d", len(executed))
+	}
+}
+
+// TestForceCommitCanceled checks that ForceCommit returns when the context is canceled,
+// even though the event loop does not run, and that the checkpoint is committed once the event loop runs.
+func TestForceCommitCanceled(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	hs := hl[0]
+	signers := hl.Signers()
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 1)
+	hs.BlockChain().Store(b1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := hs.Consensus().ForceCommit(ctx, testutil.CreateQC(t, b1, signers)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ForceCommit to return the error of the context, got: %v", err)
+	}
+	if executed := network.Node(1).Executed(); len(executed) > 0 {
+		t.Fatalf("expected no blocks to be executed before the event loop runs, got %d", len(executed))
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	hs.EventLoop().AddEvent(func() { cancel() })
+	hs.EventLoop().Run(ctx)
+	if committed := hs.Consensus().CommittedBlock(); committed.Hash() != b1.Hash() {
+		t.Errorf("expected the checkpoint to be committed once the event loop runs, got %v", committed)
+	}
+}
+
+// TestCommitProven checks that CommitProven only commits the blocks that the commit rule decides,
+// even though the QC certifies a newer block.
+func TestCommitProven(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	hs := hl[0]
+	signers := hl.Signers()
+
+	// genesis <- b1 <- b2 <- b3 <- b4
+	genesis := consensus.GetGenesis()
+	blocks := []*consensus.Block{consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 1)}
+	for i := 2; i <= 4; i++ {
+		parent := blocks[len(blocks)-1]
+		blocks = append(blocks, consensus.NewBlock(parent.Hash(), testutil.CreateQC(t, parent, signers), consensus.Command("b"+strconv.Itoa(i)), consensus.View(i), 1))
+	}
+	for _, block := range blocks {
+		hs.BlockChain().Store(block)
+	}
+
+	// a QC for b1 does not prove that any block was committed.
+	if err := testutil.CommitProven(hs, testutil.CreateQC(t, blocks[0], signers)); err == nil {
+		t.Error("expected CommitProven to fail when no block is decided")
+	}
+	if executed := network.Node(1).Executed(); len(executed) > 0 {
+		t.Errorf("expected no blocks to be executed, got %d", len(executed))
+	}
+
+	// with the three-chain rule, a QC for b4 proves that b2 was committed, but not b3 or b4.
+	if err := testutil.CommitProven(hs, testutil.CreateQC(t, blocks[3], signers)); err != nil {
+		t.Fatalf("CommitProven failed: %v", err)
+	}
+	executed := network.Node(1).Executed()
+	if len(executed) != 2 || executed[0].Hash() != blocks[0].Hash() || executed[1].Hash() != blocks[1].Hash() {
+		t.Errorf("expected b1 and b2 to be executed, got %v", executed)
+	}
+}
+
+// failingExecutor fails every command that ends with the digit 3.
+type failingExecutor struct {
+	mut      sync.Mutex
+	executed []*consensus.Block
+}
+
+func (e *failingExecutor) Exec(block *consensus.Block) error {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	e.executed = append(e.executed, block)
+	if strings.HasSuffix(string(block.Command()), "3") {
+		return fmt.Errorf("command %s failed", block.Command())
+	}
+	return nil
+}
+
+func (e *failingExecutor) Executed() []*consensus.Block {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	return append([]*consensus.Block(nil), e.executed...)
+}
+
+// TestExecError checks that all replicas record the same outcome for commands that fail execution.
+func TestExecError(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	executors := make([]*failingExecutor, len(builders))
+	for i, builder := range builders {
+		executors[i] = &failingExecutor{}
+		builder.Register(executors[i])
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			if len(executors[0].Executed()) >= 20 {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	// only check the commands that were executed by all replicas
+	executed := executors[0].Executed()
+	for _, e := range executors[1:] {
+		if n := len(e.Executed()); n < len(executed) {
+			executed = executed[:n]
+		}
+	}
+
+	failed := 0
+	for _, block := range executed {
+		cmd := block.Command()
+		wantFail := strings.HasSuffix(string(cmd), "3")
+		if wantFail {
+			failed++
+		}
+		for i, node := range network.Nodes() {
+			err := node.Modules().Consensus().ExecError(block.Hash())
+			if wantFail && (err == nil || err.Error() != fmt.Sprintf("command %s failed", cmd)) {
+				t.Errorf("replica %d: expected command %s to fail, got %v", i+1, cmd, err)
+			}
+			if !wantFail && err != nil {
+				t.Errorf("replica %d: expected command %s to succeed, got %v", i+1, cmd, err)
+			}
+		}
+	}
+	if failed == 0 {
+		t.Errorf("no failing commands were executed by all replicas (executed %d commands)", len(executed))
+	}
+}
+
+// TestExecErrorRetention checks that the errors of blocks are forgotten once the blocks leave the retention window.
+func TestExecErrorRetention(t *testing.T) {
+	const window = 5
+	network, builders := testutil.CreateNetwork(t, 4)
+	executor := &failingExecutor{}
+	for i, builder := range builders {
+		builder.Options().SetRetentionWindow(window)
+		if i == 0 {
+			builder.Register(executor)
+		}
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			if len(executor.Executed()) >= 30 {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	cs := network.Node(1).Modules().Consensus()
+	committed := cs.CommittedBlock().View()
+	checked := 0
+	for _, block := range executor.Executed() {
+		if !strings.HasSuffix(string(block.Command()), "3") {
+			continue
+		}
+		err := cs.ExecError(block.Hash())
+		if block.View()+window < committed && err != nil {
+			t.Errorf("expected the error of block %.8s at view %d to be pruned (committed view %d)", block.Hash(), block.View(), committed)
+		}
+		if block.View() >= committed && err == nil {
+			t.Errorf("expected the error of the committed block %.8s to be kept", block.Hash())
+		}
+		checked++
+	}
+	if checked == 0 {
+		t.Error("no failing commands were executed")
+	}
+}
+
+// TestProposalSigners checks that followers can extract the signer set of a proposal's QC,
+// and that the signer set matches the replicas that voted for the block.
+func TestProposalSigners(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetShouldIncludeQCSigners()
+	}
+	hl := builders.Build()
+
+	var (
+		mut       sync.Mutex
+		voters    = make(map[consensus.Hash]map[hotstuff.ID]bool)
+		proposals []consensus.ProposeMsg
+	)
+	for _, hs := range hl {
+		hs.EventLoop().RegisterObserver(consensus.VoteMsg{}, func(event interface{}) {
+			vote := event.(consensus.VoteMsg)
+			mut.Lock()
+			defer mut.Unlock()
+			hash := vote.PartialCert.BlockHash()
+			if voters[hash] == nil {
+				voters[hash] = make(map[hotstuff.ID]bool)
+			}
+			voters[hash][vote.ID] = true
+		})
+		hs.EventLoop().RegisterObserver(consensus.ProposeMsg{}, func(event interface{}) {
+			mut.Lock()
+			defer mut.Unlock()
+			proposals = append(proposals, event.(consensus.ProposeMsg))
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	network.Run(ctx)
+
+	mut.Lock()
+	defer mut.Unlock()
+	checked := 0
+	for _, proposal := range proposals {
+		qc := proposal.Block.QuorumCert()
+		if qc.BlockHash() == consensus.GetGenesis().Hash() {
+			continue
+		}
+		if len(proposal.Signers) < hotstuff.QuorumSize(len(hl)) {
+			t.Errorf("expected at least %d signers, got %v", hotstuff.QuorumSize(len(hl)), proposal.Signers)
+		}
+		for _, id := range proposal.Signers {
+			if !qc.Signature().Participants().Contains(id) {
+				t.Errorf("signer %d is not a participant of the QC", id)
+			}
+			if !voters[qc.BlockHash()][id] {
+				t.Errorf("signer %d did not vote for block %.8s", id, qc.BlockHash())
+			}
+		}
+		checked++
+	}
+	if checked == 0 {
+		t.Error("no proposals were received")
+	}
+}
+
+// congestionMonitor reports congestion when the flag is set.
+type congestionMonitor struct {
+	flag *int32
+}
+
+func (m congestionMonitor) Congested() bool {
+	return atomic.LoadInt32(m.flag) == 1
+}
+
+// TestCongestionBackPressure checks that the leaders slow down their proposals when the replicas are congested,
+// and that they speed up again when the congestion clears.
+func TestCongestionBackPressure(t *testing.T) {
+	var congested int32
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetCongestionDelay(50 * time.Millisecond)
+		builder.Register(congestionMonitor{&congested}, synchronizer.New(testutil.FixedTimeout(500)))
+	}
+	hl := builders.Build()
+
+	var proposals int32
+	hl[0].EventLoop().RegisterObserver(consensus.ProposeMsg{}, func(_ interface{}) {
+		atomic.AddInt32(&proposals, 1)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go network.Run(ctx)
+
+	measure := func() int32 {
+		start := atomic.LoadInt32(&proposals)
+		time.Sleep(500 * time.Millisecond)
+		return atomic.LoadInt32(&proposals) - start
+	}
+
+	normal := measure()
+	atomic.StoreInt32(&congested, 1)
+	time.Sleep(100 * time.Millisecond) // let the signal reach the leaders
+	slow := measure()
+	atomic.StoreInt32(&congested, 0)
+	time.Sleep(100 * time.Millisecond)
+	recovered := measure()
+
+	if slow*2 > normal {
+		t.Errorf("expected the proposal rate to drop when congested: normal: %d, congested: %d", normal, slow)
+	}
+	if recovered <= slow*2 {
+		t.Errorf("expected the proposal rate to recover: congested: %d, recovered: %d", slow, recovered)
+	}
+}
+
+// TestCongestionSignal checks that only verified votes signal congestion,
+// and that the signals expire once the votes fall outside the vote window.
+func TestCongestionSignal(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetVoteWindow(2)
+	}
+	hl := builders.Build()
+	signers := hl.Signers()
+	hs := network.Node(1).Modules()
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 1)
+	b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "b2", 2, 2)
+	b3 := consensus.NewBlock(b2.Hash(), testutil.CreateQC(t, b2, signers), "b3", 3, 3)
+	other := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "other", 2, 2)
+	hs.BlockChain().Store(b1)
+	hs.BlockChain().Store(b2)
+	hs.BlockChain().Store(b3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		hs.Run(ctx)
+		close(stopped)
+	}()
+	congested := func() bool {
+		c := make(chan bool)
+		hs.EventLoop().AddEvent(func() { c <- hs.VotingMachine().Congested() })
+		return <-c
+	}
+	vote := func(id hotstuff.ID, block *consensus.Block, valid bool) {
+		pc, err := signers[id-1].CreatePartialCert(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !valid {
+			// the signature is for another block.
+			pc = consensus.NewPartialCert(pc.Signature(), b2.View(), b2.Hash())
+		}
+		hs.EventLoop().AddEvent(consensus.VoteMsg{ID: id, PartialCert: pc, Congested: true})
+	}
+
+	for id := hotstuff.ID(2); id <= 4; id++ {
+		vote(id, other, false)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if congested() {
+		t.Error("expected invalid votes not to signal congestion")
+	}
+
+	for id := hotstuff.ID(2); id <= 4; id++ {
+		vote(id, b2, true)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !congested() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected verified votes to signal congestion")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// advance to view 4, such that the votes for view 2 fall outside the vote window.
+	hs.EventLoop().AddEvent(consensus.NewViewMsg{ID: 3, SyncInfo: consensus.NewSyncInfo().WithQC(testutil.CreateQC(t, b3, signers))})
+	deadline = time.Now().Add(time.Second)
+	for congested() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the congestion signals of votes outside the vote window to expire")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	<-stopped
+}
+
+// TestVoteWatermark checks that votes for views at or below the committed block are rejected without fetching the block,
+// and that a vote whose view was raised above the watermark is rejected without fetching the block, since the view is signed.
+func TestVoteWatermark(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 1)
+	b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "b2", 2, 2)
+	b3 := consensus.NewBlock(b2.Hash(), testutil.CreateQC(t, b2, signers), "b3", 3, 3)
+	old := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "old", 1, 2)
+	for _, block := range []*consensus.Block{b1, b2, b3} {
+		network.Node(2).Modules().BlockChain().Store(block)
+	}
+
+	node := network.Node(1)
+	hs := node.Modules()
+	if err := testutil.ForceCommit(hs, testutil.CreateQC(t, b2, signers)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hs.Run(ctx)
+
+	send := func(pc consensus.PartialCert) {
+		done := make(chan struct{})
+		hs.EventLoop().AddEvent(consensus.VoteMsg{ID: 2, PartialCert: pc, Deferred: true})
+		hs.EventLoop().AddEvent(func() { close(done) })
+		<-done
+	}
+	vote := func(block *consensus.Block) {
+		pc, err := hl[1].Crypto().CreatePartialCert(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		send(pc)
+	}
+
+	fetches := node.Fetches()
+	vote(old)
+	if got := node.Fetches(); got != fetches {
+		t.Errorf("expected no fetch for a vote below the watermark, got %d", got-fetches)
+	}
+	pc, err := hl[1].Crypto().CreatePartialCert(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send(consensus.NewPartialCert(pc.Signature(), 10, old.Hash()))
+	if got := node.Fetches(); got != fetches {
+		t.Errorf("expected no fetch for a vote with a forged view, got %d", got-fetches)
+	}
+	vote(b3)
+	if got := node.Fetches(); got != fetches+1 {
+		t.Errorf("expected one fetch for a vote above the watermark, got %d", got-fetches)
+	}
+}
+
+// TestPendingVoteLimits checks that votes for missing blocks are bounded when flooded,
+// and that a quorum for a legitimate block can still be assembled once the block arrives.
+func TestPendingVoteLimits(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders[0].Options().SetMaxPendingBlocks(2)
+	builders[0].Options().SetMaxPendingVotes(4)
+	hl := builders.Build()
+	signers := hl.Signers()
+	hs := network.Node(1).Modules()
+
+	qcs := make(chan consensus.QuorumCert, 1)
+	hs.EventLoop().RegisterObserver(consensus.NewViewMsg{}, func(event interface{}) {
+		if qc, ok := event.(consensus.NewViewMsg).SyncInfo.QC(); ok && qc.View() > 0 {
+			select {
+			case qcs <- qc:
+			default:
+			}
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go hs.Run(ctx)
+
+	vote := func(id hotstuff.ID, block *consensus.Block) {
+		pc, err := signers[id-1].CreatePartialCert(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs.EventLoop().AddEvent(consensus.VoteMsg{ID: id, PartialCert: pc})
+	}
+	pending := func() int {
+		c := make(chan int)
+		hs.EventLoop().AddEvent(func() { c <- hs.VotingMachine().PendingVotes() })
+		return <-c
+	}
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 2)
+
+	// the votes for b1 arrive before the proposal.
+	vote(2, b1)
+	vote(3, b1)
+	// replica 3 floods the replica with duplicate votes, and votes for blocks that do not exist.
+	for i := 0; i < 100; i++ {
+		vote(3, b1)
+		vote(3, consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), consensus.Command(fmt.Sprint(i)), 1, 2))
+	}
+	// one vote for b1 from each of the two replicas, and one vote for the last missing block,
+	// as the duplicate votes keep b1 from being evicted.
+	if got := pending(); got != 3 {
+		t.Errorf("expected 3 pending votes, got %d", got)
+	}
+
+	hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: 2, Block: b1})
+	vote(4, b1)
+
+	select {
+	case qc := <-qcs:
+		if qc.BlockHash() != b1.Hash() {
+			t.Errorf("expected a QC for b1, got %v", qc)
+		}
+	case <-ctx.Done():
+		t.Fatal("no QC was formed")
+	}
+}
+
+// TestWaitingVotesPerSender checks that a replica that votes for many blocks that do not exist
+// can only have MaxWaitingVotes votes waiting, and thus only cause that many fetches.
+func TestWaitingVotesPerSender(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	signers := hl.Signers()
+	hs := network.Node(1).Modules()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go hs.Run(ctx)
+
+	genesis := consensus.GetGenesis()
+	vote := func(id hotstuff.ID, cmd string) {
+		block := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), consensus.Command(cmd), 1, 2)
+		pc, err := signers[id-1].CreatePartialCert(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs.EventLoop().AddEvent(consensus.VoteMsg{ID: id, PartialCert: pc})
+	}
+	for i := 0; i < 100; i++ {
+		vote(3, fmt.Sprint(i))
+	}
+	vote(2, "honest")
+
+	c := make(chan int)
+	hs.EventLoop().AddEvent(func() { c <- hs.VotingMachine().PendingVotes() })
+	select {
+	case pending := <-c:
+		if want := consensus.DefaultMaxWaitingVotes + 1; pending != want {
+			t.Errorf("expected %d pending votes, got %d", want, pending)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+}
+
+// TestPendingBlocksEviction checks that a flood of votes for blocks that do not exist does not grow the waiting room
+// beyond MaxPendingBlocks, and that the votes for the blocks that were least recently voted for are evicted first.
+func TestPendingBlocksEviction(t *testing.T) {
+	const (
+		maxBlocks = 16
+		junk      = 3000
+	)
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders[0].Options().SetMaxPendingBlocks(maxBlocks)
+	hl := builders.Build()
+	signers := hl.Signers()
+	hs := network.Node(1).Modules()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go hs.Run(ctx)
+
+	genesis := consensus.GetGenesis()
+	blocks := make([]*consensus.Block, junk)
+	for i := range blocks {
+		blocks[i] = consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), consensus.Command(fmt.Sprint(i)), 1, 2)
+		id := hotstuff.ID(i%3 + 2)
+		pc, err := signers[id-1].CreatePartialCert(blocks[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs.EventLoop().AddEvent(consensus.VoteMsg{ID: id, PartialCert: pc})
+	}
+
+	c := make(chan int)
+	hs.EventLoop().AddEvent(func() { c <- hs.VotingMachine().PendingVotes() })
+	select {
+	case pending := <-c:
+		if pending > maxBlocks {
+			t.Errorf("expected at most %d pending votes, got %d", maxBlocks, pending)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+	if hashes := hs.WaitingRoom().Hashes(); len(hashes) > maxBlocks {
+		t.Errorf("expected votes for at most %d blocks to be waiting, got %d", maxBlocks, len(hashes))
+	}
+	for i, block := range blocks {
+		if want := i >= junk-maxBlocks; hs.WaitingRoom().Waiting(block.Hash()) != want {
+			t.Errorf("block %d: waiting %v, want %v", i, !want, want)
+		}
+	}
+}
+
+// TestBatchFetch checks that the blocks of several pending votes are fetched in a single request.
+func TestBatchFetch(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders[0].Options().SetShouldBatchFetch()
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	// the blocks are withheld from replica 1.
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 2)
+	b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "b2", 2, 3)
+	b3 := consensus.NewBlock(b2.Hash(), testutil.CreateQC(t, b2, signers), "b3", 3, 4)
+	blocks := []*consensus.Block{b1, b2, b3}
+	for _, block := range blocks {
+		network.Node(2).Modules().BlockChain().Store(block)
+	}
+
+	node := network.Node(1)
+	hs := node.Modules()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go hs.Run(ctx)
+
+	pending := func() int {
+		c := make(chan int)
+		hs.EventLoop().AddEvent(func() { c <- hs.VotingMachine().PendingVotes() })
+		return <-c
+	}
+
+	for _, block := range blocks {
+		pc, err := signers[1].CreatePartialCert(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs.EventLoop().AddEvent(consensus.VoteMsg{ID: 2, PartialCert: pc})
+	}
+	if got := pending(); got != len(blocks) {
+		t.Fatalf("expected %d pending votes, got %d", len(blocks), got)
+	}
+
+	// any proposal replays the pending votes; this one is rejected without fetching anything.
+	hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: 2, Block: genesis})
+	for pending() > 0 {
+		if ctx.Err() != nil {
+			t.Fatal("the pending votes were not replayed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, block := range blocks {
+		if _, ok := hs.BlockChain().LocalGet(block.Hash()); !ok {
+			t.Errorf("block %.8s was not fetched", block.Hash())
+		}
+	}
+	if got := node.Fetches(); got != 1 {
+		t.Errorf("expected a single fetch, got %d", got)
+	}
+}
+
+// TestWaitingRoom checks that a proposal and votes that wait for the same missing block
+// are all released and handled when the block arrives, without fetching it.
+func TestWaitingRoom(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	signers := hl.Signers()
+	node := network.Node(1)
+	hs := node.Modules()
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 2)
+	b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "b2", 2, 3)
+
+	// the observer runs on the event loop.
+	var released int
+	hs.EventLoop().RegisterObserver(consensus.VoteMsg{}, func(event interface{}) {
+		if event.(consensus.VoteMsg).Deferred {
+			released++
+		}
+	})
+
+	// the votes for b1 and the proposal of b2 arrive before b1.
+	for id := hotstuff.ID(2); id <= 4; id++ {
+		pc, err := signers[id-1].CreatePartialCert(b1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs.EventLoop().AddEvent(consensus.VoteMsg{ID: id, PartialCert: pc})
+	}
+	hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: 3, Block: b2})
+	var waiting, remaining int
+	hs.EventLoop().AddEvent(func() {
+		waiting = hs.WaitingRoom().Len()
+		hs.BlockChain().Store(b1)
+		remaining = hs.WaitingRoom().Len()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	hs.Run(ctx)
+
+	if waiting != 4 {
+		t.Errorf("expected 4 messages waiting for b1, got %d", waiting)
+	}
+	if remaining != 0 {
+		t.Errorf("expected all messages to be released when b1 was stored, got %d remaining", remaining)
+	}
+	if released != 3 {
+		t.Errorf("expected 3 released votes, got %d", released)
+	}
+	if _, ok := hs.BlockChain().LocalGet(b2.Hash()); !ok {
+		t.Error("the released proposal of b2 was not accepted")
+	}
+	if hs.Synchronizer().HighQC().BlockHash() != b1.Hash() {
+		t.Error("expected the highQC to certify b1")
+	}
+	if got := node.Fetches(); got != 0 {
+		t.Errorf("expected no fetches, got %d", got)
+	}
+}
+
+// TestVoteRelay checks that votes are relayed within a region when the leader is in another region,
+// such that fewer vote messages cross between the regions, and that the leaders still form QCs
+// from the votes of distinct signers in both regions.
+func TestVoteRelay(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 7)
+	for _, builder := range builders {
+		builder.Options().SetVoteRelayWindow(50 * time.Millisecond)
+	}
+	builders.Build()
+	regions := map[hotstuff.ID]string{1: "eu", 2: "eu", 3: "eu", 4: "us", 5: "us", 6: "us", 7: "us"}
+	for id, region := range regions {
+		network.SetRegion(region, id)
+	}
+
+	var crossMessages, crossVotes int32
+	network.SetTap(func(from, to hotstuff.ID, msg interface{}) {
+		if regions[from] == regions[to] {
+			return
+		}
+		switch m := msg.(type) {
+		case consensus.VoteMsg:
+			atomic.AddInt32(&crossMessages, 1)
+			atomic.AddInt32(&crossVotes, 1)
+		case consensus.RelayedVotesMsg:
+			atomic.AddInt32(&crossMessages, 1)
+			atomic.AddInt32(&crossVotes, int32(len(m.Votes)))
+		}
+	})
+
+	var (
+		mut        sync.Mutex
+		qcs        int
+		mixedQCs   int
+		badSigners []string
+	)
+	for _, node := range network.Nodes() {
+		id := node.ID()
+		quorum := node.Modules().Configuration().QuorumSize()
+		node.Modules().MetricsEventLoop().RegisterObserver(consensus.QCFormedEvent{}, func(event interface{}) {
+			signers := event.(consensus.QCFormedEvent).QC.Signers()
+			mut.Lock()
+			defer mut.Unlock()
+			qcs++
+			if len(signers) < quorum {
+				badSigners = append(badSigners, fmt.Sprint(id, signers))
+			}
+			for _, signer := range signers {
+				if regions[signer] != regions[id] {
+					mixedQCs++
+					break
+				}
+			}
+		})
+	}
+
+	node := network.Node(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(node.Executed()) < 10 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	if executed := len(node.Executed()); executed < 10 {
+		t.Fatalf("only %d blocks were committed", executed)
+	}
+	mut.Lock()
+	defer mut.Unlock()
+	if len(badSigners) > 0 {
+		t.Errorf("QCs formed from too few distinct signers: %v", badSigners)
+	}
+	if qcs == 0 || mixedQCs == 0 {
+		t.Errorf("expected QCs with signers from the other region, got %d of %d", mixedQCs, qcs)
+	}
+	messages, votes := atomic.LoadInt32(&crossMessages), atomic.LoadInt32(&crossVotes)
+	if votes == 0 || 2*messages > votes {
+		t.Errorf("expected the votes to be relayed across regions, got %d votes in %d messages", votes, messages)
+	}
+}
+
+// TestHeartbeats checks that heartbeats keep the status of the other replicas fresh when no blocks are proposed,
+// and reveal a replica that has fallen behind before any proposal is made.
+func TestHeartbeats(t *testing.T) {
+	const interval = 10 * time.Millisecond
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Register(synchronizer.New(testutil.FixedTimeout(10000)), silentQueue{})
+		builder.Options().SetHeartbeatInterval(interval)
+	}
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	var proposals int32
+	network.SetTap(func(_, _ hotstuff.ID, msg interface{}) {
+		if _, ok := msg.(consensus.ProposeMsg); ok {
+			atomic.AddInt32(&proposals, 1)
+		}
+	})
+	hs := network.Node(1).Modules()
+	lagging := make(chan consensus.LaggingPeerEvent, 1)
+	hs.MetricsEventLoop().RegisterObserver(consensus.LaggingPeerEvent{}, func(event interface{}) {
+		select {
+		case lagging <- event.(consensus.LaggingPeerEvent):
+		default:
+		}
+	})
+
+	// replicas 1-3 advance to view 5 through a timeout certificate that replica 4 does not receive.
+	tc := testutil.CreateTC(t, 4, signers)
+	for id := hotstuff.ID(1); id <= 3; id++ {
+		network.Node(id).Modules().EventLoop().AddEvent(consensus.NewViewMsg{ID: 2, SyncInfo: consensus.NewSyncInfo().WithTC(tc)})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		network.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case e := <-lagging:
+		if e.ID != 4 || e.View != 1 {
+			t.Errorf("expected replica 4 to lag behind in view 1, got replica %d in view %d", e.ID, e.View)
+		}
+	case <-ctx.Done():
+		t.Fatal("the lagging replica was not detected")
+	}
+	if n := atomic.LoadInt32(&proposals); n != 0 {
+		t.Errorf("expected no proposals, got %d", n)
+	}
+
+	time.Sleep(10 * interval)
+	peers := hs.Peers()
+	for id := hotstuff.ID(2); id <= 4; id++ {
+		status, ok := peers[id]
+		if !ok {
+			t.Errorf("no status for replica %d", id)
+			continue
+		}
+		if age := time.Since(status.LastSeen); age > 5*interval {
+			t.Errorf("the status of replica %d is %v old", id, age)
+		}
+	}
+	if view := peers[2].View; view != 5 {
+		t.Errorf("expected replica 2 to be in view 5, got %d", view)
+	}
+}
+
+// TestStartupBarrier checks that the first view does not time out when half of the replicas start late,
+// because the leader of view 1 waits until a quorum of replicas are ready before it proposes.
+// Without the barrier, the proposal for view 1 is lost, and the replicas must change view before they can commit.
+func TestStartupBarrier(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		barrier bool
+	}{
+		{"WithBarrier", true},
+		{"WithoutBarrier", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			network, builders := testutil.CreateNetwork(t, 4)
+			if tt.barrier {
+				for _, builder := range builders {
+					builder.Options().SetStartupBarrierTimeout(5 * time.Second)
+				}
+			}
+			builders.Build()
+
+			var timeouts int32
+			network.SetTap(func(_, _ hotstuff.ID, msg interface{}) {
+				if _, ok := msg.(consensus.TimeoutMsg); ok {
+					atomic.AddInt32(&timeouts, 1)
+				}
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			var wg sync.WaitGroup
+			defer func() {
+				cancel()
+				wg.Wait()
+			}()
+			// replica 2 leads view 1, and starts together with replica 3, twice the view timeout before the others.
+			wg.Add(2)
+			go func() {
+				network.Run(ctx, 2, 3)
+				wg.Done()
+			}()
+			time.Sleep(200 * time.Millisecond)
+			go func() {
+				network.Run(ctx, 1, 4)
+				wg.Done()
+			}()
+
+		waitForCommit:
+			for {
+				select {
+				case <-ctx.Done():
+					t.Fatal("the replicas did not commit")
+				case <-time.After(10 * time.Millisecond):
+					for _, node := range network.Nodes() {
+						if len(node.Executed()) == 0 {
+							continue waitForCommit
+						}
+					}
+					break waitForCommit
+				}
+			}
+
+			for _, node := range network.Nodes() {
+				first := node.Executed()[0]
+				if tt.barrier && first.View() != 1 {
+					t.Errorf("replica %d: the first committed block is from view %d, expected view 1", node.ID(), first.View())
+				}
+			}
+			n := atomic.LoadInt32(&timeouts)
+			if tt.barrier && n != 0 {
+				t.Errorf("expected no view change before the first commit, got %d timeout messages", n)
+			}
+			if !tt.barrier && n == 0 {
+				t.Error("expected the late replicas to cause a view change")
+			}
+		})
+	}
+}
+
+// TestReadOnly checks that a replica that cannot reach a quorum becomes read-only and stops voting,
+// and that it resumes voting once it can reach a quorum again.
+func TestReadOnly(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetQuorumLossTimeout(200 * time.Millisecond)
+	}
+	builders.Build()
+	hs := network.Node(1).Modules()
+
+	var votes int32 // the votes sent by replica 1
+	for _, node := range network.Nodes()[1:] {
+		node.Modules().EventLoop().RegisterObserver(consensus.VoteMsg{}, func(event interface{}) {
+			if event.(consensus.VoteMsg).ID == 1 {
+				atomic.AddInt32(&votes, 1)
+			}
+		})
+	}
+	waitFor := func(cond func() bool) bool {
+		for i := 0; i < 300 && !cond(); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return cond()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		network.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	if !waitFor(func() bool { return atomic.LoadInt32(&votes) > 0 }) {
+		t.Fatal("replica 1 did not vote")
+	}
+
+	// replica 1 can only reach replica 4, which is not enough for a quorum.
+	network.Disconnect(1, 2, 3)
+	if !waitFor(hs.ReadOnly) {
+		t.Fatal("expected replica 1 to become read-only")
+	}
+	// allow votes that were sent before the replica became read-only to be delivered.
+	time.Sleep(100 * time.Millisecond)
+	before := atomic.LoadInt32(&votes)
+	time.Sleep(500 * time.Millisecond)
+	if after := atomic.LoadInt32(&votes); after != before {
+		t.Errorf("replica 1 sent %d votes while read-only", after-before)
+	}
+
+	network.Reconnect(1, 2, 3)
+	if !waitFor(func() bool { return !hs.ReadOnly() }) {
+		t.Fatal("expected replica 1 to leave read-only mode")
+	}
+	before = atomic.LoadInt32(&votes)
+	if !waitFor(func() bool { return atomic.LoadInt32(&votes) > before }) {
+		t.Error("replica 1 did not resume voting")
+	}
+}
+
+// TestCommitCerts checks that commit certificates collected from a quorum of replicas attest to the same committed block.
+func TestCommitCerts(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 1)
+	qc1 := testutil.CreateQC(t, b1, signers)
+	b2 := consensus.NewBlock(b1.Hash(), qc1, "b2", 2, 2)
+	qc2 := testutil.CreateQC(t, b2, signers)
+
+	if _, err := hl[3].Consensus().CommitCert(); err == nil {
+		t.Error("expected CommitCert to fail before any block is committed")
+	}
+
+	var certs []consensus.CommitCert
+	for _, node := range network.Nodes() {
+		hs := node.Modules()
+		hs.BlockChain().Store(b1)
+		hs.BlockChain().Store(b2)
+		checkpoint := qc1
+		// replica 4 is ahead of the others.
+		if node.ID() == 4 {
+			checkpoint = qc2
+		}
+		if err := testutil.ForceCommit(hs, checkpoint); err != nil {
+			t.Fatal(err)
+		}
+		cert, err := hs.Consensus().CommitCert()
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs = append(certs, cert)
+	}
+
+	// the auditor uses the public keys of the replicas to verify the certificates.
+	auditor := hl[3].Crypto()
+	quorum := hl[0].Configuration().QuorumSize()
+
+	hash, err := consensus.VerifyCommitCerts(auditor, certs[:3], quorum)
+	if err != nil {
+		t.Fatalf("failed to verify commit certificates: %v", err)
+	}
+	if hash != b1.Hash() {
+		t.Errorf("commit certificates attest to the wrong block: got %.8s, want %.8s", hash, b1.Hash())
+	}
+
+	if _, err := consensus.VerifyCommitCerts(auditor, certs, quorum); err == nil {
+		t.Error("expected verification to fail when a replica committed a different block")
+	}
+	if _, err := consensus.VerifyCommitCerts(auditor, []consensus.CommitCert{certs[0], certs[1], certs[0]}, quorum); err == nil {
+		t.Error("expected verification to fail when the certificates are not from a quorum of distinct replicas")
+	}
+	forged := consensus.NewCommitCert(3, certs[0].View(), certs[0].BlockHash(), certs[0].Signature())
+	if _, err := consensus.VerifyCommitCerts(auditor, []consensus.CommitCert{certs[0], certs[1], forged}, quorum); err == nil {
+		t.Error("expected verification to fail for a certificate that was not signed by its replica")
+	}
+	vote, err := hl[2].Crypto().CreatePartialCert(b1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromVote := consensus.NewCommitCert(3, b1.View(), b1.Hash(), vote.Signature())
+	if _, err := consensus.VerifyCommitCerts(auditor, []consensus.CommitCert{certs[0], certs[1], fromVote}, quorum); err == nil {
+		t.Error("expected verification to fail for a vote disguised as a commit certificate")
+	}
+}
+
+// TestFinalityCert checks that a finality certificate forms for a committed block,
+// that it reaches the replicas that did not form it, and that it can be verified by an external party.
+func TestFinalityCert(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 5)
+	for _, builder := range builders {
+		builder.Options().SetShouldFinalizeCommits()
+	}
+	hl := builders.Build()
+
+	var (
+		mut       sync.Mutex
+		finalized = make(map[hotstuff.ID][]consensus.FinalizedEvent)
+	)
+	// replica 5 does not run the protocol, and acts as the external party.
+	running := []hotstuff.ID{1, 2, 3, 4}
+	for _, id := range running {
+		id := id
+		network.Node(id).Modules().MetricsEventLoop().RegisterObserver(consensus.FinalizedEvent{}, func(event interface{}) {
+			mut.Lock()
+			finalized[id] = append(finalized[id], event.(consensus.FinalizedEvent))
+			mut.Unlock()
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			mut.Lock()
+			done := len(finalized) == len(running)
+			mut.Unlock()
+			if done {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx, running...)
+
+	mut.Lock()
+	defer mut.Unlock()
+	auditor := hl[4].Crypto()
+	quorum := hl[4].Configuration().QuorumSize()
+	for _, id := range running {
+		events := finalized[id]
+		if len(events) == 0 {
+			t.Errorf("replica %d did not finalize any blocks", id)
+			continue
+		}
+		for _, event := range events {
+			if event.Block.Hash() != event.Cert.BlockHash() {
+				t.Errorf("replica %d: finality certificate for %.8s does not match the finalized block %v", id, event.Cert.BlockHash(), event.Block)
+			}
+			if err := consensus.VerifyFinalityCert(auditor, event.Cert, quorum); err != nil {
+				t.Errorf("replica %d: failed to verify finality certificate: %v", id, err)
+			}
+			committed := false
+			for _, block := range network.Node(id).Executed() {
+				if block.Hash() == event.Block.Hash() {
+					committed = true
+				}
+			}
+			if !committed {
+				t.Errorf("replica %d: finalized block %v was not committed", id, event.Block)
+			}
+		}
+	}
+
+	cert := finalized[1][0].Cert
+	partial := consensus.NewFinalityCert(cert.CommitCerts()[:quorum-1])
+	if err := consensus.VerifyFinalityCert(auditor, partial, quorum); err == nil {
+		t.Error("expected verification to fail for a finality certificate without a quorum of commit certificates")
+	}
+}
+
+// eagerRules commits every block as soon as it is proposed, without locking it first.
+// This violates the invariant that the committed block can never be newer than the locked block.
+type eagerRules struct{}
+
+func (eagerRules) VoteRule(_ consensus.ProposeMsg) bool { return true }
+
+func (eagerRules) CommitRule(block *consensus.Block) *consensus.Block { return block }
+
+func (eagerRules) LockedBlock() *consensus.Block { return consensus.GetGenesis() }
+
+// TestStrictMode checks that an invariant violation causes a panic in strict mode.
+func TestStrictMode(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4) // the network runs in strict mode
+	builders[0].Register(consensus.New(eagerRules{}))
+	hl := builders.Build()
+	hs := network.Node(1).Modules()
+
+	genesis := consensus.GetGenesis()
+	block := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "foo", 1, 1)
+	hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: hl[0].LeaderRotation().GetLeader(1), Block: block})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	defer func() {
+		msg := fmt.Sprint(recover())
+		if !strings.Contains(msg, "invariant violation on replica 1") || !strings.Contains(msg, "newer than the locked block") {
+			t.Errorf("expected a panic describing the invariant violation, got: %q", msg)
+		}
+	}()
+	// run the event loop on this goroutine so that we can recover from the panic.
+	hs.EventLoop().Run(ctx)
+}
+
+// fixedRules votes for every proposal, and commits and locks a fixed block.
+type fixedRules struct {
+	block *consensus.Block
+}
+
+func (r fixedRules) VoteRule(_ consensus.ProposeMsg) bool { return true }
+
+func (r fixedRules) CommitRule(_ *consensus.Block) *consensus.Block { return r.block }
+
+func (r fixedRules) LockedBlock() *consensus.Block { return r.block }
+
+// TestCommitViewOrder checks that a block is not executed after a block with a higher view.
+func TestCommitViewOrder(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4) // the network runs in strict mode
+
+	// the parent has a higher view than the child.
+	genesis := consensus.GetGenesis()
+	parent := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "foo", 5, 1)
+	child := consensus.NewBlock(parent.Hash(), consensus.NewQuorumCert(nil, 5, parent.Hash()), "bar", 3, 1)
+
+	builders[0].Register(consensus.New(fixedRules{child}))
+	hl := builders.Build()
+	hs := network.Node(1).Modules()
+	hs.BlockChain().Store(parent)
+	hs.BlockChain().Store(child)
+
+	block := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "baz", 1, 1)
+	hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: hl[0].LeaderRotation().GetLeader(1), Block: block})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	defer func() {
+		msg := fmt.Sprint(recover())
+		if !strings.Contains(msg, "refusing to commit block") {
+			t.Errorf("expected a panic describing the invariant violation, got: %q", msg)
+		}
+		for _, executed := range network.Node(1).Executed() {
+			if executed.Hash() == child.Hash() {
+				t.Error("block with a lower view than its parent was executed")
+			}
+		}
+	}()
+	// run the event loop on this goroutine so that we can recover from the panic.
+	hs.EventLoop().Run(ctx)
+}
+
+// TestCommitViewOrderLenient checks that outside of strict mode, a block that is refused because its view is lower
+// than the view of its parent is not executed, and that the descendants of the refused block are not executed either.
+func TestCommitViewOrderLenient(t *testing.T) {
+	network, builders := testutil.CreateLenientNetwork(t, 4)
+
+	// the parent has a higher view than the child, and the grandchild extends the child.
+	genesis := consensus.GetGenesis()
+	parent := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "foo", 5, 1)
+	child := consensus.NewBlock(parent.Hash(), consensus.NewQuorumCert(nil, 5, parent.Hash()), "bar", 3, 1)
+	grandchild := consensus.NewBlock(child.Hash(), consensus.NewQuorumCert(nil, 3, child.Hash()), "qux", 6, 1)
+
+	builders[0].Register(consensus.New(fixedRules{grandchild}))
+	hl := builders.Build()
+	hs := network.Node(1).Modules()
+	for _, block := range []*consensus.Block{parent, child, grandchild} {
+		hs.BlockChain().Store(block)
+	}
+
+	block := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "baz", 1, 1)
+	hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: hl[0].LeaderRotation().GetLeader(1), Block: block})
+	drained, cancel := context.WithCancel(context.Background())
+	cancel()
+	hs.EventLoop().Run(drained)
+
+	executed := network.Node(1).Executed()
+	if len(executed) != 1 || executed[0].Hash() != parent.Hash() {
+		t.Errorf("got executed blocks %v, want only the parent of the refused block", executed)
+	}
+	if committed := hs.Consensus().CommittedBlock(); committed.Hash() != parent.Hash() {
+		t.Errorf("got committed block %v, want the parent of the refused block", committed)
+	}
+}
+
+// TestViewContinuity checks that a committed chain may skip views when the block after the gap is certified to extend its parent,
+// and that a gap that is not justified by the QC of the block is reported.
+func TestViewContinuity(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		justified bool
+	}{
+		{"Justified", true},
+		{"Unjustified", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			network, builders := testutil.CreateNetwork(t, 4) // the network runs in strict mode
+			for _, builder := range builders {
+				builder.Options().SetShouldVerifyViewContinuity()
+			}
+			hl := builders.Build()
+			signers := hl.Signers()
+			hs := network.Node(1).Modules()
+
+			// views 3 and 4 timed out, so b3 is proposed in view 5.
+			genesis := consensus.GetGenesis()
+			b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 2)
+			b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "b2", 2, 3)
+			qc := testutil.CreateQC(t, b2, signers)
+			if !tt.justified {
+				// b3 extends b2, but its QC certifies b1, so nothing shows that views 3 and 4 ended without a decision.
+				qc = testutil.CreateQC(t, b1, signers)
+			}
+			b3 := consensus.NewBlock(b2.Hash(), qc, "b3", 5, 2)
+			b4 := consensus.NewBlock(b3.Hash(), testutil.CreateQC(t, b3, signers), "b4", 6, 3)
+			for _, block := range []*consensus.Block{b1, b2, b3, b4} {
+				hs.BlockChain().Store(block)
+			}
+
+			defer func() {
+				msg := fmt.Sprint(recover())
+				if tt.justified && msg != "<nil>" {
+					t.Errorf("unexpected panic: %s", msg)
+				}
+				if !tt.justified && !strings.Contains(msg, "skips views") {
+					t.Errorf("expected a panic describing the view gap, got: %q", msg)
+				}
+			}()
+			if err := testutil.ForceCommit(hs, testutil.CreateQC(t, b4, signers)); err != nil {
+				t.Fatal(err)
+			}
+			if executed := network.Node(1).Executed(); len(executed) != 4 {
+				t.Errorf("expected 4 executed blocks, got %d", len(executed))
+			}
+		})
+	}
+}
+
+// TestProposalDedup checks that a proposal that is delivered multiple times is processed and voted for only once.
+func TestProposalDedup(t *testing.T) {
+	run := func(t *testing.T, dedup bool) (proposals, votes int) {
+		network, builders := testutil.CreateNetwork(t, 4)
+		// replica 1 leads the first views, such that its vote is delivered to itself.
+		builders[0].Register(testutil.NewLeaderRotation(t, 1, 1, 1))
+		if dedup {
+			builders[0].Options().SetShouldDedupProposals()
+		}
+		builders.Build()
+		hs := network.Node(1).Modules()
+
+		var mut sync.Mutex
+		hs.MetricsEventLoop().RegisterObserver(consensus.ProposalReceivedEvent{}, func(_ interface{}) {
+			mut.Lock()
+			proposals++
+			mut.Unlock()
+		})
+		hs.MetricsEventLoop().RegisterObserver(consensus.VoteReceivedEvent{}, func(_ interface{}) {
+			mut.Lock()
+			votes++
+			mut.Unlock()
+		})
+
+		genesis := consensus.GetGenesis()
+		block := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "foo", 1, 1)
+		for i := 0; i < 3; i++ {
+			hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: 1, Block: block})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		hs.Run(ctx)
+
+		mut.Lock()
+		defer mut.Unlock()
+		return proposals, votes
+	}
+
+	t.Run("WithDedup", func(t *testing.T) {
+		proposals, votes := run(t, true)
+		if proposals != 1 {
+			t.Errorf("expected the proposal to be processed once, got %d", proposals)
+		}
+		if votes != 1 {
+			t.Errorf("expected one vote, got %d", votes)
+		}
+	})
+	t.Run("WithoutDedup", func(t *testing.T) {
+		proposals, votes := run(t, false)
+		if proposals != 3 {
+			t.Errorf("expected the proposal to be processed three times, got %d", proposals)
+		}
+		if votes != 1 {
+			t.Errorf("expected one vote, got %d", votes)
+		}
+	})
+}
+
+// TestVoteSentEvents checks that the VoteSentEvents emitted by the replicas match the votes received by the leaders.
+func TestVoteSentEvents(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetShouldEmitVoteEvents()
+	}
+	hl := builders.Build()
+
+	type vote struct {
+		voter, leader hotstuff.ID
+		hash          consensus.Hash
+	}
+	var (
+		mut      sync.Mutex
+		sent     = make(map[consensus.View]map[vote]bool)
+		received = make(map[consensus.View]map[vote]bool)
+	)
+	add := func(votes map[consensus.View]map[vote]bool, view consensus.View, v vote) {
+		mut.Lock()
+		defer mut.Unlock()
+		if votes[view] == nil {
+			votes[view] = make(map[vote]bool)
+		}
+		votes[view][v] = true
+	}
+	for _, mods := range hl {
+		id := mods.ID()
+		mods.MetricsEventLoop().RegisterObserver(consensus.VoteSentEvent{}, func(event interface{}) {
+			e := event.(consensus.VoteSentEvent)
+			if e.ID != id {
+				t.Errorf("replica %d emitted a VoteSentEvent with ID %d", id, e.ID)
+			}
+			add(sent, e.View, vote{e.ID, e.Leader, e.BlockHash})
+		})
+		mods.MetricsEventLoop().RegisterObserver(consensus.VoteReceivedEvent{}, func(event interface{}) {
+			e := event.(consensus.VoteReceivedEvent)
+			add(received, e.View, vote{e.ID, id, e.BlockHash})
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	node := network.Node(1)
+	go func() {
+		for ctx.Err() == nil {
+			if len(node.Executed()) >= 10 {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	// only the executed blocks are checked, since votes for later blocks may still have been in transit.
+	for _, block := range node.Executed() {
+		votes := sent[block.View()]
+		if len(votes) < hotstuff.QuorumSize(len(hl)) {
+			t.Errorf("view %d: expected votes from at least a quorum, got %d", block.View(), len(votes))
+		}
+		for v := range votes {
+			if v.hash != block.Hash() {
+				t.Errorf("view %d: replica %d voted for a different block", block.View(), v.voter)
+			}
+			if !received[block.View()][v] {
+				t.Errorf("view %d: vote from replica %d was not received by replica %d", block.View(), v.voter, v.leader)
+			}
+		}
+		for v := range received[block.View()] {
+			if !votes[v] {
+				t.Errorf("view %d: replica %d received a vote from replica %d without a VoteSentEvent", block.View(), v.leader, v.voter)
+			}
+		}
+	}
+}
+
+// TestDummyBlocks checks that a single dummy block is inserted when a view is skipped,
+// and that dummy blocks are never executed.
+func TestDummyBlocks(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetDummyPolicy(consensus.FillViewGaps)
+	}
+	builders.Build()
+
+	// replica 4 is not running, so the views that it leads are skipped.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	node := network.Node(1)
+	go func() {
+		for ctx.Err() == nil {
+			if len(node.Executed()) >= 10 {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx, 1, 2, 3)
+
+	executed := node.Executed()
+	if len(executed) < 10 {
+		t.Fatalf("expected at least 10 executed blocks, got %d", len(executed))
+	}
+	for _, block := range executed {
+		if block.IsDummy() {
+			t.Errorf("dummy block was executed: %v", block)
+		}
+	}
+
+	chain := node.Modules().BlockChain()
+	dummies := 0
+	for _, block := range chain.(interface{ Blocks() []*consensus.Block }).Blocks() {
+		if !block.IsDummy() {
+			continue
+		}
+		dummies++
+		parent, ok := chain.LocalGet(block.Parent())
+		if !ok {
+			t.Errorf("parent of dummy block %v not found", block)
+			continue
+		}
+		if parent.IsDummy() {
+			t.Errorf("dummy block %v extends another dummy block", block)
+		}
+		if parent.View() >= block.View() {
+			t.Errorf("dummy block %v does not fill a view gap after %v", block, parent)
+		}
+		if parent.Hash() != block.QuorumCert().BlockHash() {
+			t.Errorf("dummy block %v does not extend its certified block", block)
+		}
+	}
+	if dummies == 0 {
+		t.Error("expected dummy blocks to be inserted for the skipped views")
+	}
+}
+
+// TestDummyChainCommit checks that a block extending several dummy blocks is only accepted if it extends
+// exactly the dummy blocks required by the policy, and that the certified block before the dummy blocks
+// is committed by a three-chain that passes through them, without executing the dummy blocks.
+func TestDummyChainCommit(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	// replica 1 does not lead any views, so it only votes for the proposals that are delivered to it.
+	builders[0].Register(testutil.NewLeaderRotation(t, 2, 3, 4, 2, 3, 4, 2, 3, 4))
+	for _, builder := range builders {
+		builder.Options().SetDummyPolicy(consensus.FillEveryView)
+	}
+	hl := builders.Build()
+	node := network.Node(1)
+	hs := node.Modules()
+	signers := hl.Signers()
+
+	propose := func(parent *consensus.Block, qc consensus.QuorumCert, view consensus.View) *consensus.Block {
+		block := consensus.NewBlock(parent.Hash(), qc, consensus.Command(fmt.Sprint(view)), view, hs.LeaderRotation().GetLeader(view))
+		hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: block.Proposer(), Block: block})
+		return block
+	}
+	waitExecuted := func(n int) []*consensus.Block {
+		for i := 0; i < 100 && len(node.Executed()) < n; i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return node.Executed()
+	}
+
+	genesis := consensus.GetGenesis()
+	b1 := propose(genesis, consensus.NewQuorumCert(nil, 0, genesis.Hash()), 1)
+	qc1 := testutil.CreateQC(t, b1, signers)
+
+	// views 2 and 3 were skipped, so a dummy block must be inserted in each of them.
+	wrongDummy := consensus.NewDummyBlock(b1.Hash(), qc1, 3)
+	propose(wrongDummy, qc1, 4)
+	d2 := consensus.NewDummyBlock(b1.Hash(), qc1, 2)
+	d3 := consensus.NewDummyBlock(d2.Hash(), qc1, 3)
+	b4 := propose(d3, qc1, 4)
+	b5 := propose(b4, testutil.CreateQC(t, b4, signers), 5)
+	b6 := propose(b5, testutil.CreateQC(t, b5, signers), 6)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go hs.EventLoop().Run(ctx)
+
+	// b6 certifies b5, which certifies b4, which extends b1 through the dummy blocks.
+	executed := waitExecuted(1)
+	if len(executed) != 1 || executed[0].Hash() != b1.Hash() {
+		t.Fatalf("expected only the block before the dummy blocks to be executed, got: %v", executed)
+	}
+	if _, ok := hs.BlockChain().LocalGet(wrongDummy.Hash()); ok {
+		t.Error("stored a dummy block that does not match the dummy policy")
+	}
+	for _, dummy := range []*consensus.Block{d2, d3} {
+		if _, ok := hs.BlockChain().LocalGet(dummy.Hash()); !ok {
+			t.Errorf("dummy block %v was not recreated", dummy)
+		}
+	}
+
+	// the next block commits the block extending the dummy blocks.
+	propose(b6, testutil.CreateQC(t, b6, signers), 7)
+	executed = waitExecuted(2)
+	if len(executed) != 2 || executed[1].Hash() != b4.Hash() {
+		t.Fatalf("expected the block extending the dummy blocks to be executed next, got: %v", executed)
+	}
+}
+
+// TestPayloadRef checks that a block that refers to its command is executed with the command,
+// after the command has been fetched from the replica that it was disseminated to.
+func TestPayloadRef(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	node := network.Node(1)
+	hs := node.Modules()
+	signers := hl.Signers()
+
+	propose := func(block *consensus.Block) {
+		hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: block.Proposer(), Block: block})
+	}
+	leader := hs.LeaderRotation().GetLeader
+
+	// the payload is only known by replica 3, so replica 1 must fetch it.
+	payload := consensus.Command("large payload")
+	network.Node(3).Modules().BlockChain().StorePayload(payload)
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewPayloadRefBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), consensus.PayloadHash(payload), 1, leader(1))
+	propose(b1)
+	// no replica knows the payload of this block, so it cannot be voted for.
+	unknown := consensus.NewPayloadRefBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), consensus.PayloadHash("unknown"), 2, leader(2))
+	propose(unknown)
+	parent := b1
+	for view := consensus.View(2); view <= 4; view++ {
+		block := consensus.NewBlock(parent.Hash(), testutil.CreateQC(t, parent, signers), consensus.Command(fmt.Sprint(view)), view, leader(view))
+		propose(block)
+		parent = block
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go hs.EventLoop().Run(ctx)
+
+	for i := 0; i < 100 && len(node.Executed()) < 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	executed := node.Executed()
+	if len(executed) != 1 || executed[0].Hash() != b1.Hash() {
+		t.Fatalf("expected the block referring to the payload to be executed, got: %v", executed)
+	}
+	if executed[0].Command() != payload {
+		t.Errorf("executed command %q, expected %q", executed[0].Command(), payload)
+	}
+	if _, ok := hs.BlockChain().LocalGet(unknown.Hash()); ok {
+		t.Error("stored a block whose payload could not be resolved")
+	}
+}
+
+// TestGenesisLeader checks that when two replicas both attempt to lead view 1,
+// the honest followers only accept the proposal of the configured genesis leader, so the chain does not fork.
+func TestGenesisLeader(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for i, builder := range builders {
+		builder.Options().SetShouldEmitVoteEvents()
+		// replica 2 is misconfigured, and believes that the leader rotation decides the leader of view 1.
+		if i != 1 {
+			builder.Options().SetGenesisLeader(1)
+		}
+	}
+	builders.Build()
+
+	var (
+		mut       sync.Mutex
+		proposers = make(map[hotstuff.ID]bool)           // the replicas that proposed in view 1
+		votes     = make(map[hotstuff.ID]consensus.Hash) // the view 1 votes of the followers
+	)
+	followers := []hotstuff.ID{3, 4}
+	for _, id := range followers {
+		hs := network.Node(id).Modules()
+		hs.EventLoop().RegisterObserver(consensus.ProposeMsg{}, func(event interface{}) {
+			proposal := event.(consensus.ProposeMsg)
+			if proposal.Block.View() == 1 {
+				mut.Lock()
+				proposers[proposal.ID] = true
+				mut.Unlock()
+			}
+		})
+		hs.MetricsEventLoop().RegisterObserver(consensus.VoteSentEvent{}, func(event interface{}) {
+			vote := event.(consensus.VoteSentEvent)
+			if vote.View == 1 {
+				mut.Lock()
+				votes[vote.ID] = vote.BlockHash
+				mut.Unlock()
+			}
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			if len(network.Node(3).Executed()) >= 5 && len(network.Node(4).Executed()) >= 5 {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	mut.Lock()
+	defer mut.Unlock()
+	if !proposers[1] || !proposers[2] {
+		t.Fatalf("expected proposals for view 1 from replicas 1 and 2, got proposals from: %v", proposers)
+	}
+
+	executed := network.Node(3).Executed()
+	if len(executed) == 0 || executed[0].View() != 1 || executed[0].Proposer() != 1 {
+		t.Fatalf("expected the first executed block to be proposed by replica 1 in view 1, got: %v", executed)
+	}
+	for _, id := range followers {
+		if hash, ok := votes[id]; !ok || hash != executed[0].Hash() {
+			t.Errorf("replica %d did not vote for the genesis leader's proposal", id)
+		}
+	}
+	other := network.Node(4).Executed()
+	for i := 0; i < len(executed) && i < len(other); i++ {
+		if executed[i].Hash() != other[i].Hash() {
+			t.Errorf("replicas 3 and 4 executed different blocks at height %d", i)
+		}
+	}
+}
+
+// TestLifecycleEvents checks that the lifecycle events are emitted in the expected order with the expected payloads.
+func TestLifecycleEvents(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	hs := hl[0]
+
+	var (
+		mut    sync.Mutex
+		events []interface{}
+	)
+	record := func(event interface{}) {
+		mut.Lock()
+		events = append(events, event)
+		mut.Unlock()
+	}
+	for _, eventType := range []interface{}{
+		consensus.ProposalReceivedEvent{},
+		consensus.VoteReceivedEvent{},
+		consensus.QCFormedEvent{},
+		consensus.BlockCommittedEvent{},
+		synchronizer.ViewChangeEvent{},
+	} {
+		hs.MetricsEventLoop().RegisterObserver(eventType, record)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	node := network.Node(1)
+	go func() {
+		for ctx.Err() == nil {
+			if len(node.Executed()) >= 10 {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	var (
+		committed []*consensus.Block
+		proposed  = make(map[consensus.Hash]bool)
+		votes     = make(map[consensus.Hash]int)
+		qcs       int
+		lastView  consensus.View
+	)
+	for _, event := range events {
+		switch e := event.(type) {
+		case consensus.ProposalReceivedEvent:
+			if e.ID != e.Block.Proposer() {
+				t.Errorf("proposal from replica %d was proposed by replica %d", e.ID, e.Block.Proposer())
+			}
+			proposed[e.Block.Hash()] = true
+		case consensus.VoteReceivedEvent:
+			votes[e.BlockHash]++
+		case consensus.QCFormedEvent:
+			qcs++
+			hash := e.QC.BlockHash()
+			if !proposed[hash] {
+				t.Errorf("QC formed for block %.8s before it was proposed", hash)
+			}
+			if votes[hash] < hs.Configuration().QuorumSize() {
+				t.Errorf("QC formed for block %.8s after only %d votes", hash, votes[hash])
+			}
+		case consensus.BlockCommittedEvent:
+			if !proposed[e.Block.Hash()] {
+				t.Errorf("block %.8s committed before it was proposed", e.Block.Hash())
+			}
+			committed = append(committed, e.Block)
+		case synchronizer.ViewChangeEvent:
+			if e.View <= lastView {
+				t.Errorf("view changed from %d to %d", lastView, e.View)
+			}
+			lastView = e.View
+		}
+	}
+
+	if qcs == 0 {
+		t.Error("expected at least one QCFormedEvent")
+	}
+	executed := node.Executed()
+	if len(committed) == 0 || len(committed) > len(executed) {
+		t.Fatalf("expected up to %d BlockCommittedEvents, got %d", len(executed), len(committed))
+	}
+	for i, block := range committed {
+		if block.Hash() != executed[i].Hash() {
+			t.Errorf("BlockCommittedEvent %d: got %v, want %v", i, block, executed[i])
+		}
+	}
+}
+
+// emittedEvent is an event that is emitted by TestEmitEventOverflow.
+type emittedEvent int
+
+// TestEmitEventOverflow checks that no events are lost, and that they are delivered in order,
+// when more events are emitted than fit in the queue of the metrics event loop.
+func TestEmitEventOverflow(t *testing.T) {
+	const n = 1000
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	mods := builder.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []emittedEvent
+	mods.MetricsEventLoop().RegisterObserver(emittedEvent(0), func(event interface{}) {
+		got = append(got, event.(emittedEvent))
+		if len(got) == n {
+			cancel()
+		}
+	})
+	for i := 0; i < n; i++ {
+		mods.EmitEvent(emittedEvent(i))
+	}
+	mods.MetricsEventLoop().Run(ctx)
+
+	if len(got) != n {
+		t.Fatalf("got %d events, want %d", len(got), n)
+	}
+	for i, event := range got {
+		if event != emittedEvent(i) {
+			t.Fatalf("event %d: got %d, events were delivered out of order", i, event)
+		}
+	}
+}
+
+// TestEquivocationEvent checks that an EquivocationDetectedEvent is emitted when a leader proposes two blocks in the same view.
+func TestEquivocationEvent(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	hl := builders.Build()
+	hs := network.Node(1).Modules()
+
+	detected := make(chan consensus.EquivocationDetectedEvent, 1)
+	hs.MetricsEventLoop().RegisterObserver(consensus.EquivocationDetectedEvent{}, func(event interface{}) {
+		detected <- event.(consensus.EquivocationDetectedEvent)
+	})
+
+	genesis := consensus.GetGenesis()
+	qc := consensus.NewQuorumCert(nil, 0, genesis.Hash())
+	leader := hl[0].LeaderRotation().GetLeader(1)
+	a := consensus.NewBlock(genesis.Hash(), qc, "a", 1, leader)
+	b := consensus.NewBlock(genesis.Hash(), qc, "b", 1, leader)
+	hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: leader, Block: a})
+	hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: leader, Block: b})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go hs.Run(ctx)
+
+	select {
+	case e := <-detected:
+		if e.ID != leader || e.View != 1 || e.First.Hash() != a.Hash() || e.Second.Hash() != b.Hash() {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected an EquivocationDetectedEvent")
+	}
+}
+
+// TestMinProposalInterval checks that leaders wait at least the minimum interval between their proposals,
+// while the pipeline keeps committing blocks without view timeouts.
+func TestMinProposalInterval(t *testing.T) {
+	const (
+		interval  = 30 * time.Millisecond
+		tolerance = 5 * time.Millisecond
+	)
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetMinProposalInterval(interval)
+	}
+	hl := builders.Build()
+
+	var (
+		mut       sync.Mutex
+		proposals = make(map[hotstuff.ID][]time.Time)
+		timeouts  int
+	)
+	hl[0].EventLoop().RegisterObserver(consensus.ProposeMsg{}, func(event interface{}) {
+		mut.Lock()
+		defer mut.Unlock()
+		id := event.(consensus.ProposeMsg).ID
+		proposals[id] = append(proposals[id], time.Now())
+	})
+	hl[0].MetricsEventLoop().RegisterObserver(synchronizer.ViewChangeEvent{}, func(event interface{}) {
+		mut.Lock()
+		defer mut.Unlock()
+		if event.(synchronizer.ViewChangeEvent).Timeout {
+			timeouts++
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	node := network.Node(1)
+	go func() {
+		for ctx.Err() == nil {
+			if len(node.Executed()) >= 20 {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	if executed := len(node.Executed()); executed < 20 {
+		t.Fatalf("expected at least 20 executed blocks, got %d", executed)
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+	for id, times := range proposals {
+		for i := 1; i < len(times); i++ {
+			if d := times[i].Sub(times[i-1]); d < interval-tolerance {
+				t.Errorf("replica %d proposed twice within %v", id, d)
+			}
+		}
+	}
+	if timeouts > 0 {
+		t.Errorf("expected no view timeouts, got %d", timeouts)
+	}
+}
+
+// monopolyLeader lets replica 1 lead every view while monopolize is set, and otherwise rotates the leader.
+type monopolyLeader struct {
+	monopolize *int32
+	n          int
+}
+
+func (l monopolyLeader) GetLeader(view consensus.View) hotstuff.ID {
+	if atomic.LoadInt32(l.monopolize) == 1 {
+		return 1
+	}
+	return hotstuff.ID(int(view)%l.n + 1)
+}
+
+func TestMinDistinctProposers(t *testing.T) {
+	const n = 4
+	network, builders := testutil.CreateNetwork(t, n)
+	monopolize := int32(1)
+	for _, builder := range builders {
+		builder.Register(monopolyLeader{&monopolize, n})
+		builder.Options().SetMinDistinctProposers(2, 5)
+	}
+	builders.Build()
+	hs := network.Node(2).Modules()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		network.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitFor := func(cond func() bool) bool {
+		for i := 0; i < 300 && !cond(); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return cond()
+	}
+	var view consensus.View
+	viewReached := func(v consensus.View) func() bool {
+		return func() bool {
+			hs.EventLoop().AddEvent(func() { atomic.StoreUint64((*uint64)(&view), uint64(hs.Synchronizer().View())) })
+			return consensus.View(atomic.LoadUint64((*uint64)(&view))) >= v
+		}
+	}
+
+	// the chain grows, but nothing is committed while replica 1 proposes every block.
+	if !waitFor(viewReached(20)) {
+		t.Fatal("the replicas did not make progress")
+	}
+	for _, node := range network.Nodes() {
+		if executed := len(node.Executed()); executed > 0 {
+			t.Fatalf("replica %d executed %d blocks while a single replica proposed", node.ID(), executed)
+		}
+	}
+
+	atomic.StoreInt32(&monopolize, 0)
+	if !waitFor(func() bool {
+		for _, node := range network.Nodes() {
+			if len(node.Executed()) < 10 {
+				return false
+			}
+		}
+		return true
+	}) {
+		t.Fatal("commits did not resume once the proposers were diverse")
+	}
+	// the blocks that were postponed are committed along with the rest of the chain.
+	if proposer := network.Node(1).Executed()[0].Proposer(); proposer != 1 {
+		t.Errorf("expected the first committed block to be proposed by replica 1, got replica %d", proposer)
+	}
+}
+
+// TestProposalSignInterval checks that followers accept proposals when the leaders only sign every few proposals,
+// and that the signatures of the signed proposals verify for the signed blocks and for the unsigned blocks they cover.
+func TestProposalSignInterval(t *testing.T) {
+	const interval = 3
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetShouldUseFetchProofs()
+		builder.Options().SetProposalSignInterval(interval)
+	}
+	builders.Build()
+	follower := network.Node(2).Modules()
+
+	var (
+		mut      sync.Mutex
+		signed   int
+		unsigned int
+		covered  int
+		invalid  int
+	)
+	follower.EventLoop().RegisterObserver(consensus.ProposeMsg{}, func(event interface{}) {
+		proposal := event.(consensus.ProposeMsg)
+		mut.Lock()
+		defer mut.Unlock()
+		if proposal.ProposerSig == nil {
+			unsigned++
+			return
+		}
+		signed++
+		if len(proposal.ProposerRun) == 0 {
+			if !consensus.VerifyInclusionProof(follower.Crypto(), proposal.Block, consensus.NewProposerProof(proposal.ProposerSig)) {
+				invalid++
+			}
+			return
+		}
+		run := append(append([]consensus.Hash(nil), proposal.ProposerRun...), proposal.Block.Hash())
+		proof := consensus.NewProposerRunProof(proposal.ProposerSig, run)
+		if !consensus.VerifyInclusionProof(follower.Crypto(), proposal.Block, proof) {
+			invalid++
+		}
+		for _, hash := range proposal.ProposerRun {
+			if block, ok := follower.BlockChain().LocalGet(hash); ok {
+				covered++
+				if !consensus.VerifyInclusionProof(follower.Crypto(), block, proof) {
+					invalid++
+				}
+			}
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(network.Node(2).Executed()) < 20 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	if executed := len(network.Node(2).Executed()); executed < 20 {
+		t.Fatalf("the follower only executed %d blocks", executed)
+	}
+	mut.Lock()
+	defer mut.Unlock()
+	if signed == 0 || unsigned == 0 || covered == 0 {
+		t.Fatalf("expected both signed and unsigned proposals, and signatures that cover unsigned blocks, "+
+			"got %d signed, %d unsigned and %d covered", signed, unsigned, covered)
+	}
+	// each leader makes at most interval-1 unsigned proposals before it signs one.
+	if unsigned > (interval-1)*(signed+4) {
+		t.Errorf("too many unsigned proposals: %d unsigned and %d signed", unsigned, signed)
+	}
+	if invalid > 0 {
+		t.Errorf("%d proposer signatures failed to verify", invalid)
+	}
+}
+
+// TestProposerRun checks that a follower rejects a signed proposal whose run was altered,
+// and an unsigned proposal from a leader that should have signed it.
+func TestProposerRun(t *testing.T) {
+	const interval = 3
+	_, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetShouldUseFetchProofs()
+		builder.Options().SetProposalSignInterval(interval)
+		builder.Options().SetShouldEmitVoteEvents()
+		builder.Register(testutil.NewLeaderRotation(t, 1, 1, 1, 1, 1, 1))
+	}
+	hl := builders.Build()
+	leader, follower := hl[0], hl[1]
+
+	var votes int
+	follower.MetricsEventLoop().RegisterObserver(consensus.VoteSentEvent{}, func(_ interface{}) { votes++ })
+	drained, cancel := context.WithCancel(context.Background())
+	cancel()
+	propose := func(proposal consensus.ProposeMsg) bool {
+		before := votes
+		follower.EventLoop().AddEvent(proposal)
+		follower.EventLoop().Run(drained)
+		follower.MetricsEventLoop().Run(drained)
+		return votes > before
+	}
+
+	parent := consensus.GetGenesis()
+	qc := consensus.NewQuorumCert(nil, 0, parent.Hash())
+	var run []consensus.Hash
+	next := func(view consensus.View) *consensus.Block {
+		block := consensus.NewBlock(parent.Hash(), qc, consensus.Command(fmt.Sprintf("cmd-%d", view)), view, 1)
+		parent, qc = block, testutil.CreateQC(t, block, hl.Signers())
+		return block
+	}
+
+	// the leader may make interval-1 unsigned proposals in a row.
+	for view := consensus.View(1); view < interval; view++ {
+		block := next(view)
+		if !propose(consensus.ProposeMsg{ID: 1, Block: block}) {
+			t.Fatalf("view %d: expected the follower to vote for the unsigned proposal", view)
+		}
+		run = append(run, block.Hash())
+	}
+
+	block := next(interval)
+	sig, err := leader.Crypto().Sign(consensus.ProposerRunDigest(append(append([]consensus.Hash(nil), run...), block.Hash())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if propose(consensus.ProposeMsg{ID: 1, Block: block}) {
+		t.Error("expected the follower to reject an unsigned proposal after interval-1 unsigned proposals")
+	}
+	if propose(consensus.ProposeMsg{ID: 1, Block: block, ProposerSig: sig, ProposerRun: run[1:]}) {
+		t.Error("expected the follower to reject a signature that does not cover the claimed run")
+	}
+	if !propose(consensus.ProposeMsg{ID: 1, Block: block, ProposerSig: sig, ProposerRun: run}) {
+		t.Fatal("expected the follower to vote for the signed proposal")
+	}
+	proof, ok := follower.BlockChain().Proof(run[0])
+	if !ok {
+		t.Fatal("expected the follower to store an inclusion proof for the unsigned block")
+	}
+	first, _ := follower.BlockChain().LocalGet(run[0])
+	if !consensus.VerifyInclusionProof(follower.Crypto(), first, proof) {
+		t.Error("expected the inclusion proof of the unsigned block to verify")
+	}
+}
+
+func TestLeaderRetryTimeout(t *testing.T) {
+	const n = 4
+	network, builders := testutil.CreateNetwork(t, n)
+	for _, builder := range builders {
+		// the replicas give up on the leader long after the leader resends its proposal.
+		builder.Register(synchronizer.New(testutil.FixedTimeout(1000)))
+		builder.Options().SetLeaderRetryTimeout(50 * time.Millisecond)
+	}
+	builders.Build()
+
+	leader := network.Node(1).Modules().LeaderRotation().GetLeader(1)
+	var timeouts int32
+	for _, node := range network.Nodes() {
+		node.Modules().EventLoop().RegisterObserver(consensus.TimeoutMsg{}, func(_ interface{}) {
+			atomic.AddInt32(&timeouts, 1)
+		})
+		if node.ID() != leader {
+			// the votes for the first proposal are lost.
+			network.DisconnectOneWay(node.ID(), leader)
+		}
+	}
+	time.AfterFunc(30*time.Millisecond, func() {
+		for _, node := range network.Nodes() {
+			network.Reconnect(node.ID(), leader)
+		}
+	})
+
+	// the context expires before the replicas would time out the first view.
+	ctx, cancel := context.WithTimeout(context.Background(), 800*time.Millisecond)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(network.Node(leader).Executed()) < 3 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	if executed := len(network.Node(leader).Executed()); executed < 3 {
+		t.Fatalf("the leader only executed %d blocks", executed)
+	}
+	if n := atomic.LoadInt32(&timeouts); n > 0 {
+		t.Errorf("expected the leader to recover without a view change, but %d timeout messages were sent", n)
+	}
+}
+
+// countingCrypto counts the quorum certificates that it verifies.
+type countingCrypto struct {
+	consensus.Crypto
+	qcs int32
+}
+
+func (c *countingCrypto) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	if mod, ok := c.Crypto.(consensus.Module); ok {
+		mod.InitConsensusModule(mods, opts)
+	}
+}
+
+func (c *countingCrypto) VerifyQuorumCert(qc consensus.QuorumCert) bool {
+	atomic.AddInt32(&c.qcs, 1)
+	return c.Crypto.VerifyQuorumCert(qc)
+}
+
+func TestDecidedViewProposal(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	cryptos := make([]*countingCrypto, len(builders))
+	for i, builder := range builders {
+		cryptos[i] = &countingCrypto{Crypto: crypto.NewCache(ecdsa.New(), 100)}
+		// the view timer must not fire while the proposals are replayed.
+		builder.Register(cryptos[i], synchronizer.New(testutil.FixedTimeout(10000)))
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	replica := network.Node(2)
+	go func() {
+		for ctx.Err() == nil && len(replica.Executed()) < 10 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	if executed := len(replica.Executed()); executed < 10 {
+		t.Fatalf("only %d blocks were executed", executed)
+	}
+	// process any messages that were still queued when the network stopped.
+	hs := replica.Modules()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	hs.Run(ctx)
+
+	executed := replica.Executed()
+	var proposals int32
+	hs.MetricsEventLoop().RegisterObserver(consensus.ProposalReceivedEvent{}, func(_ interface{}) {
+		atomic.AddInt32(&proposals, 1)
+	})
+
+	// replay a committed proposal, and a conflicting proposal for a committed view whose parent is unknown.
+	old := executed[len(executed)/2]
+	conflicting := consensus.NewBlock(consensus.Hash{1}, old.QuorumCert(), "conflicting", old.View(), old.Proposer())
+	qcs, fetches := atomic.LoadInt32(&cryptos[1].qcs), replica.Fetches()
+	for _, block := range []*consensus.Block{old, conflicting} {
+		hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: block.Proposer(), Block: block})
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	hs.Run(ctx)
+
+	if n := atomic.LoadInt32(&cryptos[1].qcs) - qcs; n > 0 {
+		t.Errorf("expected the proposals to be rejected without verification, but %d QCs were verified", n)
+	}
+	if n := replica.Fetches() - fetches; n > 0 {
+		t.Errorf("expected the proposals to be rejected without fetching, but %d blocks were fetched", n)
+	}
+	if n := atomic.LoadInt32(&proposals); n > 0 {
+		t.Errorf("expected the proposals to be rejected, but %d were received", n)
+	}
+	if len(replica.Executed()) != len(executed) {
+		t.Error("expected no blocks to be executed")
+	}
+}
+
+// mockBeacon is a randomness beacon whose output for each round is an HMAC of the round under a shared key.
+// The round advances every time the randomness is requested.
+type mockBeacon struct {
+	key   []byte
+	round *uint64
+}
+
+func (b mockBeacon) output(round uint64) []byte {
+	mac := hmac.New(sha256.New, b.key)
+	fmt.Fprint(mac, round)
+	return mac.Sum(nil)
+}
+
+func (b mockBeacon) Randomness(_ context.Context) (consensus.BeaconValue, bool) {
+	round := atomic.AddUint64(b.round, 1)
+	return consensus.BeaconValue{Round: round, Value: b.output(round)}, true
+}
+
+func (b mockBeacon) VerifyRandomness(value consensus.BeaconValue) bool {
+	return hmac.Equal(value.Value, b.output(value.Round))
+}
+
+func TestBeacon(t *testing.T) {
+	beacon := mockBeacon{key: []byte("beacon"), round: new(uint64)}
+
+	t.Run("Agreement", func(t *testing.T) {
+		const n = 4
+		network, builders := testutil.CreateNetwork(t, n)
+		for _, builder := range builders {
+			builder.Register(beacon)
+		}
+		builders.Build()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		go func() {
+			for ctx.Err() == nil {
+				done := true
+				for _, node := range network.Nodes() {
+					if len(node.Executed()) < 10 {
+						done = false
+					}
+				}
+				if done {
+					cancel()
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+		network.Run(ctx)
+
+		reference := network.Node(1).Executed()
+		var round uint64
+		for i, block := range reference {
+			value, ok := block.Beacon()
+			if !ok {
+				t.Fatalf("committed block %v does not include a beacon value", block)
+			}
+			if !beacon.VerifyRandomness(value) || value.Round < round {
+				t.Errorf("committed block %d has an invalid beacon value for round %d", i, value.Round)
+			}
+			round = value.Round
+		}
+		for _, node := range network.Nodes() {
+			executed := node.Executed()
+			for i := 0; i < len(executed) && i < len(reference); i++ {
+				value, _ := executed[i].Beacon()
+				if want, _ := reference[i].Beacon(); !value.Equals(want) {
+					t.Errorf("replica %d committed beacon round %d at height %d, replica 1 committed round %d",
+						node.ID(), value.Round, i, want.Round)
+				}
+			}
+			// the leader rotation and other modules use the randomness of the last committed block.
+			got, _ := consensus.CommittedRandomness(node.Modules())
+			if want, _ := executed[len(executed)-1].Beacon(); !got.Equals(want) {
+				t.Errorf("replica %d: got committed randomness of round %d, want round %d", node.ID(), got.Round, want.Round)
+			}
+		}
+	})
+
+	t.Run("InvalidValue", func(t *testing.T) {
+		for _, valid := range []bool{true, false} {
+			network, builders := testutil.CreateNetwork(t, 4)
+			for _, builder := range builders {
+				builder.Register(beacon)
+			}
+			builders.Build()
+			hs := network.Node(2).Modules()
+
+			var proposals int32
+			hs.MetricsEventLoop().RegisterObserver(consensus.ProposalReceivedEvent{}, func(_ interface{}) {
+				atomic.AddInt32(&proposals, 1)
+			})
+
+			value, _ := beacon.Randomness(context.Background())
+			if !valid {
+				value.Value = beacon.output(value.Round + 1)
+			}
+			leader := hs.LeaderRotation().GetLeader(1)
+			genesis := consensus.GetGenesis()
+			block := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "foo", 1, leader)
+			hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: leader, Block: block.WithBeacon(value)})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			hs.Run(ctx)
+			cancel()
+
+			if got := atomic.LoadInt32(&proposals); valid && got != 1 {
+				t.Error("expected the proposal with a valid beacon value to be accepted")
+			} else if !valid && got != 0 {
+				t.Error("expected the proposal with an invalid beacon value to be rejected")
+			}
+		}
+	})
+}
+
+// listQueue proposes commands that consist of a fixed number of comma-separated client commands of a fixed size.
+type listQueue struct {
+	commands int
+	size     int
+	next     int64
+}
+
+func (q *listQueue) Get(_ context.Context) (consensus.Command, bool) {
+	cmds := make([]string, q.commands)
+	for i := range cmds {
+		cmds[i] = fmt.Sprintf("%0*d", q.size, atomic.AddInt64(&q.next, 1))
+	}
+	return consensus.Command(strings.Join(cmds, ",")), true
+}
+
+func (q *listQueue) Accept(_ consensus.Command) bool { return true }
+
+func (q *listQueue) Proposed(_ consensus.Command) {}
+
+func (q *listQueue) CountCommands(cmd consensus.Command) (int, bool) {
+	return strings.Count(string(cmd), ",") + 1, true
+}
+
+func TestProposalLimits(t *testing.T) {
+	const (
+		n         = 4
+		byzantine = hotstuff.ID(1)
+	)
+	tests := []struct {
+		name   string
+		honest listQueue
+		stuffy listQueue
+	}{
+		{"Commands", listQueue{commands: 2, size: 8}, listQueue{commands: 5, size: 8}},
+		{"Bytes", listQueue{commands: 1, size: 8}, listQueue{commands: 1, size: 200}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			network, builders := testutil.CreateNetwork(t, n)
+			for i, builder := range builders {
+				queue := test.honest
+				if hotstuff.ID(i+1) == byzantine {
+					queue = test.stuffy
+				}
+				builder.Register(&queue)
+				builder.Options().SetProposalLimits(3, 100)
+			}
+			builders.Build()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			go func() {
+				for ctx.Err() == nil {
+					done := true
+					for _, node := range network.Nodes() {
+						if len(node.Executed()) < 10 {
+							done = false
+						}
+					}
+					if done {
+						cancel()
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+			}()
+			network.Run(ctx)
+
+			for _, node := range network.Nodes() {
+				executed := node.Executed()
+				if len(executed) < 10 {
+					t.Errorf("replica %d only executed %d blocks", node.ID(), len(executed))
+				}
+				for _, block := range executed {
+					if block.Proposer() == byzantine {
+						t.Errorf("replica %d executed block %v with command %.20q, which exceeds the limits",
+							node.ID(), block, block.Command())
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestProposalLimitsVote checks that a replica does not vote for a proposal that exceeds the proposal limits,
+// and that it can still vote for a valid proposal in the same view afterwards.
+func TestProposalLimitsVote(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders[0].Options().SetProposalLimits(0, 10)
+	builders[0].Options().SetShouldEmitVoteEvents()
+	builders.Build()
+	hs := network.Node(1).Modules()
+
+	var votes []consensus.VoteSentEvent
+	hs.MetricsEventLoop().RegisterObserver(consensus.VoteSentEvent{}, func(event interface{}) {
+		votes = append(votes, event.(consensus.VoteSentEvent))
+	})
+	drained, cancel := context.WithCancel(context.Background())
+	cancel()
+	propose := func(block *consensus.Block) {
+		hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: block.Proposer(), Block: block})
+		hs.EventLoop().Run(drained)
+		hs.MetricsEventLoop().Run(drained)
+	}
+
+	genesis := consensus.GetGenesis()
+	genesisQC := consensus.NewQuorumCert(nil, 0, genesis.Hash())
+	leader := hs.LeaderRotation().GetLeader(1)
+	propose(consensus.NewBlock(genesis.Hash(), genesisQC, consensus.Command(strings.Repeat("x", 100)), 1, leader))
+	if len(votes) > 0 {
+		t.Fatalf("voted for block %.8s, which exceeds the proposal limits", votes[0].BlockHash)
+	}
+
+	valid := consensus.NewBlock(genesis.Hash(), genesisQC, "valid", 1, leader)
+	propose(valid)
+	if len(votes) != 1 || votes[0].BlockHash != valid.Hash() {
+		t.Errorf("got votes %v, want a vote for the valid block in the same view", votes)
+	}
+}
+
+func TestCheckpointPinning(t *testing.T) {
+	const (
+		n        = 4
+		interval = 5
+		victim   = hotstuff.ID(4)
+	)
+	dir := t.TempDir()
+	network, builders := testutil.CreateNetwork(t, n)
+	for _, builder := range builders {
+		builder.Options().SetCheckpointInterval(interval)
+	}
+	builders[victim-1].Register(store.NewFileStore(dir))
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(network.Node(victim).Executed()) < 3*interval {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	executed := make(map[consensus.View]consensus.Hash)
+	for _, block := range network.Node(victim).Executed() {
+		executed[block.View()] = block.Hash()
+	}
+	pins, err := store.NewFileStore(dir).LoadCheckpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) < 2 {
+		t.Fatalf("expected at least two pinned checkpoints, got %d", len(pins))
+	}
+	for _, pin := range pins {
+		if executed[pin.View] != pin.Hash {
+			t.Errorf("pinned checkpoint %.8s at view %d was not committed", pin.Hash, pin.View)
+		}
+	}
+
+	// the other replicas sign an alternative history from genesis that omits the checkpoints.
+	latest := pins[len(pins)-1]
+	genesis := consensus.GetGenesis()
+	parent, qc := genesis, consensus.NewQuorumCert(nil, 0, genesis.Hash())
+	var fork []*consensus.Block
+	for view := consensus.View(1); view <= latest.View+1; view++ {
+		block := consensus.NewBlock(parent.Hash(), qc, "fork", view, 1)
+		var pcs []consensus.PartialCert
+		for _, node := range network.Nodes()[:n-1] {
+			pc, err := node.Modules().Crypto().CreatePartialCert(block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pcs = append(pcs, pc)
+		}
+		if qc, err = network.Node(1).Modules().Crypto().CreateQuorumCert(block, pcs); err != nil {
+			t.Fatal(err)
+		}
+		fork = append(fork, block)
+		parent = block
+	}
+
+	// restart the replica without any state, except for the pinned checkpoints if it uses the store.
+	restart := func(pinned bool) *consensus.Modules {
+		builder := network.Restart(victim)
+		builder.Options().SetCheckpointInterval(interval)
+		if pinned {
+			builder.Register(store.NewFileStore(dir))
+		}
+		builder.Build()
+		hs := network.Node(victim).Modules()
+		for _, block := range fork {
+			hs.BlockChain().Store(block)
+		}
+		return hs
+	}
+
+	if err := testutil.ForceCommit(restart(false), qc); err != nil {
+		t.Fatalf("expected a replica without checkpoints to accept the alternative history: %v", err)
+	}
+
+	hs := restart(true)
+	if err := testutil.ForceCommit(hs, qc); err == nil || !strings.Contains(err.Error(), "pinned checkpoint") {
+		t.Errorf("expected the alternative history to be rejected due to the pinned checkpoint, got: %v", err)
+	}
+	if len(network.Node(victim).Executed()) > 0 {
+		t.Error("expected no blocks to be executed")
+	}
+
+	// a proposal that extends the alternative history is not voted for either.
+	// The replica proposes it itself, such that its vote is delivered to itself.
+	var votes int32
+	hs.EventLoop().RegisterObserver(consensus.VoteMsg{}, func(_ interface{}) {
+		atomic.AddInt32(&votes, 1)
+	})
+	view := latest.View + 2
+	for hs.LeaderRotation().GetLeader(view) != victim {
+		view++
+	}
+	proposal := consensus.NewBlock(parent.Hash(), qc, "fork", view, victim)
+	hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: proposal.Proposer(), Block: proposal})
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	hs.Run(ctx)
+	if atomic.LoadInt32(&votes) > 0 {
+		t.Error("expected no vote for a proposal that extends the alternative history")
+	}
+}
+
+// emptyQueue makes a leader propose filler blocks with an empty command.
+type emptyQueue struct{}
+
+func (emptyQueue) Get(_ context.Context) (consensus.Command, bool) {
+	return "", true
+}
+
+// TestSkipEmptyBlocks checks that blocks with an empty command are committed, but not executed,
+// when the ShouldSkipEmptyBlocks option is set.
+func TestSkipEmptyBlocks(t *testing.T) {
+	const filler = hotstuff.ID(2)
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders[filler-1].Register(emptyQueue{})
+	for _, builder := range builders {
+		builder.Options().SetShouldSkipEmptyBlocks()
+	}
+	builders.Build()
+
+	node := network.Node(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(node.Executed()) < 10 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	executed := node.Executed()
+	if len(executed) < 10 {
+		t.Fatalf("only %d blocks were executed", len(executed))
+	}
+	for _, block := range executed {
+		if block.Proposer() == filler || block.Command() == "" {
+			t.Errorf("the empty block %.8s at view %d was executed", block.Hash(), block.View())
+		}
+	}
+
+	// the empty blocks are still part of the committed chain.
+	hs := node.Modules()
+	empty := 0
+	for block := hs.Consensus().CommittedBlock(); block.View() > 0; {
+		if block.IsEmpty() {
+			empty++
+		}
+		var ok bool
+		if block, ok = hs.BlockChain().LocalGet(block.Parent()); !ok {
+			t.Fatal("the committed chain is incomplete")
+		}
+	}
+	if empty == 0 {
+		t.Error("expected empty blocks to be committed")
+	}
+}
+
+// TestBandwidth checks that large batches take longer to propagate over links with limited bandwidth,
+// and that the replicas still commit under the constraint.
+func TestBandwidth(t *testing.T) {
+	link := testutil.Link{Bandwidth: 1 << 20, Burst: 1 << 10, Latency: time.Millisecond, Jitter: time.Millisecond}
+
+	t.Run("Deterministic", func(t *testing.T) {
+		a, b := testutil.NewBandwidth(link, 1), testutil.NewBandwidth(link, 1)
+		now := time.Now()
+		for i := 0; i < 100; i++ {
+			now = now.Add(time.Millisecond)
+			if da, db := a.DelayMessage(1, 2, i*100, now), b.DelayMessage(1, 2, i*100, now); da != db {
+				t.Fatalf("message %d: got different delays with the same seed: %v and %v", i, da, db)
+			}
+		}
+	})
+
+	const blocks = 10
+	commitTime := func(t *testing.T, commands, size int) time.Duration {
+		network, builders := testutil.CreateNetwork(t, 4)
+		network.SetDelayModel(testutil.NewBandwidth(link, 1))
+		for _, builder := range builders {
+			builder.Register(&listQueue{commands: commands, size: size})
+		}
+		builders.Build()
+
+		node := network.Node(1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		var elapsed time.Duration
+		start := time.Now()
+		go func() {
+			for ctx.Err() == nil && len(node.Executed()) < blocks {
+				time.Sleep(time.Millisecond)
+			}
+			elapsed = time.Since(start)
+			cancel()
+		}()
+		network.Run(ctx)
+
+		if n := len(node.Executed()); n < blocks {
+			t.Fatalf("only %d blocks were committed under the bandwidth constraint", n)
+		}
+		return elapsed
+	}
+
+	// a proposal of the large batches is about 30 kB, which takes about 30 ms to send over each link.
+	small := commitTime(t, 1, 10)
+	large := commitTime(t, 300, 100)
+	t.Logf("time to commit %d blocks: small batches: %v, large batches: %v", blocks, small, large)
+	if large < 2*small {
+		t.Errorf("expected large batches to take measurably longer: small batches: %v, large batches: %v", small, large)
+	}
+}
+
+// silentQueue makes a leader skip its proposals, as if it had failed.
+type silentQueue struct{}
+
+func (silentQueue) Get(_ context.Context) (consensus.Command, bool) {
+	return "", false
+}
+
+// TestFallbackLeader checks that the replicas agree on a fallback leader when several consecutive leaders fail,
+// and that they resume committing.
+func TestFallbackLeader(t *testing.T) {
+	const (
+		n    = 4
+		good = hotstuff.ID(1)
+	)
+	network, builders := testutil.CreateNetwork(t, n)
+	for i, builder := range builders {
+		// the replicas other than the good replica vote, but never propose.
+		if hotstuff.ID(i+1) != good {
+			builder.Register(silentQueue{})
+		}
+		builder.Register(synchronizer.New(testutil.FixedTimeout(50)))
+		builder.Options().SetFallbackLeaderAfter(4)
+	}
+	builders.Build()
+
+	node := network.Node(good)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(node.Executed()) < 10 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	executed := node.Executed()
+	if len(executed) < 10 {
+		t.Fatalf("only %d blocks were committed", len(executed))
+	}
+	fallback := 0
+	for _, block := range executed {
+		// the good replica only leads every n'th view by round-robin.
+		if block.View()%n+1 != consensus.View(good) {
+			fallback++
+		}
+	}
+	if fallback == 0 {
+		t.Error("expected blocks to be committed in views where the good replica was the fallback leader")
+	}
+}
+
+// creditedRoundRobin is a round-robin leader rotation that counts the committed blocks it is credited with.
+type creditedRoundRobin struct {
+	consensus.LeaderRotation
+	mut     sync.Mutex
+	credits int
+}
+
+func (r *creditedRoundRobin) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	r.LeaderRotation.(consensus.Module).InitConsensusModule(mods, opts)
+}
+
+func (r *creditedRoundRobin) CreditProposer(_ *consensus.Block) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.credits++
+}
+
+func (r *creditedRoundRobin) Credits() int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.credits
+}
+
+// TestFallbackLeaderCreditsProposer checks that a leader rotation is credited with the committed blocks
+// when it is overridden by the genesis and fallback leaders.
+func TestFallbackLeaderCreditsProposer(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	rotations := make([]*creditedRoundRobin, len(builders))
+	for i, builder := range builders {
+		rotations[i] = &creditedRoundRobin{LeaderRotation: leaderrotation.NewRoundRobin()}
+		builder.Register(rotations[i])
+		builder.Options().SetGenesisLeader(1)
+		builder.Options().SetFallbackLeaderAfter(4)
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(network.Node(1).Executed()) < 5 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	if executed, credits := len(network.Node(1).Executed()), rotations[0].Credits(); credits != executed {
+		t.Errorf("expected the leader rotation to be credited with %d blocks, got %d", executed, credits)
+	}
+}
+
+// TestSpeculativeProposal checks that a leader that enters a view through a timeout holds back its proposal
+// until a quorum of NewView messages has arrived, and that the proposal is replaced if one of them carries a higher QC.
+func TestSpeculativeProposal(t *testing.T) {
+	for _, higherQC := range []bool{false, true} {
+		t.Run(fmt.Sprintf("HigherQC=%v", higherQC), func(t *testing.T) {
+			const view = consensus.View(3)
+			network, builders := testutil.CreateNetwork(t, 4)
+			for _, builder := range builders {
+				builder.Register(synchronizer.New(testutil.FixedTimeout(10000)))
+				builder.Options().SetShouldProposeSpeculatively()
+			}
+			builders.Build()
+
+			nodes := network.Nodes()
+			leader := network.Node(nodes[0].Modules().LeaderRotation().GetLeader(view))
+			hs := leader.Modules()
+			var others []hotstuff.ID
+			for _, node := range nodes {
+				if node.ID() != leader.ID() {
+					others = append(others, node.ID())
+				}
+			}
+
+			// the block of view 1 was certified, but the leader did not receive the QC before views 1 and 2 timed out.
+			genesisQC := hs.Synchronizer().HighQC()
+			b1 := consensus.NewBlock(consensus.GetGenesis().Hash(), genesisQC, "certified", 1, hs.LeaderRotation().GetLeader(1))
+			hs.BlockChain().Store(b1)
+			var (
+				votes    []consensus.PartialCert
+				timeouts []consensus.TimeoutMsg
+			)
+			for _, node := range nodes[:3] {
+				pc, err := node.Modules().Crypto().CreatePartialCert(b1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				votes = append(votes, pc)
+				sig, err := node.Modules().Crypto().Sign((view - 1).ToHash())
+				if err != nil {
+					t.Fatal(err)
+				}
+				timeouts = append(timeouts, consensus.TimeoutMsg{
+					ID:            node.ID(),
+					View:          view - 1,
+					SyncInfo:      consensus.NewSyncInfo().WithQC(genesisQC),
+					ViewSignature: sig,
+				})
+			}
+			qc1, err := hs.Crypto().CreateQuorumCert(b1, votes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc, err := hs.Crypto().CreateTimeoutCert(view-1, timeouts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				mut    sync.Mutex
+				events []consensus.SpeculativeProposalEvent
+			)
+			hs.MetricsEventLoop().RegisterObserver(consensus.SpeculativeProposalEvent{}, func(event interface{}) {
+				mut.Lock()
+				events = append(events, event.(consensus.SpeculativeProposalEvent))
+				mut.Unlock()
+			})
+			run := func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+				defer cancel()
+				hs.Run(ctx)
+			}
+
+			// the first NewView message makes the leader enter the view, but it does not complete a quorum.
+			hs.EventLoop().AddEvent(consensus.NewViewMsg{ID: others[0], SyncInfo: consensus.NewSyncInfo().WithQC(genesisQC).WithTC(tc)})
+			run()
+			if hs.Synchronizer().View() != view {
+				t.Fatalf("expected the leader to be in view %d, got %d", view, hs.Synchronizer().View())
+			}
+			mut.Lock()
+			if len(events) > 0 {
+				t.Errorf("expected the proposal to be held back, got %v", events)
+			}
+			mut.Unlock()
+
+			reported := genesisQC
+			if higherQC {
+				reported = qc1
+			}
+			hs.EventLoop().AddEvent(consensus.NewViewMsg{ID: others[1], SyncInfo: consensus.NewSyncInfo().WithQC(reported).WithTC(tc)})
+			run()
+
+			mut.Lock()
+			defer mut.Unlock()
+			wantEvents := 1
+			if higherQC {
+				wantEvents = 2
+			}
+			if len(events) != wantEvents {
+				t.Fatalf("expected %d speculative proposal events, got %d", wantEvents, len(events))
+			}
+			sent := events[len(events)-1]
+			if !sent.Sent {
+				t.Fatal("expected the speculative proposal to be sent")
+			}
+			if sent.Block.View() != view {
+				t.Errorf("expected the proposal to be for view %d, got %d", view, sent.Block.View())
+			}
+			if hash := sent.Block.QuorumCert().BlockHash(); hash != reported.BlockHash() {
+				t.Errorf("expected the proposal to extend %.8s, got %.8s", reported.BlockHash(), hash)
+			}
+			if _, ok := hs.BlockChain().LocalGet(sent.Block.Hash()); !ok {
+				t.Error("the sent proposal was not stored")
+			}
+			if higherQC {
+				discarded := events[0]
+				if discarded.Sent || discarded.Block.QuorumCert().BlockHash() != genesisQC.BlockHash() {
+					t.Errorf("expected the proposal on the genesis QC to be discarded, got %v", discarded)
+				}
+				if discarded.Block.Command() != sent.Block.Command() {
+					t.Error("expected the replacement proposal to carry the command of the discarded proposal")
+				}
+			}
+		})
+	}
+}
+
+func TestExplicitDecision(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetCommitMode(consensus.ExplicitDecision)
+	}
+	builders.Build()
+
+	node := network.Node(2)
+	var decisions int32
+	node.Modules().MetricsEventLoop().RegisterObserver(consensus.DecisionEvent{}, func(event interface{}) {
+		if event.(consensus.DecisionEvent).ID != node.ID() {
+			atomic.AddInt32(&decisions, 1)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(node.Executed()) < 10 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	if executed := len(node.Executed()); executed < 10 {
+		t.Fatalf("only %d blocks were committed", executed)
+	}
+	if atomic.LoadInt32(&decisions) == 0 {
+		t.Error("expected the blocks to be committed by decisions from the leaders")
+	}
+}
+
+// TestForgedDecision checks that a replica in the ExplicitDecision commit mode does not commit a block
+// when it receives the finalizing QC in a proposal, nor when it receives a decision with a forged QC,
+// but commits the block when it receives a valid decision.
+func TestForgedDecision(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Register(synchronizer.New(testutil.FixedTimeout(10000)))
+		builder.Options().SetCommitMode(consensus.ExplicitDecision)
+	}
+	builders.Build()
+
+	nodes := network.Nodes()
+	node := network.Node(2)
+	hs := node.Modules()
+	leader := hs.LeaderRotation().GetLeader
+
+	// b1 <- b2 <- b3 is a three-chain, so the QC for b3 decides b1.
+	var (
+		blocks []*consensus.Block
+		qcs    []consensus.QuorumCert
+	)
+	qc := hs.Synchronizer().HighQC()
+	parent := consensus.GetGenesis()
+	for view := consensus.View(1); view <= 3; view++ {
+		block := consensus.NewBlock(parent.Hash(), qc, consensus.Command(fmt.Sprint(view)), view, leader(view))
+		hs.BlockChain().Store(block)
+		var votes []consensus.PartialCert
+		for _, n := range nodes[:3] {
+			votes = append(votes, testutil.CreatePC(t, block, n.Modules().Crypto()))
+		}
+		var err error
+		qc, err = hs.Crypto().CreateQuorumCert(block, votes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+		qcs = append(qcs, qc)
+		parent = block
+	}
+
+	var (
+		mut       sync.Mutex
+		decisions []consensus.DecisionEvent
+	)
+	hs.MetricsEventLoop().RegisterObserver(consensus.DecisionEvent{}, func(event interface{}) {
+		mut.Lock()
+		decisions = append(decisions, event.(consensus.DecisionEvent))
+		mut.Unlock()
+	})
+	run := func(event interface{}) {
+		hs.EventLoop().AddEvent(event)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		hs.Run(ctx)
+	}
+
+	// the proposal for view 4 carries the finalizing QC, but only the decision of the leader commits b1.
+	b4 := consensus.NewBlock(blocks[2].Hash(), qcs[2], "4", 4, leader(4))
+	run(consensus.ProposeMsg{ID: leader(4), Block: b4})
+	if executed := node.Executed(); len(executed) > 0 {
+		t.Fatalf("expected no blocks to be committed without a decision, got %v", executed)
+	}
+
+	// the signature of the QC for b2 does not certify b3.
+	forged := consensus.NewQuorumCert(qcs[1].Signature(), qcs[2].View(), qcs[2].BlockHash())
+	run(consensus.DecideMsg{ID: leader(4), QC: forged})
+	if executed := node.Executed(); len(executed) > 0 {
+		t.Fatalf("expected the forged decision to be rejected, got %v", executed)
+	}
+
+	run(consensus.DecideMsg{ID: leader(4), QC: qcs[2]})
+	executed := node.Executed()
+	if len(executed) != 1 || executed[0].Hash() != blocks[0].Hash() {
+		t.Fatalf("expected b1 to be committed, got %v", executed)
+	}
+	mut.Lock()
+	defer mut.Unlock()
+	if len(decisions) != 1 || decisions[0].Block.Hash() != blocks[0].Hash() || decisions[0].ID != leader(4) {
+		t.Errorf("expected a single decision event for b1, got %v", decisions)
+	}
+}
+
+// kvStore executes comma-separated client commands of the form "key:suffix", which append the suffix to the value of the key.
+// It schedules the commands such that commands on the same key conflict, and records how many commands run at once.
+type kvStore struct {
+	mut         sync.Mutex
+	values      map[string]string
+	active      map[string]bool
+	running     int
+	maxRunning  int
+	overlapping bool // true if two commands on the same key ran at the same time
+}
+
+func newKVStore() *kvStore {
+	return &kvStore{values: make(map[string]string), active: make(map[string]bool)}
+}
+
+func kvKey(cmd consensus.Command) []string {
+	return []string{strings.SplitN(string(cmd), ":", 2)[0]}
+}
+
+func (kv *kvStore) Schedule(cmd consensus.Command) (consensus.Schedule, bool) {
+	var cmds []consensus.Command
+	for _, c := range strings.Split(string(cmd), ",") {
+		if !strings.Contains(c, ":") {
+			return consensus.Schedule{}, false
+		}
+		cmds = append(cmds, consensus.Command(c))
+	}
+	return consensus.ScheduleByKeys(cmds, kvKey), true
+}
+
+func (kv *kvStore) ExecCommand(cmd consensus.Command) error {
+	parts := strings.SplitN(string(cmd), ":", 2)
+	kv.mut.Lock()
+	kv.running++
+	if kv.running > kv.maxRunning {
+		kv.maxRunning = kv.running
+	}
+	if kv.active[parts[0]] {
+		kv.overlapping = true
+	}
+	kv.active[parts[0]] = true
+	kv.mut.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	kv.mut.Lock()
+	defer kv.mut.Unlock()
+	kv.values[parts[0]] += parts[1]
+	kv.active[parts[0]] = false
+	kv.running--
+	return nil
+}
+
+// TestParallelExecution checks that the commands of a committed block that access different keys execute concurrently,
+// and that commands on the same key execute in the order of the block, such that the outcome matches serial execution.
+func TestParallelExecution(t *testing.T) {
+	const workers = 3
+	network, builders := testutil.CreateNetwork(t, 4)
+	stores := make([]*kvStore, len(builders))
+	for i, builder := range builders {
+		stores[i] = newKVStore()
+		builder.Register(stores[i])
+		builder.Options().SetExecutionWorkers(workers)
+	}
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()),
+		"a:1,b:1,c:1,d:1,a:2,b:2,a:3,e:1,c:2,a:4", 1, 2)
+	b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "b:3,a:5,f:1", 2, 3)
+	hs := network.Node(1).Modules()
+	hs.BlockChain().Store(b1)
+	hs.BlockChain().Store(b2)
+	if err := testutil.ForceCommit(hs, testutil.CreateQC(t, b2, signers)); err != nil {
+		t.Fatal(err)
+	}
+
+	// the outcome of executing the commands one by one in the order of the blocks.
+	want := map[string]string{"a": "12345", "b": "123", "c": "12", "d": "1", "e": "1", "f": "1"}
+	kv := stores[0]
+	kv.mut.Lock()
+	defer kv.mut.Unlock()
+	for key, value := range want {
+		if got := kv.values[key]; got != value {
+			t.Errorf("key %s: got %q, want %q", key, got, value)
+		}
+	}
+	if kv.overlapping {
+		t.Error("conflicting commands executed concurrently")
+	}
+	if kv.maxRunning < 2 {
+		t.Error("expected independent commands to execute concurrently")
+	}
+	if kv.maxRunning > workers {
+		t.Errorf("%d commands executed concurrently, but only %d workers are allowed", kv.maxRunning, workers)
+	}
+	if executed := network.Node(1).Executed(); len(executed) != 0 {
+		t.Errorf("expected the scheduled executor to execute the blocks, but the executor executed %d blocks", len(executed))
+	}
+}
+
+// batchAcceptor accepts comma-separated batches of client commands.
+type batchAcceptor struct{}
+
+func (batchAcceptor) Accept(_ consensus.Command) bool { return true }
+
+func (batchAcceptor) Proposed(_ consensus.Command) {}
+
+func (batchAcceptor) SplitCommands(cmd consensus.Command) ([]consensus.Command, bool) {
+	var cmds []consensus.Command
+	for _, c := range strings.Split(string(cmd), ",") {
+		cmds = append(cmds, consensus.Command(c))
+	}
+	return cmds, true
+}
+
+// TestCommandProof checks that a replica can prove that a client command was committed,
+// and that the proof does not verify if it is tampered with.
+func TestCommandProof(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Register(batchAcceptor{})
+		builder.Options().SetShouldCommitCommandRoot()
+	}
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	cmds := []consensus.Command{"a", "b", "c", "d", "e"}
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "a,b,c,d,e", 1, 2).
+		WithCommandRoot(consensus.CommandRoot(cmds))
+	b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "f", 2, 3).
+		WithCommandRoot(consensus.CommandRoot([]consensus.Command{"f"}))
+	hs := network.Node(1).Modules()
+	hs.BlockChain().Store(b1)
+	hs.BlockChain().Store(b2)
+
+	if _, err := hs.ProveCommand(b1.Hash(), "b"); err == nil {
+		t.Error("expected no proof for a block that is not committed")
+	}
+	if err := testutil.ForceCommit(hs, testutil.CreateQC(t, b2, signers)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, cmd := range cmds {
+		proof, err := hs.ProveCommand(b1.Hash(), cmd)
+		if err != nil {
+			t.Fatalf("failed to prove command %q: %v", cmd, err)
+		}
+		if proof.Index != i || proof.BlockHash() != b1.Hash() {
+			t.Errorf("command %q: got index %d in block %.8s, want index %d in block %.8s", cmd, proof.Index, proof.BlockHash(), i, b1.Hash())
+		}
+		if proof.Header.Command() != "" {
+			t.Errorf("command %q: the proof includes the command of the block", cmd)
+		}
+		if !consensus.VerifyCommandProof(hs.Crypto(), proof) {
+			t.Errorf("command %q: the proof does not verify", cmd)
+		}
+	}
+
+	proof, err := hs.ProveCommand(b1.Hash(), "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := proof
+	tampered.Command = "x"
+	if consensus.VerifyCommandProof(hs.Crypto(), tampered) {
+		t.Error("a proof for another command verifies")
+	}
+	tampered = proof
+	tampered.Index = 3
+	if consensus.VerifyCommandProof(hs.Crypto(), tampered) {
+		t.Error("a proof with another index verifies")
+	}
+	tampered = proof
+	tampered.Path = append([]consensus.Hash(nil), proof.Path...)
+	tampered.Path[0][0] ^= 1
+	if consensus.VerifyCommandProof(hs.Crypto(), tampered) {
+		t.Error("a proof with a modified path verifies")
+	}
+	tampered = proof
+	tampered.QC = testutil.CreateQC(t, b2, signers)
+	if consensus.VerifyCommandProof(hs.Crypto(), tampered) {
+		t.Error("a proof with the QC of another block verifies")
+	}
+
+	if _, err := hs.ProveCommand(b1.Hash(), "f"); err == nil {
+		t.Error("expected no proof for a command that is not in the block")
+	}
+	if _, err := hs.ProveCommand(b2.Hash(), "f"); err == nil {
+		t.Error("expected no proof for a block without a known QC")
+	}
+}
+
+// TestCommandRootProposals checks that replicas agree on blocks that commit to the root of their commands.
+func TestCommandRootProposals(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetShouldCommitCommandRoot()
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		network.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	for {
+		if executed := network.Node(1).Executed(); len(executed) > 0 {
+			for _, block := range executed {
+				root, ok := block.CommandRoot()
+				if !ok || root != consensus.CommandRoot([]consensus.Command{block.Command()}) {
+					t.Errorf("block %.8s does not commit to the root of its command", block.Hash())
+				}
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("no blocks were committed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// pendingQueue is a command queue that reports how many commands it holds, without handing any out.
+type pendingQueue struct {
+	pending int
+}
+
+func (q *pendingQueue) Get(_ context.Context) (consensus.Command, bool) {
+	return "", false
+}
+
+func (q *pendingQueue) Pending() int {
+	return q.pending
+}
+
+// TestDryRunPropose checks that the dry run reports the specific reason why a replica cannot propose.
+func TestDryRunPropose(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	queue := &pendingQueue{}
+	builders[0].Register(queue)
+	hl := builders.Build()
+	signers := hl.Signers()
+	hs := network.Node(1).Modules()
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 2)
+	qc := testutil.CreateQC(t, b1, signers)
+	cert := consensus.NewSyncInfo().WithQC(qc)
+
+	if err := hs.Consensus().DryRunPropose(consensus.NewSyncInfo()); !errors.Is(err, consensus.ErrNoHighQC) {
+		t.Errorf("without a QC: got %v, want %v", err, consensus.ErrNoHighQC)
+	}
+	if err := hs.Consensus().DryRunPropose(cert); !errors.Is(err, consensus.ErrMissingQCBlock) {
+		t.Errorf("without the block of the QC: got %v, want %v", err, consensus.ErrMissingQCBlock)
+	}
+
+	hs.BlockChain().Store(b1)
+	forged := consensus.NewSyncInfo().WithQC(consensus.NewQuorumCert(nil, 1, b1.Hash()))
+	if err := hs.Consensus().DryRunPropose(forged); !errors.Is(err, consensus.ErrInvalidQC) {
+		t.Errorf("with an invalid QC: got %v, want %v", err, consensus.ErrInvalidQC)
+	}
+	// the queue is empty, but b1 is not committed yet, so an empty proposal would help commit it.
+	if err := hs.Consensus().DryRunPropose(cert); err != nil {
+		t.Errorf("with an uncommitted block: got %v, want no blocker", err)
+	}
+
+	if err := testutil.ForceCommit(hs, qc); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.Consensus().DryRunPropose(cert); !errors.Is(err, consensus.ErrNoCommands) {
+		t.Errorf("with an empty queue: got %v, want %v", err, consensus.ErrNoCommands)
+	}
+	queue.pending = 1
+	if err := hs.Consensus().DryRunPropose(cert); err != nil {
+		t.Errorf("with a pending command: got %v, want no blocker", err)
+	}
+
+	hs.Quiesce()
+	if err := hs.Consensus().DryRunPropose(cert); !errors.Is(err, consensus.ErrQuiescing) {
+		t.Errorf("while quiescing: got %v, want %v", err, consensus.ErrQuiescing)
+	}
+}
+
+// TestViewChangeBundle checks that a replica that missed a view change accepts the first proposal after it
+// because of the embedded view-change bundle, and rejects the proposal if the bundle is missing or does not fit.
+func TestViewChangeBundle(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Options().SetShouldBundleViewChanges()
+		builder.Options().SetShouldEmitVoteEvents()
+	}
+	builders.Build()
+	nodes := network.Nodes()
+
+	leaderID := nodes[0].Modules().LeaderRotation().GetLeader(3)
+	var others []hotstuff.ID
+	for _, node := range nodes {
+		if node.ID() != leaderID {
+			others = append(others, node.ID())
+		}
+	}
+	leader := network.Node(leaderID).Modules()
+	receiver, idle := network.Node(others[0]), network.Node(others[1]).Modules()
+
+	// the block of view 1 was certified, and view 2 timed out. The receiver and the idle replica saw none of it.
+	genesisQC := leader.Synchronizer().HighQC()
+	b1 := consensus.NewBlock(consensus.GetGenesis().Hash(), genesisQC, "b1", 1, leader.LeaderRotation().GetLeader(1))
+	var (
+		votes    []consensus.PartialCert
+		timeouts = make(map[consensus.View][]consensus.TimeoutMsg)
+	)
+	for _, node := range nodes {
+		node.Modules().BlockChain().Store(b1)
+	}
+	for _, node := range nodes[:3] {
+		pc, err := node.Modules().Crypto().CreatePartialCert(b1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		votes = append(votes, pc)
+		for _, view := range []consensus.View{1, 2} {
+			sig, err := node.Modules().Crypto().Sign(view.ToHash())
+			if err != nil {
+				t.Fatal(err)
+			}
+			timeouts[view] = append(timeouts[view], consensus.TimeoutMsg{ID: node.ID(), View: view, ViewSignature: sig})
+		}
+	}
+	qc1, err := leader.Crypto().CreateQuorumCert(b1, votes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc1, err := leader.Crypto().CreateTimeoutCert(1, timeouts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc2, err := leader.Crypto().CreateTimeoutCert(2, timeouts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proposals := make(chan consensus.ProposeMsg, 1)
+	network.SetTap(func(from, to hotstuff.ID, msg interface{}) {
+		if proposal, ok := msg.(consensus.ProposeMsg); ok && from == leaderID && proposal.Block.View() == 3 {
+			select {
+			case proposals <- proposal:
+			default:
+			}
+		}
+	})
+	voted := make(chan consensus.VoteSentEvent, 1)
+	receiver.Modules().MetricsEventLoop().RegisterObserver(consensus.VoteSentEvent{}, func(event interface{}) {
+		if vote := event.(consensus.VoteSentEvent); vote.View == 3 {
+			select {
+			case voted <- vote:
+			default:
+			}
+		}
+	})
+
+	// the leader completes the view change and proposes for view 3.
+	leader.EventLoop().AddEvent(consensus.NewViewMsg{ID: others[0], SyncInfo: consensus.NewSyncInfo().WithQC(qc1).WithTC(tc2)})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		network.Run(ctx, leaderID, receiver.ID())
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var proposal consensus.ProposeMsg
+	select {
+	case proposal = <-proposals:
+	case <-time.After(time.Second):
+		t.Fatal("the leader did not propose for view 3")
+	}
+	if bundle := proposal.ViewChange; bundle == nil || bundle.TC.View() != 2 || !bundle.HighQC.Equals(qc1) {
+		t.Fatalf("expected the proposal to carry the TC for view 2 and the QC for view 1, got %v", bundle)
+	}
+	select {
+	case vote := <-voted:
+		if vote.BlockHash != proposal.Block.Hash() {
+			t.Errorf("the receiver voted for %.8s, want %.8s", vote.BlockHash, proposal.Block.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the receiver did not vote for the proposal")
+	}
+	cancel()
+	<-done
+	if view := receiver.Modules().Synchronizer().View(); view != 3 {
+		t.Errorf("expected the receiver to enter view 3, got view %d", view)
+	}
+
+	// deliver makes the idle replica, which is not running, handle the proposal.
+	deliver := func(proposal consensus.ProposeMsg) {
+		idle.EventLoop().AddEvent(proposal)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		idle.Run(ctx)
+	}
+	for _, tt := range []struct {
+		name   string
+		bundle *consensus.ViewChangeBundle
+	}{
+		{"no bundle", nil},
+		{"wrong view", &consensus.ViewChangeBundle{TC: tc1, HighQC: qc1}},
+		{"wrong QC", &consensus.ViewChangeBundle{TC: tc2, HighQC: genesisQC}},
+		{"forged TC", &consensus.ViewChangeBundle{TC: consensus.NewTimeoutCert(nil, 2), HighQC: qc1}},
+	} {
+		forged := proposal
+		forged.ViewChange = tt.bundle
+		deliver(forged)
+		if _, ok := idle.BlockChain().LocalGet(proposal.Block.Hash()); ok || idle.Synchronizer().View() != 1 {
+			t.Errorf("%s: expected the proposal to be rejected", tt.name)
+		}
+	}
+	deliver(proposal)
+	if _, ok := idle.BlockChain().LocalGet(proposal.Block.Hash()); !ok || idle.Synchronizer().View() != 3 {
+		t.Error("expected the proposal with the bundle to be accepted")
+	}
+}
+
+// trustingCrypto accepts every partial certificate, like a verifier with a bug would.
+type trustingCrypto struct {
+	consensus.Crypto
+}
+
+func (c trustingCrypto) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	if m, ok := c.Crypto.(consensus.Module); ok {
+		m.InitConsensusModule(mods, opts)
+	}
+}
+
+func (trustingCrypto) VerifyPartialCert(_ consensus.PartialCert) bool {
+	return true
+}
+
+// TestValidateVotes checks that a vote from a replica outside the configuration, which a faulty verifier let through,
+// is discarded before the QC is created, and that the QC is created from the valid votes.
+// BLS12 signatures are used, because they are aggregated without being verified again.
+func TestValidateVotes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	keys := testutil.GenerateKeys(t, 5, testutil.GenerateBLS12Key)
+	// replica 5 is not part of the configuration of the other replicas.
+	builders := testutil.CreateBuilders(t, ctrl, 4, keys[:4]...)
+	for _, builder := range builders {
+		builder.Register(crypto.New(bls12.New()))
+	}
+	cfg, _ := testutil.CreateMockConfigurationWithReplicas(t, ctrl, 4, keys[:4]...)
+	cfg.EXPECT().Replica(hotstuff.ID(5)).AnyTimes().Return(nil, false)
+	// the replica proposes once the QC is formed.
+	cfg.EXPECT().Propose(gomock.Any()).AnyTimes()
+	builders[0].Register(
+		cfg,
+		trustingCrypto{crypto.New(bls12.New())},
+		consensus.New(chainedhotstuff.New()),
+		synchronizer.New(testutil.FixedTimeout(100)),
+		leaderrotation.NewFixed(1),
+	)
+	builders[0].Options().SetShouldValidateVotes()
+	outsider := testutil.TestModules(t, ctrl, 5, keys[4])
+	outsider.Register(crypto.New(bls12.New()))
+	hl := append(builders.Build(), outsider.Build())
+	hs := hl[0]
+
+	genesis := consensus.GetGenesis()
+	b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 1)
+	hs.BlockChain().Store(b1)
+	vote := func(signer *consensus.Modules) {
+		pc, err := signer.Crypto().CreatePartialCert(b1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hs.EventLoop().AddEvent(consensus.VoteMsg{ID: signer.ID(), PartialCert: pc})
+	}
+
+	qcs := make(chan consensus.QuorumCert, 2)
+	hs.MetricsEventLoop().RegisterObserver(consensus.QCFormedEvent{}, func(event interface{}) {
+		qcs <- event.(consensus.QCFormedEvent).QC
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		hs.Run(ctx)
+		close(done)
+	}()
+	stop := func() {
+		cancel()
+		<-done
+	}
+
+	vote(hl[4])
+	vote(hl[1])
+	// the votes are verified concurrently, so the first two votes are given time to be collected.
+	time.Sleep(50 * time.Millisecond)
+	vote(hl[2])
+	select {
+	case qc := <-qcs:
+		t.Fatalf("a QC was formed from the vote of the outsider: %v", qc)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	vote(hl[3])
+	select {
+	case qc := <-qcs:
+		// the replica uses the QC for its next proposal, so it is stopped before the QC is verified.
+		stop()
+		if !hl[1].Crypto().VerifyQuorumCert(qc) {
+			t.Errorf("the QC does not verify: %v", qc)
+		}
+		if signers := qc.Signers(); len(signers) != 3 || signers[0] != 2 || signers[1] != 3 || signers[2] != 4 {
+			t.Errorf("got QC signers %v, want [2 3 4]", signers)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no QC was formed from the valid votes")
+	}
+}
+
+// TestStateStore checks that a replica that restarts with the safety state from its store refuses to vote again
+// in a view it already voted in, and refuses to vote for a block that conflicts with its locked block,
+// while a replica that restarts without the store votes for both.
+func TestStateStore(t *testing.T) {
+	dir := t.TempDir()
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders[0].Register(store.NewFileStore(dir))
+	builders[0].Options().SetShouldEmitVoteEvents()
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	// propose delivers a proposal to the replica, and returns true if the replica voted for it.
+	propose := func(hs *consensus.Modules, block *consensus.Block) bool {
+		voted := false
+		hs.MetricsEventLoop().RegisterObserver(consensus.VoteSentEvent{}, func(event interface{}) {
+			voted = voted || event.(consensus.VoteSentEvent).BlockHash == block.Hash()
+		})
+		hs.EventLoop().AddEvent(consensus.ProposeMsg{ID: block.Proposer(), Block: block})
+		// the event loops handle the queued events before they return.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		hs.EventLoop().Run(ctx)
+		hs.MetricsEventLoop().Run(ctx)
+		return voted
+	}
+	leader := hl[0].LeaderRotation().GetLeader
+	genesis := consensus.GetGenesis()
+
+	parent, qc := genesis, consensus.NewQuorumCert(nil, 0, genesis.Hash())
+	for view := consensus.View(1); view <= 3; view++ {
+		block := consensus.NewBlock(parent.Hash(), qc, consensus.Command(fmt.Sprintf("b%d", view)), view, leader(view))
+		if !propose(hl[0], block) {
+			t.Fatalf("the replica did not vote in view %d", view)
+		}
+		parent, qc = block, testutil.CreateQC(t, block, signers)
+	}
+	locked := hl[0].Consensus().LockedBlock()
+	if locked.View() != 1 {
+		t.Fatalf("expected the block of view 1 to be locked, got %v", locked)
+	}
+
+	genesisQC := consensus.NewQuorumCert(nil, 0, genesis.Hash())
+	// a conflicting block in the view that the replica already voted in.
+	sameView := consensus.NewBlock(genesis.Hash(), genesisQC, "conflict", 3, leader(3))
+	// a block in a new view that does not extend the locked block.
+	conflictsLock := consensus.NewBlock(genesis.Hash(), genesisQC, "conflict", 4, leader(4))
+
+	for _, tt := range []struct {
+		name      string
+		withStore bool
+	}{
+		{"WithoutStore", false},
+		{"WithStore", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := network.Restart(1)
+			if tt.withStore {
+				builder.Register(store.NewFileStore(dir))
+			}
+			builder.Options().SetShouldEmitVoteEvents()
+			hs := builder.Build()
+
+			if restored := hs.Consensus().LockedBlock(); tt.withStore && restored.Hash() != locked.Hash() {
+				t.Errorf("the restored locked block is %v, want %v", restored, locked)
+			}
+			if voted := propose(hs, sameView); voted == tt.withStore {
+				t.Errorf("voted for a conflicting block in view 3: %v, want %v", voted, !tt.withStore)
+			}
+			if voted := propose(hs, conflictsLock); voted == tt.withStore {
+				t.Errorf("voted for a block that conflicts with the locked block: %v, want %v", voted, !tt.withStore)
+			}
+		})
+	}
+}
+
+// waitingQueue is a command queue whose Get waits until a command is pushed or the context is cancelled.
+type waitingQueue struct {
+	waiting chan struct{}
+	cmds    chan consensus.Command
+}
+
+func (q *waitingQueue) Get(ctx context.Context) (consensus.Command, bool) {
+	q.waiting <- struct{}{}
+	select {
+	case cmd := <-q.cmds:
+		return cmd, true
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+// TestProposeWaitsForCommand checks that a leader with an empty command queue waits for a command during the view,
+// and that the proposal is abandoned without storing a block or voting when the view ends.
+func TestProposeWaitsForCommand(t *testing.T) {
+	run := func(t *testing.T, endView bool) {
+		network, builders := testutil.CreateNetwork(t, 4)
+		queue := &waitingQueue{waiting: make(chan struct{}, 1), cmds: make(chan consensus.Command)}
+		// replica 2 is the leader of view 1.
+		builders[1].Register(queue)
+		builders[1].Options().SetShouldEmitVoteEvents()
+		hl := builders.Build()
+		hs := network.Node(2).Modules()
+
+		var votes []consensus.VoteSentEvent
+		hs.MetricsEventLoop().RegisterObserver(consensus.VoteSentEvent{}, func(event interface{}) {
+			votes = append(votes, event.(consensus.VoteSentEvent))
+		})
+
+		genesis := consensus.GetGenesis()
+		genesisQC := consensus.NewQuorumCert(nil, 0, genesis.Hash())
+		done := make(chan struct{})
+		go func() {
+			hs.Consensus().Propose(consensus.NewSyncInfo().WithQC(genesisQC))
+			close(done)
+		}()
+		<-queue.waiting
+
+		if endView {
+			b1 := consensus.NewBlock(genesis.Hash(), genesisQC, "b1", 1, 2)
+			hs.BlockChain().Store(b1)
+			hs.Synchronizer().AdvanceView(consensus.NewSyncInfo().WithQC(testutil.CreateQC(t, b1, hl.Signers())))
+		} else {
+			queue.cmds <- "cmd"
+		}
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("the proposal did not finish")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		hs.EventLoop().Run(ctx)
+		hs.MetricsEventLoop().Run(ctx)
+
+		proposed := consensus.NewBlock(genesis.Hash(), genesisQC, "cmd", 1, 2)
+		_, stored := hs.BlockChain().LocalGet(proposed.Hash())
+		if endView {
+			if len(votes) > 0 {
+				t.Errorf("voted for block %.8s in view %d after the view ended", votes[0].BlockHash, votes[0].View)
+			}
+			return
+		}
+		if !stored {
+			t.Error("the proposed block was not stored")
+		}
+		if len(votes) != 1 || votes[0].BlockHash != proposed.Hash() {
+			t.Errorf("got votes %v, want a vote for the proposed block", votes)
+		}
+	}
+	t.Run("Command", func(t *testing.T) { run(t, false) })
+	t.Run("ViewEnds", func(t *testing.T) { run(t, true) })
+}
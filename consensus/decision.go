@@ -0,0 +1,51 @@
+package consensus
+
+// explicitDecisions returns true if blocks are only committed when a decision message is received.
+func (cs *consensusBase) explicitDecisions() bool {
+	if cs.mods.Options().CommitMode() != ExplicitDecision {
+		return false
+	}
+	_, ok := cs.mods.Configuration().(Decider)
+	return ok
+}
+
+// onDecide handles a decision message.
+// The leader sends a decision to itself when it forms a QC, and broadcasts it if the QC decides a block.
+func (cs *consensusBase) onDecide(msg DecideMsg) {
+	if !cs.explicitDecisions() {
+		cs.mods.Logger().Debug("OnDecide: the commit mode is not ExplicitDecision")
+		return
+	}
+	qc := msg.QC
+
+	// the QCs formed by this replica have already been verified vote by vote.
+	if msg.ID != cs.mods.ID() && !cs.mods.Crypto().VerifyQuorumCert(qc) {
+		cs.mods.Logger().Infof("OnDecide: invalid QC in decision from replica %d", msg.ID)
+		return
+	}
+
+	certified, ok := cs.mods.BlockChain().Get(qc.BlockHash())
+	if !ok {
+		cs.mods.Logger().Infof("OnDecide: could not find block %.8s", qc.BlockHash())
+		return
+	}
+
+	// the commit rules decide based on the QC of a block, so the QC is applied through a block
+	// that extends the certified block in the next view, as the leader's next proposal would.
+	carrier := NewBlock(certified.Hash(), qc, "", certified.View()+1, msg.ID)
+	decided := cs.impl.CommitRule(carrier)
+	if decided == nil {
+		cs.mods.Logger().Debugf("OnDecide: QC for %.8s does not decide a block", qc.BlockHash())
+		return
+	}
+	if decided.View() <= cs.CommittedBlock().View() {
+		return
+	}
+
+	if msg.ID == cs.mods.ID() {
+		cs.mods.Configuration().(Decider).Decide(msg)
+	}
+	cs.mods.Logger().Debugf("OnDecide: block %.8s was decided by replica %d", decided.Hash(), msg.ID)
+	cs.mods.EmitEvent(DecisionEvent{ID: msg.ID, Block: decided})
+	cs.commit(decided)
+}
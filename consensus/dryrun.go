@@ -0,0 +1,69 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The blockers that DryRunPropose reports. The returned errors wrap these, such that they can be checked with errors.Is.
+var (
+	// ErrReadOnly means that the replica cannot reach a quorum, see the QuorumLossTimeout option.
+	ErrReadOnly = errors.New("the replica is read-only")
+	// ErrQuiescing means that the replica is shutting down, see Modules.Quiesce.
+	ErrQuiescing = errors.New("the replica is quiescing")
+	// ErrNoHighQC means that the sync info does not carry a QC for the proposal to extend.
+	ErrNoHighQC = errors.New("no QC to extend")
+	// ErrMissingQCBlock means that the block certified by the QC is not stored locally, so it would have to be fetched.
+	ErrMissingQCBlock = errors.New("the block certified by the QC is not known")
+	// ErrInvalidQC means that the QC does not verify.
+	ErrInvalidQC = errors.New("the QC is invalid")
+	// ErrCannotSign means that the replica failed to create a signature.
+	ErrCannotSign = errors.New("the replica cannot sign")
+	// ErrNoCommands means that the command queue is empty, and there are no uncommitted blocks that an empty proposal would help commit.
+	ErrNoCommands = errors.New("no commands to propose")
+)
+
+// checkState returns an error if the state of the replica prevents it from proposing.
+func (cs *consensusBase) checkState() error {
+	if cs.mods.ReadOnly() {
+		return ErrReadOnly
+	}
+	if cs.mods.Quiescing() {
+		return ErrQuiescing
+	}
+	return nil
+}
+
+// DryRunPropose checks whether the replica could make a proposal for the sync info in the current view, without proposing.
+// It returns an error describing the first blocker it finds. The check does not fetch blocks or take commands from
+// the command queue, so an empty queue is only detected if the queue implements PendingCounter.
+// It must be called from the event loop.
+func (cs *consensusBase) DryRunPropose(cert SyncInfo) error {
+	if err := cs.checkState(); err != nil {
+		return err
+	}
+
+	qc, ok := cert.QC()
+	if !ok {
+		return ErrNoHighQC
+	}
+	block, ok := cs.mods.BlockChain().LocalGet(qc.BlockHash())
+	if !ok {
+		return fmt.Errorf("%w: %.8s", ErrMissingQCBlock, qc.BlockHash())
+	}
+	if !cs.mods.Crypto().VerifyQuorumCert(qc) {
+		return fmt.Errorf("%w: %v", ErrInvalidQC, qc)
+	}
+
+	if _, err := cs.mods.Crypto().Sign(cs.mods.Synchronizer().View().ToHash()); err != nil {
+		return fmt.Errorf("%w: %v", ErrCannotSign, err)
+	}
+
+	if counter, ok := cs.mods.CommandQueue().(PendingCounter); ok && counter.Pending() == 0 {
+		// an empty proposal is still useful if it extends the chain of blocks that are waiting to be committed.
+		if block.View() <= cs.CommittedBlock().View() {
+			return ErrNoCommands
+		}
+	}
+	return nil
+}
@@ -3,15 +3,22 @@ package consensus
 import (
 	"crypto/sha256"
 	"fmt"
+	"time"
 
 	"github.com/relab/hotstuff"
 )
 
 // ProposeMsg is broadcast when a leader makes a proposal.
 type ProposeMsg struct {
-	ID          hotstuff.ID  // The ID of the replica who sent the message.
-	Block       *Block       // The block that is proposed.
-	AggregateQC *AggregateQC // Optional AggregateQC
+	ID           hotstuff.ID       // The ID of the replica who sent the message.
+	Block        *Block            // The block that is proposed.
+	AggregateQC  *AggregateQC      // Optional AggregateQC
+	Signers      []hotstuff.ID     // Optional signer set of the block's QC, in ascending order.
+	ProposerSig  Signature         // Optional signature of the block hash, or of the ProposerRunDigest, created by the proposer.
+	ProposerRun  []Hash            // The proposer's unsigned blocks that ProposerSig also covers, oldest first.
+	FinalityCert *FinalityCert     // Optional finality certificate for a block committed by a quorum of replicas.
+	ViewChange   *ViewChangeBundle // Optional proof of the view change that preceded the proposal.
+	Deferred     bool              // True if the proposal was released from the waiting room.
 }
 
 // VoteMsg is sent to the leader by replicas voting on a proposal.
@@ -19,6 +26,8 @@ type VoteMsg struct {
 	ID          hotstuff.ID // the ID of the replica who sent the message.
 	PartialCert PartialCert // The partial certificate.
 	Deferred    bool
+	Congested   bool        // True if the sender is congested.
+	CommitCert  *CommitCert // Optional attestation of the sender's most recently committed block.
 }
 
 // TimeoutMsg is broadcast whenever a replica has a local timeout.
@@ -55,6 +64,144 @@ type NewViewMsg struct {
 	SyncInfo SyncInfo    // The highest QC / TC.
 }
 
+// DecideMsg is broadcast by the leader in the ExplicitDecision commit mode when it forms a QC that decides a block.
+// The decision is verified by applying the commit rule to the QC, so the replicas do not need to trust the sender.
+type DecideMsg struct {
+	ID hotstuff.ID // The ID of the replica who sent the message.
+	QC QuorumCert  // The finalizing QC.
+}
+
+// RelayedVotesMsg is sent to the leader by a regional relay, carrying the votes that the relay collected in its region.
+// The votes are verified by the leader, so the leader does not need to trust the relay.
+type RelayedVotesMsg struct {
+	ID    hotstuff.ID // The ID of the relay.
+	Votes []VoteMsg   // The relayed votes, at most one from each signer.
+}
+
+// HeartbeatMsg is broadcast by replicas at the HeartbeatInterval to report their liveness and progress.
+// Heartbeats are only used to track the status of the other replicas, and are never counted as proposals or votes.
+type HeartbeatMsg struct {
+	ID         hotstuff.ID // The ID of the replica who sent the message.
+	View       View        // The current view of the sender.
+	HighQCView View        // The view of the sender's highQC.
+}
+
+// RelinquishMsg is broadcast by a leader that steps down, instead of proposing, such that the replicas move on
+// to the next view without waiting for the relinquished view to time out. The relinquished view is the view
+// after the highest certificate in the sync info, and the sync info is verified before the view is skipped.
+// A replica steps down by adding a RelinquishMsg with its own ID to its event loop, see Modules.Relinquish.
+type RelinquishMsg struct {
+	ID       hotstuff.ID // The ID of the replica who sent the message.
+	SyncInfo SyncInfo    // The certificates that the sender entered the relinquished view with.
+}
+
+// View returns the view that is relinquished.
+func (msg RelinquishMsg) View() View {
+	var view View
+	if qc, ok := msg.SyncInfo.QC(); ok {
+		view = qc.View()
+	}
+	if tc, ok := msg.SyncInfo.TC(); ok && tc.View() > view {
+		view = tc.View()
+	}
+	return view + 1
+}
+
+// Lifecycle events
+//
+// The following events are emitted on the metrics event loop at the relevant points of the protocol.
+// To subscribe to an event, register an observer for its type with the metrics event loop before it is started:
+//
+//  mods.MetricsEventLoop().RegisterObserver(consensus.BlockCommittedEvent{}, func(event interface{}) { ... })
+//
+// The synchronizer also emits a synchronizer.ViewChangeEvent whenever the view changes, including on timeouts.
+
+// ProposalReceivedEvent is emitted when a replica receives a proposal from the leader of the proposal's view.
+type ProposalReceivedEvent struct {
+	ID    hotstuff.ID // The ID of the replica who sent the proposal.
+	Block *Block      // The proposed block.
+}
+
+// VoteReceivedEvent is emitted when a replica receives a vote.
+type VoteReceivedEvent struct {
+	ID        hotstuff.ID // The ID of the replica who sent the vote.
+	View      View        // The view of the block that was voted for.
+	BlockHash Hash        // The hash of the block that was voted for.
+}
+
+// VoteSentEvent is emitted when a replica votes for a block, if the ShouldEmitVoteEvents option is set.
+// By collecting these events from all replicas, a monitor can determine which replicas voted for a block,
+// even if their votes did not reach the leader.
+type VoteSentEvent struct {
+	ID        hotstuff.ID // The ID of the replica that voted.
+	Leader    hotstuff.ID // The ID of the replica that the vote was sent to.
+	View      View        // The view of the block that was voted for.
+	BlockHash Hash        // The hash of the block that was voted for.
+}
+
+// QCFormedEvent is emitted when a replica has collected enough votes to form a quorum certificate.
+type QCFormedEvent struct {
+	QC            QuorumCert
+	CriticalVoter hotstuff.ID // The replica whose vote completed the quorum.
+}
+
+// CertifiedEvent is emitted when a replica accepts a proposal that carries a quorum certificate for a known block.
+// A certified block is likely to be committed, but it may still be abandoned if the chain forks before it is committed.
+type CertifiedEvent struct {
+	Block *Block // The certified block.
+}
+
+// BlockCommittedEvent is emitted when a block is committed, in the order that blocks are executed.
+type BlockCommittedEvent struct {
+	Block      *Block
+	Commands   int       // The number of client commands that were executed, see CommandSplitter.
+	CommitTime time.Time // The time at which the commit rule was satisfied.
+	ExecTime   time.Time // The time at which the block's command was executed.
+}
+
+// FinalizedEvent is emitted when a replica learns of a finality certificate for a block that it has committed,
+// if the ShouldFinalizeCommits option is set. All blocks up to and including the finalized block are final.
+type FinalizedEvent struct {
+	Block *Block       // The finalized block.
+	Cert  FinalityCert // The finality certificate of the block.
+}
+
+// DecisionEvent is emitted when a replica commits a block because of a decision message,
+// if the commit mode is ExplicitDecision.
+type DecisionEvent struct {
+	ID    hotstuff.ID // The ID of the replica who sent the decision.
+	Block *Block      // The decided block.
+}
+
+// EquivocationDetectedEvent is emitted when the leader of a view is found to have proposed two different blocks.
+type EquivocationDetectedEvent struct {
+	ID     hotstuff.ID // The ID of the leader that equivocated.
+	View   View        // The view in which the leader equivocated.
+	First  *Block      // The first block that was received.
+	Second *Block      // The conflicting block.
+}
+
+// SpeculativeProposalEvent is emitted when the leader resolves a speculative proposal,
+// if the ShouldProposeSpeculatively option is set.
+type SpeculativeProposalEvent struct {
+	Block *Block // The speculative block.
+	Sent  bool   // False if the block was discarded because a replica reported a higher QC.
+}
+
+// LaggingPeerEvent is emitted when another replica reports a view that is more than one view behind the current view.
+type LaggingPeerEvent struct {
+	ID         hotstuff.ID // The ID of the lagging replica.
+	View       View        // The view that the replica reported.
+	HighQCView View        // The view of the highQC that the replica reported.
+}
+
+// ReadOnlyEvent is emitted when the replica enters or leaves the read-only state.
+// A replica is read-only while it cannot reach a quorum of replicas, and does not propose or vote.
+type ReadOnlyEvent struct {
+	ReadOnly  bool // Whether the replica is now read-only.
+	Reachable int  // The number of reachable replicas, including the local replica.
+}
+
 // CommitEvent is raised whenever a block is committed,
 // and includes the number of client commands that were executed.
 type CommitEvent struct {
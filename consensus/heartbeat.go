@@ -0,0 +1,121 @@
+package consensus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/modules"
+)
+
+// PeerStatus is the latest information that the replica has about another replica.
+type PeerStatus struct {
+	LastSeen   time.Time // When the replica last received a heartbeat, proposal, or vote from the peer.
+	View       View      // The latest view that the peer reported being in.
+	HighQCView View      // The view of the highest QC that the peer reported.
+}
+
+// heartbeatMonitor sends heartbeats to the other replicas at the HeartbeatInterval,
+// and tracks the status of the other replicas from their heartbeats, proposals, and votes.
+type heartbeatMonitor struct {
+	mods    *Modules
+	mut     sync.Mutex
+	peers   map[hotstuff.ID]PeerStatus
+	lagging map[hotstuff.ID]bool
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (m *heartbeatMonitor) InitConsensusModule(mods *Modules, _ *OptionsBuilder) {
+	m.mods = mods
+	m.peers = make(map[hotstuff.ID]PeerStatus)
+	m.lagging = make(map[hotstuff.ID]bool)
+}
+
+// InitModule starts tracking the other replicas and sending heartbeats, if the HeartbeatInterval option is set.
+// This must happen after the options have been set.
+func (m *heartbeatMonitor) InitModule(_ *modules.Modules) {
+	interval := m.mods.Options().HeartbeatInterval()
+	if interval <= 0 {
+		return
+	}
+	m.mods.EventLoop().RegisterHandler(HeartbeatMsg{}, func(event interface{}) {
+		hb := event.(HeartbeatMsg)
+		m.update(hb.ID, hb.View, hb.HighQCView)
+	})
+	// proposals and votes carry the view of the sender, so they count as heartbeats.
+	m.mods.EventLoop().RegisterObserver(ProposeMsg{}, func(event interface{}) {
+		proposal := event.(ProposeMsg)
+		if !proposal.Deferred && proposal.Block != nil {
+			m.update(proposal.ID, proposal.Block.View(), proposal.Block.QuorumCert().View())
+		}
+	})
+	m.mods.EventLoop().RegisterObserver(VoteMsg{}, func(event interface{}) {
+		vote := event.(VoteMsg)
+		if !vote.Deferred {
+			m.update(vote.ID, vote.PartialCert.View(), 0)
+		}
+	})
+
+	if _, ok := m.mods.Configuration().(Heartbeater); !ok {
+		m.mods.Logger().Warn("HeartbeatInterval is set, but the configuration cannot send heartbeats")
+		return
+	}
+	m.mods.EventLoop().AddTicker(interval, func(_ time.Time) interface{} {
+		return func() {
+			if !m.mods.Quiescing() {
+				m.beat()
+			}
+		}
+	})
+}
+
+// beat sends a heartbeat to the other replicas. It must be called from the event loop.
+func (m *heartbeatMonitor) beat() {
+	m.mods.Configuration().(Heartbeater).Heartbeat(HeartbeatMsg{
+		ID:         m.mods.ID(),
+		View:       m.mods.Synchronizer().View(),
+		HighQCView: m.mods.Synchronizer().HighQC().View(),
+	})
+}
+
+// update records that the peer is alive and in the given view, and emits a LaggingPeerEvent
+// if the peer has fallen more than one view behind this replica. A highQCView of zero leaves the recorded view unchanged.
+func (m *heartbeatMonitor) update(id hotstuff.ID, view, highQCView View) {
+	if id == m.mods.ID() {
+		return
+	}
+	m.mut.Lock()
+	status := m.peers[id]
+	status.LastSeen = time.Now()
+	if view > status.View {
+		status.View = view
+	}
+	if highQCView > status.HighQCView {
+		status.HighQCView = highQCView
+	}
+	m.peers[id] = status
+	m.mut.Unlock()
+
+	current := m.mods.Synchronizer().View()
+	lagging := status.View+1 < current
+	if lagging == m.lagging[id] {
+		return
+	}
+	m.lagging[id] = lagging
+	if lagging {
+		m.mods.Logger().Infof("Replica %d is lagging behind in view %d (current view %d)", id, status.View, current)
+		m.mods.EmitEvent(LaggingPeerEvent{ID: id, View: status.View, HighQCView: status.HighQCView})
+	}
+}
+
+// peerStatus returns a copy of the status of the peers.
+func (m *heartbeatMonitor) peerStatus() map[hotstuff.ID]PeerStatus {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	peers := make(map[hotstuff.ID]PeerStatus, len(m.peers))
+	for id, status := range m.peers {
+		peers[id] = status
+	}
+	return peers
+}
@@ -0,0 +1,90 @@
+package consensus
+
+import "crypto/sha256"
+
+// InclusionProof proves that a fetched block is part of the certified chain,
+// rather than an arbitrary block that merely has the requested hash.
+// It contains the QC that certifies the block if one is known,
+// and otherwise the signature of the replica that proposed the block.
+type InclusionProof struct {
+	qc          *QuorumCert
+	proposerSig Signature
+	run         []Hash // the blocks that the proposer signature covers, if it covers more than one block
+}
+
+// NewQCProof returns an inclusion proof for a block that has been certified by the given QC.
+func NewQCProof(qc QuorumCert) InclusionProof {
+	return InclusionProof{qc: &qc}
+}
+
+// NewProposerProof returns an inclusion proof for a block that has not been certified yet.
+// The signature must be the proposer's signature of the block hash.
+func NewProposerProof(sig Signature) InclusionProof {
+	return InclusionProof{proposerSig: sig}
+}
+
+// NewProposerRunProof returns an inclusion proof for a block in a run of blocks that the proposer signed at once.
+// The signature must be the proposer's signature of the ProposerRunDigest of the run.
+func NewProposerRunProof(sig Signature, run []Hash) InclusionProof {
+	return InclusionProof{proposerSig: sig, run: run}
+}
+
+// ProposerRunDigest returns the digest that a proposer signs to cover a run of its blocks, oldest first.
+// The digest is a hash chain over the block hashes, and the digest of a single block is the block hash.
+func ProposerRunDigest(run []Hash) Hash {
+	if len(run) == 1 {
+		return run[0]
+	}
+	var digest Hash
+	for _, hash := range run {
+		digest = sha256.Sum256(append(digest[:], hash[:]...))
+	}
+	return digest
+}
+
+// QC returns the quorum certificate, if present.
+func (proof InclusionProof) QC() (_ QuorumCert, _ bool) {
+	if proof.qc != nil {
+		return *proof.qc, true
+	}
+	return
+}
+
+// ProposerSignature returns the proposer's signature, or nil if the proof contains a QC.
+func (proof InclusionProof) ProposerSignature() Signature {
+	return proof.proposerSig
+}
+
+// Run returns the blocks that the proposer signature covers, or nil if it only covers the block itself.
+func (proof InclusionProof) Run() []Hash {
+	return proof.run
+}
+
+// IsEmpty returns true if the proof contains neither a QC nor a proposer signature.
+func (proof InclusionProof) IsEmpty() bool {
+	return proof.qc == nil && proof.proposerSig == nil
+}
+
+// VerifyInclusionProof verifies that the proof is valid for the block.
+// A QC must certify the block, and a proposer signature must have been created by the block's proposer,
+// either of the block hash, or of the digest of a run of blocks that includes the block.
+func VerifyInclusionProof(crypto Crypto, block *Block, proof InclusionProof) bool {
+	if qc, ok := proof.QC(); ok {
+		return qc.BlockHash() == block.Hash() && qc.View() == block.View() && crypto.VerifyQuorumCert(qc)
+	}
+	if sig := proof.ProposerSignature(); sig != nil {
+		if sig.Signer() != block.Proposer() {
+			return false
+		}
+		if len(proof.run) == 0 {
+			return crypto.Verify(sig, block.Hash())
+		}
+		for _, hash := range proof.run {
+			if hash == block.Hash() {
+				return crypto.Verify(sig, ProposerRunDigest(proof.run))
+			}
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package consensus
+
+import (
+	"sync"
+	"time"
+)
+
+// LockStats contains measurements of the contention on a lock.
+type LockStats struct {
+	Acquisitions uint64        // The number of times the lock was acquired.
+	Wait         time.Duration // The total time spent waiting to acquire the lock.
+	MaxWait      time.Duration // The longest time spent waiting to acquire the lock.
+	Hold         time.Duration // The total time that the lock was held.
+	MaxHold      time.Duration // The longest time that the lock was held.
+}
+
+// LockReporter is implemented by Consensus implementations that can report the contention
+// on the lock that guards their state. Measurements are only recorded if the ShouldInstrumentLocks option is set.
+type LockReporter interface {
+	// LockStats returns the measurements recorded since the previous call.
+	LockStats() LockStats
+}
+
+// timedMutex is a mutex that can measure how long it is waited for and held.
+// When it is not enabled, the only overhead is checking a boolean in Lock and Unlock.
+// The measurements are only accessed while holding the mutex, so they need no additional synchronization.
+type timedMutex struct {
+	mut      sync.Mutex
+	enabled  bool      // must not be changed while the mutex is in use
+	acquired time.Time // when the mutex was last acquired
+	stats    LockStats
+}
+
+func (m *timedMutex) Lock() {
+	if !m.enabled {
+		m.mut.Lock()
+		return
+	}
+	start := time.Now()
+	m.mut.Lock()
+	m.acquired = time.Now()
+	wait := m.acquired.Sub(start)
+	m.stats.Acquisitions++
+	m.stats.Wait += wait
+	if wait > m.stats.MaxWait {
+		m.stats.MaxWait = wait
+	}
+}
+
+func (m *timedMutex) Unlock() {
+	if m.enabled {
+		hold := time.Since(m.acquired)
+		m.stats.Hold += hold
+		if hold > m.stats.MaxHold {
+			m.stats.MaxHold = hold
+		}
+	}
+	m.mut.Unlock()
+}
+
+// reset returns the measurements and clears them.
+func (m *timedMutex) reset() LockStats {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	stats := m.stats
+	m.stats = LockStats{}
+	return stats
+}
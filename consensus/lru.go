@@ -0,0 +1,50 @@
+package consensus
+
+import "container/list"
+
+// hashLRU orders block hashes by when they were last touched.
+// It is used to decide which entries to evict from the maps of the VotingMachine and the WaitingRoom,
+// such that a replica that sends votes for many different blocks cannot make those maps grow without bound.
+// It is not safe for concurrent use.
+type hashLRU struct {
+	order    *list.List // the least recently touched hash is at the front
+	elements map[Hash]*list.Element
+}
+
+func newHashLRU() *hashLRU {
+	return &hashLRU{
+		order:    list.New(),
+		elements: make(map[Hash]*list.Element),
+	}
+}
+
+// touch marks the hash as the most recently touched hash.
+func (lru *hashLRU) touch(hash Hash) {
+	if elem, ok := lru.elements[hash]; ok {
+		lru.order.MoveToBack(elem)
+		return
+	}
+	lru.elements[hash] = lru.order.PushBack(hash)
+}
+
+// remove removes the hash.
+func (lru *hashLRU) remove(hash Hash) {
+	if elem, ok := lru.elements[hash]; ok {
+		lru.order.Remove(elem)
+		delete(lru.elements, hash)
+	}
+}
+
+// oldest returns the least recently touched hash.
+func (lru *hashLRU) oldest() (Hash, bool) {
+	elem := lru.order.Front()
+	if elem == nil {
+		return Hash{}, false
+	}
+	return elem.Value.(Hash), true
+}
+
+// len returns the number of hashes.
+func (lru *hashLRU) len() int {
+	return lru.order.Len()
+}
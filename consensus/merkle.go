@@ -0,0 +1,188 @@
+package consensus
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// The Merkle tree over the client commands of a block follows RFC 6962:
+// leaves and interior nodes are hashed with different prefixes, such that a leaf cannot be passed off as a node,
+// and the left subtree of a node with n leaves holds the largest power of two that is less than n.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+func merkleLeaf(cmd Command) Hash {
+	return sha256.Sum256(append([]byte{merkleLeafPrefix}, cmd...))
+}
+
+func merkleNode(left, right Hash) Hash {
+	buf := make([]byte, 0, 1+2*len(left))
+	buf = append(buf, merkleNodePrefix)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
+
+// merkleSplit returns the number of leaves in the left subtree of a tree with n > 1 leaves.
+func merkleSplit(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// CommandRoot returns the Merkle root of the given client commands.
+// The root of an empty list is the hash of the empty string.
+func CommandRoot(cmds []Command) Hash {
+	switch len(cmds) {
+	case 0:
+		return sha256.Sum256(nil)
+	case 1:
+		return merkleLeaf(cmds[0])
+	}
+	k := merkleSplit(len(cmds))
+	return merkleNode(CommandRoot(cmds[:k]), CommandRoot(cmds[k:]))
+}
+
+// merklePath returns the hashes of the siblings on the path from the leaf at the given index to the root, starting at the leaf.
+func merklePath(cmds []Command, index int) []Hash {
+	if len(cmds) <= 1 {
+		return nil
+	}
+	k := merkleSplit(len(cmds))
+	if index < k {
+		return append(merklePath(cmds[:k], index), CommandRoot(cmds[k:]))
+	}
+	return append(merklePath(cmds[k:], index-k), CommandRoot(cmds[:k]))
+}
+
+// merkleRootFromPath returns the root of a tree with the given number of leaves, computed from a leaf at the given index
+// and the path from the leaf to the root. It returns false if the path does not fit the index and the number of leaves.
+func merkleRootFromPath(leaf Hash, index, count int, path []Hash) (Hash, bool) {
+	if count <= 1 {
+		return leaf, index == 0 && count == 1 && len(path) == 0
+	}
+	if len(path) == 0 {
+		return Hash{}, false
+	}
+	sibling := path[len(path)-1]
+	k := merkleSplit(count)
+	if index < k {
+		left, ok := merkleRootFromPath(leaf, index, k, path[:len(path)-1])
+		return merkleNode(left, sibling), ok
+	}
+	right, ok := merkleRootFromPath(leaf, index-k, count-k, path[:len(path)-1])
+	return merkleNode(sibling, right), ok
+}
+
+// CommandProof proves that a client command was committed in a block, without including the other commands of the block.
+// The header of the block commits to the Merkle root of the client commands, and the QC certifies the header.
+type CommandProof struct {
+	Command Command    // the client command
+	Index   int        // the position of the command among the client commands of the block
+	Count   int        // the number of client commands in the block
+	Path    []Hash     // the hashes of the siblings on the path from the command to the root, starting at the command
+	Header  *Block     // the block without its command
+	QC      QuorumCert // the QC that certifies the block
+}
+
+// BlockHash returns the hash of the block that the command was committed in.
+func (proof CommandProof) BlockHash() Hash {
+	return proof.Header.Hash()
+}
+
+// VerifyCommandProof verifies that the command in the proof is included in a block that is certified by the QC of the proof.
+// The hash of the header is recomputed, so the proof does not need to be trusted.
+func VerifyCommandProof(crypto Crypto, proof CommandProof) bool {
+	if proof.Header == nil {
+		return false
+	}
+	root, ok := proof.Header.CommandRoot()
+	if !ok {
+		return false
+	}
+	if computed, ok := merkleRootFromPath(merkleLeaf(proof.Command), proof.Index, proof.Count, proof.Path); !ok || computed != root {
+		return false
+	}
+	hash := Hash(sha256.Sum256(proof.Header.ToBytes()))
+	return proof.QC.BlockHash() == hash && proof.QC.View() == proof.Header.View() && crypto.VerifyQuorumCert(proof.QC)
+}
+
+// splitCommands returns the client commands in the command, using the acceptor if it implements CommandSplitter.
+// Otherwise, the command is a single client command.
+func (mods *Modules) splitCommands(cmd Command) ([]Command, bool) {
+	if splitter, ok := mods.Acceptor().(CommandSplitter); ok {
+		return splitter.SplitCommands(cmd)
+	}
+	return []Command{cmd}, true
+}
+
+// ProveCommand returns a proof that the given client command was committed in the block with the given hash.
+// The block must commit to the Merkle root of its client commands, see the ShouldCommitCommandRoot option,
+// and the replica must know the QC that certifies the block.
+func (mods *Modules) ProveCommand(hash Hash, cmd Command) (CommandProof, error) {
+	block, ok := mods.BlockChain().LocalGet(hash)
+	if !ok {
+		return CommandProof{}, fmt.Errorf("block %.8s not found", hash)
+	}
+	if !mods.isCommitted(block) {
+		return CommandProof{}, fmt.Errorf("block %.8s is not committed", hash)
+	}
+	if _, ok := block.CommandRoot(); !ok {
+		return CommandProof{}, fmt.Errorf("block %.8s does not commit to a command root", hash)
+	}
+	if !block.IsResolved() {
+		ref, _ := block.PayloadRef()
+		payload, ok := mods.BlockChain().LocalGetPayload(ref)
+		if !ok {
+			return CommandProof{}, fmt.Errorf("the payload of block %.8s is not known", hash)
+		}
+		block, _ = block.WithPayload(payload)
+	}
+	cmds, ok := mods.splitCommands(block.Command())
+	if !ok {
+		return CommandProof{}, fmt.Errorf("the command of block %.8s is malformed", hash)
+	}
+	index := -1
+	for i, c := range cmds {
+		if c == cmd {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return CommandProof{}, fmt.Errorf("the command is not in block %.8s", hash)
+	}
+	inclusion, ok := mods.BlockChain().Proof(hash)
+	if !ok {
+		return CommandProof{}, fmt.Errorf("no QC for block %.8s is known", hash)
+	}
+	qc, ok := inclusion.QC()
+	if !ok {
+		return CommandProof{}, fmt.Errorf("no QC for block %.8s is known", hash)
+	}
+	return CommandProof{
+		Command: cmd,
+		Index:   index,
+		Count:   len(cmds),
+		Path:    merklePath(cmds, index),
+		Header:  block.header(),
+		QC:      qc,
+	}, nil
+}
+
+// isCommitted returns true if the block is an ancestor of, or equal to, the committed block.
+func (mods *Modules) isCommitted(block *Block) bool {
+	current := mods.Consensus().CommittedBlock()
+	for current.View() > block.View() {
+		parent, ok := mods.BlockChain().LocalGet(current.Parent())
+		if !ok {
+			return false
+		}
+		current = parent
+	}
+	return current.Hash() == block.Hash()
+}
@@ -2,6 +2,9 @@ package consensus
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/eventloop"
@@ -17,17 +20,33 @@ type Modules struct {
 	opts          Options
 	eventLoop     *eventloop.EventLoop
 	votingMachine *VotingMachine
+	waitingRoom   *WaitingRoom
+	readOnly      *readOnlyMonitor
+	heartbeats    *heartbeatMonitor
+	barrier       *startupBarrier
+	quiescing     int32
+	parameters    parameterSchedule
+	emitter       eventEmitter
 
 	acceptor       Acceptor
 	blockChain     BlockChain
 	commandQueue   CommandQueue
 	config         Configuration
 	consensus      Consensus
-	executor       ExecutorExt
+	executor       FallibleExecutorExt
+	scheduler      CommandScheduler
+	scheduled      ScheduledExecutor
 	leaderRotation LeaderRotation
 	crypto         Crypto
 	synchronizer   Synchronizer
 	forkHandler    ForkHandlerExt
+	congestion     CongestionMonitor
+	connectivity   ConnectivityMonitor
+	syncStore      SyncStateStore
+	beacon         Beacon
+	checkpoints    CheckpointStore
+	stateStore     StateStore
+	commitSink     CommitSink
 }
 
 // Run starts both event loops using the provided context and returns when both event loops have exited.
@@ -67,6 +86,16 @@ func (mods *Modules) EventLoop() *eventloop.EventLoop {
 	return mods.eventLoop
 }
 
+// VotingMachine returns the voting machine, which collects votes to form quorum certificates.
+func (mods *Modules) VotingMachine() *VotingMachine {
+	return mods.votingMachine
+}
+
+// WaitingRoom returns the waiting room, which holds the proposals and votes that are waiting for a block to arrive.
+func (mods *Modules) WaitingRoom() *WaitingRoom {
+	return mods.waitingRoom
+}
+
 // Acceptor returns the acceptor.
 func (mods *Modules) Acceptor() Acceptor {
 	return mods.acceptor
@@ -93,10 +122,15 @@ func (mods *Modules) Consensus() Consensus {
 }
 
 // Executor returns the executor.
-func (mods *Modules) Executor() ExecutorExt {
+func (mods *Modules) Executor() FallibleExecutorExt {
 	return mods.executor
 }
 
+// CommandScheduler returns the command scheduler, or nil if no command scheduler is registered.
+func (mods *Modules) CommandScheduler() CommandScheduler {
+	return mods.scheduler
+}
+
 // LeaderRotation returns the leader rotation implementation.
 func (mods *Modules) LeaderRotation() LeaderRotation {
 	return mods.leaderRotation
@@ -117,6 +151,154 @@ func (mods *Modules) ForkHandler() ForkHandlerExt {
 	return mods.forkHandler
 }
 
+// Congested returns true if the replica is congested, according to the registered CongestionMonitor.
+// If there is no CongestionMonitor, the replica is never congested.
+func (mods *Modules) Congested() bool {
+	return mods.congestion != nil && mods.congestion.Congested()
+}
+
+// ReadOnly returns true if the replica is read-only because it has been unable to reach a quorum of replicas
+// for longer than the QuorumLossTimeout. A read-only replica does not propose or vote.
+func (mods *Modules) ReadOnly() bool {
+	return mods.readOnly.get()
+}
+
+// Quiesce stops the replica from starting new work: it no longer proposes, times out of its view, or sends heartbeats,
+// but it still votes on and commits the proposals that are in flight. Once every replica in a configuration is quiescing,
+// the replicas stop sending messages to each other, such that they can be shut down in any order.
+func (mods *Modules) Quiesce() {
+	if atomic.SwapInt32(&mods.quiescing, 1) == 0 {
+		mods.Logger().Info("Quiescing")
+	}
+}
+
+// Relinquish makes the replica step down the next time it becomes the leader of a view:
+// instead of proposing, it tells the other replicas to move on to the next view, carrying its highQC,
+// such that the next leader can propose without waiting for the view to time out.
+// The configuration must implement Relinquisher, or the replica proposes as usual.
+func (mods *Modules) Relinquish() {
+	mods.eventLoop.AddEvent(RelinquishMsg{ID: mods.ID()})
+}
+
+// Quiescing returns true if Quiesce has been called.
+func (mods *Modules) Quiescing() bool {
+	return atomic.LoadInt32(&mods.quiescing) == 1
+}
+
+// Peers returns the latest status of the other replicas that the replica has received heartbeats, proposals, or votes from.
+// The status of the other replicas is only tracked if the HeartbeatInterval option is set.
+func (mods *Modules) Peers() map[hotstuff.ID]PeerStatus {
+	return mods.heartbeats.peerStatus()
+}
+
+// AwaitStartup calls f on the event loop once a quorum of replicas are ready to start the first view,
+// or when the StartupBarrierTimeout expires. The first call announces that this replica is ready.
+// If the StartupBarrierTimeout option is not set, f is called on the event loop without waiting.
+func (mods *Modules) AwaitStartup(f func()) {
+	if mods.opts.StartupBarrierTimeout() <= 0 {
+		go mods.EventLoop().AddEvent(f)
+		return
+	}
+	mods.barrier.await(f)
+}
+
+// SyncStateStore returns the store that persists the state of the view synchronizer, or nil if none was registered.
+func (mods *Modules) SyncStateStore() SyncStateStore {
+	return mods.syncStore
+}
+
+// CheckpointStore returns the store that persists the pinned checkpoints, or nil if none was registered.
+func (mods *Modules) CheckpointStore() CheckpointStore {
+	return mods.checkpoints
+}
+
+// StateStore returns the store that persists the safety state of the consensus protocol, or nil if none was registered.
+func (mods *Modules) StateStore() StateStore {
+	return mods.stateStore
+}
+
+// CommitSink returns the module that is notified of committed blocks, or nil if none was registered.
+func (mods *Modules) CommitSink() CommitSink {
+	return mods.commitSink
+}
+
+// Beacon returns the randomness beacon, or nil if none was registered.
+func (mods *Modules) Beacon() Beacon {
+	return mods.beacon
+}
+
+// CommitAttestation returns a commit certificate for the most recently committed block, to be attached to votes.
+// It returns nil if the ShouldFinalizeCommits option is not set, or if no block has been committed.
+func (mods *Modules) CommitAttestation() *CommitCert {
+	if !mods.opts.ShouldFinalizeCommits() {
+		return nil
+	}
+	cert, err := mods.consensus.CommitCert()
+	if err != nil {
+		return nil
+	}
+	return &cert
+}
+
+// EmitEvent sends a lifecycle event to its subscribers on the metrics event loop.
+// Events are delivered asynchronously and in the order they were emitted. If the metrics event loop falls behind,
+// the events are buffered until it catches up, such that slow subscribers never block the consensus protocol,
+// and no events are lost.
+func (mods *Modules) EmitEvent(event interface{}) {
+	mods.emitter.emit(mods.MetricsEventLoop(), event)
+}
+
+// eventEmitter buffers the events that do not fit in the queue of the metrics event loop.
+type eventEmitter struct {
+	mut      sync.Mutex
+	pending  []interface{} // the events that are waiting for room in the queue, in the order they were emitted
+	draining bool          // whether a goroutine is moving the pending events to the queue
+}
+
+// emit adds the event to the queue of the event loop, or to the pending events if the queue is full,
+// or if earlier events are still draining.
+func (e *eventEmitter) emit(el *eventloop.EventLoop, event interface{}) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	// while the events are draining, the last pending event may not have reached the queue yet.
+	if !e.draining && el.TryAddEvent(event) {
+		return
+	}
+	e.pending = append(e.pending, event)
+	if !e.draining {
+		e.draining = true
+		go e.drain(el)
+	}
+}
+
+// drain adds the pending events to the queue of the event loop, waiting for room in the queue.
+func (e *eventEmitter) drain(el *eventloop.EventLoop) {
+	for {
+		e.mut.Lock()
+		if len(e.pending) == 0 {
+			e.draining = false
+			e.pending = nil
+			e.mut.Unlock()
+			return
+		}
+		event := e.pending[0]
+		e.pending[0] = nil
+		e.pending = e.pending[1:]
+		e.mut.Unlock()
+		el.AddEvent(event)
+	}
+}
+
+// InvariantViolation reports that a protocol invariant was violated.
+// In strict mode, it panics with a message describing the violation. Otherwise, the violation is logged as an error.
+func (mods *Modules) InvariantViolation(format string, args ...interface{}) {
+	msg := fmt.Sprintf("invariant violation on replica %d: %s", mods.ID(), fmt.Sprintf(format, args...))
+	if mods.opts.ShouldUseStrictMode() {
+		panic(msg)
+	}
+	mods.Logger().Error(msg)
+}
+
 // Builder is a helper for constructing a HotStuff instance.
 type Builder struct {
 	baseBuilder modules.Builder
@@ -132,11 +314,18 @@ func NewBuilder(id hotstuff.ID, privateKey PrivateKey) Builder {
 		mods: &Modules{
 			privateKey:    privateKey,
 			votingMachine: NewVotingMachine(),
+			waitingRoom:   NewWaitingRoom(),
+			readOnly:      &readOnlyMonitor{},
+			heartbeats:    &heartbeatMonitor{},
+			barrier:       &startupBarrier{},
 			eventLoop:     eventloop.New(100), // TODO: make this configurable
 		},
 	}
+	bl.cfg.opts.retentionWindow = DefaultRetentionWindow
+	bl.cfg.opts.maxPendingBlocks = DefaultMaxPendingBlocks
+	bl.cfg.opts.maxWaitingVotes = DefaultMaxWaitingVotes
 	// some of the default modules need to be registered
-	bl.Register(bl.mods.votingMachine)
+	bl.Register(bl.mods.votingMachine, bl.mods.waitingRoom, bl.mods.readOnly, bl.mods.heartbeats, bl.mods.barrier)
 	return bl
 }
 
@@ -163,12 +352,24 @@ func (b *Builder) Register(mods ...interface{}) {
 		if m, ok := module.(Consensus); ok {
 			b.mods.consensus = m
 		}
-		if m, ok := module.(ExecutorExt); ok {
+		if m, ok := module.(FallibleExecutorExt); ok {
 			b.mods.executor = m
 		}
+		if m, ok := module.(FallibleExecutor); ok {
+			b.mods.executor = fallibleExecutorWrapper{m}
+		}
+		if m, ok := module.(ExecutorExt); ok {
+			b.mods.executor = executorExtWrapper{m}
+		}
 		if m, ok := module.(Executor); ok {
 			b.mods.executor = executorWrapper{m}
 		}
+		if m, ok := module.(CommandScheduler); ok {
+			b.mods.scheduler = m
+		}
+		if m, ok := module.(ScheduledExecutor); ok {
+			b.mods.scheduled = m
+		}
 		if m, ok := module.(LeaderRotation); ok {
 			b.mods.leaderRotation = m
 		}
@@ -184,22 +385,144 @@ func (b *Builder) Register(mods ...interface{}) {
 		if m, ok := module.(ForkHandler); ok {
 			b.mods.forkHandler = forkHandlerWrapper{m}
 		}
+		if m, ok := module.(CongestionMonitor); ok {
+			b.mods.congestion = m
+		}
+		if m, ok := module.(ConnectivityMonitor); ok {
+			b.mods.connectivity = m
+		}
+		if m, ok := module.(SyncStateStore); ok {
+			b.mods.syncStore = m
+		}
+		if m, ok := module.(Beacon); ok {
+			b.mods.beacon = m
+		}
+		if m, ok := module.(CheckpointStore); ok {
+			b.mods.checkpoints = m
+		}
+		if m, ok := module.(StateStore); ok {
+			b.mods.stateStore = m
+		}
+		if m, ok := module.(CommitSink); ok {
+			b.mods.commitSink = m
+		}
 		if m, ok := module.(Module); ok {
 			b.modules = append(b.modules, m)
 		}
 	}
 }
 
+// Options returns the OptionsBuilder that is used to configure the modules.
+func (b *Builder) Options() *OptionsBuilder {
+	return &b.cfg
+}
+
 // Build initializes all modules and returns the HotStuff object.
 func (b *Builder) Build() *Modules {
 	for _, module := range b.modules {
 		module.InitConsensusModule(b.mods, &b.cfg)
 	}
 	b.mods.opts = b.cfg.opts
+	if id := b.mods.opts.GenesisLeader(); id != 0 && b.mods.leaderRotation != nil {
+		b.mods.leaderRotation = genesisLeader{b.mods.leaderRotation, id}
+	}
+	if views := b.mods.opts.FallbackLeaderAfter(); views > 0 && b.mods.leaderRotation != nil {
+		b.mods.leaderRotation = &fallbackLeader{LeaderRotation: b.mods.leaderRotation, mods: b.mods, after: views}
+	}
 	b.mods.Modules = b.baseBuilder.Build()
 	return b.mods
 }
 
+// genesisLeader overrides the leader of view 1 with the configured genesis leader,
+// such that all replicas agree on the first leader regardless of the state of the leader rotation.
+type genesisLeader struct {
+	LeaderRotation
+	id hotstuff.ID
+}
+
+// GetLeader returns the id of the leader in the given view.
+func (l genesisLeader) GetLeader(view View) hotstuff.ID {
+	if view == 1 {
+		return l.id
+	}
+	return l.LeaderRotation.GetLeader(view)
+}
+
+// CreditProposer passes the committed block to the wrapped leader rotation, if it is a ProposerCreditor.
+func (l genesisLeader) CreditProposer(block *Block) {
+	if creditor, ok := l.LeaderRotation.(ProposerCreditor); ok {
+		creditor.CreditProposer(block)
+	}
+}
+
+// fallbackHistory is the number of committed blocks that the fallback leader rotation remembers.
+const fallbackHistory = 64
+
+// fallbackLeader overrides the leader rotation after the configured number of consecutive views without a commit.
+// The fallback leader only depends on the view and on the blocks that were committed before it,
+// such that the replicas that committed the same blocks agree on it.
+type fallbackLeader struct {
+	LeaderRotation
+	mods  *Modules
+	after View
+
+	mut       sync.Mutex
+	committed []committedProposal // the most recently committed blocks, in ascending order of view
+}
+
+// committedProposal is the view and proposer of a committed block.
+type committedProposal struct {
+	view     View
+	proposer hotstuff.ID
+}
+
+// GetLeader returns the id of the leader in the given view.
+func (l *fallbackLeader) GetLeader(view View) hotstuff.ID {
+	last, ok := l.lastCommitted(view)
+	if !ok || view <= last.view+l.after {
+		return l.LeaderRotation.GetLeader(view)
+	}
+	// the proposer of the last committed block is known to have made progress, so it takes over first.
+	// If nothing but the genesis block was committed, the replicas start from the first replica.
+	first := uint64(last.proposer)
+	if first == 0 {
+		first = 1
+	}
+	failed := view - last.view - 1
+	n := uint64(l.mods.Configuration().Len())
+	next := uint64((failed - l.after) / l.after)
+	return hotstuff.ID((first-1+next)%n + 1)
+}
+
+// lastCommitted returns the newest committed block before the given view.
+// It returns false if the block is older than the remembered blocks.
+func (l *fallbackLeader) lastCommitted(view View) (committedProposal, bool) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	for i := len(l.committed) - 1; i >= 0; i-- {
+		if l.committed[i].view < view {
+			return l.committed[i], true
+		}
+	}
+	if len(l.committed) == fallbackHistory {
+		return committedProposal{}, false
+	}
+	return committedProposal{}, true
+}
+
+// CreditProposer remembers the committed block, and passes it to the wrapped leader rotation, if it is a ProposerCreditor.
+func (l *fallbackLeader) CreditProposer(block *Block) {
+	l.mut.Lock()
+	if len(l.committed) == fallbackHistory {
+		l.committed = append(l.committed[:0], l.committed[1:]...)
+	}
+	l.committed = append(l.committed, committedProposal{view: block.View(), proposer: block.Proposer()})
+	l.mut.Unlock()
+	if creditor, ok := l.LeaderRotation.(ProposerCreditor); ok {
+		creditor.CreditProposer(block)
+	}
+}
+
 // Module interfaces
 
 // Module is an interface that can be implemented by types that need access to other consensus modules.
@@ -219,6 +542,13 @@ type CommandQueue interface {
 	Get(ctx context.Context) (cmd Command, ok bool)
 }
 
+// PendingCounter is an optional interface for command queues that can tell how many commands are waiting to be proposed,
+// which lets Consensus.DryRunPropose detect an empty queue without taking commands from it.
+type PendingCounter interface {
+	// Pending returns the number of commands that are waiting to be proposed.
+	Pending() int
+}
+
 //go:generate mockgen -destination=../internal/mocks/acceptor_mock.go -package=mocks . Acceptor
 
 // Acceptor decides if a replica should accept a command.
@@ -230,6 +560,48 @@ type Acceptor interface {
 	Proposed(Command)
 }
 
+// ViewAcceptor is an optional interface for acceptors whose decision depends on the view of the proposed block,
+// for example because commands expire. If the Acceptor implements this interface,
+// AcceptInView is used instead of Accept when a proposal is received.
+type ViewAcceptor interface {
+	// AcceptInView returns true if the replica should accept the command proposed in the given view.
+	// The decision must only depend on the command and the view, such that all correct replicas agree on it.
+	AcceptInView(cmd Command, view View) bool
+}
+
+// BlockAcceptor is an optional interface for acceptors that decide based on the block that carries the command,
+// for example to reject commands that are already in one of the block's ancestors. If the Acceptor implements
+// this interface, AcceptBlock is used instead of Accept and AcceptInView when a proposal is received.
+type BlockAcceptor interface {
+	// AcceptBlock returns true if the replica should accept the command of the block.
+	// The decision must only depend on the block, its ancestors, and the committed blocks,
+	// such that all correct replicas that committed the same blocks agree on it.
+	AcceptBlock(block *Block) bool
+}
+
+// CommitTracker is an optional interface for acceptors that keep track of which commands were committed,
+// for example to reject commands that were already executed. If the Acceptor implements this interface,
+// Committed is called with the command of each executed block, in commit order.
+type CommitTracker interface {
+	// Committed tells the acceptor that the command was committed.
+	Committed(Command)
+}
+
+// CommandCounter is an optional interface for acceptors of commands that batch several client commands,
+// which is needed to enforce the MaxProposalCommands option.
+type CommandCounter interface {
+	// CountCommands returns the number of client commands in the command, or false if the command is malformed.
+	CountCommands(cmd Command) (n int, ok bool)
+}
+
+// CommandSplitter is an optional interface for acceptors of commands that batch several client commands,
+// which is needed to build a Merkle tree over the client commands of a block, see the ShouldCommitCommandRoot option.
+// If the acceptor does not implement it, the command of a block is treated as a single client command.
+type CommandSplitter interface {
+	// SplitCommands returns the client commands in the command, in order, or false if the command is malformed.
+	SplitCommands(cmd Command) (cmds []Command, ok bool)
+}
+
 //go:generate mockgen -destination=../internal/mocks/executor_mock.go -package=mocks . Executor
 
 // Executor is responsible for executing the commands that are committed by the consensus protocol.
@@ -247,6 +619,45 @@ type ExecutorExt interface {
 	Exec(block *Block)
 }
 
+// FallibleExecutor is responsible for executing the commands that are committed by the consensus protocol,
+// and reports whether the execution succeeded.
+//
+// A command that fails execution is still committed, and the consensus protocol will not roll it back.
+// Instead, the error is recorded by the Consensus module so that the outcome can be reported to clients.
+// Exec must be deterministic, such that all replicas agree on the outcome of each command.
+type FallibleExecutor interface {
+	// Exec executes the command, and returns an error if the execution failed.
+	Exec(cmd Command) error
+}
+
+// FallibleExecutorExt is responsible for executing the commands that are committed by the consensus protocol,
+// and reports whether the execution succeeded.
+//
+// This interface is similar to the FallibleExecutor interface, except it takes a block as an argument,
+// instead of a command.
+type FallibleExecutorExt interface {
+	// Exec executes the command in the block, and returns an error if the execution failed.
+	Exec(block *Block) error
+}
+
+// ScheduledExecutor is an optional executor that executes the client commands of a committed block one at a time,
+// such that commands that do not conflict can execute concurrently. See the ExecutionWorkers option.
+// The commands of a block are scheduled by the CommandScheduler.
+type ScheduledExecutor interface {
+	// ExecCommand executes a single client command, and returns an error if the execution failed.
+	// ExecCommand may be called concurrently for commands that the schedule does not order.
+	ExecCommand(cmd Command) error
+}
+
+// CommandScheduler splits the command of a committed block into the client commands that it batches,
+// and decides which of the commands must execute after each other. See Schedule.
+// An Acceptor that batches client commands can implement this interface too.
+type CommandScheduler interface {
+	// Schedule returns the schedule of the client commands in the command, or false if the command is malformed.
+	// The schedule must only depend on the command, such that all replicas execute the commands in the same order.
+	Schedule(cmd Command) (schedule Schedule, ok bool)
+}
+
 // ForkHandler handles commands that do not get committed due to a forked blockchain.
 //
 // TODO: think of a better name/interface
@@ -263,6 +674,57 @@ type ForkHandlerExt interface {
 	Fork(block *Block)
 }
 
+// CongestionMonitor reports whether the replica is congested, e.g. because of a slow executor or a backlog of blocks to fetch.
+// Followers send this signal to the leader along with their votes.
+// The signal only affects the pace of proposals, not safety.
+type CongestionMonitor interface {
+	// Congested returns true if the replica is congested.
+	Congested() bool
+}
+
+// ConnectivityMonitor reports how many replicas the replica can currently communicate with.
+// It is used to detect that a quorum cannot be reached, see the QuorumLossTimeout option.
+type ConnectivityMonitor interface {
+	// Reachable returns the number of reachable replicas, including the local replica.
+	Reachable() int
+}
+
+// SyncState is the state of the view synchronizer that is persisted by a SyncStateStore.
+type SyncState struct {
+	View   View        // The current view.
+	HighQC QuorumCert  // The highest known QC.
+	HighTC TimeoutCert // The highest known TC.
+}
+
+// SyncStateStore durably stores the state of the view synchronizer,
+// such that a replica that restarts can rejoin at the view it left off, rather than at view 1.
+// If no store is registered, the state is only kept in memory.
+type SyncStateStore interface {
+	// SaveSyncState durably stores the state. It is called whenever the synchronizer advances to a new view.
+	SaveSyncState(state SyncState) error
+	// LoadSyncState returns the stored state. If no state has been stored, ok is false.
+	LoadSyncState() (state SyncState, ok bool, err error)
+}
+
+// CommitSink is notified of every committed block, in commit order, after the block has been executed.
+// Unlike BlockCommittedEvent, which is delivered asynchronously on the metrics event loop,
+// Committed is called before the next block is committed.
+// Dummy blocks are not committed, as they contain no command.
+type CommitSink interface {
+	// Committed is called for each committed block while the block is being committed, so it must not block.
+	Committed(block *Block)
+}
+
+// Beacon is an external source of verifiable randomness, such as drand.
+// If a beacon is registered, each proposal must include a beacon value that the replicas verify before voting,
+// such that the committed chain holds randomness that no single replica could bias.
+type Beacon interface {
+	// Randomness returns the latest output of the beacon, waiting until it is available or the context is cancelled.
+	Randomness(ctx context.Context) (value BeaconValue, ok bool)
+	// VerifyRandomness returns true if the value is the beacon's output for its round.
+	VerifyRandomness(value BeaconValue) bool
+}
+
 // CryptoImpl implements only the cryptographic primitives that are needed for HotStuff.
 // This interface is implemented by the ecdsa and bls12 packages.
 type CryptoImpl interface {
@@ -316,14 +778,59 @@ type BlockChain interface {
 	// LocalGet retrieves a block given its hash, without fetching it from other replicas.
 	LocalGet(Hash) (*Block, bool)
 
+	// GetBatch retrieves the blocks with the given hashes, fetching the missing blocks from other replicas if necessary.
+	// It returns the blocks that were found.
+	GetBatch(hashes []Hash) map[Hash]*Block
+
 	// Extends checks if the given block extends the branch of the target hash.
 	Extends(block, target *Block) bool
 
+	// StoreProof stores an inclusion proof for the block with the given hash.
+	// A proof containing a QC replaces a proof containing only the proposer's signature.
+	StoreProof(hash Hash, proof InclusionProof)
+
+	// Proof returns the inclusion proof for the block with the given hash, if one is known.
+	Proof(hash Hash) (proof InclusionProof, ok bool)
+
+	// StorePayload stores a command that blocks may refer to, and returns its reference.
+	StorePayload(cmd Command) (ref Hash)
+
+	// GetPayload retrieves the command with the given reference, attempting to fetch it from other replicas if necessary.
+	GetPayload(ref Hash) (cmd Command, ok bool)
+
+	// LocalGetPayload retrieves the command with the given reference, without fetching it from other replicas.
+	LocalGetPayload(ref Hash) (cmd Command, ok bool)
+
 	// Prunes blocks from the in-memory tree up to the specified height.
 	// Returns a set of forked blocks (blocks that were on a different branch, and thus not committed).
 	PruneToHeight(height View) (forkedBlocks []*Block)
+
+	// Prune removes the blocks below the given view, along with their proofs and payloads, to bound the memory usage
+	// of the block chain. The genesis block is never removed. See the RetentionWindow option.
+	Prune(belowView View)
+
+	// Snapshot returns the committed blocks that are still stored, ordered from the oldest block to the committed block,
+	// along with their inclusion proofs, such that a replica that is far behind can restore them without fetching
+	// the blocks one by one.
+	Snapshot() ([]FetchedBlock, error)
+
+	// Restore stores the blocks of a snapshot, and commits them with CommitProven, such that only the blocks
+	// that the QCs of the snapshot prove committed are committed. The snapshot may be obtained from any replica.
+	// Like CommitProven, it must not be called from the event loop, and it returns when the context is canceled.
+	Restore(ctx context.Context, snapshot []FetchedBlock) error
 }
 
+// DefaultRetentionWindow is the default value of the RetentionWindow option.
+const DefaultRetentionWindow View = 300
+
+// DefaultMaxPendingBlocks is the default value of the MaxPendingBlocks option.
+const DefaultMaxPendingBlocks = 1000
+
+// DefaultMaxWaitingVotes is the default value of the MaxWaitingVotes option.
+// A correct replica votes once per view, and its votes only wait until the proposal arrives,
+// so it rarely has more than a few votes waiting at once.
+const DefaultMaxWaitingVotes = 8
+
 //go:generate mockgen -destination=../internal/mocks/replica_mock.go -package=mocks . Replica
 
 // Replica represents a remote replica participating in the consensus protocol.
@@ -361,7 +868,67 @@ type Configuration interface {
 	// Timeout sends the timeout message to all replicas.
 	Timeout(msg TimeoutMsg)
 	// Fetch requests a block from all the replicas in the configuration.
-	Fetch(ctx context.Context, hash Hash) (block *Block, ok bool)
+	// The replica that delivers the block also returns its inclusion proof for the block, if it has one.
+	Fetch(ctx context.Context, hash Hash) (block *Block, proof InclusionProof, ok bool)
+	// FetchPayload requests the command with the given reference from all the replicas in the configuration.
+	FetchPayload(ctx context.Context, ref Hash) (cmd Command, ok bool)
+}
+
+// FetchedBlock is a block that was fetched from another replica, along with its inclusion proof, if it has one.
+type FetchedBlock struct {
+	Block *Block
+	Proof InclusionProof
+}
+
+// BatchFetcher is an optional interface for configurations that can request several blocks in a single request,
+// which is used when the ShouldBatchFetch option is set.
+type BatchFetcher interface {
+	// FetchBatch requests the blocks with the given hashes from the replicas in the configuration.
+	// It returns the blocks that were found. Blocks that none of the replicas could deliver are omitted.
+	FetchBatch(ctx context.Context, hashes []Hash) []FetchedBlock
+}
+
+// SnapshotFetcher is an optional interface for configurations that can request a snapshot of the committed blocks
+// from the other replicas.
+type SnapshotFetcher interface {
+	// FetchSnapshot requests a snapshot from the replicas in the configuration, and returns the first snapshot it receives.
+	// The snapshot is not validated, see BlockChain.Restore.
+	FetchSnapshot(ctx context.Context) (snapshot []FetchedBlock, ok bool)
+}
+
+// Decider is an optional interface for configurations that can broadcast decision messages,
+// which is needed by the ExplicitDecision commit mode.
+type Decider interface {
+	// Decide sends the decision to all replicas in the configuration.
+	Decide(msg DecideMsg)
+}
+
+// RegionalReplica is an optional interface for replicas whose region in the network topology is known,
+// which is needed to relay votes within a region, see Options.VoteRelayWindow.
+type RegionalReplica interface {
+	// Region returns the label of the region that the replica is located in, or an empty string if it is unknown.
+	Region() string
+}
+
+// VoteRelayer is an optional interface for replicas that can receive the votes collected by a regional relay
+// in a single message. Votes are sent to replicas that do not implement it one at a time.
+type VoteRelayer interface {
+	// RelayVotes sends the votes to the replica.
+	RelayVotes(votes []VoteMsg)
+}
+
+// Relinquisher is an optional interface for configurations that can broadcast relinquish messages,
+// which is needed by Modules.Relinquish.
+type Relinquisher interface {
+	// Relinquish sends the message to all replicas in the configuration.
+	Relinquish(msg RelinquishMsg)
+}
+
+// Heartbeater is an optional interface for configurations that can broadcast heartbeats,
+// which is needed by the HeartbeatInterval option.
+type Heartbeater interface {
+	// Heartbeat sends the heartbeat to all replicas in the configuration.
+	Heartbeat(msg HeartbeatMsg)
 }
 
 //go:generate mockgen -destination=../internal/mocks/consensus_mock.go -package=mocks . Consensus
@@ -376,6 +943,39 @@ type Consensus interface {
 	Propose(cert SyncInfo)
 	// CommittedBlock returns the most recently committed block.
 	CommittedBlock() *Block
+	// LockedBlock returns the locked block, or nil if the consensus implementation does not lock blocks.
+	// It must be called from the event loop.
+	LockedBlock() *Block
+	// ForceCommit commits all blocks up to and including the block certified by the checkpoint QC.
+	// The checkpoint must have been verified out-of-band, e.g. by obtaining it from a trusted majority.
+	// Any missing blocks are fetched, and the chain is validated before it is executed.
+	// ForceCommit returns an error if the checkpoint would move the committed block backward,
+	// or if the checkpoint block was not executed.
+	// The blocks are committed on the event loop, so ForceCommit must not be called from the event loop,
+	// and it does not return until the event loop is running, or the context is canceled.
+	// If the context is canceled after the blocks were queued for commit, the error of the context is returned,
+	// but the blocks are still committed once the event loop handles them.
+	// ForceCommit is an administrative operation, see replica.Replica.ForceCommit.
+	ForceCommit(ctx context.Context, checkpoint QuorumCert) error
+	// CommitProven commits all blocks up to and including the newest block that the commit rule decides,
+	// given the chain of blocks that ends with the block certified by the QC.
+	// Unlike ForceCommit, the QC need not be verified out-of-band, since the chain is verified
+	// and only the blocks that it proves committed are committed.
+	// It returns an error if the chain does not decide any block.
+	// Like ForceCommit, it must not be called from the event loop, and it returns when the context is canceled.
+	CommitProven(ctx context.Context, qc QuorumCert) error
+	// ExecError returns the error that was recorded when the command of the committed block failed execution.
+	// If the commands of the block were executed separately, the error names the first command that failed
+	// by its index in the block. ExecError returns nil if the block was executed successfully, if it has not
+	// been executed, or if it is older than the RetentionWindow.
+	ExecError(block Hash) error
+	// CommitCert returns a commit certificate for the most recently committed block, signed by this replica.
+	// It returns an error if no block other than the genesis block has been committed.
+	CommitCert() (CommitCert, error)
+	// DryRunPropose checks whether the replica could make a proposal for the sync info in the current view,
+	// without proposing, and returns an error describing the first blocker it finds.
+	// It must be called from the event loop.
+	DryRunPropose(cert SyncInfo) error
 }
 
 // LeaderRotation implements a leader rotation scheme.
@@ -384,6 +984,14 @@ type LeaderRotation interface {
 	GetLeader(View) hotstuff.ID
 }
 
+// ProposerCreditor is an optional interface for LeaderRotation implementations that select leaders
+// based on which proposals were committed.
+type ProposerCreditor interface {
+	// CreditProposer is called for each committed block, in commit order, while the block is being committed.
+	// Dummy blocks are not credited, as they are not proposed by any replica.
+	CreditProposer(block *Block)
+}
+
 //go:generate mockgen -destination=../internal/mocks/synchronizer_mock.go -package=mocks . Synchronizer
 
 // Synchronizer synchronizes replicas to the same view.
@@ -415,8 +1023,26 @@ type executorWrapper struct {
 	executor Executor
 }
 
-func (ew executorWrapper) Exec(block *Block) {
+func (ew executorWrapper) Exec(block *Block) error {
 	ew.executor.Exec(block.cmd)
+	return nil
+}
+
+type executorExtWrapper struct {
+	executor ExecutorExt
+}
+
+func (ew executorExtWrapper) Exec(block *Block) error {
+	ew.executor.Exec(block)
+	return nil
+}
+
+type fallibleExecutorWrapper struct {
+	executor FallibleExecutor
+}
+
+func (ew fallibleExecutorWrapper) Exec(block *Block) error {
+	return ew.executor.Exec(block.cmd)
 }
 
 type forkHandlerWrapper struct {
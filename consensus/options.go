@@ -1,8 +1,108 @@
 package consensus
 
+import (
+	"time"
+
+	"github.com/relab/hotstuff"
+)
+
+// DummyPolicy decides when dummy blocks are inserted into the chain.
+type DummyPolicy int
+
+const (
+	// NoDummies never inserts dummy blocks. This is the default.
+	NoDummies DummyPolicy = iota
+	// FillViewGaps makes the leader insert a single dummy block when it proposes after one or more views were skipped,
+	// such that the parent of the proposed block is in the preceding view.
+	// The dummy block always extends the certified block of the leader's highQC, so dummies never follow each other.
+	FillViewGaps
+	// FillEveryView makes the leader insert one dummy block for each skipped view when it proposes,
+	// such that the chain has a block in every view. The first dummy block extends the certified block
+	// of the leader's highQC, and each following dummy block extends the previous one.
+	FillEveryView
+)
+
+// PacemakerMode decides what makes the synchronizer advance to the next view.
+type PacemakerMode int
+
+const (
+	// HybridPacemaker advances to the next view as soon as a QC or TC for the current view is received,
+	// and sends a timeout message if the view timer expires first. This is the default.
+	HybridPacemaker PacemakerMode = iota
+	// MessageDrivenPacemaker advances to the next view only when a QC or TC is received.
+	// The view timer is never started, so timeout messages are never sent, and the replicas
+	// stop making progress if a leader fails. This is intended for experiments in a synchronous network.
+	MessageDrivenPacemaker
+	// TimeoutDrivenPacemaker advances to the next view only when the view timer expires, without sending timeout messages.
+	// Certificates for the current view update the highQC, and are forwarded to the next leader, but do not end the view.
+	// A replica that receives a certificate for a later view has fallen behind, and jumps directly to that view.
+	TimeoutDrivenPacemaker
+)
+
+// CommitMode decides how the replicas learn that a block can be committed.
+type CommitMode int
+
+const (
+	// ImplicitCommit makes each replica apply the commit rule to the QCs of the proposals that it receives. This is the default.
+	ImplicitCommit CommitMode = iota
+	// ExplicitDecision makes the leader apply the commit rule when it forms a QC, and broadcast a DecideMsg
+	// with the QC if it decides a block. The replicas only commit when they receive a valid decision,
+	// which makes the decision point explicit at the cost of an extra message for each decided block.
+	// The configuration must implement Decider, otherwise the replicas commit implicitly.
+	ExplicitDecision
+)
+
 // Options stores runtime configuration settings.
 type Options struct {
-	shouldUseAggQC bool
+	shouldUseAggQC         bool
+	shouldIncludeQCSigners bool
+	shouldVerifyQCChain    bool
+	shouldVerifyContinuity bool
+	shouldAuditQCs         bool
+	congestionDelay        time.Duration
+	voteRelayWindow        time.Duration
+	voteWindow             View
+	shouldUseStrictMode    bool
+	shouldUseFetchProofs   bool
+	shouldBatchFetch       bool
+	dummyPolicy            DummyPolicy
+	minProposalInterval    time.Duration
+	shouldDedupProposals   bool
+	shouldSpeculate        bool
+	shouldFilterProposals  bool
+	verificationWorkers    int
+	shouldEmitVoteEvents   bool
+	shouldFinalizeCommits  bool
+	shouldSkipEmptyBlocks  bool
+	genesisLeader          hotstuff.ID
+	fallbackLeaderAfter    View
+	payloadRefThreshold    int
+	maxPendingVotes        int
+	maxPendingBlocks       int
+	maxWaitingMessages     int
+	maxWaitingVotes        int
+	quorumLossTimeout      time.Duration
+	heartbeatInterval      time.Duration
+	shouldInstrumentLocks  bool
+	pacemakerMode          PacemakerMode
+	commitMode             CommitMode
+	minDistinctProposers   int
+	proposerWindow         int
+	proposalSignInterval   int
+	leaderRetryTimeout     time.Duration
+	maxProposalCommands    int
+	maxProposalBytes       int
+	checkpointInterval     View
+	executionWorkers       int
+	startupBarrierTimeout  time.Duration
+	shouldCommitCmdRoot    bool
+	shouldIsolateFetches   bool
+	shouldBundleViewChange bool
+	shouldStageParameters  bool
+	shouldValidateVotes    bool
+	retentionWindow        View
+	fetchTimeout           time.Duration
+	fetchRetries           int
 }
 
 // ShouldUseAggQC returns true if aggregated quorum certificates should be used.
@@ -11,6 +111,331 @@ func (c Options) ShouldUseAggQC() bool {
 	return c.shouldUseAggQC
 }
 
+// ShouldIncludeQCSigners returns true if proposals should include the signer set of the block's QC.
+func (c Options) ShouldIncludeQCSigners() bool {
+	return c.shouldIncludeQCSigners
+}
+
+// ShouldVerifyQCChain returns true if the QCs of all blocks between the last trusted block and a new highQC
+// should be verified before the highQC is accepted.
+func (c Options) ShouldVerifyQCChain() bool {
+	return c.shouldVerifyQCChain
+}
+
+// ShouldVerifyViewContinuity returns true if the views of committed blocks should be checked for unjustified gaps.
+// A committed block must either be in the view after its parent, or be certified to extend its parent by its QC,
+// which shows that the views in between ended without a decision. Other gaps are reported as invariant violations.
+func (c Options) ShouldVerifyViewContinuity() bool {
+	return c.shouldVerifyContinuity
+}
+
+// ShouldAuditQCs returns true if every QC should be verified immediately after it is created.
+// A QC that fails its own verification reveals a bug in the aggregation of the signatures, and is reported as an
+// invariant violation instead of being sent to the other replicas. This doubles the cost of creating a QC.
+func (c Options) ShouldAuditQCs() bool {
+	return c.shouldAuditQCs
+}
+
+// VoteRelayWindow returns how long a regional relay collects votes before it sends them to the leader.
+// When it is set, replicas that are not in the same region as the leader send their votes to the relay of their own region,
+// which is the replica with the lowest ID in the region. The relay sends the votes it collected for a block to the leader
+// in a single message as soon as it has the votes of every replica in its region, or when the window ends,
+// such that only one message per region crosses to the region of the leader.
+// The regions of the replicas are provided by replicas that implement RegionalReplica.
+// If zero, or if the region of the replica or the leader is unknown, votes are sent directly to the leader.
+func (c Options) VoteRelayWindow() time.Duration {
+	return c.voteRelayWindow
+}
+
+// CongestionDelay returns how long the leader should delay its proposals when a quorum of replicas are congested.
+// If it is 0, the leader does not delay its proposals.
+func (c Options) CongestionDelay() time.Duration {
+	return c.congestionDelay
+}
+
+// VoteWindow returns the number of views before the current view for which votes are accepted.
+// If it is 0, votes are accepted for any view after the committed block.
+func (c Options) VoteWindow() View {
+	return c.voteWindow
+}
+
+// ShouldUseStrictMode returns true if violations of protocol invariants should cause a panic instead of being logged.
+// This is intended for tests and staging environments.
+func (c Options) ShouldUseStrictMode() bool {
+	return c.shouldUseStrictMode
+}
+
+// ShouldUseFetchProofs returns true if leaders should sign their proposals,
+// and fetched blocks should only be accepted along with a valid inclusion proof.
+func (c Options) ShouldUseFetchProofs() bool {
+	return c.shouldUseFetchProofs
+}
+
+// ShouldBatchFetch returns true if the blocks of votes that are waiting for their blocks to arrive
+// should be fetched together in a single request.
+func (c Options) ShouldBatchFetch() bool {
+	return c.shouldBatchFetch
+}
+
+// ShouldIsolateFetches returns true if fetch requests should be sent over separate connections from the consensus messages,
+// such that large block transfers do not delay proposals and votes.
+func (c Options) ShouldIsolateFetches() bool {
+	return c.shouldIsolateFetches
+}
+
+// ProposalSignInterval returns the number of proposals that a leader makes for each proposal that it signs,
+// when fetch proofs are used. If it is 0 or 1, every proposal is signed.
+func (c Options) ProposalSignInterval() int {
+	return c.proposalSignInterval
+}
+
+// DummyPolicy returns the policy that decides when dummy blocks are inserted into the chain.
+func (c Options) DummyPolicy() DummyPolicy {
+	return c.dummyPolicy
+}
+
+// PacemakerMode returns the mode that decides what makes the synchronizer advance to the next view.
+func (c Options) PacemakerMode() PacemakerMode {
+	return c.pacemakerMode
+}
+
+// CommitMode returns the mode that decides how the replicas learn that a block can be committed.
+func (c Options) CommitMode() CommitMode {
+	return c.commitMode
+}
+
+// MinDistinctProposers returns the number of distinct proposers that must have proposed one of the last
+// ProposerWindow blocks of the chain before the chain is committed. If it is 0, commits do not depend on the proposers.
+func (c Options) MinDistinctProposers() int {
+	return c.minDistinctProposers
+}
+
+// ProposerWindow returns the number of blocks in which MinDistinctProposers distinct proposers are required.
+func (c Options) ProposerWindow() int {
+	return c.proposerWindow
+}
+
+// MinProposalInterval returns the minimum time between two successive proposals by the same leader.
+// If it is 0, the leader proposes as soon as it can.
+func (c Options) MinProposalInterval() time.Duration {
+	return c.minProposalInterval
+}
+
+// ShouldDedupProposals returns true if proposals that the replica has already voted for should be ignored.
+func (c Options) ShouldDedupProposals() bool {
+	return c.shouldDedupProposals
+}
+
+// ShouldProposeSpeculatively returns true if the leader of a view that was entered through a timeout should prepare
+// its proposal on its highQC right away, but only send it once a quorum of replicas have sent NewView messages for the view.
+// If one of the NewView messages carries a higher QC, the proposal is discarded and replaced by one that extends the higher QC.
+func (c Options) ShouldProposeSpeculatively() bool {
+	return c.shouldSpeculate
+}
+
+// ShouldFilterProposals returns true if the backend should drop proposals that are certain to be rejected,
+// such as proposals for decided views, oversized proposals, and proposals from replicas that do not lead the view,
+// before it converts them and passes them to the consensus module.
+func (c Options) ShouldFilterProposals() bool {
+	return c.shouldFilterProposals
+}
+
+// VerificationWorkers returns the number of signature verifications that may run concurrently.
+// If it is 0, verifications run on the goroutine that requests them, without a limit.
+func (c Options) VerificationWorkers() int {
+	return c.verificationWorkers
+}
+
+// ShouldEmitVoteEvents returns true if replicas should emit a VoteSentEvent whenever they vote.
+// This is intended for debugging, and is disabled by default to avoid the overhead.
+func (c Options) ShouldEmitVoteEvents() bool {
+	return c.shouldEmitVoteEvents
+}
+
+// ShouldFinalizeCommits returns true if replicas should run an additional finalization round after committing a block.
+// Replicas attach a signed commit certificate to their votes, and the leader combines a quorum of them into a
+// finality certificate, which it includes in its next proposal. Client commands are only acknowledged once
+// their block is finalized, which adds latency but gives clients a portable proof of finality.
+func (c Options) ShouldFinalizeCommits() bool {
+	return c.shouldFinalizeCommits
+}
+
+// ShouldSkipEmptyBlocks returns true if blocks with an empty command should be committed without being executed.
+func (c Options) ShouldSkipEmptyBlocks() bool {
+	return c.shouldSkipEmptyBlocks
+}
+
+// GenesisLeader returns the ID of the replica that leads view 1, overriding the leader rotation.
+// Every replica starts out with the genesis block, so two replicas that both believe that they lead view 1
+// would fork the chain immediately. With a genesis leader, all replicas agree on the single leader of view 1,
+// and proposals for view 1 from any other replica are rejected. If zero, the leader rotation decides.
+func (c Options) GenesisLeader() hotstuff.ID {
+	return c.genesisLeader
+}
+
+// FallbackLeaderAfter returns the number of consecutive views after the last committed block after which the leader
+// rotation is overridden by a fallback leader. The fallback leader is the proposer of the last committed block,
+// which is known to have made progress recently. If it fails for the same number of views, the next replica takes over.
+// If zero, the leader rotation always decides.
+func (c Options) FallbackLeaderAfter() View {
+	return c.fallbackLeaderAfter
+}
+
+// MaxPendingVotes returns the maximum number of messages that are retained in the waiting room for a block that has not yet arrived.
+// Only one vote and one proposal from each replica is retained for each block, so a quorum can still be assembled as long as
+// the limit is at least the number of replicas. If zero, the messages for a block are only limited by the number of replicas.
+func (c Options) MaxPendingVotes() int {
+	return c.maxPendingVotes
+}
+
+// MaxPendingBlocks returns the maximum number of distinct blocks that have not yet arrived,
+// for which messages are retained in the waiting room, and the maximum number of distinct blocks
+// for which the voting machine retains verified votes that have not yet formed a QC.
+// When the limit is reached, the messages for the block that was least recently touched are evicted.
+// The default is DefaultMaxPendingBlocks. If zero, there is no limit.
+func (c Options) MaxPendingBlocks() int {
+	return c.maxPendingBlocks
+}
+
+// MaxWaitingMessages returns the maximum number of proposals and votes that are retained in the waiting room in total.
+// Messages that arrive while the waiting room is full are discarded. If zero, there is no limit.
+func (c Options) MaxWaitingMessages() int {
+	return c.maxWaitingMessages
+}
+
+// MaxWaitingVotes returns the maximum number of votes from each replica that are retained in the waiting room.
+// Since the block of a waiting vote is fetched if it does not arrive, this limits the number of fetches
+// that a single replica can cause by voting for blocks that do not exist.
+// The default is DefaultMaxWaitingVotes. If zero, there is no limit.
+func (c Options) MaxWaitingVotes() int {
+	return c.maxWaitingVotes
+}
+
+// HeartbeatInterval returns how often the replica broadcasts a heartbeat with its current view and the view of its highQC.
+// The heartbeats, along with the proposals and votes that the replica receives, keep the status of the other replicas
+// fresh when there is little load, and reveal replicas that have fallen behind, see Modules.Peers and LaggingPeerEvent.
+// Heartbeats are only sent if the configuration implements Heartbeater. If zero, no heartbeats are sent.
+func (c Options) HeartbeatInterval() time.Duration {
+	return c.heartbeatInterval
+}
+
+// StartupBarrierTimeout returns how long a replica waits at startup for a quorum of replicas to be ready before it starts the first view.
+// Until then, the leader of the first view does not propose and the view timer does not run, which avoids a view change
+// when the replicas do not start at the same time. Replicas announce that they are ready with heartbeats,
+// so the configuration must implement Heartbeater. If the timeout expires, the replica starts anyway.
+// If zero, the replica starts the first view immediately.
+func (c Options) StartupBarrierTimeout() time.Duration {
+	return c.startupBarrierTimeout
+}
+
+// QuorumLossTimeout returns how long a replica must be unable to reach a quorum of replicas before it becomes read-only.
+// A read-only replica neither proposes nor votes, but keeps its committed state,
+// and becomes active again as soon as a quorum is reachable. Connectivity is reported by the ConnectivityMonitor module.
+// If zero, or if no ConnectivityMonitor is registered, the replica never becomes read-only.
+// The gorums backend considers a replica reachable if it sent a message within the timeout,
+// so the HeartbeatInterval should be shorter than the timeout, such that idle replicas remain reachable.
+func (c Options) QuorumLossTimeout() time.Duration {
+	return c.quorumLossTimeout
+}
+
+// MaxProposalCommands returns the maximum number of client commands that a proposal may include.
+// The commands are counted by the Acceptor, which must implement CommandCounter. If zero, there is no limit.
+func (c Options) MaxProposalCommands() int {
+	return c.maxProposalCommands
+}
+
+// MaxProposalBytes returns the maximum size in bytes of the command of a proposal. If zero, there is no limit.
+func (c Options) MaxProposalBytes() int {
+	return c.maxProposalBytes
+}
+
+// CheckpointInterval returns the number of views between the pinned checkpoints.
+// A committed block is pinned if its view is at least CheckpointInterval views after the latest pinned checkpoint,
+// and the replica rejects any chain that does not include the latest pinned checkpoint.
+// If a CheckpointStore is registered, the checkpoints are persisted, such that they are kept even if the replica loses
+// the rest of its state. If zero, no checkpoints are pinned.
+func (c Options) CheckpointInterval() View {
+	return c.checkpointInterval
+}
+
+// LeaderRetryTimeout returns how long a leader waits for a quorum of votes for its proposal before it resends the proposal.
+// Replicas that already voted for the proposal resend their vote, such that the leader can recover from lost votes
+// without a view change. The leader keeps resending the proposal at this interval until the proposal is certified
+// or the view ends. If zero, proposals are never resent.
+func (c Options) LeaderRetryTimeout() time.Duration {
+	return c.leaderRetryTimeout
+}
+
+// ExecutionWorkers returns the number of commands from a committed block that may execute concurrently.
+// This requires both a CommandScheduler, which splits the block into commands and orders the commands that conflict,
+// and a ScheduledExecutor. If it is 0, or if either module is missing, each block is executed as a whole by the Executor.
+func (c Options) ExecutionWorkers() int {
+	return c.executionWorkers
+}
+
+// ShouldCommitCommandRoot returns true if blocks should commit to the Merkle root of their client commands,
+// such that a replica can prove that a client command was committed without sending the whole block, see Modules.ProveCommand.
+// The leader adds the root to its proposals, and the replicas reject proposals without a root, or with a root
+// that does not match the command. The client commands are found by the acceptor, if it implements CommandSplitter.
+func (c Options) ShouldCommitCommandRoot() bool {
+	return c.shouldCommitCmdRoot
+}
+
+// ShouldBundleViewChanges returns true if the first proposal after a view change should carry the timeout certificate
+// that ended the previous view, together with the QC that the proposal extends. The replicas reject proposals
+// that skip views without such a bundle, so a replica that missed the view change can verify the new view from the
+// proposal alone. Views do not end with timeout certificates in the TimeoutDrivenPacemaker mode, so the setting has no effect there.
+func (c Options) ShouldBundleViewChanges() bool {
+	return c.shouldBundleViewChange
+}
+
+// ShouldStageParameterChanges returns true if blocks may carry parameter changes, see ParameterChange.
+// A parameter change is not passed to the acceptor or the executor. Instead, the replicas vote for it if it takes effect
+// after the view of its block, and schedule it when its block is committed.
+func (c Options) ShouldStageParameterChanges() bool {
+	return c.shouldStageParameters
+}
+
+// ShouldValidateVotes returns true if the leader should check the votes it has collected for a block before it creates a QC.
+// Votes that do not target the block, that repeat an earlier vote of the same replica, or that are signed by replicas
+// outside the configuration are logged and discarded, and the QC is created once a quorum of valid votes remains.
+func (c Options) ShouldValidateVotes() bool {
+	return c.shouldValidateVotes
+}
+
+// RetentionWindow returns the number of views below the committed block for which blocks are kept in the block chain.
+// Older blocks are pruned when a block is committed, and can no longer be served to replicas that fetch them.
+// It is DefaultRetentionWindow unless it is set, and if it is 0, blocks are never pruned.
+func (c Options) RetentionWindow() View {
+	return c.retentionWindow
+}
+
+// FetchTimeout returns how long a replica waits for the other replicas to deliver a missing block before it gives up
+// on the request. If it is 0, a fetch is only abandoned when the view ends.
+func (c Options) FetchTimeout() time.Duration {
+	return c.fetchTimeout
+}
+
+// FetchRetries returns the number of times a fetch that timed out is repeated, see FetchTimeout.
+// Each attempt asks all the other replicas again, such that a replica that was unreachable or slow can still deliver.
+// The votes that were waiting for a block that could not be fetched are discarded.
+func (c Options) FetchRetries() int {
+	return c.fetchRetries
+}
+
+// ShouldInstrumentLocks returns true if the time spent waiting for and holding the lock that guards
+// the consensus state should be measured. The measurements are available through the LockReporter interface.
+func (c Options) ShouldInstrumentLocks() bool {
+	return c.shouldInstrumentLocks
+}
+
+// PayloadRefThreshold returns the size in bytes above which a leader proposes a command by reference.
+// Such a block carries only the hash of the command, and replicas fetch the command when they need it.
+// If zero, commands are always included in the blocks.
+func (c Options) PayloadRefThreshold() int {
+	return c.payloadRefThreshold
+}
+
 // OptionsBuilder is used to set the values of immutable configuration settings.
 type OptionsBuilder struct {
 	opts Options
@@ -20,3 +445,255 @@ type OptionsBuilder struct {
 func (builder *OptionsBuilder) SetShouldUseAggQC() {
 	builder.opts.shouldUseAggQC = true
 }
+
+// SetShouldIncludeQCSigners sets the ShouldIncludeQCSigners setting to true.
+func (builder *OptionsBuilder) SetShouldIncludeQCSigners() {
+	builder.opts.shouldIncludeQCSigners = true
+}
+
+// SetShouldVerifyViewContinuity sets the ShouldVerifyViewContinuity setting to true.
+func (builder *OptionsBuilder) SetShouldVerifyViewContinuity() {
+	builder.opts.shouldVerifyContinuity = true
+}
+
+// SetShouldAuditQCs sets the ShouldAuditQCs setting to true.
+func (builder *OptionsBuilder) SetShouldAuditQCs() {
+	builder.opts.shouldAuditQCs = true
+}
+
+// SetShouldVerifyQCChain sets the ShouldVerifyQCChain setting to true.
+func (builder *OptionsBuilder) SetShouldVerifyQCChain() {
+	builder.opts.shouldVerifyQCChain = true
+}
+
+// SetVoteRelayWindow sets the VoteRelayWindow setting.
+func (builder *OptionsBuilder) SetVoteRelayWindow(window time.Duration) {
+	builder.opts.voteRelayWindow = window
+}
+
+// SetCongestionDelay sets the CongestionDelay setting.
+func (builder *OptionsBuilder) SetCongestionDelay(delay time.Duration) {
+	builder.opts.congestionDelay = delay
+}
+
+// SetVoteWindow sets the VoteWindow setting.
+func (builder *OptionsBuilder) SetVoteWindow(views View) {
+	builder.opts.voteWindow = views
+}
+
+// SetShouldUseStrictMode sets the ShouldUseStrictMode setting to true.
+func (builder *OptionsBuilder) SetShouldUseStrictMode() {
+	builder.opts.shouldUseStrictMode = true
+}
+
+// SetShouldUseFetchProofs sets the ShouldUseFetchProofs setting to true.
+func (builder *OptionsBuilder) SetShouldUseFetchProofs() {
+	builder.opts.shouldUseFetchProofs = true
+}
+
+// SetShouldBatchFetch sets the ShouldBatchFetch setting to true.
+// Batching reduces the number of round trips that are needed to catch up after missing several proposals.
+// If the configuration does not implement BatchFetcher, the blocks are fetched one by one.
+func (builder *OptionsBuilder) SetShouldBatchFetch() {
+	builder.opts.shouldBatchFetch = true
+}
+
+// SetProposalSignInterval sets the ProposalSignInterval setting.
+// Signing only every interval'th proposal amortizes the signing cost of a leader that proposes frequently.
+// A signed proposal carries the hashes of the leader's unsigned proposals since its last signed proposal,
+// and the leader signs the ProposerRunDigest of those blocks and the proposed block, such that the signature
+// is an inclusion proof for each of them. A follower rejects an unsigned proposal from a leader that has already
+// made interval-1 unsigned proposals that the follower has seen since the leader's last signed proposal.
+// The unsigned blocks have no inclusion proof until the next signed proposal, so they cannot be fetched before then.
+func (builder *OptionsBuilder) SetProposalSignInterval(interval int) {
+	builder.opts.proposalSignInterval = interval
+}
+
+// SetDummyPolicy sets the DummyPolicy setting.
+func (builder *OptionsBuilder) SetDummyPolicy(policy DummyPolicy) {
+	builder.opts.dummyPolicy = policy
+}
+
+// SetPacemakerMode sets the PacemakerMode setting.
+func (builder *OptionsBuilder) SetPacemakerMode(mode PacemakerMode) {
+	builder.opts.pacemakerMode = mode
+}
+
+// SetCommitMode sets the CommitMode setting.
+func (builder *OptionsBuilder) SetCommitMode(mode CommitMode) {
+	builder.opts.commitMode = mode
+}
+
+// SetMinDistinctProposers sets the MinDistinctProposers and ProposerWindow settings.
+// This is a policy for studying leader diversity, not a safety rule: while a single leader monopolizes the chain,
+// commits are postponed, and they resume once the last window blocks have enough distinct proposers.
+func (builder *OptionsBuilder) SetMinDistinctProposers(proposers, window int) {
+	builder.opts.minDistinctProposers = proposers
+	builder.opts.proposerWindow = window
+}
+
+// SetMinProposalInterval sets the MinProposalInterval setting.
+func (builder *OptionsBuilder) SetMinProposalInterval(interval time.Duration) {
+	builder.opts.minProposalInterval = interval
+}
+
+// SetShouldDedupProposals sets the ShouldDedupProposals setting to true.
+func (builder *OptionsBuilder) SetShouldDedupProposals() {
+	builder.opts.shouldDedupProposals = true
+}
+
+// SetShouldProposeSpeculatively sets the ShouldProposeSpeculatively setting to true.
+// Without it, the leader proposes as soon as it enters the view, even if another replica knows of a higher QC,
+// in which case the replicas that are locked on the higher QC reject the proposal and the view is wasted.
+// The leader does not send a NewView message to itself, but it counts towards the quorum.
+func (builder *OptionsBuilder) SetShouldProposeSpeculatively() {
+	builder.opts.shouldSpeculate = true
+}
+
+// SetShouldFilterProposals sets the ShouldFilterProposals setting to true.
+// The backend calls the leader rotation outside of the event loop to filter proposals,
+// so the leader rotation must be safe for concurrent use.
+func (builder *OptionsBuilder) SetShouldFilterProposals() {
+	builder.opts.shouldFilterProposals = true
+}
+
+// SetVerificationWorkers sets the VerificationWorkers setting.
+func (builder *OptionsBuilder) SetVerificationWorkers(workers int) {
+	builder.opts.verificationWorkers = workers
+}
+
+// SetShouldEmitVoteEvents sets the ShouldEmitVoteEvents setting to true.
+func (builder *OptionsBuilder) SetShouldEmitVoteEvents() {
+	builder.opts.shouldEmitVoteEvents = true
+}
+
+// SetShouldFinalizeCommits sets the ShouldFinalizeCommits setting to true.
+func (builder *OptionsBuilder) SetShouldFinalizeCommits() {
+	builder.opts.shouldFinalizeCommits = true
+}
+
+// SetShouldSkipEmptyBlocks sets the ShouldSkipEmptyBlocks setting to true.
+// Empty blocks are filler proposals that still count towards the commit rules, so skipping them only avoids
+// the executor calls. The commit events are still emitted for them.
+func (builder *OptionsBuilder) SetShouldSkipEmptyBlocks() {
+	builder.opts.shouldSkipEmptyBlocks = true
+}
+
+// SetGenesisLeader sets the GenesisLeader setting.
+func (builder *OptionsBuilder) SetGenesisLeader(id hotstuff.ID) {
+	builder.opts.genesisLeader = id
+}
+
+// SetFallbackLeaderAfter sets the FallbackLeaderAfter setting.
+// A block is committed some views after it was proposed, so the setting must be larger than the number of views
+// that the commit rule needs, or the fallback leader also takes over when there are no failures. Each fallback leader
+// leads for the given number of consecutive views, which must also be enough for it to commit a block on its own.
+func (builder *OptionsBuilder) SetFallbackLeaderAfter(views View) {
+	builder.opts.fallbackLeaderAfter = views
+}
+
+// SetPayloadRefThreshold sets the PayloadRefThreshold setting.
+func (builder *OptionsBuilder) SetPayloadRefThreshold(bytes int) {
+	builder.opts.payloadRefThreshold = bytes
+}
+
+// SetMaxPendingVotes sets the MaxPendingVotes setting.
+func (builder *OptionsBuilder) SetMaxPendingVotes(votes int) {
+	builder.opts.maxPendingVotes = votes
+}
+
+// SetMaxPendingBlocks sets the MaxPendingBlocks setting.
+func (builder *OptionsBuilder) SetMaxPendingBlocks(blocks int) {
+	builder.opts.maxPendingBlocks = blocks
+}
+
+// SetMaxWaitingMessages sets the MaxWaitingMessages setting.
+func (builder *OptionsBuilder) SetMaxWaitingMessages(messages int) {
+	builder.opts.maxWaitingMessages = messages
+}
+
+// SetMaxWaitingVotes sets the MaxWaitingVotes setting.
+func (builder *OptionsBuilder) SetMaxWaitingVotes(votes int) {
+	builder.opts.maxWaitingVotes = votes
+}
+
+// SetHeartbeatInterval sets the HeartbeatInterval setting.
+func (builder *OptionsBuilder) SetHeartbeatInterval(interval time.Duration) {
+	builder.opts.heartbeatInterval = interval
+}
+
+// SetStartupBarrierTimeout sets the StartupBarrierTimeout setting.
+func (builder *OptionsBuilder) SetStartupBarrierTimeout(timeout time.Duration) {
+	builder.opts.startupBarrierTimeout = timeout
+}
+
+// SetQuorumLossTimeout sets the QuorumLossTimeout setting.
+func (builder *OptionsBuilder) SetQuorumLossTimeout(timeout time.Duration) {
+	builder.opts.quorumLossTimeout = timeout
+}
+
+// SetProposalLimits sets the MaxProposalCommands and MaxProposalBytes settings.
+// Replicas reject proposals that exceed the limits, which bounds the work that a faulty leader can impose
+// on the other replicas in a single view. All replicas must use the same limits.
+func (builder *OptionsBuilder) SetProposalLimits(commands, bytes int) {
+	builder.opts.maxProposalCommands = commands
+	builder.opts.maxProposalBytes = bytes
+}
+
+// SetCheckpointInterval sets the CheckpointInterval setting.
+func (builder *OptionsBuilder) SetCheckpointInterval(views View) {
+	builder.opts.checkpointInterval = views
+}
+
+// SetLeaderRetryTimeout sets the LeaderRetryTimeout setting.
+// The timeout should be shorter than the view timeout, such that the leader can retry before the other replicas give up on it.
+func (builder *OptionsBuilder) SetLeaderRetryTimeout(timeout time.Duration) {
+	builder.opts.leaderRetryTimeout = timeout
+}
+
+// SetShouldInstrumentLocks sets the ShouldInstrumentLocks setting to true.
+func (builder *OptionsBuilder) SetShouldInstrumentLocks() {
+	builder.opts.shouldInstrumentLocks = true
+}
+
+// SetExecutionWorkers sets the ExecutionWorkers setting.
+func (builder *OptionsBuilder) SetExecutionWorkers(workers int) {
+	builder.opts.executionWorkers = workers
+}
+
+// SetShouldCommitCommandRoot sets the ShouldCommitCommandRoot setting to true.
+func (builder *OptionsBuilder) SetShouldCommitCommandRoot() {
+	builder.opts.shouldCommitCmdRoot = true
+}
+
+// SetShouldIsolateFetches sets the ShouldIsolateFetches setting to true.
+// The setting is read by the networking backend when it connects to the other replicas.
+func (builder *OptionsBuilder) SetShouldIsolateFetches() {
+	builder.opts.shouldIsolateFetches = true
+}
+
+// SetShouldBundleViewChanges sets the ShouldBundleViewChanges setting to true.
+func (builder *OptionsBuilder) SetShouldBundleViewChanges() {
+	builder.opts.shouldBundleViewChange = true
+}
+
+// SetShouldStageParameterChanges sets the ShouldStageParameterChanges setting to true.
+func (builder *OptionsBuilder) SetShouldStageParameterChanges() {
+	builder.opts.shouldStageParameters = true
+}
+
+// SetShouldValidateVotes sets the ShouldValidateVotes setting to true.
+func (builder *OptionsBuilder) SetShouldValidateVotes() {
+	builder.opts.shouldValidateVotes = true
+}
+
+// SetRetentionWindow sets the RetentionWindow setting.
+func (builder *OptionsBuilder) SetRetentionWindow(views View) {
+	builder.opts.retentionWindow = views
+}
+
+// SetFetchTimeout sets the FetchTimeout and FetchRetries settings.
+func (builder *OptionsBuilder) SetFetchTimeout(timeout time.Duration, retries int) {
+	builder.opts.fetchTimeout = timeout
+	builder.opts.fetchRetries = retries
+}
@@ -0,0 +1,110 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Parameters are the protocol parameters that can be changed while the replicas are running, see ParameterChange.
+// The zero value of a parameter means that the parameter is not changed.
+type Parameters struct {
+	ViewTimeout time.Duration // the duration of a view, which overrides the view duration of the synchronizer
+}
+
+// merge returns the parameters with the parameters that are set in other replaced.
+func (p Parameters) merge(other Parameters) Parameters {
+	if other.ViewTimeout > 0 {
+		p.ViewTimeout = other.ViewTimeout
+	}
+	return p
+}
+
+// ParameterChange changes the protocol parameters from the given view on, if the ShouldStageParameterChanges option is set.
+// A change is proposed as the command of a block, see EncodeParameterChange, and the replicas schedule it when the block
+// is committed. Since every replica commits the same blocks, every replica switches to the new parameters at the same view,
+// as long as the block is committed before the view begins. A replica that commits the block later switches as soon as
+// it has committed it.
+type ParameterChange struct {
+	View       View
+	Parameters Parameters
+}
+
+// parameterChangePrefix marks the commands that carry parameter changes.
+var parameterChangePrefix = []byte("hotstuff/parameters\x00")
+
+// EncodeParameterChange returns a command that carries the parameter change.
+func EncodeParameterChange(change ParameterChange) Command {
+	buf := make([]byte, len(parameterChangePrefix)+16)
+	n := copy(buf, parameterChangePrefix)
+	binary.BigEndian.PutUint64(buf[n:], uint64(change.View))
+	binary.BigEndian.PutUint64(buf[n+8:], uint64(change.Parameters.ViewTimeout))
+	return Command(buf)
+}
+
+// DecodeParameterChange returns the parameter change that is carried by the command.
+// It returns false if the command does not carry a parameter change.
+func DecodeParameterChange(cmd Command) (change ParameterChange, ok bool) {
+	b := []byte(cmd)
+	if len(b) != len(parameterChangePrefix)+16 || !bytes.HasPrefix(b, parameterChangePrefix) {
+		return ParameterChange{}, false
+	}
+	b = b[len(parameterChangePrefix):]
+	change.View = View(binary.BigEndian.Uint64(b))
+	change.Parameters.ViewTimeout = time.Duration(binary.BigEndian.Uint64(b[8:]))
+	return change, change.Parameters.ViewTimeout >= 0
+}
+
+// parameterSchedule holds the committed parameter changes, ordered by the view they take effect in.
+type parameterSchedule struct {
+	mut     sync.Mutex
+	changes []ParameterChange
+}
+
+func (s *parameterSchedule) add(change ParameterChange) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	// changes that take effect in the same view are applied in the order they were committed.
+	i := sort.Search(len(s.changes), func(i int) bool { return s.changes[i].View > change.View })
+	s.changes = append(s.changes, ParameterChange{})
+	copy(s.changes[i+1:], s.changes[i:])
+	s.changes[i] = change
+}
+
+func (s *parameterSchedule) at(view View) (params Parameters) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for _, change := range s.changes {
+		if change.View > view {
+			break
+		}
+		params = params.merge(change.Parameters)
+	}
+	return params
+}
+
+// Parameters returns the protocol parameters that are in effect in the given view,
+// according to the parameter changes that the replica has committed.
+func (mods *Modules) Parameters(view View) Parameters {
+	return mods.parameters.at(view)
+}
+
+// parameterChange returns the parameter change that is carried by the command of the block,
+// if the ShouldStageParameterChanges option is set.
+func (cs *consensusBase) parameterChange(block *Block) (ParameterChange, bool) {
+	if !cs.mods.Options().ShouldStageParameterChanges() {
+		return ParameterChange{}, false
+	}
+	return DecodeParameterChange(block.Command())
+}
+
+// proposed tells the acceptor that the command of the block was proposed.
+// Parameter changes are not passed to the acceptor, since they are not client commands.
+func (cs *consensusBase) proposed(block *Block) {
+	if _, ok := cs.parameterChange(block); ok {
+		return
+	}
+	cs.mods.Acceptor().Proposed(block.Command())
+}
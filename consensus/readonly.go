@@ -0,0 +1,68 @@
+package consensus
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/relab/hotstuff/modules"
+)
+
+// readOnlyMonitor makes the replica read-only when it has been unable to reach a quorum of replicas
+// for longer than the QuorumLossTimeout, and active again as soon as a quorum is reachable.
+type readOnlyMonitor struct {
+	mods     *Modules
+	readOnly int32
+	lostAt   time.Time // when the replica became unable to reach a quorum, or zero if it can reach a quorum
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (m *readOnlyMonitor) InitConsensusModule(mods *Modules, _ *OptionsBuilder) {
+	m.mods = mods
+}
+
+// InitModule starts checking the connectivity of the replica, if the QuorumLossTimeout option is set.
+// This must happen after the options have been set.
+func (m *readOnlyMonitor) InitModule(_ *modules.Modules) {
+	timeout := m.mods.Options().QuorumLossTimeout()
+	if timeout <= 0 || m.mods.connectivity == nil {
+		return
+	}
+	m.mods.EventLoop().AddTicker(timeout/4, func(tick time.Time) interface{} {
+		return func() { m.check(tick) }
+	})
+}
+
+func (m *readOnlyMonitor) get() bool {
+	return atomic.LoadInt32(&m.readOnly) == 1
+}
+
+// set changes the state, and returns true if the state was changed.
+func (m *readOnlyMonitor) set(readOnly bool) bool {
+	var v int32
+	if readOnly {
+		v = 1
+	}
+	return atomic.SwapInt32(&m.readOnly, v) != v
+}
+
+// check updates the state according to the current connectivity. It must be called from the event loop.
+func (m *readOnlyMonitor) check(now time.Time) {
+	reachable := m.mods.connectivity.Reachable()
+	quorum := m.mods.Configuration().QuorumSize()
+	if reachable >= quorum {
+		m.lostAt = time.Time{}
+		if m.set(false) {
+			m.mods.Logger().Infof("%d replicas are reachable again, leaving read-only mode", reachable)
+			m.mods.EmitEvent(ReadOnlyEvent{ReadOnly: false, Reachable: reachable})
+		}
+		return
+	}
+	if m.lostAt.IsZero() {
+		m.lostAt = now
+	}
+	if now.Sub(m.lostAt) >= m.mods.Options().QuorumLossTimeout() && m.set(true) {
+		m.mods.Logger().Warnf("only %d of the %d replicas needed for a quorum are reachable, entering read-only mode", reachable, quorum)
+		m.mods.EmitEvent(ReadOnlyEvent{ReadOnly: true, Reachable: reachable})
+	}
+}
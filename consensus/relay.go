@@ -0,0 +1,129 @@
+package consensus
+
+import (
+	"time"
+
+	"github.com/relab/hotstuff"
+)
+
+// region returns the region of the replica with the given ID, or an empty string if it is unknown.
+func (vm *VotingMachine) region(id hotstuff.ID) string {
+	replica, ok := vm.mods.Configuration().Replica(id)
+	if !ok {
+		return ""
+	}
+	if regional, ok := replica.(RegionalReplica); ok {
+		return regional.Region()
+	}
+	return ""
+}
+
+// relayOf returns the relay of the given region, which is the replica with the lowest ID in the region,
+// and the number of replicas in the region.
+func (vm *VotingMachine) relayOf(region string) (relay hotstuff.ID, members int) {
+	for id := range vm.mods.Configuration().Replicas() {
+		if vm.region(id) != region {
+			continue
+		}
+		members++
+		if relay == 0 || id < relay {
+			relay = id
+		}
+	}
+	return relay, members
+}
+
+// relay returns the relay that the votes of this replica should be sent through to reach the given leader.
+// It returns false if the votes should be sent directly to the leader.
+func (vm *VotingMachine) relay(leader hotstuff.ID) (hotstuff.ID, bool) {
+	if vm.mods.Options().VoteRelayWindow() <= 0 || leader == vm.mods.ID() {
+		return 0, false
+	}
+	region := vm.region(vm.mods.ID())
+	if region == "" {
+		return 0, false
+	}
+	if leaderRegion := vm.region(leader); leaderRegion == "" || leaderRegion == region {
+		return 0, false
+	}
+	relay, _ := vm.relayOf(region)
+	return relay, true
+}
+
+// voteTarget returns the ID of the replica that a vote for a block in the given view should be sent to.
+func (vm *VotingMachine) voteTarget(view View) hotstuff.ID {
+	leader := vm.mods.LeaderRotation().GetLeader(view)
+	if relay, ok := vm.relay(leader); ok {
+		return relay
+	}
+	return leader
+}
+
+// relayVote adds a vote from the region of this replica to the votes that will be relayed to the leader.
+// The votes for a block are sent once the relay has a vote from every replica in the region,
+// or when the relay window ends. A vote that arrives after the votes were sent is relayed in a new message.
+func (vm *VotingMachine) relayVote(vote VoteMsg) {
+	hash := vote.PartialCert.BlockHash()
+	signer := vote.PartialCert.Signature().Signer()
+	votes := vm.relayed[hash]
+	for _, v := range votes {
+		if v.PartialCert.Signature().Signer() == signer {
+			return
+		}
+	}
+	if len(votes) == 0 {
+		time.AfterFunc(vm.mods.Options().VoteRelayWindow(), func() {
+			vm.mods.EventLoop().AddEvent(func() { vm.flushRelay(hash) })
+		})
+	}
+	votes = append(votes, vote)
+	vm.relayed[hash] = votes
+
+	if _, members := vm.relayOf(vm.region(vm.mods.ID())); len(votes) >= members {
+		vm.flushRelay(hash)
+	}
+}
+
+// flushRelay sends the collected votes for the block with the given hash to the leader.
+func (vm *VotingMachine) flushRelay(hash Hash) {
+	votes, ok := vm.relayed[hash]
+	if !ok {
+		return
+	}
+	delete(vm.relayed, hash)
+
+	leaderID := vm.mods.LeaderRotation().GetLeader(votes[0].PartialCert.View())
+	leader, ok := vm.mods.Configuration().Replica(leaderID)
+	if !ok {
+		vm.mods.Logger().Warnf("Replica with ID %d was not found!", leaderID)
+		return
+	}
+	vm.mods.Logger().Debugf("Relaying %d votes for block %.8s to replica %d", len(votes), hash, leaderID)
+	if relayer, ok := leader.(VoteRelayer); ok {
+		relayer.RelayVotes(votes)
+		return
+	}
+	for _, vote := range votes {
+		leader.Vote(vote.PartialCert)
+	}
+}
+
+// OnRelayedVotes handles the votes relayed from another region.
+// Each vote is handled as if it was sent by its signer, and duplicate votes from the same signer are ignored.
+func (vm *VotingMachine) OnRelayedVotes(msg RelayedVotesMsg) {
+	vm.mods.Logger().Debugf("OnRelayedVotes(%d): %d votes", msg.ID, len(msg.Votes))
+	signers := make(idSetMap)
+	for _, vote := range msg.Votes {
+		if vote.PartialCert.Signature() == nil {
+			continue
+		}
+		signer := vote.PartialCert.Signature().Signer()
+		if signers.Contains(signer) {
+			continue
+		}
+		signers.Add(signer)
+		vote.ID = signer
+		vote.Deferred = false
+		vm.OnVote(vote)
+	}
+}
@@ -0,0 +1,116 @@
+package consensus
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Schedule is the execution order of the client commands in a committed block.
+// Commands[i] must execute after the commands with the indices in After[i], which are all less than i.
+// Commands that are not ordered, directly or through other commands, may execute concurrently.
+// Executing the commands according to the schedule has the same observable outcome as executing them serially,
+// as long as the schedule orders every pair of conflicting commands.
+type Schedule struct {
+	Commands []Command
+	After    [][]int
+}
+
+// ScheduleByKeys returns the schedule of the given commands, where two commands conflict if they access a common key.
+// Each command is ordered after the latest preceding command that accessed each of its keys.
+// The schedule only depends on the commands and the keys function, so every replica derives the same schedule.
+func ScheduleByKeys(cmds []Command, keys func(Command) []string) Schedule {
+	schedule := Schedule{
+		Commands: cmds,
+		After:    make([][]int, len(cmds)),
+	}
+	latest := make(map[string]int)
+	for i, cmd := range cmds {
+		deps := make(map[int]struct{})
+		for _, key := range keys(cmd) {
+			if j, ok := latest[key]; ok {
+				deps[j] = struct{}{}
+			}
+			latest[key] = i
+		}
+		for j := range deps {
+			schedule.After[i] = append(schedule.After[i], j)
+		}
+		sort.Ints(schedule.After[i])
+	}
+	return schedule
+}
+
+// valid returns an error if the schedule orders a command after itself or a later command.
+func (s Schedule) valid() error {
+	if len(s.After) != 0 && len(s.After) != len(s.Commands) {
+		return fmt.Errorf("schedule has %d dependency lists for %d commands", len(s.After), len(s.Commands))
+	}
+	for i, deps := range s.After {
+		for _, j := range deps {
+			if j < 0 || j >= i {
+				return fmt.Errorf("command %d cannot execute after command %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// exec executes the command in the block, using the ScheduledExecutor if the ExecutionWorkers option is set
+// and the command can be scheduled. Otherwise, the block is executed by the Executor.
+func (cs *consensusBase) exec(block *Block) error {
+	workers := cs.mods.Options().ExecutionWorkers()
+	if workers <= 0 || cs.mods.scheduler == nil || cs.mods.scheduled == nil {
+		return cs.mods.Executor().Exec(block)
+	}
+	schedule, ok := cs.mods.scheduler.Schedule(block.Command())
+	if !ok {
+		return cs.mods.Executor().Exec(block)
+	}
+	if err := schedule.valid(); err != nil {
+		cs.mods.InvariantViolation("invalid schedule for block %.8s: %v", block.Hash(), err)
+		return cs.mods.Executor().Exec(block)
+	}
+	return execSchedule(schedule, cs.mods.scheduled, workers)
+}
+
+// execSchedule executes the commands in the schedule, running at most workers commands at a time.
+// A command runs once all the commands it is ordered after have finished, even if some of them failed,
+// because failed commands are still committed. If any commands fail, the error of the first of them is returned.
+func execSchedule(schedule Schedule, executor ScheduledExecutor, workers int) error {
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, workers)
+		done = make([]chan struct{}, len(schedule.Commands))
+		errs = make([]error, len(schedule.Commands))
+	)
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	for i, cmd := range schedule.Commands {
+		var deps []int
+		if len(schedule.After) > 0 {
+			deps = schedule.After[i]
+		}
+		wg.Add(1)
+		go func(i int, cmd Command, deps []int) {
+			defer wg.Done()
+			defer close(done[i])
+			// the dependencies have lower indices, so they never wait for this command, and a worker is only taken
+			// once the command is ready to run.
+			for _, j := range deps {
+				<-done[j]
+			}
+			sem <- struct{}{}
+			errs[i] = executor.ExecCommand(cmd)
+			<-sem
+		}(i, cmd, deps)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("command %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/relab/hotstuff"
+)
+
+// SignerBitmap is the canonical encoding of the set of replicas that signed a certificate.
+//
+// The replica with ID i is represented by bit (i-1)%8 of byte (i-1)/8. The position of a replica only depends on its ID,
+// which the configuration assigns to the replica for its lifetime, and not on the position of the replica
+// in the configuration. Replicas that join or leave the configuration therefore never shift the positions of the others,
+// and a bitmap that was created before a reconfiguration still identifies the same signers after it.
+// The bitmap has no trailing zero bytes, such that every set of signers has exactly one encoding.
+type SignerBitmap []byte
+
+// NewSignerBitmap returns the canonical bitmap of the given set of signers.
+func NewSignerBitmap(signers IDSet) SignerBitmap {
+	var bm SignerBitmap
+	signers.ForEach(func(id hotstuff.ID) {
+		if id == 0 {
+			return
+		}
+		i := int(id) - 1
+		if len(bm) <= i/8 {
+			bm = append(bm, make([]byte, i/8+1-len(bm))...)
+		}
+		bm[i/8] |= 1 << (i % 8)
+	})
+	return bm
+}
+
+// ParseSignerBitmap returns the bitmap encoded in b, or an error if b is not a canonical bitmap.
+func ParseSignerBitmap(b []byte) (SignerBitmap, error) {
+	if len(b) > 0 && b[len(b)-1] == 0 {
+		return nil, errors.New("signer bitmap has trailing zero bytes")
+	}
+	return SignerBitmap(b), nil
+}
+
+// IDs returns the IDs of the signers in ascending order.
+func (bm SignerBitmap) IDs() []hotstuff.ID {
+	ids := make([]hotstuff.ID, 0)
+	for i, b := range bm {
+		for bit := 0; bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				ids = append(ids, hotstuff.ID(i*8+bit+1))
+			}
+		}
+	}
+	return ids
+}
+
+// CheckConfiguration returns an error if any of the signers are not part of the configuration.
+// A bitmap that refers to a replica that has left the configuration is rejected, instead of ignoring the signer.
+func (bm SignerBitmap) CheckConfiguration(cfg Configuration) error {
+	for _, id := range bm.IDs() {
+		if _, ok := cfg.Replica(id); !ok {
+			return fmt.Errorf("signer %d is not in the configuration", id)
+		}
+	}
+	return nil
+}
@@ -76,3 +76,22 @@ func (hs *SimpleHotStuff) CommitRule(block *consensus.Block) *consensus.Block {
 	}
 	return nil
 }
+
+// Checkpoint updates the locked block after the replica was forced to commit up to a checkpoint.
+func (hs *SimpleHotStuff) Checkpoint(block *consensus.Block) {
+	if block.View() > hs.locked.View() {
+		hs.locked = block
+	}
+}
+
+// RestoreLock restores the locked block after the replica restarted.
+func (hs *SimpleHotStuff) RestoreLock(block *consensus.Block) {
+	if block.View() > hs.locked.View() {
+		hs.locked = block
+	}
+}
+
+// LockedBlock returns the currently locked block.
+func (hs *SimpleHotStuff) LockedBlock() *consensus.Block {
+	return hs.locked
+}
@@ -0,0 +1,106 @@
+package consensus
+
+// speculation is a proposal that the leader prepared on its highQC when it entered a view through a timeout.
+// The proposal is held back until a quorum of replicas have sent NewView messages for the view,
+// such that it is only sent if it extends the highest QC known to the quorum.
+type speculation struct {
+	cert     SyncInfo
+	cmd      Command
+	proposal ProposeMsg
+}
+
+// shouldSpeculate returns true if the proposal for the given sync info should be held back as a speculative proposal.
+// This is the case when the view was entered through a timeout certificate, since the replicas that timed out
+// may know of a higher QC than the leader. An aggregate QC already proves the highest QC of a quorum,
+// so there is nothing to speculate on when aggregate QCs are used.
+func (cs *consensusBase) shouldSpeculate(cert SyncInfo) bool {
+	if !cs.mods.Options().ShouldProposeSpeculatively() || cs.mods.Options().ShouldUseAggQC() {
+		return false
+	}
+	tc, ok := cert.TC()
+	if !ok {
+		return false
+	}
+	qc, ok := cert.QC()
+	return !ok || qc.View() < tc.View()
+}
+
+// onNewView records the sender of a NewView message, and adopts the QC of the message if it is higher than the highQC.
+// The synchronizer only uses the TC of a sync info that has one, so the leader would otherwise not learn of the QC.
+func (cs *consensusBase) onNewView(msg NewViewMsg) {
+	if !cs.mods.Options().ShouldProposeSpeculatively() {
+		return
+	}
+
+	var view View
+	if tc, ok := msg.SyncInfo.TC(); ok {
+		view = tc.View() + 1
+	}
+	if qc, ok := msg.SyncInfo.QC(); ok {
+		if qc.View()+1 > view {
+			view = qc.View() + 1
+		}
+		if qc.View() > cs.mods.Synchronizer().HighQC().View() {
+			cs.mods.Synchronizer().UpdateHighQC(qc)
+		}
+	}
+
+	current := cs.mods.Synchronizer().View()
+	for v := range cs.newViews {
+		if v < current {
+			delete(cs.newViews, v)
+		}
+	}
+	if view < current {
+		return
+	}
+	// the message may arrive before the leader has entered the view, in which case it is counted once the leader gets there.
+	senders, ok := cs.newViews[view]
+	if !ok {
+		senders = make(idSetMap)
+		cs.newViews[view] = senders
+	}
+	senders.Add(msg.ID)
+
+	cs.resolveSpeculation()
+}
+
+// resolveSpeculation replaces the speculative proposal if a higher QC has been learned since it was prepared,
+// and sends it once a quorum of replicas, including the leader, have sent NewView messages for its view.
+// The speculative proposal is dropped if the view ends before then.
+func (cs *consensusBase) resolveSpeculation() {
+	spec := cs.speculation
+	if spec == nil {
+		return
+	}
+	view := spec.proposal.Block.View()
+	if view != cs.mods.Synchronizer().View() {
+		cs.speculation = nil
+		return
+	}
+
+	if highQC, qc := cs.mods.Synchronizer().HighQC(), spec.proposal.Block.QuorumCert(); highQC.BlockHash() != qc.BlockHash() {
+		cs.mods.Logger().Debugf("Propose: discarding speculative proposal %.8s, a replica reported a higher QC", spec.proposal.Block.Hash())
+		cs.mods.EmitEvent(SpeculativeProposalEvent{Block: spec.proposal.Block, Sent: false})
+		cert := spec.cert.WithQC(highQC)
+		proposal, ok := cs.createProposal(cert, spec.cmd)
+		if !ok {
+			cs.speculation = nil
+			return
+		}
+		spec.cert, spec.proposal = cert, proposal
+	}
+
+	senders := cs.newViews[view]
+	votes := len(senders)
+	if !senders.Contains(cs.mods.ID()) {
+		votes++
+	}
+	if votes < cs.mods.Configuration().QuorumSize() {
+		return
+	}
+
+	cs.speculation = nil
+	cs.mods.EmitEvent(SpeculativeProposalEvent{Block: spec.proposal.Block, Sent: true})
+	cs.sendProposal(spec.proposal)
+}
@@ -0,0 +1,83 @@
+package consensus
+
+// SafetyState is the protocol state that a replica must keep across restarts.
+// A replica that loses it could vote twice in the same view, or vote for a block that conflicts with its locked block.
+type SafetyState struct {
+	LastVote View       // The latest view in which the replica voted, or stopped voting.
+	Locked   *Block     // The locked block, or nil if the Rules implementation does not lock blocks.
+	HighQC   QuorumCert // The highest known QC.
+}
+
+// StateStore durably stores the safety state of the consensus protocol,
+// such that a replica that restarts does not contradict the votes it sent before the restart.
+// If no store is registered, the state is only kept in memory.
+type StateStore interface {
+	// SaveState durably stores the state. It is called before each vote is sent, and whenever the locked block changes.
+	SaveState(state SafetyState) error
+	// LoadState returns the stored state. If no state has been stored, ok is false.
+	LoadState() (state SafetyState, ok bool, err error)
+}
+
+// LockRestorer is an optional interface that adds a RestoreLock method.
+// This allows implementors to restore their locked block from a StateStore when the replica restarts.
+type LockRestorer interface {
+	// RestoreLock is called with the stored locked block before the replica participates in the protocol.
+	RestoreLock(block *Block)
+}
+
+// loadState restores the safety state from the StateStore, if one is registered.
+// The highQC is only restored if its block is stored locally, otherwise it is learned again from the other replicas.
+func (cs *consensusBase) loadState() {
+	store := cs.mods.StateStore()
+	if store == nil {
+		return
+	}
+	state, ok, err := store.LoadState()
+	if err != nil {
+		cs.mods.Logger().Warnf("Failed to load the safety state: %v", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	cs.StopVoting(state.LastVote)
+	if state.Locked != nil {
+		cs.mods.BlockChain().Store(state.Locked)
+		if restorer, ok := cs.impl.(LockRestorer); ok {
+			restorer.RestoreLock(state.Locked)
+		}
+	}
+	if _, ok := cs.mods.BlockChain().LocalGet(state.HighQC.BlockHash()); ok {
+		cs.mods.Synchronizer().UpdateHighQC(state.HighQC)
+	}
+	cs.saved = state
+}
+
+// saveState stores the safety state, if a StateStore is registered and the state has changed since it was last stored.
+func (cs *consensusBase) saveState() error {
+	store := cs.mods.StateStore()
+	if store == nil {
+		return nil
+	}
+	state := SafetyState{
+		LastVote: cs.lastVote,
+		Locked:   cs.LockedBlock(),
+		HighQC:   cs.mods.Synchronizer().HighQC(),
+	}
+	if state.LastVote == cs.saved.LastVote && sameBlock(state.Locked, cs.saved.Locked) &&
+		state.HighQC.BlockHash() == cs.saved.HighQC.BlockHash() && state.HighQC.View() == cs.saved.HighQC.View() {
+		return nil
+	}
+	if err := store.SaveState(state); err != nil {
+		return err
+	}
+	cs.saved = state
+	return nil
+}
+
+func sameBlock(a, b *Block) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Hash() == b.Hash()
+}
@@ -3,6 +3,7 @@ package consensus
 import (
 	"bytes"
 	"crypto"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
 	"fmt"
@@ -109,12 +110,13 @@ type ThresholdSignature interface {
 // PartialCert is a signed block hash.
 type PartialCert struct {
 	signature Signature
+	view      View
 	blockHash Hash
 }
 
 // NewPartialCert returns a new partial certificate.
-func NewPartialCert(signature Signature, blockHash Hash) PartialCert {
-	return PartialCert{signature, blockHash}
+func NewPartialCert(signature Signature, view View, blockHash Hash) PartialCert {
+	return PartialCert{signature, view, blockHash}
 }
 
 // Signature returns the signature.
@@ -127,6 +129,11 @@ func (pc PartialCert) BlockHash() Hash {
 	return pc.blockHash
 }
 
+// View returns the view of the block that was signed.
+func (pc PartialCert) View() View {
+	return pc.view
+}
+
 // ToBytes returns a byte representation of the partial certificate.
 func (pc PartialCert) ToBytes() []byte {
 	return append(pc.blockHash[:], pc.signature.ToBytes()...)
@@ -220,6 +227,8 @@ func (qc QuorumCert) ToBytes() []byte {
 	b = append(b, qc.hash[:]...)
 	if qc.signature != nil {
 		b = append(b, qc.signature.ToBytes()...)
+		// not every signature scheme encodes the signers in its signatures.
+		b = append(b, qc.SignerBitmap()...)
 	}
 	return b
 }
@@ -239,6 +248,19 @@ func (qc QuorumCert) View() View {
 	return qc.view
 }
 
+// Signers returns the IDs of the replicas that signed the QC, in ascending order.
+func (qc QuorumCert) Signers() []hotstuff.ID {
+	return qc.SignerBitmap().IDs()
+}
+
+// SignerBitmap returns the canonical bitmap of the replicas that signed the QC.
+func (qc QuorumCert) SignerBitmap() SignerBitmap {
+	if qc.signature == nil {
+		return nil
+	}
+	return NewSignerBitmap(qc.signature.Participants())
+}
+
 // Equals returns true if the other QC equals this QC.
 func (qc QuorumCert) Equals(other QuorumCert) bool {
 	if qc.view != other.view {
@@ -253,6 +275,19 @@ func (qc QuorumCert) Equals(other QuorumCert) bool {
 	return bytes.Equal(qc.signature.ToBytes(), other.signature.ToBytes())
 }
 
+// VoteDigest returns the hash that is signed by votes and quorum certificates, which covers the view along with the block hash.
+// Signing the view prevents the view of a quorum certificate from being changed without invalidating its signature,
+// such that quorum certificates can be compared by view without looking up their blocks.
+func VoteDigest(view View, blockHash Hash) Hash {
+	h := sha256.New()
+	_, _ = h.Write([]byte("vote"))
+	_, _ = h.Write(view.ToBytes())
+	_, _ = h.Write(blockHash[:])
+	var digest Hash
+	h.Sum(digest[:0])
+	return digest
+}
+
 func (qc QuorumCert) String() string {
 	var sb strings.Builder
 	if qc.signature != nil {
@@ -0,0 +1,63 @@
+package consensus
+
+import "fmt"
+
+// ViewChangeBundle proves that a view change happened before a proposal, see the ShouldBundleViewChanges option.
+// The timeout certificate shows that a quorum of replicas gave up on the view before the proposal,
+// and the QC is the highest QC that the leader knew of when it proposed, which the proposed block extends.
+type ViewChangeBundle struct {
+	TC     TimeoutCert
+	HighQC QuorumCert
+}
+
+func (b ViewChangeBundle) String() string {
+	return fmt.Sprintf("ViewChangeBundle{ TC: %v, HighQC: %v }", b.TC, b.HighQC)
+}
+
+// followsViewChange returns true if the block does not directly follow the view of its QC,
+// which means that at least one view ended without a QC before the block was proposed.
+func followsViewChange(block *Block) bool {
+	return block.View() > block.QuorumCert().View()+1
+}
+
+// bundleViewChange returns the view-change bundle for the proposal, if the sync info that the proposal was made for
+// carries the timeout certificate of the view before the proposal.
+func bundleViewChange(cert SyncInfo, block *Block) (*ViewChangeBundle, bool) {
+	if !followsViewChange(block) {
+		return nil, false
+	}
+	tc, ok := cert.TC()
+	if !ok || tc.View()+1 != block.View() {
+		return nil, false
+	}
+	return &ViewChangeBundle{TC: tc, HighQC: block.QuorumCert()}, true
+}
+
+// verifyViewChange verifies the view-change bundle of a proposal that follows a view change.
+// The QC of the block must already have been verified.
+func (cs *consensusBase) verifyViewChange(proposal ProposeMsg) error {
+	block := proposal.Block
+	bundle := proposal.ViewChange
+	if bundle == nil {
+		if followsViewChange(block) {
+			return fmt.Errorf("block at view %d skips views after its QC at view %d, but has no view-change bundle",
+				block.View(), block.QuorumCert().View())
+		}
+		return nil
+	}
+	if bundle.TC.View()+1 != block.View() {
+		return fmt.Errorf("the timeout certificate is for view %d, not the view before the block at view %d", bundle.TC.View(), block.View())
+	}
+	if !bundle.HighQC.Equals(block.QuorumCert()) {
+		return fmt.Errorf("the block does not extend the high QC of the bundle")
+	}
+	if !cs.mods.Crypto().VerifyTimeoutCert(bundle.TC) {
+		return fmt.Errorf("invalid timeout certificate for view %d", bundle.TC.View())
+	}
+	return nil
+}
+
+// bundlesViewChanges returns true if proposals that follow a view change must carry a view-change bundle.
+func (cs *consensusBase) bundlesViewChanges() bool {
+	return cs.mods.Options().ShouldBundleViewChanges() && cs.mods.Options().PacemakerMode() != TimeoutDrivenPacemaker
+}
@@ -1,28 +1,44 @@
 package consensus
 
 import (
+	"crypto/sha256"
 	"sync"
+
+	"github.com/relab/hotstuff"
 )
 
 // VotingMachine collects votes.
 type VotingMachine struct {
 	mut           sync.Mutex
 	mods          *Modules
-	verifiedVotes map[Hash][]PartialCert // verified votes that could become a QC
+	verifiedVotes map[Hash][]PartialCert           // verified votes that could become a QC
+	votesLRU      *hashLRU                         // the order in which the blocks in verifiedVotes were last voted for
+	congested     map[hotstuff.ID]congestionSignal // the congestion signal from the latest verified vote of each replica
+	relayed       map[Hash][]VoteMsg               // the votes from the region of this replica that will be relayed to the leader
 }
 
 // NewVotingMachine returns a new VotingMachine.
 func NewVotingMachine() *VotingMachine {
 	return &VotingMachine{
 		verifiedVotes: make(map[Hash][]PartialCert),
+		votesLRU:      newHashLRU(),
+		congested:     make(map[hotstuff.ID]congestionSignal),
+		relayed:       make(map[Hash][]VoteMsg),
 	}
 }
 
+// congestionSignal is the congestion signal of a vote, and the view of the vote.
+type congestionSignal struct {
+	congested bool
+	view      View
+}
+
 // InitConsensusModule gives the module a reference to the Modules object.
 // It also allows the module to set module options using the OptionsBuilder.
 func (vm *VotingMachine) InitConsensusModule(mods *Modules, _ *OptionsBuilder) {
 	vm.mods = mods
 	vm.mods.EventLoop().RegisterHandler(VoteMsg{}, func(event interface{}) { vm.OnVote(event.(VoteMsg)) })
+	vm.mods.EventLoop().RegisterHandler(RelayedVotesMsg{}, func(event interface{}) { vm.OnRelayedVotes(event.(RelayedVotesMsg)) })
 }
 
 // OnVote handles an incoming vote.
@@ -30,6 +46,23 @@ func (vm *VotingMachine) OnVote(vote VoteMsg) {
 	cert := vote.PartialCert
 	vm.mods.Logger().Debugf("OnVote(%d): %.8s", vote.ID, cert.BlockHash())
 
+	if cert.Signature() != nil {
+		if relay, ok := vm.relay(vm.mods.LeaderRotation().GetLeader(cert.View())); ok && relay == vm.mods.ID() {
+			vm.relayVote(vote)
+			return
+		}
+	}
+
+	if !vote.Deferred {
+		vm.mods.EmitEvent(VoteReceivedEvent{ID: vote.ID, View: cert.View(), BlockHash: cert.BlockHash()})
+	}
+
+	// reject votes that are too old to be useful before attempting to fetch the block.
+	if watermark := vm.watermark(); cert.View() <= watermark {
+		vm.mods.Logger().Debugf("OnVote(%d): vote for view %d is below the watermark %d", vote.ID, cert.View(), watermark)
+		return
+	}
+
 	var (
 		block *Block
 		ok    bool
@@ -39,31 +72,127 @@ func (vm *VotingMachine) OnVote(vote VoteMsg) {
 		// first, try to get the block from the local cache
 		block, ok = vm.mods.BlockChain().LocalGet(cert.BlockHash())
 		if !ok {
-			// if that does not work, we will try to handle this event later.
-			// hopefully, the block has arrived by then.
+			// if that does not work, the vote waits for the block to arrive.
+			// if it has not arrived by the time the next proposal has been handled, we will try to fetch it.
 			vm.mods.Logger().Debugf("Local cache miss for block: %.8s", cert.BlockHash())
-			vote.Deferred = true
-			vm.mods.EventLoop().DelayUntil(ProposeMsg{}, vote)
+			hash := cert.BlockHash()
+			if !vm.mods.WaitingRoom().Wait(hash, vote.ID, vote) {
+				vm.mods.Logger().Debugf("OnVote(%d): discarding pending vote for block: %.8s", vote.ID, cert.BlockHash())
+				return
+			}
+			vm.mods.EventLoop().DelayUntil(ProposeMsg{}, func() { vm.releasePending(hash) })
 			return
 		}
 	} else {
 		// if the block has not arrived at this point we will try to fetch it.
-		block, ok = vm.mods.BlockChain().Get(cert.BlockHash())
+		block, ok = vm.mods.BlockChain().LocalGet(cert.BlockHash())
+		if !ok {
+			// the view that passed the watermark is signed along with the block hash,
+			// so the vote is verified before the view is trusted enough to fetch the block.
+			if !vm.mods.Crypto().VerifyPartialCert(cert) {
+				vm.mods.Logger().Infof("OnVote(%d): discarding invalid vote for missing block %.8s", vote.ID, cert.BlockHash())
+				return
+			}
+			block, ok = vm.mods.BlockChain().Get(cert.BlockHash())
+		}
 		if !ok {
-			vm.mods.Logger().Debugf("Could not find block for vote: %.8s.", cert.BlockHash())
+			vm.mods.Logger().Infof("OnVote(%d): discarding vote for block %.8s that could not be fetched", vote.ID, cert.BlockHash())
 			return
 		}
 	}
 
-	if block.View() <= vm.mods.Synchronizer().LeafBlock().View() {
+	if block.View() != cert.View() {
+		vm.mods.Logger().Infof("OnVote(%d): vote view %d does not match block view %d", vote.ID, cert.View(), block.View())
+		return
+	}
+
+	leafView := vm.mods.Synchronizer().LeafBlock().View()
+	if block.View() <= leafView {
 		// too old
 		return
 	}
 
-	go vm.verifyCert(cert, block)
+	// the synchronizer must only be accessed from the event loop, so the view of the leaf block is passed along.
+	go vm.verifyCert(vote, block, leafView)
+}
+
+// releasePending releases the votes that are still waiting for the block with the given hash, such that they fetch the block.
+// If the ShouldBatchFetch option is set, the missing blocks of all waiting messages are fetched in a single request first.
+// The block chain releases the messages waiting for each block that it fetches, so those messages find their blocks locally.
+func (vm *VotingMachine) releasePending(hash Hash) {
+	room := vm.mods.WaitingRoom()
+	if !room.Waiting(hash) {
+		// the block has already arrived.
+		return
+	}
+	if vm.mods.Options().ShouldBatchFetch() {
+		hashes := []Hash{hash}
+		for _, pending := range room.Hashes() {
+			if pending != hash {
+				hashes = append(hashes, pending)
+			}
+		}
+		vm.mods.BlockChain().GetBatch(hashes)
+	}
+	room.Release(hash)
+}
+
+// PendingVotes returns the number of votes that are waiting for their block to arrive.
+func (vm *VotingMachine) PendingVotes() int {
+	return vm.mods.WaitingRoom().count(VoteMsg{})
+}
+
+// watermark returns the highest view for which votes are rejected.
+// Votes for views at or below the committed block can never form a useful QC.
+// If the VoteWindow option is set, votes that are more than VoteWindow views behind the current view are also rejected.
+func (vm *VotingMachine) watermark() View {
+	watermark := vm.mods.Consensus().CommittedBlock().View()
+	window := vm.mods.Options().VoteWindow()
+	if current := vm.mods.Synchronizer().View(); window > 0 && current > window && current-window > watermark {
+		watermark = current - window
+	}
+	return watermark
+}
+
+// Congested returns true if a quorum of replicas signaled congestion in their latest verified votes.
+// The signals of replicas that have not voted within the vote window are discarded.
+// The vote window is VoteWindow views, or two rounds of leaders if the VoteWindow option is not set,
+// since a leader only receives votes in the views after the ones it leads.
+// It must be called from the event loop.
+func (vm *VotingMachine) Congested() bool {
+	window := vm.mods.Options().VoteWindow()
+	if window == 0 {
+		window = View(2 * vm.mods.Configuration().Len())
+	}
+	var expired View
+	if current := vm.mods.Synchronizer().View(); current > window {
+		expired = current - window
+	}
+
+	vm.mut.Lock()
+	defer vm.mut.Unlock()
+
+	n := 0
+	for id, signal := range vm.congested {
+		if signal.view <= expired {
+			delete(vm.congested, id)
+			continue
+		}
+		if signal.congested {
+			n++
+		}
+	}
+	return n >= vm.mods.Configuration().QuorumSize()
+}
+
+// removeVotes removes the verified votes for the block with the given hash. The caller must hold the lock.
+func (vm *VotingMachine) removeVotes(hash Hash) {
+	delete(vm.verifiedVotes, hash)
+	vm.votesLRU.remove(hash)
 }
 
-func (vm *VotingMachine) verifyCert(cert PartialCert, block *Block) {
+func (vm *VotingMachine) verifyCert(vote VoteMsg, block *Block, leafView View) {
+	cert := vote.PartialCert
 	if !vm.mods.Crypto().VerifyPartialCert(cert) {
 		vm.mods.Logger().Info("OnVote: Vote could not be verified!")
 		return
@@ -72,21 +201,40 @@ func (vm *VotingMachine) verifyCert(cert PartialCert, block *Block) {
 	vm.mut.Lock()
 	defer vm.mut.Unlock()
 
+	if signal, ok := vm.congested[vote.ID]; !ok || cert.View() >= signal.view {
+		vm.congested[vote.ID] = congestionSignal{congested: vote.Congested, view: cert.View()}
+	}
+
 	// this defer will clean up any old votes in verifiedVotes
 	defer func() {
 		// delete any pending QCs with lower height than bLeaf
 		for k := range vm.verifiedVotes {
 			if block, ok := vm.mods.BlockChain().LocalGet(k); ok {
-				if block.View() <= vm.mods.Synchronizer().LeafBlock().View() {
-					delete(vm.verifiedVotes, k)
+				if block.View() <= leafView {
+					vm.removeVotes(k)
 				}
 			} else {
-				delete(vm.verifiedVotes, k)
+				vm.removeVotes(k)
 			}
 		}
 	}()
 
-	votes := vm.verifiedVotes[cert.BlockHash()]
+	votes, ok := vm.verifiedVotes[cert.BlockHash()]
+	if max := vm.mods.Options().MaxPendingBlocks(); !ok && max > 0 {
+		// evict the votes for the blocks that were least recently voted for.
+		for vm.votesLRU.len() >= max {
+			hash, _ := vm.votesLRU.oldest()
+			vm.removeVotes(hash)
+		}
+	}
+	vm.votesLRU.touch(cert.BlockHash())
+	for _, vote := range votes {
+		if vote.Signature().Signer() == cert.Signature().Signer() {
+			// replicas resend their votes if the leader resends its proposal.
+			vm.mods.Logger().Debugf("OnVote(%d): duplicate vote for block: %.8s", cert.Signature().Signer(), cert.BlockHash())
+			return
+		}
+	}
 	votes = append(votes, cert)
 	vm.verifiedVotes[cert.BlockHash()] = votes
 
@@ -94,14 +242,64 @@ func (vm *VotingMachine) verifyCert(cert PartialCert, block *Block) {
 		return
 	}
 
+	if vm.mods.Options().ShouldValidateVotes() {
+		votes = vm.validVotes(block, votes)
+		vm.verifiedVotes[cert.BlockHash()] = votes
+		if len(votes) < vm.mods.Configuration().QuorumSize() {
+			return
+		}
+	}
+
 	qc, err := vm.mods.Crypto().CreateQuorumCert(block, votes)
 	if err != nil {
 		vm.mods.Logger().Info("OnVote: could not create QC for block: ", err)
 		return
 	}
-	delete(vm.verifiedVotes, cert.BlockHash())
+	vm.removeVotes(cert.BlockHash())
+	vm.mods.EmitEvent(QCFormedEvent{QC: qc, CriticalVoter: cert.Signature().Signer()})
+
+	if vm.mods.Options().CommitMode() == ExplicitDecision {
+		// the leader decides before it uses the QC to advance to the next view.
+		vm.mods.EventLoop().AddEvent(DecideMsg{ID: vm.mods.ID(), QC: qc})
+	}
 
 	// signal the synchronizer
 	// because votes are handled asynchronously, we can safely use AddEvent without starting a goroutine.
 	vm.mods.EventLoop().AddEvent(NewViewMsg{ID: vm.mods.ID(), SyncInfo: NewSyncInfo().WithQC(qc)})
 }
+
+// validVotes returns the votes that target the content of the block and come from distinct replicas in the configuration.
+// The other votes can only have been collected because of a bug, so they are logged and discarded.
+func (vm *VotingMachine) validVotes(block *Block, votes []PartialCert) []PartialCert {
+	if hash := Hash(sha256.Sum256(block.ToBytes())); hash != block.Hash() {
+		vm.mods.Logger().Warnf("OnVote: the content of block %.8s hashes to %.8s, discarding its votes", block.Hash(), hash)
+		return nil
+	}
+	valid := make([]PartialCert, 0, len(votes))
+	signers := make(map[hotstuff.ID]bool, len(votes))
+	for _, vote := range votes {
+		signer := vote.Signature().Signer()
+		switch {
+		case vote.BlockHash() != block.Hash() || vote.View() != block.View():
+			vm.mods.Logger().Warnf("OnVote: discarding vote from %d for block %.8s at view %d, which was collected for block %.8s at view %d",
+				signer, vote.BlockHash(), vote.View(), block.Hash(), block.View())
+		case signers[signer]:
+			vm.mods.Logger().Warnf("OnVote: discarding duplicate vote from %d for block %.8s", signer, block.Hash())
+		case !vm.isMember(signer):
+			vm.mods.Logger().Warnf("OnVote: discarding vote for block %.8s from %d, which is not in the configuration", block.Hash(), signer)
+		default:
+			signers[signer] = true
+			valid = append(valid, vote)
+		}
+	}
+	return valid
+}
+
+// isMember returns true if the replica with the given ID is this replica or one of the replicas in the configuration.
+func (vm *VotingMachine) isMember(id hotstuff.ID) bool {
+	if id == vm.mods.ID() {
+		return true
+	}
+	_, ok := vm.mods.Configuration().Replica(id)
+	return ok
+}
@@ -0,0 +1,194 @@
+package consensus
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/relab/hotstuff"
+)
+
+// WaitingRoom holds proposals and votes that refer to a block that has not yet arrived.
+// The messages are keyed by the hash of the awaited block, and all messages waiting for a block
+// are released together, in the order they arrived, when the block is stored in the block chain.
+// Released messages are added to the event loop again with the Deferred field set,
+// such that the handlers know that they should not be deferred a second time.
+//
+// The waiting room is bounded by the MaxPendingBlocks, MaxPendingVotes, MaxWaitingVotes, and MaxWaitingMessages options.
+// Only one message of each type from each sender is retained for a block.
+// When messages arrive for more than MaxPendingBlocks blocks, the messages for the block that was least recently
+// waited for are evicted, such that votes for blocks that do not exist cannot crowd out the votes for the current block.
+type WaitingRoom struct {
+	mut     sync.Mutex
+	mods    *Modules
+	waiting map[Hash][]waitingMsg
+	lru     *hashLRU
+	size    int
+	votes   map[hotstuff.ID]int // the number of waiting votes from each sender
+}
+
+type waitingMsg struct {
+	sender hotstuff.ID
+	msg    interface{}
+}
+
+// NewWaitingRoom returns a new WaitingRoom.
+func NewWaitingRoom() *WaitingRoom {
+	return &WaitingRoom{
+		waiting: make(map[Hash][]waitingMsg),
+		lru:     newHashLRU(),
+		votes:   make(map[hotstuff.ID]int),
+	}
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (wr *WaitingRoom) InitConsensusModule(mods *Modules, _ *OptionsBuilder) {
+	wr.mods = mods
+}
+
+// Wait adds a message from the given sender that waits for the block with the given hash.
+// Only ProposeMsg and VoteMsg can wait for a block.
+// It returns false if the message was discarded, because the sender already has a message of the same type waiting
+// for the block, or because the limits of the waiting room have been reached.
+func (wr *WaitingRoom) Wait(hash Hash, sender hotstuff.ID, msg interface{}) bool {
+	switch msg.(type) {
+	case ProposeMsg, VoteMsg:
+	default:
+		return false
+	}
+
+	wr.mut.Lock()
+	defer wr.mut.Unlock()
+
+	if max := wr.mods.Options().MaxWaitingMessages(); max > 0 && wr.size >= max {
+		return false
+	}
+	_, isVote := msg.(VoteMsg)
+	if max := wr.mods.Options().MaxWaitingVotes(); isVote && max > 0 && wr.votes[sender] >= max {
+		return false
+	}
+	msgs, ok := wr.waiting[hash]
+	if !ok {
+		if max := wr.mods.Options().MaxPendingBlocks(); max > 0 {
+			for len(wr.waiting) >= max {
+				if !wr.evictOldest() {
+					break
+				}
+			}
+		}
+	}
+	wr.lru.touch(hash)
+	for _, m := range msgs {
+		if m.sender == sender && reflect.TypeOf(m.msg) == reflect.TypeOf(msg) {
+			return false
+		}
+	}
+	if max := wr.mods.Options().MaxPendingVotes(); max > 0 && len(msgs) >= max {
+		return false
+	}
+	wr.waiting[hash] = append(msgs, waitingMsg{sender: sender, msg: msg})
+	wr.size++
+	if isVote {
+		wr.votes[sender]++
+	}
+	return true
+}
+
+// Waiting returns true if any messages are waiting for the block with the given hash.
+func (wr *WaitingRoom) Waiting(hash Hash) bool {
+	wr.mut.Lock()
+	defer wr.mut.Unlock()
+	_, ok := wr.waiting[hash]
+	return ok
+}
+
+// Hashes returns the hashes of the blocks that messages are waiting for.
+func (wr *WaitingRoom) Hashes() []Hash {
+	wr.mut.Lock()
+	defer wr.mut.Unlock()
+	hashes := make([]Hash, 0, len(wr.waiting))
+	for hash := range wr.waiting {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+// Len returns the number of messages in the waiting room.
+func (wr *WaitingRoom) Len() int {
+	wr.mut.Lock()
+	defer wr.mut.Unlock()
+	return wr.size
+}
+
+// Release removes the messages that are waiting for the block with the given hash,
+// and adds them to the event loop in the order they arrived.
+// It is safe to call Release from the event loop.
+func (wr *WaitingRoom) Release(hash Hash) {
+	wr.mut.Lock()
+	msgs, ok := wr.waiting[hash]
+	wr.remove(hash)
+	wr.mut.Unlock()
+
+	if !ok {
+		return
+	}
+	wr.mods.Logger().Debugf("Releasing %d messages waiting for block: %.8s", len(msgs), hash)
+	// must use a goroutine to avoid blocking the event loop when the queue is full.
+	go func() {
+		for _, m := range msgs {
+			switch msg := m.msg.(type) {
+			case ProposeMsg:
+				msg.Deferred = true
+				wr.mods.EventLoop().AddEvent(msg)
+			case VoteMsg:
+				msg.Deferred = true
+				wr.mods.EventLoop().AddEvent(msg)
+			}
+		}
+	}()
+}
+
+// remove removes the messages that are waiting for the block with the given hash.
+// The caller must hold the lock.
+func (wr *WaitingRoom) remove(hash Hash) {
+	for _, m := range wr.waiting[hash] {
+		if _, ok := m.msg.(VoteMsg); !ok {
+			continue
+		}
+		if wr.votes[m.sender]--; wr.votes[m.sender] == 0 {
+			delete(wr.votes, m.sender)
+		}
+	}
+	wr.size -= len(wr.waiting[hash])
+	delete(wr.waiting, hash)
+	wr.lru.remove(hash)
+}
+
+// evictOldest discards the messages that are waiting for the block that was least recently waited for.
+// The caller must hold the lock.
+// It returns false if the waiting room is empty.
+func (wr *WaitingRoom) evictOldest() bool {
+	hash, ok := wr.lru.oldest()
+	if !ok {
+		return false
+	}
+	wr.mods.Logger().Debugf("Evicting %d messages waiting for block: %.8s", len(wr.waiting[hash]), hash)
+	wr.remove(hash)
+	return true
+}
+
+// count returns the number of messages in the waiting room with the same type as msgType.
+func (wr *WaitingRoom) count(msgType interface{}) int {
+	wr.mut.Lock()
+	defer wr.mut.Unlock()
+	t := reflect.TypeOf(msgType)
+	n := 0
+	for _, msgs := range wr.waiting {
+		for _, m := range msgs {
+			if reflect.TypeOf(m.msg) == t {
+				n++
+			}
+		}
+	}
+	return n
+}
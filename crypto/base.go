@@ -2,39 +2,60 @@
 package crypto
 
 import (
+	"sync"
+
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
 )
 
 type base struct {
 	consensus.CryptoImpl
+	mods *consensus.Modules
+
+	poolOnce sync.Once
+	pool     *verifierPool // nil if verifications run on the calling goroutine
 }
 
 // New returns a new base implementation of the Crypto interface. It will use the given CryptoImpl to create and verify
 // signatures.
 func New(impl consensus.CryptoImpl) consensus.Crypto {
-	return base{CryptoImpl: impl}
+	return &base{CryptoImpl: impl}
 }
 
 // InitConsensusModule gives the module a reference to the Modules object.
 // It also allows the module to set module options using the OptionsBuilder.
-func (base base) InitConsensusModule(mods *consensus.Modules, cfg *consensus.OptionsBuilder) {
+func (base *base) InitConsensusModule(mods *consensus.Modules, cfg *consensus.OptionsBuilder) {
+	base.mods = mods
 	if mod, ok := base.CryptoImpl.(consensus.Module); ok {
 		mod.InitConsensusModule(mods, cfg)
 	}
 }
 
+// verify runs the verification function in the verification pool, if the VerificationWorkers option is set.
+// Only the top-level Verify methods use the pool, since a verification running in the pool must not wait for another.
+func (base *base) verify(verify func() bool) bool {
+	base.poolOnce.Do(func() {
+		if workers := base.mods.Options().VerificationWorkers(); workers > 0 {
+			base.pool = newVerifierPool(workers)
+		}
+	})
+	if base.pool == nil {
+		return verify()
+	}
+	return <-base.pool.Go(verify)
+}
+
 // CreatePartialCert signs a single block and returns the partial certificate.
-func (base base) CreatePartialCert(block *consensus.Block) (cert consensus.PartialCert, err error) {
-	sig, err := base.Sign(block.Hash())
+func (base *base) CreatePartialCert(block *consensus.Block) (cert consensus.PartialCert, err error) {
+	sig, err := base.Sign(consensus.VoteDigest(block.View(), block.Hash()))
 	if err != nil {
 		return consensus.PartialCert{}, err
 	}
-	return consensus.NewPartialCert(sig, block.Hash()), nil
+	return consensus.NewPartialCert(sig, block.View(), block.Hash()), nil
 }
 
 // CreateQuorumCert creates a quorum certificate from a list of partial certificates.
-func (base base) CreateQuorumCert(block *consensus.Block, signatures []consensus.PartialCert) (cert consensus.QuorumCert, err error) {
+func (base *base) CreateQuorumCert(block *consensus.Block, signatures []consensus.PartialCert) (cert consensus.QuorumCert, err error) {
 	// genesis QC is always valid.
 	if block.Hash() == consensus.GetGenesis().Hash() {
 		return consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash()), nil
@@ -43,15 +64,21 @@ func (base base) CreateQuorumCert(block *consensus.Block, signatures []consensus
 	for _, sig := range signatures {
 		sigs = append(sigs, sig.Signature())
 	}
-	sig, err := base.CreateThresholdSignature(sigs, block.Hash())
+	sig, err := base.CreateThresholdSignature(sigs, consensus.VoteDigest(block.View(), block.Hash()))
 	if err != nil {
 		return consensus.QuorumCert{}, err
 	}
-	return consensus.NewQuorumCert(sig, block.View(), block.Hash()), nil
+	cert = consensus.NewQuorumCert(sig, block.View(), block.Hash())
+	if base.mods.Options().ShouldAuditQCs() && !base.VerifyQuorumCert(cert) {
+		base.mods.InvariantViolation("the QC created for block %.8s in view %d from %d partial certificates fails verification",
+			block.Hash(), block.View(), len(signatures))
+		return consensus.QuorumCert{}, ErrAuditFailed
+	}
+	return cert, nil
 }
 
 // CreateTimeoutCert creates a timeout certificate from a list of timeout messages.
-func (base base) CreateTimeoutCert(view consensus.View, timeouts []consensus.TimeoutMsg) (cert consensus.TimeoutCert, err error) {
+func (base *base) CreateTimeoutCert(view consensus.View, timeouts []consensus.TimeoutMsg) (cert consensus.TimeoutCert, err error) {
 	// view 0 is always valid.
 	if view == 0 {
 		return consensus.NewTimeoutCert(nil, 0), nil
@@ -67,7 +94,7 @@ func (base base) CreateTimeoutCert(view consensus.View, timeouts []consensus.Tim
 	return consensus.NewTimeoutCert(sig, view), nil
 }
 
-func (base base) CreateAggregateQC(view consensus.View, timeouts []consensus.TimeoutMsg) (aggQC consensus.AggregateQC, err error) {
+func (base *base) CreateAggregateQC(view consensus.View, timeouts []consensus.TimeoutMsg) (aggQC consensus.AggregateQC, err error) {
 	qcs := make(map[hotstuff.ID]consensus.QuorumCert)
 	sigs := make([]consensus.Signature, 0, len(timeouts))
 	hashes := make(map[hotstuff.ID]consensus.Hash)
@@ -88,28 +115,40 @@ func (base base) CreateAggregateQC(view consensus.View, timeouts []consensus.Tim
 }
 
 // VerifyPartialCert verifies a single partial certificate.
-func (base base) VerifyPartialCert(cert consensus.PartialCert) bool {
-	return base.Verify(cert.Signature(), cert.BlockHash())
+func (base *base) VerifyPartialCert(cert consensus.PartialCert) bool {
+	return base.verify(func() bool {
+		return base.Verify(cert.Signature(), consensus.VoteDigest(cert.View(), cert.BlockHash()))
+	})
 }
 
 // VerifyQuorumCert verifies a quorum certificate.
-func (base base) VerifyQuorumCert(qc consensus.QuorumCert) bool {
+func (base *base) VerifyQuorumCert(qc consensus.QuorumCert) bool {
+	return base.verify(func() bool { return base.verifyQuorumCert(qc) })
+}
+
+func (base *base) verifyQuorumCert(qc consensus.QuorumCert) bool {
 	if qc.BlockHash() == consensus.GetGenesis().Hash() {
 		return true
 	}
-	return base.VerifyThresholdSignature(qc.Signature(), qc.BlockHash())
+	if err := qc.SignerBitmap().CheckConfiguration(base.mods.Configuration()); err != nil {
+		base.mods.Logger().Infof("VerifyQuorumCert: %v", err)
+		return false
+	}
+	return base.VerifyThresholdSignature(qc.Signature(), consensus.VoteDigest(qc.View(), qc.BlockHash()))
 }
 
 // VerifyTimeoutCert verifies a timeout certificate.
-func (base base) VerifyTimeoutCert(tc consensus.TimeoutCert) bool {
+func (base *base) VerifyTimeoutCert(tc consensus.TimeoutCert) bool {
 	if tc.View() == 0 {
 		return true
 	}
-	return base.VerifyThresholdSignature(tc.Signature(), tc.View().ToHash())
+	return base.verify(func() bool {
+		return base.VerifyThresholdSignature(tc.Signature(), tc.View().ToHash())
+	})
 }
 
 // VerifyAggregateQC verifies the AggregateQC and returns the highQC, if valid.
-func (base base) VerifyAggregateQC(aggQC consensus.AggregateQC) (bool, consensus.QuorumCert) {
+func (base *base) VerifyAggregateQC(aggQC consensus.AggregateQC) (bool, consensus.QuorumCert) {
 	var highQC *consensus.QuorumCert
 	hashes := make(map[hotstuff.ID]consensus.Hash)
 	for id, qc := range aggQC.QCs() {
@@ -127,11 +166,10 @@ func (base base) VerifyAggregateQC(aggQC consensus.AggregateQC) (bool, consensus
 			SyncInfo: consensus.NewSyncInfo().WithQC(qc),
 		}.Hash()
 	}
-	ok := base.VerifyThresholdSignatureForMessageSet(aggQC.Sig(), hashes)
-	if !ok {
-		return false, consensus.QuorumCert{}
-	}
-	if base.VerifyQuorumCert(*highQC) {
+	ok := base.verify(func() bool {
+		return base.VerifyThresholdSignatureForMessageSet(aggQC.Sig(), hashes) && base.verifyQuorumCert(*highQC)
+	})
+	if ok {
 		return true, *highQC
 	}
 	return false, consensus.QuorumCert{}
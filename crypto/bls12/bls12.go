@@ -226,13 +226,20 @@ func (bc *bls12Crypto) VerifyThresholdSignature(signature consensus.ThresholdSig
 		return false
 	}
 	pubKeys := make([]*PublicKey, 0)
+	unknown := false
 	sig.participants.ForEach(func(id hotstuff.ID) {
 		replica, ok := bc.mods.Configuration().Replica(id)
 		if !ok {
+			unknown = true
 			return
 		}
 		pubKeys = append(pubKeys, replica.PublicKey().(*PublicKey))
 	})
+	if unknown {
+		// the participants must not include replicas that are not in the configuration, even if they did not sign.
+		bc.mods.Logger().Info("bls12Crypto: aggregate signature includes a replica that is not in the configuration")
+		return false
+	}
 	ps, err := bls12.NewG2().HashToCurve(hash[:], domain)
 	if err != nil {
 		bc.mods.Logger().Error(err)
@@ -1,7 +1,13 @@
 package crypto_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/relab/hotstuff"
@@ -168,9 +174,11 @@ func runAll(t *testing.T, run func(*testing.T, setupFunc)) {
 	t.Run("Cache+Ecdsa", func(t *testing.T) { run(t, setup(NewCache(ecdsa.New), testutil.GenerateECDSAKey)) })
 	t.Run("BLS12-381", func(t *testing.T) { run(t, setup(NewBase(bls12.New), testutil.GenerateBLS12Key)) })
 	t.Run("Cache+BLS12-381", func(t *testing.T) { run(t, setup(NewCache(bls12.New), testutil.GenerateBLS12Key)) })
+	t.Run("Pool+Ecdsa", func(t *testing.T) { run(t, setupPool(NewBase(ecdsa.New), testutil.GenerateECDSAKey, 1)) })
+	t.Run("Pool+BLS12-381", func(t *testing.T) { run(t, setupPool(NewBase(bls12.New), testutil.GenerateBLS12Key, 1)) })
 }
 
-func createBlock(t *testing.T, signer consensus.Crypto) *consensus.Block {
+func createBlock(t testing.TB, signer consensus.Crypto) *consensus.Block {
 	t.Helper()
 
 	qc, err := signer.CreateQuorumCert(consensus.GetGenesis(), []consensus.PartialCert{})
@@ -182,12 +190,19 @@ func createBlock(t *testing.T, signer consensus.Crypto) *consensus.Block {
 	return b
 }
 
-type keyFunc func(t *testing.T) consensus.PrivateKey
-type setupFunc func(*testing.T, *gomock.Controller, int) testData
+type keyFunc func(t testing.TB) consensus.PrivateKey
+type setupFunc func(testing.TB, *gomock.Controller, int) testData
 
 func setup(newFunc func() consensus.Crypto, keyFunc keyFunc) setupFunc {
-	return func(t *testing.T, ctrl *gomock.Controller, n int) testData {
-		return newTestData(t, ctrl, n, newFunc, keyFunc)
+	return func(t testing.TB, ctrl *gomock.Controller, n int) testData {
+		return newTestData(t, ctrl, n, newFunc, keyFunc, 0)
+	}
+}
+
+// setupPool is like setup, but runs verifications in a verification pool with the given number of workers.
+func setupPool(newFunc func() consensus.Crypto, keyFunc keyFunc, workers int) setupFunc {
+	return func(t testing.TB, ctrl *gomock.Controller, n int) testData {
+		return newTestData(t, ctrl, n, newFunc, keyFunc, workers)
 	}
 }
 
@@ -209,13 +224,14 @@ type testData struct {
 	block     *consensus.Block
 }
 
-func newTestData(t *testing.T, ctrl *gomock.Controller, n int, newFunc func() consensus.Crypto, keyFunc keyFunc) testData {
+func newTestData(t testing.TB, ctrl *gomock.Controller, n int, newFunc func() consensus.Crypto, keyFunc keyFunc, workers int) testData {
 	t.Helper()
 
 	bl := testutil.CreateBuilders(t, ctrl, n, testutil.GenerateKeys(t, n, keyFunc)...)
 	for _, builder := range bl {
 		signer := newFunc()
 		builder.Register(signer)
+		builder.Options().SetVerificationWorkers(workers)
 	}
 	hl := bl.Build()
 
@@ -225,3 +241,239 @@ func newTestData(t *testing.T, ctrl *gomock.Controller, n int, newFunc func() co
 		block:     createBlock(t, hl[0].Crypto()),
 	}
 }
+
+// TestVerificationPool checks that verifications in the verification pool give the same results as without it,
+// also when many verifications run concurrently.
+func TestVerificationPool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	td := setupPool(NewBase(ecdsa.New), testutil.GenerateECDSAKey, 2)(t, ctrl, 4)
+
+	qc := testutil.CreateQC(t, td.block, td.signers)
+	// the forged QC is signed by replicas that are not part of the configuration.
+	forgers := newTestData(t, ctrl, 4, NewBase(ecdsa.New), testutil.GenerateECDSAKey, 0).signers
+	forged := testutil.CreateQC(t, td.block, forgers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if !td.verifiers[0].VerifyQuorumCert(qc) {
+				t.Error("valid QC was not verified")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if td.verifiers[0].VerifyQuorumCert(forged) {
+				t.Error("forged QC was verified")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// staleAggregator is a broken CryptoImpl that returns the first threshold signature it created for every later QC.
+type staleAggregator struct {
+	consensus.CryptoImpl
+	mut   sync.Mutex
+	stale consensus.ThresholdSignature
+}
+
+func (a *staleAggregator) InitConsensusModule(mods *consensus.Modules, cfg *consensus.OptionsBuilder) {
+	a.CryptoImpl.(consensus.Module).InitConsensusModule(mods, cfg)
+}
+
+func (a *staleAggregator) CreateThresholdSignature(sigs []consensus.Signature, hash consensus.Hash) (consensus.ThresholdSignature, error) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	if a.stale != nil {
+		return a.stale, nil
+	}
+	sig, err := a.CryptoImpl.CreateThresholdSignature(sigs, hash)
+	a.stale = sig
+	return sig, err
+}
+
+// TestAuditQC checks that a QC that fails its own verification is caught when it is created, if the QCs are audited.
+func TestAuditQC(t *testing.T) {
+	run := func(t *testing.T, audit, strict bool) error {
+		ctrl := gomock.NewController(t)
+		bl := testutil.CreateBuilders(t, ctrl, 4)
+		for i, builder := range bl {
+			impl := ecdsa.New()
+			if i == 0 {
+				impl = &staleAggregator{CryptoImpl: impl}
+			}
+			builder.Register(crypto.New(impl))
+			if audit {
+				builder.Options().SetShouldAuditQCs()
+			}
+			if strict {
+				builder.Options().SetShouldUseStrictMode()
+			}
+		}
+		hl := bl.Build()
+		signers := hl.Signers()
+
+		first := createBlock(t, signers[0])
+		if _, err := signers[0].CreateQuorumCert(first, testutil.CreatePCs(t, first, signers)); err != nil {
+			t.Fatalf("Failed to create the first QC: %v", err)
+		}
+		second := consensus.NewBlock(first.Hash(), first.QuorumCert(), "bar", 43, 1)
+		qc, err := signers[0].CreateQuorumCert(second, testutil.CreatePCs(t, second, signers))
+		if err == nil && hl.Verifiers()[1].VerifyQuorumCert(qc) {
+			t.Fatal("expected the QC created by the broken aggregator to be invalid")
+		}
+		return err
+	}
+
+	t.Run("WithoutAudit", func(t *testing.T) {
+		if err := run(t, false, false); err != nil {
+			t.Errorf("expected the invalid QC to be created, got: %v", err)
+		}
+	})
+	t.Run("Audit", func(t *testing.T) {
+		if err := run(t, true, false); !errors.Is(err, crypto.ErrAuditFailed) {
+			t.Errorf("expected ErrAuditFailed, got: %v", err)
+		}
+	})
+	t.Run("AuditStrict", func(t *testing.T) {
+		defer func() {
+			if msg := fmt.Sprint(recover()); !strings.Contains(msg, "fails verification") {
+				t.Errorf("expected a panic when the QC is created, got: %q", msg)
+			}
+		}()
+		run(t, true, true)
+	})
+}
+
+// BenchmarkVerificationBurst measures how long the event loop takes to handle an event
+// while a burst of QC verifications is running.
+func BenchmarkVerificationBurst(b *testing.B) {
+	const burst = 256
+	run := func(b *testing.B, workers int) {
+		ctrl := gomock.NewController(b)
+		td := setupPool(NewBase(ecdsa.New), testutil.GenerateECDSAKey, workers)(b, ctrl, 4)
+		qc := testutil.CreateQC(b, td.block, td.signers)
+		verifier := td.verifiers[0]
+
+		bl := testutil.CreateBuilders(b, ctrl, 1)
+		eventLoop := bl.Build()[0].EventLoop()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go eventLoop.Run(ctx)
+
+		var total time.Duration
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var wg sync.WaitGroup
+			for j := 0; j < burst; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					verifier.VerifyQuorumCert(qc)
+				}()
+			}
+			start := time.Now()
+			done := make(chan struct{})
+			eventLoop.AddEvent(func() { close(done) })
+			<-done
+			total += time.Since(start)
+			wg.Wait()
+		}
+		b.ReportMetric(float64(total.Microseconds())/float64(b.N), "event-µs/op")
+	}
+	b.Run("WithoutPool", func(b *testing.B) { run(b, 0) })
+	b.Run("WithPool", func(b *testing.B) { run(b, 1) })
+}
+
+// BenchmarkProposalSigning measures the signing cost per proposal of a leader,
+// for each signature scheme, when signing every proposal and when signing the run digest of every eight proposals.
+func BenchmarkProposalSigning(b *testing.B) {
+	run := func(b *testing.B, newFunc func() consensus.Crypto, keyFunc keyFunc, interval int) {
+		ctrl := gomock.NewController(b)
+		td := setup(newFunc, keyFunc)(b, ctrl, 4)
+		signer := td.signers[0]
+
+		var run []consensus.Hash
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			block := consensus.NewBlock(td.block.Hash(), td.block.QuorumCert(), "foo", consensus.View(i+1), 1)
+			run = append(run, block.Hash())
+			if len(run) < interval {
+				continue
+			}
+			if _, err := signer.Sign(consensus.ProposerRunDigest(run)); err != nil {
+				b.Fatal(err)
+			}
+			run = run[:0]
+		}
+	}
+	for _, interval := range []int{1, 8} {
+		b.Run(fmt.Sprintf("Ecdsa/Interval=%d", interval), func(b *testing.B) {
+			run(b, NewBase(ecdsa.New), testutil.GenerateECDSAKey, interval)
+		})
+		b.Run(fmt.Sprintf("BLS12-381/Interval=%d", interval), func(b *testing.B) {
+			run(b, NewBase(bls12.New), testutil.GenerateBLS12Key, interval)
+		})
+	}
+}
+
+// BenchmarkQuorumCertVerification measures the cost of verifying a QC signed by a quorum of replicas,
+// for each signature scheme and configuration size. An ECDSA QC contains one signature per signer,
+// whereas a BLS12-381 QC contains a single aggregate signature.
+func BenchmarkQuorumCertVerification(b *testing.B) {
+	run := func(b *testing.B, newFunc func() consensus.Crypto, keyFunc keyFunc, n int) {
+		ctrl := gomock.NewController(b)
+		td := setup(newFunc, keyFunc)(b, ctrl, n)
+		qc := testutil.CreateQC(b, td.block, td.signers[:n-(n-1)/3])
+		verifier := td.verifiers[0]
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if !verifier.VerifyQuorumCert(qc) {
+				b.Fatal("the QC could not be verified")
+			}
+		}
+	}
+	for _, n := range []int{4, 16, 64} {
+		b.Run(fmt.Sprintf("Ecdsa/n=%d", n), func(b *testing.B) {
+			run(b, NewBase(ecdsa.New), testutil.GenerateECDSAKey, n)
+		})
+		b.Run(fmt.Sprintf("BLS12-381/n=%d", n), func(b *testing.B) {
+			run(b, NewBase(bls12.New), testutil.GenerateBLS12Key, n)
+		})
+	}
+}
+
+// BenchmarkVoteVerification measures the time it takes to verify a burst of votes that arrive at the same time,
+// when the verifications run on a single worker and on eight workers.
+func BenchmarkVoteVerification(b *testing.B) {
+	const burst = 64
+	run := func(b *testing.B, workers int) {
+		ctrl := gomock.NewController(b)
+		td := setupPool(NewBase(ecdsa.New), testutil.GenerateECDSAKey, workers)(b, ctrl, 4)
+		pcs := testutil.CreatePCs(b, td.block, td.signers)
+		verifier := td.verifiers[0]
+
+		b.ResetTimer()
+		start := time.Now()
+		for i := 0; i < b.N; i++ {
+			var wg sync.WaitGroup
+			for j := 0; j < burst; j++ {
+				wg.Add(1)
+				// the voting machine verifies each vote on its own goroutine.
+				go func(pc consensus.PartialCert) {
+					defer wg.Done()
+					if !verifier.VerifyPartialCert(pc) {
+						b.Error("the vote could not be verified")
+					}
+				}(pcs[j%len(pcs)])
+			}
+			wg.Wait()
+		}
+		b.ReportMetric(float64(burst*b.N)/time.Since(start).Seconds(), "votes/s")
+	}
+	b.Run("Workers=1", func(b *testing.B) { run(b, 1) })
+	b.Run("Workers=8", func(b *testing.B) { run(b, 8) })
+}
@@ -15,6 +15,9 @@ var (
 	// ErrNotAQuorum is the error used when a quorum is not reached.
 	ErrNotAQuorum = fmt.Errorf("not a quorum")
 
+	// ErrAuditFailed is the error used when a newly created certificate fails its own verification.
+	ErrAuditFailed = fmt.Errorf("created certificate failed verification")
+
 	// ErrWrongType is the error used when an incompatible type is encountered.
 	ErrWrongType = fmt.Errorf("incompatible type")
 )
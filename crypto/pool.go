@@ -0,0 +1,37 @@
+package crypto
+
+// verifierPool runs signature verifications on a fixed number of long-lived workers,
+// such that a burst of verifications cannot starve the other goroutines of the replica, such as the event loop.
+// The workers run for the lifetime of the replica.
+type verifierPool struct {
+	tasks chan verification
+}
+
+// verification is a verification function, and the channel that receives its result.
+type verification struct {
+	verify func() bool
+	result chan<- bool
+}
+
+func newVerifierPool(workers int) *verifierPool {
+	pool := &verifierPool{tasks: make(chan verification)}
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+// work runs the verifications that are submitted to the pool.
+func (pool *verifierPool) work() {
+	for task := range pool.tasks {
+		task.result <- task.verify()
+	}
+}
+
+// Go hands the verification function to a worker once one is available,
+// and returns a channel that receives the result of the verification.
+func (pool *verifierPool) Go(verify func() bool) <-chan bool {
+	result := make(chan bool, 1)
+	pool.tasks <- verification{verify: verify, result: result}
+	return result
+}
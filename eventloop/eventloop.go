@@ -26,7 +26,7 @@ type EventLoop struct {
 	mut sync.Mutex
 
 	eventQ        chan interface{}
-	waitingEvents map[interface{}][]interface{}
+	waitingEvents map[reflect.Type][]interface{}
 
 	handlers  map[reflect.Type]EventHandler
 	observers map[reflect.Type][]EventHandler
@@ -39,7 +39,7 @@ type EventLoop struct {
 func New(bufferSize uint) *EventLoop {
 	el := &EventLoop{
 		eventQ:        make(chan interface{}, bufferSize),
-		waitingEvents: make(map[interface{}][]interface{}),
+		waitingEvents: make(map[reflect.Type][]interface{}),
 		handlers:      make(map[reflect.Type]EventHandler),
 		observers:     make(map[reflect.Type][]EventHandler),
 		tickers:       make(map[int]*ticker),
@@ -69,6 +69,18 @@ func (el *EventLoop) AddEvent(event interface{}) {
 	el.eventQ <- event
 }
 
+// TryAddEvent adds an event to the event queue without blocking.
+// It returns false if the event queue is full, in which case the event is dropped.
+// Unlike AddEvent, TryAddEvent is safe to call from the event loop goroutine.
+func (el *EventLoop) TryAddEvent(event interface{}) bool {
+	select {
+	case el.eventQ <- event:
+		return true
+	default:
+		return false
+	}
+}
+
 // Run runs the event loop. A context object can be provided to stop the event loop.
 func (el *EventLoop) Run(ctx context.Context) {
 	for {
@@ -131,10 +143,11 @@ func (el *EventLoop) dispatchDelayedEvents(t reflect.Type) {
 // The eventType parameter decides the type of event to wait for, and it should be the zero value
 // of that event type. The event parameter is the event that will be delayed.
 func (el *EventLoop) DelayUntil(eventType, event interface{}) {
+	t := reflect.TypeOf(eventType)
 	el.mut.Lock()
-	v := el.waitingEvents[eventType]
+	v := el.waitingEvents[t]
 	v = append(v, event)
-	el.waitingEvents[eventType] = v
+	el.waitingEvents[t] = v
 	el.mut.Unlock()
 }
 
@@ -76,3 +76,53 @@ func TestTicker(t *testing.T) {
 		t.Fatal("ticker was not stopped")
 	}
 }
+
+type sliceEvent struct {
+	values []int
+}
+
+func TestDelayUntil(t *testing.T) {
+	el := eventloop.New(10)
+	c := make(chan interface{})
+	el.RegisterHandler(testEvent(0), func(event interface{}) {
+		c <- event
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go el.Run(ctx)
+
+	want := testEvent(42)
+	el.DelayUntil(sliceEvent{}, want)
+	el.AddEvent(sliceEvent{values: []int{1}})
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	case event := <-c:
+		if event != want {
+			t.Fatalf("wrong value for event: got: %v, want: %v", event, want)
+		}
+	}
+}
+
+func TestTryAddEvent(t *testing.T) {
+	el := eventloop.New(1)
+	if !el.TryAddEvent(testEvent(1)) {
+		t.Fatal("expected the first event to be added")
+	}
+	if el.TryAddEvent(testEvent(2)) {
+		t.Fatal("expected the second event to be dropped when the queue is full")
+	}
+
+	c := make(chan testEvent, 2)
+	el.RegisterHandler(testEvent(0), func(event interface{}) {
+		c <- event.(testEvent)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	el.Run(ctx)
+
+	if len(c) != 1 || <-c != testEvent(1) {
+		t.Fatal("expected only the first event to be handled")
+	}
+}
@@ -10,6 +10,7 @@ require (
 	github.com/mattn/go-isatty v0.0.12
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/mroth/weightedrand v0.4.1
+	github.com/nats-io/nats.go v1.11.0
 	github.com/relab/gorums v0.5.1-0.20210629194217-9811e4f219ca
 	github.com/relab/iago v0.0.0-20210721102751-67ef5c5ec2b0
 	github.com/relab/wrfs v0.0.0-20210628111300-b51570396aec
@@ -75,6 +75,8 @@ func init() {
 	runCmd.Flags().Float64("rate-limit", math.Inf(1), "rate limit for clients (in commands/second)")
 	runCmd.Flags().Float64("rate-step", 0, "rate limit step up for clients (in commands/second)")
 	runCmd.Flags().Duration("rate-step-interval", time.Hour, "how often the client rate limit should be increased")
+	runCmd.Flags().String("state-dir", "", "the directory that the replicas store their protocol state in (disabled by default)")
+	runCmd.Flags().Bool("debug", false, "serve debug endpoints, such as the block chain of each replica, over HTTP")
 	runCmd.Flags().StringSlice("byzantine", nil, "byzantine strategies to use, as a comma separated list of 'name:count'")
 
 	err := viper.BindPFlags(runCmd.Flags())
@@ -108,6 +110,8 @@ func runController() {
 			InitialTimeout:    durationpb.New(viper.GetDuration("view-timeout")),
 			TimeoutSamples:    viper.GetUint32("duration-samples"),
 			MaxTimeout:        durationpb.New(viper.GetDuration("max-timeout")),
+			Debug:             viper.GetBool("debug"),
+			StateDir:          viper.GetString("state-dir"),
 		},
 		ClientOpts: &orchestrationpb.ClientOpts{
 			UseTLS:           true,
@@ -37,12 +37,13 @@ func (m *MockConfiguration) EXPECT() *MockConfigurationMockRecorder {
 }
 
 // Fetch mocks base method.
-func (m *MockConfiguration) Fetch(arg0 context.Context, arg1 consensus.Hash) (*consensus.Block, bool) {
+func (m *MockConfiguration) Fetch(arg0 context.Context, arg1 consensus.Hash) (*consensus.Block, consensus.InclusionProof, bool) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Fetch", arg0, arg1)
 	ret0, _ := ret[0].(*consensus.Block)
-	ret1, _ := ret[1].(bool)
-	return ret0, ret1
+	ret1, _ := ret[1].(consensus.InclusionProof)
+	ret2, _ := ret[2].(bool)
+	return ret0, ret1, ret2
 }
 
 // Fetch indicates an expected call of Fetch.
@@ -51,6 +52,21 @@ func (mr *MockConfigurationMockRecorder) Fetch(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fetch", reflect.TypeOf((*MockConfiguration)(nil).Fetch), arg0, arg1)
 }
 
+// FetchPayload mocks base method.
+func (m *MockConfiguration) FetchPayload(arg0 context.Context, arg1 consensus.Hash) (consensus.Command, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchPayload", arg0, arg1)
+	ret0, _ := ret[0].(consensus.Command)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// FetchPayload indicates an expected call of FetchPayload.
+func (mr *MockConfigurationMockRecorder) FetchPayload(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchPayload", reflect.TypeOf((*MockConfiguration)(nil).FetchPayload), arg0, arg1)
+}
+
 // Len mocks base method.
 func (m *MockConfiguration) Len() int {
 	m.ctrl.T.Helper()
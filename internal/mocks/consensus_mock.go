@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,6 +35,35 @@ func (m *MockConsensus) EXPECT() *MockConsensusMockRecorder {
 	return m.recorder
 }
 
+// CommitCert mocks base method.
+func (m *MockConsensus) CommitCert() (consensus.CommitCert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitCert")
+	ret0, _ := ret[0].(consensus.CommitCert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CommitCert indicates an expected call of CommitCert.
+func (mr *MockConsensusMockRecorder) CommitCert() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitCert", reflect.TypeOf((*MockConsensus)(nil).CommitCert))
+}
+
+// CommitProven mocks base method.
+func (m *MockConsensus) CommitProven(arg0 context.Context, arg1 consensus.QuorumCert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CommitProven", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CommitProven indicates an expected call of CommitProven.
+func (mr *MockConsensusMockRecorder) CommitProven(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitProven", reflect.TypeOf((*MockConsensus)(nil).CommitProven), arg0, arg1)
+}
+
 // CommittedBlock mocks base method.
 func (m *MockConsensus) CommittedBlock() *consensus.Block {
 	m.ctrl.T.Helper()
@@ -48,6 +78,62 @@ func (mr *MockConsensusMockRecorder) CommittedBlock() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommittedBlock", reflect.TypeOf((*MockConsensus)(nil).CommittedBlock))
 }
 
+// DryRunPropose mocks base method.
+func (m *MockConsensus) DryRunPropose(arg0 consensus.SyncInfo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DryRunPropose", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DryRunPropose indicates an expected call of DryRunPropose.
+func (mr *MockConsensusMockRecorder) DryRunPropose(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DryRunPropose", reflect.TypeOf((*MockConsensus)(nil).DryRunPropose), arg0)
+}
+
+// ExecError mocks base method.
+func (m *MockConsensus) ExecError(arg0 consensus.Hash) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecError", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExecError indicates an expected call of ExecError.
+func (mr *MockConsensusMockRecorder) ExecError(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecError", reflect.TypeOf((*MockConsensus)(nil).ExecError), arg0)
+}
+
+// ForceCommit mocks base method.
+func (m *MockConsensus) ForceCommit(arg0 context.Context, arg1 consensus.QuorumCert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceCommit", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceCommit indicates an expected call of ForceCommit.
+func (mr *MockConsensusMockRecorder) ForceCommit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceCommit", reflect.TypeOf((*MockConsensus)(nil).ForceCommit), arg0, arg1)
+}
+
+// LockedBlock mocks base method.
+func (m *MockConsensus) LockedBlock() *consensus.Block {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockedBlock")
+	ret0, _ := ret[0].(*consensus.Block)
+	return ret0
+}
+
+// LockedBlock indicates an expected call of LockedBlock.
+func (mr *MockConsensusMockRecorder) LockedBlock() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockedBlock", reflect.TypeOf((*MockConsensus)(nil).LockedBlock))
+}
+
 // Propose mocks base method.
 func (m *MockConsensus) Propose(arg0 consensus.SyncInfo) {
 	m.ctrl.T.Helper()
@@ -36,6 +36,20 @@ func (m *MockReplica) EXPECT() *MockReplicaMockRecorder {
 	return m.recorder
 }
 
+// GetRep mocks base method.
+func (m *MockReplica) GetRep() float64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRep")
+	ret0, _ := ret[0].(float64)
+	return ret0
+}
+
+// GetRep indicates an expected call of GetRep.
+func (mr *MockReplicaMockRecorder) GetRep() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRep", reflect.TypeOf((*MockReplica)(nil).GetRep))
+}
+
 // ID mocks base method.
 func (m *MockReplica) ID() hotstuff.ID {
 	m.ctrl.T.Helper()
@@ -76,6 +90,18 @@ func (mr *MockReplicaMockRecorder) PublicKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublicKey", reflect.TypeOf((*MockReplica)(nil).PublicKey))
 }
 
+// UpdateRep mocks base method.
+func (m *MockReplica) UpdateRep(arg0 float64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateRep", arg0)
+}
+
+// UpdateRep indicates an expected call of UpdateRep.
+func (mr *MockReplicaMockRecorder) UpdateRep(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRep", reflect.TypeOf((*MockReplica)(nil).UpdateRep), arg0)
+}
+
 // Vote mocks base method.
 func (m *MockReplica) Vote(arg0 consensus.PartialCert) {
 	m.ctrl.T.Helper()
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"strconv"
 	"time"
 
 	"github.com/relab/hotstuff"
@@ -130,6 +131,9 @@ func (e *Experiment) createReplicas() (cfg *orchestrationpb.ReplicaConfiguration
 		for id, replicaCfg := range wcfg.GetReplicas() {
 			replicaCfg.Address = host
 			cfg.Replicas[id] = replicaCfg
+			if port := replicaCfg.GetDebugPort(); port != 0 {
+				log.Printf("replica %d serves its block chain at http://%s/debug/blockchain.dot", id, net.JoinHostPort(host, strconv.Itoa(int(port))))
+			}
 		}
 	}
 
@@ -36,6 +36,8 @@ func TestOrchestration(t *testing.T) {
 				Consensus:         consensusImpl,
 				Crypto:            crypto,
 				LeaderRotation:    "car",
+				Debug:             true,
+				StateDir:          t.TempDir(),
 			},
 			Duration: 1 * time.Second,
 			Hosts:    map[string]orchestration.RemoteWorker{"127.0.0.1": workerProxy},
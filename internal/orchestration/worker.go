@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/relab/hotstuff/client"
 	"github.com/relab/hotstuff/config"
 	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/consensus/basichotstuff"
 	"github.com/relab/hotstuff/consensus/byzantine"
 	"github.com/relab/hotstuff/consensus/chainedhotstuff"
 	"github.com/relab/hotstuff/consensus/fasthotstuff"
@@ -129,11 +132,25 @@ func (w *Worker) createReplicas(req *orchestrationpb.CreateReplicaRequest) (*orc
 		r.StartServers(replicaListener, clientListener)
 		w.replicas[hotstuff.ID(cfg.GetID())] = r
 
+		var debugPort uint32
+		if cfg.GetDebug() {
+			debugListener, err := net.Listen("tcp", ":0")
+			if err != nil {
+				return nil, fmt.Errorf("failed to create listener: %w", err)
+			}
+			debugPort, err = getPort(debugListener)
+			if err != nil {
+				return nil, err
+			}
+			r.StartDebugServer(debugListener)
+		}
+
 		resp.Replicas[cfg.GetID()] = &orchestrationpb.ReplicaInfo{
 			ID:          cfg.GetID(),
 			PublicKey:   cfg.GetPublicKey(),
 			ReplicaPort: replicaPort,
 			ClientPort:  clientPort,
+			DebugPort:   debugPort,
 		}
 	}
 	return resp, nil
@@ -162,6 +179,8 @@ func (w *Worker) createReplica(opts *orchestrationpb.ReplicaOpts) (*replica.Repl
 
 	var consensusRules consensus.Rules
 	switch opts.GetConsensus() {
+	case "basichotstuff":
+		consensusRules = basichotstuff.New()
 	case "chainedhotstuff":
 		consensusRules = chainedhotstuff.New()
 	case "fasthotstuff":
@@ -201,6 +220,8 @@ func (w *Worker) createReplica(opts *orchestrationpb.ReplicaOpts) (*replica.Repl
 		leaderRotation = leaderrotation.NewFixed(1)
 	case "rep":
 		leaderRotation = leaderrotation.NewRepBased()
+	case "commit-rep":
+		leaderRotation = leaderrotation.NewCommitReputation(leaderrotation.ReputationConfig{Penalty: 50, Reward: 10})
 	case "car":
 		fmt.Println(" --------------- CAR _-----------------------")
 		leaderRotation = leaderrotation.NewCarousel()
@@ -230,6 +251,16 @@ func (w *Worker) createReplica(opts *orchestrationpb.ReplicaOpts) (*replica.Repl
 		builder.Register(metrics.NewTicker(w.measurementInterval))
 	}
 
+	var stateDir string
+	if dir := opts.GetStateDir(); dir != "" {
+		// each replica has its own subdirectory, since several replicas may run on the same worker.
+		stateDir = filepath.Join(dir, strconv.Itoa(int(opts.GetID())))
+		err = os.MkdirAll(stateDir, 0755)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create state directory: %w", err)
+		}
+	}
+
 	c := replica.Config{
 		ID:          hotstuff.ID(opts.GetID()),
 		PrivateKey:  privKey,
@@ -237,6 +268,7 @@ func (w *Worker) createReplica(opts *orchestrationpb.ReplicaOpts) (*replica.Repl
 		Certificate: &certificate,
 		RootCAs:     rootCAs,
 		BatchSize:   opts.GetBatchSize(),
+		StateDir:    stateDir,
 		ManagerOptions: []gorums.ManagerOption{
 			gorums.WithDialTimeout(opts.GetConnectTimeout().AsDuration()),
 			gorums.WithGrpcDialOptions(grpc.WithReturnConnectionError()),
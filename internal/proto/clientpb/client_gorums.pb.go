@@ -149,11 +149,41 @@ type QuorumSpec interface {
 	// be used by the quorum function. If the in parameter is not needed
 	// you should implement your quorum function with '_ *Command'.
 	ExecCommandQF(in *Command, replies map[uint32]*emptypb.Empty) (*emptypb.Empty, bool)
+
+	// GetReceiptQF is the quorum function for the GetReceipt
+	// quorum call method. The in parameter is the request object
+	// supplied to the GetReceipt method at call time, and may or may not
+	// be used by the quorum function. If the in parameter is not needed
+	// you should implement your quorum function with '_ *CommandID'.
+	GetReceiptQF(in *CommandID, replies map[uint32]*Receipt) (*Receipts, bool)
+}
+
+// GetReceipt requests signed receipts for a committed command from the replicas.
+// The replicas only sign receipts if they are configured to, and only keep them for the result retention window.
+func (c *Configuration) GetReceipt(ctx context.Context, in *CommandID) (resp *Receipts, err error) {
+	cd := gorums.QuorumCallData{
+		Message: in,
+		Method:  "clientpb.Client.GetReceipt",
+	}
+	cd.QuorumFunction = func(req protoreflect.ProtoMessage, replies map[uint32]protoreflect.ProtoMessage) (protoreflect.ProtoMessage, bool) {
+		r := make(map[uint32]*Receipt, len(replies))
+		for k, v := range replies {
+			r[k] = v.(*Receipt)
+		}
+		return c.qspec.GetReceiptQF(req.(*CommandID), r)
+	}
+
+	res, err := c.Configuration.QuorumCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Receipts), err
 }
 
 // Client is the server-side API for the Client Service
 type Client interface {
 	ExecCommand(ctx gorums.ServerCtx, request *Command) (response *emptypb.Empty, err error)
+	GetReceipt(ctx gorums.ServerCtx, request *CommandID) (response *Receipt, err error)
 }
 
 func RegisterClientServer(srv *gorums.Server, impl Client) {
@@ -163,6 +193,12 @@ func RegisterClientServer(srv *gorums.Server, impl Client) {
 		resp, err := impl.ExecCommand(ctx, req)
 		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
 	})
+	srv.RegisterHandler("clientpb.Client.GetReceipt", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*CommandID)
+		defer ctx.Release()
+		resp, err := impl.GetReceipt(ctx, req)
+		gorums.SendMessage(ctx, finished, gorums.WrapMessage(in.Metadata, resp, err))
+	})
 }
 
 type internalEmpty struct {
@@ -171,6 +207,12 @@ type internalEmpty struct {
 	err   error
 }
 
+type internalReceipt struct {
+	nid   uint32
+	reply *Receipt
+	err   error
+}
+
 // AsyncEmpty is a async object for processing replies.
 type AsyncEmpty struct {
 	*gorums.Async
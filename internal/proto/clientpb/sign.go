@@ -0,0 +1,44 @@
+package clientpb
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// digest returns the hash of the command without its signature.
+func digest(cmd *Command) ([]byte, error) {
+	unsigned := proto.Clone(cmd).(*Command)
+	unsigned.Signature = nil
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(b)
+	return h[:], nil
+}
+
+// SignCommand signs the command with the client's private key, and stores the signature in the command.
+func SignCommand(cmd *Command, key *ecdsa.PrivateKey) error {
+	h, err := digest(cmd)
+	if err != nil {
+		return err
+	}
+	sig, err := ecdsa.SignASN1(rand.Reader, key, h)
+	if err != nil {
+		return err
+	}
+	cmd.Signature = sig
+	return nil
+}
+
+// VerifyCommand returns true if the command carries a valid signature from the given public key.
+func VerifyCommand(cmd *Command, key *ecdsa.PublicKey) bool {
+	h, err := digest(cmd)
+	if err != nil {
+		return false
+	}
+	return ecdsa.VerifyASN1(key, h, cmd.GetSignature())
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/crypto"
 	"github.com/relab/hotstuff/crypto/bls12"
 	"github.com/relab/hotstuff/crypto/ecdsa"
 )
@@ -66,7 +67,7 @@ func ThresholdSignatureToProto(sig consensus.ThresholdSignature) *ThresholdSigna
 	case *bls12.AggregateSignature:
 		signature.AggSig = &ThresholdSignature_BLS12Sig{BLS12Sig: &BLS12AggregateSignature{
 			Sig:          s.ToBytes(),
-			Participants: s.Bitfield(),
+			Participants: consensus.NewSignerBitmap(s.Participants()),
 		}}
 	}
 	return signature
@@ -86,7 +87,12 @@ func ThresholdSignatureFromProto(sig *ThresholdSignature) consensus.ThresholdSig
 		return ecdsa.RestoreThresholdSignature(sigs)
 	}
 	if signature := sig.GetBLS12Sig(); signature != nil {
-		aggSig, err := bls12.RestoreAggregateSignature(signature.GetSig(), signature.GetParticipants())
+		participants, err := consensus.ParseSignerBitmap(signature.GetParticipants())
+		if err != nil {
+			return nil
+		}
+		// the bitfield uses the same encoding as the signer bitmap.
+		aggSig, err := bls12.RestoreAggregateSignature(signature.GetSig(), crypto.Bitfield(participants))
 		if err != nil {
 			return nil
 		}
@@ -100,6 +106,7 @@ func PartialCertToProto(cert consensus.PartialCert) *PartialCert {
 	hash := cert.BlockHash()
 	return &PartialCert{
 		Sig:  SignatureToProto(cert.Signature()),
+		View: uint64(cert.View()),
 		Hash: hash[:],
 	}
 }
@@ -108,7 +115,43 @@ func PartialCertToProto(cert consensus.PartialCert) *PartialCert {
 func PartialCertFromProto(cert *PartialCert) consensus.PartialCert {
 	var h consensus.Hash
 	copy(h[:], cert.GetHash())
-	return consensus.NewPartialCert(SignatureFromProto(cert.GetSig()), h)
+	return consensus.NewPartialCert(SignatureFromProto(cert.GetSig()), consensus.View(cert.GetView()), h)
+}
+
+// CommitCertToProto converts a consensus.CommitCert to a hotstuffpb.CommitCert.
+func CommitCertToProto(cert consensus.CommitCert) *CommitCert {
+	hash := cert.BlockHash()
+	return &CommitCert{
+		ID:   uint32(cert.ID()),
+		View: uint64(cert.View()),
+		Hash: hash[:],
+		Sig:  SignatureToProto(cert.Signature()),
+	}
+}
+
+// CommitCertFromProto converts a hotstuffpb.CommitCert to a consensus.CommitCert.
+func CommitCertFromProto(cert *CommitCert) consensus.CommitCert {
+	var h consensus.Hash
+	copy(h[:], cert.GetHash())
+	return consensus.NewCommitCert(hotstuff.ID(cert.GetID()), consensus.View(cert.GetView()), h, SignatureFromProto(cert.GetSig()))
+}
+
+// FinalityCertToProto converts a consensus.FinalityCert to a hotstuffpb.FinalityCert.
+func FinalityCertToProto(fc consensus.FinalityCert) *FinalityCert {
+	certs := make([]*CommitCert, 0, len(fc.CommitCerts()))
+	for _, cert := range fc.CommitCerts() {
+		certs = append(certs, CommitCertToProto(cert))
+	}
+	return &FinalityCert{CommitCerts: certs}
+}
+
+// FinalityCertFromProto converts a hotstuffpb.FinalityCert to a consensus.FinalityCert.
+func FinalityCertFromProto(fc *FinalityCert) consensus.FinalityCert {
+	certs := make([]consensus.CommitCert, 0, len(fc.GetCommitCerts()))
+	for _, cert := range fc.GetCommitCerts() {
+		certs = append(certs, CommitCertFromProto(cert))
+	}
+	return consensus.NewFinalityCert(certs)
 }
 
 // QuorumCertToProto converts a consensus.QuorumCert to a hotstuffpb.QuorumCert.
@@ -136,6 +179,22 @@ func ProposalToProto(proposal consensus.ProposeMsg) *Proposal {
 	if proposal.AggregateQC != nil {
 		p.AggQC = AggregateQCToProto(*proposal.AggregateQC)
 	}
+	for _, id := range proposal.Signers {
+		p.Signers = append(p.Signers, uint32(id))
+	}
+	if proposal.ProposerSig != nil {
+		p.ProposerSig = SignatureToProto(proposal.ProposerSig)
+	}
+	p.ProposerRun = hashesToProto(proposal.ProposerRun)
+	if proposal.FinalityCert != nil {
+		p.FinalityCert = FinalityCertToProto(*proposal.FinalityCert)
+	}
+	if proposal.ViewChange != nil {
+		p.ViewChange = &ViewChange{
+			TC:     TimeoutCertToProto(proposal.ViewChange.TC),
+			HighQC: QuorumCertToProto(proposal.ViewChange.HighQC),
+		}
+	}
 	return p
 }
 
@@ -146,32 +205,132 @@ func ProposalFromProto(p *Proposal) (proposal consensus.ProposeMsg) {
 		aggQC := AggregateQCFromProto(p.GetAggQC())
 		proposal.AggregateQC = &aggQC
 	}
+	for _, id := range p.GetSigners() {
+		proposal.Signers = append(proposal.Signers, hotstuff.ID(id))
+	}
+	if p.GetProposerSig() != nil {
+		proposal.ProposerSig = SignatureFromProto(p.GetProposerSig())
+	}
+	proposal.ProposerRun = hashesFromProto(p.GetProposerRun())
+	if p.GetFinalityCert() != nil {
+		fc := FinalityCertFromProto(p.GetFinalityCert())
+		proposal.FinalityCert = &fc
+	}
+	if vc := p.GetViewChange(); vc != nil {
+		proposal.ViewChange = &consensus.ViewChangeBundle{
+			TC:     TimeoutCertFromProto(vc.GetTC()),
+			HighQC: QuorumCertFromProto(vc.GetHighQC()),
+		}
+	}
 	return
 }
 
 // BlockToProto converts a consensus.Block to a hotstuffpb.Block.
+// If the block refers to its command, only the reference is included, even if the payload has been resolved.
 func BlockToProto(block *consensus.Block) *Block {
 	parentHash := block.Parent()
-	return &Block{
+	pb := &Block{
 		Parent:   parentHash[:],
-		Command:  []byte(block.Command()),
 		QC:       QuorumCertToProto(block.QuorumCert()),
 		View:     uint64(block.View()),
 		Proposer: uint32(block.Proposer()),
 	}
+	if ref, ok := block.PayloadRef(); ok {
+		pb.PayloadRef = ref[:]
+	} else {
+		pb.Command = []byte(block.Command())
+	}
+	if value, ok := block.Beacon(); ok {
+		pb.BeaconRound = value.Round
+		pb.BeaconValue = value.Value
+	}
+	if root, ok := block.CommandRoot(); ok {
+		pb.CommandRoot = root[:]
+	}
+	return pb
 }
 
 // BlockFromProto converts a hotstuffpb.Block to a consensus.Block.
 func BlockFromProto(block *Block) *consensus.Block {
 	var p consensus.Hash
 	copy(p[:], block.GetParent())
-	return consensus.NewBlock(
-		p,
-		QuorumCertFromProto(block.GetQC()),
-		consensus.Command(block.GetCommand()),
-		consensus.View(block.GetView()),
-		hotstuff.ID(block.GetProposer()),
-	)
+	var b *consensus.Block
+	if len(block.GetPayloadRef()) > 0 {
+		var ref consensus.Hash
+		copy(ref[:], block.GetPayloadRef())
+		b = consensus.NewPayloadRefBlock(
+			p,
+			QuorumCertFromProto(block.GetQC()),
+			ref,
+			consensus.View(block.GetView()),
+			hotstuff.ID(block.GetProposer()),
+		)
+	} else {
+		b = consensus.NewBlock(
+			p,
+			QuorumCertFromProto(block.GetQC()),
+			consensus.Command(block.GetCommand()),
+			consensus.View(block.GetView()),
+			hotstuff.ID(block.GetProposer()),
+		)
+	}
+	if value := (consensus.BeaconValue{Round: block.GetBeaconRound(), Value: block.GetBeaconValue()}); !value.IsZero() {
+		b = b.WithBeacon(value)
+	}
+	if len(block.GetCommandRoot()) > 0 {
+		var root consensus.Hash
+		copy(root[:], block.GetCommandRoot())
+		b = b.WithCommandRoot(root)
+	}
+	return b
+}
+
+// InclusionProofToProto converts a consensus.InclusionProof to a hotstuffpb.InclusionProof.
+func InclusionProofToProto(proof consensus.InclusionProof) *InclusionProof {
+	p := &InclusionProof{}
+	if qc, ok := proof.QC(); ok {
+		p.QC = QuorumCertToProto(qc)
+	} else if sig := proof.ProposerSignature(); sig != nil {
+		p.ProposerSig = SignatureToProto(sig)
+		p.Run = hashesToProto(proof.Run())
+	}
+	return p
+}
+
+// InclusionProofFromProto converts a hotstuffpb.InclusionProof to a consensus.InclusionProof.
+func InclusionProofFromProto(p *InclusionProof) consensus.InclusionProof {
+	if qc := p.GetQC(); qc != nil {
+		return consensus.NewQCProof(QuorumCertFromProto(qc))
+	}
+	if sig := p.GetProposerSig(); sig != nil {
+		if run := hashesFromProto(p.GetRun()); len(run) > 0 {
+			return consensus.NewProposerRunProof(SignatureFromProto(sig), run)
+		}
+		return consensus.NewProposerProof(SignatureFromProto(sig))
+	}
+	return consensus.InclusionProof{}
+}
+
+func hashesToProto(hashes []consensus.Hash) [][]byte {
+	if len(hashes) == 0 {
+		return nil
+	}
+	b := make([][]byte, len(hashes))
+	for i := range hashes {
+		b[i] = hashes[i][:]
+	}
+	return b
+}
+
+func hashesFromProto(b [][]byte) []consensus.Hash {
+	if len(b) == 0 {
+		return nil
+	}
+	hashes := make([]consensus.Hash, len(b))
+	for i := range b {
+		copy(hashes[i][:], b[i])
+	}
+	return hashes
 }
 
 // TimeoutMsgFromProto converts a TimeoutMsg proto to the hotstuff type.
@@ -260,3 +419,15 @@ func SyncInfoToProto(syncInfo consensus.SyncInfo) *SyncInfo {
 	}
 	return m
 }
+
+// RelinquishMsgToProto converts a RelinquishMsg from the hotstuff type to the protobuf type.
+func RelinquishMsgToProto(msg consensus.RelinquishMsg) *RelinquishMsg {
+	return &RelinquishMsg{View: uint64(msg.View()), SyncInfo: SyncInfoToProto(msg.SyncInfo)}
+}
+
+// RelinquishMsgFromProto converts a RelinquishMsg from the protobuf type to the hotstuff type.
+// The 'ok' return value is false if the relinquished view is not the view that follows the certificates.
+func RelinquishMsgFromProto(m *RelinquishMsg) (msg consensus.RelinquishMsg, ok bool) {
+	msg = consensus.RelinquishMsg{SyncInfo: SyncInfoFromProto(m.GetSyncInfo())}
+	return msg, uint64(msg.View()) == m.GetView()
+}
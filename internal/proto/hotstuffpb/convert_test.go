@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
 	"github.com/relab/hotstuff/crypto"
 	"github.com/relab/hotstuff/crypto/bls12"
@@ -54,6 +55,54 @@ func TestConvertQuorumCert(t *testing.T) {
 	}
 }
 
+// TestConvertQuorumCertSignerBitmap checks that the signers of a BLS12 QC survive a round trip
+// through the canonical signer bitmap, and that non-canonical bitmaps are rejected.
+func TestConvertQuorumCertSignerBitmap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	builders := testutil.CreateBuilders(t, ctrl, 4, testutil.GenerateKeys(t, 4, testutil.GenerateBLS12Key)...)
+	for i := range builders {
+		builders[i].Register(crypto.New(bls12.New()))
+	}
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	b1 := consensus.NewBlock(consensus.GetGenesis().Hash(), consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash()), "", 1, 1)
+	signatures := testutil.CreatePCs(t, b1, []consensus.Crypto{signers[0], signers[2], signers[3]})
+	want, err := hl[0].Crypto().CreateQuorumCert(b1, signatures)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// replicas 1, 3, and 4 are bits 0, 2, and 3 of the first byte.
+	wantBitmap := []byte{0x0d}
+	wantIDs := []hotstuff.ID{1, 3, 4}
+
+	pb := QuorumCertToProto(want)
+	if got := pb.GetSig().GetBLS12Sig().GetParticipants(); !bytes.Equal(got, wantBitmap) {
+		t.Errorf("got bitmap %x, want %x", got, wantBitmap)
+	}
+	got := QuorumCertFromProto(pb)
+	if !bytes.Equal(got.SignerBitmap(), wantBitmap) {
+		t.Errorf("got bitmap %x after the round trip, want %x", got.SignerBitmap(), wantBitmap)
+	}
+	if ids := got.Signers(); len(ids) != len(wantIDs) || ids[0] != wantIDs[0] || ids[1] != wantIDs[1] || ids[2] != wantIDs[2] {
+		t.Errorf("got signers %v, want %v", ids, wantIDs)
+	}
+	if !bytes.Equal(want.ToBytes(), got.ToBytes()) {
+		t.Error("Certificates don't match.")
+	}
+	if !hl[0].Crypto().VerifyQuorumCert(got) {
+		t.Error("failed to verify the converted QC")
+	}
+
+	// a trailing zero byte does not change the set of signers, but the encoding is not canonical.
+	pb.GetSig().GetBLS12Sig().Participants = append(wantBitmap, 0)
+	if sig := QuorumCertFromProto(pb).Signature(); sig != nil {
+		t.Error("expected a non-canonical bitmap to be rejected")
+	}
+}
+
 func TestConvertBlock(t *testing.T) {
 	qc := consensus.NewQuorumCert(nil, 0, consensus.Hash{})
 	want := consensus.NewBlock(consensus.GetGenesis().Hash(), qc, "", 1, 1)
@@ -65,6 +114,43 @@ func TestConvertBlock(t *testing.T) {
 	}
 }
 
+func TestConvertPayloadRefBlock(t *testing.T) {
+	qc := consensus.NewQuorumCert(nil, 0, consensus.Hash{})
+	cmd := consensus.Command("payload")
+	block := consensus.NewPayloadRefBlock(consensus.GetGenesis().Hash(), qc, consensus.PayloadHash(cmd), 1, 1)
+	want, ok := block.WithPayload(cmd)
+	if !ok {
+		t.Fatal("payload does not match the reference")
+	}
+	pb := BlockToProto(want)
+	if len(pb.GetCommand()) > 0 {
+		t.Error("the payload was included in the block")
+	}
+	got := BlockFromProto(pb)
+
+	if want.Hash() != got.Hash() {
+		t.Error("Hashes don't match.")
+	}
+	if got.IsResolved() {
+		t.Error("expected the converted block to refer to its payload")
+	}
+}
+
+func TestConvertCommandRootBlock(t *testing.T) {
+	qc := consensus.NewQuorumCert(nil, 0, consensus.Hash{})
+	cmd := consensus.Command("payload")
+	want := consensus.NewBlock(consensus.GetGenesis().Hash(), qc, cmd, 1, 1).
+		WithCommandRoot(consensus.CommandRoot([]consensus.Command{cmd}))
+	got := BlockFromProto(BlockToProto(want))
+
+	if want.Hash() != got.Hash() {
+		t.Error("Hashes don't match.")
+	}
+	if root, ok := got.CommandRoot(); !ok || root != consensus.CommandRoot([]consensus.Command{cmd}) {
+		t.Error("the command root was not converted")
+	}
+}
+
 func TestConvertTimeoutCertBLS12(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -83,3 +169,77 @@ func TestConvertTimeoutCertBLS12(t *testing.T) {
 		t.Fatal("Failed to verify timeout cert")
 	}
 }
+
+func TestConvertProposalSigners(t *testing.T) {
+	qc := consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash())
+	want := consensus.ProposeMsg{
+		Block:   consensus.NewBlock(consensus.GetGenesis().Hash(), qc, "foo", 1, 1),
+		Signers: []hotstuff.ID{1, 2, 4},
+	}
+	got := ProposalFromProto(ProposalToProto(want))
+
+	if got.Block.Hash() != want.Block.Hash() {
+		t.Error("Hashes don't match.")
+	}
+	if len(got.Signers) != len(want.Signers) {
+		t.Fatalf("got %d signers, want %d", len(got.Signers), len(want.Signers))
+	}
+	for i := range want.Signers {
+		if got.Signers[i] != want.Signers[i] {
+			t.Errorf("signer %d: got %d, want %d", i, got.Signers[i], want.Signers[i])
+		}
+	}
+}
+
+func TestConvertProposalViewChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	builders := testutil.CreateBuilders(t, ctrl, 4)
+	hl := builders.Build()
+
+	b1 := consensus.NewBlock(consensus.GetGenesis().Hash(), consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash()), "", 1, 1)
+	qc := testutil.CreateQC(t, b1, hl.Signers())
+	want := consensus.ProposeMsg{
+		Block:      consensus.NewBlock(b1.Hash(), qc, "foo", 3, 1),
+		ViewChange: &consensus.ViewChangeBundle{TC: testutil.CreateTC(t, 2, hl.Signers()), HighQC: qc},
+	}
+	got := ProposalFromProto(ProposalToProto(want))
+
+	if got.ViewChange == nil {
+		t.Fatal("the view-change bundle was not converted")
+	}
+	if got.ViewChange.TC.View() != 2 || !hl[0].Crypto().VerifyTimeoutCert(got.ViewChange.TC) {
+		t.Error("the timeout certificate does not verify")
+	}
+	if !got.ViewChange.HighQC.Equals(qc) || !hl[0].Crypto().VerifyQuorumCert(got.ViewChange.HighQC) {
+		t.Error("the high QC does not match")
+	}
+}
+
+func TestConvertProposerRunProof(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	builder := testutil.TestModules(t, ctrl, 1, testutil.GenerateECDSAKey(t))
+	hs := builder.Build()
+
+	qc := consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash())
+	b1 := consensus.NewBlock(consensus.GetGenesis().Hash(), qc, "foo", 1, 1)
+	b2 := consensus.NewBlock(b1.Hash(), qc, "bar", 2, 1)
+	run := []consensus.Hash{b1.Hash(), b2.Hash()}
+	sig, err := hs.Crypto().Sign(consensus.ProposerRunDigest(run))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proposal := ProposalFromProto(ProposalToProto(consensus.ProposeMsg{Block: b2, ProposerSig: sig, ProposerRun: run[:1]}))
+	if len(proposal.ProposerRun) != 1 || proposal.ProposerRun[0] != b1.Hash() {
+		t.Errorf("got run %v, want %v", proposal.ProposerRun, run[:1])
+	}
+
+	proof := InclusionProofFromProto(InclusionProofToProto(consensus.NewProposerRunProof(sig, run)))
+	for _, block := range []*consensus.Block{b1, b2} {
+		if !consensus.VerifyInclusionProof(hs.Crypto(), block, proof) {
+			t.Errorf("the proof does not verify for block %.8s", block.Hash())
+		}
+	}
+}
@@ -27,8 +27,14 @@ type Proposal struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Block *Block `protobuf:"bytes,1,opt,name=Block,proto3" json:"Block,omitempty"`
-	AggQC *AggQC `protobuf:"bytes,2,opt,name=AggQC,proto3,oneof" json:"AggQC,omitempty"`
+	Block        *Block        `protobuf:"bytes,1,opt,name=Block,proto3" json:"Block,omitempty"`
+	AggQC        *AggQC        `protobuf:"bytes,2,opt,name=AggQC,proto3,oneof" json:"AggQC,omitempty"`
+	Signers      []uint32      `protobuf:"varint,3,rep,packed,name=Signers,proto3" json:"Signers,omitempty"`
+	ProposerSig  *Signature    `protobuf:"bytes,4,opt,name=ProposerSig,proto3,oneof" json:"ProposerSig,omitempty"`
+	FinalityCert *FinalityCert `protobuf:"bytes,5,opt,name=FinalityCert,proto3,oneof" json:"FinalityCert,omitempty"`
+	ViewChange   *ViewChange   `protobuf:"bytes,8,opt,name=ViewChange,proto3,oneof" json:"ViewChange,omitempty"`
+	// The hashes of the proposer's unsigned blocks that ProposerSig also covers, oldest first.
+	ProposerRun [][]byte `protobuf:"bytes,9,rep,name=ProposerRun,proto3" json:"ProposerRun,omitempty"`
 }
 
 func (x *Proposal) Reset() {
@@ -77,16 +83,53 @@ func (x *Proposal) GetAggQC() *AggQC {
 	return nil
 }
 
-type BlockHash struct {
+func (x *Proposal) GetSigners() []uint32 {
+	if x != nil {
+		return x.Signers
+	}
+	return nil
+}
+
+func (x *Proposal) GetProposerSig() *Signature {
+	if x != nil {
+		return x.ProposerSig
+	}
+	return nil
+}
+
+func (x *Proposal) GetFinalityCert() *FinalityCert {
+	if x != nil {
+		return x.FinalityCert
+	}
+	return nil
+}
+
+func (x *Proposal) GetViewChange() *ViewChange {
+	if x != nil {
+		return x.ViewChange
+	}
+	return nil
+}
+
+func (x *Proposal) GetProposerRun() [][]byte {
+	if x != nil {
+		return x.ProposerRun
+	}
+	return nil
+}
+
+// ViewChange proves the view change that preceded a proposal.
+type ViewChange struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Hash []byte `protobuf:"bytes,1,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	TC     *TimeoutCert `protobuf:"bytes,1,opt,name=TC,proto3" json:"TC,omitempty"`
+	HighQC *QuorumCert  `protobuf:"bytes,2,opt,name=HighQC,proto3" json:"HighQC,omitempty"`
 }
 
-func (x *BlockHash) Reset() {
-	*x = BlockHash{}
+func (x *ViewChange) Reset() {
+	*x = ViewChange{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -94,13 +137,13 @@ func (x *BlockHash) Reset() {
 	}
 }
 
-func (x *BlockHash) String() string {
+func (x *ViewChange) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BlockHash) ProtoMessage() {}
+func (*ViewChange) ProtoMessage() {}
 
-func (x *BlockHash) ProtoReflect() protoreflect.Message {
+func (x *ViewChange) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -112,32 +155,36 @@ func (x *BlockHash) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BlockHash.ProtoReflect.Descriptor instead.
-func (*BlockHash) Descriptor() ([]byte, []int) {
+// Deprecated: Use ViewChange.ProtoReflect.Descriptor instead.
+func (*ViewChange) Descriptor() ([]byte, []int) {
 	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *BlockHash) GetHash() []byte {
+func (x *ViewChange) GetTC() *TimeoutCert {
 	if x != nil {
-		return x.Hash
+		return x.TC
 	}
 	return nil
 }
 
-type Block struct {
+func (x *ViewChange) GetHighQC() *QuorumCert {
+	if x != nil {
+		return x.HighQC
+	}
+	return nil
+}
+
+type HeartbeatMsg struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Parent   []byte      `protobuf:"bytes,1,opt,name=Parent,proto3" json:"Parent,omitempty"`
-	QC       *QuorumCert `protobuf:"bytes,2,opt,name=QC,proto3" json:"QC,omitempty"`
-	View     uint64      `protobuf:"varint,3,opt,name=View,proto3" json:"View,omitempty"`
-	Command  []byte      `protobuf:"bytes,4,opt,name=Command,proto3" json:"Command,omitempty"`
-	Proposer uint32      `protobuf:"varint,5,opt,name=Proposer,proto3" json:"Proposer,omitempty"`
+	View       uint64 `protobuf:"varint,1,opt,name=View,proto3" json:"View,omitempty"`
+	HighQCView uint64 `protobuf:"varint,2,opt,name=HighQCView,proto3" json:"HighQCView,omitempty"`
 }
 
-func (x *Block) Reset() {
-	*x = Block{}
+func (x *HeartbeatMsg) Reset() {
+	*x = HeartbeatMsg{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -145,13 +192,13 @@ func (x *Block) Reset() {
 	}
 }
 
-func (x *Block) String() string {
+func (x *HeartbeatMsg) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Block) ProtoMessage() {}
+func (*HeartbeatMsg) ProtoMessage() {}
 
-func (x *Block) ProtoReflect() protoreflect.Message {
+func (x *HeartbeatMsg) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -163,58 +210,38 @@ func (x *Block) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Block.ProtoReflect.Descriptor instead.
-func (*Block) Descriptor() ([]byte, []int) {
+// Deprecated: Use HeartbeatMsg.ProtoReflect.Descriptor instead.
+func (*HeartbeatMsg) Descriptor() ([]byte, []int) {
 	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *Block) GetParent() []byte {
-	if x != nil {
-		return x.Parent
-	}
-	return nil
-}
-
-func (x *Block) GetQC() *QuorumCert {
-	if x != nil {
-		return x.QC
-	}
-	return nil
-}
-
-func (x *Block) GetView() uint64 {
+func (x *HeartbeatMsg) GetView() uint64 {
 	if x != nil {
 		return x.View
 	}
 	return 0
 }
 
-func (x *Block) GetCommand() []byte {
-	if x != nil {
-		return x.Command
-	}
-	return nil
-}
-
-func (x *Block) GetProposer() uint32 {
+func (x *HeartbeatMsg) GetHighQCView() uint64 {
 	if x != nil {
-		return x.Proposer
+		return x.HighQCView
 	}
 	return 0
 }
 
-type ECDSASignature struct {
+// RelinquishMsg is sent by the leader of View when it steps down,
+// along with the certificates that it entered the view with.
+type RelinquishMsg struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Signer uint32 `protobuf:"varint,1,opt,name=Signer,proto3" json:"Signer,omitempty"`
-	R      []byte `protobuf:"bytes,2,opt,name=R,proto3" json:"R,omitempty"`
-	S      []byte `protobuf:"bytes,3,opt,name=S,proto3" json:"S,omitempty"`
+	View     uint64    `protobuf:"varint,1,opt,name=View,proto3" json:"View,omitempty"`
+	SyncInfo *SyncInfo `protobuf:"bytes,2,opt,name=SyncInfo,proto3" json:"SyncInfo,omitempty"`
 }
 
-func (x *ECDSASignature) Reset() {
-	*x = ECDSASignature{}
+func (x *RelinquishMsg) Reset() {
+	*x = RelinquishMsg{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -222,13 +249,13 @@ func (x *ECDSASignature) Reset() {
 	}
 }
 
-func (x *ECDSASignature) String() string {
+func (x *RelinquishMsg) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ECDSASignature) ProtoMessage() {}
+func (*RelinquishMsg) ProtoMessage() {}
 
-func (x *ECDSASignature) ProtoReflect() protoreflect.Message {
+func (x *RelinquishMsg) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -240,42 +267,38 @@ func (x *ECDSASignature) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ECDSASignature.ProtoReflect.Descriptor instead.
-func (*ECDSASignature) Descriptor() ([]byte, []int) {
+// Deprecated: Use RelinquishMsg.ProtoReflect.Descriptor instead.
+func (*RelinquishMsg) Descriptor() ([]byte, []int) {
 	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *ECDSASignature) GetSigner() uint32 {
+func (x *RelinquishMsg) GetView() uint64 {
 	if x != nil {
-		return x.Signer
+		return x.View
 	}
 	return 0
 }
 
-func (x *ECDSASignature) GetR() []byte {
-	if x != nil {
-		return x.R
-	}
-	return nil
-}
-
-func (x *ECDSASignature) GetS() []byte {
+func (x *RelinquishMsg) GetSyncInfo() *SyncInfo {
 	if x != nil {
-		return x.S
+		return x.SyncInfo
 	}
 	return nil
 }
 
-type BLS12Signature struct {
+// BlockHash requests a single block or payload using Hash,
+// or several blocks at once using Hashes.
+type BlockHash struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sig []byte `protobuf:"bytes,1,opt,name=Sig,proto3" json:"Sig,omitempty"`
+	Hash   []byte   `protobuf:"bytes,1,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	Hashes [][]byte `protobuf:"bytes,2,rep,name=Hashes,proto3" json:"Hashes,omitempty"`
 }
 
-func (x *BLS12Signature) Reset() {
-	*x = BLS12Signature{}
+func (x *BlockHash) Reset() {
+	*x = BlockHash{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -283,13 +306,13 @@ func (x *BLS12Signature) Reset() {
 	}
 }
 
-func (x *BLS12Signature) String() string {
+func (x *BlockHash) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BLS12Signature) ProtoMessage() {}
+func (*BlockHash) ProtoMessage() {}
 
-func (x *BLS12Signature) ProtoReflect() protoreflect.Message {
+func (x *BlockHash) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -301,31 +324,38 @@ func (x *BLS12Signature) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BLS12Signature.ProtoReflect.Descriptor instead.
-func (*BLS12Signature) Descriptor() ([]byte, []int) {
+// Deprecated: Use BlockHash.ProtoReflect.Descriptor instead.
+func (*BlockHash) Descriptor() ([]byte, []int) {
 	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *BLS12Signature) GetSig() []byte {
+func (x *BlockHash) GetHash() []byte {
 	if x != nil {
-		return x.Sig
+		return x.Hash
 	}
 	return nil
 }
 
-type Signature struct {
+func (x *BlockHash) GetHashes() [][]byte {
+	if x != nil {
+		return x.Hashes
+	}
+	return nil
+}
+
+type InclusionProof struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to Sig:
-	//	*Signature_ECDSASig
-	//	*Signature_BLS12Sig
-	Sig isSignature_Sig `protobuf_oneof:"Sig"`
+	QC          *QuorumCert `protobuf:"bytes,1,opt,name=QC,proto3,oneof" json:"QC,omitempty"`
+	ProposerSig *Signature  `protobuf:"bytes,2,opt,name=ProposerSig,proto3,oneof" json:"ProposerSig,omitempty"`
+	// The hashes of the blocks that ProposerSig covers, if it covers more than the block itself.
+	Run [][]byte `protobuf:"bytes,3,rep,name=Run,proto3" json:"Run,omitempty"`
 }
 
-func (x *Signature) Reset() {
-	*x = Signature{}
+func (x *InclusionProof) Reset() {
+	*x = InclusionProof{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -333,13 +363,13 @@ func (x *Signature) Reset() {
 	}
 }
 
-func (x *Signature) String() string {
+func (x *InclusionProof) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Signature) ProtoMessage() {}
+func (*InclusionProof) ProtoMessage() {}
 
-func (x *Signature) ProtoReflect() protoreflect.Message {
+func (x *InclusionProof) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -351,59 +381,45 @@ func (x *Signature) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Signature.ProtoReflect.Descriptor instead.
-func (*Signature) Descriptor() ([]byte, []int) {
+// Deprecated: Use InclusionProof.ProtoReflect.Descriptor instead.
+func (*InclusionProof) Descriptor() ([]byte, []int) {
 	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{5}
 }
 
-func (m *Signature) GetSig() isSignature_Sig {
-	if m != nil {
-		return m.Sig
+func (x *InclusionProof) GetQC() *QuorumCert {
+	if x != nil {
+		return x.QC
 	}
 	return nil
 }
 
-func (x *Signature) GetECDSASig() *ECDSASignature {
-	if x, ok := x.GetSig().(*Signature_ECDSASig); ok {
-		return x.ECDSASig
+func (x *InclusionProof) GetProposerSig() *Signature {
+	if x != nil {
+		return x.ProposerSig
 	}
 	return nil
 }
 
-func (x *Signature) GetBLS12Sig() *BLS12Signature {
-	if x, ok := x.GetSig().(*Signature_BLS12Sig); ok {
-		return x.BLS12Sig
+func (x *InclusionProof) GetRun() [][]byte {
+	if x != nil {
+		return x.Run
 	}
 	return nil
 }
 
-type isSignature_Sig interface {
-	isSignature_Sig()
-}
-
-type Signature_ECDSASig struct {
-	ECDSASig *ECDSASignature `protobuf:"bytes,1,opt,name=ECDSASig,proto3,oneof"`
-}
-
-type Signature_BLS12Sig struct {
-	BLS12Sig *BLS12Signature `protobuf:"bytes,2,opt,name=BLS12Sig,proto3,oneof"`
-}
-
-func (*Signature_ECDSASig) isSignature_Sig() {}
-
-func (*Signature_BLS12Sig) isSignature_Sig() {}
-
-type PartialCert struct {
+type FetchedBlock struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sig  *Signature `protobuf:"bytes,1,opt,name=Sig,proto3" json:"Sig,omitempty"`
-	Hash []byte     `protobuf:"bytes,2,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	Block   *Block          `protobuf:"bytes,1,opt,name=Block,proto3" json:"Block,omitempty"`
+	Proof   *InclusionProof `protobuf:"bytes,2,opt,name=Proof,proto3" json:"Proof,omitempty"`
+	Payload []byte          `protobuf:"bytes,3,opt,name=Payload,proto3" json:"Payload,omitempty"`
+	Blocks  []*FetchedBlock `protobuf:"bytes,4,rep,name=Blocks,proto3" json:"Blocks,omitempty"` // the blocks found for a request for several hashes
 }
 
-func (x *PartialCert) Reset() {
-	*x = PartialCert{}
+func (x *FetchedBlock) Reset() {
+	*x = FetchedBlock{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -411,13 +427,13 @@ func (x *PartialCert) Reset() {
 	}
 }
 
-func (x *PartialCert) String() string {
+func (x *FetchedBlock) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PartialCert) ProtoMessage() {}
+func (*FetchedBlock) ProtoMessage() {}
 
-func (x *PartialCert) ProtoReflect() protoreflect.Message {
+func (x *FetchedBlock) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -429,35 +445,50 @@ func (x *PartialCert) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PartialCert.ProtoReflect.Descriptor instead.
-func (*PartialCert) Descriptor() ([]byte, []int) {
+// Deprecated: Use FetchedBlock.ProtoReflect.Descriptor instead.
+func (*FetchedBlock) Descriptor() ([]byte, []int) {
 	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *PartialCert) GetSig() *Signature {
+func (x *FetchedBlock) GetBlock() *Block {
 	if x != nil {
-		return x.Sig
+		return x.Block
 	}
 	return nil
 }
 
-func (x *PartialCert) GetHash() []byte {
+func (x *FetchedBlock) GetProof() *InclusionProof {
 	if x != nil {
-		return x.Hash
+		return x.Proof
 	}
 	return nil
 }
 
-type ECDSAThresholdSignature struct {
+func (x *FetchedBlock) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *FetchedBlock) GetBlocks() []*FetchedBlock {
+	if x != nil {
+		return x.Blocks
+	}
+	return nil
+}
+
+// Snapshot holds the committed blocks of a replica, ordered from the oldest block to the committed block.
+type Snapshot struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sigs []*ECDSASignature `protobuf:"bytes,1,rep,name=Sigs,proto3" json:"Sigs,omitempty"`
+	Blocks []*FetchedBlock `protobuf:"bytes,1,rep,name=Blocks,proto3" json:"Blocks,omitempty"`
 }
 
-func (x *ECDSAThresholdSignature) Reset() {
-	*x = ECDSAThresholdSignature{}
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -465,13 +496,13 @@ func (x *ECDSAThresholdSignature) Reset() {
 	}
 }
 
-func (x *ECDSAThresholdSignature) String() string {
+func (x *Snapshot) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ECDSAThresholdSignature) ProtoMessage() {}
+func (*Snapshot) ProtoMessage() {}
 
-func (x *ECDSAThresholdSignature) ProtoReflect() protoreflect.Message {
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -483,29 +514,36 @@ func (x *ECDSAThresholdSignature) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ECDSAThresholdSignature.ProtoReflect.Descriptor instead.
-func (*ECDSAThresholdSignature) Descriptor() ([]byte, []int) {
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
 	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *ECDSAThresholdSignature) GetSigs() []*ECDSASignature {
+func (x *Snapshot) GetBlocks() []*FetchedBlock {
 	if x != nil {
-		return x.Sigs
+		return x.Blocks
 	}
 	return nil
 }
 
-type BLS12AggregateSignature struct {
+type Block struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Sig          []byte `protobuf:"bytes,1,opt,name=Sig,proto3" json:"Sig,omitempty"`
-	Participants []byte `protobuf:"bytes,2,opt,name=participants,proto3" json:"participants,omitempty"`
+	Parent      []byte      `protobuf:"bytes,1,opt,name=Parent,proto3" json:"Parent,omitempty"`
+	QC          *QuorumCert `protobuf:"bytes,2,opt,name=QC,proto3" json:"QC,omitempty"`
+	View        uint64      `protobuf:"varint,3,opt,name=View,proto3" json:"View,omitempty"`
+	Command     []byte      `protobuf:"bytes,4,opt,name=Command,proto3" json:"Command,omitempty"`
+	Proposer    uint32      `protobuf:"varint,5,opt,name=Proposer,proto3" json:"Proposer,omitempty"`
+	PayloadRef  []byte      `protobuf:"bytes,6,opt,name=PayloadRef,proto3" json:"PayloadRef,omitempty"`
+	BeaconRound uint64      `protobuf:"varint,7,opt,name=BeaconRound,proto3" json:"BeaconRound,omitempty"`
+	BeaconValue []byte      `protobuf:"bytes,8,opt,name=BeaconValue,proto3" json:"BeaconValue,omitempty"`
+	CommandRoot []byte      `protobuf:"bytes,9,opt,name=CommandRoot,proto3" json:"CommandRoot,omitempty"`
 }
 
-func (x *BLS12AggregateSignature) Reset() {
-	*x = BLS12AggregateSignature{}
+func (x *Block) Reset() {
+	*x = Block{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -513,13 +551,13 @@ func (x *BLS12AggregateSignature) Reset() {
 	}
 }
 
-func (x *BLS12AggregateSignature) String() string {
+func (x *Block) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BLS12AggregateSignature) ProtoMessage() {}
+func (*Block) ProtoMessage() {}
 
-func (x *BLS12AggregateSignature) ProtoReflect() protoreflect.Message {
+func (x *Block) ProtoReflect() protoreflect.Message {
 	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -531,53 +569,600 @@ func (x *BLS12AggregateSignature) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BLS12AggregateSignature.ProtoReflect.Descriptor instead.
-func (*BLS12AggregateSignature) Descriptor() ([]byte, []int) {
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
 	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *BLS12AggregateSignature) GetSig() []byte {
+func (x *Block) GetParent() []byte {
 	if x != nil {
-		return x.Sig
+		return x.Parent
 	}
 	return nil
 }
 
-func (x *BLS12AggregateSignature) GetParticipants() []byte {
+func (x *Block) GetQC() *QuorumCert {
 	if x != nil {
-		return x.Participants
+		return x.QC
 	}
 	return nil
 }
 
-type ThresholdSignature struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	// Types that are assignable to AggSig:
-	//	*ThresholdSignature_ECDSASigs
-	//	*ThresholdSignature_BLS12Sig
-	AggSig isThresholdSignature_AggSig `protobuf_oneof:"AggSig"`
+func (x *Block) GetView() uint64 {
+	if x != nil {
+		return x.View
+	}
+	return 0
 }
 
-func (x *ThresholdSignature) Reset() {
-	*x = ThresholdSignature{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[9]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *Block) GetCommand() []byte {
+	if x != nil {
+		return x.Command
 	}
+	return nil
 }
 
-func (x *ThresholdSignature) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Block) GetProposer() uint32 {
+	if x != nil {
+		return x.Proposer
+	}
+	return 0
 }
 
-func (*ThresholdSignature) ProtoMessage() {}
+func (x *Block) GetPayloadRef() []byte {
+	if x != nil {
+		return x.PayloadRef
+	}
+	return nil
+}
+
+func (x *Block) GetBeaconRound() uint64 {
+	if x != nil {
+		return x.BeaconRound
+	}
+	return 0
+}
+
+func (x *Block) GetBeaconValue() []byte {
+	if x != nil {
+		return x.BeaconValue
+	}
+	return nil
+}
+
+func (x *Block) GetCommandRoot() []byte {
+	if x != nil {
+		return x.CommandRoot
+	}
+	return nil
+}
+
+type ECDSASignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Signer uint32 `protobuf:"varint,1,opt,name=Signer,proto3" json:"Signer,omitempty"`
+	R      []byte `protobuf:"bytes,2,opt,name=R,proto3" json:"R,omitempty"`
+	S      []byte `protobuf:"bytes,3,opt,name=S,proto3" json:"S,omitempty"`
+}
+
+func (x *ECDSASignature) Reset() {
+	*x = ECDSASignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ECDSASignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ECDSASignature) ProtoMessage() {}
+
+func (x *ECDSASignature) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ECDSASignature.ProtoReflect.Descriptor instead.
+func (*ECDSASignature) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ECDSASignature) GetSigner() uint32 {
+	if x != nil {
+		return x.Signer
+	}
+	return 0
+}
+
+func (x *ECDSASignature) GetR() []byte {
+	if x != nil {
+		return x.R
+	}
+	return nil
+}
+
+func (x *ECDSASignature) GetS() []byte {
+	if x != nil {
+		return x.S
+	}
+	return nil
+}
+
+type BLS12Signature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sig []byte `protobuf:"bytes,1,opt,name=Sig,proto3" json:"Sig,omitempty"`
+}
+
+func (x *BLS12Signature) Reset() {
+	*x = BLS12Signature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BLS12Signature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BLS12Signature) ProtoMessage() {}
+
+func (x *BLS12Signature) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BLS12Signature.ProtoReflect.Descriptor instead.
+func (*BLS12Signature) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *BLS12Signature) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+type Signature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Sig:
+	//	*Signature_ECDSASig
+	//	*Signature_BLS12Sig
+	Sig isSignature_Sig `protobuf_oneof:"Sig"`
+}
+
+func (x *Signature) Reset() {
+	*x = Signature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Signature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Signature) ProtoMessage() {}
+
+func (x *Signature) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Signature.ProtoReflect.Descriptor instead.
+func (*Signature) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{11}
+}
+
+func (m *Signature) GetSig() isSignature_Sig {
+	if m != nil {
+		return m.Sig
+	}
+	return nil
+}
+
+func (x *Signature) GetECDSASig() *ECDSASignature {
+	if x, ok := x.GetSig().(*Signature_ECDSASig); ok {
+		return x.ECDSASig
+	}
+	return nil
+}
+
+func (x *Signature) GetBLS12Sig() *BLS12Signature {
+	if x, ok := x.GetSig().(*Signature_BLS12Sig); ok {
+		return x.BLS12Sig
+	}
+	return nil
+}
+
+type isSignature_Sig interface {
+	isSignature_Sig()
+}
+
+type Signature_ECDSASig struct {
+	ECDSASig *ECDSASignature `protobuf:"bytes,1,opt,name=ECDSASig,proto3,oneof"`
+}
+
+type Signature_BLS12Sig struct {
+	BLS12Sig *BLS12Signature `protobuf:"bytes,2,opt,name=BLS12Sig,proto3,oneof"`
+}
+
+func (*Signature_ECDSASig) isSignature_Sig() {}
+
+func (*Signature_BLS12Sig) isSignature_Sig() {}
+
+type PartialCert struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sig        *Signature  `protobuf:"bytes,1,opt,name=Sig,proto3" json:"Sig,omitempty"`
+	Hash       []byte      `protobuf:"bytes,2,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	Congested  bool        `protobuf:"varint,3,opt,name=Congested,proto3" json:"Congested,omitempty"`
+	View       uint64      `protobuf:"varint,4,opt,name=View,proto3" json:"View,omitempty"`
+	CommitCert *CommitCert `protobuf:"bytes,5,opt,name=CommitCert,proto3,oneof" json:"CommitCert,omitempty"`
+	// votes collected by a regional relay; the other fields are empty when this is set.
+	Relayed []*PartialCert `protobuf:"bytes,6,rep,name=Relayed,proto3" json:"Relayed,omitempty"`
+}
+
+func (x *PartialCert) Reset() {
+	*x = PartialCert{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PartialCert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartialCert) ProtoMessage() {}
+
+func (x *PartialCert) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartialCert.ProtoReflect.Descriptor instead.
+func (*PartialCert) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PartialCert) GetSig() *Signature {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *PartialCert) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *PartialCert) GetCongested() bool {
+	if x != nil {
+		return x.Congested
+	}
+	return false
+}
+
+func (x *PartialCert) GetView() uint64 {
+	if x != nil {
+		return x.View
+	}
+	return 0
+}
+
+func (x *PartialCert) GetCommitCert() *CommitCert {
+	if x != nil {
+		return x.CommitCert
+	}
+	return nil
+}
+
+func (x *PartialCert) GetRelayed() []*PartialCert {
+	if x != nil {
+		return x.Relayed
+	}
+	return nil
+}
+
+type CommitCert struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID   uint32     `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	View uint64     `protobuf:"varint,2,opt,name=View,proto3" json:"View,omitempty"`
+	Hash []byte     `protobuf:"bytes,3,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	Sig  *Signature `protobuf:"bytes,4,opt,name=Sig,proto3" json:"Sig,omitempty"`
+}
+
+func (x *CommitCert) Reset() {
+	*x = CommitCert{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CommitCert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitCert) ProtoMessage() {}
+
+func (x *CommitCert) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitCert.ProtoReflect.Descriptor instead.
+func (*CommitCert) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CommitCert) GetID() uint32 {
+	if x != nil {
+		return x.ID
+	}
+	return 0
+}
+
+func (x *CommitCert) GetView() uint64 {
+	if x != nil {
+		return x.View
+	}
+	return 0
+}
+
+func (x *CommitCert) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *CommitCert) GetSig() *Signature {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+type FinalityCert struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CommitCerts []*CommitCert `protobuf:"bytes,1,rep,name=CommitCerts,proto3" json:"CommitCerts,omitempty"`
+}
+
+func (x *FinalityCert) Reset() {
+	*x = FinalityCert{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FinalityCert) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalityCert) ProtoMessage() {}
+
+func (x *FinalityCert) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalityCert.ProtoReflect.Descriptor instead.
+func (*FinalityCert) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *FinalityCert) GetCommitCerts() []*CommitCert {
+	if x != nil {
+		return x.CommitCerts
+	}
+	return nil
+}
+
+type ECDSAThresholdSignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sigs []*ECDSASignature `protobuf:"bytes,1,rep,name=Sigs,proto3" json:"Sigs,omitempty"`
+}
+
+func (x *ECDSAThresholdSignature) Reset() {
+	*x = ECDSAThresholdSignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ECDSAThresholdSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ECDSAThresholdSignature) ProtoMessage() {}
+
+func (x *ECDSAThresholdSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ECDSAThresholdSignature.ProtoReflect.Descriptor instead.
+func (*ECDSAThresholdSignature) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ECDSAThresholdSignature) GetSigs() []*ECDSASignature {
+	if x != nil {
+		return x.Sigs
+	}
+	return nil
+}
+
+type BLS12AggregateSignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sig          []byte `protobuf:"bytes,1,opt,name=Sig,proto3" json:"Sig,omitempty"`
+	Participants []byte `protobuf:"bytes,2,opt,name=participants,proto3" json:"participants,omitempty"`
+}
+
+func (x *BLS12AggregateSignature) Reset() {
+	*x = BLS12AggregateSignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BLS12AggregateSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BLS12AggregateSignature) ProtoMessage() {}
+
+func (x *BLS12AggregateSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BLS12AggregateSignature.ProtoReflect.Descriptor instead.
+func (*BLS12AggregateSignature) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BLS12AggregateSignature) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *BLS12AggregateSignature) GetParticipants() []byte {
+	if x != nil {
+		return x.Participants
+	}
+	return nil
+}
+
+type ThresholdSignature struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to AggSig:
+	//	*ThresholdSignature_ECDSASigs
+	//	*ThresholdSignature_BLS12Sig
+	AggSig isThresholdSignature_AggSig `protobuf_oneof:"AggSig"`
+}
+
+func (x *ThresholdSignature) Reset() {
+	*x = ThresholdSignature{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ThresholdSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThresholdSignature) ProtoMessage() {}
 
 func (x *ThresholdSignature) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[9]
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -590,7 +1175,7 @@ func (x *ThresholdSignature) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ThresholdSignature.ProtoReflect.Descriptor instead.
 func (*ThresholdSignature) Descriptor() ([]byte, []int) {
-	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{9}
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{17}
 }
 
 func (m *ThresholdSignature) GetAggSig() isThresholdSignature_AggSig {
@@ -643,7 +1228,7 @@ type QuorumCert struct {
 func (x *QuorumCert) Reset() {
 	*x = QuorumCert{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[10]
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -656,7 +1241,7 @@ func (x *QuorumCert) String() string {
 func (*QuorumCert) ProtoMessage() {}
 
 func (x *QuorumCert) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[10]
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -669,7 +1254,7 @@ func (x *QuorumCert) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QuorumCert.ProtoReflect.Descriptor instead.
 func (*QuorumCert) Descriptor() ([]byte, []int) {
-	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{10}
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *QuorumCert) GetSig() *ThresholdSignature {
@@ -705,7 +1290,7 @@ type TimeoutCert struct {
 func (x *TimeoutCert) Reset() {
 	*x = TimeoutCert{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[11]
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -718,7 +1303,7 @@ func (x *TimeoutCert) String() string {
 func (*TimeoutCert) ProtoMessage() {}
 
 func (x *TimeoutCert) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[11]
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -731,7 +1316,7 @@ func (x *TimeoutCert) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TimeoutCert.ProtoReflect.Descriptor instead.
 func (*TimeoutCert) Descriptor() ([]byte, []int) {
-	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{11}
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *TimeoutCert) GetSig() *ThresholdSignature {
@@ -753,29 +1338,280 @@ type TimeoutMsg struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	View     uint64     `protobuf:"varint,1,opt,name=View,proto3" json:"View,omitempty"`
-	SyncInfo *SyncInfo  `protobuf:"bytes,2,opt,name=SyncInfo,proto3" json:"SyncInfo,omitempty"`
-	ViewSig  *Signature `protobuf:"bytes,3,opt,name=ViewSig,proto3" json:"ViewSig,omitempty"`
-	MsgSig   *Signature `protobuf:"bytes,4,opt,name=MsgSig,proto3,oneof" json:"MsgSig,omitempty"`
+	View     uint64     `protobuf:"varint,1,opt,name=View,proto3" json:"View,omitempty"`
+	SyncInfo *SyncInfo  `protobuf:"bytes,2,opt,name=SyncInfo,proto3" json:"SyncInfo,omitempty"`
+	ViewSig  *Signature `protobuf:"bytes,3,opt,name=ViewSig,proto3" json:"ViewSig,omitempty"`
+	MsgSig   *Signature `protobuf:"bytes,4,opt,name=MsgSig,proto3,oneof" json:"MsgSig,omitempty"`
+}
+
+func (x *TimeoutMsg) Reset() {
+	*x = TimeoutMsg{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TimeoutMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeoutMsg) ProtoMessage() {}
+
+func (x *TimeoutMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeoutMsg.ProtoReflect.Descriptor instead.
+func (*TimeoutMsg) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *TimeoutMsg) GetView() uint64 {
+	if x != nil {
+		return x.View
+	}
+	return 0
+}
+
+func (x *TimeoutMsg) GetSyncInfo() *SyncInfo {
+	if x != nil {
+		return x.SyncInfo
+	}
+	return nil
+}
+
+func (x *TimeoutMsg) GetViewSig() *Signature {
+	if x != nil {
+		return x.ViewSig
+	}
+	return nil
+}
+
+func (x *TimeoutMsg) GetMsgSig() *Signature {
+	if x != nil {
+		return x.MsgSig
+	}
+	return nil
+}
+
+type SyncInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	QC    *QuorumCert  `protobuf:"bytes,1,opt,name=QC,proto3,oneof" json:"QC,omitempty"`
+	TC    *TimeoutCert `protobuf:"bytes,2,opt,name=TC,proto3,oneof" json:"TC,omitempty"`
+	AggQC *AggQC       `protobuf:"bytes,3,opt,name=AggQC,proto3,oneof" json:"AggQC,omitempty"`
+}
+
+func (x *SyncInfo) Reset() {
+	*x = SyncInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SyncInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncInfo) ProtoMessage() {}
+
+func (x *SyncInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncInfo.ProtoReflect.Descriptor instead.
+func (*SyncInfo) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SyncInfo) GetQC() *QuorumCert {
+	if x != nil {
+		return x.QC
+	}
+	return nil
+}
+
+func (x *SyncInfo) GetTC() *TimeoutCert {
+	if x != nil {
+		return x.TC
+	}
+	return nil
+}
+
+func (x *SyncInfo) GetAggQC() *AggQC {
+	if x != nil {
+		return x.AggQC
+	}
+	return nil
+}
+
+type SyncState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	View     uint64    `protobuf:"varint,1,opt,name=View,proto3" json:"View,omitempty"`
+	SyncInfo *SyncInfo `protobuf:"bytes,2,opt,name=SyncInfo,proto3" json:"SyncInfo,omitempty"`
+}
+
+func (x *SyncState) Reset() {
+	*x = SyncState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SyncState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncState) ProtoMessage() {}
+
+func (x *SyncState) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncState.ProtoReflect.Descriptor instead.
+func (*SyncState) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SyncState) GetView() uint64 {
+	if x != nil {
+		return x.View
+	}
+	return 0
+}
+
+func (x *SyncState) GetSyncInfo() *SyncInfo {
+	if x != nil {
+		return x.SyncInfo
+	}
+	return nil
+}
+
+type SafetyState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LastVote uint64      `protobuf:"varint,1,opt,name=LastVote,proto3" json:"LastVote,omitempty"`
+	Locked   *Block      `protobuf:"bytes,2,opt,name=Locked,proto3,oneof" json:"Locked,omitempty"`
+	HighQC   *QuorumCert `protobuf:"bytes,3,opt,name=HighQC,proto3" json:"HighQC,omitempty"`
+}
+
+func (x *SafetyState) Reset() {
+	*x = SafetyState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SafetyState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SafetyState) ProtoMessage() {}
+
+func (x *SafetyState) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SafetyState.ProtoReflect.Descriptor instead.
+func (*SafetyState) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SafetyState) GetLastVote() uint64 {
+	if x != nil {
+		return x.LastVote
+	}
+	return 0
+}
+
+func (x *SafetyState) GetLocked() *Block {
+	if x != nil {
+		return x.Locked
+	}
+	return nil
+}
+
+func (x *SafetyState) GetHighQC() *QuorumCert {
+	if x != nil {
+		return x.HighQC
+	}
+	return nil
+}
+
+type AggQC struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	QCs  map[uint32]*QuorumCert `protobuf:"bytes,1,rep,name=QCs,proto3" json:"QCs,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Sig  *ThresholdSignature    `protobuf:"bytes,2,opt,name=Sig,proto3" json:"Sig,omitempty"`
+	View uint64                 `protobuf:"varint,3,opt,name=View,proto3" json:"View,omitempty"`
 }
 
-func (x *TimeoutMsg) Reset() {
-	*x = TimeoutMsg{}
+func (x *AggQC) Reset() {
+	*x = AggQC{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[12]
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *TimeoutMsg) String() string {
+func (x *AggQC) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TimeoutMsg) ProtoMessage() {}
+func (*AggQC) ProtoMessage() {}
 
-func (x *TimeoutMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[12]
+func (x *AggQC) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -786,66 +1622,59 @@ func (x *TimeoutMsg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TimeoutMsg.ProtoReflect.Descriptor instead.
-func (*TimeoutMsg) Descriptor() ([]byte, []int) {
-	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{12}
-}
-
-func (x *TimeoutMsg) GetView() uint64 {
-	if x != nil {
-		return x.View
-	}
-	return 0
+// Deprecated: Use AggQC.ProtoReflect.Descriptor instead.
+func (*AggQC) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{24}
 }
 
-func (x *TimeoutMsg) GetSyncInfo() *SyncInfo {
+func (x *AggQC) GetQCs() map[uint32]*QuorumCert {
 	if x != nil {
-		return x.SyncInfo
+		return x.QCs
 	}
 	return nil
 }
 
-func (x *TimeoutMsg) GetViewSig() *Signature {
+func (x *AggQC) GetSig() *ThresholdSignature {
 	if x != nil {
-		return x.ViewSig
+		return x.Sig
 	}
 	return nil
 }
 
-func (x *TimeoutMsg) GetMsgSig() *Signature {
+func (x *AggQC) GetView() uint64 {
 	if x != nil {
-		return x.MsgSig
+		return x.View
 	}
-	return nil
+	return 0
 }
 
-type SyncInfo struct {
+// StreamCommand is the command of a single labeled command stream.
+type StreamCommand struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	QC    *QuorumCert  `protobuf:"bytes,1,opt,name=QC,proto3,oneof" json:"QC,omitempty"`
-	TC    *TimeoutCert `protobuf:"bytes,2,opt,name=TC,proto3,oneof" json:"TC,omitempty"`
-	AggQC *AggQC       `protobuf:"bytes,3,opt,name=AggQC,proto3,oneof" json:"AggQC,omitempty"`
+	Label   string `protobuf:"bytes,1,opt,name=Label,proto3" json:"Label,omitempty"`
+	Command []byte `protobuf:"bytes,2,opt,name=Command,proto3" json:"Command,omitempty"`
 }
 
-func (x *SyncInfo) Reset() {
-	*x = SyncInfo{}
+func (x *StreamCommand) Reset() {
+	*x = StreamCommand{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[13]
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SyncInfo) String() string {
+func (x *StreamCommand) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SyncInfo) ProtoMessage() {}
+func (*StreamCommand) ProtoMessage() {}
 
-func (x *SyncInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[13]
+func (x *StreamCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -856,59 +1685,51 @@ func (x *SyncInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SyncInfo.ProtoReflect.Descriptor instead.
-func (*SyncInfo) Descriptor() ([]byte, []int) {
-	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{13}
-}
-
-func (x *SyncInfo) GetQC() *QuorumCert {
-	if x != nil {
-		return x.QC
-	}
-	return nil
+// Deprecated: Use StreamCommand.ProtoReflect.Descriptor instead.
+func (*StreamCommand) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{25}
 }
 
-func (x *SyncInfo) GetTC() *TimeoutCert {
+func (x *StreamCommand) GetLabel() string {
 	if x != nil {
-		return x.TC
+		return x.Label
 	}
-	return nil
+	return ""
 }
 
-func (x *SyncInfo) GetAggQC() *AggQC {
+func (x *StreamCommand) GetCommand() []byte {
 	if x != nil {
-		return x.AggQC
+		return x.Command
 	}
 	return nil
 }
 
-type AggQC struct {
+// StreamBatch contains the commands of several command streams that are proposed in a single block.
+type StreamBatch struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	QCs  map[uint32]*QuorumCert `protobuf:"bytes,1,rep,name=QCs,proto3" json:"QCs,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	Sig  *ThresholdSignature    `protobuf:"bytes,2,opt,name=Sig,proto3" json:"Sig,omitempty"`
-	View uint64                 `protobuf:"varint,3,opt,name=View,proto3" json:"View,omitempty"`
+	Streams []*StreamCommand `protobuf:"bytes,1,rep,name=Streams,proto3" json:"Streams,omitempty"`
 }
 
-func (x *AggQC) Reset() {
-	*x = AggQC{}
+func (x *StreamBatch) Reset() {
+	*x = StreamBatch{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[14]
+		mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[26]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *AggQC) String() string {
+func (x *StreamBatch) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AggQC) ProtoMessage() {}
+func (*StreamBatch) ProtoMessage() {}
 
-func (x *AggQC) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[14]
+func (x *StreamBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[26]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -919,32 +1740,18 @@ func (x *AggQC) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AggQC.ProtoReflect.Descriptor instead.
-func (*AggQC) Descriptor() ([]byte, []int) {
-	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{14}
-}
-
-func (x *AggQC) GetQCs() map[uint32]*QuorumCert {
-	if x != nil {
-		return x.QCs
-	}
-	return nil
+// Deprecated: Use StreamBatch.ProtoReflect.Descriptor instead.
+func (*StreamBatch) Descriptor() ([]byte, []int) {
+	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *AggQC) GetSig() *ThresholdSignature {
+func (x *StreamBatch) GetStreams() []*StreamCommand {
 	if x != nil {
-		return x.Sig
+		return x.Streams
 	}
 	return nil
 }
 
-func (x *AggQC) GetView() uint64 {
-	if x != nil {
-		return x.View
-	}
-	return 0
-}
-
 var File_internal_proto_hotstuffpb_hotstuff_proto protoreflect.FileDescriptor
 
 var file_internal_proto_hotstuffpb_hotstuff_proto_rawDesc = []byte{
@@ -954,137 +1761,271 @@ var file_internal_proto_hotstuffpb_hotstuff_proto_rawDesc = []byte{
 	0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x1a, 0x0c, 0x67, 0x6f, 0x72, 0x75, 0x6d, 0x73, 0x2e, 0x70,
 	0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f,
 	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x22, 0x6b, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x27, 0x0a,
-	0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x68,
-	0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52,
-	0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x2c, 0x0a, 0x05, 0x41, 0x67, 0x67, 0x51, 0x43, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66,
-	0x70, 0x62, 0x2e, 0x41, 0x67, 0x67, 0x51, 0x43, 0x48, 0x00, 0x52, 0x05, 0x41, 0x67, 0x67, 0x51,
-	0x43, 0x88, 0x01, 0x01, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x41, 0x67, 0x67, 0x51, 0x43, 0x22, 0x1f,
-	0x0a, 0x09, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x48,
-	0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x48, 0x61, 0x73, 0x68, 0x22,
-	0x91, 0x01, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x50, 0x61, 0x72,
-	0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x50, 0x61, 0x72, 0x65, 0x6e,
-	0x74, 0x12, 0x26, 0x0a, 0x02, 0x51, 0x43, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
-	0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x51, 0x75, 0x6f, 0x72, 0x75,
-	0x6d, 0x43, 0x65, 0x72, 0x74, 0x52, 0x02, 0x51, 0x43, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65,
-	0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x18, 0x0a,
-	0x07, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07,
-	0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f,
-	0x73, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f,
-	0x73, 0x65, 0x72, 0x22, 0x44, 0x0a, 0x0e, 0x45, 0x43, 0x44, 0x53, 0x41, 0x53, 0x69, 0x67, 0x6e,
-	0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x12, 0x0c, 0x0a,
-	0x01, 0x52, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x52, 0x12, 0x0c, 0x0a, 0x01, 0x53,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x53, 0x22, 0x22, 0x0a, 0x0e, 0x42, 0x4c, 0x53,
-	0x31, 0x32, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x53,
-	0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x53, 0x69, 0x67, 0x22, 0x86, 0x01,
-	0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x38, 0x0a, 0x08, 0x45,
-	0x43, 0x44, 0x53, 0x41, 0x53, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
-	0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x45, 0x43, 0x44, 0x53, 0x41,
-	0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x48, 0x00, 0x52, 0x08, 0x45, 0x43, 0x44,
-	0x53, 0x41, 0x53, 0x69, 0x67, 0x12, 0x38, 0x0a, 0x08, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x53, 0x69,
-	0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
-	0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74,
-	0x75, 0x72, 0x65, 0x48, 0x00, 0x52, 0x08, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x53, 0x69, 0x67, 0x42,
-	0x05, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x22, 0x4a, 0x0a, 0x0b, 0x50, 0x61, 0x72, 0x74, 0x69, 0x61,
-	0x6c, 0x43, 0x65, 0x72, 0x74, 0x12, 0x27, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e,
-	0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x03, 0x53, 0x69, 0x67, 0x12, 0x12,
-	0x0a, 0x04, 0x48, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x48, 0x61,
-	0x73, 0x68, 0x22, 0x49, 0x0a, 0x17, 0x45, 0x43, 0x44, 0x53, 0x41, 0x54, 0x68, 0x72, 0x65, 0x73,
-	0x68, 0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x2e, 0x0a,
-	0x04, 0x53, 0x69, 0x67, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x6f,
-	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x45, 0x43, 0x44, 0x53, 0x41, 0x53, 0x69,
-	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x04, 0x53, 0x69, 0x67, 0x73, 0x22, 0x4f, 0x0a,
-	0x17, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x53,
-	0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x53, 0x69, 0x67, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x61,
-	0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x0c, 0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x73, 0x22, 0xa6,
-	0x01, 0x0a, 0x12, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e,
-	0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x43, 0x0a, 0x09, 0x45, 0x43, 0x44, 0x53, 0x41, 0x53, 0x69,
-	0x67, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74,
-	0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x45, 0x43, 0x44, 0x53, 0x41, 0x54, 0x68, 0x72, 0x65, 0x73,
-	0x68, 0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x48, 0x00, 0x52,
-	0x09, 0x45, 0x43, 0x44, 0x53, 0x41, 0x53, 0x69, 0x67, 0x73, 0x12, 0x41, 0x0a, 0x08, 0x42, 0x4c,
-	0x53, 0x31, 0x32, 0x53, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x68,
-	0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x41,
-	0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
-	0x65, 0x48, 0x00, 0x52, 0x08, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x53, 0x69, 0x67, 0x42, 0x08, 0x0a,
-	0x06, 0x41, 0x67, 0x67, 0x53, 0x69, 0x67, 0x22, 0x66, 0x0a, 0x0a, 0x51, 0x75, 0x6f, 0x72, 0x75,
-	0x6d, 0x43, 0x65, 0x72, 0x74, 0x12, 0x30, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e,
-	0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
-	0x72, 0x65, 0x52, 0x03, 0x53, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x12, 0x0a, 0x04, 0x48,
-	0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x48, 0x61, 0x73, 0x68, 0x22,
-	0x53, 0x0a, 0x0b, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x43, 0x65, 0x72, 0x74, 0x12, 0x30,
-	0x0a, 0x03, 0x53, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x68, 0x6f,
-	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f,
-	0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x03, 0x53, 0x69, 0x67,
-	0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04,
-	0x56, 0x69, 0x65, 0x77, 0x22, 0xc2, 0x01, 0x0a, 0x0a, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
-	0x4d, 0x73, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x6f, 0x22, 0xa1, 0x03, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x12, 0x27,
+	0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x52, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x2c, 0x0a, 0x05, 0x41, 0x67, 0x67, 0x51, 0x43,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66,
+	0x66, 0x70, 0x62, 0x2e, 0x41, 0x67, 0x67, 0x51, 0x43, 0x48, 0x00, 0x52, 0x05, 0x41, 0x67, 0x67,
+	0x51, 0x43, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x07, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x07, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72, 0x73, 0x12,
+	0x3c, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x53, 0x69, 0x67, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70,
+	0x62, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x48, 0x01, 0x52, 0x0b, 0x50,
+	0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x53, 0x69, 0x67, 0x88, 0x01, 0x01, 0x12, 0x41, 0x0a,
+	0x0c, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62,
+	0x2e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x48, 0x02, 0x52,
+	0x0c, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x88, 0x01, 0x01,
+	0x12, 0x3b, 0x0a, 0x0a, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70,
+	0x62, 0x2e, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x48, 0x03, 0x52, 0x0a,
+	0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x88, 0x01, 0x01, 0x12, 0x20, 0x0a,
+	0x0b, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x52, 0x75, 0x6e, 0x18, 0x09, 0x20, 0x03,
+	0x28, 0x0c, 0x52, 0x0b, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x52, 0x75, 0x6e, 0x42,
+	0x08, 0x0a, 0x06, 0x5f, 0x41, 0x67, 0x67, 0x51, 0x43, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x50, 0x72,
+	0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x53, 0x69, 0x67, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x46, 0x69,
+	0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x56,
+	0x69, 0x65, 0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x4a, 0x04, 0x08, 0x06, 0x10, 0x07, 0x4a,
+	0x04, 0x08, 0x07, 0x10, 0x08, 0x22, 0x65, 0x0a, 0x0a, 0x56, 0x69, 0x65, 0x77, 0x43, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x12, 0x27, 0x0a, 0x02, 0x54, 0x43, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x43, 0x65, 0x72, 0x74, 0x52, 0x02, 0x54, 0x43, 0x12, 0x2e, 0x0a, 0x06,
+	0x48, 0x69, 0x67, 0x68, 0x51, 0x43, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68,
+	0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d,
+	0x43, 0x65, 0x72, 0x74, 0x52, 0x06, 0x48, 0x69, 0x67, 0x68, 0x51, 0x43, 0x22, 0x42, 0x0a, 0x0c,
+	0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x4d, 0x73, 0x67, 0x12, 0x12, 0x0a, 0x04,
+	0x56, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x56, 0x69, 0x65, 0x77,
+	0x12, 0x1e, 0x0a, 0x0a, 0x48, 0x69, 0x67, 0x68, 0x51, 0x43, 0x56, 0x69, 0x65, 0x77, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x48, 0x69, 0x67, 0x68, 0x51, 0x43, 0x56, 0x69, 0x65, 0x77,
+	0x22, 0x55, 0x0a, 0x0d, 0x52, 0x65, 0x6c, 0x69, 0x6e, 0x71, 0x75, 0x69, 0x73, 0x68, 0x4d, 0x73,
+	0x67, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x30, 0x0a, 0x08, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66,
+	0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
+	0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x53,
+	0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x37, 0x0a, 0x09, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x48, 0x61, 0x73, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x48, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x48, 0x61, 0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x48, 0x61, 0x73, 0x68,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x06, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73,
+	0x22, 0xa4, 0x01, 0x0a, 0x0e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72,
+	0x6f, 0x6f, 0x66, 0x12, 0x2b, 0x0a, 0x02, 0x51, 0x43, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x51, 0x75, 0x6f,
+	0x72, 0x75, 0x6d, 0x43, 0x65, 0x72, 0x74, 0x48, 0x00, 0x52, 0x02, 0x51, 0x43, 0x88, 0x01, 0x01,
+	0x12, 0x3c, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x53, 0x69, 0x67, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66,
+	0x70, 0x62, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x48, 0x01, 0x52, 0x0b,
+	0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x53, 0x69, 0x67, 0x88, 0x01, 0x01, 0x12, 0x10,
+	0x0a, 0x03, 0x52, 0x75, 0x6e, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x03, 0x52, 0x75, 0x6e,
+	0x42, 0x05, 0x0a, 0x03, 0x5f, 0x51, 0x43, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x50, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x65, 0x72, 0x53, 0x69, 0x67, 0x22, 0xb5, 0x01, 0x0a, 0x0c, 0x46, 0x65, 0x74, 0x63,
+	0x68, 0x65, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x27, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
+	0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x05, 0x42, 0x6c, 0x6f, 0x63,
+	0x6b, 0x12, 0x30, 0x0a, 0x05, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x49, 0x6e,
+	0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x05, 0x50, 0x72,
+	0x6f, 0x6f, 0x66, 0x12, 0x18, 0x0a, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x30, 0x0a,
+	0x06, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e,
+	0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x46, 0x65, 0x74, 0x63, 0x68,
+	0x65, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x06, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22,
+	0x3c, 0x0a, 0x08, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x30, 0x0a, 0x06, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x6f,
+	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x46, 0x65, 0x74, 0x63, 0x68, 0x65, 0x64,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x06, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x97, 0x02,
+	0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x50, 0x61, 0x72, 0x65, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x12,
+	0x26, 0x0a, 0x02, 0x51, 0x43, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68, 0x6f,
+	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x43,
+	0x65, 0x72, 0x74, 0x52, 0x02, 0x51, 0x43, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x18, 0x0a, 0x07, 0x43,
+	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x43, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65,
+	0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65,
+	0x72, 0x12, 0x1e, 0x0a, 0x0a, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x66, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65,
+	0x66, 0x12, 0x20, 0x0a, 0x0b, 0x42, 0x65, 0x61, 0x63, 0x6f, 0x6e, 0x52, 0x6f, 0x75, 0x6e, 0x64,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x42, 0x65, 0x61, 0x63, 0x6f, 0x6e, 0x52, 0x6f,
+	0x75, 0x6e, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x42, 0x65, 0x61, 0x63, 0x6f, 0x6e, 0x56, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x42, 0x65, 0x61, 0x63, 0x6f, 0x6e,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64,
+	0x52, 0x6f, 0x6f, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x43, 0x6f, 0x6d, 0x6d,
+	0x61, 0x6e, 0x64, 0x52, 0x6f, 0x6f, 0x74, 0x22, 0x44, 0x0a, 0x0e, 0x45, 0x43, 0x44, 0x53, 0x41,
+	0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x69, 0x67,
+	0x6e, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x53, 0x69, 0x67, 0x6e, 0x65,
+	0x72, 0x12, 0x0c, 0x0a, 0x01, 0x52, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x52, 0x12,
+	0x0c, 0x0a, 0x01, 0x53, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x01, 0x53, 0x22, 0x22, 0x0a,
+	0x0e, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12,
+	0x10, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x53, 0x69,
+	0x67, 0x22, 0x86, 0x01, 0x0a, 0x09, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12,
+	0x38, 0x0a, 0x08, 0x45, 0x43, 0x44, 0x53, 0x41, 0x53, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x45,
+	0x43, 0x44, 0x53, 0x41, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x48, 0x00, 0x52,
+	0x08, 0x45, 0x43, 0x44, 0x53, 0x41, 0x53, 0x69, 0x67, 0x12, 0x38, 0x0a, 0x08, 0x42, 0x4c, 0x53,
+	0x31, 0x32, 0x53, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x6f,
+	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x53, 0x69,
+	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x48, 0x00, 0x52, 0x08, 0x42, 0x4c, 0x53, 0x31, 0x32,
+	0x53, 0x69, 0x67, 0x42, 0x05, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x22, 0xfb, 0x01, 0x0a, 0x0b, 0x50,
+	0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x65, 0x72, 0x74, 0x12, 0x27, 0x0a, 0x03, 0x53, 0x69,
+	0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
+	0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x03,
+	0x53, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x48, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x04, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6f, 0x6e, 0x67, 0x65,
+	0x73, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x43, 0x6f, 0x6e, 0x67,
+	0x65, 0x73, 0x74, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x3b, 0x0a, 0x0a, 0x43, 0x6f, 0x6d,
+	0x6d, 0x69, 0x74, 0x43, 0x65, 0x72, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x43, 0x65, 0x72, 0x74, 0x48, 0x00, 0x52, 0x0a, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x43,
+	0x65, 0x72, 0x74, 0x88, 0x01, 0x01, 0x12, 0x31, 0x0a, 0x07, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x65,
+	0x64, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
+	0x66, 0x66, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x65, 0x72, 0x74,
+	0x52, 0x07, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x65, 0x64, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x43, 0x6f,
+	0x6d, 0x6d, 0x69, 0x74, 0x43, 0x65, 0x72, 0x74, 0x22, 0x6d, 0x0a, 0x0a, 0x43, 0x6f, 0x6d, 0x6d,
+	0x69, 0x74, 0x43, 0x65, 0x72, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x02, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x12, 0x0a, 0x04, 0x48, 0x61,
+	0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x48, 0x61, 0x73, 0x68, 0x12, 0x27,
+	0x0a, 0x03, 0x53, 0x69, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x6f,
+	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x52, 0x03, 0x53, 0x69, 0x67, 0x22, 0x48, 0x0a, 0x0c, 0x46, 0x69, 0x6e, 0x61, 0x6c,
+	0x69, 0x74, 0x79, 0x43, 0x65, 0x72, 0x74, 0x12, 0x38, 0x0a, 0x0b, 0x43, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x43, 0x65, 0x72, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68,
+	0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x43, 0x65, 0x72, 0x74, 0x52, 0x0b, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x43, 0x65, 0x72, 0x74,
+	0x73, 0x22, 0x49, 0x0a, 0x17, 0x45, 0x43, 0x44, 0x53, 0x41, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68,
+	0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x2e, 0x0a, 0x04,
+	0x53, 0x69, 0x67, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x6f, 0x74,
+	0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x45, 0x43, 0x44, 0x53, 0x41, 0x53, 0x69, 0x67,
+	0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x04, 0x53, 0x69, 0x67, 0x73, 0x22, 0x4f, 0x0a, 0x17,
+	0x42, 0x4c, 0x53, 0x31, 0x32, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x53, 0x69,
+	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x53, 0x69, 0x67, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x61, 0x72,
+	0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0c, 0x70, 0x61, 0x72, 0x74, 0x69, 0x63, 0x69, 0x70, 0x61, 0x6e, 0x74, 0x73, 0x22, 0xa6, 0x01,
+	0x0a, 0x12, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x12, 0x43, 0x0a, 0x09, 0x45, 0x43, 0x44, 0x53, 0x41, 0x53, 0x69, 0x67,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
+	0x66, 0x66, 0x70, 0x62, 0x2e, 0x45, 0x43, 0x44, 0x53, 0x41, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68,
+	0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x48, 0x00, 0x52, 0x09,
+	0x45, 0x43, 0x44, 0x53, 0x41, 0x53, 0x69, 0x67, 0x73, 0x12, 0x41, 0x0a, 0x08, 0x42, 0x4c, 0x53,
+	0x31, 0x32, 0x53, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x68, 0x6f,
+	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x41, 0x67,
+	0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x48, 0x00, 0x52, 0x08, 0x42, 0x4c, 0x53, 0x31, 0x32, 0x53, 0x69, 0x67, 0x42, 0x08, 0x0a, 0x06,
+	0x41, 0x67, 0x67, 0x53, 0x69, 0x67, 0x22, 0x66, 0x0a, 0x0a, 0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d,
+	0x43, 0x65, 0x72, 0x74, 0x12, 0x30, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1e, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x54,
+	0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x52, 0x03, 0x53, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x12, 0x0a, 0x04, 0x48, 0x61,
+	0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x48, 0x61, 0x73, 0x68, 0x22, 0x53,
+	0x0a, 0x0b, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x43, 0x65, 0x72, 0x74, 0x12, 0x30, 0x0a,
+	0x03, 0x53, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x68, 0x6f, 0x74,
+	0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c,
+	0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x03, 0x53, 0x69, 0x67, 0x12,
+	0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x56,
+	0x69, 0x65, 0x77, 0x22, 0xc2, 0x01, 0x0a, 0x0a, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d,
+	0x73, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x30, 0x0a, 0x08, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e,
+	0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74,
+	0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08,
+	0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2f, 0x0a, 0x07, 0x56, 0x69, 0x65, 0x77,
+	0x53, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x6f, 0x74, 0x73,
+	0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x52, 0x07, 0x56, 0x69, 0x65, 0x77, 0x53, 0x69, 0x67, 0x12, 0x32, 0x0a, 0x06, 0x4d, 0x73, 0x67,
+	0x53, 0x69, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x6f, 0x74, 0x73,
+	0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x48, 0x00, 0x52, 0x06, 0x4d, 0x73, 0x67, 0x53, 0x69, 0x67, 0x88, 0x01, 0x01, 0x42, 0x09, 0x0a,
+	0x07, 0x5f, 0x4d, 0x73, 0x67, 0x53, 0x69, 0x67, 0x22, 0xab, 0x01, 0x0a, 0x08, 0x53, 0x79, 0x6e,
+	0x63, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2b, 0x0a, 0x02, 0x51, 0x43, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x51,
+	0x75, 0x6f, 0x72, 0x75, 0x6d, 0x43, 0x65, 0x72, 0x74, 0x48, 0x00, 0x52, 0x02, 0x51, 0x43, 0x88,
+	0x01, 0x01, 0x12, 0x2c, 0x0a, 0x02, 0x54, 0x43, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x43, 0x65, 0x72, 0x74, 0x48, 0x01, 0x52, 0x02, 0x54, 0x43, 0x88, 0x01, 0x01,
+	0x12, 0x2c, 0x0a, 0x05, 0x41, 0x67, 0x67, 0x51, 0x43, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x11, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x41, 0x67, 0x67,
+	0x51, 0x43, 0x48, 0x02, 0x52, 0x05, 0x41, 0x67, 0x67, 0x51, 0x43, 0x88, 0x01, 0x01, 0x42, 0x05,
+	0x0a, 0x03, 0x5f, 0x51, 0x43, 0x42, 0x05, 0x0a, 0x03, 0x5f, 0x54, 0x43, 0x42, 0x08, 0x0a, 0x06,
+	0x5f, 0x41, 0x67, 0x67, 0x51, 0x43, 0x22, 0x51, 0x0a, 0x09, 0x53, 0x79, 0x6e, 0x63, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x04, 0x52, 0x04, 0x56, 0x69, 0x65, 0x77, 0x12, 0x30, 0x0a, 0x08, 0x53, 0x79, 0x6e, 0x63, 0x49,
 	0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x68, 0x6f, 0x74, 0x73,
 	0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x52,
-	0x08, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2f, 0x0a, 0x07, 0x56, 0x69, 0x65,
-	0x77, 0x53, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x6f, 0x74,
-	0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
-	0x65, 0x52, 0x07, 0x56, 0x69, 0x65, 0x77, 0x53, 0x69, 0x67, 0x12, 0x32, 0x0a, 0x06, 0x4d, 0x73,
-	0x67, 0x53, 0x69, 0x67, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x68, 0x6f, 0x74,
-	0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
-	0x65, 0x48, 0x00, 0x52, 0x06, 0x4d, 0x73, 0x67, 0x53, 0x69, 0x67, 0x88, 0x01, 0x01, 0x42, 0x09,
-	0x0a, 0x07, 0x5f, 0x4d, 0x73, 0x67, 0x53, 0x69, 0x67, 0x22, 0xab, 0x01, 0x0a, 0x08, 0x53, 0x79,
-	0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2b, 0x0a, 0x02, 0x51, 0x43, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e,
-	0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x43, 0x65, 0x72, 0x74, 0x48, 0x00, 0x52, 0x02, 0x51, 0x43,
-	0x88, 0x01, 0x01, 0x12, 0x2c, 0x0a, 0x02, 0x54, 0x43, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x17, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x54, 0x69, 0x6d,
-	0x65, 0x6f, 0x75, 0x74, 0x43, 0x65, 0x72, 0x74, 0x48, 0x01, 0x52, 0x02, 0x54, 0x43, 0x88, 0x01,
-	0x01, 0x12, 0x2c, 0x0a, 0x05, 0x41, 0x67, 0x67, 0x51, 0x43, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x11, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x41, 0x67,
-	0x67, 0x51, 0x43, 0x48, 0x02, 0x52, 0x05, 0x41, 0x67, 0x67, 0x51, 0x43, 0x88, 0x01, 0x01, 0x42,
-	0x05, 0x0a, 0x03, 0x5f, 0x51, 0x43, 0x42, 0x05, 0x0a, 0x03, 0x5f, 0x54, 0x43, 0x42, 0x08, 0x0a,
-	0x06, 0x5f, 0x41, 0x67, 0x67, 0x51, 0x43, 0x22, 0xcb, 0x01, 0x0a, 0x05, 0x41, 0x67, 0x67, 0x51,
-	0x43, 0x12, 0x2c, 0x0a, 0x03, 0x51, 0x43, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a,
-	0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x41, 0x67, 0x67, 0x51,
-	0x43, 0x2e, 0x51, 0x43, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x03, 0x51, 0x43, 0x73, 0x12,
-	0x30, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x68,
-	0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68,
-	0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x03, 0x53, 0x69,
-	0x67, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52,
-	0x04, 0x56, 0x69, 0x65, 0x77, 0x1a, 0x4e, 0x0a, 0x08, 0x51, 0x43, 0x73, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x2c, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e,
-	0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x43, 0x65, 0x72, 0x74, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
-	0x65, 0x3a, 0x02, 0x38, 0x01, 0x32, 0xc1, 0x02, 0x0a, 0x08, 0x48, 0x6f, 0x74, 0x73, 0x74, 0x75,
-	0x66, 0x66, 0x12, 0x3d, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x12, 0x14, 0x2e,
-	0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f,
-	0x73, 0x61, 0x6c, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x98, 0xb5, 0x18,
-	0x01, 0x12, 0x3d, 0x0a, 0x04, 0x56, 0x6f, 0x74, 0x65, 0x12, 0x17, 0x2e, 0x68, 0x6f, 0x74, 0x73,
-	0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x65,
-	0x72, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x90, 0xb5, 0x18, 0x01,
-	0x12, 0x3f, 0x0a, 0x07, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x16, 0x2e, 0x68, 0x6f,
-	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
-	0x4d, 0x73, 0x67, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x98, 0xb5, 0x18,
-	0x01, 0x12, 0x3d, 0x0a, 0x07, 0x4e, 0x65, 0x77, 0x56, 0x69, 0x65, 0x77, 0x12, 0x14, 0x2e, 0x68,
-	0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e,
-	0x66, 0x6f, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x90, 0xb5, 0x18, 0x01,
-	0x12, 0x37, 0x0a, 0x05, 0x46, 0x65, 0x74, 0x63, 0x68, 0x12, 0x15, 0x2e, 0x68, 0x6f, 0x74, 0x73,
-	0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68,
-	0x1a, 0x11, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x6c,
-	0x6f, 0x63, 0x6b, 0x22, 0x04, 0xa0, 0xb5, 0x18, 0x01, 0x42, 0x35, 0x5a, 0x33, 0x67, 0x69, 0x74,
-	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x72, 0x65, 0x6c, 0x61, 0x62, 0x2f, 0x68, 0x6f,
-	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x08, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x94, 0x01, 0x0a, 0x0b, 0x53, 0x61,
+	0x66, 0x65, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x4c, 0x61, 0x73,
+	0x74, 0x56, 0x6f, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x4c, 0x61, 0x73,
+	0x74, 0x56, 0x6f, 0x74, 0x65, 0x12, 0x2e, 0x0a, 0x06, 0x4c, 0x6f, 0x63, 0x6b, 0x65, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66,
+	0x70, 0x62, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x00, 0x52, 0x06, 0x4c, 0x6f, 0x63, 0x6b,
+	0x65, 0x64, 0x88, 0x01, 0x01, 0x12, 0x2e, 0x0a, 0x06, 0x48, 0x69, 0x67, 0x68, 0x51, 0x43, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66,
+	0x70, 0x62, 0x2e, 0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x43, 0x65, 0x72, 0x74, 0x52, 0x06, 0x48,
+	0x69, 0x67, 0x68, 0x51, 0x43, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x4c, 0x6f, 0x63, 0x6b, 0x65, 0x64,
+	0x22, 0xcb, 0x01, 0x0a, 0x05, 0x41, 0x67, 0x67, 0x51, 0x43, 0x12, 0x2c, 0x0a, 0x03, 0x51, 0x43,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
+	0x66, 0x66, 0x70, 0x62, 0x2e, 0x41, 0x67, 0x67, 0x51, 0x43, 0x2e, 0x51, 0x43, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x03, 0x51, 0x43, 0x73, 0x12, 0x30, 0x0a, 0x03, 0x53, 0x69, 0x67, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66,
+	0x70, 0x62, 0x2e, 0x54, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x53, 0x69, 0x67, 0x6e,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x03, 0x53, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x56, 0x69,
+	0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x56, 0x69, 0x65, 0x77, 0x1a, 0x4e,
+	0x0a, 0x08, 0x51, 0x43, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2c, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68, 0x6f,
+	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x43,
+	0x65, 0x72, 0x74, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x3f,
+	0x0a, 0x0d, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x4c, 0x61, 0x62, 0x65, 0x6c, 0x12, 0x18, 0x0a, 0x07, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x22,
+	0x42, 0x0a, 0x0b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x33,
+	0x0a, 0x07, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x74, 0x72,
+	0x65, 0x61, 0x6d, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x07, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x73, 0x32, 0xd9, 0x04, 0x0a, 0x08, 0x48, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66,
+	0x12, 0x3d, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x12, 0x14, 0x2e, 0x68, 0x6f,
+	0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61,
+	0x6c, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x98, 0xb5, 0x18, 0x01, 0x12,
+	0x3d, 0x0a, 0x04, 0x56, 0x6f, 0x74, 0x65, 0x12, 0x17, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
+	0x66, 0x66, 0x70, 0x62, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x65, 0x72, 0x74,
+	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x90, 0xb5, 0x18, 0x01, 0x12, 0x3f,
+	0x0a, 0x07, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x16, 0x2e, 0x68, 0x6f, 0x74, 0x73,
+	0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73,
+	0x67, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x98, 0xb5, 0x18, 0x01, 0x12,
+	0x3d, 0x0a, 0x07, 0x4e, 0x65, 0x77, 0x56, 0x69, 0x65, 0x77, 0x12, 0x14, 0x2e, 0x68, 0x6f, 0x74,
+	0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x49, 0x6e, 0x66, 0x6f,
+	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x90, 0xb5, 0x18, 0x01, 0x12, 0x3e,
+	0x0a, 0x05, 0x46, 0x65, 0x74, 0x63, 0x68, 0x12, 0x15, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
+	0x66, 0x66, 0x70, 0x62, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x1a, 0x18,
+	0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x46, 0x65, 0x74, 0x63,
+	0x68, 0x65, 0x64, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x04, 0xa0, 0xb5, 0x18, 0x01, 0x12, 0x43,
+	0x0a, 0x0d, 0x46, 0x65, 0x74, 0x63, 0x68, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x14, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75,
+	0x66, 0x66, 0x70, 0x62, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x22, 0x04, 0xa0,
+	0xb5, 0x18, 0x01, 0x12, 0x3e, 0x0a, 0x06, 0x44, 0x65, 0x63, 0x69, 0x64, 0x65, 0x12, 0x16, 0x2e,
+	0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x51, 0x75, 0x6f, 0x72, 0x75,
+	0x6d, 0x43, 0x65, 0x72, 0x74, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x98,
+	0xb5, 0x18, 0x01, 0x12, 0x43, 0x0a, 0x09, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74,
+	0x12, 0x18, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x2e, 0x48, 0x65,
+	0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x4d, 0x73, 0x67, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x22, 0x04, 0x98, 0xb5, 0x18, 0x01, 0x12, 0x45, 0x0a, 0x0a, 0x52, 0x65, 0x6c, 0x69,
+	0x6e, 0x71, 0x75, 0x69, 0x73, 0x68, 0x12, 0x19, 0x2e, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66,
+	0x66, 0x70, 0x62, 0x2e, 0x52, 0x65, 0x6c, 0x69, 0x6e, 0x71, 0x75, 0x69, 0x73, 0x68, 0x4d, 0x73,
+	0x67, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x04, 0x98, 0xb5, 0x18, 0x01, 0x42,
+	0x35, 0x5a, 0x33, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x72, 0x65,
+	0x6c, 0x61, 0x62, 0x2f, 0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x2f, 0x69, 0x6e, 0x74,
+	0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x68, 0x6f, 0x74, 0x73,
+	0x74, 0x75, 0x66, 0x66, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1099,62 +2040,102 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_rawDescGZIP() []byte {
 	return file_internal_proto_hotstuffpb_hotstuff_proto_rawDescData
 }
 
-var file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
 var file_internal_proto_hotstuffpb_hotstuff_proto_goTypes = []interface{}{
 	(*Proposal)(nil),                // 0: hotstuffpb.Proposal
-	(*BlockHash)(nil),               // 1: hotstuffpb.BlockHash
-	(*Block)(nil),                   // 2: hotstuffpb.Block
-	(*ECDSASignature)(nil),          // 3: hotstuffpb.ECDSASignature
-	(*BLS12Signature)(nil),          // 4: hotstuffpb.BLS12Signature
-	(*Signature)(nil),               // 5: hotstuffpb.Signature
-	(*PartialCert)(nil),             // 6: hotstuffpb.PartialCert
-	(*ECDSAThresholdSignature)(nil), // 7: hotstuffpb.ECDSAThresholdSignature
-	(*BLS12AggregateSignature)(nil), // 8: hotstuffpb.BLS12AggregateSignature
-	(*ThresholdSignature)(nil),      // 9: hotstuffpb.ThresholdSignature
-	(*QuorumCert)(nil),              // 10: hotstuffpb.QuorumCert
-	(*TimeoutCert)(nil),             // 11: hotstuffpb.TimeoutCert
-	(*TimeoutMsg)(nil),              // 12: hotstuffpb.TimeoutMsg
-	(*SyncInfo)(nil),                // 13: hotstuffpb.SyncInfo
-	(*AggQC)(nil),                   // 14: hotstuffpb.AggQC
-	nil,                             // 15: hotstuffpb.AggQC.QCsEntry
-	(*emptypb.Empty)(nil),           // 16: google.protobuf.Empty
+	(*ViewChange)(nil),              // 1: hotstuffpb.ViewChange
+	(*HeartbeatMsg)(nil),            // 2: hotstuffpb.HeartbeatMsg
+	(*RelinquishMsg)(nil),           // 3: hotstuffpb.RelinquishMsg
+	(*BlockHash)(nil),               // 4: hotstuffpb.BlockHash
+	(*InclusionProof)(nil),          // 5: hotstuffpb.InclusionProof
+	(*FetchedBlock)(nil),            // 6: hotstuffpb.FetchedBlock
+	(*Snapshot)(nil),                // 7: hotstuffpb.Snapshot
+	(*Block)(nil),                   // 8: hotstuffpb.Block
+	(*ECDSASignature)(nil),          // 9: hotstuffpb.ECDSASignature
+	(*BLS12Signature)(nil),          // 10: hotstuffpb.BLS12Signature
+	(*Signature)(nil),               // 11: hotstuffpb.Signature
+	(*PartialCert)(nil),             // 12: hotstuffpb.PartialCert
+	(*CommitCert)(nil),              // 13: hotstuffpb.CommitCert
+	(*FinalityCert)(nil),            // 14: hotstuffpb.FinalityCert
+	(*ECDSAThresholdSignature)(nil), // 15: hotstuffpb.ECDSAThresholdSignature
+	(*BLS12AggregateSignature)(nil), // 16: hotstuffpb.BLS12AggregateSignature
+	(*ThresholdSignature)(nil),      // 17: hotstuffpb.ThresholdSignature
+	(*QuorumCert)(nil),              // 18: hotstuffpb.QuorumCert
+	(*TimeoutCert)(nil),             // 19: hotstuffpb.TimeoutCert
+	(*TimeoutMsg)(nil),              // 20: hotstuffpb.TimeoutMsg
+	(*SyncInfo)(nil),                // 21: hotstuffpb.SyncInfo
+	(*SyncState)(nil),               // 22: hotstuffpb.SyncState
+	(*SafetyState)(nil),             // 23: hotstuffpb.SafetyState
+	(*AggQC)(nil),                   // 24: hotstuffpb.AggQC
+	(*StreamCommand)(nil),           // 25: hotstuffpb.StreamCommand
+	(*StreamBatch)(nil),             // 26: hotstuffpb.StreamBatch
+	nil,                             // 27: hotstuffpb.AggQC.QCsEntry
+	(*emptypb.Empty)(nil),           // 28: google.protobuf.Empty
 }
 var file_internal_proto_hotstuffpb_hotstuff_proto_depIdxs = []int32{
-	2,  // 0: hotstuffpb.Proposal.Block:type_name -> hotstuffpb.Block
-	14, // 1: hotstuffpb.Proposal.AggQC:type_name -> hotstuffpb.AggQC
-	10, // 2: hotstuffpb.Block.QC:type_name -> hotstuffpb.QuorumCert
-	3,  // 3: hotstuffpb.Signature.ECDSASig:type_name -> hotstuffpb.ECDSASignature
-	4,  // 4: hotstuffpb.Signature.BLS12Sig:type_name -> hotstuffpb.BLS12Signature
-	5,  // 5: hotstuffpb.PartialCert.Sig:type_name -> hotstuffpb.Signature
-	3,  // 6: hotstuffpb.ECDSAThresholdSignature.Sigs:type_name -> hotstuffpb.ECDSASignature
-	7,  // 7: hotstuffpb.ThresholdSignature.ECDSASigs:type_name -> hotstuffpb.ECDSAThresholdSignature
-	8,  // 8: hotstuffpb.ThresholdSignature.BLS12Sig:type_name -> hotstuffpb.BLS12AggregateSignature
-	9,  // 9: hotstuffpb.QuorumCert.Sig:type_name -> hotstuffpb.ThresholdSignature
-	9,  // 10: hotstuffpb.TimeoutCert.Sig:type_name -> hotstuffpb.ThresholdSignature
-	13, // 11: hotstuffpb.TimeoutMsg.SyncInfo:type_name -> hotstuffpb.SyncInfo
-	5,  // 12: hotstuffpb.TimeoutMsg.ViewSig:type_name -> hotstuffpb.Signature
-	5,  // 13: hotstuffpb.TimeoutMsg.MsgSig:type_name -> hotstuffpb.Signature
-	10, // 14: hotstuffpb.SyncInfo.QC:type_name -> hotstuffpb.QuorumCert
-	11, // 15: hotstuffpb.SyncInfo.TC:type_name -> hotstuffpb.TimeoutCert
-	14, // 16: hotstuffpb.SyncInfo.AggQC:type_name -> hotstuffpb.AggQC
-	15, // 17: hotstuffpb.AggQC.QCs:type_name -> hotstuffpb.AggQC.QCsEntry
-	9,  // 18: hotstuffpb.AggQC.Sig:type_name -> hotstuffpb.ThresholdSignature
-	10, // 19: hotstuffpb.AggQC.QCsEntry.value:type_name -> hotstuffpb.QuorumCert
-	0,  // 20: hotstuffpb.Hotstuff.Propose:input_type -> hotstuffpb.Proposal
-	6,  // 21: hotstuffpb.Hotstuff.Vote:input_type -> hotstuffpb.PartialCert
-	12, // 22: hotstuffpb.Hotstuff.Timeout:input_type -> hotstuffpb.TimeoutMsg
-	13, // 23: hotstuffpb.Hotstuff.NewView:input_type -> hotstuffpb.SyncInfo
-	1,  // 24: hotstuffpb.Hotstuff.Fetch:input_type -> hotstuffpb.BlockHash
-	16, // 25: hotstuffpb.Hotstuff.Propose:output_type -> google.protobuf.Empty
-	16, // 26: hotstuffpb.Hotstuff.Vote:output_type -> google.protobuf.Empty
-	16, // 27: hotstuffpb.Hotstuff.Timeout:output_type -> google.protobuf.Empty
-	16, // 28: hotstuffpb.Hotstuff.NewView:output_type -> google.protobuf.Empty
-	2,  // 29: hotstuffpb.Hotstuff.Fetch:output_type -> hotstuffpb.Block
-	25, // [25:30] is the sub-list for method output_type
-	20, // [20:25] is the sub-list for method input_type
-	20, // [20:20] is the sub-list for extension type_name
-	20, // [20:20] is the sub-list for extension extendee
-	0,  // [0:20] is the sub-list for field type_name
+	8,  // 0: hotstuffpb.Proposal.Block:type_name -> hotstuffpb.Block
+	24, // 1: hotstuffpb.Proposal.AggQC:type_name -> hotstuffpb.AggQC
+	11, // 2: hotstuffpb.Proposal.ProposerSig:type_name -> hotstuffpb.Signature
+	14, // 3: hotstuffpb.Proposal.FinalityCert:type_name -> hotstuffpb.FinalityCert
+	1,  // 4: hotstuffpb.Proposal.ViewChange:type_name -> hotstuffpb.ViewChange
+	19, // 5: hotstuffpb.ViewChange.TC:type_name -> hotstuffpb.TimeoutCert
+	18, // 6: hotstuffpb.ViewChange.HighQC:type_name -> hotstuffpb.QuorumCert
+	21, // 7: hotstuffpb.RelinquishMsg.SyncInfo:type_name -> hotstuffpb.SyncInfo
+	18, // 8: hotstuffpb.InclusionProof.QC:type_name -> hotstuffpb.QuorumCert
+	11, // 9: hotstuffpb.InclusionProof.ProposerSig:type_name -> hotstuffpb.Signature
+	8,  // 10: hotstuffpb.FetchedBlock.Block:type_name -> hotstuffpb.Block
+	5,  // 11: hotstuffpb.FetchedBlock.Proof:type_name -> hotstuffpb.InclusionProof
+	6,  // 12: hotstuffpb.FetchedBlock.Blocks:type_name -> hotstuffpb.FetchedBlock
+	6,  // 13: hotstuffpb.Snapshot.Blocks:type_name -> hotstuffpb.FetchedBlock
+	18, // 14: hotstuffpb.Block.QC:type_name -> hotstuffpb.QuorumCert
+	9,  // 15: hotstuffpb.Signature.ECDSASig:type_name -> hotstuffpb.ECDSASignature
+	10, // 16: hotstuffpb.Signature.BLS12Sig:type_name -> hotstuffpb.BLS12Signature
+	11, // 17: hotstuffpb.PartialCert.Sig:type_name -> hotstuffpb.Signature
+	13, // 18: hotstuffpb.PartialCert.CommitCert:type_name -> hotstuffpb.CommitCert
+	12, // 19: hotstuffpb.PartialCert.Relayed:type_name -> hotstuffpb.PartialCert
+	11, // 20: hotstuffpb.CommitCert.Sig:type_name -> hotstuffpb.Signature
+	13, // 21: hotstuffpb.FinalityCert.CommitCerts:type_name -> hotstuffpb.CommitCert
+	9,  // 22: hotstuffpb.ECDSAThresholdSignature.Sigs:type_name -> hotstuffpb.ECDSASignature
+	15, // 23: hotstuffpb.ThresholdSignature.ECDSASigs:type_name -> hotstuffpb.ECDSAThresholdSignature
+	16, // 24: hotstuffpb.ThresholdSignature.BLS12Sig:type_name -> hotstuffpb.BLS12AggregateSignature
+	17, // 25: hotstuffpb.QuorumCert.Sig:type_name -> hotstuffpb.ThresholdSignature
+	17, // 26: hotstuffpb.TimeoutCert.Sig:type_name -> hotstuffpb.ThresholdSignature
+	21, // 27: hotstuffpb.TimeoutMsg.SyncInfo:type_name -> hotstuffpb.SyncInfo
+	11, // 28: hotstuffpb.TimeoutMsg.ViewSig:type_name -> hotstuffpb.Signature
+	11, // 29: hotstuffpb.TimeoutMsg.MsgSig:type_name -> hotstuffpb.Signature
+	18, // 30: hotstuffpb.SyncInfo.QC:type_name -> hotstuffpb.QuorumCert
+	19, // 31: hotstuffpb.SyncInfo.TC:type_name -> hotstuffpb.TimeoutCert
+	24, // 32: hotstuffpb.SyncInfo.AggQC:type_name -> hotstuffpb.AggQC
+	21, // 33: hotstuffpb.SyncState.SyncInfo:type_name -> hotstuffpb.SyncInfo
+	8,  // 34: hotstuffpb.SafetyState.Locked:type_name -> hotstuffpb.Block
+	18, // 35: hotstuffpb.SafetyState.HighQC:type_name -> hotstuffpb.QuorumCert
+	27, // 36: hotstuffpb.AggQC.QCs:type_name -> hotstuffpb.AggQC.QCsEntry
+	17, // 37: hotstuffpb.AggQC.Sig:type_name -> hotstuffpb.ThresholdSignature
+	25, // 38: hotstuffpb.StreamBatch.Streams:type_name -> hotstuffpb.StreamCommand
+	18, // 39: hotstuffpb.AggQC.QCsEntry.value:type_name -> hotstuffpb.QuorumCert
+	0,  // 40: hotstuffpb.Hotstuff.Propose:input_type -> hotstuffpb.Proposal
+	12, // 41: hotstuffpb.Hotstuff.Vote:input_type -> hotstuffpb.PartialCert
+	20, // 42: hotstuffpb.Hotstuff.Timeout:input_type -> hotstuffpb.TimeoutMsg
+	21, // 43: hotstuffpb.Hotstuff.NewView:input_type -> hotstuffpb.SyncInfo
+	4,  // 44: hotstuffpb.Hotstuff.Fetch:input_type -> hotstuffpb.BlockHash
+	28, // 45: hotstuffpb.Hotstuff.FetchSnapshot:input_type -> google.protobuf.Empty
+	18, // 46: hotstuffpb.Hotstuff.Decide:input_type -> hotstuffpb.QuorumCert
+	2,  // 47: hotstuffpb.Hotstuff.Heartbeat:input_type -> hotstuffpb.HeartbeatMsg
+	3,  // 48: hotstuffpb.Hotstuff.Relinquish:input_type -> hotstuffpb.RelinquishMsg
+	28, // 49: hotstuffpb.Hotstuff.Propose:output_type -> google.protobuf.Empty
+	28, // 50: hotstuffpb.Hotstuff.Vote:output_type -> google.protobuf.Empty
+	28, // 51: hotstuffpb.Hotstuff.Timeout:output_type -> google.protobuf.Empty
+	28, // 52: hotstuffpb.Hotstuff.NewView:output_type -> google.protobuf.Empty
+	6,  // 53: hotstuffpb.Hotstuff.Fetch:output_type -> hotstuffpb.FetchedBlock
+	7,  // 54: hotstuffpb.Hotstuff.FetchSnapshot:output_type -> hotstuffpb.Snapshot
+	28, // 55: hotstuffpb.Hotstuff.Decide:output_type -> google.protobuf.Empty
+	28, // 56: hotstuffpb.Hotstuff.Heartbeat:output_type -> google.protobuf.Empty
+	28, // 57: hotstuffpb.Hotstuff.Relinquish:output_type -> google.protobuf.Empty
+	49, // [49:58] is the sub-list for method output_type
+	40, // [40:49] is the sub-list for method input_type
+	40, // [40:40] is the sub-list for extension type_name
+	40, // [40:40] is the sub-list for extension extendee
+	0,  // [0:40] is the sub-list for field type_name
 }
 
 func init() { file_internal_proto_hotstuffpb_hotstuff_proto_init() }
@@ -1176,7 +2157,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BlockHash); i {
+			switch v := v.(*ViewChange); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1188,7 +2169,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Block); i {
+			switch v := v.(*HeartbeatMsg); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1200,7 +2181,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ECDSASignature); i {
+			switch v := v.(*RelinquishMsg); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1212,7 +2193,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BLS12Signature); i {
+			switch v := v.(*BlockHash); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1224,7 +2205,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Signature); i {
+			switch v := v.(*InclusionProof); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1236,7 +2217,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PartialCert); i {
+			switch v := v.(*FetchedBlock); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1248,7 +2229,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ECDSAThresholdSignature); i {
+			switch v := v.(*Snapshot); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1260,7 +2241,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BLS12AggregateSignature); i {
+			switch v := v.(*Block); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1272,7 +2253,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ThresholdSignature); i {
+			switch v := v.(*ECDSASignature); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1284,7 +2265,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*QuorumCert); i {
+			switch v := v.(*BLS12Signature); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1296,7 +2277,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TimeoutCert); i {
+			switch v := v.(*Signature); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1308,7 +2289,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TimeoutMsg); i {
+			switch v := v.(*PartialCert); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1320,7 +2301,7 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SyncInfo); i {
+			switch v := v.(*CommitCert); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1332,6 +2313,126 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 			}
 		}
 		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FinalityCert); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ECDSAThresholdSignature); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BLS12AggregateSignature); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ThresholdSignature); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*QuorumCert); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TimeoutCert); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TimeoutMsg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SyncInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SyncState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SafetyState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*AggQC); i {
 			case 0:
 				return &v.state
@@ -1343,25 +2444,52 @@ func file_internal_proto_hotstuffpb_hotstuff_proto_init() {
 				return nil
 			}
 		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamCommand); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamBatch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[0].OneofWrappers = []interface{}{}
-	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[5].OneofWrappers = []interface{}{
+	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[5].OneofWrappers = []interface{}{}
+	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[11].OneofWrappers = []interface{}{
 		(*Signature_ECDSASig)(nil),
 		(*Signature_BLS12Sig)(nil),
 	}
-	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[9].OneofWrappers = []interface{}{
+	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[12].OneofWrappers = []interface{}{}
+	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[17].OneofWrappers = []interface{}{
 		(*ThresholdSignature_ECDSASigs)(nil),
 		(*ThresholdSignature_BLS12Sig)(nil),
 	}
-	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[12].OneofWrappers = []interface{}{}
-	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[13].OneofWrappers = []interface{}{}
+	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[20].OneofWrappers = []interface{}{}
+	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[21].OneofWrappers = []interface{}{}
+	file_internal_proto_hotstuffpb_hotstuff_proto_msgTypes[23].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_internal_proto_hotstuffpb_hotstuff_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   16,
+			NumMessages:   28,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -148,6 +148,45 @@ func (c *Configuration) Timeout(ctx context.Context, in *TimeoutMsg, opts ...gor
 	c.Configuration.Multicast(ctx, cd, opts...)
 }
 
+// Reference imports to suppress errors if they are not otherwise used.
+var _ emptypb.Empty
+
+// Decide sends the decision of the leader in the ExplicitDecision commit mode.
+func (c *Configuration) Decide(ctx context.Context, in *QuorumCert, opts ...gorums.CallOption) {
+	cd := gorums.QuorumCallData{
+		Message: in,
+		Method:  "hotstuffpb.Hotstuff.Decide",
+	}
+
+	c.Configuration.Multicast(ctx, cd, opts...)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ emptypb.Empty
+
+// Heartbeat reports the liveness and progress of the sender.
+func (c *Configuration) Heartbeat(ctx context.Context, in *HeartbeatMsg, opts ...gorums.CallOption) {
+	cd := gorums.QuorumCallData{
+		Message: in,
+		Method:  "hotstuffpb.Hotstuff.Heartbeat",
+	}
+
+	c.Configuration.Multicast(ctx, cd, opts...)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ emptypb.Empty
+
+// Relinquish tells the other replicas that the leader of a view steps down.
+func (c *Configuration) Relinquish(ctx context.Context, in *RelinquishMsg, opts ...gorums.CallOption) {
+	cd := gorums.QuorumCallData{
+		Message: in,
+		Method:  "hotstuffpb.Hotstuff.Relinquish",
+	}
+
+	c.Configuration.Multicast(ctx, cd, opts...)
+}
+
 // QuorumSpec is the interface of quorum functions for Hotstuff.
 type QuorumSpec interface {
 	gorums.ConfigOption
@@ -157,20 +196,27 @@ type QuorumSpec interface {
 	// supplied to the Fetch method at call time, and may or may not
 	// be used by the quorum function. If the in parameter is not needed
 	// you should implement your quorum function with '_ *BlockHash'.
-	FetchQF(in *BlockHash, replies map[uint32]*Block) (*Block, bool)
+	FetchQF(in *BlockHash, replies map[uint32]*FetchedBlock) (*FetchedBlock, bool)
+
+	// FetchSnapshotQF is the quorum function for the FetchSnapshot
+	// quorum call method. The in parameter is the request object
+	// supplied to the FetchSnapshot method at call time, and may or may not
+	// be used by the quorum function. If the in parameter is not needed
+	// you should implement your quorum function with '_ *emptypb.Empty'.
+	FetchSnapshotQF(in *emptypb.Empty, replies map[uint32]*Snapshot) (*Snapshot, bool)
 }
 
 // Fetch is a quorum call invoked on all nodes in configuration c,
 // with the same argument in, and returns a combined result.
-func (c *Configuration) Fetch(ctx context.Context, in *BlockHash) (resp *Block, err error) {
+func (c *Configuration) Fetch(ctx context.Context, in *BlockHash) (resp *FetchedBlock, err error) {
 	cd := gorums.QuorumCallData{
 		Message: in,
 		Method:  "hotstuffpb.Hotstuff.Fetch",
 	}
 	cd.QuorumFunction = func(req protoreflect.ProtoMessage, replies map[uint32]protoreflect.ProtoMessage) (protoreflect.ProtoMessage, bool) {
-		r := make(map[uint32]*Block, len(replies))
+		r := make(map[uint32]*FetchedBlock, len(replies))
 		for k, v := range replies {
-			r[k] = v.(*Block)
+			r[k] = v.(*FetchedBlock)
 		}
 		return c.qspec.FetchQF(req.(*BlockHash), r)
 	}
@@ -179,7 +225,28 @@ func (c *Configuration) Fetch(ctx context.Context, in *BlockHash) (resp *Block,
 	if err != nil {
 		return nil, err
 	}
-	return res.(*Block), err
+	return res.(*FetchedBlock), err
+}
+
+// FetchSnapshot requests a snapshot of the committed blocks.
+func (c *Configuration) FetchSnapshot(ctx context.Context, in *emptypb.Empty) (resp *Snapshot, err error) {
+	cd := gorums.QuorumCallData{
+		Message: in,
+		Method:  "hotstuffpb.Hotstuff.FetchSnapshot",
+	}
+	cd.QuorumFunction = func(req protoreflect.ProtoMessage, replies map[uint32]protoreflect.ProtoMessage) (protoreflect.ProtoMessage, bool) {
+		r := make(map[uint32]*Snapshot, len(replies))
+		for k, v := range replies {
+			r[k] = v.(*Snapshot)
+		}
+		return c.qspec.FetchSnapshotQF(req.(*emptypb.Empty), r)
+	}
+
+	res, err := c.Configuration.QuorumCall(ctx, cd)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*Snapshot), err
 }
 
 // Hotstuff is the server-side API for the Hotstuff Service
@@ -188,7 +255,11 @@ type Hotstuff interface {
 	Vote(ctx gorums.ServerCtx, request *PartialCert)
 	Timeout(ctx gorums.ServerCtx, request *TimeoutMsg)
 	NewView(ctx gorums.ServerCtx, request *SyncInfo)
-	Fetch(ctx gorums.ServerCtx, request *BlockHash) (response *Block, err error)
+	Fetch(ctx gorums.ServerCtx, request *BlockHash) (response *FetchedBlock, err error)
+	FetchSnapshot(ctx gorums.ServerCtx, request *emptypb.Empty) (response *Snapshot, err error)
+	Decide(ctx gorums.ServerCtx, request *QuorumCert)
+	Heartbeat(ctx gorums.ServerCtx, request *HeartbeatMsg)
+	Relinquish(ctx gorums.ServerCtx, request *RelinquishMsg)
 }
 
 func RegisterHotstuffServer(srv *gorums.Server, impl Hotstuff) {
@@ -221,11 +292,41 @@ func RegisterHotstuffServer(srv *gorums.Server, impl Hotstuff) {
 		case <-ctx.Done():
 		}
 	})
+	srv.RegisterHandler("hotstuffpb.Hotstuff.FetchSnapshot", func(ctx gorums.ServerCtx, in *gorums.Message, finished chan<- *gorums.Message) {
+		req := in.Message.(*emptypb.Empty)
+		defer ctx.Release()
+		resp, err := impl.FetchSnapshot(ctx, req)
+		select {
+		case finished <- gorums.WrapMessage(in.Metadata, resp, err):
+		case <-ctx.Done():
+		}
+	})
+	srv.RegisterHandler("hotstuffpb.Hotstuff.Decide", func(ctx gorums.ServerCtx, in *gorums.Message, _ chan<- *gorums.Message) {
+		req := in.Message.(*QuorumCert)
+		defer ctx.Release()
+		impl.Decide(ctx, req)
+	})
+	srv.RegisterHandler("hotstuffpb.Hotstuff.Heartbeat", func(ctx gorums.ServerCtx, in *gorums.Message, _ chan<- *gorums.Message) {
+		req := in.Message.(*HeartbeatMsg)
+		defer ctx.Release()
+		impl.Heartbeat(ctx, req)
+	})
+	srv.RegisterHandler("hotstuffpb.Hotstuff.Relinquish", func(ctx gorums.ServerCtx, in *gorums.Message, _ chan<- *gorums.Message) {
+		req := in.Message.(*RelinquishMsg)
+		defer ctx.Release()
+		impl.Relinquish(ctx, req)
+	})
+}
+
+type internalFetchedBlock struct {
+	nid   uint32
+	reply *FetchedBlock
+	err   error
 }
 
-type internalBlock struct {
+type internalSnapshot struct {
 	nid   uint32
-	reply *Block
+	reply *Snapshot
 	err   error
 }
 
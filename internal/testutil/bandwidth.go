@@ -0,0 +1,151 @@
+package testutil
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// MessageDelayModel is a DelayModel whose delays depend on the size of the messages.
+// A Network calls DelayMessage instead of Delay if the delay model implements this interface.
+type MessageDelayModel interface {
+	DelayModel
+	// DelayMessage returns how long a message of the given size in bytes,
+	// sent from one replica to another at the given time, should be delayed.
+	DelayMessage(from, to hotstuff.ID, size int, now time.Time) time.Duration
+}
+
+// Link describes the bandwidth and latency of a directed link between two replicas.
+type Link struct {
+	Bandwidth int           // the bandwidth of the link in bytes per second, or 0 for unlimited bandwidth
+	Burst     int           // the number of bytes that can be sent at once before the bandwidth limit applies
+	Latency   time.Duration // the propagation delay of the link
+	Jitter    time.Duration // the upper bound of a random extra delay that is added to each message
+}
+
+// Bandwidth models links with limited bandwidth, such that large messages take longer to deliver than small messages.
+// Each directed link has a token bucket that holds up to Burst bytes, and is refilled at the bandwidth of the link.
+// A message that is larger than the available tokens waits until the bucket has been refilled,
+// and the messages that are sent while it waits are queued behind it.
+// The jitter is drawn from a random number generator with a fixed seed,
+// so that the same sequence of messages sent at the same times gets the same sequence of delays.
+type Bandwidth struct {
+	mut     sync.Mutex
+	link    Link
+	links   map[[2]hotstuff.ID]Link
+	buckets map[[2]hotstuff.ID]*tokenBucket
+	rnd     *rand.Rand
+}
+
+type tokenBucket struct {
+	tokens float64 // may be negative while messages are queued on the link
+	last   time.Time
+}
+
+// NewBandwidth returns a new bandwidth model where every link has the given properties.
+func NewBandwidth(link Link, seed int64) *Bandwidth {
+	return &Bandwidth{
+		link:    link,
+		links:   make(map[[2]hotstuff.ID]Link),
+		buckets: make(map[[2]hotstuff.ID]*tokenBucket),
+		rnd:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+// SetLink sets the properties of the link from one replica to another, overriding the default link.
+func (b *Bandwidth) SetLink(from, to hotstuff.ID, link Link) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	b.links[[2]hotstuff.ID{from, to}] = link
+	delete(b.buckets, [2]hotstuff.ID{from, to})
+}
+
+// Delay returns the delay of an empty message.
+func (b *Bandwidth) Delay(from, to hotstuff.ID, now time.Time) time.Duration {
+	return b.DelayMessage(from, to, 0, now)
+}
+
+// DelayMessage returns the delay of a message of the given size.
+func (b *Bandwidth) DelayMessage(from, to hotstuff.ID, size int, now time.Time) time.Duration {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	key := [2]hotstuff.ID{from, to}
+	link, ok := b.links[key]
+	if !ok {
+		link = b.link
+	}
+
+	delay := link.Latency
+	if link.Jitter > 0 {
+		delay += time.Duration(b.rnd.Int63n(int64(link.Jitter)))
+	}
+	if link.Bandwidth <= 0 {
+		return delay
+	}
+
+	bucket, ok := b.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(link.Burst), last: now}
+		b.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens += elapsed.Seconds() * float64(link.Bandwidth)
+		if bucket.tokens > float64(link.Burst) {
+			bucket.tokens = float64(link.Burst)
+		}
+		bucket.last = now
+	}
+	bucket.tokens -= float64(size)
+	if bucket.tokens < 0 {
+		delay += time.Duration(-bucket.tokens / float64(link.Bandwidth) * float64(time.Second))
+	}
+	return delay
+}
+
+// messageSize returns the approximate size of a protocol message, based on the byte representations of its contents.
+func messageSize(msg interface{}) int {
+	switch m := msg.(type) {
+	case consensus.ProposeMsg:
+		return len(m.Block.ToBytes())
+	case consensus.VoteMsg:
+		return len(m.PartialCert.ToBytes())
+	case consensus.TimeoutMsg:
+		size := len(m.View.ToBytes()) + syncInfoSize(m.SyncInfo)
+		for _, sig := range []consensus.Signature{m.ViewSignature, m.MsgSignature} {
+			if sig != nil {
+				size += len(sig.ToBytes())
+			}
+		}
+		return size
+	case consensus.NewViewMsg:
+		return syncInfoSize(m.SyncInfo)
+	case consensus.DecideMsg:
+		return len(m.QC.ToBytes())
+	case consensus.HeartbeatMsg:
+		return len(m.View.ToBytes()) + len(m.HighQCView.ToBytes())
+	case consensus.RelayedVotesMsg:
+		size := 0
+		for _, vote := range m.Votes {
+			size += len(vote.PartialCert.ToBytes())
+		}
+		return size
+	}
+	return 0
+}
+
+func syncInfoSize(si consensus.SyncInfo) int {
+	size := 0
+	if qc, ok := si.QC(); ok {
+		size += len(qc.ToBytes())
+	}
+	if tc, ok := si.TC(); ok {
+		size += len(tc.ToBytes())
+	}
+	return size
+}
+
+var _ MessageDelayModel = (*Bandwidth)(nil)
@@ -0,0 +1,241 @@
+// Package cluster runs clusters of replicas in the test process, which communicate over the gorums backend,
+// and tears them down in a defined order.
+//
+// Stopping the replicas of a running cluster one by one makes the remaining replicas send proposals, votes,
+// and timeouts to replicas that have already stopped, which shows up as spurious errors in tests.
+// Shutdown avoids this by first making every replica quiesce, then waiting until the in-flight proposals have settled,
+// and only then stopping the replicas in the given order.
+package cluster
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/relab/gorums"
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/blockchain"
+	"github.com/relab/hotstuff/config"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"github.com/relab/hotstuff/crypto"
+	"github.com/relab/hotstuff/crypto/ecdsa"
+	"github.com/relab/hotstuff/internal/proto/clientpb"
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
+	"github.com/relab/hotstuff/replica"
+	"github.com/relab/hotstuff/source"
+	"github.com/relab/hotstuff/synchronizer"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/protobuf/proto"
+)
+
+// Cluster is a cluster of replicas that run chained HotStuff with ECDSA signatures and round-robin leader rotation.
+// The replicas propose generated commands.
+type Cluster struct {
+	ids      []hotstuff.ID
+	replicas map[hotstuff.ID]*replica.Replica
+	logs     *observer.ObservedLogs
+	stopped  bool
+
+	// QuietPeriod is how long the committed blocks of the replicas must stay unchanged
+	// before the cluster is considered settled during Shutdown.
+	QuietPeriod time.Duration
+	// SettleTimeout is how long Shutdown waits for the cluster to settle before it stops the replicas anyway.
+	SettleTimeout time.Duration
+}
+
+// New starts a cluster of n replicas. The replicas log warnings and errors to the cluster, see Errors.
+// The cluster is shut down at the end of the test, unless Shutdown has been called before.
+func New(t *testing.T, n int) *Cluster {
+	t.Helper()
+	core, logs := observer.New(zapcore.WarnLevel)
+	c := &Cluster{
+		replicas:      make(map[hotstuff.ID]*replica.Replica),
+		logs:          logs,
+		QuietPeriod:   200 * time.Millisecond,
+		SettleTimeout: 5 * time.Second,
+	}
+
+	keys := testutil.GenerateKeys(t, n, testutil.GenerateECDSAKey)
+	replicaListeners := make(map[hotstuff.ID]*listener, n)
+	cfg := config.NewConfig(0, nil, nil, 0)
+	for i, key := range keys {
+		id := hotstuff.ID(i + 1)
+		lis := &listener{replica: testutil.CreateTCPListener(t), client: testutil.CreateTCPListener(t)}
+		replicaListeners[id] = lis
+		cfg.Replicas[id] = &config.ReplicaInfo{
+			ID:      id,
+			Address: lis.replica.Addr().String(),
+			PubKey:  key.Public(),
+		}
+		c.ids = append(c.ids, id)
+	}
+
+	for i, id := range c.ids {
+		builder := consensus.NewBuilder(id, keys[i])
+		builder.Register(
+			blockchain.New(),
+			consensus.New(chainedhotstuff.New()),
+			crypto.NewCache(ecdsa.New(), 100),
+			leaderrotation.NewRoundRobin(),
+			synchronizer.New(testutil.FixedTimeout(100)),
+		)
+		r := replica.New(replica.Config{
+			ID:             id,
+			PrivateKey:     keys[i],
+			BatchSize:      1,
+			ManagerOptions: []gorums.ManagerOption{gorums.WithDialTimeout(time.Second)},
+			CommandSource:  source.NewGenerator(generate(id)),
+			Logger:         zap.New(core).Sugar().Named(fmt.Sprintf("hs%d", id)),
+		}, builder)
+		r.StartServers(replicaListeners[id].replica, replicaListeners[id].client)
+		c.replicas[id] = r
+	}
+
+	for _, id := range c.ids {
+		replicaCfg := *cfg
+		replicaCfg.ID = id
+		replicaCfg.PrivateKey = keys[id-1]
+		if err := c.replicas[id].Connect(&replicaCfg); err != nil {
+			for _, r := range c.replicas {
+				r.Close()
+			}
+			t.Fatalf("failed to connect replica %d: %v", id, err)
+		}
+	}
+	for _, id := range c.ids {
+		c.replicas[id].Start()
+	}
+	t.Cleanup(func() {
+		if err := c.Shutdown(); err != nil {
+			t.Error(err)
+		}
+	})
+	return c
+}
+
+// generate returns a generator of batches with a single command, from a client with the same ID as the replica.
+func generate(id hotstuff.ID) func(seqNum uint64) consensus.Command {
+	return func(seqNum uint64) consensus.Command {
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(&clientpb.Batch{
+			Commands: []*clientpb.Command{{ClientID: uint32(id), SequenceNumber: seqNum}},
+		})
+		if err != nil {
+			panic(err)
+		}
+		return consensus.Command(b)
+	}
+}
+
+type listener struct {
+	replica, client net.Listener
+}
+
+// Replica returns the replica with the given ID.
+func (c *Cluster) Replica(id hotstuff.ID) *replica.Replica {
+	return c.replicas[id]
+}
+
+// Committed returns the committed block of the replica with the given ID.
+func (c *Cluster) Committed(id hotstuff.ID) *consensus.Block {
+	return c.replicas[id].Modules().Consensus().CommittedBlock()
+}
+
+// Errors returns the warnings and errors that the replicas have logged.
+func (c *Cluster) Errors() []string {
+	var errs []string
+	for _, entry := range c.logs.All() {
+		errs = append(errs, fmt.Sprintf("%s: %s", entry.LoggerName, entry.Message))
+	}
+	return errs
+}
+
+// Shutdown makes every replica quiesce, waits until the cluster has settled, and then stops the replicas in the given order,
+// followed by the remaining replicas in the order of their IDs. The cluster has settled when the committed blocks of
+// the replicas have not changed for the QuietPeriod. If the cluster does not settle within the SettleTimeout,
+// the replicas are stopped anyway, and an error is returned.
+func (c *Cluster) Shutdown(order ...hotstuff.ID) error {
+	if c.stopped {
+		return nil
+	}
+	ids, err := c.shutdownOrder(order)
+	if err != nil {
+		return err
+	}
+	c.stopped = true
+	for _, id := range c.ids {
+		c.replicas[id].Quiesce()
+	}
+	err = c.settle()
+	for _, id := range ids {
+		c.replicas[id].Stop()
+	}
+	return err
+}
+
+// shutdownOrder returns the IDs of all replicas, starting with the given IDs.
+func (c *Cluster) shutdownOrder(order []hotstuff.ID) ([]hotstuff.ID, error) {
+	seen := make(map[hotstuff.ID]bool)
+	ids := make([]hotstuff.ID, 0, len(c.ids))
+	for _, id := range order {
+		if _, ok := c.replicas[id]; !ok {
+			return nil, fmt.Errorf("unknown replica %d", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("replica %d is listed more than once", id)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	rest := make([]hotstuff.ID, 0, len(c.ids))
+	for _, id := range c.ids {
+		if !seen[id] {
+			rest = append(rest, id)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool { return rest[i] < rest[j] })
+	return append(ids, rest...), nil
+}
+
+// settle waits until the committed blocks of the replicas have not changed for the QuietPeriod.
+func (c *Cluster) settle() error {
+	start := time.Now()
+	lastChange := start
+	last := c.committed()
+	for {
+		time.Sleep(c.QuietPeriod / 10)
+		now := time.Now()
+		if current := c.committed(); !equal(current, last) {
+			last = current
+			lastChange = now
+		}
+		if now.Sub(lastChange) >= c.QuietPeriod {
+			return nil
+		}
+		if now.Sub(start) >= c.SettleTimeout {
+			return fmt.Errorf("the cluster did not settle within %v", c.SettleTimeout)
+		}
+	}
+}
+
+func (c *Cluster) committed() map[hotstuff.ID]consensus.Hash {
+	hashes := make(map[hotstuff.ID]consensus.Hash, len(c.ids))
+	for _, id := range c.ids {
+		hashes[id] = c.Committed(id).Hash()
+	}
+	return hashes
+}
+
+func equal(a, b map[hotstuff.ID]consensus.Hash) bool {
+	for id, hash := range a {
+		if b[id] != hash {
+			return false
+		}
+	}
+	return len(a) == len(b)
+}
@@ -0,0 +1,49 @@
+package cluster_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/internal/testutil/cluster"
+)
+
+// TestShutdown starts and tears down a cluster repeatedly, stopping the replicas in different orders,
+// and checks that no replica logs a warning or an error.
+func TestShutdown(t *testing.T) {
+	orders := [][]hotstuff.ID{
+		nil,
+		{4, 3, 2, 1},
+		{2, 4},
+		{3},
+		{1, 3, 2, 4},
+	}
+	for _, order := range orders {
+		c := cluster.New(t, 4)
+
+		deadline := time.Now().Add(5 * time.Second)
+		for c.Committed(1).View() < 3 {
+			if time.Now().After(deadline) {
+				t.Fatalf("the cluster did not commit any blocks: %v", c.Errors())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if err := c.Shutdown(order...); err != nil {
+			t.Fatalf("shutdown in order %v failed: %v", order, err)
+		}
+		for _, err := range c.Errors() {
+			t.Errorf("shutdown in order %v: %s", order, err)
+		}
+	}
+}
+
+func TestShutdownUnknownReplica(t *testing.T) {
+	c := cluster.New(t, 4)
+	if err := c.Shutdown(1, 5); err == nil {
+		t.Error("expected shutdown to fail for an unknown replica")
+	}
+	if err := c.Shutdown(2, 2); err == nil {
+		t.Error("expected shutdown to fail for a replica that is listed twice")
+	}
+}
@@ -0,0 +1,519 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/blockchain"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/consensus/chainedhotstuff"
+	"github.com/relab/hotstuff/crypto"
+	"github.com/relab/hotstuff/crypto/ecdsa"
+	"github.com/relab/hotstuff/internal/logging"
+	"github.com/relab/hotstuff/leaderrotation"
+	"github.com/relab/hotstuff/synchronizer"
+)
+
+// Network is an in-memory network of replicas.
+// Messages are delivered by adding them directly to the event loop of the receiving replica,
+// which makes it possible to run the full protocol in tests without starting the gorums backend.
+type Network struct {
+	mut          sync.RWMutex
+	nodes        map[hotstuff.ID]*Node
+	delay        DelayModel
+	disconnected map[[2]hotstuff.ID]bool
+	regions      map[hotstuff.ID]string
+	tap          func(from, to hotstuff.ID, msg interface{})
+	lenient      bool // if true, the replicas do not run in strict mode
+}
+
+// CreateNetwork creates an in-memory network of n replicas and returns a builder for each of them.
+// The builders contain the modules needed to run chained HotStuff with ECDSA signatures and round-robin leader rotation.
+// Other modules can be registered with the builders to replace the defaults before calling Build.
+// The replicas run in strict mode, such that any protocol invariant violation fails the test.
+func CreateNetwork(t testing.TB, n int) (*Network, BuilderList) {
+	t.Helper()
+	return createNetwork(t, n, true)
+}
+
+// CreateLenientNetwork is like CreateNetwork, but the replicas only log protocol invariant violations,
+// which is needed to test how a replica handles them outside of strict mode.
+func CreateLenientNetwork(t testing.TB, n int) (*Network, BuilderList) {
+	t.Helper()
+	return createNetwork(t, n, false)
+}
+
+func createNetwork(t testing.TB, n int, strict bool) (*Network, BuilderList) {
+	t.Helper()
+	network := &Network{
+		nodes:        make(map[hotstuff.ID]*Node),
+		disconnected: make(map[[2]hotstuff.ID]bool),
+		regions:      make(map[hotstuff.ID]string),
+		lenient:      !strict,
+	}
+	builders := make(BuilderList, n)
+	for i := 0; i < n; i++ {
+		id := hotstuff.ID(i + 1)
+		builders[i] = network.newNode(id, GenerateECDSAKey(t))
+	}
+	return network, builders
+}
+
+// newNode adds a replica with the given id and key to the network, replacing any existing replica with the same id,
+// and returns a builder with the default modules.
+func (n *Network) newNode(id hotstuff.ID, key consensus.PrivateKey) *consensus.Builder {
+	node := &Node{id: id, pubKey: key.Public(), network: n}
+	n.mut.Lock()
+	n.nodes[id] = node
+	n.mut.Unlock()
+
+	builder := consensus.NewBuilder(id, key)
+	builder.Register(
+		logging.New(fmt.Sprintf("hs%d", id)),
+		blockchain.New(),
+		consensus.New(chainedhotstuff.New()),
+		crypto.NewCache(ecdsa.New(), 100),
+		leaderrotation.NewRoundRobin(),
+		synchronizer.New(FixedTimeout(100)),
+		&networkConfig{node: node},
+		node,
+	)
+	if !n.lenient {
+		builder.Options().SetShouldUseStrictMode()
+	}
+	return &builder
+}
+
+// Restart replaces the replica with the given id by a new replica with the same key and no state,
+// as if the replica was restarted after a crash. The old replica must not be running.
+// The returned builder contains the default modules, and must be built before the new replica is started.
+func (n *Network) Restart(id hotstuff.ID) *consensus.Builder {
+	return n.newNode(id, n.Node(id).mods.PrivateKey())
+}
+
+// Node returns the replica with the given id.
+func (n *Network) Node(id hotstuff.ID) *Node {
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+	return n.nodes[id]
+}
+
+// Nodes returns all replicas in the network.
+func (n *Network) Nodes() []*Node {
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+	nodes := make([]*Node, 0, len(n.nodes))
+	for id := hotstuff.ID(1); int(id) <= len(n.nodes); id++ {
+		nodes = append(nodes, n.nodes[id])
+	}
+	return nodes
+}
+
+// Run starts the replicas with the given ids and runs them until the context is cancelled.
+// If no ids are given, all replicas in the network are started.
+// Messages sent to replicas that are not running are dropped.
+func (n *Network) Run(ctx context.Context, ids ...hotstuff.ID) {
+	nodes := n.Nodes()
+	if len(ids) > 0 {
+		nodes = nodes[:0]
+		for _, id := range ids {
+			nodes = append(nodes, n.Node(id))
+		}
+	}
+	for _, node := range nodes {
+		atomic.StoreInt32(&node.running, 1)
+	}
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *Node) {
+			node.mods.Synchronizer().Start(ctx)
+			node.mods.Run(ctx)
+			atomic.StoreInt32(&node.running, 0)
+			wg.Done()
+		}(node)
+	}
+	wg.Wait()
+}
+
+// SetDelayModel sets the model that decides how long messages are delayed before they are delivered.
+// By default, messages are delivered immediately. SetDelayModel must be called before Run.
+func (n *Network) SetDelayModel(model DelayModel) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	n.delay = model
+}
+
+// SetRegion places the replicas with the given ids in the given region.
+// By default, the regions of the replicas are unknown.
+func (n *Network) SetRegion(region string, ids ...hotstuff.ID) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	for _, id := range ids {
+		n.regions[id] = region
+	}
+}
+
+// SetTap sets a function that is called with every message that is sent between replicas that are connected and running.
+// SetTap must be called before Run.
+func (n *Network) SetTap(tap func(from, to hotstuff.ID, msg interface{})) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	n.tap = tap
+}
+
+// Disconnect drops all messages between the replica with the given id and the given peers, in both directions.
+func (n *Network) Disconnect(id hotstuff.ID, peers ...hotstuff.ID) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	for _, peer := range peers {
+		n.disconnected[[2]hotstuff.ID{id, peer}] = true
+		n.disconnected[[2]hotstuff.ID{peer, id}] = true
+	}
+}
+
+// DisconnectOneWay drops all messages sent from the replica with the given id to the given peers.
+// Messages sent in the other direction are still delivered. Reconnect restores the connections.
+func (n *Network) DisconnectOneWay(id hotstuff.ID, peers ...hotstuff.ID) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	for _, peer := range peers {
+		n.disconnected[[2]hotstuff.ID{id, peer}] = true
+	}
+}
+
+// Reconnect restores the connections between the replica with the given id and the given peers.
+func (n *Network) Reconnect(id hotstuff.ID, peers ...hotstuff.ID) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+	for _, peer := range peers {
+		delete(n.disconnected, [2]hotstuff.ID{id, peer})
+		delete(n.disconnected, [2]hotstuff.ID{peer, id})
+	}
+}
+
+// connected returns true if messages can be sent from one replica to the other.
+func (n *Network) connected(from, to hotstuff.ID) bool {
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+	return !n.disconnected[[2]hotstuff.ID{from, to}]
+}
+
+func (n *Network) send(from, to hotstuff.ID, msg interface{}) {
+	node := n.Node(to)
+	if node == nil || atomic.LoadInt32(&node.running) == 0 || !n.connected(from, to) {
+		return
+	}
+	n.mut.RLock()
+	model := n.delay
+	tap := n.tap
+	n.mut.RUnlock()
+	if tap != nil {
+		tap(from, to, msg)
+	}
+	if model != nil {
+		var d time.Duration
+		if sized, ok := model.(MessageDelayModel); ok {
+			d = sized.DelayMessage(from, to, messageSize(msg), time.Now())
+		} else {
+			d = model.Delay(from, to, time.Now())
+		}
+		if d > 0 {
+			time.AfterFunc(d, func() {
+				if atomic.LoadInt32(&node.running) == 1 {
+					node.mods.EventLoop().AddEvent(msg)
+				}
+			})
+			return
+		}
+	}
+	// a goroutine is needed because the sender may be running on its own event loop.
+	go node.mods.EventLoop().AddEvent(msg)
+}
+
+func (n *Network) broadcast(from hotstuff.ID, msg interface{}) {
+	for _, node := range n.Nodes() {
+		if node.id != from {
+			n.send(from, node.id, msg)
+		}
+	}
+}
+
+// Node is a replica in a Network.
+// It acts as the command queue, acceptor, executor, and fork handler of the replica,
+// and records the blocks that the replica executes.
+type Node struct {
+	id      hotstuff.ID
+	pubKey  consensus.PublicKey
+	network *Network
+	mods    *consensus.Modules
+	running int32
+	fetches int32
+
+	mut      sync.Mutex
+	seqNum   uint64
+	executed []*consensus.Block
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (node *Node) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	node.mods = mods
+}
+
+// ID returns the id of the replica.
+func (node *Node) ID() hotstuff.ID {
+	return node.id
+}
+
+// Modules returns the modules of the replica.
+func (node *Node) Modules() *consensus.Modules {
+	return node.mods
+}
+
+// Executed returns the blocks that have been executed by the replica, in the order they were executed.
+func (node *Node) Executed() []*consensus.Block {
+	node.mut.Lock()
+	defer node.mut.Unlock()
+	executed := make([]*consensus.Block, len(node.executed))
+	copy(executed, node.executed)
+	return executed
+}
+
+// Get returns a new unique command.
+func (node *Node) Get(_ context.Context) (cmd consensus.Command, ok bool) {
+	node.mut.Lock()
+	defer node.mut.Unlock()
+	node.seqNum++
+	return consensus.Command(fmt.Sprintf("%d:%d", node.id, node.seqNum)), true
+}
+
+// Accept accepts every command.
+func (node *Node) Accept(_ consensus.Command) bool {
+	return true
+}
+
+// Proposed does nothing.
+func (node *Node) Proposed(_ consensus.Command) {}
+
+// Fetches returns the number of times the replica has tried to fetch blocks from the other replicas.
+// A batched fetch of several blocks counts once.
+func (node *Node) Fetches() int {
+	return int(atomic.LoadInt32(&node.fetches))
+}
+
+// Exec records the executed block.
+func (node *Node) Exec(block *consensus.Block) {
+	node.mut.Lock()
+	defer node.mut.Unlock()
+	node.executed = append(node.executed, block)
+}
+
+// Fork does nothing.
+func (node *Node) Fork(_ *consensus.Block) {}
+
+// networkConfig implements the Configuration interface for a replica in a Network.
+type networkConfig struct {
+	node *Node
+}
+
+// Replicas returns all of the replicas in the configuration.
+func (cfg *networkConfig) Replicas() map[hotstuff.ID]consensus.Replica {
+	replicas := make(map[hotstuff.ID]consensus.Replica)
+	for _, node := range cfg.node.network.Nodes() {
+		replicas[node.id] = &networkReplica{from: cfg.node.id, node: node}
+	}
+	return replicas
+}
+
+// Replica returns a replica if present in the configuration.
+func (cfg *networkConfig) Replica(id hotstuff.ID) (replica consensus.Replica, ok bool) {
+	node := cfg.node.network.Node(id)
+	if node == nil {
+		return nil, false
+	}
+	return &networkReplica{from: cfg.node.id, node: node}, true
+}
+
+// Len returns the number of replicas in the configuration.
+func (cfg *networkConfig) Len() int {
+	return len(cfg.node.network.Nodes())
+}
+
+// QuorumSize returns the size of a quorum.
+func (cfg *networkConfig) QuorumSize() int {
+	return hotstuff.QuorumSize(cfg.Len())
+}
+
+// Propose sends the block to all replicas in the configuration.
+func (cfg *networkConfig) Propose(proposal consensus.ProposeMsg) {
+	proposal.ID = cfg.node.id
+	cfg.node.network.broadcast(cfg.node.id, proposal)
+}
+
+// Timeout sends the timeout message to all replicas.
+func (cfg *networkConfig) Timeout(msg consensus.TimeoutMsg) {
+	msg.ID = cfg.node.id
+	cfg.node.network.broadcast(cfg.node.id, msg)
+}
+
+// Decide sends the decision to all replicas.
+func (cfg *networkConfig) Decide(msg consensus.DecideMsg) {
+	msg.ID = cfg.node.id
+	cfg.node.network.broadcast(cfg.node.id, msg)
+}
+
+// Heartbeat sends the heartbeat to all replicas.
+func (cfg *networkConfig) Heartbeat(msg consensus.HeartbeatMsg) {
+	cfg.node.network.broadcast(cfg.node.id, msg)
+}
+
+// Relinquish sends the relinquish message to all replicas.
+func (cfg *networkConfig) Relinquish(msg consensus.RelinquishMsg) {
+	cfg.node.network.broadcast(cfg.node.id, msg)
+}
+
+// Fetch requests a block from all the replicas in the configuration.
+// The first replica that has the block also delivers its inclusion proof, if any.
+func (cfg *networkConfig) Fetch(ctx context.Context, hash consensus.Hash) (block *consensus.Block, proof consensus.InclusionProof, ok bool) {
+	atomic.AddInt32(&cfg.node.fetches, 1)
+	for _, node := range cfg.node.network.Nodes() {
+		if ctx.Err() != nil {
+			return nil, proof, false
+		}
+		if node.id == cfg.node.id || node.mods == nil || !cfg.node.network.connected(cfg.node.id, node.id) {
+			continue
+		}
+		if block, ok := node.mods.BlockChain().LocalGet(hash); ok {
+			proof, _ = node.mods.BlockChain().Proof(hash)
+			return block, proof, true
+		}
+	}
+	return nil, proof, false
+}
+
+// FetchBatch requests several blocks from all the replicas in the configuration in a single request.
+// Each block is delivered by the first replica that has it, along with its inclusion proof, if any.
+func (cfg *networkConfig) FetchBatch(ctx context.Context, hashes []consensus.Hash) []consensus.FetchedBlock {
+	atomic.AddInt32(&cfg.node.fetches, 1)
+	var fetched []consensus.FetchedBlock
+	for _, hash := range hashes {
+		for _, node := range cfg.node.network.Nodes() {
+			if ctx.Err() != nil {
+				return fetched
+			}
+			if node.id == cfg.node.id || node.mods == nil || !cfg.node.network.connected(cfg.node.id, node.id) {
+				continue
+			}
+			if block, ok := node.mods.BlockChain().LocalGet(hash); ok {
+				proof, _ := node.mods.BlockChain().Proof(hash)
+				fetched = append(fetched, consensus.FetchedBlock{Block: block, Proof: proof})
+				break
+			}
+		}
+	}
+	return fetched
+}
+
+// Reachable returns the number of running replicas that the replica is connected to, including itself.
+func (cfg *networkConfig) Reachable() int {
+	reachable := 1
+	for _, node := range cfg.node.network.Nodes() {
+		if node.id != cfg.node.id && atomic.LoadInt32(&node.running) == 1 && cfg.node.network.connected(cfg.node.id, node.id) {
+			reachable++
+		}
+	}
+	return reachable
+}
+
+// FetchPayload requests the command with the given reference from all the replicas in the configuration.
+func (cfg *networkConfig) FetchPayload(ctx context.Context, ref consensus.Hash) (cmd consensus.Command, ok bool) {
+	for _, node := range cfg.node.network.Nodes() {
+		if ctx.Err() != nil {
+			return "", false
+		}
+		if node.id == cfg.node.id || node.mods == nil || !cfg.node.network.connected(cfg.node.id, node.id) {
+			continue
+		}
+		if cmd, ok := node.mods.BlockChain().LocalGetPayload(ref); ok {
+			return cmd, true
+		}
+	}
+	return "", false
+}
+
+var (
+	_ consensus.Configuration       = (*networkConfig)(nil)
+	_ consensus.ConnectivityMonitor = (*networkConfig)(nil)
+	_ consensus.BatchFetcher        = (*networkConfig)(nil)
+	_ consensus.Decider             = (*networkConfig)(nil)
+	_ consensus.Heartbeater         = (*networkConfig)(nil)
+	_ consensus.Relinquisher        = (*networkConfig)(nil)
+)
+
+// networkReplica implements the Replica interface for a replica in a Network.
+type networkReplica struct {
+	from hotstuff.ID
+	node *Node
+
+	reputation float64
+}
+
+// ID returns the replica's id.
+func (r *networkReplica) ID() hotstuff.ID {
+	return r.node.id
+}
+
+// PublicKey returns the replica's public key.
+func (r *networkReplica) PublicKey() consensus.PublicKey {
+	return r.node.pubKey
+}
+
+// Vote sends the partial certificate to the other replica.
+func (r *networkReplica) Vote(cert consensus.PartialCert) {
+	mods := r.node.network.Node(r.from).mods
+	r.node.network.send(r.from, r.node.id, consensus.VoteMsg{
+		ID:          r.from,
+		PartialCert: cert,
+		Congested:   mods.Congested(),
+		CommitCert:  mods.CommitAttestation(),
+	})
+}
+
+// RelayVotes sends the votes collected by the relay to the other replica.
+func (r *networkReplica) RelayVotes(votes []consensus.VoteMsg) {
+	r.node.network.send(r.from, r.node.id, consensus.RelayedVotesMsg{ID: r.from, Votes: votes})
+}
+
+// Region returns the region of the replica.
+func (r *networkReplica) Region() string {
+	r.node.network.mut.RLock()
+	defer r.node.network.mut.RUnlock()
+	return r.node.network.regions[r.node.id]
+}
+
+// NewView sends the quorum certificate to the other replica.
+func (r *networkReplica) NewView(si consensus.SyncInfo) {
+	r.node.network.send(r.from, r.node.id, consensus.NewViewMsg{ID: r.from, SyncInfo: si})
+}
+
+// GetRep returns the replica's reputation.
+func (r *networkReplica) GetRep() float64 {
+	return r.reputation
+}
+
+// UpdateRep adds to the replica's reputation.
+func (r *networkReplica) UpdateRep(rep float64) {
+	r.reputation += rep
+}
+
+var (
+	_ consensus.Replica         = (*networkReplica)(nil)
+	_ consensus.RegionalReplica = (*networkReplica)(nil)
+	_ consensus.VoteRelayer     = (*networkReplica)(nil)
+)
@@ -0,0 +1,58 @@
+package testutil
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+)
+
+// DelayModel decides how long a message is delayed in a Network.
+type DelayModel interface {
+	// Delay returns how long a message sent from one replica to another at the given time should be delayed.
+	Delay(from, to hotstuff.ID, now time.Time) time.Duration
+}
+
+// PartialSynchrony models the partial synchrony assumption.
+// Before the global stabilization time (GST), messages are delayed arbitrarily;
+// this model holds them back until after GST, such that no message sent before GST arrives before it.
+// After GST, every message is delivered within the bound.
+// The delays are drawn from a random number generator with a fixed seed,
+// so that the same sequence of messages gets the same sequence of delays.
+type PartialSynchrony struct {
+	mut   sync.Mutex
+	gst   time.Time
+	bound time.Duration
+	rnd   *rand.Rand
+}
+
+// NewPartialSynchrony returns a new partial synchrony model with the given GST and delay bound.
+func NewPartialSynchrony(gst time.Time, bound time.Duration, seed int64) *PartialSynchrony {
+	return &PartialSynchrony{
+		gst:   gst,
+		bound: bound,
+		rnd:   rand.New(rand.NewSource(seed)),
+	}
+}
+
+// GST returns the global stabilization time.
+func (ps *PartialSynchrony) GST() time.Time {
+	return ps.gst
+}
+
+// Delay returns the delay of a message sent at the given time.
+func (ps *PartialSynchrony) Delay(_, _ hotstuff.ID, now time.Time) time.Duration {
+	ps.mut.Lock()
+	defer ps.mut.Unlock()
+	var delay time.Duration
+	if ps.bound > 0 {
+		delay = time.Duration(ps.rnd.Int63n(int64(ps.bound)))
+	}
+	if now.Before(ps.gst) {
+		delay += ps.gst.Sub(now)
+	}
+	return delay
+}
+
+var _ DelayModel = (*PartialSynchrony)(nil)
@@ -23,7 +23,7 @@ import (
 )
 
 // TestModules returns a builder containing default modules for testing.
-func TestModules(t *testing.T, ctrl *gomock.Controller, id hotstuff.ID, privkey consensus.PrivateKey) consensus.Builder {
+func TestModules(t testing.TB, ctrl *gomock.Controller, id hotstuff.ID, privkey consensus.PrivateKey) consensus.Builder {
 	t.Helper()
 	builder := consensus.NewBuilder(id, privkey)
 
@@ -109,7 +109,7 @@ func (hl HotStuffList) Keys() (keys []consensus.PrivateKey) {
 }
 
 // CreateBuilders creates n builders with default consensus. Configurations are initialized with replicas.
-func CreateBuilders(t *testing.T, ctrl *gomock.Controller, n int, keys ...consensus.PrivateKey) (builders BuilderList) {
+func CreateBuilders(t testing.TB, ctrl *gomock.Controller, n int, keys ...consensus.PrivateKey) (builders BuilderList) {
 	t.Helper()
 	builders = make([]*consensus.Builder, n)
 	replicas := make([]*mocks.MockReplica, n)
@@ -146,7 +146,7 @@ func CreateBuilders(t *testing.T, ctrl *gomock.Controller, n int, keys ...consen
 }
 
 // CreateMockConfigurationWithReplicas creates a configuration with n replicas.
-func CreateMockConfigurationWithReplicas(t *testing.T, ctrl *gomock.Controller, n int, keys ...consensus.PrivateKey) (*mocks.MockConfiguration, []*mocks.MockReplica) {
+func CreateMockConfigurationWithReplicas(t testing.TB, ctrl *gomock.Controller, n int, keys ...consensus.PrivateKey) (*mocks.MockConfiguration, []*mocks.MockReplica) {
 	t.Helper()
 	cfg := mocks.NewMockConfiguration(ctrl)
 	replicas := make([]*mocks.MockReplica, n)
@@ -166,7 +166,7 @@ func CreateMockConfigurationWithReplicas(t *testing.T, ctrl *gomock.Controller,
 }
 
 // CreateMockReplica returns a mock of a consensus.Replica.
-func CreateMockReplica(t *testing.T, ctrl *gomock.Controller, id hotstuff.ID, key consensus.PublicKey) *mocks.MockReplica {
+func CreateMockReplica(t testing.TB, ctrl *gomock.Controller, id hotstuff.ID, key consensus.PublicKey) *mocks.MockReplica {
 	t.Helper()
 
 	replica := mocks.NewMockReplica(ctrl)
@@ -185,7 +185,7 @@ func CreateMockReplica(t *testing.T, ctrl *gomock.Controller, id hotstuff.ID, ke
 }
 
 // ConfigAddReplica adds a mock replica to a mock configuration.
-func ConfigAddReplica(t *testing.T, cfg *mocks.MockConfiguration, replica *mocks.MockReplica) {
+func ConfigAddReplica(t testing.TB, cfg *mocks.MockConfiguration, replica *mocks.MockReplica) {
 	t.Helper()
 
 	cfg.
@@ -196,7 +196,7 @@ func ConfigAddReplica(t *testing.T, cfg *mocks.MockConfiguration, replica *mocks
 }
 
 // CreateTCPListener creates a net.Listener on a random port.
-func CreateTCPListener(t *testing.T) net.Listener {
+func CreateTCPListener(t testing.TB) net.Listener {
 	t.Helper()
 	lis, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
@@ -206,7 +206,7 @@ func CreateTCPListener(t *testing.T) net.Listener {
 }
 
 // Sign creates a signature using the given signer.
-func Sign(t *testing.T, hash consensus.Hash, signer consensus.Crypto) consensus.Signature {
+func Sign(t testing.TB, hash consensus.Hash, signer consensus.Crypto) consensus.Signature {
 	t.Helper()
 	sig, err := signer.Sign(hash)
 	if err != nil {
@@ -216,7 +216,7 @@ func Sign(t *testing.T, hash consensus.Hash, signer consensus.Crypto) consensus.
 }
 
 // CreateSignatures creates partial certificates from multiple signers.
-func CreateSignatures(t *testing.T, hash consensus.Hash, signers []consensus.Crypto) []consensus.Signature {
+func CreateSignatures(t testing.TB, hash consensus.Hash, signers []consensus.Crypto) []consensus.Signature {
 	t.Helper()
 	sigs := make([]consensus.Signature, 0, len(signers))
 	for _, signer := range signers {
@@ -226,7 +226,7 @@ func CreateSignatures(t *testing.T, hash consensus.Hash, signers []consensus.Cry
 }
 
 // CreateTimeouts creates a set of TimeoutMsg messages from the given signers.
-func CreateTimeouts(t *testing.T, view consensus.View, signers []consensus.Crypto) (timeouts []consensus.TimeoutMsg) {
+func CreateTimeouts(t testing.TB, view consensus.View, signers []consensus.Crypto) (timeouts []consensus.TimeoutMsg) {
 	t.Helper()
 	timeouts = make([]consensus.TimeoutMsg, 0, len(signers))
 	viewSigs := CreateSignatures(t, view.ToHash(), signers)
@@ -245,7 +245,7 @@ func CreateTimeouts(t *testing.T, view consensus.View, signers []consensus.Crypt
 }
 
 // CreatePC creates a partial certificate using the given signer.
-func CreatePC(t *testing.T, block *consensus.Block, signer consensus.Crypto) consensus.PartialCert {
+func CreatePC(t testing.TB, block *consensus.Block, signer consensus.Crypto) consensus.PartialCert {
 	t.Helper()
 	pc, err := signer.CreatePartialCert(block)
 	if err != nil {
@@ -255,7 +255,7 @@ func CreatePC(t *testing.T, block *consensus.Block, signer consensus.Crypto) con
 }
 
 // CreatePCs creates one partial certificate using each of the given signers.
-func CreatePCs(t *testing.T, block *consensus.Block, signers []consensus.Crypto) []consensus.PartialCert {
+func CreatePCs(t testing.TB, block *consensus.Block, signers []consensus.Crypto) []consensus.PartialCert {
 	t.Helper()
 	pcs := make([]consensus.PartialCert, 0, len(signers))
 	for _, signer := range signers {
@@ -265,7 +265,7 @@ func CreatePCs(t *testing.T, block *consensus.Block, signers []consensus.Crypto)
 }
 
 // CreateQC creates a QC using the given signers.
-func CreateQC(t *testing.T, block *consensus.Block, signers []consensus.Crypto) consensus.QuorumCert {
+func CreateQC(t testing.TB, block *consensus.Block, signers []consensus.Crypto) consensus.QuorumCert {
 	t.Helper()
 	if len(signers) == 0 {
 		return consensus.QuorumCert{}
@@ -278,7 +278,7 @@ func CreateQC(t *testing.T, block *consensus.Block, signers []consensus.Crypto)
 }
 
 // CreateTC generates a TC using the given signers.
-func CreateTC(t *testing.T, view consensus.View, signers []consensus.Crypto) consensus.TimeoutCert {
+func CreateTC(t testing.TB, view consensus.View, signers []consensus.Crypto) consensus.TimeoutCert {
 	t.Helper()
 	if len(signers) == 0 {
 		return consensus.TimeoutCert{}
@@ -291,7 +291,7 @@ func CreateTC(t *testing.T, view consensus.View, signers []consensus.Crypto) con
 }
 
 // GenerateECDSAKey generates an ECDSA private key for use in tests.
-func GenerateECDSAKey(t *testing.T) consensus.PrivateKey {
+func GenerateECDSAKey(t testing.TB) consensus.PrivateKey {
 	t.Helper()
 	key, err := keygen.GenerateECDSAPrivateKey()
 	if err != nil {
@@ -301,7 +301,7 @@ func GenerateECDSAKey(t *testing.T) consensus.PrivateKey {
 }
 
 // GenerateBLS12Key generates a BLS12-381 private key for use in tests.
-func GenerateBLS12Key(t *testing.T) consensus.PrivateKey {
+func GenerateBLS12Key(t testing.TB) consensus.PrivateKey {
 	t.Helper()
 	key, err := bls12.GeneratePrivateKey()
 	if err != nil {
@@ -311,7 +311,7 @@ func GenerateBLS12Key(t *testing.T) consensus.PrivateKey {
 }
 
 // GenerateKeys generates n keys.
-func GenerateKeys(t *testing.T, n int, keyFunc func(t *testing.T) consensus.PrivateKey) (keys []consensus.PrivateKey) {
+func GenerateKeys(t testing.TB, n int, keyFunc func(t testing.TB) consensus.PrivateKey) (keys []consensus.PrivateKey) {
 	keys = make([]consensus.PrivateKey, n)
 	for i := 0; i < n; i++ {
 		keys[i] = keyFunc(t)
@@ -325,7 +325,7 @@ func NewProposeMsg(parent consensus.Hash, qc consensus.QuorumCert, cmd consensus
 }
 
 type leaderRotation struct {
-	t     *testing.T
+	t     testing.TB
 	order []hotstuff.ID
 }
 
@@ -342,7 +342,7 @@ func (l leaderRotation) GetLeader(v consensus.View) hotstuff.ID {
 }
 
 // NewLeaderRotation returns a leader rotation implementation that will return leaders in the specified order.
-func NewLeaderRotation(t *testing.T, order ...hotstuff.ID) consensus.LeaderRotation {
+func NewLeaderRotation(t testing.TB, order ...hotstuff.ID) consensus.LeaderRotation {
 	t.Helper()
 	return leaderRotation{t, order}
 }
@@ -360,3 +360,30 @@ func (d fixedDuration) Duration() time.Duration { return d.timeout }
 func (d fixedDuration) ViewStarted()            {}
 func (d fixedDuration) ViewSucceeded()          {}
 func (d fixedDuration) ViewTimeout()            {}
+
+// ForceCommit calls ForceCommit on a replica that is not running, and runs the event loop of the replica
+// on the calling goroutine until the checkpoint has been committed. Events that are queued by the commit are
+// handled before it returns, and a panic during the commit happens on the calling goroutine.
+func ForceCommit(mods *consensus.Modules, checkpoint consensus.QuorumCert) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	var err error
+	go func() {
+		err = mods.Consensus().ForceCommit(context.Background(), checkpoint)
+		cancel()
+	}()
+	mods.EventLoop().Run(ctx)
+	return err
+}
+
+// CommitProven calls CommitProven on a replica that is not running, and runs the event loop of the replica
+// on the calling goroutine until it returns, like ForceCommit.
+func CommitProven(mods *consensus.Modules, qc consensus.QuorumCert) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	var err error
+	go func() {
+		err = mods.Consensus().CommitProven(context.Background(), qc)
+		cancel()
+	}()
+	mods.EventLoop().Run(ctx)
+	return err
+}
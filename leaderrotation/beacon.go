@@ -0,0 +1,41 @@
+package leaderrotation
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+type beaconBased struct {
+	mods *consensus.Modules
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (b *beaconBased) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	b.mods = mods
+}
+
+// GetLeader returns the id of the leader in the given view.
+// The leader is chosen pseudo-randomly from the committed output of the randomness beacon and the view.
+// Until a block with a beacon value has been committed, the leader is chosen by round-robin.
+// Replicas that have not committed the same blocks may disagree on the leader, in which case the view times out.
+func (b beaconBased) GetLeader(view consensus.View) hotstuff.ID {
+	n := uint64(b.mods.Configuration().Len())
+	value, ok := consensus.CommittedRandomness(b.mods)
+	if !ok {
+		return hotstuff.ID(uint64(view)%n + 1)
+	}
+	var viewBuf [8]byte
+	binary.LittleEndian.PutUint64(viewBuf[:], uint64(view))
+	h := sha256.Sum256(append(value.ToBytes(), viewBuf[:]...))
+	return hotstuff.ID(binary.LittleEndian.Uint64(h[:8])%n + 1)
+}
+
+// NewBeaconBased returns a new leader rotation that chooses leaders using the committed output of the randomness beacon.
+// It requires a consensus.Beacon module.
+func NewBeaconBased() consensus.LeaderRotation {
+	return &beaconBased{}
+}
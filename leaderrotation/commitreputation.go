@@ -0,0 +1,134 @@
+package leaderrotation
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// maxReputation is the reputation that every replica starts with, and the highest reputation a replica can have.
+const maxReputation = 100
+
+// ReputationConfig configures the commit-based reputation leader rotation.
+type ReputationConfig struct {
+	// Penalty is the percentage, from 0 to 100, that the reputation of a replica is reduced by
+	// for each view that it led without proposing a block that was committed.
+	Penalty uint
+	// Reward is the reputation that a replica regains, up to 100, for each of its proposals that is committed.
+	Reward uint64
+	// MinReputation is the lowest reputation a replica can have, from 1 to 100, such that every replica
+	// keeps a chance to be selected, and can regain its reputation if it recovers. Zero means 1.
+	MinReputation uint64
+}
+
+type commitReputation struct {
+	mods *consensus.Modules
+	cfg  ReputationConfig
+
+	mut        sync.Mutex
+	reputation map[hotstuff.ID]uint64
+	last       *consensus.Block // the last credited block
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (cr *commitReputation) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	cr.mods = mods
+}
+
+// GetLeader returns the id of the leader in the given view.
+// The leader is drawn at random, weighted by the reputations of the replicas, seeded by the last committed block
+// and the view, so replicas that have committed the same blocks select the same leader.
+func (cr *commitReputation) GetLeader(view consensus.View) hotstuff.ID {
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+	return cr.leader(view)
+}
+
+// leader returns the leader of the given view, based on the reputations after the last credited block.
+// The caller must hold the lock.
+func (cr *commitReputation) leader(view consensus.View) hotstuff.ID {
+	n := cr.mods.Configuration().Len()
+	var total uint64
+	for id := hotstuff.ID(1); int(id) <= n; id++ {
+		total += cr.reputationOf(id)
+	}
+
+	var viewBuf [8]byte
+	binary.BigEndian.PutUint64(viewBuf[:], uint64(view))
+	hash := cr.last.Hash()
+	seed := sha256.Sum256(append(hash[:], viewBuf[:]...))
+	pick := binary.BigEndian.Uint64(seed[:8]) % total
+	// assume IDs start at 1
+	for id := hotstuff.ID(1); int(id) <= n; id++ {
+		rep := cr.reputationOf(id)
+		if pick < rep {
+			return id
+		}
+		pick -= rep
+	}
+	return hotstuff.ID(n)
+}
+
+// reputationOf returns the reputation of the replica with the given ID. The caller must hold the lock.
+func (cr *commitReputation) reputationOf(id hotstuff.ID) uint64 {
+	if rep, ok := cr.reputation[id]; ok {
+		return rep
+	}
+	return maxReputation
+}
+
+// CreditProposer rewards the proposer of the committed block, and penalizes the leaders of the views
+// between the previous committed block and this block, as none of their proposals were committed.
+// Only the leaders of the last n missed views are penalized, where n is the number of replicas,
+// such that a long outage of the whole configuration does not cost every replica its reputation.
+func (cr *commitReputation) CreditProposer(block *consensus.Block) {
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+
+	minRep := cr.cfg.MinReputation
+	if minRep == 0 {
+		minRep = 1
+	}
+	from := cr.last.View() + 1
+	if n := consensus.View(cr.mods.Configuration().Len()); block.View() > from+n {
+		from = block.View() - n
+	}
+	// every leader is selected before the reputations change, so the penalties are computed first.
+	var missed []hotstuff.ID
+	for view := from; view < block.View(); view++ {
+		missed = append(missed, cr.leader(view))
+	}
+	for _, id := range missed {
+		rep := cr.reputationOf(id)
+		rep -= rep * uint64(cr.cfg.Penalty) / 100
+		if rep < minRep {
+			rep = minRep
+		}
+		cr.reputation[id] = rep
+	}
+
+	rep := cr.reputationOf(block.Proposer()) + cr.cfg.Reward
+	if rep > maxReputation {
+		rep = maxReputation
+	}
+	cr.reputation[block.Proposer()] = rep
+	cr.last = block
+}
+
+// NewCommitReputation returns a new leader rotation that selects leaders weighted by their reputation,
+// where the reputation of a replica drops for each view it led without its proposal being committed,
+// and recovers for each of its proposals that is committed.
+// The reputations are only kept in memory, so they are reset when the replica restarts.
+func NewCommitReputation(cfg ReputationConfig) consensus.LeaderRotation {
+	return &commitReputation{
+		cfg:        cfg,
+		reputation: make(map[hotstuff.ID]uint64),
+		last:       consensus.GetGenesis(),
+	}
+}
+
+var _ consensus.ProposerCreditor = (*commitReputation)(nil)
@@ -0,0 +1,102 @@
+package leaderrotation_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
+)
+
+// TestCommitReputation checks that a leader whose proposals are never committed is selected less and less often,
+// but is never excluded, and that all replicas that credit the same blocks select the same leader for each view.
+func TestCommitReputation(t *testing.T) {
+	const (
+		faulty hotstuff.ID = 4
+		views              = 400
+		early              = 20
+	)
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Register(leaderrotation.NewCommitReputation(leaderrotation.ReputationConfig{
+			Penalty:       50,
+			Reward:        10,
+			MinReputation: 10,
+		}))
+	}
+	builders.Build()
+
+	// the faulty replica fails every view it leads, and the blocks of the other leaders are committed.
+	var selected []consensus.View
+	parent := consensus.GetGenesis()
+	for view := consensus.View(1); view <= views; view++ {
+		leader := network.Node(1).Modules().LeaderRotation().GetLeader(view)
+		for _, node := range network.Nodes() {
+			if got := node.Modules().LeaderRotation().GetLeader(view); got != leader {
+				t.Fatalf("view %d: replica %d selected leader %d, replica 1 selected leader %d", view, node.ID(), got, leader)
+			}
+		}
+		if leader == faulty {
+			selected = append(selected, view)
+			continue
+		}
+		block := consensus.NewBlock(parent.Hash(), consensus.NewQuorumCert(nil, parent.View(), parent.Hash()), consensus.Command(fmt.Sprint(view)), view, leader)
+		for _, node := range network.Nodes() {
+			node.Modules().LeaderRotation().(consensus.ProposerCreditor).CreditProposer(block)
+		}
+		parent = block
+	}
+
+	t.Logf("the faulty replica was selected in views %v", selected)
+	first, last := 0, 0
+	for _, view := range selected {
+		if view <= early {
+			first++
+		}
+		if view > views/2 {
+			last++
+		}
+	}
+	if first < 2 {
+		t.Errorf("the faulty replica was selected %d times in the first %d views, want at least 2", first, early)
+	}
+	// the share of views led by the faulty replica drops from about one quarter to its minimum reputation
+	// relative to the other replicas, that is 10 / 310.
+	if last == 0 || float64(last)/(views/2) >= float64(first)/early {
+		t.Errorf("the faulty replica was selected %d times in the last %d views, want a smaller share than in the first %d views, but more than zero",
+			last, views/2, early)
+	}
+}
+
+// TestCommitReputationCredit checks that the consensus credits the proposers of committed blocks,
+// such that the replicas agree on the leaders and keep committing blocks.
+func TestCommitReputationCredit(t *testing.T) {
+	const minCommit = 20
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Register(leaderrotation.NewCommitReputation(leaderrotation.ReputationConfig{Penalty: 50, Reward: 10}))
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	node := network.Node(1)
+	go func() {
+		for ctx.Err() == nil {
+			if len(node.Executed()) >= minCommit {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	if executed := len(node.Executed()); executed < minCommit {
+		t.Fatalf("expected at least %d committed blocks, got %d", minCommit, executed)
+	}
+}
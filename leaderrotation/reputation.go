@@ -1,30 +1,47 @@
 package leaderrotation
 
 import (
-	"fmt"
+	"encoding/binary"
 	"hash/fnv"
 	"math/rand"
-	"strconv"
-
-	wr "github.com/mroth/weightedrand"
+	"sort"
 
 	"github.com/relab/hotstuff"
 	"github.com/relab/hotstuff/consensus"
 )
 
+// TieBreak decides the order of replicas with equal reputation in the reputation-based leader rotation.
+// The leader is drawn from the replicas in this order, so every replica must use the same tie-break
+// to select the same leader for a view.
+type TieBreak int
+
+const (
+	// LowestID orders replicas with equal reputation by ascending ID.
+	LowestID TieBreak = iota
+	// ViewHash orders replicas with equal reputation by a hash of the view and their ID,
+	// such that the order of tied replicas changes from view to view. Hash collisions are ordered by ascending ID.
+	ViewHash
+)
+
 type repBased struct {
-	mods        *consensus.Modules
-	replicaList []wr.Choice
+	mods     *consensus.Modules
+	tieBreak TieBreak
+}
+
+// candidate is a replica that can be selected as leader, weighted by its reputation.
+type candidate struct {
+	id     hotstuff.ID
+	weight uint
+	key    uint64 // the tie-break key
 }
 
-//InitConsensusModule gives the module a reference to the Modules object.
-//It also allows the module to set module options using the OptionsBuilder
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder
 func (r *repBased) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
 	r.mods = mods
-	r.replicaList = []wr.Choice{}
 }
 
-//GetLeader returns the id of the leader in the given view
+// GetLeader returns the id of the leader in the given view
 func (r repBased) GetLeader(view consensus.View) hotstuff.ID {
 	commit_head := r.mods.Consensus().CommittedBlock() //fetch previous comitted block
 	numReplicas := r.mods.Configuration().Len()
@@ -32,7 +49,6 @@ func (r repBased) GetLeader(view consensus.View) hotstuff.ID {
 	h := fnv.New32a()
 	h.Write([]byte(blockHash))
 	hashInt := h.Sum32()
-	//rand.Seed(int64(hashInt))
 
 	if int(view) <= numReplicas+10 {
 		return hotstuff.ID(view%consensus.View(numReplicas) + 1)
@@ -46,29 +62,77 @@ func (r repBased) GetLeader(view consensus.View) hotstuff.ID {
 	frac := float64((2.0 / 3.0) * float64(numReplicas))
 	reputation := ((numVotes - frac) / frac)
 
+	candidates := make([]candidate, 0, numReplicas)
 	voters.ForEach(func(voterID hotstuff.ID) {
 		currentVoter, ok := r.mods.Configuration().Replica(voterID)
 		if !ok {
-			r.mods.Logger().Info("Failed fetching current replica", currentVoter)
+			r.mods.Logger().Info("Failed fetching current replica", voterID)
+			return
+		}
+		currentVoter.UpdateRep(reputation)
+		candidates = append(candidates, candidate{
+			id:     voterID,
+			weight: uint(currentVoter.GetRep() * 10),
+			key:    r.tieBreakKey(view, voterID),
+		})
+	})
+
+	// the participants are not ordered, so the candidates are sorted by reputation, and then by the tie-break,
+	// such that every replica draws from the same sequence.
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.weight != b.weight {
+			return a.weight > b.weight
+		}
+		if a.key != b.key {
+			return a.key < b.key
 		}
-		if currentVoter.ID() == voterID {
-				currentVoter.UpdateRep(reputation)
-		} 
-		r.replicaList = append(r.replicaList, wr.Choice{Item: strconv.Itoa(int(currentVoter.ID())), Weight: uint(currentVoter.GetRep()*10)}) 
+		return a.id < b.id
 	})
-	//fmt.Println("the list", r.replicaList)
-	chooser, err := wr.NewChooser(r.replicaList...)
-	if err != nil {
-		fmt.Println(err)
+
+	var total uint64
+	for _, c := range candidates {
+		total += uint64(c.weight)
+	}
+	if total == 0 {
+		// no replica has a positive reputation, so the first replica in the tie-break order leads.
+		if len(candidates) == 0 {
+			return hotstuff.ID(view%consensus.View(numReplicas) + 1)
+		}
+		return candidates[0].id
 	}
 	rs := rand.New(rand.NewSource(int64(hashInt)))
-	resultLeader := chooser.PickSource(rs).(string)
-	intLeader, _ := strconv.Atoi(resultLeader)
-	fmt.Println("picked leader", intLeader, "with seed", hashInt, "from",  r.mods.ID())
-	return hotstuff.ID(intLeader)
+	pick := uint64(rs.Int63n(int64(total)))
+	for _, c := range candidates {
+		if pick < uint64(c.weight) {
+			return c.id
+		}
+		pick -= uint64(c.weight)
+	}
+	return candidates[len(candidates)-1].id
 }
 
-//NewRepBased returns a new random reputation-based leader rotation implementation
+// tieBreakKey returns the key that orders the replica with the given ID among replicas with equal reputation.
+func (r repBased) tieBreakKey(view consensus.View, id hotstuff.ID) uint64 {
+	if r.tieBreak != ViewHash {
+		return uint64(id)
+	}
+	var b [12]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(view))
+	binary.BigEndian.PutUint32(b[8:], uint32(id))
+	h := fnv.New64a()
+	h.Write(b[:])
+	return h.Sum64()
+}
+
+// NewRepBased returns a new random reputation-based leader rotation implementation
+// that orders replicas with equal reputation by ascending ID.
 func NewRepBased() consensus.LeaderRotation {
-	return &repBased{}
+	return &repBased{tieBreak: LowestID}
+}
+
+// NewRepBasedWithTieBreak returns a new random reputation-based leader rotation implementation
+// that orders replicas with equal reputation using the given tie-break.
+func NewRepBasedWithTieBreak(tieBreak TieBreak) consensus.LeaderRotation {
+	return &repBased{tieBreak: tieBreak}
 }
@@ -0,0 +1,60 @@
+package leaderrotation_test
+
+import (
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
+)
+
+// TestRepBasedTieBreak checks that replicas with equal reputation are ordered by the tie-break,
+// such that all replicas select the same leader for each view.
+func TestRepBasedTieBreak(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		tieBreak leaderrotation.TieBreak
+	}{
+		{"LowestID", leaderrotation.LowestID},
+		{"ViewHash", leaderrotation.ViewHash},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			network, builders := testutil.CreateNetwork(t, 4)
+			for _, builder := range builders {
+				builder.Register(leaderrotation.NewRepBasedWithTieBreak(tt.tieBreak))
+			}
+			hl := builders.Build()
+			signers := hl.Signers()
+
+			// every replica signed the QC of the committed block, so they all have the same reputation.
+			genesis := consensus.GetGenesis()
+			b1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "b1", 1, 2)
+			b2 := consensus.NewBlock(b1.Hash(), testutil.CreateQC(t, b1, signers), "b2", 2, 3)
+			qc := testutil.CreateQC(t, b2, signers)
+			for _, hs := range hl {
+				hs.BlockChain().Store(b1)
+				hs.BlockChain().Store(b2)
+				if err := testutil.ForceCommit(hs, qc); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			leaders := make(map[hotstuff.ID]bool)
+			for view := consensus.View(20); view < 60; view++ {
+				leader := hl[0].LeaderRotation().GetLeader(view)
+				for _, node := range network.Nodes() {
+					for i := 0; i < 3; i++ {
+						if got := node.Modules().LeaderRotation().GetLeader(view); got != leader {
+							t.Fatalf("view %d: replica %d selected leader %d, replica 1 selected leader %d", view, node.ID(), got, leader)
+						}
+					}
+				}
+				leaders[leader] = true
+			}
+			if tt.tieBreak == leaderrotation.ViewHash && len(leaders) == 1 {
+				t.Error("expected the tied replicas to take turns leading")
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package leaderrotation_test
+
+import (
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
+)
+
+// TestRoundRobin checks that the leader of each view is the replica with ID view%n+1, at every replica.
+func TestRoundRobin(t *testing.T) {
+	const n = 4
+	network, builders := testutil.CreateNetwork(t, n)
+	for _, builder := range builders {
+		builder.Register(leaderrotation.NewRoundRobin())
+	}
+	builders.Build()
+
+	for view := consensus.View(0); view < 3*n; view++ {
+		want := hotstuff.ID(view%n + 1)
+		for _, node := range network.Nodes() {
+			if got := node.Modules().LeaderRotation().GetLeader(view); got != want {
+				t.Errorf("view %d: replica %d selected leader %d, want %d", view, node.ID(), got, want)
+			}
+		}
+	}
+}
+
+// TestFixed checks that the configured leader leads every view, at every replica.
+func TestFixed(t *testing.T) {
+	const leader hotstuff.ID = 3
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Register(leaderrotation.NewFixed(leader))
+	}
+	builders.Build()
+
+	for view := consensus.View(0); view < 12; view++ {
+		for _, node := range network.Nodes() {
+			if got := node.Modules().LeaderRotation().GetLeader(view); got != leader {
+				t.Errorf("view %d: replica %d selected leader %d, want %d", view, node.ID(), got, leader)
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package leaderrotation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+// stakeUpdatePrefix marks a command that sets the stake of a replica.
+const stakeUpdatePrefix = "hotstuff/stake\x00"
+
+// EncodeStakeUpdate returns a command that sets the stake of the replica with the given ID once it is committed.
+// The acceptor and executor of the application must let the command through like any other command.
+func EncodeStakeUpdate(id hotstuff.ID, stake uint64) consensus.Command {
+	var b [12]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(id))
+	binary.BigEndian.PutUint64(b[4:], stake)
+	return consensus.Command(stakeUpdatePrefix + string(b[:]))
+}
+
+// DecodeStakeUpdate returns the replica ID and stake of a command created by EncodeStakeUpdate.
+// The 'ok' return value is false if the command is not a stake update.
+func DecodeStakeUpdate(cmd consensus.Command) (id hotstuff.ID, stake uint64, ok bool) {
+	b := []byte(cmd)
+	if len(b) != len(stakeUpdatePrefix)+12 || !bytes.HasPrefix(b, []byte(stakeUpdatePrefix)) {
+		return 0, 0, false
+	}
+	b = b[len(stakeUpdatePrefix):]
+	return hotstuff.ID(binary.BigEndian.Uint32(b[:4])), binary.BigEndian.Uint64(b[4:]), true
+}
+
+// StakeState is the stake table of the stake-based leader rotation after a committed block.
+type StakeState struct {
+	// Block is the hash of the last committed block whose stake update and proposer are included.
+	Block consensus.Hash
+	// Stakes are the stakes of the replicas after the committed stake updates.
+	Stakes map[hotstuff.ID]uint64
+	// Recent are the proposers of the most recent committed blocks, starting with the proposer of Block.
+	Recent []hotstuff.ID
+}
+
+// StakeStore durably stores the stake table, such that a replica that restarts selects the same leaders
+// as the other replicas.
+type StakeStore interface {
+	// SaveStakes durably stores the stake table. It is called after each committed block.
+	SaveStakes(state StakeState) error
+	// LoadStakes returns the stored stake table. If no stake table has been stored, ok is false.
+	LoadStakes() (state StakeState, ok bool, err error)
+}
+
+// StakeConfig configures the stake-based leader rotation.
+type StakeConfig struct {
+	// Stakes are the stakes of the replicas until stake updates are committed. Replicas without a stake cannot lead.
+	Stakes map[hotstuff.ID]uint64
+	// Window is the number of committed blocks whose proposers count as recent leaders.
+	Window int
+	// Decay is the percentage, from 0 to 100, that the stake of a replica is reduced by for each block
+	// it proposed among the recent committed blocks.
+	Decay uint
+	// MaxConsecutive is the number of consecutive committed blocks that a replica can propose before it is passed over
+	// for the next view. Zero means no limit.
+	MaxConsecutive int
+	// Store persists the stake table. If nil, the stake table is stored in the StateStore of the replica
+	// if it is also a StakeStore, such as store.FileStore. Otherwise, the stake table is only kept in memory,
+	// and a replica that restarts starts over from the configured stakes.
+	Store StakeStore
+}
+
+type stakeBased struct {
+	mods *consensus.Modules
+	cfg  StakeConfig
+
+	mut    sync.Mutex
+	loaded bool // whether the stake table was loaded from the store
+	state  StakeState
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (s *stakeBased) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	s.mods = mods
+	if s.cfg.Store == nil {
+		if store, ok := mods.StateStore().(StakeStore); ok {
+			s.cfg.Store = store
+		}
+	}
+}
+
+// GetLeader returns the id of the leader in the given view.
+// The leader is drawn at random, weighted by the stakes of the replicas after the committed stake updates,
+// where the stake of a replica decays for each recent committed block that it proposed.
+// The draw is seeded by the last committed block and the view, so replicas that have committed the same blocks
+// select the same leader. Replicas that disagree on the leader time out the view.
+func (s *stakeBased) GetLeader(view consensus.View) hotstuff.ID {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.load()
+
+	leads := make(map[hotstuff.ID]int)
+	for i, id := range s.state.Recent {
+		if i < s.cfg.Window {
+			leads[id]++
+		}
+	}
+	var excluded hotstuff.ID
+	if max := s.cfg.MaxConsecutive; max > 0 && len(s.state.Recent) >= max {
+		excluded = s.state.Recent[0]
+		for _, id := range s.state.Recent[1:max] {
+			if id != excluded {
+				excluded = 0
+				break
+			}
+		}
+	}
+
+	candidates := make([]candidate, 0, len(s.state.Stakes))
+	for id, stake := range s.state.Stakes {
+		if id == excluded || stake == 0 {
+			continue
+		}
+		weight := stake
+		for i := 0; i < leads[id] && weight > 0; i++ {
+			weight -= weight * uint64(s.cfg.Decay) / 100
+		}
+		candidates = append(candidates, candidate{id: id, weight: uint(weight)})
+	}
+	// the stakes are stored in a map, so the candidates are sorted such that every replica draws from the same sequence.
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].id < candidates[j].id })
+
+	var total uint64
+	for _, c := range candidates {
+		total += uint64(c.weight)
+	}
+	if total == 0 {
+		// every replica is out of stake, or has decayed to nothing, so the leader is chosen by round-robin.
+		return hotstuff.ID(uint64(view)%uint64(s.mods.Configuration().Len()) + 1)
+	}
+
+	var viewBuf [8]byte
+	binary.BigEndian.PutUint64(viewBuf[:], uint64(view))
+	hash := s.state.Block
+	seed := sha256.Sum256(append(hash[:], viewBuf[:]...))
+	pick := binary.BigEndian.Uint64(seed[:8]) % total
+	for _, c := range candidates {
+		if pick < uint64(c.weight) {
+			return c.id
+		}
+		pick -= uint64(c.weight)
+	}
+	return candidates[len(candidates)-1].id
+}
+
+// CreditProposer applies the stake update of the committed block, if any, and records its proposer
+// as a recent leader. The stake table is then saved to the store, if one is configured.
+func (s *stakeBased) CreditProposer(block *consensus.Block) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.load()
+
+	if id, stake, ok := DecodeStakeUpdate(block.Command()); ok {
+		s.state.Stakes[id] = stake
+	}
+	window := s.cfg.Window
+	if s.cfg.MaxConsecutive > window {
+		window = s.cfg.MaxConsecutive
+	}
+	s.state.Recent = append([]hotstuff.ID{block.Proposer()}, s.state.Recent...)
+	if len(s.state.Recent) > window {
+		s.state.Recent = s.state.Recent[:window]
+	}
+	s.state.Block = block.Hash()
+
+	if s.cfg.Store != nil {
+		if err := s.cfg.Store.SaveStakes(s.state); err != nil {
+			s.mods.Logger().Warnf("Failed to save the stake table: %v", err)
+		}
+	}
+}
+
+// load initializes the stake table from the store, or from the configured stakes if none was stored.
+// The caller must hold the lock.
+func (s *stakeBased) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	if s.cfg.Store != nil {
+		state, ok, err := s.cfg.Store.LoadStakes()
+		if err != nil {
+			s.mods.Logger().Warnf("Failed to load the stake table: %v", err)
+		} else if ok {
+			s.state = state
+			return
+		}
+	}
+	s.state = StakeState{Block: consensus.GetGenesis().Hash(), Stakes: make(map[hotstuff.ID]uint64, len(s.cfg.Stakes))}
+	for id, stake := range s.cfg.Stakes {
+		s.state.Stakes[id] = stake
+	}
+}
+
+// NewStakeBased returns a new leader rotation that selects leaders weighted by their committed stake,
+// where the stake of recent leaders decays to spread leadership among the replicas.
+// The stakes can be changed by committing commands created by EncodeStakeUpdate.
+func NewStakeBased(cfg StakeConfig) consensus.LeaderRotation {
+	return &stakeBased{cfg: cfg}
+}
+
+var _ consensus.ProposerCreditor = (*stakeBased)(nil)
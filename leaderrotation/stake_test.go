@@ -0,0 +1,142 @@
+package leaderrotation_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
+	"github.com/relab/hotstuff/store"
+)
+
+// TestStakeBased checks that replicas with more stake lead more often, that no replica leads more than
+// MaxConsecutive views in a row, that committed stake updates change the leaders, and that all replicas
+// select the same leader for each view.
+func TestStakeBased(t *testing.T) {
+	const maxConsecutive = 3
+	network, builders := testutil.CreateNetwork(t, 4)
+	for _, builder := range builders {
+		builder.Register(leaderrotation.NewStakeBased(leaderrotation.StakeConfig{
+			Stakes:         map[hotstuff.ID]uint64{1: 1000, 2: 100, 3: 100, 4: 100},
+			Window:         4,
+			Decay:          25,
+			MaxConsecutive: maxConsecutive,
+		}))
+	}
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	parent := consensus.GetGenesis()
+	qc := consensus.NewQuorumCert(nil, 0, parent.Hash())
+	// commit proposes a block with the given command from the leader of the view, and commits it at every replica.
+	commit := func(view consensus.View, cmd consensus.Command) hotstuff.ID {
+		leader := hl[0].LeaderRotation().GetLeader(view)
+		for _, node := range network.Nodes() {
+			if got := node.Modules().LeaderRotation().GetLeader(view); got != leader {
+				t.Fatalf("view %d: replica %d selected leader %d, replica 1 selected leader %d", view, node.ID(), got, leader)
+			}
+		}
+		block := consensus.NewBlock(parent.Hash(), qc, cmd, view, leader)
+		blockQC := testutil.CreateQC(t, block, signers)
+		for _, hs := range hl {
+			hs.BlockChain().Store(block)
+			if err := testutil.ForceCommit(hs, blockQC); err != nil {
+				t.Fatal(err)
+			}
+			if committed := hs.Consensus().CommittedBlock(); committed != block {
+				t.Fatalf("replica %d committed %v, want %v", hs.ID(), committed, block)
+			}
+		}
+		parent, qc = block, blockQC
+		return leader
+	}
+
+	count := func(from, to consensus.View, cmd func(view consensus.View) consensus.Command) map[hotstuff.ID]int {
+		leads := make(map[hotstuff.ID]int)
+		var last hotstuff.ID
+		run := 0
+		for view := from; view < to; view++ {
+			leader := commit(view, cmd(view))
+			leads[leader]++
+			if leader == last {
+				run++
+			} else {
+				last, run = leader, 1
+			}
+			if run > maxConsecutive {
+				t.Fatalf("view %d: replica %d led %d views in a row", view, leader, run)
+			}
+		}
+		return leads
+	}
+	command := func(view consensus.View) consensus.Command {
+		return consensus.Command(fmt.Sprintf("cmd-%d", view))
+	}
+
+	leads := count(1, 201, command)
+	for id := hotstuff.ID(2); id <= 4; id++ {
+		if leads[1] <= leads[id] {
+			t.Errorf("replica 1 led %d views, which is not more than the %d views of replica %d: %v", leads[1], leads[id], id, leads)
+		}
+	}
+	if leads[1] == 200 {
+		t.Errorf("replica 1 led every view: %v", leads)
+	}
+
+	// replica 4 gets the most stake, and replica 1 loses its stake.
+	commit(201, leaderrotation.EncodeStakeUpdate(4, 2000))
+	commit(202, leaderrotation.EncodeStakeUpdate(1, 0))
+	leads = count(203, 403, command)
+	if leads[1] != 0 {
+		t.Errorf("replica 1 led %d views without stake", leads[1])
+	}
+	for id := hotstuff.ID(2); id <= 3; id++ {
+		if leads[4] <= leads[id] {
+			t.Errorf("replica 4 led %d views, which is not more than the %d views of replica %d: %v", leads[4], leads[id], id, leads)
+		}
+	}
+}
+
+// TestStakeBasedRestart checks that a replica that restarts with the stored stake table selects the same leaders
+// as before the restart, even though the blocks that updated the stakes are no longer available.
+// The stake table is stored in the file store that is registered with the replica.
+func TestStakeBasedRestart(t *testing.T) {
+	cfg := leaderrotation.StakeConfig{
+		Stakes: map[hotstuff.ID]uint64{1: 100, 2: 100, 3: 100, 4: 100},
+		Window: 4,
+		Decay:  25,
+	}
+	dir := t.TempDir()
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders[0].Register(leaderrotation.NewStakeBased(cfg), store.NewFileStore(dir))
+	hl := builders.Build()
+	hs := hl[0]
+
+	parent := consensus.GetGenesis()
+	qc := consensus.NewQuorumCert(nil, 0, parent.Hash())
+	for view := consensus.View(1); view <= 10; view++ {
+		cmd := consensus.Command(fmt.Sprintf("cmd-%d", view))
+		if view == 5 {
+			cmd = leaderrotation.EncodeStakeUpdate(3, 5000)
+		}
+		block := consensus.NewBlock(parent.Hash(), qc, cmd, view, hs.LeaderRotation().GetLeader(view))
+		blockQC := testutil.CreateQC(t, block, hl.Signers())
+		hs.BlockChain().Store(block)
+		if err := testutil.ForceCommit(hs, blockQC); err != nil {
+			t.Fatal(err)
+		}
+		parent, qc = block, blockQC
+	}
+
+	// the restarted replica has no blocks other than the genesis block.
+	restarted := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	restarted.Register(network.Node(1).Modules().Configuration(), leaderrotation.NewStakeBased(cfg), store.NewFileStore(dir))
+	mods := restarted.Build()
+	for view := consensus.View(11); view < 50; view++ {
+		if got, want := mods.LeaderRotation().GetLeader(view), hs.LeaderRotation().GetLeader(view); got != want {
+			t.Errorf("view %d: restarted replica selected leader %d, want %d", view, got, want)
+		}
+	}
+}
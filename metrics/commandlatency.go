@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/clientpb"
+	"github.com/relab/hotstuff/metrics/types"
+	"github.com/relab/hotstuff/modules"
+	"google.golang.org/protobuf/proto"
+)
+
+func init() {
+	RegisterReplicaMetric("command-latency", func() interface{} {
+		return &CommandLatency{}
+	})
+}
+
+// CommandID identifies a client command.
+type CommandID struct {
+	ClientID       uint32
+	SequenceNumber uint64
+}
+
+// CommandLatencies holds the latencies of a single command, measured from the time the client submitted it.
+type CommandLatencies struct {
+	Commit time.Duration // The time until the command's block was committed.
+	Exec   time.Duration // The time until the command's block was executed.
+}
+
+// CommandLatency measures the latency of each committed command, from submission to commit and execution.
+// The latencies are recorded from BlockCommittedEvents, and percentiles are written to the metrics logger on every tick.
+// Note that the latencies depend on the clocks of the clients and replicas being synchronized.
+type CommandLatency struct {
+	mut         sync.Mutex
+	mods        *modules.Modules
+	unmarshaler proto.UnmarshalOptions
+	latencies   map[CommandID]CommandLatencies // latencies of the commands committed since the last tick
+}
+
+// InitModule gives the module access to the other modules.
+func (cl *CommandLatency) InitModule(mods *modules.Modules) {
+	cl.mods = mods
+	cl.unmarshaler = proto.UnmarshalOptions{DiscardUnknown: true}
+	cl.latencies = make(map[CommandID]CommandLatencies)
+
+	cl.mods.MetricsEventLoop().RegisterHandler(consensus.BlockCommittedEvent{}, func(event interface{}) {
+		cl.recordBlock(event.(consensus.BlockCommittedEvent))
+	})
+
+	cl.mods.MetricsEventLoop().RegisterObserver(types.TickEvent{}, func(event interface{}) {
+		cl.tick(event.(types.TickEvent))
+	})
+
+	cl.mods.Logger().Info("Command Latency metric enabled")
+}
+
+// Latency returns the latencies of the command with the given ID,
+// if it was committed since the last tick.
+func (cl *CommandLatency) Latency(id CommandID) (latencies CommandLatencies, ok bool) {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+	latencies, ok = cl.latencies[id]
+	return latencies, ok
+}
+
+// Percentiles returns the p-th percentile of the commit and execution latencies
+// of the commands committed since the last tick. p must be in the range (0, 100].
+func (cl *CommandLatency) Percentiles(p float64) (commit, exec time.Duration) {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+	commits, execs := cl.sorted()
+	return percentile(commits, p), percentile(execs, p)
+}
+
+func (cl *CommandLatency) recordBlock(event consensus.BlockCommittedEvent) {
+	batch := new(clientpb.Batch)
+	err := cl.unmarshaler.Unmarshal([]byte(event.Block.Command()), batch)
+	if err != nil {
+		cl.mods.Logger().Errorf("Failed to unmarshal batch: %v", err)
+		return
+	}
+
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	for _, cmd := range batch.GetCommands() {
+		if cmd.GetSubmitTime() == nil {
+			continue
+		}
+		submitTime := cmd.GetSubmitTime().AsTime()
+		id := CommandID{cmd.GetClientID(), cmd.GetSequenceNumber()}
+		cl.latencies[id] = CommandLatencies{
+			Commit: event.CommitTime.Sub(submitTime),
+			Exec:   event.ExecTime.Sub(submitTime),
+		}
+	}
+}
+
+// sorted returns the commit and execution latencies in ascending order.
+func (cl *CommandLatency) sorted() (commits, execs []time.Duration) {
+	commits = make([]time.Duration, 0, len(cl.latencies))
+	execs = make([]time.Duration, 0, len(cl.latencies))
+	for _, latencies := range cl.latencies {
+		commits = append(commits, latencies.Commit)
+		execs = append(execs, latencies.Exec)
+	}
+	sort.Slice(commits, func(i, j int) bool { return commits[i] < commits[j] })
+	sort.Slice(execs, func(i, j int) bool { return execs[i] < execs[j] })
+	return commits, execs
+}
+
+func (cl *CommandLatency) tick(tick types.TickEvent) {
+	cl.mut.Lock()
+	defer cl.mut.Unlock()
+
+	commits, execs := cl.sorted()
+	millis := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	event := &types.CommandLatencyMeasurement{
+		Event:     types.NewReplicaEvent(uint32(cl.mods.ID()), time.Now()),
+		Count:     uint64(len(commits)),
+		CommitP50: millis(percentile(commits, 50)),
+		CommitP90: millis(percentile(commits, 90)),
+		CommitP99: millis(percentile(commits, 99)),
+		ExecP50:   millis(percentile(execs, 50)),
+		ExecP90:   millis(percentile(execs, 90)),
+		ExecP99:   millis(percentile(execs, 99)),
+	}
+	cl.mods.MetricsLogger().Log(event)
+	cl.latencies = make(map[CommandID]CommandLatencies)
+}
+
+// percentile returns the p-th percentile of the sorted values, using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/clientpb"
+	"github.com/relab/hotstuff/modules"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestCommandLatency(t *testing.T) {
+	builder := modules.NewBuilder(1)
+	cl := &CommandLatency{}
+	builder.Register(cl)
+	builder.Build()
+
+	now := time.Now()
+
+	// commands 1-10 from client 1 are submitted 10ms, 20ms, ..., 100ms before the commit.
+	// commands 1-10 from client 2 are submitted at the same time as the commit.
+	batch := new(clientpb.Batch)
+	for i := 1; i <= 10; i++ {
+		batch.Commands = append(batch.Commands, &clientpb.Command{
+			ClientID:       1,
+			SequenceNumber: uint64(i),
+			SubmitTime:     timestamppb.New(now.Add(-time.Duration(i) * 10 * time.Millisecond)),
+		}, &clientpb.Command{
+			ClientID:       2,
+			SequenceNumber: uint64(i),
+			SubmitTime:     timestamppb.New(now),
+		})
+	}
+	// a command without a submission time is not recorded.
+	batch.Commands = append(batch.Commands, &clientpb.Command{ClientID: 3, SequenceNumber: 1})
+
+	b, err := proto.Marshal(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := consensus.NewBlock(consensus.GetGenesis().Hash(), consensus.QuorumCert{}, consensus.Command(b), 1, 1)
+	cl.recordBlock(consensus.BlockCommittedEvent{
+		Block:      block,
+		CommitTime: now,
+		ExecTime:   now.Add(5 * time.Millisecond),
+	})
+
+	for i := 1; i <= 10; i++ {
+		latencies, ok := cl.Latency(CommandID{1, uint64(i)})
+		if !ok {
+			t.Fatalf("no latency recorded for command %d from client 1", i)
+		}
+		if want := time.Duration(i) * 10 * time.Millisecond; latencies.Commit != want {
+			t.Errorf("command %d from client 1: commit latency: got: %v, want: %v", i, latencies.Commit, want)
+		}
+		if want := time.Duration(i)*10*time.Millisecond + 5*time.Millisecond; latencies.Exec != want {
+			t.Errorf("command %d from client 1: exec latency: got: %v, want: %v", i, latencies.Exec, want)
+		}
+
+		latencies, ok = cl.Latency(CommandID{2, uint64(i)})
+		if !ok {
+			t.Fatalf("no latency recorded for command %d from client 2", i)
+		}
+		if latencies.Commit != 0 || latencies.Exec != 5*time.Millisecond {
+			t.Errorf("command %d from client 2: got: %+v, want: {Commit: 0s, Exec: 5ms}", i, latencies)
+		}
+	}
+	if _, ok := cl.Latency(CommandID{3, 1}); ok {
+		t.Error("expected no latency for a command without a submission time")
+	}
+
+	// 20 commands: 10 with commit latency 0, and 10 with commit latencies 10ms to 100ms.
+	commit, exec := cl.Percentiles(50)
+	if commit != 0 || exec != 5*time.Millisecond {
+		t.Errorf("50th percentile: got: (%v, %v), want: (0s, 5ms)", commit, exec)
+	}
+	commit, exec = cl.Percentiles(90)
+	if commit != 80*time.Millisecond || exec != 85*time.Millisecond {
+		t.Errorf("90th percentile: got: (%v, %v), want: (80ms, 85ms)", commit, exec)
+	}
+	commit, _ = cl.Percentiles(100)
+	if commit != 100*time.Millisecond {
+		t.Errorf("100th percentile: got: %v, want: 100ms", commit)
+	}
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/metrics/types"
+	"github.com/relab/hotstuff/modules"
+)
+
+func init() {
+	RegisterReplicaMetric("critical-path", func() interface{} {
+		return NewCriticalPath()
+	})
+}
+
+// CriticalPathReport describes which replicas' votes completed the quorums of the committed blocks.
+type CriticalPathReport struct {
+	Blocks         int                 // The number of committed blocks for which the replica formed the quorum certificate.
+	CriticalVoters map[hotstuff.ID]int // The number of those blocks for which each replica's vote completed the quorum.
+}
+
+// CriticalPath records which replica's vote completed the quorum certificate of each committed block,
+// that is, the replica on the critical path of the block. A replica that is rarely on the critical path
+// votes faster than a quorum of the other replicas, while a replica that is often on the critical path is a straggler
+// that holds back the others. A replica only knows the critical voters of the quorum certificates that it formed itself,
+// so the reports of all replicas must be combined to cover every block.
+// The counts are written to the metrics logger on every tick.
+type CriticalPath struct {
+	mut      sync.Mutex
+	mods     *modules.Modules
+	voters   map[consensus.Hash]criticalVoter // the critical voters of the QCs that were formed, but not yet committed
+	blocks   int
+	critical map[hotstuff.ID]int
+}
+
+type criticalVoter struct {
+	view consensus.View
+	id   hotstuff.ID
+}
+
+// NewCriticalPath returns a new critical path metric.
+func NewCriticalPath() *CriticalPath {
+	return &CriticalPath{
+		voters:   make(map[consensus.Hash]criticalVoter),
+		critical: make(map[hotstuff.ID]int),
+	}
+}
+
+// InitModule gives the module access to the other modules.
+func (cp *CriticalPath) InitModule(mods *modules.Modules) {
+	cp.mods = mods
+
+	cp.mods.MetricsEventLoop().RegisterObserver(consensus.QCFormedEvent{}, func(event interface{}) {
+		cp.recordQC(event.(consensus.QCFormedEvent))
+	})
+
+	cp.mods.MetricsEventLoop().RegisterObserver(consensus.BlockCommittedEvent{}, func(event interface{}) {
+		cp.recordCommit(event.(consensus.BlockCommittedEvent))
+	})
+
+	cp.mods.MetricsEventLoop().RegisterObserver(types.TickEvent{}, func(event interface{}) {
+		cp.tick(event.(types.TickEvent))
+	})
+
+	cp.mods.Logger().Info("Critical path metric enabled")
+}
+
+func (cp *CriticalPath) recordQC(event consensus.QCFormedEvent) {
+	cp.mut.Lock()
+	defer cp.mut.Unlock()
+	cp.voters[event.QC.BlockHash()] = criticalVoter{view: event.QC.View(), id: event.CriticalVoter}
+}
+
+func (cp *CriticalPath) recordCommit(event consensus.BlockCommittedEvent) {
+	cp.mut.Lock()
+	defer cp.mut.Unlock()
+	if voter, ok := cp.voters[event.Block.Hash()]; ok {
+		cp.blocks++
+		cp.critical[voter.id]++
+		cp.mods.Logger().Debugf("Replica %d was on the critical path of block %.8s", voter.id, event.Block.Hash())
+	}
+	// the QCs of blocks that were committed or abandoned are no longer needed.
+	for hash, voter := range cp.voters {
+		if voter.view <= event.Block.View() {
+			delete(cp.voters, hash)
+		}
+	}
+}
+
+// Report returns the critical voters of the blocks committed since the last tick.
+func (cp *CriticalPath) Report() CriticalPathReport {
+	cp.mut.Lock()
+	defer cp.mut.Unlock()
+	report := CriticalPathReport{
+		Blocks:         cp.blocks,
+		CriticalVoters: make(map[hotstuff.ID]int, len(cp.critical)),
+	}
+	for id, count := range cp.critical {
+		report.CriticalVoters[id] = count
+	}
+	return report
+}
+
+func (cp *CriticalPath) tick(_ types.TickEvent) {
+	cp.mut.Lock()
+	defer cp.mut.Unlock()
+
+	m := &types.CriticalPathMeasurement{
+		Event:          types.NewReplicaEvent(uint32(cp.mods.ID()), time.Now()),
+		Blocks:         uint64(cp.blocks),
+		CriticalVoters: make(map[uint32]uint64, len(cp.critical)),
+	}
+	for id, count := range cp.critical {
+		m.CriticalVoters[uint32(id)] = uint64(count)
+	}
+	cp.mods.MetricsLogger().Log(m)
+	cp.blocks = 0
+	cp.critical = make(map[hotstuff.ID]int)
+}
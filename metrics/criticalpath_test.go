@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/internal/testutil"
+)
+
+// senderDelay delays the messages sent by each replica by a fixed duration.
+type senderDelay map[hotstuff.ID]time.Duration
+
+func (d senderDelay) Delay(from, _ hotstuff.ID, _ time.Time) time.Duration {
+	return d[from]
+}
+
+func TestCriticalPath(t *testing.T) {
+	const (
+		n    = 4
+		slow = hotstuff.ID(4)
+	)
+	network, builders := testutil.CreateNetwork(t, n)
+	network.SetDelayModel(senderDelay{slow: 20 * time.Millisecond})
+	metrics := make([]*CriticalPath, n)
+	for i, builder := range builders {
+		metrics[i] = NewCriticalPath()
+		builder.Register(metrics[i])
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(network.Node(1).Executed()) < 40 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	// combine the reports of all replicas to cover the blocks of every leader.
+	var blocks int
+	critical := make(map[hotstuff.ID]int)
+	for _, m := range metrics {
+		report := m.Report()
+		blocks += report.Blocks
+		for id, count := range report.CriticalVoters {
+			critical[id] += count
+		}
+	}
+	if blocks < 20 {
+		t.Fatalf("only %d blocks were reported", blocks)
+	}
+	t.Logf("critical voters of %d blocks: %v", blocks, critical)
+
+	// a quorum of the fast replicas can always vote before the slow replica.
+	if critical[slow] > blocks/10 {
+		t.Errorf("the slow replica was on the critical path of %d of %d blocks", critical[slow], blocks)
+	}
+	// the fast replicas complete the quorums of the remaining blocks, so at least one of them is often on the critical path.
+	var most int
+	for id, count := range critical {
+		if id != slow && count > most {
+			most = count
+		}
+	}
+	if most < blocks/4 {
+		t.Errorf("no fast replica was on the critical path of more than %d of %d blocks", most, blocks)
+	}
+}
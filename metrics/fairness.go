@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/clientpb"
+	"github.com/relab/hotstuff/metrics/types"
+	"github.com/relab/hotstuff/modules"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func init() {
+	RegisterReplicaMetric("fairness", func() interface{} {
+		return NewFairness(DefaultFairnessThreshold)
+	})
+}
+
+// DefaultFairnessThreshold is the Gini coefficient above which the fairness metric flags a leader as unfair.
+const DefaultFairnessThreshold = 0.25
+
+// ClientInclusion holds the delays from submission to inclusion in a proposal of the commands of a client.
+type ClientInclusion struct {
+	Count int
+	Mean  time.Duration
+	Max   time.Duration
+}
+
+// FairnessReport describes how fairly the leaders included the commands of the clients in their proposals.
+type FairnessReport struct {
+	Clients map[uint32]ClientInclusion // The inclusion delays of each client.
+	Gini    float64                    // The Gini coefficient of the clients' mean inclusion delays.
+	Leaders map[hotstuff.ID]float64    // The Gini coefficient of the clients' mean inclusion delays, for the proposals of each leader.
+	Unfair  []hotstuff.ID              // The leaders whose Gini coefficient exceeds the threshold, in ascending order.
+}
+
+// Fairness audits how fairly the leaders include the commands of the clients in their proposals.
+// It records the delay from the time a client submitted a command until the command was proposed,
+// for each client and leader, and flags the leaders for which the clients' mean delays are unequal,
+// as measured by the Gini coefficient. The report is written to the metrics logger on every tick.
+// Note that the delays depend on the clocks of the clients and replicas being synchronized.
+type Fairness struct {
+	mut         sync.Mutex
+	mods        *modules.Modules
+	unmarshaler proto.UnmarshalOptions
+	threshold   float64
+	delays      map[hotstuff.ID]map[uint32]*inclusion // the delays since the last tick, by leader and client
+}
+
+// inclusion accumulates inclusion delays.
+type inclusion struct {
+	count int
+	total time.Duration
+	max   time.Duration
+}
+
+func (in *inclusion) add(delay time.Duration) {
+	in.count++
+	in.total += delay
+	if delay > in.max {
+		in.max = delay
+	}
+}
+
+func (in *inclusion) mean() time.Duration {
+	if in.count == 0 {
+		return 0
+	}
+	return in.total / time.Duration(in.count)
+}
+
+// NewFairness returns a new fairness metric that flags leaders whose Gini coefficient exceeds the threshold.
+func NewFairness(threshold float64) *Fairness {
+	return &Fairness{
+		threshold:   threshold,
+		unmarshaler: proto.UnmarshalOptions{DiscardUnknown: true},
+		delays:      make(map[hotstuff.ID]map[uint32]*inclusion),
+	}
+}
+
+// InitModule gives the module access to the other modules.
+func (f *Fairness) InitModule(mods *modules.Modules) {
+	f.mods = mods
+
+	f.mods.MetricsEventLoop().RegisterObserver(consensus.ProposalReceivedEvent{}, func(event interface{}) {
+		f.recordProposal(event.(consensus.ProposalReceivedEvent), time.Now())
+	})
+
+	f.mods.MetricsEventLoop().RegisterObserver(types.TickEvent{}, func(event interface{}) {
+		f.tick(event.(types.TickEvent))
+	})
+
+	f.mods.Logger().Info("Fairness metric enabled")
+}
+
+func (f *Fairness) recordProposal(event consensus.ProposalReceivedEvent, received time.Time) {
+	batch := new(clientpb.Batch)
+	err := f.unmarshaler.Unmarshal([]byte(event.Block.Command()), batch)
+	if err != nil {
+		f.mods.Logger().Errorf("Failed to unmarshal batch: %v", err)
+		return
+	}
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	leader := event.Block.Proposer()
+	clients, ok := f.delays[leader]
+	if !ok {
+		clients = make(map[uint32]*inclusion)
+		f.delays[leader] = clients
+	}
+	for _, cmd := range batch.GetCommands() {
+		if cmd.GetSubmitTime() == nil {
+			continue
+		}
+		in, ok := clients[cmd.GetClientID()]
+		if !ok {
+			in = new(inclusion)
+			clients[cmd.GetClientID()] = in
+		}
+		in.add(received.Sub(cmd.GetSubmitTime().AsTime()))
+	}
+}
+
+// Report returns the fairness report for the proposals received since the last tick.
+func (f *Fairness) Report() FairnessReport {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return f.report()
+}
+
+func (f *Fairness) report() FairnessReport {
+	report := FairnessReport{
+		Clients: make(map[uint32]ClientInclusion),
+		Leaders: make(map[hotstuff.ID]float64),
+	}
+	total := make(map[uint32]*inclusion)
+	for leader, clients := range f.delays {
+		means := make([]float64, 0, len(clients))
+		for client, in := range clients {
+			means = append(means, float64(in.mean()))
+			t, ok := total[client]
+			if !ok {
+				t = new(inclusion)
+				total[client] = t
+			}
+			t.count += in.count
+			t.total += in.total
+			if in.max > t.max {
+				t.max = in.max
+			}
+		}
+		report.Leaders[leader] = gini(means)
+		if report.Leaders[leader] > f.threshold {
+			report.Unfair = append(report.Unfair, leader)
+		}
+	}
+	sort.Slice(report.Unfair, func(i, j int) bool { return report.Unfair[i] < report.Unfair[j] })
+
+	means := make([]float64, 0, len(total))
+	for client, in := range total {
+		report.Clients[client] = ClientInclusion{Count: in.count, Mean: in.mean(), Max: in.max}
+		means = append(means, float64(in.mean()))
+	}
+	report.Gini = gini(means)
+	return report
+}
+
+func (f *Fairness) tick(_ types.TickEvent) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	report := f.report()
+	m := &types.FairnessMeasurement{
+		Event: types.NewReplicaEvent(uint32(f.mods.ID()), time.Now()),
+		Gini:  report.Gini,
+	}
+	clients := make([]uint32, 0, len(report.Clients))
+	for client := range report.Clients {
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i] < clients[j] })
+	for _, client := range clients {
+		in := report.Clients[client]
+		m.Clients = append(m.Clients, &types.ClientInclusion{
+			ClientID:  client,
+			Count:     uint64(in.Count),
+			MeanDelay: durationpb.New(in.Mean),
+			MaxDelay:  durationpb.New(in.Max),
+		})
+	}
+	for _, leader := range report.Unfair {
+		m.UnfairLeaders = append(m.UnfairLeaders, uint32(leader))
+		f.mods.Logger().Warnf("Leader %d included the commands of some clients much later than others (Gini coefficient %.2f)",
+			leader, report.Leaders[leader])
+	}
+	f.mods.MetricsLogger().Log(m)
+	f.delays = make(map[hotstuff.ID]map[uint32]*inclusion)
+}
+
+// gini returns the Gini coefficient of the values, which is 0 if all values are equal,
+// and approaches 1 as the values become more unequal.
+func gini(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum, diffs float64
+	for _, x := range values {
+		sum += x
+		for _, y := range values {
+			diffs += math.Abs(x - y)
+		}
+	}
+	if sum == 0 {
+		return 0
+	}
+	n := float64(len(values))
+	return diffs / (2 * n * sum)
+}
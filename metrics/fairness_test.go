@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/clientpb"
+	"github.com/relab/hotstuff/metrics/types"
+	"github.com/relab/hotstuff/modules"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFairness(t *testing.T) {
+	builder := modules.NewBuilder(1)
+	f := NewFairness(DefaultFairnessThreshold)
+	logger := &recordingLogger{}
+	builder.Register(f, logger)
+	builder.Build()
+
+	now := time.Now()
+	var seq uint64
+	propose := func(leader hotstuff.ID, delays map[uint32]time.Duration) {
+		batch := new(clientpb.Batch)
+		for client, delay := range delays {
+			seq++
+			batch.Commands = append(batch.Commands, &clientpb.Command{
+				ClientID:       client,
+				SequenceNumber: seq,
+				SubmitTime:     timestamppb.New(now.Add(-delay)),
+			})
+		}
+		b, err := proto.Marshal(batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block := consensus.NewBlock(consensus.GetGenesis().Hash(), consensus.QuorumCert{}, consensus.Command(b), consensus.View(seq), leader)
+		f.recordProposal(consensus.ProposalReceivedEvent{ID: leader, Block: block}, now)
+	}
+
+	// leader 1 includes the commands of client 2 much later than those of client 1,
+	// while leader 2 includes the commands of both clients equally fast.
+	for i := 0; i < 5; i++ {
+		propose(1, map[uint32]time.Duration{1: 10 * time.Millisecond, 2: 200 * time.Millisecond})
+		propose(2, map[uint32]time.Duration{1: 10 * time.Millisecond, 2: 10 * time.Millisecond})
+	}
+
+	report := f.Report()
+	want := map[uint32]ClientInclusion{
+		1: {Count: 10, Mean: 10 * time.Millisecond, Max: 10 * time.Millisecond},
+		2: {Count: 10, Mean: 105 * time.Millisecond, Max: 200 * time.Millisecond},
+	}
+	for client, w := range want {
+		if got := report.Clients[client]; got != w {
+			t.Errorf("client %d: got %+v, want %+v", client, got, w)
+		}
+	}
+	approx := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	// the Gini coefficient of two values x < y is (y-x)/(2(x+y)).
+	if want := 190.0 / 420.0; !approx(report.Leaders[1], want) {
+		t.Errorf("leader 1: got Gini coefficient %v, want %v", report.Leaders[1], want)
+	}
+	if report.Leaders[2] != 0 {
+		t.Errorf("leader 2: got Gini coefficient %v, want 0", report.Leaders[2])
+	}
+	if want := 95.0 / 230.0; !approx(report.Gini, want) {
+		t.Errorf("got Gini coefficient %v, want %v", report.Gini, want)
+	}
+	if len(report.Unfair) != 1 || report.Unfair[0] != 1 {
+		t.Errorf("expected only leader 1 to be flagged as unfair, got %v", report.Unfair)
+	}
+
+	f.tick(types.TickEvent{})
+	if len(logger.messages) != 1 {
+		t.Fatalf("expected one measurement, got %d", len(logger.messages))
+	}
+	m, ok := logger.messages[0].(*types.FairnessMeasurement)
+	if !ok {
+		t.Fatalf("unexpected measurement type: %T", logger.messages[0])
+	}
+	if len(m.GetClients()) != 2 || m.GetClients()[1].GetMeanDelay().AsDuration() != 105*time.Millisecond {
+		t.Errorf("unexpected client measurements: %v", m.GetClients())
+	}
+	if len(m.GetUnfairLeaders()) != 1 || m.GetUnfairLeaders()[0] != 1 {
+		t.Errorf("expected leader 1 to be reported as unfair, got %v", m.GetUnfairLeaders())
+	}
+	if report := f.Report(); len(report.Clients) != 0 {
+		t.Error("expected the delays to be reset after the tick")
+	}
+}
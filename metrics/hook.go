@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"sync/atomic"
+
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/modules"
+	"github.com/relab/hotstuff/synchronizer"
+)
+
+// Hook receives callbacks about the progress of a replica, for example to export the progress to a monitoring system.
+// The callbacks are called from the metrics event loop, so they do not hold up the consensus protocol,
+// but they must return quickly, or the events that follow them will be delayed.
+type Hook interface {
+	// BlockCommitted is called when a block is committed, with the number of client commands that were executed.
+	BlockCommitted(view consensus.View, commands int)
+	// ViewChanged is called when the replica advances from one view to another.
+	ViewChanged(from, to consensus.View)
+	// VoteReceived is called when the replica receives a vote for a block in the given view.
+	VoteReceived(view consensus.View)
+}
+
+// NopHook is a Hook that ignores all callbacks.
+type NopHook struct{}
+
+// BlockCommitted does nothing.
+func (NopHook) BlockCommitted(consensus.View, int) {}
+
+// ViewChanged does nothing.
+func (NopHook) ViewChanged(consensus.View, consensus.View) {}
+
+// VoteReceived does nothing.
+func (NopHook) VoteReceived(consensus.View) {}
+
+// HookMetric calls a Hook with the events from the metrics event loop.
+type HookMetric struct {
+	mods *modules.Modules
+	hook Hook
+	view consensus.View
+}
+
+// NewHookMetric returns a metric that calls the given hook. If the hook is nil, a NopHook is used.
+func NewHookMetric(hook Hook) *HookMetric {
+	if hook == nil {
+		hook = NopHook{}
+	}
+	// the synchronizer starts in view 1, and only emits an event when it leaves the view.
+	return &HookMetric{hook: hook, view: 1}
+}
+
+// InitModule gives the module access to the other modules.
+func (hm *HookMetric) InitModule(mods *modules.Modules) {
+	hm.mods = mods
+
+	hm.mods.MetricsEventLoop().RegisterObserver(consensus.BlockCommittedEvent{}, func(event interface{}) {
+		committed := event.(consensus.BlockCommittedEvent)
+		hm.hook.BlockCommitted(committed.Block.View(), committed.Commands)
+	})
+
+	hm.mods.MetricsEventLoop().RegisterObserver(synchronizer.ViewChangeEvent{}, func(event interface{}) {
+		view := event.(synchronizer.ViewChangeEvent).View
+		hm.hook.ViewChanged(hm.view, view)
+		hm.view = view
+	})
+
+	hm.mods.MetricsEventLoop().RegisterObserver(consensus.VoteReceivedEvent{}, func(event interface{}) {
+		hm.hook.VoteReceived(event.(consensus.VoteReceivedEvent).View)
+	})
+}
+
+// Counts are the values of a Counter.
+type Counts struct {
+	Blocks      uint64         // The number of committed blocks.
+	Commands    uint64         // The number of executed client commands.
+	ViewChanges uint64         // The number of view changes.
+	Votes       uint64         // The number of received votes.
+	View        consensus.View // The current view.
+}
+
+// Counter is a Hook that counts the callbacks. It can be read concurrently with the callbacks,
+// for example by a handler that serves the counts to a monitoring system.
+type Counter struct {
+	blocks      uint64
+	commands    uint64
+	viewChanges uint64
+	votes       uint64
+	view        uint64
+}
+
+// BlockCommitted counts the block and its commands.
+func (c *Counter) BlockCommitted(_ consensus.View, commands int) {
+	atomic.AddUint64(&c.blocks, 1)
+	atomic.AddUint64(&c.commands, uint64(commands))
+}
+
+// ViewChanged counts the view change and records the new view.
+func (c *Counter) ViewChanged(_, to consensus.View) {
+	atomic.AddUint64(&c.viewChanges, 1)
+	atomic.StoreUint64(&c.view, uint64(to))
+}
+
+// VoteReceived counts the vote.
+func (c *Counter) VoteReceived(consensus.View) {
+	atomic.AddUint64(&c.votes, 1)
+}
+
+// Counts returns the current counts.
+func (c *Counter) Counts() Counts {
+	return Counts{
+		Blocks:      atomic.LoadUint64(&c.blocks),
+		Commands:    atomic.LoadUint64(&c.commands),
+		ViewChanges: atomic.LoadUint64(&c.viewChanges),
+		Votes:       atomic.LoadUint64(&c.votes),
+		View:        consensus.View(atomic.LoadUint64(&c.view)),
+	}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/internal/testutil"
+)
+
+func TestHookMetric(t *testing.T) {
+	const n = 4
+	network, builders := testutil.CreateNetwork(t, n)
+	counters := make([]*Counter, n)
+	for i, builder := range builders {
+		counters[i] = &Counter{}
+		builder.Register(NewHookMetric(counters[i]))
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && counters[0].Counts().Blocks < 10 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	var votes uint64
+	for _, c := range counters {
+		votes += c.Counts().Votes
+	}
+	counts := counters[0].Counts()
+	if counts.Blocks < 10 {
+		t.Fatalf("only %d committed blocks were reported", counts.Blocks)
+	}
+	if counts.Commands == 0 {
+		t.Error("no executed commands were reported")
+	}
+	if counts.ViewChanges == 0 || counts.View <= 1 {
+		t.Errorf("got %d view changes to view %d, want the replica to have advanced", counts.ViewChanges, counts.View)
+	}
+	if votes == 0 {
+		t.Error("no received votes were reported")
+	}
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/metrics/types"
+	"github.com/relab/hotstuff/modules"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func init() {
+	RegisterReplicaMetric("lock-contention", func() interface{} {
+		return &LockContention{}
+	})
+}
+
+// LockContention measures the time spent waiting for and holding the lock that guards the consensus state.
+// It enables the ShouldInstrumentLocks option, and writes the measurements to the metrics logger on every tick.
+type LockContention struct {
+	mods *consensus.Modules
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (lc *LockContention) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	lc.mods = mods
+	opts.SetShouldInstrumentLocks()
+}
+
+// InitModule gives the module access to the other modules.
+func (lc *LockContention) InitModule(mods *modules.Modules) {
+	mods.MetricsEventLoop().RegisterObserver(types.TickEvent{}, func(event interface{}) {
+		lc.tick(event.(types.TickEvent))
+	})
+	mods.Logger().Info("Lock contention metric enabled")
+}
+
+func (lc *LockContention) tick(_ types.TickEvent) {
+	reporter, ok := lc.mods.Consensus().(consensus.LockReporter)
+	if !ok {
+		return
+	}
+	stats := reporter.LockStats()
+	lc.mods.MetricsLogger().Log(&types.LockContentionMeasurement{
+		Event:        types.NewReplicaEvent(uint32(lc.mods.ID()), time.Now()),
+		Acquisitions: stats.Acquisitions,
+		Wait:         durationpb.New(stats.Wait),
+		MaxWait:      durationpb.New(stats.MaxWait),
+		Hold:         durationpb.New(stats.Hold),
+		MaxHold:      durationpb.New(stats.MaxHold),
+	})
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/metrics/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// recordingLogger is a metrics logger that keeps the logged messages in memory.
+type recordingLogger struct {
+	mut      sync.Mutex
+	messages []proto.Message
+}
+
+func (l *recordingLogger) Log(msg proto.Message) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	l.messages = append(l.messages, msg)
+}
+
+func (l *recordingLogger) Close() error { return nil }
+
+func TestLockContention(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	lc := &LockContention{}
+	logger := &recordingLogger{}
+	builders[0].Register(lc, logger)
+	builders.Build()
+	hs := network.Node(1).Modules()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	// read the committed block concurrently with the protocol, to create contention on the lock.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				hs.Consensus().CommittedBlock()
+			}
+		}()
+	}
+	network.Run(ctx)
+	wg.Wait()
+
+	lc.tick(types.TickEvent{})
+	if len(logger.messages) != 1 {
+		t.Fatalf("expected one measurement, got %d", len(logger.messages))
+	}
+	m, ok := logger.messages[0].(*types.LockContentionMeasurement)
+	if !ok {
+		t.Fatalf("unexpected measurement type: %T", logger.messages[0])
+	}
+	if m.GetAcquisitions() == 0 {
+		t.Error("no lock acquisitions were recorded")
+	}
+	if m.GetWait().AsDuration() <= 0 || m.GetMaxWait().AsDuration() <= 0 {
+		t.Errorf("expected nonzero wait times, got: %v, max: %v", m.GetWait().AsDuration(), m.GetMaxWait().AsDuration())
+	}
+	if m.GetHold().AsDuration() <= 0 || m.GetMaxHold().AsDuration() <= 0 {
+		t.Errorf("expected nonzero hold times, got: %v, max: %v", m.GetHold().AsDuration(), m.GetMaxHold().AsDuration())
+	}
+
+	// the measurements are reset after each reading.
+	lc.tick(types.TickEvent{})
+	if m := logger.messages[1].(*types.LockContentionMeasurement); m.GetAcquisitions() != 0 {
+		t.Errorf("expected the measurements to be reset, got %d acquisitions", m.GetAcquisitions())
+	}
+}
@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
 // 	protoc-gen-go v1.26.0
-// 	protoc        v3.17.3
+// 	protoc        v3.16.0
 // source: metrics/types/types.proto
 
 package types
@@ -342,6 +342,411 @@ func (x *ViewTimeouts) GetTimeouts() uint64 {
 	return 0
 }
 
+// CommandLatencyMeasurement contains percentiles of the latencies of the commands committed since the last reading.
+// The latencies are measured from the time the client submitted a command, and are given in milliseconds.
+type CommandLatencyMeasurement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Event     *Event  `protobuf:"bytes,1,opt,name=Event,proto3" json:"Event,omitempty"`
+	Count     uint64  `protobuf:"varint,2,opt,name=Count,proto3" json:"Count,omitempty"`
+	CommitP50 float64 `protobuf:"fixed64,3,opt,name=CommitP50,proto3" json:"CommitP50,omitempty"`
+	CommitP90 float64 `protobuf:"fixed64,4,opt,name=CommitP90,proto3" json:"CommitP90,omitempty"`
+	CommitP99 float64 `protobuf:"fixed64,5,opt,name=CommitP99,proto3" json:"CommitP99,omitempty"`
+	ExecP50   float64 `protobuf:"fixed64,6,opt,name=ExecP50,proto3" json:"ExecP50,omitempty"`
+	ExecP90   float64 `protobuf:"fixed64,7,opt,name=ExecP90,proto3" json:"ExecP90,omitempty"`
+	ExecP99   float64 `protobuf:"fixed64,8,opt,name=ExecP99,proto3" json:"ExecP99,omitempty"`
+}
+
+func (x *CommandLatencyMeasurement) Reset() {
+	*x = CommandLatencyMeasurement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_metrics_types_types_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CommandLatencyMeasurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandLatencyMeasurement) ProtoMessage() {}
+
+func (x *CommandLatencyMeasurement) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_types_types_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandLatencyMeasurement.ProtoReflect.Descriptor instead.
+func (*CommandLatencyMeasurement) Descriptor() ([]byte, []int) {
+	return file_metrics_types_types_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CommandLatencyMeasurement) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *CommandLatencyMeasurement) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *CommandLatencyMeasurement) GetCommitP50() float64 {
+	if x != nil {
+		return x.CommitP50
+	}
+	return 0
+}
+
+func (x *CommandLatencyMeasurement) GetCommitP90() float64 {
+	if x != nil {
+		return x.CommitP90
+	}
+	return 0
+}
+
+func (x *CommandLatencyMeasurement) GetCommitP99() float64 {
+	if x != nil {
+		return x.CommitP99
+	}
+	return 0
+}
+
+func (x *CommandLatencyMeasurement) GetExecP50() float64 {
+	if x != nil {
+		return x.ExecP50
+	}
+	return 0
+}
+
+func (x *CommandLatencyMeasurement) GetExecP90() float64 {
+	if x != nil {
+		return x.ExecP90
+	}
+	return 0
+}
+
+func (x *CommandLatencyMeasurement) GetExecP99() float64 {
+	if x != nil {
+		return x.ExecP99
+	}
+	return 0
+}
+
+// LockContentionMeasurement contains the time spent waiting for and holding the lock
+// that guards the consensus state, since the last reading.
+type LockContentionMeasurement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Event        *Event               `protobuf:"bytes,1,opt,name=Event,proto3" json:"Event,omitempty"`
+	Acquisitions uint64               `protobuf:"varint,2,opt,name=Acquisitions,proto3" json:"Acquisitions,omitempty"`
+	Wait         *durationpb.Duration `protobuf:"bytes,3,opt,name=Wait,proto3" json:"Wait,omitempty"`
+	MaxWait      *durationpb.Duration `protobuf:"bytes,4,opt,name=MaxWait,proto3" json:"MaxWait,omitempty"`
+	Hold         *durationpb.Duration `protobuf:"bytes,5,opt,name=Hold,proto3" json:"Hold,omitempty"`
+	MaxHold      *durationpb.Duration `protobuf:"bytes,6,opt,name=MaxHold,proto3" json:"MaxHold,omitempty"`
+}
+
+func (x *LockContentionMeasurement) Reset() {
+	*x = LockContentionMeasurement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_metrics_types_types_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LockContentionMeasurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LockContentionMeasurement) ProtoMessage() {}
+
+func (x *LockContentionMeasurement) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_types_types_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LockContentionMeasurement.ProtoReflect.Descriptor instead.
+func (*LockContentionMeasurement) Descriptor() ([]byte, []int) {
+	return file_metrics_types_types_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LockContentionMeasurement) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *LockContentionMeasurement) GetAcquisitions() uint64 {
+	if x != nil {
+		return x.Acquisitions
+	}
+	return 0
+}
+
+func (x *LockContentionMeasurement) GetWait() *durationpb.Duration {
+	if x != nil {
+		return x.Wait
+	}
+	return nil
+}
+
+func (x *LockContentionMeasurement) GetMaxWait() *durationpb.Duration {
+	if x != nil {
+		return x.MaxWait
+	}
+	return nil
+}
+
+func (x *LockContentionMeasurement) GetHold() *durationpb.Duration {
+	if x != nil {
+		return x.Hold
+	}
+	return nil
+}
+
+func (x *LockContentionMeasurement) GetMaxHold() *durationpb.Duration {
+	if x != nil {
+		return x.MaxHold
+	}
+	return nil
+}
+
+// FairnessMeasurement contains the delays from submission to inclusion in a proposal of the commands of each client
+// since the last reading, the Gini coefficient of the clients' mean delays,
+// and the leaders whose proposals delayed some clients' commands much more than others.
+type FairnessMeasurement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Event         *Event             `protobuf:"bytes,1,opt,name=Event,proto3" json:"Event,omitempty"`
+	Gini          float64            `protobuf:"fixed64,2,opt,name=Gini,proto3" json:"Gini,omitempty"`
+	Clients       []*ClientInclusion `protobuf:"bytes,3,rep,name=Clients,proto3" json:"Clients,omitempty"`
+	UnfairLeaders []uint32           `protobuf:"varint,4,rep,packed,name=UnfairLeaders,proto3" json:"UnfairLeaders,omitempty"`
+}
+
+func (x *FairnessMeasurement) Reset() {
+	*x = FairnessMeasurement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_metrics_types_types_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FairnessMeasurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FairnessMeasurement) ProtoMessage() {}
+
+func (x *FairnessMeasurement) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_types_types_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FairnessMeasurement.ProtoReflect.Descriptor instead.
+func (*FairnessMeasurement) Descriptor() ([]byte, []int) {
+	return file_metrics_types_types_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FairnessMeasurement) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *FairnessMeasurement) GetGini() float64 {
+	if x != nil {
+		return x.Gini
+	}
+	return 0
+}
+
+func (x *FairnessMeasurement) GetClients() []*ClientInclusion {
+	if x != nil {
+		return x.Clients
+	}
+	return nil
+}
+
+func (x *FairnessMeasurement) GetUnfairLeaders() []uint32 {
+	if x != nil {
+		return x.UnfairLeaders
+	}
+	return nil
+}
+
+// ClientInclusion contains the delays from submission to inclusion in a proposal of the commands of a client.
+type ClientInclusion struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClientID  uint32               `protobuf:"varint,1,opt,name=ClientID,proto3" json:"ClientID,omitempty"`
+	Count     uint64               `protobuf:"varint,2,opt,name=Count,proto3" json:"Count,omitempty"`
+	MeanDelay *durationpb.Duration `protobuf:"bytes,3,opt,name=MeanDelay,proto3" json:"MeanDelay,omitempty"`
+	MaxDelay  *durationpb.Duration `protobuf:"bytes,4,opt,name=MaxDelay,proto3" json:"MaxDelay,omitempty"`
+}
+
+func (x *ClientInclusion) Reset() {
+	*x = ClientInclusion{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_metrics_types_types_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClientInclusion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientInclusion) ProtoMessage() {}
+
+func (x *ClientInclusion) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_types_types_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientInclusion.ProtoReflect.Descriptor instead.
+func (*ClientInclusion) Descriptor() ([]byte, []int) {
+	return file_metrics_types_types_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ClientInclusion) GetClientID() uint32 {
+	if x != nil {
+		return x.ClientID
+	}
+	return 0
+}
+
+func (x *ClientInclusion) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ClientInclusion) GetMeanDelay() *durationpb.Duration {
+	if x != nil {
+		return x.MeanDelay
+	}
+	return nil
+}
+
+func (x *ClientInclusion) GetMaxDelay() *durationpb.Duration {
+	if x != nil {
+		return x.MaxDelay
+	}
+	return nil
+}
+
+// CriticalPathMeasurement contains the number of blocks committed since the last reading
+// for which the replica formed the quorum certificate, and how often each replica's vote completed the quorum.
+type CriticalPathMeasurement struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Event          *Event            `protobuf:"bytes,1,opt,name=Event,proto3" json:"Event,omitempty"`
+	Blocks         uint64            `protobuf:"varint,2,opt,name=Blocks,proto3" json:"Blocks,omitempty"`
+	CriticalVoters map[uint32]uint64 `protobuf:"bytes,3,rep,name=CriticalVoters,proto3" json:"CriticalVoters,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (x *CriticalPathMeasurement) Reset() {
+	*x = CriticalPathMeasurement{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_metrics_types_types_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CriticalPathMeasurement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CriticalPathMeasurement) ProtoMessage() {}
+
+func (x *CriticalPathMeasurement) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_types_types_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CriticalPathMeasurement.ProtoReflect.Descriptor instead.
+func (*CriticalPathMeasurement) Descriptor() ([]byte, []int) {
+	return file_metrics_types_types_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CriticalPathMeasurement) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *CriticalPathMeasurement) GetBlocks() uint64 {
+	if x != nil {
+		return x.Blocks
+	}
+	return 0
+}
+
+func (x *CriticalPathMeasurement) GetCriticalVoters() map[uint32]uint64 {
+	if x != nil {
+		return x.CriticalVoters
+	}
+	return nil
+}
+
 var File_metrics_types_types_proto protoreflect.FileDescriptor
 
 var file_metrics_types_types_proto_rawDesc = []byte{
@@ -386,10 +791,82 @@ var file_metrics_types_types_proto_rawDesc = []byte{
 	0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x69, 0x65, 0x77, 0x73, 0x18,
 	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x56, 0x69, 0x65, 0x77, 0x73, 0x12, 0x1a, 0x0a, 0x08,
 	0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08,
-	0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x73, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68,
-	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x72, 0x65, 0x6c, 0x61, 0x62, 0x2f, 0x68, 0x6f, 0x74,
-	0x73, 0x74, 0x75, 0x66, 0x66, 0x2f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2f, 0x74, 0x79,
-	0x70, 0x65, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x73, 0x22, 0xfd, 0x01, 0x0a, 0x19, 0x43, 0x6f, 0x6d,
+	0x6d, 0x61, 0x6e, 0x64, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x65, 0x61, 0x73, 0x75,
+	0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x22, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x52, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x43, 0x6f, 0x75, 0x6e, 0x74,
+	0x12, 0x1c, 0x0a, 0x09, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x50, 0x35, 0x30, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x09, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x50, 0x35, 0x30, 0x12, 0x1c,
+	0x0a, 0x09, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x50, 0x39, 0x30, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x09, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x50, 0x39, 0x30, 0x12, 0x1c, 0x0a, 0x09,
+	0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x50, 0x39, 0x39, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x09, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x50, 0x39, 0x39, 0x12, 0x18, 0x0a, 0x07, 0x45, 0x78,
+	0x65, 0x63, 0x50, 0x35, 0x30, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x45, 0x78, 0x65,
+	0x63, 0x50, 0x35, 0x30, 0x12, 0x18, 0x0a, 0x07, 0x45, 0x78, 0x65, 0x63, 0x50, 0x39, 0x30, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x45, 0x78, 0x65, 0x63, 0x50, 0x39, 0x30, 0x12, 0x18,
+	0x0a, 0x07, 0x45, 0x78, 0x65, 0x63, 0x50, 0x39, 0x39, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x07, 0x45, 0x78, 0x65, 0x63, 0x50, 0x39, 0x39, 0x22, 0xab, 0x02, 0x0a, 0x19, 0x4c, 0x6f, 0x63,
+	0x6b, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x61, 0x73, 0x75,
+	0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x22, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x52, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x41, 0x63,
+	0x71, 0x75, 0x69, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x0c, 0x41, 0x63, 0x71, 0x75, 0x69, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2d,
+	0x0a, 0x04, 0x57, 0x61, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x04, 0x57, 0x61, 0x69, 0x74, 0x12, 0x33, 0x0a,
+	0x07, 0x4d, 0x61, 0x78, 0x57, 0x61, 0x69, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x4d, 0x61, 0x78, 0x57, 0x61,
+	0x69, 0x74, 0x12, 0x2d, 0x0a, 0x04, 0x48, 0x6f, 0x6c, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x04, 0x48, 0x6f, 0x6c,
+	0x64, 0x12, 0x33, 0x0a, 0x07, 0x4d, 0x61, 0x78, 0x48, 0x6f, 0x6c, 0x64, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07, 0x4d,
+	0x61, 0x78, 0x48, 0x6f, 0x6c, 0x64, 0x22, 0xa5, 0x01, 0x0a, 0x13, 0x46, 0x61, 0x69, 0x72, 0x6e,
+	0x65, 0x73, 0x73, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x22,
+	0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e,
+	0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x47, 0x69, 0x6e, 0x69, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x04, 0x47, 0x69, 0x6e, 0x69, 0x12, 0x30, 0x0a, 0x07, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e,
+	0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x52,
+	0x07, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x24, 0x0a, 0x0d, 0x55, 0x6e, 0x66, 0x61,
+	0x69, 0x72, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0d, 0x52,
+	0x0d, 0x55, 0x6e, 0x66, 0x61, 0x69, 0x72, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x22, 0xb3,
+	0x01, 0x0a, 0x0f, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x44, 0x12, 0x14,
+	0x0a, 0x05, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x37, 0x0a, 0x09, 0x4d, 0x65, 0x61, 0x6e, 0x44, 0x65, 0x6c, 0x61,
+	0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x09, 0x4d, 0x65, 0x61, 0x6e, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x12, 0x35, 0x0a,
+	0x08, 0x4d, 0x61, 0x78, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x4d, 0x61, 0x78, 0x44,
+	0x65, 0x6c, 0x61, 0x79, 0x22, 0xf4, 0x01, 0x0a, 0x17, 0x43, 0x72, 0x69, 0x74, 0x69, 0x63, 0x61,
+	0x6c, 0x50, 0x61, 0x74, 0x68, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+	0x12, 0x22, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0c, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x5a, 0x0a, 0x0e,
+	0x43, 0x72, 0x69, 0x74, 0x69, 0x63, 0x61, 0x6c, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x73, 0x18, 0x03,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x32, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x43, 0x72, 0x69,
+	0x74, 0x69, 0x63, 0x61, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65,
+	0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x43, 0x72, 0x69, 0x74, 0x69, 0x63, 0x61, 0x6c, 0x56, 0x6f, 0x74,
+	0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x43, 0x72, 0x69, 0x74, 0x69, 0x63,
+	0x61, 0x6c, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x73, 0x1a, 0x41, 0x0a, 0x13, 0x43, 0x72, 0x69, 0x74,
+	0x69, 0x63, 0x61, 0x6c, 0x56, 0x6f, 0x74, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b, 0x65,
+	0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x29, 0x5a, 0x27, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x72, 0x65, 0x6c, 0x61, 0x62, 0x2f,
+	0x68, 0x6f, 0x74, 0x73, 0x74, 0x75, 0x66, 0x66, 0x2f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
+	0x2f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -404,28 +881,46 @@ func file_metrics_types_types_proto_rawDescGZIP() []byte {
 	return file_metrics_types_types_proto_rawDescData
 }
 
-var file_metrics_types_types_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_metrics_types_types_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_metrics_types_types_proto_goTypes = []interface{}{
-	(*StartEvent)(nil),            // 0: types.StartEvent
-	(*Event)(nil),                 // 1: types.Event
-	(*ThroughputMeasurement)(nil), // 2: types.ThroughputMeasurement
-	(*LatencyMeasurement)(nil),    // 3: types.LatencyMeasurement
-	(*ViewTimeouts)(nil),          // 4: types.ViewTimeouts
-	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
-	(*durationpb.Duration)(nil),   // 6: google.protobuf.Duration
+	(*StartEvent)(nil),                // 0: types.StartEvent
+	(*Event)(nil),                     // 1: types.Event
+	(*ThroughputMeasurement)(nil),     // 2: types.ThroughputMeasurement
+	(*LatencyMeasurement)(nil),        // 3: types.LatencyMeasurement
+	(*ViewTimeouts)(nil),              // 4: types.ViewTimeouts
+	(*CommandLatencyMeasurement)(nil), // 5: types.CommandLatencyMeasurement
+	(*LockContentionMeasurement)(nil), // 6: types.LockContentionMeasurement
+	(*FairnessMeasurement)(nil),       // 7: types.FairnessMeasurement
+	(*ClientInclusion)(nil),           // 8: types.ClientInclusion
+	(*CriticalPathMeasurement)(nil),   // 9: types.CriticalPathMeasurement
+	nil,                               // 10: types.CriticalPathMeasurement.CriticalVotersEntry
+	(*timestamppb.Timestamp)(nil),     // 11: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),       // 12: google.protobuf.Duration
 }
 var file_metrics_types_types_proto_depIdxs = []int32{
-	1, // 0: types.StartEvent.Event:type_name -> types.Event
-	5, // 1: types.Event.Timestamp:type_name -> google.protobuf.Timestamp
-	1, // 2: types.ThroughputMeasurement.Event:type_name -> types.Event
-	6, // 3: types.ThroughputMeasurement.Duration:type_name -> google.protobuf.Duration
-	1, // 4: types.LatencyMeasurement.Event:type_name -> types.Event
-	1, // 5: types.ViewTimeouts.Event:type_name -> types.Event
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	1,  // 0: types.StartEvent.Event:type_name -> types.Event
+	11, // 1: types.Event.Timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 2: types.ThroughputMeasurement.Event:type_name -> types.Event
+	12, // 3: types.ThroughputMeasurement.Duration:type_name -> google.protobuf.Duration
+	1,  // 4: types.LatencyMeasurement.Event:type_name -> types.Event
+	1,  // 5: types.ViewTimeouts.Event:type_name -> types.Event
+	1,  // 6: types.CommandLatencyMeasurement.Event:type_name -> types.Event
+	1,  // 7: types.LockContentionMeasurement.Event:type_name -> types.Event
+	12, // 8: types.LockContentionMeasurement.Wait:type_name -> google.protobuf.Duration
+	12, // 9: types.LockContentionMeasurement.MaxWait:type_name -> google.protobuf.Duration
+	12, // 10: types.LockContentionMeasurement.Hold:type_name -> google.protobuf.Duration
+	12, // 11: types.LockContentionMeasurement.MaxHold:type_name -> google.protobuf.Duration
+	1,  // 12: types.FairnessMeasurement.Event:type_name -> types.Event
+	8,  // 13: types.FairnessMeasurement.Clients:type_name -> types.ClientInclusion
+	12, // 14: types.ClientInclusion.MeanDelay:type_name -> google.protobuf.Duration
+	12, // 15: types.ClientInclusion.MaxDelay:type_name -> google.protobuf.Duration
+	1,  // 16: types.CriticalPathMeasurement.Event:type_name -> types.Event
+	10, // 17: types.CriticalPathMeasurement.CriticalVoters:type_name -> types.CriticalPathMeasurement.CriticalVotersEntry
+	18, // [18:18] is the sub-list for method output_type
+	18, // [18:18] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_metrics_types_types_proto_init() }
@@ -494,6 +989,66 @@ func file_metrics_types_types_proto_init() {
 				return nil
 			}
 		}
+		file_metrics_types_types_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CommandLatencyMeasurement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_metrics_types_types_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LockContentionMeasurement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_metrics_types_types_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FairnessMeasurement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_metrics_types_types_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClientInclusion); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_metrics_types_types_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CriticalPathMeasurement); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -501,7 +1056,7 @@ func file_metrics_types_types_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_metrics_types_types_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,201 @@
+// Package replay records the consensus messages and events of a replica in a versioned JSON format,
+// and replays the recorded proposals into another replica.
+//
+// The JSON format is intended for external tools, such as analysis scripts and visualizers.
+// A log contains the version of the format, the ID of the recording replica, and a list of entries in the order they were recorded.
+// Each entry has a type, a timestamp, and the ID of the replica that it concerns:
+//
+//	proposal     a proposal received from the leader; includes the proposed block in the protojson encoding of hotstuffpb.Block.
+//	vote         a vote received from a replica; includes the view and hash of the block.
+//	new-view     a new-view message received from a replica; includes the view of its highest certificate.
+//	view-change  the local replica advanced to a new view; timeout is set if the view changed due to a timeout certificate.
+//	commit       the local replica committed a block; includes the view and hash of the block.
+//
+// Block hashes are hex encoded.
+package replay
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
+	"github.com/relab/hotstuff/modules"
+	"github.com/relab/hotstuff/synchronizer"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// LogVersion is the version of the JSON format written by this package.
+const LogVersion = 1
+
+// EntryType is the type of a log entry.
+type EntryType string
+
+// The types of log entries.
+const (
+	Proposal   EntryType = "proposal"
+	Vote       EntryType = "vote"
+	NewView    EntryType = "new-view"
+	ViewChange EntryType = "view-change"
+	Commit     EntryType = "commit"
+)
+
+// Entry is a recorded message or event.
+type Entry struct {
+	Type      EntryType       `json:"type"`
+	Time      time.Time       `json:"time"`
+	Replica   hotstuff.ID     `json:"replica"` // the sender of a message, or the recording replica for view changes and commits
+	View      consensus.View  `json:"view"`
+	BlockHash string          `json:"blockHash,omitempty"`
+	Block     json.RawMessage `json:"block,omitempty"`
+	Timeout   bool            `json:"timeout,omitempty"`
+}
+
+// Log is a recorded log of the messages and events of a replica.
+type Log struct {
+	Version int         `json:"version"`
+	Replica hotstuff.ID `json:"replica"`
+	Entries []Entry     `json:"entries"`
+}
+
+// WriteJSON writes the log to w.
+func (l *Log) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+// ReadJSON reads a log from r.
+func ReadJSON(r io.Reader) (*Log, error) {
+	var l Log
+	if err := json.NewDecoder(r).Decode(&l); err != nil {
+		return nil, fmt.Errorf("failed to decode log: %w", err)
+	}
+	if l.Version != LogVersion {
+		return nil, fmt.Errorf("unsupported log version %d (expected %d)", l.Version, LogVersion)
+	}
+	return &l, nil
+}
+
+// Recorder is a module that records the messages and events of a replica.
+// Events are recorded from the metrics event loop, which receives every emitted event in order.
+type Recorder struct {
+	mut sync.Mutex
+	log Log
+}
+
+// NewRecorder returns a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{log: Log{Version: LogVersion}}
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (r *Recorder) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	mods.EventLoop().RegisterObserver(consensus.NewViewMsg{}, func(event interface{}) {
+		msg := event.(consensus.NewViewMsg)
+		r.add(Entry{Type: NewView, Replica: msg.ID, View: highestView(msg.SyncInfo)})
+	})
+}
+
+// InitModule gives the module access to the other modules.
+func (r *Recorder) InitModule(mods *modules.Modules) {
+	r.log.Replica = mods.ID()
+	mods.MetricsEventLoop().RegisterObserver(consensus.ProposalReceivedEvent{}, func(event interface{}) {
+		proposal := event.(consensus.ProposalReceivedEvent)
+		b, err := protojson.Marshal(hotstuffpb.BlockToProto(proposal.Block))
+		if err != nil {
+			mods.Logger().Errorf("Failed to encode block: %v", err)
+			return
+		}
+		r.add(Entry{Type: Proposal, Replica: proposal.ID, View: proposal.Block.View(), BlockHash: encodeHash(proposal.Block.Hash()), Block: b})
+	})
+	mods.MetricsEventLoop().RegisterObserver(consensus.VoteReceivedEvent{}, func(event interface{}) {
+		vote := event.(consensus.VoteReceivedEvent)
+		r.add(Entry{Type: Vote, Replica: vote.ID, View: vote.View, BlockHash: encodeHash(vote.BlockHash)})
+	})
+	mods.MetricsEventLoop().RegisterObserver(synchronizer.ViewChangeEvent{}, func(event interface{}) {
+		viewChange := event.(synchronizer.ViewChangeEvent)
+		r.add(Entry{Type: ViewChange, Replica: mods.ID(), View: viewChange.View, Timeout: viewChange.Timeout})
+	})
+	mods.MetricsEventLoop().RegisterObserver(consensus.BlockCommittedEvent{}, func(event interface{}) {
+		commit := event.(consensus.BlockCommittedEvent)
+		r.add(Entry{Type: Commit, Time: commit.CommitTime, Replica: mods.ID(), View: commit.Block.View(), BlockHash: encodeHash(commit.Block.Hash())})
+	})
+}
+
+func (r *Recorder) add(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.log.Entries = append(r.log.Entries, entry)
+}
+
+// Log returns a copy of the recorded log.
+func (r *Recorder) Log() *Log {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	l := r.log
+	l.Entries = append([]Entry(nil), r.log.Entries...)
+	return &l
+}
+
+// Replay passes the recorded proposals to the given replica, in the order they were recorded,
+// and runs the replica's event loops until all of the proposals have been processed.
+// If the replica has the same ID and configuration as the recording replica, and starts without any state,
+// it commits the same chain of blocks. The replica must not already be running.
+// Blocks that the replica proposes itself during the replay are not committed, since no other replica votes for them.
+func Replay(ctx context.Context, l *Log, mods *consensus.Modules) error {
+	proposals := make([]consensus.ProposeMsg, 0, len(l.Entries))
+	for i, entry := range l.Entries {
+		if entry.Type != Proposal {
+			continue
+		}
+		pb := new(hotstuffpb.Block)
+		if err := protojson.Unmarshal(entry.Block, pb); err != nil {
+			return fmt.Errorf("failed to decode block of entry %d: %w", i, err)
+		}
+		block := hotstuffpb.BlockFromProto(pb)
+		if hash := encodeHash(block.Hash()); hash != entry.BlockHash {
+			return fmt.Errorf("block of entry %d has hash %s, but the entry has hash %s", i, hash, entry.BlockHash)
+		}
+		proposals = append(proposals, consensus.ProposeMsg{ID: entry.Replica, Block: block})
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		for _, proposal := range proposals {
+			mods.EventLoop().AddEvent(proposal)
+		}
+		// the event loop processes events in order, so all proposals have been processed when this runs.
+		mods.EventLoop().AddEvent(func() { cancel() })
+	}()
+	mods.Run(ctx)
+	return nil
+}
+
+// highestView returns the view of the highest certificate in the sync info.
+func highestView(syncInfo consensus.SyncInfo) (view consensus.View) {
+	if qc, ok := syncInfo.QC(); ok {
+		view = qc.View()
+	}
+	if tc, ok := syncInfo.TC(); ok && tc.View() > view {
+		view = tc.View()
+	}
+	return view
+}
+
+func encodeHash(hash consensus.Hash) string {
+	return hex.EncodeToString(hash[:])
+}
+
+var _ consensus.Module = (*Recorder)(nil)
@@ -0,0 +1,75 @@
+package replay_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/replay"
+)
+
+func TestExportAndReplay(t *testing.T) {
+	const n = 4
+	network, builders := testutil.CreateNetwork(t, n)
+	recorder := replay.NewRecorder()
+	builders[0].Register(recorder)
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(network.Node(1).Executed()) < 10 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	executed := network.Node(1).Executed()
+	if len(executed) < 10 {
+		t.Fatalf("only %d blocks were executed", len(executed))
+	}
+
+	var buf bytes.Buffer
+	if err := recorder.Log().WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, entryType := range []replay.EntryType{replay.Proposal, replay.Vote, replay.ViewChange, replay.Commit} {
+		if !strings.Contains(buf.String(), `"type": "`+string(entryType)+`"`) {
+			t.Errorf("the exported log does not contain any %s entries", entryType)
+		}
+	}
+
+	l, err := replay.ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Replica != 1 {
+		t.Errorf("wrong replica: got %d, want 1", l.Replica)
+	}
+
+	restarted := network.Restart(1)
+	restarted.Build()
+	if err := replay.Replay(context.Background(), l, network.Node(1).Modules()); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := network.Node(1).Executed()
+	if len(replayed) != len(executed) {
+		t.Fatalf("replay executed %d blocks, want %d", len(replayed), len(executed))
+	}
+	for i := range executed {
+		if replayed[i].Hash() != executed[i].Hash() {
+			t.Fatalf("replay executed block %.8s at height %d, want %.8s", replayed[i].Hash(), i, executed[i].Hash())
+		}
+	}
+}
+
+func TestReadJSONVersion(t *testing.T) {
+	if _, err := replay.ReadJSON(strings.NewReader(`{"version": 2, "replica": 1, "entries": []}`)); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
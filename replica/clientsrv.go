@@ -1,10 +1,13 @@
 package replica
 
 import (
+	"container/list"
 	"crypto/sha256"
+	"fmt"
 	"hash"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/relab/gorums"
@@ -20,20 +23,54 @@ import (
 type clientSrv struct {
 	mut          sync.Mutex
 	mods         *modules.Modules
+	opts         *consensus.Options
 	srv          *gorums.Server
 	awaitingCmds map[cmdID]chan<- error
+	committed    map[uint32]uint64 // the highest committed sequence number of each client
+	unfinalized  []executedBatch   // executed batches that are awaiting finalization, in execution order
 	cmdCache     *cmdCache
 	hash         hash.Hash
+	onAck        func(ack *clientpb.Ack)
+	optimistic   map[cmdID]bool // commands that were acknowledged optimistically, but are not yet final
+	retention    time.Duration  // how long the results of committed commands are kept
+	results      map[cmdID]result
+	resultOrder  list.List // the IDs of the commands in results, in the order they were committed
+	signReceipts bool      // whether receipts are signed for the executed commands
+	hs           *consensus.Modules
+	apply        func(cmd *clientpb.Command) error
+}
+
+// result is the reply to a committed command, which is kept for clients that retry the command.
+type result struct {
+	err     error
+	expires time.Time
+	receipt *clientpb.Receipt // nil if the command was executed without a receipt
+}
+
+// executedBatch is a batch of commands that was executed, but not yet acknowledged to the clients.
+type executedBatch struct {
+	cmd      consensus.Command
+	ids      []cmdID
+	errs     []error             // the errors of the commands that failed, or nil for the commands that succeeded
+	receipts []*clientpb.Receipt // the receipts of the commands, if signed
 }
 
 // newClientServer returns a new client server.
 func newClientServer(conf Config, srvOpts []gorums.ServerOption) (srv *clientSrv) {
 	srv = &clientSrv{
 		awaitingCmds: make(map[cmdID]chan<- error),
+		committed:    make(map[uint32]uint64),
 		srv:          gorums.NewServer(srvOpts...),
-		cmdCache:     newCmdCache(int(conf.BatchSize)),
+		cmdCache:     newCmdCache(int(conf.BatchSize), int(conf.BatchBytes), conf.ClientKeys),
 		hash:         sha256.New(),
+		onAck:        conf.OnAck,
+		optimistic:   make(map[cmdID]bool),
+		retention:    conf.ResultRetention,
+		results:      make(map[cmdID]result),
+		signReceipts: conf.SignReceipts,
+		apply:        conf.Apply,
 	}
+	srv.cmdCache.onExpired = srv.expire
 	clientpb.RegisterClientServer(srv.srv, srv)
 	return srv
 }
@@ -41,7 +78,21 @@ func newClientServer(conf Config, srvOpts []gorums.ServerOption) (srv *clientSrv
 // InitModule gives the module access to the other modules.
 func (srv *clientSrv) InitModule(mods *modules.Modules) {
 	srv.mods = mods
-	srv.cmdCache.InitModule(mods)
+	srv.mods.MetricsEventLoop().RegisterHandler(consensus.FinalizedEvent{}, func(event interface{}) {
+		srv.onFinalized(event.(consensus.FinalizedEvent))
+	})
+	if srv.onAck != nil {
+		srv.mods.MetricsEventLoop().RegisterHandler(consensus.CertifiedEvent{}, func(event interface{}) {
+			srv.onCertified(event.(consensus.CertifiedEvent))
+		})
+	}
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (srv *clientSrv) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	srv.opts = mods.Options()
+	srv.hs = mods
 }
 
 func (srv *clientSrv) Start(addr string) error {
@@ -66,11 +117,30 @@ func (srv *clientSrv) Stop() {
 	srv.srv.Stop()
 }
 
+// ExecCommand queues the command and returns once it is executed. Retried commands get the result of their
+// first execution. While the replica is read-only, new commands are rejected with codes.Unavailable.
 func (srv *clientSrv) ExecCommand(ctx gorums.ServerCtx, cmd *clientpb.Command) (*empty.Empty, error) {
+	if !srv.cmdCache.authorized(cmd) {
+		return nil, status.Error(codes.PermissionDenied, "command is not signed by the client")
+	}
 	id := cmdID{cmd.ClientID, cmd.SequenceNumber}
 
 	c := make(chan error)
 	srv.mut.Lock()
+	if res, ok := srv.cachedResult(id); ok {
+		srv.mut.Unlock()
+		// the command was already committed, so it is not proposed again.
+		return &empty.Empty{}, res.err
+	}
+	if id.sequenceNum <= srv.committed[id.clientID] {
+		srv.mut.Unlock()
+		return nil, status.Error(codes.AlreadyExists, "command was already committed, and its result is no longer kept")
+	}
+	if srv.hs.ReadOnly() {
+		// the committed results above are still served, but new commands cannot be committed without a quorum.
+		srv.mut.Unlock()
+		return nil, status.Error(codes.Unavailable, "the replica is read-only, since it cannot reach a quorum")
+	}
 	srv.awaitingCmds[id] = c
 	srv.mut.Unlock()
 
@@ -80,28 +150,171 @@ func (srv *clientSrv) ExecCommand(ctx gorums.ServerCtx, cmd *clientpb.Command) (
 	return &empty.Empty{}, err
 }
 
-func (srv *clientSrv) Exec(cmd consensus.Command) {
+func (srv *clientSrv) Exec(cmd consensus.Command) error {
+	return srv.exec(cmd, nil)
+}
+
+// GetReceipt returns the signed receipt of a committed command.
+func (srv *clientSrv) GetReceipt(_ gorums.ServerCtx, req *clientpb.CommandID) (*clientpb.Receipt, error) {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	res, ok := srv.cachedResult(cmdID{req.GetClientID(), req.GetSequenceNumber()})
+	if !ok || res.receipt == nil {
+		return nil, status.Error(codes.NotFound, "no receipt for the command")
+	}
+	return res.receipt, nil
+}
+
+// exec executes the commands in the batch. If the block is known, and receipts are enabled,
+// a receipt is signed for each command. If any commands fail, the error of the first of them is returned,
+// and the clients of the failed commands get their errors.
+func (srv *clientSrv) exec(cmd consensus.Command, block *consensus.Block) error {
 	batch := new(clientpb.Batch)
 	err := proto.UnmarshalOptions{AllowPartial: true}.Unmarshal([]byte(cmd), batch)
 	if err != nil {
 		srv.mods.Logger().Errorf("Failed to unmarshal command: %v", err)
-		return
+		return err
 	}
 
-	srv.mods.MetricsEventLoop().AddEvent(consensus.CommitEvent{Commands: len(batch.GetCommands())})
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
 
-	for _, cmd := range batch.GetCommands() {
+	ids := make([]cmdID, 0, len(batch.GetCommands()))
+	errs := make([]error, 0, len(batch.GetCommands()))
+	var (
+		receipts []*clientpb.Receipt
+		failed   error
+	)
+	for i, cmd := range batch.GetCommands() {
+		id := cmdID{cmd.GetClientID(), cmd.GetSequenceNumber()}
+		if id.sequenceNum <= srv.committed[id.clientID] {
+			// the command, or a later command of the same client, was committed in an earlier block.
+			// This only depends on the committed commands, so all replicas skip the same commands.
+			continue
+		}
+		srv.committed[id.clientID] = id.sequenceNum
 		_, _ = srv.hash.Write(cmd.Data)
 		if err != nil {
 			srv.mods.Logger().Errorf("Error writing data: %v", err)
 		}
-		srv.mut.Lock()
-		id := cmdID{cmd.GetClientID(), cmd.GetSequenceNumber()}
+		var cmdErr error
+		if srv.apply != nil {
+			cmdErr = srv.apply(cmd)
+			if cmdErr != nil && failed == nil {
+				failed = fmt.Errorf("command %d: %w", i, cmdErr)
+			}
+		}
+		ids = append(ids, id)
+		errs = append(errs, cmdErr)
+		if srv.signReceipts && block != nil {
+			receipts = append(receipts, srv.receipt(id, block))
+		}
+	}
+
+	srv.mods.MetricsEventLoop().AddEvent(consensus.CommitEvent{Commands: len(ids)})
+
+	if srv.opts != nil && srv.opts.ShouldFinalizeCommits() {
+		// the results are returned to the clients once the block is finalized.
+		srv.unfinalized = append(srv.unfinalized, executedBatch{cmd, ids, errs, receipts})
+		return failed
+	}
+	srv.acknowledge(ids, errs, receipts)
+	return failed
+}
+
+// onFinalized acknowledges the commands of the finalized block, and of all blocks that were executed before it.
+// If several executed blocks have the same command, the oldest one is assumed to be finalized,
+// such that commands are never acknowledged before they are final.
+func (srv *clientSrv) onFinalized(event consensus.FinalizedEvent) {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	for i, batch := range srv.unfinalized {
+		if batch.cmd != event.Block.Command() {
+			continue
+		}
+		for _, batch := range srv.unfinalized[:i+1] {
+			srv.acknowledge(batch.ids, batch.errs, batch.receipts)
+		}
+		srv.unfinalized = srv.unfinalized[i+1:]
+		return
+	}
+}
+
+// acknowledge notifies the clients that their commands were executed, and passes them the errors
+// of the commands that failed. The errors and receipts are either nil, or one for each command.
+// The caller must hold srv.mut.
+func (srv *clientSrv) acknowledge(ids []cmdID, errs []error, receipts []*clientpb.Receipt) {
+	for i, id := range ids {
+		var (
+			err     error
+			receipt *clientpb.Receipt
+		)
+		if errs != nil {
+			err = errs[i]
+		}
+		if receipts != nil {
+			receipt = receipts[i]
+		}
+		srv.storeResult(id, err, receipt)
 		if done, ok := srv.awaitingCmds[id]; ok {
-			done <- nil
+			done <- err
 			delete(srv.awaitingCmds, id)
+			delete(srv.optimistic, id)
+			srv.notify(id, clientpb.Confidence_FINAL)
 		}
-		srv.mut.Unlock()
+	}
+}
+
+// storeResult keeps the result of a committed command for the retention window. The caller must hold srv.mut.
+func (srv *clientSrv) storeResult(id cmdID, err error, receipt *clientpb.Receipt) {
+	if srv.retention <= 0 {
+		return
+	}
+	if _, ok := srv.results[id]; ok {
+		return
+	}
+	srv.results[id] = result{err: err, expires: time.Now().Add(srv.retention), receipt: receipt}
+	srv.resultOrder.PushBack(id)
+}
+
+// cachedResult returns the result of a committed command, if it is still kept. The caller must hold srv.mut.
+func (srv *clientSrv) cachedResult(id cmdID) (res result, ok bool) {
+	now := time.Now()
+	// the results expire in the order they were stored.
+	for elem := srv.resultOrder.Front(); elem != nil; elem = srv.resultOrder.Front() {
+		expired := elem.Value.(cmdID)
+		if srv.results[expired].expires.After(now) {
+			break
+		}
+		delete(srv.results, expired)
+		srv.resultOrder.Remove(elem)
+	}
+	res, ok = srv.results[id]
+	return res, ok
+}
+
+// onCertified optimistically acknowledges the commands of the certified block.
+func (srv *clientSrv) onCertified(event consensus.CertifiedEvent) {
+	batch := new(clientpb.Batch)
+	err := proto.UnmarshalOptions{AllowPartial: true}.Unmarshal([]byte(event.Block.Command()), batch)
+	if err != nil {
+		return
+	}
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	for _, cmd := range batch.GetCommands() {
+		id := cmdID{cmd.GetClientID(), cmd.GetSequenceNumber()}
+		if _, ok := srv.awaitingCmds[id]; ok && !srv.optimistic[id] {
+			srv.optimistic[id] = true
+			srv.notify(id, clientpb.Confidence_OPTIMISTIC)
+		}
+	}
+}
+
+// notify passes an acknowledgment of the command to the OnAck callback, if set. The caller must hold srv.mut.
+func (srv *clientSrv) notify(id cmdID, confidence clientpb.Confidence) {
+	if srv.onAck != nil {
+		srv.onAck(&clientpb.Ack{ClientID: id.clientID, SequenceNumber: id.sequenceNum, Confidence: confidence})
 	}
 }
 
@@ -116,6 +329,10 @@ func (srv *clientSrv) Fork(cmd consensus.Command) {
 	for _, cmd := range batch.GetCommands() {
 		srv.mut.Lock()
 		id := cmdID{cmd.GetClientID(), cmd.GetSequenceNumber()}
+		if srv.optimistic[id] {
+			delete(srv.optimistic, id)
+			srv.notify(id, clientpb.Confidence_RETRACTED)
+		}
 		if done, ok := srv.awaitingCmds[id]; ok {
 			done <- status.Error(codes.Aborted, "blockchain was forked")
 			delete(srv.awaitingCmds, id)
@@ -123,3 +340,14 @@ func (srv *clientSrv) Fork(cmd consensus.Command) {
 		srv.mut.Unlock()
 	}
 }
+
+// expire notifies the client that the command expired before it was committed.
+func (srv *clientSrv) expire(cmd *clientpb.Command) {
+	srv.mut.Lock()
+	defer srv.mut.Unlock()
+	id := cmdID{cmd.GetClientID(), cmd.GetSequenceNumber()}
+	if done, ok := srv.awaitingCmds[id]; ok {
+		done <- status.Error(codes.DeadlineExceeded, "command expired before it was committed")
+		delete(srv.awaitingCmds, id)
+	}
+}
@@ -0,0 +1,523 @@
+package replica
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/relab/gorums"
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/crypto"
+	"github.com/relab/hotstuff/crypto/ecdsa"
+	"github.com/relab/hotstuff/internal/mocks"
+	"github.com/relab/hotstuff/internal/proto/clientpb"
+	"github.com/relab/hotstuff/internal/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFinalizedAcknowledgement(t *testing.T) {
+	srv := newClientServer(Config{BatchSize: 1}, nil)
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(srv, srv.cmdCache)
+	builder.Options().SetShouldFinalizeCommits()
+	builder.Build()
+
+	submit := func(cmd *clientpb.Command) (consensus.Command, <-chan error) {
+		c := make(chan error, 1)
+		srv.mut.Lock()
+		srv.awaitingCmds[cmdID{cmd.GetClientID(), cmd.GetSequenceNumber()}] = c
+		srv.mut.Unlock()
+		b, err := proto.Marshal(&clientpb.Batch{Commands: []*clientpb.Command{cmd}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return consensus.Command(b), c
+	}
+	acknowledged := func(c <-chan error) bool {
+		select {
+		case err := <-c:
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			return true
+		default:
+			return false
+		}
+	}
+
+	cmd1, done1 := submit(&clientpb.Command{ClientID: 1, SequenceNumber: 1})
+	cmd2, done2 := submit(&clientpb.Command{ClientID: 1, SequenceNumber: 2})
+	for _, cmd := range []consensus.Command{cmd1, cmd2} {
+		if err := srv.Exec(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if acknowledged(done1) || acknowledged(done2) {
+		t.Fatal("commands were acknowledged before they were finalized")
+	}
+
+	genesis := consensus.GetGenesis()
+	block1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), cmd1, 1, 1)
+	srv.onFinalized(consensus.FinalizedEvent{Block: block1})
+	if !acknowledged(done1) {
+		t.Error("the command of the finalized block was not acknowledged")
+	}
+	if acknowledged(done2) {
+		t.Error("the command of a block after the finalized block was acknowledged")
+	}
+
+	// finalizing a block also finalizes the blocks before it.
+	cmd3, done3 := submit(&clientpb.Command{ClientID: 1, SequenceNumber: 3})
+	if err := srv.Exec(cmd3); err != nil {
+		t.Fatal(err)
+	}
+	block3 := consensus.NewBlock(block1.Hash(), consensus.NewQuorumCert(nil, 1, block1.Hash()), cmd3, 3, 1)
+	srv.onFinalized(consensus.FinalizedEvent{Block: block3})
+	if !acknowledged(done2) || !acknowledged(done3) {
+		t.Error("expected the commands of all blocks up to the finalized block to be acknowledged")
+	}
+}
+
+func TestOptimisticAcknowledgement(t *testing.T) {
+	var acks []*clientpb.Ack
+	srv := newClientServer(Config{BatchSize: 1, OnAck: func(ack *clientpb.Ack) { acks = append(acks, ack) }}, nil)
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(srv, srv.cmdCache)
+	builder.Build()
+
+	submit := func(cmd *clientpb.Command) (consensus.Command, <-chan error) {
+		c := make(chan error, 1)
+		srv.mut.Lock()
+		srv.awaitingCmds[cmdID{cmd.GetClientID(), cmd.GetSequenceNumber()}] = c
+		srv.mut.Unlock()
+		b, err := proto.Marshal(&clientpb.Batch{Commands: []*clientpb.Command{cmd}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return consensus.Command(b), c
+	}
+	checkAcks := func(want ...clientpb.Confidence) {
+		t.Helper()
+		if len(acks) != len(want) {
+			t.Fatalf("got %d acknowledgments, want %d", len(acks), len(want))
+		}
+		for i, ack := range acks {
+			if ack.GetConfidence() != want[i] {
+				t.Errorf("acknowledgment %d: got %v, want %v", i, ack.GetConfidence(), want[i])
+			}
+		}
+		acks = nil
+	}
+
+	genesis := consensus.GetGenesis()
+	cmd1, done1 := submit(&clientpb.Command{ClientID: 1, SequenceNumber: 1})
+	block1 := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), cmd1, 1, 1)
+
+	// the command is acknowledged optimistically once its block is certified, but only once.
+	srv.onCertified(consensus.CertifiedEvent{Block: block1})
+	srv.onCertified(consensus.CertifiedEvent{Block: block1})
+	checkAcks(clientpb.Confidence_OPTIMISTIC)
+	select {
+	case <-done1:
+		t.Fatal("the client request completed before the command was executed")
+	default:
+	}
+
+	// the acknowledgment is upgraded once the command is executed.
+	if err := srv.Exec(cmd1); err != nil {
+		t.Fatal(err)
+	}
+	checkAcks(clientpb.Confidence_FINAL)
+	if err := <-done1; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// the acknowledgment is retracted if the certified block is abandoned.
+	cmd2, done2 := submit(&clientpb.Command{ClientID: 1, SequenceNumber: 2})
+	block2 := consensus.NewBlock(block1.Hash(), consensus.NewQuorumCert(nil, 1, block1.Hash()), cmd2, 2, 1)
+	srv.onCertified(consensus.CertifiedEvent{Block: block2})
+	srv.Fork(cmd2)
+	checkAcks(clientpb.Confidence_OPTIMISTIC, clientpb.Confidence_RETRACTED)
+	if status.Code(<-done2) != codes.Aborted {
+		t.Error("expected the client request to be aborted")
+	}
+}
+
+// firstReply is a quorum spec that completes a client request with the first reply.
+type firstReply struct{}
+
+func (firstReply) ExecCommandQF(_ *clientpb.Command, replies map[uint32]*empty.Empty) (*empty.Empty, bool) {
+	for _, reply := range replies {
+		return reply, true
+	}
+	return nil, false
+}
+
+func (firstReply) GetReceiptQF(_ *clientpb.CommandID, replies map[uint32]*clientpb.Receipt) (*clientpb.Receipts, bool) {
+	for _, reply := range replies {
+		return &clientpb.Receipts{Receipts: []*clientpb.Receipt{reply}}, true
+	}
+	return nil, false
+}
+
+func TestResultRetention(t *testing.T) {
+	srv := newClientServer(Config{BatchSize: 1, ResultRetention: time.Minute}, nil)
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(srv, srv.cmdCache)
+	builder.Build()
+	lis := testutil.CreateTCPListener(t)
+	srv.StartOnListener(lis)
+	defer srv.Stop()
+
+	mgr := clientpb.NewManager(
+		gorums.WithDialTimeout(time.Second),
+		gorums.WithGrpcDialOptions(grpc.WithBlock(), grpc.WithInsecure()),
+	)
+	defer mgr.Close()
+	cfg, err := mgr.NewConfiguration(firstReply{}, gorums.WithNodeList([]string{lis.Addr().String()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cmd := &clientpb.Command{ClientID: 1, SequenceNumber: 1, Data: []byte("foo")}
+	b, err := proto.Marshal(&clientpb.Batch{Commands: []*clientpb.Command{cmd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := cfg.ExecCommand(ctx, cmd)
+	for srv.cmdCache.Pending() == 0 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("the command was not queued")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if err := srv.Exec(consensus.Command(b)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reply.Get(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hash := srv.hash.Sum(nil)
+	pending := srv.cmdCache.Pending()
+
+	// the client retries the command, for example because the reply was late.
+	if _, err := cfg.ExecCommand(ctx, cmd).Get(); err != nil {
+		t.Fatalf("expected the retried command to get the original result, got %v", err)
+	}
+	if srv.cmdCache.Pending() != pending {
+		t.Error("expected the retried command not to be proposed again")
+	}
+	// the command is not executed again, even if a faulty leader proposes it again.
+	if err := srv.Exec(consensus.Command(b)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(hash, srv.hash.Sum(nil)) {
+		t.Error("the retried command was executed again")
+	}
+
+	// results are only kept for the retention window.
+	short := newClientServer(Config{BatchSize: 1, ResultRetention: time.Millisecond}, nil)
+	short.acknowledge([]cmdID{{clientID: 1, sequenceNum: 1}}, nil, nil)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := short.cachedResult(cmdID{clientID: 1, sequenceNum: 1}); ok {
+		t.Error("expected the result to expire")
+	}
+}
+
+// connectivity is a ConnectivityMonitor that reports a fixed number of reachable replicas.
+type connectivity struct {
+	reachable int32
+}
+
+func (c *connectivity) Reachable() int {
+	return int(atomic.LoadInt32(&c.reachable))
+}
+
+// TestReadOnlyCommands checks that a read-only replica rejects new commands, but still returns the results
+// of committed commands, and that it accepts new commands again once a quorum is reachable.
+func TestReadOnlyCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv := newClientServer(Config{BatchSize: 1, ResultRetention: time.Minute}, nil)
+	cfg := mocks.NewMockConfiguration(ctrl)
+	cfg.EXPECT().QuorumSize().AnyTimes().Return(3)
+	conn := &connectivity{reachable: 4}
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(srv, srv.cmdCache, cfg, conn)
+	builder.Options().SetQuorumLossTimeout(20 * time.Millisecond)
+	mods := builder.Build()
+	lis := testutil.CreateTCPListener(t)
+	srv.StartOnListener(lis)
+	defer srv.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go mods.EventLoop().Run(ctx)
+	waitFor := func(cond func() bool) {
+		for !cond() {
+			select {
+			case <-ctx.Done():
+				t.Fatal("timed out")
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+
+	mgr := clientpb.NewManager(
+		gorums.WithDialTimeout(time.Second),
+		gorums.WithGrpcDialOptions(grpc.WithBlock(), grpc.WithInsecure()),
+	)
+	defer mgr.Close()
+	client, err := mgr.NewConfiguration(firstReply{}, gorums.WithNodeList([]string{lis.Addr().String()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	committed := &clientpb.Command{ClientID: 1, SequenceNumber: 1, Data: []byte("foo")}
+	b, err := proto.Marshal(&clientpb.Batch{Commands: []*clientpb.Command{committed}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Exec(consensus.Command(b)); err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&conn.reachable, 1)
+	waitFor(mods.ReadOnly)
+	if _, err := client.ExecCommand(ctx, committed).Get(); err != nil {
+		t.Errorf("expected the result of the committed command, got %v", err)
+	}
+	cmd := &clientpb.Command{ClientID: 1, SequenceNumber: 2, Data: []byte("bar")}
+	_, err = client.ExecCommand(ctx, cmd).Get()
+	var qcErr gorums.QuorumCallError
+	if !errors.As(err, &qcErr) || len(qcErr.Errors) != 1 || status.Code(qcErr.Errors[0].Cause) != codes.Unavailable {
+		t.Errorf("expected the new command to be rejected with codes.Unavailable, got %v", err)
+	}
+	if srv.cmdCache.Pending() != 0 {
+		t.Error("expected the rejected command not to be queued")
+	}
+
+	atomic.StoreInt32(&conn.reachable, 4)
+	waitFor(func() bool { return !mods.ReadOnly() })
+	reply := client.ExecCommand(ctx, cmd)
+	waitFor(func() bool { return srv.cmdCache.Pending() > 0 })
+	b, err = proto.Marshal(&clientpb.Batch{Commands: []*clientpb.Command{cmd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Exec(consensus.Command(b)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reply.Get(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestExactlyOnce checks that a command is executed at most once, even if its result is not kept.
+func TestExactlyOnce(t *testing.T) {
+	srv := newClientServer(Config{BatchSize: 1}, nil)
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(srv, srv.cmdCache)
+	builder.Build()
+
+	batch := func(cmds ...*clientpb.Command) consensus.Command {
+		b, err := proto.Marshal(&clientpb.Batch{Commands: cmds})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return consensus.Command(b)
+	}
+	cmd1 := &clientpb.Command{ClientID: 1, SequenceNumber: 1, Data: []byte("foo")}
+	cmd2 := &clientpb.Command{ClientID: 1, SequenceNumber: 2, Data: []byte("bar")}
+	if err := srv.Exec(batch(cmd1, cmd2)); err != nil {
+		t.Fatal(err)
+	}
+	hash := srv.hash.Sum(nil)
+
+	// a faulty leader proposes the committed commands again.
+	for _, cmd := range []*clientpb.Command{cmd1, cmd2} {
+		if err := srv.Exec(batch(cmd)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(hash, srv.hash.Sum(nil)) {
+		t.Error("a committed command was executed again")
+	}
+
+	cmd3 := &clientpb.Command{ClientID: 1, SequenceNumber: 3, Data: []byte("baz")}
+	if err := srv.Exec(batch(cmd3)); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(hash, srv.hash.Sum(nil)) {
+		t.Error("a new command was not executed")
+	}
+}
+
+// TestCommandFailure checks that the errors of commands that fail are passed to their clients,
+// and kept for clients that retry them.
+func TestCommandFailure(t *testing.T) {
+	srv := newClientServer(Config{
+		BatchSize:       2,
+		ResultRetention: time.Minute,
+		Apply: func(cmd *clientpb.Command) error {
+			if string(cmd.GetData()) == "fail" {
+				return errors.New("invalid command")
+			}
+			return nil
+		},
+	}, nil)
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(srv, srv.cmdCache)
+	builder.Build()
+
+	ok := &clientpb.Command{ClientID: 1, SequenceNumber: 1, Data: []byte("ok")}
+	fail := &clientpb.Command{ClientID: 2, SequenceNumber: 1, Data: []byte("fail")}
+	done := make([]chan error, 2)
+	for i, cmd := range []*clientpb.Command{ok, fail} {
+		done[i] = make(chan error, 1)
+		srv.awaitingCmds[cmdID{cmd.GetClientID(), cmd.GetSequenceNumber()}] = done[i]
+	}
+	b, err := proto.Marshal(&clientpb.Batch{Commands: []*clientpb.Command{ok, fail}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Exec(consensus.Command(b)); err == nil {
+		t.Error("expected the batch to report the failed command")
+	}
+
+	if err := <-done[0]; err != nil {
+		t.Errorf("expected the first command to succeed, got %v", err)
+	}
+	if err := <-done[1]; err == nil || err.Error() != "invalid command" {
+		t.Errorf("expected the second command to fail, got %v", err)
+	}
+	res, found := srv.cachedResult(cmdID{fail.GetClientID(), fail.GetSequenceNumber()})
+	if !found || res.err == nil {
+		t.Errorf("expected the error of the failed command to be kept, got %v", res.err)
+	}
+}
+
+// allReplies is a quorum spec that waits for the replies of all n replicas.
+type allReplies struct {
+	n int
+}
+
+func (q allReplies) ExecCommandQF(_ *clientpb.Command, replies map[uint32]*empty.Empty) (*empty.Empty, bool) {
+	return &empty.Empty{}, len(replies) == q.n
+}
+
+func (q allReplies) GetReceiptQF(_ *clientpb.CommandID, replies map[uint32]*clientpb.Receipt) (*clientpb.Receipts, bool) {
+	if len(replies) < q.n {
+		return nil, false
+	}
+	receipts := &clientpb.Receipts{}
+	for _, reply := range replies {
+		receipts.Receipts = append(receipts.Receipts, reply)
+	}
+	return receipts, true
+}
+
+// TestReceipts checks that the replicas that committed and executed a command sign receipts that attest to the same
+// block and result, and that a replica whose state diverged signs a receipt with a different result.
+func TestReceipts(t *testing.T) {
+	const n = 4
+	ctrl := gomock.NewController(t)
+	keys := testutil.GenerateKeys(t, n, testutil.GenerateECDSAKey)
+
+	cmd := &clientpb.Command{ClientID: 1, SequenceNumber: 1, Data: []byte("foo")}
+	b, err := proto.Marshal(&clientpb.Batch{Commands: []*clientpb.Command{cmd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := consensus.GetGenesis()
+	block := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), consensus.Command(b), 1, 1)
+
+	// replica 4 executed another command before, so its state differs from the other replicas.
+	other, err := proto.Marshal(&clientpb.Batch{Commands: []*clientpb.Command{{ClientID: 2, SequenceNumber: 1, Data: []byte("bar")}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var verifier consensus.Crypto
+	addrs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		srv := newClientServer(Config{BatchSize: 1, ResultRetention: time.Minute, SignReceipts: true}, nil)
+		cfg, _ := testutil.CreateMockConfigurationWithReplicas(t, ctrl, n, keys...)
+		builder := consensus.NewBuilder(hotstuff.ID(i+1), keys[i])
+		builder.Register(srv, srv.cmdCache, cfg, crypto.NewCache(ecdsa.New(), 100))
+		mods := builder.Build()
+		verifier = mods.Crypto()
+
+		if i == n-1 {
+			if err := srv.Exec(consensus.Command(other)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := (blockExecutor{srv}).Exec(block); err != nil {
+			t.Fatal(err)
+		}
+		lis := testutil.CreateTCPListener(t)
+		srv.StartOnListener(lis)
+		defer srv.Stop()
+		addrs = append(addrs, lis.Addr().String())
+	}
+
+	mgr := clientpb.NewManager(
+		gorums.WithDialTimeout(time.Second),
+		gorums.WithGrpcDialOptions(grpc.WithBlock(), grpc.WithInsecure()),
+	)
+	defer mgr.Close()
+	cfg, err := mgr.NewConfiguration(allReplies{n}, gorums.WithNodeList(addrs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipts, err := cfg.GetReceipt(ctx, &clientpb.CommandID{ClientID: 1, SequenceNumber: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := block.Hash()
+	results := make(map[string][]uint32)
+	for _, receipt := range receipts.GetReceipts() {
+		if !VerifyReceipt(verifier, receipt) {
+			t.Errorf("the receipt of replica %d does not verify", receipt.GetReplicaID())
+		}
+		if !bytes.Equal(receipt.GetBlockHash(), hash[:]) || receipt.GetView() != 1 {
+			t.Errorf("the receipt of replica %d attests to block %.8x in view %d, want %.8x in view 1",
+				receipt.GetReplicaID(), receipt.GetBlockHash(), receipt.GetView(), hash[:])
+		}
+		results[string(receipt.GetResultDigest())] = append(results[string(receipt.GetResultDigest())], receipt.GetReplicaID())
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected two different results, got %v", results)
+	}
+	for _, replicas := range results {
+		if len(replicas) != hotstuff.QuorumSize(n) && (len(replicas) != 1 || replicas[0] != n) {
+			t.Errorf("unexpected replicas %v with the same result", replicas)
+		}
+	}
+
+	// a receipt cannot be moved to another command.
+	forged := proto.Clone(receipts.GetReceipts()[0]).(*clientpb.Receipt)
+	forged.SequenceNumber = 2
+	if VerifyReceipt(verifier, forged) {
+		t.Error("a receipt for another command verifies")
+	}
+
+	if _, err := cfg.GetReceipt(ctx, &clientpb.CommandID{ClientID: 1, SequenceNumber: 2}); err == nil {
+		t.Error("expected no receipts for a command that was not committed")
+	}
+}
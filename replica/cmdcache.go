@@ -3,40 +3,62 @@ package replica
 import (
 	"container/list"
 	"context"
+	"crypto/ecdsa"
 	"sync"
 
 	"github.com/relab/hotstuff/consensus"
 	"github.com/relab/hotstuff/internal/proto/clientpb"
-	"github.com/relab/hotstuff/modules"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
 type cmdCache struct {
 	mut           sync.Mutex
-	mods          *modules.Modules
+	mods          *consensus.Modules
 	c             chan struct{}
 	batchSize     int
+	batchBytes    int               // if set, the target size in bytes of a proposal; overrides batchSize
 	serialNumbers map[uint32]uint64 // highest proposed serial number per client ID
 	cache         list.List
 	marshaler     proto.MarshalOptions
 	unmarshaler   proto.UnmarshalOptions
+	onExpired     func(cmd *clientpb.Command) // called for commands that expire before they are proposed
+	clientKeys    map[uint32]*ecdsa.PublicKey // if set, only commands signed by their clients are accepted
 }
 
-func newCmdCache(batchSize int) *cmdCache {
+func newCmdCache(batchSize, batchBytes int, clientKeys map[uint32]*ecdsa.PublicKey) *cmdCache {
 	return &cmdCache{
+		clientKeys:    clientKeys,
 		c:             make(chan struct{}),
 		batchSize:     batchSize,
+		batchBytes:    batchBytes,
 		serialNumbers: make(map[uint32]uint64),
 		marshaler:     proto.MarshalOptions{Deterministic: true},
 		unmarshaler:   proto.UnmarshalOptions{DiscardUnknown: true},
 	}
 }
 
-// InitModule gives the module access to the other modules.
-func (c *cmdCache) InitModule(mods *modules.Modules) {
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (c *cmdCache) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
 	c.mods = mods
 }
 
+// authorized returns true if the command was signed by its client, or if client signatures are not required.
+func (c *cmdCache) authorized(cmd *clientpb.Command) bool {
+	if c.clientKeys == nil {
+		return true
+	}
+	key, ok := c.clientKeys[cmd.GetClientID()]
+	return ok && clientpb.VerifyCommand(cmd, key)
+}
+
+// isExpired returns true if the command may not be proposed in the given view.
+func isExpired(cmd *clientpb.Command, view consensus.View) bool {
+	return cmd.GetExpiryView() != 0 && consensus.View(cmd.GetExpiryView()) < view
+}
+
 func (c *cmdCache) addCommand(cmd *clientpb.Command) {
 	c.mut.Lock()
 	defer c.mut.Unlock()
@@ -45,7 +67,7 @@ func (c *cmdCache) addCommand(cmd *clientpb.Command) {
 		return
 	}
 	c.cache.PushBack(cmd)
-	if c.cache.Len() >= c.batchSize {
+	if c.batchBytes > 0 || c.cache.Len() >= c.batchSize {
 		// notify Get that we are ready to send a new batch.
 		select {
 		case c.c <- struct{}{}:
@@ -55,8 +77,15 @@ func (c *cmdCache) addCommand(cmd *clientpb.Command) {
 }
 
 // Get returns a batch of commands to propose.
+// Commands that expire before the current view are removed from the cache.
 func (c *cmdCache) Get(ctx context.Context) (cmd consensus.Command, ok bool) {
+	if c.batchBytes > 0 {
+		return c.getBytes(ctx)
+	}
+
 	batch := new(clientpb.Batch)
+	// the command is proposed in the current view.
+	view := c.mods.Synchronizer().View()
 
 	c.mut.Lock()
 awaitBatch:
@@ -85,6 +114,13 @@ awaitBatch:
 			i--
 			continue
 		}
+		if isExpired(cmd, view) {
+			if c.onExpired != nil {
+				c.onExpired(cmd)
+			}
+			i--
+			continue
+		}
 		batch.Commands = append(batch.Commands, cmd)
 	}
 
@@ -106,8 +142,100 @@ awaitBatch:
 	return cmd, true
 }
 
+// getBytes returns a batch containing as many of the queued commands as fit in a proposal of batchBytes bytes,
+// accounting for the size of the rest of the block, including its quorum certificate.
+// A command that does not fit in a proposal on its own is proposed alone.
+func (c *cmdCache) getBytes(ctx context.Context) (cmd consensus.Command, ok bool) {
+	batch := new(clientpb.Batch)
+	// the command is proposed in the current view.
+	view := c.mods.Synchronizer().View()
+	budget := c.batchBytes - c.blockOverhead(view)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	size := 0
+	for len(batch.Commands) == 0 {
+		// wait until there is at least one command.
+		for c.cache.Len() == 0 {
+			c.mut.Unlock()
+			select {
+			case <-c.c:
+			case <-ctx.Done():
+				c.mut.Lock()
+				return "", false
+			}
+			c.mut.Lock()
+		}
+
+		for elem := c.cache.Front(); elem != nil; elem = c.cache.Front() {
+			cmd := elem.Value.(*clientpb.Command)
+			if serialNo := c.serialNumbers[cmd.GetClientID()]; serialNo >= cmd.GetSequenceNumber() {
+				// command is too old
+				c.cache.Remove(elem)
+				continue
+			}
+			if isExpired(cmd, view) {
+				c.cache.Remove(elem)
+				if c.onExpired != nil {
+					c.onExpired(cmd)
+				}
+				continue
+			}
+			// the size of the command as an element of the Commands field.
+			cmdSize := 1 + protowire.SizeBytes(proto.Size(cmd))
+			if len(batch.Commands) > 0 && size+cmdSize > budget {
+				break
+			}
+			c.cache.Remove(elem)
+			batch.Commands = append(batch.Commands, cmd)
+			size += cmdSize
+			if size > budget {
+				c.mods.Logger().Debugf("Proposing a command of %d bytes that exceeds the proposal size target alone", cmdSize)
+				break
+			}
+		}
+	}
+
+	b, err := c.marshaler.Marshal(batch)
+	if err != nil {
+		c.mods.Logger().Errorf("Failed to marshal batch: %v", err)
+		return "", false
+	}
+	return consensus.Command(b), true
+}
+
+// blockOverhead returns the size in bytes of a proposal in the given view, excluding its commands.
+func (c *cmdCache) blockOverhead(view consensus.View) int {
+	block := consensus.NewBlock(consensus.Hash{}, c.mods.Synchronizer().HighQC(), "", view, c.mods.ID())
+	// the Command field of the block adds a tag and a length prefix for the batch.
+	return proto.Size(hotstuffpb.BlockToProto(block)) + 1 + protowire.SizeVarint(uint64(c.batchBytes))
+}
+
+// CountCommands returns the number of commands in the batch.
+func (c *cmdCache) CountCommands(cmd consensus.Command) (n int, ok bool) {
+	batch := new(clientpb.Batch)
+	if err := c.unmarshaler.Unmarshal([]byte(cmd), batch); err != nil {
+		return 0, false
+	}
+	return len(batch.GetCommands()), true
+}
+
 // Accept returns true if the replica can accept the batch.
 func (c *cmdCache) Accept(cmd consensus.Command) bool {
+	return c.accept(cmd, 0)
+}
+
+// AcceptInView returns true if the replica can accept the batch proposed in the given view.
+// A batch that contains an expired command is not accepted.
+func (c *cmdCache) AcceptInView(cmd consensus.Command, view consensus.View) bool {
+	return c.accept(cmd, view)
+}
+
+// accept returns true if the batch contains no old commands, no commands that have expired before the view,
+// and no commands that were not signed by their clients, if client signatures are required.
+// If the view is 0, expiry is not checked.
+func (c *cmdCache) accept(cmd consensus.Command, view consensus.View) bool {
 	batch := new(clientpb.Batch)
 	err := c.unmarshaler.Unmarshal([]byte(cmd), batch)
 	if err != nil {
@@ -123,6 +251,13 @@ func (c *cmdCache) Accept(cmd consensus.Command) bool {
 			// command is too old, can't accept
 			return false
 		}
+		if view != 0 && isExpired(cmd, view) {
+			return false
+		}
+		if !c.authorized(cmd) {
+			c.mods.Logger().Infof("Rejecting batch with unauthorized command %d from client %d", cmd.GetSequenceNumber(), cmd.GetClientID())
+			return false
+		}
 	}
 
 	return true
@@ -147,4 +282,16 @@ func (c *cmdCache) Proposed(cmd consensus.Command) {
 	}
 }
 
-var _ consensus.Acceptor = (*cmdCache)(nil)
+// Pending returns the number of cached commands. Some of them may turn out to be expired or already proposed.
+func (c *cmdCache) Pending() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.cache.Len()
+}
+
+var (
+	_ consensus.Acceptor       = (*cmdCache)(nil)
+	_ consensus.ViewAcceptor   = (*cmdCache)(nil)
+	_ consensus.CommandCounter = (*cmdCache)(nil)
+	_ consensus.PendingCounter = (*cmdCache)(nil)
+)
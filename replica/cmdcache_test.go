@@ -0,0 +1,275 @@
+package replica
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/rand"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/relab/gorums"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/mocks"
+	"github.com/relab/hotstuff/internal/proto/clientpb"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
+	"github.com/relab/hotstuff/internal/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCommandExpiry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	synchronizer := mocks.NewMockSynchronizer(ctrl)
+	synchronizer.EXPECT().View().AnyTimes().Return(consensus.View(5))
+
+	srv := newClientServer(Config{BatchSize: 1}, nil)
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(synchronizer, srv, srv.cmdCache)
+	builder.Build()
+
+	submit := func(cmd *clientpb.Command) <-chan error {
+		c := make(chan error, 1)
+		srv.mut.Lock()
+		srv.awaitingCmds[cmdID{cmd.GetClientID(), cmd.GetSequenceNumber()}] = c
+		srv.mut.Unlock()
+		srv.cmdCache.addCommand(cmd)
+		return c
+	}
+
+	// the first command expires after view 3, before it can be proposed in view 5.
+	expired := &clientpb.Command{ClientID: 1, SequenceNumber: 1, ExpiryView: 3}
+	valid := &clientpb.Command{ClientID: 2, SequenceNumber: 1, ExpiryView: 5}
+	expiredDone := submit(expired)
+	submit(valid)
+
+	cmd, ok := srv.cmdCache.Get(context.Background())
+	if !ok {
+		t.Fatal("expected a batch")
+	}
+	batch := new(clientpb.Batch)
+	if err := proto.Unmarshal([]byte(cmd), batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.GetCommands()) != 1 || batch.GetCommands()[0].GetClientID() != valid.GetClientID() {
+		t.Errorf("expected a batch containing only the valid command, got: %v", batch.GetCommands())
+	}
+
+	select {
+	case err := <-expiredDone:
+		if status.Code(err) != codes.DeadlineExceeded {
+			t.Errorf("expected the client to be notified that the command expired, got: %v", err)
+		}
+	default:
+		t.Error("the client was not notified about the expired command")
+	}
+
+	// replicas must agree on whether a command in a proposal has expired, so the view of the proposal is used.
+	b, err := proto.Marshal(&clientpb.Batch{Commands: []*clientpb.Command{{ClientID: 3, SequenceNumber: 1, ExpiryView: 3}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !srv.cmdCache.AcceptInView(consensus.Command(b), 3) {
+		t.Error("expected the command to be accepted in its expiry view")
+	}
+	if srv.cmdCache.AcceptInView(consensus.Command(b), 4) {
+		t.Error("expected the command to be rejected after its expiry view")
+	}
+}
+
+// TestBatchSize checks that the cache proposes batches of at most BatchSize commands,
+// and that the commands of the batches are executed and acknowledged in the order they were submitted.
+func TestBatchSize(t *testing.T) {
+	const batchSize = 3
+	ctrl := gomock.NewController(t)
+	synchronizer := mocks.NewMockSynchronizer(ctrl)
+	synchronizer.EXPECT().View().AnyTimes().Return(consensus.View(1))
+
+	var acks []*clientpb.Ack
+	srv := newClientServer(Config{BatchSize: batchSize, OnAck: func(ack *clientpb.Ack) { acks = append(acks, ack) }}, nil)
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(synchronizer, srv, srv.cmdCache)
+	builder.Build()
+
+	for seq := uint64(1); seq <= 8; seq++ {
+		srv.mut.Lock()
+		srv.awaitingCmds[cmdID{1, seq}] = make(chan error, 1)
+		srv.mut.Unlock()
+		srv.cmdCache.addCommand(&clientpb.Command{ClientID: 1, SequenceNumber: seq})
+	}
+
+	var next uint64 = 1
+	for i := 0; i < 2; i++ {
+		cmd, ok := srv.cmdCache.Get(context.Background())
+		if !ok {
+			t.Fatalf("expected batch %d", i)
+		}
+		batch := new(clientpb.Batch)
+		if err := proto.Unmarshal([]byte(cmd), batch); err != nil {
+			t.Fatal(err)
+		}
+		if len(batch.GetCommands()) != batchSize {
+			t.Fatalf("batch %d has %d commands, want %d", i, len(batch.GetCommands()), batchSize)
+		}
+		for _, c := range batch.GetCommands() {
+			if c.GetSequenceNumber() != next {
+				t.Errorf("batch %d: got command %d, want %d", i, c.GetSequenceNumber(), next)
+			}
+			next++
+		}
+		if err := srv.Exec(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(acks) != 2*batchSize {
+		t.Fatalf("got %d acknowledgments, want %d", len(acks), 2*batchSize)
+	}
+	for i, ack := range acks {
+		if ack.GetSequenceNumber() != uint64(i+1) {
+			t.Errorf("acknowledgment %d is for command %d, want %d", i, ack.GetSequenceNumber(), i+1)
+		}
+	}
+}
+
+func TestCommandAuthorization(t *testing.T) {
+	clientKey := testutil.GenerateECDSAKey(t).(*ecdsa.PrivateKey)
+	forgerKey := testutil.GenerateECDSAKey(t).(*ecdsa.PrivateKey)
+
+	srv := newClientServer(Config{BatchSize: 2, ClientKeys: map[uint32]*ecdsa.PublicKey{1: &clientKey.PublicKey}}, nil)
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(srv, srv.cmdCache)
+	builder.Build()
+
+	sign := func(cmd *clientpb.Command, key *ecdsa.PrivateKey) *clientpb.Command {
+		if err := clientpb.SignCommand(cmd, key); err != nil {
+			t.Fatal(err)
+		}
+		return cmd
+	}
+	batch := func(cmds ...*clientpb.Command) consensus.Command {
+		b, err := proto.Marshal(&clientpb.Batch{Commands: cmds})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return consensus.Command(b)
+	}
+
+	valid := sign(&clientpb.Command{ClientID: 1, SequenceNumber: 1, Data: []byte("valid")}, clientKey)
+	forged := sign(&clientpb.Command{ClientID: 1, SequenceNumber: 2, Data: []byte("forged")}, forgerKey)
+	unknown := sign(&clientpb.Command{ClientID: 2, SequenceNumber: 1, Data: []byte("unknown")}, forgerKey)
+	unsigned := &clientpb.Command{ClientID: 1, SequenceNumber: 3, Data: []byte("unsigned")}
+
+	// a single unauthorized command causes the whole batch to be rejected.
+	for _, cmd := range []*clientpb.Command{forged, unknown, unsigned} {
+		if srv.cmdCache.Accept(batch(valid, cmd)) {
+			t.Errorf("expected batch with command %q to be rejected", cmd.GetData())
+		}
+	}
+	if !srv.cmdCache.Accept(batch(valid, sign(&clientpb.Command{ClientID: 1, SequenceNumber: 2}, clientKey))) {
+		t.Error("expected batch of signed commands to be accepted")
+	}
+
+	// modifying a signed command invalidates the signature.
+	tampered := proto.Clone(valid).(*clientpb.Command)
+	tampered.SequenceNumber = 10
+	if srv.cmdCache.Accept(batch(tampered)) {
+		t.Error("expected tampered command to be rejected")
+	}
+
+	_, err := srv.ExecCommand(gorums.ServerCtx{Context: context.Background()}, forged)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected forged command to be refused by the client server, got: %v", err)
+	}
+}
+
+// proposalSize returns the size in bytes of the proposal of cmd by the cache's replica in the current view.
+func proposalSize(t *testing.T, c *cmdCache, cmd consensus.Command) int {
+	t.Helper()
+	synchronizer := c.mods.Synchronizer()
+	block := consensus.NewBlock(consensus.Hash{}, synchronizer.HighQC(), cmd, synchronizer.View(), c.mods.ID())
+	return proto.Size(hotstuffpb.BlockToProto(block))
+}
+
+func newBatchBytesServer(t *testing.T, batchBytes uint32) *clientSrv {
+	ctrl := gomock.NewController(t)
+	synchronizer := mocks.NewMockSynchronizer(ctrl)
+	synchronizer.EXPECT().View().AnyTimes().Return(consensus.View(5))
+	synchronizer.EXPECT().HighQC().AnyTimes().Return(consensus.NewQuorumCert(nil, 4, consensus.Hash{1}))
+
+	srv := newClientServer(Config{BatchSize: 1, BatchBytes: batchBytes}, nil)
+	builder := consensus.NewBuilder(1, testutil.GenerateECDSAKey(t))
+	builder.Register(synchronizer, srv, srv.cmdCache)
+	builder.Build()
+	return srv
+}
+
+func TestBatchBytesMixedSizes(t *testing.T) {
+	const (
+		target  = 2048
+		maxData = 300
+		total   = 200
+	)
+	srv := newBatchBytesServer(t, target)
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 1; i <= total; i++ {
+		srv.cmdCache.addCommand(&clientpb.Command{ClientID: 1, SequenceNumber: uint64(i), Data: make([]byte, 1+rnd.Intn(maxData))})
+	}
+
+	proposed := 0
+	for proposed < total {
+		cmd, ok := srv.cmdCache.Get(context.Background())
+		if !ok {
+			t.Fatal("expected a batch")
+		}
+		batch := new(clientpb.Batch)
+		if err := proto.Unmarshal([]byte(cmd), batch); err != nil {
+			t.Fatal(err)
+		}
+		proposed += len(batch.GetCommands())
+
+		size := proposalSize(t, srv.cmdCache, cmd)
+		if size > target {
+			t.Errorf("proposal of %d commands is %d bytes, exceeding the target of %d bytes", len(batch.GetCommands()), size, target)
+		}
+		// unless the queue was drained, the next command did not fit, so the proposal must be near the target.
+		if proposed < total && size < target-maxData-16 {
+			t.Errorf("proposal of %d commands is only %d bytes, far below the target of %d bytes", len(batch.GetCommands()), size, target)
+		}
+	}
+	if proposed != total {
+		t.Errorf("proposed %d commands, want %d", proposed, total)
+	}
+}
+
+func TestBatchBytesOversizedCommand(t *testing.T) {
+	const target = 512
+	srv := newBatchBytesServer(t, target)
+
+	small := &clientpb.Command{ClientID: 1, SequenceNumber: 1, Data: make([]byte, 10)}
+	oversized := &clientpb.Command{ClientID: 1, SequenceNumber: 2, Data: make([]byte, 4*target)}
+	next := &clientpb.Command{ClientID: 1, SequenceNumber: 3, Data: make([]byte, 10)}
+	for _, cmd := range []*clientpb.Command{small, oversized, next} {
+		srv.cmdCache.addCommand(cmd)
+	}
+
+	want := [][]uint64{{1}, {2}, {3}}
+	for _, seqNums := range want {
+		cmd, ok := srv.cmdCache.Get(context.Background())
+		if !ok {
+			t.Fatal("expected a batch")
+		}
+		batch := new(clientpb.Batch)
+		if err := proto.Unmarshal([]byte(cmd), batch); err != nil {
+			t.Fatal(err)
+		}
+		var got []uint64
+		for _, c := range batch.GetCommands() {
+			got = append(got, c.GetSequenceNumber())
+		}
+		if len(got) != len(seqNums) || got[0] != seqNums[0] {
+			t.Errorf("got batch with commands %v, want %v", got, seqNums)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package replica
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/clientpb"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
+)
+
+// receiptDigest returns the hash that is signed by a receipt.
+// The digest is prefixed such that a receipt cannot be mistaken for a vote or a commit certificate.
+func receiptDigest(receipt *clientpb.Receipt) consensus.Hash {
+	var b [24]byte
+	binary.BigEndian.PutUint32(b[:4], receipt.GetClientID())
+	binary.BigEndian.PutUint64(b[4:12], receipt.GetSequenceNumber())
+	binary.BigEndian.PutUint64(b[12:20], receipt.GetView())
+	binary.BigEndian.PutUint32(b[20:], receipt.GetReplicaID())
+	h := sha256.New()
+	_, _ = h.Write([]byte("receipt"))
+	_, _ = h.Write(b[:])
+	_, _ = h.Write(receipt.GetBlockHash())
+	_, _ = h.Write(receipt.GetResultDigest())
+	var digest consensus.Hash
+	h.Sum(digest[:0])
+	return digest
+}
+
+// VerifyReceipt verifies that the receipt is signed by the replica that it names.
+// It does not check that the receipt agrees with the receipts of other replicas.
+func VerifyReceipt(verifier consensus.CryptoImpl, receipt *clientpb.Receipt) bool {
+	if receipt.GetSignature() == nil {
+		return false
+	}
+	sig := hotstuffpb.SignatureFromProto(receipt.GetSignature())
+	if sig == nil || sig.Signer() != hotstuff.ID(receipt.GetReplicaID()) {
+		return false
+	}
+	return verifier.Verify(sig, receiptDigest(receipt))
+}
+
+// receipt signs a receipt for a command that was just executed in the block.
+// The result digest is the hash of all commands executed so far. The caller must hold srv.mut.
+func (srv *clientSrv) receipt(id cmdID, block *consensus.Block) *clientpb.Receipt {
+	hash := block.Hash()
+	receipt := &clientpb.Receipt{
+		ClientID:       id.clientID,
+		SequenceNumber: id.sequenceNum,
+		BlockHash:      hash[:],
+		View:           uint64(block.View()),
+		ResultDigest:   srv.hash.Sum(nil),
+		ReplicaID:      uint32(srv.hs.ID()),
+	}
+	sig, err := srv.hs.Crypto().Sign(receiptDigest(receipt))
+	if err != nil {
+		srv.mods.Logger().Errorf("Failed to sign receipt: %v", err)
+		return nil
+	}
+	receipt.Signature = hotstuffpb.SignatureToProto(sig)
+	return receipt
+}
+
+// blockExecutor passes the committed blocks to the client server, such that it can sign receipts that refer to the blocks.
+type blockExecutor struct {
+	srv *clientSrv
+}
+
+// Exec executes the commands in the block.
+func (e blockExecutor) Exec(block *consensus.Block) error {
+	return e.srv.exec(block.Command(), block)
+}
+
+var _ consensus.FallibleExecutorExt = blockExecutor{}
@@ -3,18 +3,26 @@ package replica
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"net"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/relab/gorums"
 	"github.com/relab/hotstuff"
 	backend "github.com/relab/hotstuff/backend/gorums"
+	"github.com/relab/hotstuff/blockchain"
 	"github.com/relab/hotstuff/config"
 	"github.com/relab/hotstuff/consensus"
 	"github.com/relab/hotstuff/internal/logging"
+	"github.com/relab/hotstuff/internal/proto/clientpb"
+	"github.com/relab/hotstuff/source"
+	"github.com/relab/hotstuff/store"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 )
@@ -39,6 +47,9 @@ type Config struct {
 	RootCAs *x509.CertPool
 	// The number of client commands that should be batched together in a block.
 	BatchSize uint32
+	// The target size in bytes of a proposal. If set, the leader proposes as many queued commands as fit in a proposal
+	// of this size, instead of BatchSize commands. A command that exceeds the target on its own is proposed alone.
+	BatchBytes uint32
 	// Options for the client server.
 	ClientServerOptions []gorums.ServerOption
 	// Options for the replica server.
@@ -47,6 +58,34 @@ type Config struct {
 	ManagerOptions []gorums.ManagerOption
 	//Reputation of the replica.
 	Reputation float64
+	// The public keys of the clients, by client ID. If set, a command is only accepted if it is signed by its client,
+	// such that a faulty leader cannot fabricate commands.
+	ClientKeys map[uint32]*ecdsa.PublicKey
+	// If set, OnAck is called when a command is optimistically acknowledged because its block was certified,
+	// when the command is finally acknowledged, and when an optimistic acknowledgment is retracted
+	// because its block was abandoned. OnAck must not block.
+	OnAck func(ack *clientpb.Ack)
+	// If set, the replica proposes the commands from this source instead of the commands of its clients.
+	// The commands must still be accepted by the other replicas.
+	CommandSource source.Source
+	// The logger of the replica. If not set, the replica logs to standard error.
+	Logger logging.Logger
+	// How long the result of a committed command is kept. A client that retries a command within this window
+	// gets the original result, and the command is not proposed again. If 0, results are not kept.
+	// A command is never executed twice, whether or not its result is kept.
+	ResultRetention time.Duration
+	// If set, the replica signs a receipt for each command it executes, which attests to the block that the command
+	// was committed in and to the state of the replica after the command was executed. Clients can request the receipts
+	// of their commands, such that a quorum of receipts proves that the command was committed and executed.
+	// The receipts are kept as long as the results, so ResultRetention must also be set.
+	SignReceipts bool
+	// If set, Apply is called for each committed command, in commit order, and the error it returns is sent
+	// to the client that issued the command. Apply must be deterministic, such that all replicas agree
+	// on the outcome of every command.
+	Apply func(cmd *clientpb.Command) error
+	// If set, the replica stores its protocol state in files in this directory, and recovers the state
+	// from them when it is started again with the same directory. The directory must exist.
+	StateDir string
 }
 
 // Replica is a participant in the consensus protocol.
@@ -55,6 +94,7 @@ type Replica struct {
 	cfg       *backend.Config
 	hsSrv     *backend.Server
 	hs        *consensus.Modules
+	debugSrv  *http.Server // nil unless the debug server was started
 
 	execHandlers map[cmdID]func(*empty.Empty, error)
 	cancel       context.CancelFunc
@@ -105,13 +145,26 @@ func New(conf Config, builder consensus.Builder) (replica *Replica) {
 	}
 	srv.cfg = backend.NewConfig(conf.ID, creds, managerOpts...)
 
+	logger := conf.Logger
+	if logger == nil {
+		logger = logging.New("hs" + strconv.Itoa(int(conf.ID)))
+	}
 	builder.Register(
 		srv.cfg,                // configuration
 		srv.hsSrv,              // event handling
 		srv.clientSrv,          // executor
 		srv.clientSrv.cmdCache, // acceptor and command queue
-		logging.New("hs"+strconv.Itoa(int(conf.ID))),
+		logger,
 	)
+	if conf.SignReceipts {
+		builder.Register(blockExecutor{srv.clientSrv}) // replaces the executor
+	}
+	if conf.StateDir != "" {
+		builder.Register(store.NewFileStore(conf.StateDir))
+	}
+	if conf.CommandSource != nil {
+		builder.Register(source.NewQueue(conf.CommandSource)) // replaces the command queue of the client server
+	}
 	srv.hs = builder.Build()
 
 	return srv
@@ -123,6 +176,19 @@ func (srv *Replica) StartServers(replicaListen, clientListen net.Listener) {
 	srv.clientSrv.StartOnListener(clientListen)
 }
 
+// StartDebugServer starts an HTTP server that serves debug endpoints for the replica:
+// its block chain is served as a Graphviz DOT graph at /debug/blockchain.dot.
+func (srv *Replica) StartDebugServer(lis net.Listener) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/blockchain.dot", blockchain.NewDOTHandler(srv.hs))
+	srv.debugSrv = &http.Server{Handler: mux}
+	go func() {
+		if err := srv.debugSrv.Serve(lis); err != nil && err != http.ErrServerClosed {
+			srv.hs.Logger().Errorf("Debug server failed: %v", err)
+		}
+	}()
+}
+
 // Connect connects to the other replicas.
 func (srv *Replica) Connect(replicas *config.ReplicaConfig) error {
 	return srv.cfg.Connect(replicas)
@@ -140,11 +206,45 @@ func (srv *Replica) Start() {
 
 // Stop stops the replica and closes connections.
 func (srv *Replica) Stop() {
+	// the requests from the other replicas must be delivered before the event loop stops.
+	srv.hsSrv.Drain()
 	srv.cancel()
 	<-srv.done
 	srv.Close()
 }
 
+// Quiesce stops the replica from proposing and from timing out of its view, while it still takes part in
+// the proposals that are in flight. Quiescing every replica before stopping them avoids sending messages to
+// replicas that have already stopped.
+func (srv *Replica) Quiesce() {
+	srv.hs.Quiesce()
+}
+
+// Modules returns the modules of the replica.
+func (srv *Replica) Modules() *consensus.Modules {
+	return srv.hs
+}
+
+// ForceCommit commits the blocks up to and including the block certified by the checkpoint,
+// see consensus.Consensus.ForceCommit. It is meant for operators that move a replica that is far behind
+// to a checkpoint that they obtained out-of-band from a trusted majority of the replicas.
+// The replica must be running, and ForceCommit returns when the blocks have been executed, or the context is canceled.
+func (srv *Replica) ForceCommit(ctx context.Context, checkpoint consensus.QuorumCert) error {
+	return srv.hs.Consensus().ForceCommit(ctx, checkpoint)
+}
+
+// RestoreSnapshot fetches a snapshot of the committed blocks from the other replicas, and restores it,
+// see consensus.BlockChain.Restore. Unlike ForceCommit, the snapshot need not be trusted,
+// since only the blocks that its QCs prove committed are committed.
+// The replica must be running, and RestoreSnapshot returns when the blocks have been executed, or the context is canceled.
+func (srv *Replica) RestoreSnapshot(ctx context.Context) error {
+	snapshot, ok := srv.cfg.FetchSnapshot(ctx)
+	if !ok {
+		return fmt.Errorf("restore snapshot: no snapshot was received")
+	}
+	return srv.hs.BlockChain().Restore(ctx, snapshot)
+}
+
 // Run runs the replica until the context is cancelled.
 func (srv *Replica) Run(ctx context.Context) {
 	srv.hs.Synchronizer().Start(ctx)
@@ -156,6 +256,9 @@ func (srv *Replica) Close() {
 	srv.clientSrv.Stop()
 	srv.cfg.Close()
 	srv.hsSrv.Stop()
+	if srv.debugSrv != nil {
+		_ = srv.debugSrv.Close()
+	}
 }
 
 // GetHash returns the hash of all executed commands.
@@ -0,0 +1,49 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSPublisher publishes records as JSON to a NATS JetStream subject.
+// The subject must be bound to a stream. Each record uses its block hash as the message ID,
+// such that JetStream discards records that are published again within the duplicate window of the stream.
+type NATSPublisher struct {
+	conn    *nats.Conn
+	js      nats.JetStreamContext
+	subject string
+}
+
+// NewNATSPublisher connects to the NATS server at the given URL, and returns a publisher for the given subject.
+func NewNATSPublisher(url, subject string, opts ...nats.Option) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	return &NATSPublisher{conn: conn, js: js, subject: subject}, nil
+}
+
+// Publish publishes the record, and returns once JetStream has stored it.
+func (p *NATSPublisher) Publish(ctx context.Context, record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+	_, err = p.js.Publish(p.subject, data, nats.MsgId(record.Hash), nats.Context(ctx))
+	return err
+}
+
+// Close closes the connection to the NATS server.
+func (p *NATSPublisher) Close() {
+	p.conn.Close()
+}
+
+var _ Publisher = (*NATSPublisher)(nil)
@@ -0,0 +1,149 @@
+// Package sink publishes committed blocks to a message broker, such that downstream data pipelines can consume them.
+//
+// A Sink is registered with the consensus builder as a consensus.CommitSink.
+// It buffers the committed blocks and publishes them in commit order using a Publisher.
+// Blocks are published at least once: a block that fails to publish is retried until it succeeds,
+// and a block may be published again if the broker received it, but the acknowledgment was lost.
+// Consumers can use the block hash to discard duplicates.
+package sink
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+)
+
+const (
+	minRetryDelay = 10 * time.Millisecond
+	maxRetryDelay = 5 * time.Second
+)
+
+// Record is the published representation of a committed block.
+type Record struct {
+	View       consensus.View `json:"view"`
+	Hash       string         `json:"hash"`   // hex encoded
+	Parent     string         `json:"parent"` // hex encoded
+	Proposer   hotstuff.ID    `json:"proposer"`
+	Command    []byte         `json:"command"`
+	CommitTime time.Time      `json:"commitTime"`
+}
+
+// NewRecord returns the record of a block that was committed at the given time.
+func NewRecord(block *consensus.Block, commitTime time.Time) Record {
+	hash := block.Hash()
+	parent := block.Parent()
+	return Record{
+		View:       block.View(),
+		Hash:       hex.EncodeToString(hash[:]),
+		Parent:     hex.EncodeToString(parent[:]),
+		Proposer:   block.Proposer(),
+		Command:    []byte(block.Command()),
+		CommitTime: commitTime,
+	}
+}
+
+// Publisher publishes records to a message broker.
+type Publisher interface {
+	// Publish publishes the record, and returns once the broker has acknowledged it.
+	// It returns an error if the record may not have been received, in which case it is published again.
+	Publish(ctx context.Context, record Record) error
+}
+
+// Sink publishes committed blocks in commit order.
+// Publishing happens in the background, such that a slow or unavailable broker does not hold back consensus.
+// The blocks are buffered in memory until they have been published, so the buffer grows while the broker is unavailable.
+type Sink struct {
+	mods      *consensus.Modules
+	publisher Publisher
+
+	mut     sync.Mutex
+	pending []Record
+	notify  chan struct{}
+}
+
+// New returns a new sink that publishes committed blocks using the given publisher.
+// Run must be called for the blocks to be published.
+func New(publisher Publisher) *Sink {
+	return &Sink{
+		publisher: publisher,
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (s *Sink) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	s.mods = mods
+}
+
+// Committed buffers the committed block for publishing.
+func (s *Sink) Committed(block *consensus.Block) {
+	s.mut.Lock()
+	s.pending = append(s.pending, NewRecord(block, time.Now()))
+	s.mut.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Pending returns the number of committed blocks that have not yet been published.
+func (s *Sink) Pending() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return len(s.pending)
+}
+
+// Run publishes the buffered blocks until the context is cancelled.
+// A block that fails to publish is retried with exponential backoff, and the blocks after it wait until it succeeds.
+func (s *Sink) Run(ctx context.Context) {
+	delay := minRetryDelay
+	for {
+		s.mut.Lock()
+		var (
+			record Record
+			ok     bool
+		)
+		if len(s.pending) > 0 {
+			record, ok = s.pending[0], true
+		}
+		s.mut.Unlock()
+
+		if !ok {
+			select {
+			case <-s.notify:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		if err := s.publisher.Publish(ctx, record); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.mods.Logger().Warnf("Failed to publish block %.8s, retrying in %v: %v", record.Hash, delay, err)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			if delay *= 2; delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+			continue
+		}
+		delay = minRetryDelay
+
+		s.mut.Lock()
+		s.pending[0] = Record{}
+		s.pending = s.pending[1:]
+		s.mut.Unlock()
+	}
+}
+
+var _ consensus.CommitSink = (*Sink)(nil)
@@ -0,0 +1,81 @@
+package sink
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/internal/testutil"
+)
+
+// mockPublisher records the published records in memory.
+// The publish attempt with the number failAt fails, as if the broker was temporarily unavailable.
+type mockPublisher struct {
+	mut     sync.Mutex
+	records []Record
+	calls   int
+	failAt  int
+}
+
+func (p *mockPublisher) Publish(_ context.Context, record Record) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.calls++
+	if p.calls == p.failAt {
+		return errors.New("broker unavailable")
+	}
+	p.records = append(p.records, record)
+	return nil
+}
+
+func TestSink(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	publisher := &mockPublisher{failAt: 3}
+	sink := New(publisher)
+	builders[0].Register(sink)
+	builders.Build()
+
+	sinkCtx, stopSink := context.WithCancel(context.Background())
+	defer stopSink()
+	go sink.Run(sinkCtx)
+
+	node := network.Node(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil && len(node.Executed()) < 10 {
+			time.Sleep(10 * time.Millisecond)
+		}
+		cancel()
+	}()
+	network.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sink.Pending() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d blocks were not published", sink.Pending())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stopSink()
+
+	executed := node.Executed()
+	publisher.mut.Lock()
+	defer publisher.mut.Unlock()
+	if publisher.calls != len(publisher.records)+1 {
+		t.Errorf("expected one failed publish attempt, got %d", publisher.calls-len(publisher.records))
+	}
+	if len(publisher.records) != len(executed) {
+		t.Fatalf("expected %d published blocks, got %d", len(executed), len(publisher.records))
+	}
+	for i, block := range executed {
+		hash := block.Hash()
+		record := publisher.records[i]
+		if record.Hash != hex.EncodeToString(hash[:]) || record.View != block.View() || string(record.Command) != string(block.Command()) {
+			t.Errorf("record %d: got block %.8s at view %d, want block %.8s at view %d", i, record.Hash, record.View, hex.EncodeToString(hash[:]), block.View())
+		}
+	}
+}
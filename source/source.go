@@ -0,0 +1,211 @@
+// Package source provides pluggable sources of the commands that a replica proposes.
+//
+// A Source only produces commands. The Queue adapts a Source to the consensus.CommandQueue interface,
+// such that each replica in a testbed can draw its commands from a different source, for example its clients,
+// a file of recorded commands, or a generator of synthetic load. Sources that know when commands become available
+// can also implement the Notifier interface, which the Queue passes on to the proposer.
+package source
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// Source provides the commands that a replica proposes.
+type Source interface {
+	// Next returns the next command.
+	// It may wait until a command is available or the context is cancelled.
+	// If no command is available, the 'ok' return value should be false.
+	Next(ctx context.Context) (cmd consensus.Command, ok bool)
+}
+
+// Notifier is an optional interface for sources that can signal that new commands are available,
+// such that a proposer does not need to poll the source.
+type Notifier interface {
+	// Available returns a channel that receives a value when new commands become available.
+	Available() <-chan struct{}
+}
+
+// Queue is a command queue that draws its commands from a Source.
+type Queue struct {
+	src Source
+}
+
+// NewQueue returns a new command queue that draws its commands from the given source.
+func NewQueue(src Source) *Queue {
+	return &Queue{src: src}
+}
+
+// Get returns the next command from the source.
+func (q *Queue) Get(ctx context.Context) (cmd consensus.Command, ok bool) {
+	return q.src.Next(ctx)
+}
+
+// Available returns the channel of the source if it implements Notifier.
+// Otherwise, it returns a nil channel, which never receives a value.
+func (q *Queue) Available() <-chan struct{} {
+	if n, ok := q.src.(Notifier); ok {
+		return n.Available()
+	}
+	return nil
+}
+
+// FromQueue returns a source that draws its commands from a command queue,
+// for example the queue of client commands of a replica, or a workload generator.
+func FromQueue(queue consensus.CommandQueue) Source {
+	return queueSource{queue}
+}
+
+type queueSource struct {
+	queue consensus.CommandQueue
+}
+
+func (s queueSource) Next(ctx context.Context) (cmd consensus.Command, ok bool) {
+	return s.queue.Get(ctx)
+}
+
+// Reader is a source that reads commands from an io.Reader, one command per line.
+// Empty lines are skipped. Once the reader is exhausted, no more commands are available.
+type Reader struct {
+	mut     sync.Mutex
+	scanner *bufio.Scanner
+	closer  io.Closer
+	err     error
+}
+
+// NewReader returns a source that reads commands from the given reader.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{scanner: bufio.NewScanner(r)}
+}
+
+// OpenFile returns a source that reads commands from the file with the given name.
+// The file is closed when all commands have been read, or when Close is called.
+func OpenFile(name string) (*Reader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open command file: %w", err)
+	}
+	r := NewReader(f)
+	r.closer = f
+	return r, nil
+}
+
+// Next returns the next command in the file. It does not wait.
+func (r *Reader) Next(_ context.Context) (cmd consensus.Command, ok bool) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	if r.scanner == nil {
+		return "", false
+	}
+	for r.scanner.Scan() {
+		if line := r.scanner.Text(); line != "" {
+			return consensus.Command(line), true
+		}
+	}
+	r.err = r.scanner.Err()
+	r.close()
+	return "", false
+}
+
+// Err returns the error that stopped the reader, if any.
+func (r *Reader) Err() error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.err
+}
+
+// Close closes the file, if the reader was opened by OpenFile. No more commands are available after Close.
+func (r *Reader) Close() error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.close()
+}
+
+func (r *Reader) close() error {
+	r.scanner = nil
+	if r.closer == nil {
+		return nil
+	}
+	err := r.closer.Close()
+	r.closer = nil
+	return err
+}
+
+// Generator is a source that generates an unbounded sequence of synthetic commands.
+type Generator struct {
+	mut    sync.Mutex
+	seqNum uint64
+	gen    func(seqNum uint64) consensus.Command
+}
+
+// NewGenerator returns a source that calls gen with the sequence numbers 1, 2, 3, ... to generate its commands.
+func NewGenerator(gen func(seqNum uint64) consensus.Command) *Generator {
+	return &Generator{gen: gen}
+}
+
+// Next returns the next generated command. It does not wait.
+func (g *Generator) Next(_ context.Context) (cmd consensus.Command, ok bool) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	g.seqNum++
+	return g.gen(g.seqNum), true
+}
+
+// Channel is a source of commands that are pushed to it, for example by a local RPC server.
+// It signals when commands become available.
+type Channel struct {
+	cmds      chan consensus.Command
+	available chan struct{}
+}
+
+// NewChannel returns a source that buffers up to capacity pushed commands.
+func NewChannel(capacity int) *Channel {
+	return &Channel{
+		cmds:      make(chan consensus.Command, capacity),
+		available: make(chan struct{}, 1),
+	}
+}
+
+// Push adds a command to the source. It waits until there is room for the command, or the context is cancelled.
+func (c *Channel) Push(ctx context.Context, cmd consensus.Command) error {
+	select {
+	case c.cmds <- cmd:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case c.available <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Next returns the next pushed command. It waits until a command is pushed or the context is cancelled.
+func (c *Channel) Next(ctx context.Context) (cmd consensus.Command, ok bool) {
+	select {
+	case cmd := <-c.cmds:
+		return cmd, true
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+// Available returns a channel that receives a value when a command has been pushed.
+func (c *Channel) Available() <-chan struct{} {
+	return c.available
+}
+
+var (
+	_ consensus.CommandQueue = (*Queue)(nil)
+	_ Notifier               = (*Queue)(nil)
+	_ Source                 = (*Reader)(nil)
+	_ Source                 = (*Generator)(nil)
+	_ Source                 = (*Channel)(nil)
+	_ Notifier               = (*Channel)(nil)
+)
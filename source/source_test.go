@@ -0,0 +1,103 @@
+package source_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/source"
+)
+
+func TestReader(t *testing.T) {
+	r := source.NewReader(strings.NewReader("a\n\nb\nc"))
+	for _, want := range []consensus.Command{"a", "b", "c"} {
+		if cmd, ok := r.Next(context.Background()); !ok || cmd != want {
+			t.Errorf("got (%q, %v), want %q", cmd, ok, want)
+		}
+	}
+	if _, ok := r.Next(context.Background()); ok {
+		t.Error("expected no more commands")
+	}
+	if err := r.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestChannel(t *testing.T) {
+	c := source.NewChannel(1)
+	q := source.NewQueue(c)
+	if err := c.Push(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-q.Available():
+	default:
+		t.Fatal("expected the queue to signal that a command is available")
+	}
+	if cmd, ok := q.Get(context.Background()); !ok || cmd != "a" {
+		t.Errorf("got (%q, %v), want \"a\"", cmd, ok)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, ok := q.Get(ctx); ok {
+		t.Error("expected Get to wait until the context is cancelled")
+	}
+}
+
+// TestMixedSources checks that replicas that draw their commands from different sources all get their commands committed.
+func TestMixedSources(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "commands.txt")
+	if err := os.WriteFile(name, []byte("file-1\nfile-2\nfile-3\nfile-4\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := source.OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gen := source.NewGenerator(func(seqNum uint64) consensus.Command {
+		return consensus.Command(fmt.Sprintf("gen-%d", seqNum))
+	})
+
+	network, builders := testutil.CreateNetwork(t, 4)
+	// replicas 1 and 2 replace the command queue of the network, while replicas 3 and 4 keep it.
+	builders[0].Register(source.NewQueue(file))
+	builders[1].Register(source.NewQueue(gen))
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		network.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	for {
+		var fromFile, fromGen bool
+		for _, block := range network.Node(4).Executed() {
+			cmd := string(block.Command())
+			fromFile = fromFile || strings.HasPrefix(cmd, "file-")
+			fromGen = fromGen || strings.HasPrefix(cmd, "gen-")
+		}
+		if fromFile && fromGen {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("commands were not committed from both sources: file %v, generator %v", fromFile, fromGen)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package store implements durable storage of protocol state, such that a replica can recover after a restart.
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
+	"github.com/relab/hotstuff/leaderrotation"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	syncStateFile   = "syncstate"
+	checkpointsFile = "checkpoints"
+	safetyStateFile = "safetystate"
+	stakesFile      = "stakes"
+)
+
+// FileStore stores protocol state in files in a directory.
+// Each file is replaced atomically, such that a crash while saving leaves the previous state intact.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a new FileStore that stores files in the given directory.
+// The directory must exist.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// write atomically replaces the contents of the named file.
+func (fs *FileStore) write(name string, b []byte) error {
+	tmp, err := os.CreateTemp(fs.dir, name+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(fs.dir, name)); err != nil {
+		return err
+	}
+	// the rename is not durable until the directory has been synced.
+	dir, err := os.Open(fs.dir)
+	if err != nil {
+		return err
+	}
+	if err := dir.Sync(); err != nil {
+		dir.Close()
+		return err
+	}
+	return dir.Close()
+}
+
+// SaveSyncState durably stores the state of the view synchronizer.
+func (fs *FileStore) SaveSyncState(state consensus.SyncState) error {
+	si := consensus.NewSyncInfo().WithQC(state.HighQC)
+	if state.HighTC.View() > 0 {
+		si = si.WithTC(state.HighTC)
+	}
+	b, err := proto.Marshal(&hotstuffpb.SyncState{
+		View:     uint64(state.View),
+		SyncInfo: hotstuffpb.SyncInfoToProto(si),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal synchronizer state: %w", err)
+	}
+
+	if err := fs.write(syncStateFile, b); err != nil {
+		return fmt.Errorf("failed to write synchronizer state: %w", err)
+	}
+	return nil
+}
+
+// LoadSyncState returns the stored state of the view synchronizer, if any.
+func (fs *FileStore) LoadSyncState() (state consensus.SyncState, ok bool, err error) {
+	b, err := os.ReadFile(filepath.Join(fs.dir, syncStateFile))
+	if os.IsNotExist(err) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, fmt.Errorf("failed to read synchronizer state: %w", err)
+	}
+	var m hotstuffpb.SyncState
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return state, false, fmt.Errorf("failed to unmarshal synchronizer state: %w", err)
+	}
+	si := hotstuffpb.SyncInfoFromProto(m.GetSyncInfo())
+	state.View = consensus.View(m.GetView())
+	state.HighQC, _ = si.QC()
+	state.HighTC, _ = si.TC()
+	return state, true, nil
+}
+
+// SaveState durably stores the safety state of the consensus protocol.
+func (fs *FileStore) SaveState(state consensus.SafetyState) error {
+	m := &hotstuffpb.SafetyState{
+		LastVote: uint64(state.LastVote),
+		HighQC:   hotstuffpb.QuorumCertToProto(state.HighQC),
+	}
+	if state.Locked != nil {
+		m.Locked = hotstuffpb.BlockToProto(state.Locked)
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal safety state: %w", err)
+	}
+	if err := fs.write(safetyStateFile, b); err != nil {
+		return fmt.Errorf("failed to write safety state: %w", err)
+	}
+	return nil
+}
+
+// LoadState returns the stored safety state of the consensus protocol, if any.
+func (fs *FileStore) LoadState() (state consensus.SafetyState, ok bool, err error) {
+	b, err := os.ReadFile(filepath.Join(fs.dir, safetyStateFile))
+	if os.IsNotExist(err) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, fmt.Errorf("failed to read safety state: %w", err)
+	}
+	var m hotstuffpb.SafetyState
+	if err := proto.Unmarshal(b, &m); err != nil {
+		return state, false, fmt.Errorf("failed to unmarshal safety state: %w", err)
+	}
+	state.LastVote = consensus.View(m.GetLastVote())
+	if m.Locked != nil {
+		state.Locked = hotstuffpb.BlockFromProto(m.GetLocked())
+	}
+	state.HighQC = hotstuffpb.QuorumCertFromProto(m.GetHighQC())
+	return state, true, nil
+}
+
+// SaveCheckpoints durably stores the pinned checkpoints.
+func (fs *FileStore) SaveCheckpoints(checkpoints []consensus.PinnedCheckpoint) error {
+	b := make([]byte, len(checkpoints)*checkpointSize)
+	for i, c := range checkpoints {
+		buf := b[i*checkpointSize:]
+		binary.LittleEndian.PutUint64(buf, uint64(c.View))
+		copy(buf[8:], c.Hash[:])
+	}
+	if err := fs.write(checkpointsFile, b); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	return nil
+}
+
+// LoadCheckpoints returns the stored checkpoints, if any.
+func (fs *FileStore) LoadCheckpoints() ([]consensus.PinnedCheckpoint, error) {
+	b, err := os.ReadFile(filepath.Join(fs.dir, checkpointsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+	if len(b)%checkpointSize != 0 {
+		return nil, fmt.Errorf("failed to read checkpoints: invalid file size %d", len(b))
+	}
+	checkpoints := make([]consensus.PinnedCheckpoint, 0, len(b)/checkpointSize)
+	for ; len(b) > 0; b = b[checkpointSize:] {
+		var c consensus.PinnedCheckpoint
+		c.View = consensus.View(binary.LittleEndian.Uint64(b))
+		copy(c.Hash[:], b[8:checkpointSize])
+		checkpoints = append(checkpoints, c)
+	}
+	return checkpoints, nil
+}
+
+// checkpointSize is the size of a stored checkpoint: the view followed by the block hash.
+const checkpointSize = 8 + len(consensus.Hash{})
+
+// SaveStakes durably stores the stake table of the stake-based leader rotation.
+// The block hash is followed by the number of stakes, the stakes as pairs of replica ID and stake,
+// the number of recent proposers, and their IDs.
+func (fs *FileStore) SaveStakes(state leaderrotation.StakeState) error {
+	b := make([]byte, 0, len(state.Block)+4+len(state.Stakes)*12+4+len(state.Recent)*4)
+	b = append(b, state.Block[:]...)
+	b = appendUint32(b, uint32(len(state.Stakes)))
+	for id, stake := range state.Stakes {
+		b = appendUint32(b, uint32(id))
+		b = appendUint64(b, stake)
+	}
+	b = appendUint32(b, uint32(len(state.Recent)))
+	for _, id := range state.Recent {
+		b = appendUint32(b, uint32(id))
+	}
+	if err := fs.write(stakesFile, b); err != nil {
+		return fmt.Errorf("failed to write stakes: %w", err)
+	}
+	return nil
+}
+
+// LoadStakes returns the stored stake table of the stake-based leader rotation, if any.
+func (fs *FileStore) LoadStakes() (state leaderrotation.StakeState, ok bool, err error) {
+	b, err := os.ReadFile(filepath.Join(fs.dir, stakesFile))
+	if os.IsNotExist(err) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, fmt.Errorf("failed to read stakes: %w", err)
+	}
+	invalid := fmt.Errorf("failed to read stakes: invalid file size %d", len(b))
+	if len(b) < len(state.Block)+4 {
+		return state, false, invalid
+	}
+	copy(state.Block[:], b)
+	b = b[len(state.Block):]
+	n := int(binary.LittleEndian.Uint32(b))
+	b = b[4:]
+	if len(b) < n*12+4 {
+		return state, false, invalid
+	}
+	state.Stakes = make(map[hotstuff.ID]uint64, n)
+	for i := 0; i < n; i++ {
+		state.Stakes[hotstuff.ID(binary.LittleEndian.Uint32(b))] = binary.LittleEndian.Uint64(b[4:])
+		b = b[12:]
+	}
+	n = int(binary.LittleEndian.Uint32(b))
+	b = b[4:]
+	if len(b) != n*4 {
+		return state, false, invalid
+	}
+	state.Recent = make([]hotstuff.ID, n)
+	for i := range state.Recent {
+		state.Recent[i] = hotstuff.ID(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return state, true, nil
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+var (
+	_ consensus.SyncStateStore  = (*FileStore)(nil)
+	_ consensus.CheckpointStore = (*FileStore)(nil)
+	_ consensus.StateStore      = (*FileStore)(nil)
+	_ leaderrotation.StakeStore = (*FileStore)(nil)
+)
@@ -0,0 +1,198 @@
+package store_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
+	"github.com/relab/hotstuff/store"
+)
+
+// storedFile returns the path of the only file in the directory, which is the file that was saved last.
+func storedFile(t *testing.T, dir string) string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected a single file in the store, got %d", len(entries))
+	}
+	return filepath.Join(dir, entries[0].Name())
+}
+
+// truncate truncates the only file in the directory to the given length.
+func truncate(t *testing.T, dir string, length int) {
+	t.Helper()
+	if err := os.Truncate(storedFile(t, dir), int64(length)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fileSize returns the size of the only file in the directory.
+func fileSize(t *testing.T, dir string) int {
+	t.Helper()
+	info, err := os.Stat(storedFile(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(info.Size())
+}
+
+// createCerts returns a block along with a QC for it, and a TC for view 2.
+func createCerts(t *testing.T) (*consensus.Block, consensus.QuorumCert, consensus.TimeoutCert) {
+	t.Helper()
+	_, builders := testutil.CreateNetwork(t, 4)
+	signers := builders.Build().Signers()
+	genesis := consensus.GetGenesis()
+	block := consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "foo", 1, 1)
+	return block, testutil.CreateQC(t, block, signers), testutil.CreateTC(t, 2, signers)
+}
+
+func TestSyncState(t *testing.T) {
+	dir := t.TempDir()
+	fs := store.NewFileStore(dir)
+	if _, ok, err := fs.LoadSyncState(); ok || err != nil {
+		t.Fatalf("expected no synchronizer state before it is saved, got ok: %v, err: %v", ok, err)
+	}
+
+	_, qc, tc := createCerts(t)
+	want := consensus.SyncState{View: 3, HighQC: qc, HighTC: tc}
+	if err := fs.SaveSyncState(want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := fs.LoadSyncState()
+	if !ok || err != nil {
+		t.Fatalf("failed to load the synchronizer state: ok: %v, err: %v", ok, err)
+	}
+	if got.View != want.View || !got.HighQC.Equals(want.HighQC) ||
+		got.HighTC.View() != want.HighTC.View() || !bytes.Equal(got.HighTC.ToBytes(), want.HighTC.ToBytes()) {
+		t.Errorf("got synchronizer state %v, want %v", got, want)
+	}
+
+	truncate(t, dir, fileSize(t, dir)-1)
+	if _, ok, err := fs.LoadSyncState(); ok || err == nil {
+		t.Errorf("expected a truncated synchronizer state to be rejected, got ok: %v, err: %v", ok, err)
+	}
+}
+
+func TestSafetyState(t *testing.T) {
+	dir := t.TempDir()
+	fs := store.NewFileStore(dir)
+	if _, ok, err := fs.LoadState(); ok || err != nil {
+		t.Fatalf("expected no safety state before it is saved, got ok: %v, err: %v", ok, err)
+	}
+
+	block, qc, _ := createCerts(t)
+	for _, want := range []consensus.SafetyState{
+		{LastVote: 2, HighQC: qc},
+		{LastVote: 2, HighQC: qc, Locked: block},
+	} {
+		if err := fs.SaveState(want); err != nil {
+			t.Fatal(err)
+		}
+		got, ok, err := fs.LoadState()
+		if !ok || err != nil {
+			t.Fatalf("failed to load the safety state: ok: %v, err: %v", ok, err)
+		}
+		if got.LastVote != want.LastVote || !got.HighQC.Equals(want.HighQC) {
+			t.Errorf("got safety state %v, want %v", got, want)
+		}
+		if (got.Locked == nil) != (want.Locked == nil) || (got.Locked != nil && got.Locked.Hash() != want.Locked.Hash()) {
+			t.Errorf("got locked block %v, want %v", got.Locked, want.Locked)
+		}
+	}
+
+	truncate(t, dir, fileSize(t, dir)-1)
+	if _, ok, err := fs.LoadState(); ok || err == nil {
+		t.Errorf("expected a truncated safety state to be rejected, got ok: %v, err: %v", ok, err)
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	dir := t.TempDir()
+	fs := store.NewFileStore(dir)
+	if checkpoints, err := fs.LoadCheckpoints(); len(checkpoints) > 0 || err != nil {
+		t.Fatalf("expected no checkpoints before they are saved, got %v, err: %v", checkpoints, err)
+	}
+
+	want := []consensus.PinnedCheckpoint{
+		{View: 10, Hash: consensus.Hash{1}},
+		{View: 20, Hash: consensus.Hash{2}},
+	}
+	if err := fs.SaveCheckpoints(want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := fs.LoadCheckpoints()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got checkpoints %v, want %v", got, want)
+	}
+
+	truncate(t, dir, fileSize(t, dir)-1)
+	if _, err := fs.LoadCheckpoints(); err == nil {
+		t.Error("expected truncated checkpoints to be rejected")
+	}
+}
+
+func TestStakes(t *testing.T) {
+	dir := t.TempDir()
+	fs := store.NewFileStore(dir)
+	if _, ok, err := fs.LoadStakes(); ok || err != nil {
+		t.Fatalf("expected no stakes before they are saved, got ok: %v, err: %v", ok, err)
+	}
+
+	want := leaderrotation.StakeState{
+		Block:  consensus.Hash{1},
+		Stakes: map[hotstuff.ID]uint64{1: 10, 2: 20, 3: 30},
+		Recent: []hotstuff.ID{2, 1},
+	}
+	save := func() {
+		t.Helper()
+		if err := fs.SaveStakes(want); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save()
+	got, ok, err := fs.LoadStakes()
+	if !ok || err != nil {
+		t.Fatalf("failed to load the stakes: ok: %v, err: %v", ok, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got stakes %v, want %v", got, want)
+	}
+
+	// every truncation cuts either the block hash, a count, a stake or a recent proposer.
+	size := fileSize(t, dir)
+	for length := 0; length < size; length++ {
+		save()
+		truncate(t, dir, length)
+		if _, ok, err := fs.LoadStakes(); ok || err == nil {
+			t.Errorf("expected stakes truncated to %d of %d bytes to be rejected, got ok: %v, err: %v", length, size, ok, err)
+		}
+	}
+
+	// trailing bytes do not match the number of recent proposers.
+	save()
+	f, err := os.OpenFile(storedFile(t, dir), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte{0, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := fs.LoadStakes(); ok || err == nil {
+		t.Errorf("expected stakes with trailing bytes to be rejected, got ok: %v, err: %v", ok, err)
+	}
+}
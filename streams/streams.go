@@ -0,0 +1,165 @@
+// Package streams allows a single consensus log to carry several independent command streams,
+// for example one for each application in a multi-tenant deployment.
+//
+// Each stream has a label, a command queue, and an executor. The Mux combines the commands of all streams
+// that are available when the leader proposes into a single block, labeling each command with its stream.
+// When a block is executed, the Mux dispatches each command to the executor of its stream.
+// Because all streams share the same chain, the commands of each stream are executed in the order they were committed.
+package streams
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/proto/hotstuffpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Stream is a labeled command stream.
+type Stream struct {
+	Queue    consensus.CommandQueue // The commands to propose in the stream.
+	Acceptor consensus.Acceptor     // Decides whether to accept the commands of the stream. If nil, all commands are accepted.
+	Executor consensus.Executor     // Executes the committed commands of the stream.
+}
+
+// Mux is the command queue, acceptor, and executor of a replica that proposes several command streams.
+type Mux struct {
+	mods    *consensus.Modules
+	labels  []string // the labels of the streams, in the order that their commands are included in a block
+	streams map[string]Stream
+}
+
+// NewMux returns a new Mux for the given streams, indexed by their labels.
+func NewMux(streams map[string]Stream) *Mux {
+	m := &Mux{streams: streams}
+	for label := range streams {
+		m.labels = append(m.labels, label)
+	}
+	sort.Strings(m.labels)
+	return m
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (m *Mux) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	m.mods = mods
+}
+
+// Get returns a command containing the next command of each stream that has one.
+// It waits until at least one stream has a command, or the context is cancelled.
+func (m *Mux) Get(ctx context.Context) (cmd consensus.Command, ok bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		label string
+		cmd   consensus.Command
+	}
+	var (
+		wg      sync.WaitGroup
+		results = make(chan result, len(m.labels))
+	)
+	for _, label := range m.labels {
+		wg.Add(1)
+		go func(label string) {
+			defer wg.Done()
+			if cmd, ok := m.streams[label].Queue.Get(ctx); ok {
+				results <- result{label, cmd}
+			}
+		}(label)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// once a command is available, the other queues are cancelled,
+	// but any commands that they still return are included as well.
+	cmds := make(map[string]consensus.Command)
+	for r := range results {
+		cmds[r.label] = r.cmd
+		cancel()
+	}
+	if len(cmds) == 0 {
+		return "", false
+	}
+
+	batch := new(hotstuffpb.StreamBatch)
+	for _, label := range m.labels {
+		if cmd, ok := cmds[label]; ok {
+			batch.Streams = append(batch.Streams, &hotstuffpb.StreamCommand{Label: label, Command: []byte(cmd)})
+		}
+	}
+	b, err := proto.Marshal(batch)
+	if err != nil {
+		m.mods.Logger().Errorf("Failed to marshal stream batch: %v", err)
+		return "", false
+	}
+	return consensus.Command(b), true
+}
+
+// Accept returns true if the command only contains commands of known streams,
+// and the acceptor of each stream accepts its command.
+func (m *Mux) Accept(cmd consensus.Command) bool {
+	batch, ok := m.unmarshal(cmd)
+	if !ok {
+		return false
+	}
+	for _, sc := range batch.GetStreams() {
+		stream, ok := m.streams[sc.GetLabel()]
+		if !ok {
+			m.mods.Logger().Infof("Accept: unknown stream %q", sc.GetLabel())
+			return false
+		}
+		if stream.Acceptor != nil && !stream.Acceptor.Accept(consensus.Command(sc.GetCommand())) {
+			return false
+		}
+	}
+	return true
+}
+
+// Proposed tells the acceptor of each stream that its command was proposed.
+func (m *Mux) Proposed(cmd consensus.Command) {
+	batch, ok := m.unmarshal(cmd)
+	if !ok {
+		return
+	}
+	for _, sc := range batch.GetStreams() {
+		if stream, ok := m.streams[sc.GetLabel()]; ok && stream.Acceptor != nil {
+			stream.Acceptor.Proposed(consensus.Command(sc.GetCommand()))
+		}
+	}
+}
+
+// Exec dispatches the command of each stream to the executor of the stream.
+func (m *Mux) Exec(cmd consensus.Command) {
+	batch, ok := m.unmarshal(cmd)
+	if !ok {
+		return
+	}
+	for _, sc := range batch.GetStreams() {
+		stream, ok := m.streams[sc.GetLabel()]
+		if !ok {
+			m.mods.Logger().Errorf("Exec: unknown stream %q", sc.GetLabel())
+			continue
+		}
+		stream.Executor.Exec(consensus.Command(sc.GetCommand()))
+	}
+}
+
+func (m *Mux) unmarshal(cmd consensus.Command) (*hotstuffpb.StreamBatch, bool) {
+	batch := new(hotstuffpb.StreamBatch)
+	if err := proto.Unmarshal([]byte(cmd), batch); err != nil {
+		m.mods.Logger().Errorf("Failed to unmarshal stream batch: %v", err)
+		return nil, false
+	}
+	return batch, true
+}
+
+var (
+	_ consensus.CommandQueue = (*Mux)(nil)
+	_ consensus.Acceptor     = (*Mux)(nil)
+	_ consensus.Executor     = (*Mux)(nil)
+)
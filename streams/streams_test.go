@@ -0,0 +1,141 @@
+package streams_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/consensus"
+	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/streams"
+)
+
+// counterQueue is a command queue that is shared by all replicas, and returns numbered commands.
+// If sparse is set, only every other call returns a command, and the other calls wait for the context to be cancelled.
+type counterQueue struct {
+	mut    sync.Mutex
+	label  string
+	sparse bool
+	calls  int
+	next   int
+}
+
+func (q *counterQueue) Get(ctx context.Context) (consensus.Command, bool) {
+	q.mut.Lock()
+	q.calls++
+	if q.sparse && q.calls%2 == 0 {
+		q.mut.Unlock()
+		<-ctx.Done()
+		return "", false
+	}
+	q.next++
+	cmd := consensus.Command(fmt.Sprintf("%s:%d", q.label, q.next))
+	q.mut.Unlock()
+	return cmd, true
+}
+
+// recorder records the commands executed by a replica, both for each stream and in total.
+type recorder struct {
+	mut     sync.Mutex
+	all     []consensus.Command
+	streams map[string][]consensus.Command
+}
+
+func (r *recorder) executor(label string) consensus.Executor {
+	return executorFunc(func(cmd consensus.Command) {
+		r.mut.Lock()
+		defer r.mut.Unlock()
+		r.all = append(r.all, cmd)
+		r.streams[label] = append(r.streams[label], cmd)
+	})
+}
+
+func (r *recorder) executed(label string) []consensus.Command {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return append([]consensus.Command(nil), r.streams[label]...)
+}
+
+type executorFunc func(cmd consensus.Command)
+
+func (f executorFunc) Exec(cmd consensus.Command) { f(cmd) }
+
+func TestStreams(t *testing.T) {
+	const n = 4
+	network, builders := testutil.CreateNetwork(t, n)
+	queues := map[string]*counterQueue{
+		"a": {label: "a"},
+		"b": {label: "b", sparse: true},
+	}
+	recorders := make([]*recorder, n)
+	for i, builder := range builders {
+		recorders[i] = &recorder{streams: make(map[string][]consensus.Command)}
+		ss := make(map[string]streams.Stream)
+		for label, queue := range queues {
+			ss[label] = streams.Stream{Queue: queue, Executor: recorders[i].executor(label)}
+		}
+		builder.Register(streams.NewMux(ss))
+	}
+	builders.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() {
+		for ctx.Err() == nil {
+			done := true
+			for _, r := range recorders {
+				if len(r.executed("a")) < 10 || len(r.executed("b")) < 3 {
+					done = false
+				}
+			}
+			if done {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	for i, r := range recorders {
+		for label := range queues {
+			executed := r.executed(label)
+			if len(executed) == 0 {
+				t.Fatalf("replica %d did not execute any commands of stream %s", i+1, label)
+			}
+			last := 0
+			for _, cmd := range executed {
+				parts := strings.SplitN(string(cmd), ":", 2)
+				if parts[0] != label {
+					t.Errorf("replica %d: executor of stream %s received command %q", i+1, label, cmd)
+					continue
+				}
+				seq, err := strconv.Atoi(parts[1])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if seq <= last {
+					t.Errorf("replica %d: stream %s executed command %d after command %d", i+1, label, seq, last)
+				}
+				last = seq
+			}
+		}
+	}
+
+	// the streams are serialized by a single chain, so all replicas execute the commands of both streams in the same order.
+	reference := recorders[0].all
+	for i, r := range recorders[1:] {
+		r.mut.Lock()
+		for j := 0; j < len(r.all) && j < len(reference); j++ {
+			if r.all[j] != reference[j] {
+				t.Errorf("replica %d executed %q at position %d, replica 1 executed %q", i+2, r.all[j], j, reference[j])
+				break
+			}
+		}
+		r.mut.Unlock()
+	}
+}
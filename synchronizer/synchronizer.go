@@ -3,6 +3,7 @@ package synchronizer
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/relab/hotstuff"
@@ -25,8 +26,10 @@ type Synchronizer struct {
 
 	duration ViewDuration
 	timer    *time.Timer
+	params   consensus.Parameters // the protocol parameters in effect in the current view
 
 	viewCtx   context.Context // a context that is cancelled at the end of the current view
+	ctxMut    sync.Mutex      // protects cancelCtx, which is also called by the view timer
 	cancelCtx context.CancelFunc
 
 	//test
@@ -35,6 +38,8 @@ type Synchronizer struct {
 
 	// map of collected timeout messages per view
 	timeouts map[consensus.View]map[hotstuff.ID]consensus.TimeoutMsg
+
+	relinquishing bool // if true, the replica steps down instead of proposing the next time it leads a view
 }
 
 // InitConsensusModule gives the module a reference to the Modules object.
@@ -55,6 +60,15 @@ func (s *Synchronizer) InitConsensusModule(mods *consensus.Modules, opts *consen
 		s.OnRemoteTimeout(timeoutMsg)
 	})
 
+	s.mods.EventLoop().RegisterHandler(consensus.RelinquishMsg{}, func(event interface{}) {
+		relinquishMsg := event.(consensus.RelinquishMsg)
+		if relinquishMsg.ID == s.mods.ID() {
+			s.relinquishing = true
+			return
+		}
+		s.OnRelinquish(relinquishMsg)
+	})
+
 	var err error
 	s.highQC, err = s.mods.Crypto().CreateQuorumCert(consensus.GetGenesis(), []consensus.PartialCert{})
 	if err != nil {
@@ -87,21 +101,98 @@ func New(viewDuration ViewDuration) consensus.Synchronizer {
 }
 
 // Start starts the synchronizer with the given context.
+// If a SyncStateStore is registered, the synchronizer resumes from the stored view,
+// and lets the leader of that view know that it has rejoined.
 func (s *Synchronizer) Start(ctx context.Context) {
-	s.timer = time.AfterFunc(s.duration.Duration(), func() {
+	restored := false
+	if store := s.mods.SyncStateStore(); store != nil {
+		restored = s.restore(store)
+	}
+
+	if retry := s.mods.Options().LeaderRetryTimeout(); retry > 0 && retry >= s.duration.Duration() {
+		s.mods.Logger().Warnf("The leader retry timeout (%v) is not shorter than the view timeout (%v), "+
+			"so leaders give up on their proposals before they can resend them", retry, s.duration.Duration())
+	}
+
+	s.timer = time.AfterFunc(s.viewDuration(), func() {
 		// The event loop will execute onLocalTimeout for us.
+		s.ctxMut.Lock()
 		s.cancelCtx()
+		s.ctxMut.Unlock()
 		s.mods.EventLoop().AddEvent(s.onLocalTimeout)
 	})
+	if s.mods.Options().PacemakerMode() == consensus.MessageDrivenPacemaker {
+		s.timer.Stop()
+	}
 
 	go func() {
 		<-ctx.Done()
 		s.timer.Stop()
 	}()
 
+	if s.currentView == 1 && !restored && s.mods.Options().StartupBarrierTimeout() > 0 {
+		// the view timer starts once a quorum of replicas are ready, or when the replica advances to a later view.
+		s.timer.Stop()
+		s.mods.AwaitStartup(s.startFirstView)
+		return
+	}
+
+	leader := s.mods.LeaderRotation().GetLeader(s.currentView)
 	// start the initial proposal
-	if s.currentView == 1 && s.mods.LeaderRotation().GetLeader(s.currentView) == s.mods.ID() {
+	if (s.currentView == 1 || restored) && leader == s.mods.ID() {
 		s.mods.Consensus().Propose(s.SyncInfo())
+	} else if restored {
+		if replica, ok := s.mods.Configuration().Replica(leader); ok {
+			replica.NewView(s.SyncInfo())
+		}
+	}
+}
+
+// startFirstView starts the view timer and makes the leader propose, after the startup barrier has passed.
+// It does nothing if the replica has already advanced past the first view.
+func (s *Synchronizer) startFirstView() {
+	if s.currentView != 1 {
+		return
+	}
+	if s.mods.Options().PacemakerMode() != consensus.MessageDrivenPacemaker {
+		s.timer.Reset(s.viewDuration())
+	}
+	if s.mods.LeaderRotation().GetLeader(s.currentView) == s.mods.ID() {
+		s.mods.Consensus().Propose(s.SyncInfo())
+	}
+}
+
+// restore loads the synchronizer state from the store, and returns true if the state was restored.
+func (s *Synchronizer) restore(store consensus.SyncStateStore) bool {
+	state, ok, err := store.LoadSyncState()
+	if err != nil {
+		s.mods.Logger().Warnf("Failed to load synchronizer state: %v", err)
+		return false
+	}
+	if !ok || state.View <= s.currentView {
+		return false
+	}
+	s.currentView = state.View
+	if state.HighTC.View() > s.highTC.View() && s.mods.Crypto().VerifyTimeoutCert(state.HighTC) {
+		s.highTC = state.HighTC
+	}
+	// the block of the highQC is fetched from the other replicas if it is not stored locally.
+	s.UpdateHighQC(state.HighQC)
+	// the replica may have voted in any of the views before the restored view.
+	s.mods.Consensus().StopVoting(s.currentView - 1)
+	s.mods.Logger().Infof("Restored synchronizer state at view %d", s.currentView)
+	return true
+}
+
+// save stores the synchronizer state, if a SyncStateStore is registered.
+func (s *Synchronizer) save() {
+	store := s.mods.SyncStateStore()
+	if store == nil {
+		return
+	}
+	err := store.SaveSyncState(consensus.SyncState{View: s.currentView, HighQC: s.highQC, HighTC: s.highTC})
+	if err != nil {
+		s.mods.Logger().Warnf("Failed to save synchronizer state: %v", err)
 	}
 }
 
@@ -149,6 +240,19 @@ func (s *Synchronizer) SyncInfo() consensus.SyncInfo {
 }
 
 func (s *Synchronizer) onLocalTimeout() {
+	if s.mods.Quiescing() {
+		// the timer is not reset, so the replica stays in its view until it is stopped.
+		s.mods.Logger().Debug("OnLocalTimeout: replica is quiescing")
+		return
+	}
+	if s.mods.Options().PacemakerMode() == consensus.TimeoutDrivenPacemaker {
+		// the view ends when its timer expires, so no timeout certificate is needed.
+		s.mods.Consensus().StopVoting(s.currentView)
+		s.enterView(s.currentView+1, true)
+		s.startView(s.SyncInfo())
+		return
+	}
+
 	defer func() {
 		// Reset the timer and ctx here so that we can get a new timeout in the same view.
 		// I think this is necessary to ensure that we can keep sending the same timeout message
@@ -158,7 +262,7 @@ func (s *Synchronizer) onLocalTimeout() {
 		if s.viewCtx.Err() != nil {
 			s.newCtx()
 		}
-		s.timer.Reset(s.duration.Duration())
+		s.resetTimer()
 	}()
 
 	if s.lastTimeout != nil && s.lastTimeout.View == s.currentView {
@@ -266,6 +370,62 @@ func (s *Synchronizer) OnNewView(newView consensus.NewViewMsg) {
 	s.AdvanceView(newView.SyncInfo)
 }
 
+// OnRelinquish handles a relinquish message from the leader of a view that steps down.
+// If the certificates are valid and the sender leads the relinquished view, the replica skips the view,
+// and the next leader proposes immediately.
+func (s *Synchronizer) OnRelinquish(msg consensus.RelinquishMsg) {
+	view := msg.View()
+	if msg.ID != s.mods.LeaderRotation().GetLeader(view) {
+		s.mods.Logger().Infof("OnRelinquish: replica %d is not the leader of view %d", msg.ID, view)
+		return
+	}
+	qc, ok := msg.SyncInfo.QC()
+	if !ok || !s.mods.Crypto().VerifyQuorumCert(qc) {
+		s.mods.Logger().Info("OnRelinquish: Quorum Certificate could not be verified!")
+		return
+	}
+	if tc, ok := msg.SyncInfo.TC(); ok && !s.mods.Crypto().VerifyTimeoutCert(tc) {
+		s.mods.Logger().Info("OnRelinquish: Timeout Certificate could not be verified!")
+		return
+	}
+	// enter the relinquished view first, if the replica is behind.
+	s.AdvanceView(msg.SyncInfo)
+	if view != s.currentView {
+		return
+	}
+	s.mods.Logger().Debugf("OnRelinquish: replica %d relinquished view %d", msg.ID, view)
+	s.skipView()
+}
+
+// relinquish tells the other replicas that this replica steps down as the leader of the current view.
+// It returns false if the configuration cannot send the message, in which case the replica should propose.
+func (s *Synchronizer) relinquish() bool {
+	relinquisher, ok := s.mods.Configuration().(consensus.Relinquisher)
+	if !ok {
+		s.mods.Logger().Warn("Relinquish: the configuration cannot send relinquish messages")
+		return false
+	}
+	msg := consensus.RelinquishMsg{ID: s.mods.ID(), SyncInfo: s.SyncInfo()}
+	if msg.View() != s.currentView {
+		// the view was entered because the previous leader stepped down, so there is no certificate
+		// that the other replicas could verify the view with.
+		s.mods.Logger().Infof("Relinquish: no certificate for view %d, proposing instead", s.currentView)
+		return false
+	}
+	s.relinquishing = false
+	s.mods.Logger().Infof("Relinquishing view %d", s.currentView)
+	relinquisher.Relinquish(msg)
+	s.skipView()
+	return true
+}
+
+// skipView moves on to the next view without a new certificate, since the leader of the current view stepped down.
+func (s *Synchronizer) skipView() {
+	s.mods.Consensus().StopVoting(s.currentView)
+	s.enterView(s.currentView+1, false)
+	s.startView(s.SyncInfo())
+}
+
 // AdvanceView attempts to advance to the next view using the given QC.
 // qc must be either a regular quorum certificate, or a timeout certificate.
 func (s *Synchronizer) AdvanceView(syncInfo consensus.SyncInfo) {
@@ -288,38 +448,102 @@ func (s *Synchronizer) AdvanceView(syncInfo consensus.SyncInfo) {
 		}
 		s.UpdateHighQC(qc)
 		v = qc.View()
-		s.duration.ViewSucceeded()
 	}
 
 	if v < s.currentView {
 		return
 	}
+	if !timeout {
+		// a QC for an older view does not say how long the current view took.
+		s.duration.ViewSucceeded()
+	}
 
+	if s.mods.Options().PacemakerMode() == consensus.TimeoutDrivenPacemaker {
+		if v > s.currentView {
+			// the replica has fallen behind, and the leader of the certified view has already proposed.
+			s.enterView(v, timeout)
+		} else if leader := s.mods.LeaderRotation().GetLeader(v + 1); leader != s.mods.ID() {
+			// the next leader needs the certificate when the view ends.
+			if replica, ok := s.mods.Configuration().Replica(leader); ok {
+				replica.NewView(syncInfo)
+			}
+		}
+		return
+	}
+
+	s.enterView(v+1, timeout)
+	s.startView(syncInfo)
+}
+
+// viewDuration returns the duration of the current view.
+// A committed parameter change overrides the duration that is given by the ViewDuration.
+func (s *Synchronizer) viewDuration() time.Duration {
+	if timeout := s.params.ViewTimeout; timeout > 0 {
+		return timeout
+	}
+	return s.duration.Duration()
+}
+
+// enterView moves the synchronizer to the given view, and restarts the view timer.
+func (s *Synchronizer) enterView(view consensus.View, timeout bool) {
 	s.timer.Stop()
 
-	s.currentView = v + 1
+	s.currentView = view
 	s.lastTimeout = nil
 	s.duration.ViewStarted()
+	s.save()
+
+	if params := s.mods.Parameters(view); params != s.params {
+		s.params = params
+		s.mods.Logger().Infof("Parameters changed in view %d: %+v", view, params)
+		s.mods.EmitEvent(ParametersChangedEvent{View: view, Parameters: params})
+	}
 
 	// cancel the old view context and set up the next one
 	s.newCtx()
 
-	s.timer.Reset(s.duration.Duration())
+	s.resetTimer()
 
-	s.mods.MetricsEventLoop().AddEvent(ViewChangeEvent{View: s.currentView, Timeout: timeout})
+	s.mods.EmitEvent(ViewChangeEvent{View: s.currentView, Timeout: timeout})
+}
 
+// startView proposes if the replica is the leader of the current view,
+// and otherwise sends the sync info to the leader.
+func (s *Synchronizer) startView(syncInfo consensus.SyncInfo) {
 	leader := s.mods.LeaderRotation().GetLeader(s.currentView)
 
 	if leader == s.mods.ID() {
+		if s.relinquishing && s.relinquish() {
+			return
+		}
 		s.mods.Consensus().Propose(syncInfo)
 	} else if replica, ok := s.mods.Configuration().Replica(leader); ok {
+		if s.mods.Options().ShouldProposeSpeculatively() {
+			// the leader holds back its proposal until it knows the highQCs of a quorum.
+			syncInfo = syncInfo.WithQC(s.highQC)
+		}
 		replica.NewView(syncInfo)
 	}
 }
 
+// resetTimer restarts the view timer, unless the synchronizer is message-driven.
+func (s *Synchronizer) resetTimer() {
+	if s.mods.Options().PacemakerMode() == consensus.MessageDrivenPacemaker {
+		return
+	}
+	s.timer.Reset(s.viewDuration())
+}
+
 // UpdateHighQC updates HighQC if the given qc is higher than the old HighQC.
 func (s *Synchronizer) UpdateHighQC(qc consensus.QuorumCert) {
 	s.mods.Logger().Debugf("updateHighQC: %v", qc)
+
+	// the view is signed, so it cannot be changed without invalidating the QC,
+	// and QCs that are not higher can be discarded before they are verified.
+	if qc.View() <= s.highQC.View() {
+		return
+	}
+
 	if !s.mods.Crypto().VerifyQuorumCert(qc) {
 		s.mods.Logger().Info("updateHighQC: QC could not be verified!")
 		return
@@ -330,22 +554,59 @@ func (s *Synchronizer) UpdateHighQC(qc consensus.QuorumCert) {
 		s.mods.Logger().Info("updateHighQC: Could not find block referenced by new QC!")
 		return
 	}
+	if newBlock.View() != qc.View() {
+		s.mods.Logger().Info("updateHighQC: QC view does not match the view of its block!")
+		return
+	}
 
-	oldBlock, ok := s.mods.BlockChain().Get(s.highQC.BlockHash())
-	if !ok {
-		s.mods.Logger().Panic("Block from the old highQC missing from chain")
+	// the highQC may be lower than the locked and committed blocks if it was lost, e.g. when the replica restarted.
+	// a QC for a block older than either of them must not replace it, as that would move the highQC backward.
+	// the locked block itself is accepted, since it is the block of the highQC under the two-chain commit rule.
+	if committed := s.mods.Consensus().CommittedBlock(); newBlock.View() < committed.View() {
+		s.mods.Logger().Infof("updateHighQC: QC for view %d is older than the committed block", qc.View())
+		return
+	}
+	if locked := s.mods.Consensus().LockedBlock(); locked != nil && newBlock.View() < locked.View() {
+		s.mods.Logger().Infof("updateHighQC: QC for view %d is older than the locked block", qc.View())
+		return
+	}
+
+	if s.mods.Options().ShouldVerifyQCChain() && !s.verifyQCChain(newBlock, s.highQC.BlockHash()) {
+		s.mods.Logger().Info("updateHighQC: QC chain could not be verified!")
+		return
 	}
+	s.mods.Logger().Debug("HighQC updated")
+	s.highQC = qc
+	s.leafBlock = newBlock
+}
 
-	if newBlock.View() > oldBlock.View() {
-		s.mods.Logger().Debug("HighQC updated")
-		s.highQC = qc
-		s.leafBlock = newBlock
+// verifyQCChain verifies the QCs of the blocks from the given block down to a trusted block.
+// The trusted blocks are the block of the old highQC, which has already been verified, and the committed block.
+func (s *Synchronizer) verifyQCChain(block *consensus.Block, oldHash consensus.Hash) bool {
+	committed := s.mods.Consensus().CommittedBlock()
+	for block.View() > committed.View() {
+		if block.Hash() == oldHash {
+			return true
+		}
+		if !s.mods.Crypto().VerifyQuorumCert(block.QuorumCert()) {
+			s.mods.Logger().Infof("verifyQCChain: invalid QC in block %.8s", block.Hash())
+			return false
+		}
+		parent, ok := s.mods.BlockChain().Get(block.Parent())
+		if !ok {
+			s.mods.Logger().Infof("verifyQCChain: could not find block %.8s", block.Parent())
+			return false
+		}
+		block = parent
 	}
+	return block.Hash() == committed.Hash()
 }
 
 func (s *Synchronizer) newCtx() {
+	s.ctxMut.Lock()
+	defer s.ctxMut.Unlock()
 	s.cancelCtx()
-	s.viewCtx, s.cancelCtx = context.WithTimeout(context.Background(), s.duration.Duration())
+	s.viewCtx, s.cancelCtx = context.WithTimeout(context.Background(), s.viewDuration())
 }
 
 var _ consensus.Synchronizer = (*Synchronizer)(nil)
@@ -355,3 +616,10 @@ type ViewChangeEvent struct {
 	View    consensus.View
 	Timeout bool
 }
+
+// ParametersChangedEvent is sent on the metrics event loop when the synchronizer enters a view in which
+// different protocol parameters are in effect than in the previous view, see consensus.ParameterChange.
+type ParametersChangedEvent struct {
+	View       consensus.View
+	Parameters consensus.Parameters
+}
@@ -3,12 +3,20 @@ package synchronizer_test
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/relab/hotstuff"
+	"github.com/relab/hotstuff/blockchain"
 	"github.com/relab/hotstuff/consensus"
 	"github.com/relab/hotstuff/internal/mocks"
 	"github.com/relab/hotstuff/internal/testutil"
+	"github.com/relab/hotstuff/leaderrotation"
+	"github.com/relab/hotstuff/store"
 	. "github.com/relab/hotstuff/synchronizer"
 )
 
@@ -18,13 +26,14 @@ func TestLocalTimeout(t *testing.T) {
 	builder := testutil.TestModules(t, ctrl, 2, testutil.GenerateECDSAKey(t))
 	hs := mocks.NewMockConsensus(ctrl)
 	s := New(testutil.FixedTimeout(10))
-	builder.Register(hs, s)
+	builder.Register(hs, s, leaderrotation.NewFixed(1))
 	mods := builder.Build()
 	cfg := mods.Configuration().(*mocks.MockConfiguration)
 	leader := testutil.CreateMockReplica(t, ctrl, 1, testutil.GenerateECDSAKey(t))
 	testutil.ConfigAddReplica(t, cfg, leader)
 
 	c := make(chan struct{})
+	var once sync.Once
 	hs.EXPECT().StopVoting(consensus.View(1)).AnyTimes()
 	cfg.
 		EXPECT().
@@ -42,7 +51,10 @@ func TestLocalTimeout(t *testing.T) {
 			if !mods.Crypto().Verify(msg.ViewSignature, msg.View.ToHash()) {
 				t.Error("failed to verify signature")
 			}
-			close(c)
+			// the replica stays in view 1, so it sends another timeout message every 10 ms,
+			// and one may be sent before the event loop sees that the context was cancelled.
+			// Closing c twice would panic.
+			once.Do(func() { close(c) })
 		}).AnyTimes()
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
@@ -59,7 +71,9 @@ func TestAdvanceViewQC(t *testing.T) {
 	builders := testutil.CreateBuilders(t, ctrl, n)
 	s := New(testutil.FixedTimeout(1000))
 	hs := mocks.NewMockConsensus(ctrl)
-	builders[0].Register(s, hs)
+	hs.EXPECT().CommittedBlock().AnyTimes().Return(consensus.GetGenesis())
+	hs.EXPECT().LockedBlock().AnyTimes().Return(nil)
+	builders[0].Register(s, hs, leaderrotation.NewFixed(1))
 
 	hl := builders.Build()
 	signers := hl.Signers()
@@ -89,7 +103,7 @@ func TestAdvanceViewTC(t *testing.T) {
 	builders := testutil.CreateBuilders(t, ctrl, n)
 	s := New(testutil.FixedTimeout(100))
 	hs := mocks.NewMockConsensus(ctrl)
-	builders[0].Register(s, hs)
+	builders[0].Register(s, hs, leaderrotation.NewFixed(1))
 
 	hl := builders.Build()
 	signers := hl.Signers()
@@ -106,27 +120,667 @@ func TestAdvanceViewTC(t *testing.T) {
 	}
 }
 
-// func TestRemoteTimeout(t *testing.T) {
-// 	const n = 4
-// 	ctrl := gomock.NewController(t)
-// 	builders := testutil.CreateBuilders(t, ctrl, n)
-// 	s := New(testutil.FixedTimeout(100))
-// 	hs := mocks.NewMockConsensus(ctrl)
-// 	builders[0].Register(s, hs)
+// TestRemoteTimeout checks that repeated timeout messages from the same replica only count once,
+// and that the replica advances to the next view once it has timeout messages from a quorum of replicas.
+func TestRemoteTimeout(t *testing.T) {
+	const n = 4
+	ctrl := gomock.NewController(t)
+	builders := testutil.CreateBuilders(t, ctrl, n)
+	s := New(testutil.FixedTimeout(100)).(*Synchronizer)
+	hs := mocks.NewMockConsensus(ctrl)
+	builders[0].Register(s, hs, leaderrotation.NewFixed(1))
+
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	timeouts := testutil.CreateTimeouts(t, 1, signers[1:])
+
+	// the leader of the next view proposes, but the other replicas do not.
+	hs.EXPECT().Propose(gomock.AssignableToTypeOf(consensus.NewSyncInfo())).AnyTimes()
+
+	for i := 0; i < n; i++ {
+		s.OnRemoteTimeout(timeouts[0])
+	}
+	s.OnRemoteTimeout(timeouts[1])
+	if s.View() != 1 {
+		t.Fatalf("advanced to view %v with timeouts from only two replicas", s.View())
+	}
+
+	s.OnRemoteTimeout(timeouts[2])
+	if s.View() != 2 {
+		t.Errorf("wrong view: expected: %v, got: %v", 2, s.View())
+	}
+	if tc, ok := s.SyncInfo().TC(); !ok || tc.View() != 1 {
+		t.Errorf("expected a timeout certificate for view 1, got %v", tc)
+	}
+}
+
+func TestVerifyQCChain(t *testing.T) {
+	run := func(t *testing.T, verifyChain, forge bool) (updated bool) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		builders := testutil.CreateBuilders(t, ctrl, n)
+		s := New(testutil.FixedTimeout(1000))
+		hs := mocks.NewMockConsensus(ctrl)
+		hs.EXPECT().CommittedBlock().AnyTimes().Return(consensus.GetGenesis())
+		hs.EXPECT().LockedBlock().AnyTimes().Return(nil)
+		builders[0].Register(s, hs)
+		if verifyChain {
+			builders[0].Options().SetShouldVerifyQCChain()
+		}
+		hl := builders.Build()
+		signers := hl.Signers()
+
+		// the forged QC is signed by replicas that are not part of the configuration.
+		forgers := testutil.CreateBuilders(t, ctrl, n).Build().Signers()
+
+		parent := consensus.GetGenesis()
+		qc := consensus.NewQuorumCert(nil, 0, parent.Hash())
+		for view := consensus.View(1); view <= 5; view++ {
+			block := consensus.NewBlock(parent.Hash(), qc, "foo", view, 1)
+			hl[0].BlockChain().Store(block)
+			if forge && view == 2 {
+				qc = testutil.CreateQC(t, block, forgers)
+			} else {
+				qc = testutil.CreateQC(t, block, signers)
+			}
+			parent = block
+		}
+
+		s.UpdateHighQC(qc)
+		return s.HighQC().BlockHash() == qc.BlockHash()
+	}
+
+	t.Run("ValidChain", func(t *testing.T) {
+		if !run(t, true, false) {
+			t.Error("expected highQC to be updated")
+		}
+	})
+	t.Run("WithoutVerification", func(t *testing.T) {
+		if !run(t, false, true) {
+			t.Error("expected highQC to be updated")
+		}
+	})
+	t.Run("WithVerification", func(t *testing.T) {
+		if run(t, true, true) {
+			t.Error("expected highQC with a forged interior QC to be rejected")
+		}
+	})
+}
+
+// TestSignedQCView checks that the view of a QC is signed in the default configuration,
+// such that a QC with a forged view fails verification and cannot replace the highQC.
+func TestSignedQCView(t *testing.T) {
+	const n = 4
+	ctrl := gomock.NewController(t)
+	builders := testutil.CreateBuilders(t, ctrl, n)
+	s := New(testutil.FixedTimeout(1000))
+	hs := mocks.NewMockConsensus(ctrl)
+	hs.EXPECT().CommittedBlock().AnyTimes().Return(consensus.GetGenesis())
+	hs.EXPECT().LockedBlock().AnyTimes().Return(nil)
+	builders[0].Register(s, hs)
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	parent := consensus.GetGenesis()
+	qc := consensus.NewQuorumCert(nil, 0, parent.Hash())
+	qcs := make([]consensus.QuorumCert, 0, 3)
+	for view := consensus.View(1); view <= 3; view++ {
+		block := consensus.NewBlock(parent.Hash(), qc, "foo", view, 1)
+		hl[0].BlockChain().Store(block)
+		qc = testutil.CreateQC(t, block, signers)
+		if qc.View() != view {
+			t.Fatalf("QC has wrong view: got: %d, want: %d", qc.View(), view)
+		}
+		qcs = append(qcs, qc)
+		parent = block
+	}
+
+	s.UpdateHighQC(qcs[1])
+	if !s.HighQC().Equals(qcs[1]) {
+		t.Fatal("expected highQC to be updated")
+	}
+
+	s.UpdateHighQC(qcs[0])
+	if !s.HighQC().Equals(qcs[1]) {
+		t.Error("expected lower QC to be ignored")
+	}
+
+	forged := consensus.NewQuorumCert(qcs[0].Signature(), 10, qcs[0].BlockHash())
+	if hl[0].Crypto().VerifyQuorumCert(forged) {
+		t.Error("expected QC with a forged view to fail verification")
+	}
+	s.UpdateHighQC(forged)
+	if !s.HighQC().Equals(qcs[1]) {
+		t.Error("expected QC with a forged view to be rejected")
+	}
+
+	s.UpdateHighQC(qcs[2])
+	if !s.HighQC().Equals(qcs[2]) {
+		t.Error("expected highQC to be updated")
+	}
+}
+
+// forgetfulChain is a blockchain that can lose blocks, as if they could neither be found locally nor fetched.
+type forgetfulChain struct {
+	consensus.BlockChain
+	forgotten map[consensus.Hash]bool
+}
+
+func (chain *forgetfulChain) InitConsensusModule(mods *consensus.Modules, opts *consensus.OptionsBuilder) {
+	chain.BlockChain.(consensus.Module).InitConsensusModule(mods, opts)
+}
+
+func (chain *forgetfulChain) Get(hash consensus.Hash) (*consensus.Block, bool) {
+	if chain.forgotten[hash] {
+		return nil, false
+	}
+	return chain.BlockChain.Get(hash)
+}
+
+func (chain *forgetfulChain) LocalGet(hash consensus.Hash) (*consensus.Block, bool) {
+	if chain.forgotten[hash] {
+		return nil, false
+	}
+	return chain.BlockChain.LocalGet(hash)
+}
+
+// TestMissingHighQCBlock checks that the replica keeps its highQC, instead of panicking,
+// if the block of the highQC goes missing, since QCs are compared by their signed views.
+func TestMissingHighQCBlock(t *testing.T) {
+	const n = 4
+	ctrl := gomock.NewController(t)
+	builders := testutil.CreateBuilders(t, ctrl, n)
+	s := New(testutil.FixedTimeout(1000))
+	chain := &forgetfulChain{BlockChain: blockchain.New(), forgotten: make(map[consensus.Hash]bool)}
+	hs := mocks.NewMockConsensus(ctrl)
+	hs.EXPECT().CommittedBlock().AnyTimes().Return(consensus.GetGenesis())
+	hs.EXPECT().LockedBlock().AnyTimes().Return(nil)
+	builders[0].Register(s, chain, hs)
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	parent := consensus.GetGenesis()
+	qc := consensus.NewQuorumCert(nil, 0, parent.Hash())
+	blocks := make([]*consensus.Block, 0, 3)
+	qcs := make([]consensus.QuorumCert, 0, 3)
+	for view := consensus.View(1); view <= 3; view++ {
+		block := consensus.NewBlock(parent.Hash(), qc, "foo", view, 1)
+		chain.Store(block)
+		qc = testutil.CreateQC(t, block, signers)
+		blocks = append(blocks, block)
+		qcs = append(qcs, qc)
+		parent = block
+	}
+
+	s.UpdateHighQC(qcs[1])
+	chain.forgotten[blocks[1].Hash()] = true
+
+	// a lower QC does not replace the highQC, even though its block is available.
+	s.UpdateHighQC(qcs[0])
+	if !s.HighQC().Equals(qcs[1]) || s.LeafBlock() != blocks[1] {
+		t.Fatal("expected the highQC to be kept")
+	}
+
+	s.UpdateHighQC(qcs[2])
+	if !s.HighQC().Equals(qcs[2]) || s.LeafBlock() != blocks[2] {
+		t.Error("expected the highQC to be updated after recovering")
+	}
+}
+
+// TestHighQCOlderThanLock checks that a replica that lost its highQC, e.g. when it restarted,
+// does not replace it with a QC that is older than the lost one, that is, older than its locked or committed block.
+func TestHighQCOlderThanLock(t *testing.T) {
+	const n = 4
+	ctrl := gomock.NewController(t)
+	builders := testutil.CreateBuilders(t, ctrl, n)
+	s := New(testutil.FixedTimeout(1000))
+	hs := mocks.NewMockConsensus(ctrl)
+	builders[0].Register(s, hs)
+	hl := builders.Build()
+	signers := hl.Signers()
+
+	parent := consensus.GetGenesis()
+	qc := consensus.NewQuorumCert(nil, 0, parent.Hash())
+	blocks := make([]*consensus.Block, 0, 5)
+	qcs := make([]consensus.QuorumCert, 0, 5)
+	for view := consensus.View(1); view <= 5; view++ {
+		block := consensus.NewBlock(parent.Hash(), qc, "foo", view, 1)
+		hl[0].BlockChain().Store(block)
+		qc = testutil.CreateQC(t, block, signers)
+		blocks = append(blocks, block)
+		qcs = append(qcs, qc)
+		parent = block
+	}
+
+	// the replica committed blocks[0] and locked blocks[2] before it lost the QC for blocks[3].
+	hs.EXPECT().CommittedBlock().AnyTimes().Return(blocks[0])
+	hs.EXPECT().LockedBlock().AnyTimes().Return(blocks[2])
+
+	s.UpdateHighQC(qcs[1])
+	if s.HighQC().View() != 0 {
+		t.Fatalf("expected a QC older than the locked block to be rejected, got highQC for view %d", s.HighQC().View())
+	}
+
+	s.UpdateHighQC(qcs[2])
+	if !s.HighQC().Equals(qcs[2]) || s.LeafBlock() != blocks[2] {
+		t.Fatal("expected a QC for the locked block to be accepted")
+	}
+
+	s.UpdateHighQC(qcs[4])
+	if !s.HighQC().Equals(qcs[4]) || s.LeafBlock() != blocks[4] {
+		t.Error("expected a QC newer than the locked block to be accepted")
+	}
+}
+
+// TestPartialSynchrony checks that the replicas make no progress while messages are delayed until GST,
+// and that commits resume once messages are delivered within a bound after GST.
+func TestPartialSynchrony(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	builders.Build()
+
+	model := testutil.NewPartialSynchrony(time.Now().Add(500*time.Millisecond), 5*time.Millisecond, 1)
+	network.SetDelayModel(model)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	node := network.Node(1)
+
+	var beforeGST int
+	go func() {
+		time.Sleep(time.Until(model.GST()))
+		beforeGST = len(node.Executed())
+		for ctx.Err() == nil {
+			if len(node.Executed()) >= 10 {
+				cancel()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	network.Run(ctx)
+
+	if beforeGST > 0 {
+		t.Errorf("expected no commits before GST, got %d", beforeGST)
+	}
+	if executed := len(node.Executed()); executed < 10 {
+		t.Errorf("expected commits to resume after GST, got %d", executed)
+	}
+}
+
+// TestRestoreView checks that a replica that restarts with a SyncStateStore rejoins at the view it left off,
+// which is close to the current view of the other replicas, rather than at view 1.
+func TestRestoreView(t *testing.T) {
+	network, builders := testutil.CreateNetwork(t, 4)
+	dir := t.TempDir()
+	builders[3].Register(store.NewFileStore(dir))
+	builders.Build()
+
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(10 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out")
+			}
+		}
+	}
+	// view returns the current view of the replica, as seen from its event loop.
+	view := func(hs *consensus.Modules) <-chan consensus.View {
+		c := make(chan consensus.View, 1)
+		hs.EventLoop().AddEvent(func() { c <- hs.Synchronizer().View() })
+		return c
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		network.Run(ctx, 1, 2, 3)
+		wg.Done()
+	}()
+
+	crashCtx, crash := context.WithCancel(ctx)
+	crashed := make(chan struct{})
+	go func() {
+		network.Run(crashCtx, 4)
+		close(crashed)
+	}()
+	waitFor(func() bool { return len(network.Node(4).Executed()) >= 10 })
+	crash()
+	<-crashed
+
+	state, ok, err := store.NewFileStore(dir).LoadSyncState()
+	if err != nil || !ok {
+		t.Fatalf("failed to load the stored state: ok: %v, err: %v", ok, err)
+	}
+
+	builder := network.Restart(4)
+	builder.Register(store.NewFileStore(dir))
+	hs := builder.Build()
+	// this is the first event that the restarted replica handles after starting.
+	restoredView := view(hs)
+	clusterView := view(network.Node(1).Modules())
+	wg.Add(1)
+	go func() {
+		network.Run(ctx, 4)
+		wg.Done()
+	}()
+
+	restored := <-restoredView
+	if restored != state.View {
+		t.Errorf("expected the replica to restart in view %d, got %d", state.View, restored)
+	}
+	// while replica 4 was down, the other replicas could advance by at most one view per 100ms timeout.
+	if current := <-clusterView; restored+4 < current {
+		t.Errorf("expected the replica to restart close to the current view %d, got %d", current, restored)
+	}
+
+	// the restarted replica has no blocks, so it must fetch the chain from the others to execute.
+	waitFor(func() bool { return len(network.Node(4).Executed()) >= 5 })
+	cancel()
+	wg.Wait()
+}
+
+func TestPacemakerModes(t *testing.T) {
+	// setup returns a synchronizer for replica 1, which is the leader of every view, and a QC for view 1.
+	setup := func(t *testing.T, mode consensus.PacemakerMode) (*Synchronizer, *mocks.MockConsensus, *mocks.MockConfiguration, *consensus.Modules, consensus.QuorumCert) {
+		const n = 4
+		ctrl := gomock.NewController(t)
+		builders := testutil.CreateBuilders(t, ctrl, n)
+		builders[0].Options().SetPacemakerMode(mode)
+		s := New(testutil.FixedTimeout(10)).(*Synchronizer)
+		hs := mocks.NewMockConsensus(ctrl)
+		hs.EXPECT().CommittedBlock().AnyTimes().Return(consensus.GetGenesis())
+		hs.EXPECT().LockedBlock().AnyTimes().Return(nil)
+		builders[0].Register(s, hs, leaderrotation.NewFixed(1))
+		hl := builders.Build()
+
+		block := consensus.NewBlock(consensus.GetGenesis().Hash(), consensus.NewQuorumCert(nil, 0, consensus.GetGenesis().Hash()), "foo", 1, 1)
+		hl[0].BlockChain().Store(block)
+		qc := testutil.CreateQC(t, block, hl.Signers())
+		return s, hs, hl[0].Configuration().(*mocks.MockConfiguration), hl[0], qc
+	}
+	// run runs the synchronizer until the duration has passed.
+	run := func(s *Synchronizer, mods *consensus.Modules, d time.Duration) {
+		ctx, cancel := context.WithTimeout(context.Background(), d)
+		defer cancel()
+		s.Start(ctx)
+		mods.Run(ctx)
+	}
+
+	t.Run("MessageDriven", func(t *testing.T) {
+		s, hs, _, mods, qc := setup(t, consensus.MessageDrivenPacemaker)
+		// the leader proposes when the synchronizer starts.
+		// Timeouts are never expected, so the mocks fail the test if the view timer expires.
+		hs.EXPECT().Propose(gomock.Any())
+		run(s, mods, 100*time.Millisecond)
+		if s.View() != 1 {
+			t.Fatalf("the view changed without a QC: got view %d", s.View())
+		}
+		hs.EXPECT().Propose(gomock.Any())
+		s.AdvanceView(consensus.NewSyncInfo().WithQC(qc))
+		if s.View() != 2 {
+			t.Errorf("expected the QC to advance the view: got view %d", s.View())
+		}
+	})
+
+	t.Run("TimeoutDriven", func(t *testing.T) {
+		s, hs, _, mods, qc := setup(t, consensus.TimeoutDrivenPacemaker)
+		s.AdvanceView(consensus.NewSyncInfo().WithQC(qc))
+		if s.View() != 1 {
+			t.Fatalf("the QC advanced the view: got view %d", s.View())
+		}
+		if s.HighQC().BlockHash() != qc.BlockHash() {
+			t.Error("expected the QC to update the highQC")
+		}
+		hs.EXPECT().StopVoting(gomock.Any()).AnyTimes()
+		hs.EXPECT().Propose(gomock.Any()).MinTimes(2)
+		run(s, mods, 100*time.Millisecond)
+		if s.View() < 3 {
+			t.Errorf("expected the view timer to advance the view: got view %d", s.View())
+		}
+	})
+
+	t.Run("Hybrid", func(t *testing.T) {
+		s, hs, cfg, mods, qc := setup(t, consensus.HybridPacemaker)
+		hs.EXPECT().Propose(gomock.Any())
+		s.AdvanceView(consensus.NewSyncInfo().WithQC(qc))
+		if s.View() != 2 {
+			t.Fatalf("expected the QC to advance the view: got view %d", s.View())
+		}
+		// without a quorum of timeouts, the view timer only makes the replica send timeout messages.
+		var timeouts []consensus.View
+		hs.EXPECT().StopVoting(consensus.View(2)).MinTimes(1)
+		cfg.EXPECT().Timeout(gomock.Any()).Do(func(msg consensus.TimeoutMsg) {
+			timeouts = append(timeouts, msg.View)
+		}).MinTimes(1)
+		run(s, mods, 100*time.Millisecond)
+		for _, view := range timeouts {
+			if view != 2 {
+				t.Errorf("sent timeout for view %d, want view 2", view)
+			}
+		}
+	})
+}
+
+// TestExponentialViewDuration checks that a replica that sees no proposal sends a timeout message once the view times out,
+// that the timeout doubles for each failed view up to the upper bound, and that it is reset once a block is committed.
+func TestExponentialViewDuration(t *testing.T) {
+	const (
+		base       = 20 * time.Millisecond
+		maxTimeout = 300 * time.Millisecond
+	)
+	ctrl := gomock.NewController(t)
+	builder := testutil.TestModules(t, ctrl, 2, testutil.GenerateECDSAKey(t))
+	hs := mocks.NewMockConsensus(ctrl)
+	var committed atomic.Value
+	committed.Store(consensus.GetGenesis())
+	hs.EXPECT().CommittedBlock().DoAndReturn(func() *consensus.Block { return committed.Load().(*consensus.Block) }).AnyTimes()
+	hs.EXPECT().StopVoting(consensus.View(1)).AnyTimes()
+	duration := NewExponentialViewDuration(float64(base.Milliseconds()), float64(maxTimeout.Milliseconds()))
+	builder.Register(hs, New(duration))
+	mods := builder.Build()
+	cfg := mods.Configuration().(*mocks.MockConfiguration)
+	leader := testutil.CreateMockReplica(t, ctrl, 1, testutil.GenerateECDSAKey(t))
+	testutil.ConfigAddReplica(t, cfg, leader)
+
+	sent := make(chan time.Time, 2)
+	cfg.EXPECT().Timeout(gomock.AssignableToTypeOf(consensus.TimeoutMsg{})).Do(func(msg consensus.TimeoutMsg) {
+		if msg.View != 1 {
+			t.Errorf("wrong view. got: %v, want: %v", msg.View, 1)
+		}
+		select {
+		case sent <- time.Now():
+		default:
+		}
+	}).AnyTimes()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		mods.Synchronizer().Start(ctx)
+		mods.Run(ctx)
+		close(done)
+	}()
+
+	// the timeout is sent again until the view ends, after the doubled timeout.
+	var first, second time.Time
+	for _, at := range []*time.Time{&first, &second} {
+		select {
+		case *at = <-sent:
+		case <-ctx.Done():
+			t.Fatal("no timeout message was sent")
+		}
+	}
+	cancel()
+	<-done
+	if elapsed := first.Sub(start); elapsed < base {
+		t.Errorf("the first timeout message was sent after %v, want at least %v", elapsed, base)
+	}
+	if elapsed := second.Sub(first); elapsed < 2*base {
+		t.Errorf("the timeout message was resent after %v, want at least %v", elapsed, 2*base)
+	}
+
+	for want := 2 * base; want < 2*maxTimeout; want *= 2 {
+		if want > maxTimeout {
+			want = maxTimeout
+		}
+		if got := duration.Duration(); got != want {
+			t.Errorf("got timeout %v, want %v", got, want)
+		}
+		duration.ViewTimeout()
+	}
+
+	// a QC does not reset the timeout, but a committed block does.
+	duration.ViewSucceeded()
+	if got := duration.Duration(); got != maxTimeout {
+		t.Errorf("after a QC: got timeout %v, want %v", got, maxTimeout)
+	}
+	genesis := consensus.GetGenesis()
+	committed.Store(consensus.NewBlock(genesis.Hash(), consensus.NewQuorumCert(nil, 0, genesis.Hash()), "foo", 5, 1))
+	if got := duration.Duration(); got != base {
+		t.Errorf("after a commit: got timeout %v, want %v", got, base)
+	}
+}
+
+// changeQueue is a command queue that returns a parameter change once across all replicas, and generated commands otherwise.
+type changeQueue struct {
+	id       hotstuff.ID
+	change   consensus.Command
+	proposed *int32
+	seqNum   uint64
+}
+
+func (q *changeQueue) Get(_ context.Context) (cmd consensus.Command, ok bool) {
+	if atomic.CompareAndSwapInt32(q.proposed, 0, 1) {
+		return q.change, true
+	}
+	q.seqNum++
+	return consensus.Command(fmt.Sprintf("%d-%d", q.id, q.seqNum)), true
+}
+
+func TestStagedParameterChange(t *testing.T) {
+	const (
+		activation = consensus.View(20)
+		timeout    = 500 * time.Millisecond
+	)
+	change := consensus.EncodeParameterChange(consensus.ParameterChange{
+		View:       activation,
+		Parameters: consensus.Parameters{ViewTimeout: timeout},
+	})
+	network, builders := testutil.CreateNetwork(t, 4)
+	var proposed int32
+	for i, builder := range builders {
+		builder.Options().SetShouldStageParameterChanges()
+		builder.Register(&changeQueue{id: hotstuff.ID(i + 1), change: change, proposed: &proposed})
+	}
+	builders.Build()
+
+	changed := make(chan ParametersChangedEvent, len(builders))
+	for _, node := range network.Nodes() {
+		node.Modules().MetricsEventLoop().RegisterObserver(ParametersChangedEvent{}, func(event interface{}) {
+			changed <- event.(ParametersChangedEvent)
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		network.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	for range builders {
+		select {
+		case event := <-changed:
+			if event.View != activation || event.Parameters.ViewTimeout != timeout {
+				t.Errorf("got parameters %+v in view %d, want a view timeout of %v from view %d",
+					event.Parameters, event.View, timeout, activation)
+			}
+		case <-ctx.Done():
+			t.Fatal("not all replicas switched to the new parameters")
+		}
+	}
+	for _, node := range network.Nodes() {
+		if params := node.Modules().Parameters(activation - 1); params != (consensus.Parameters{}) {
+			t.Errorf("replica %d: got parameters %+v before the activation view", node.ID(), params)
+		}
+		for _, block := range node.Executed() {
+			if block.Command() == change {
+				t.Errorf("replica %d executed the parameter change", node.ID())
+			}
+		}
+	}
+}
 
-// 	hl := builders.Build()
-// 	signers := hl.Signers()
+// TestRelinquish checks that when the leader of a view relinquishes it, the next leader proposes without
+// waiting for the view to time out, and that the gap between the proposals is shorter than when the leader is down.
+func TestRelinquish(t *testing.T) {
+	const (
+		timeout  = 500 * time.Millisecond
+		stepDown = hotstuff.ID(3) // the leader of view 2
+	)
+	// gap returns the time from the proposal for view 1 until the proposal for view 3.
+	gap := func(t *testing.T, relinquish bool) time.Duration {
+		network, builders := testutil.CreateNetwork(t, 4)
+		for _, builder := range builders {
+			builder.Register(New(testutil.FixedTimeout(float64(timeout.Milliseconds()))))
+		}
+		builders.Build()
 
-// 	timeouts := testutil.CreateTimeouts(t, 1, signers[1:])
+		var mut sync.Mutex
+		proposed := make(map[consensus.View]time.Time)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		network.SetTap(func(_, _ hotstuff.ID, msg interface{}) {
+			proposal, ok := msg.(consensus.ProposeMsg)
+			if !ok {
+				return
+			}
+			mut.Lock()
+			defer mut.Unlock()
+			if _, ok := proposed[proposal.Block.View()]; !ok {
+				proposed[proposal.Block.View()] = time.Now()
+			}
+			if _, ok := proposed[3]; ok {
+				cancel()
+			}
+		})
 
-// 	// synchronizer should tell hotstuff to propose
-// 	hs.EXPECT().Propose(gomock.AssignableToTypeOf(consensus.NewSyncInfo()))
+		ids := []hotstuff.ID{1, 2, 4}
+		if relinquish {
+			network.Node(stepDown).Modules().Relinquish()
+			ids = append(ids, stepDown)
+		}
+		network.Run(ctx, ids...)
 
-// 	for _, timeout := range timeouts {
-// 		s.OnRemoteTimeout(timeout)
-// 	}
+		mut.Lock()
+		defer mut.Unlock()
+		if _, ok := proposed[2]; ok {
+			t.Errorf("view 2 was proposed, but its leader should not propose")
+		}
+		first, ok1 := proposed[1]
+		third, ok3 := proposed[3]
+		if !ok1 || !ok3 {
+			t.Fatalf("views 1 and 3 were not proposed: %v", proposed)
+		}
+		return third.Sub(first)
+	}
 
-// 	if s.View() != 2 {
-// 		t.Errorf("wrong view: expected: %v, got: %v", 2, s.View())
-// 	}
-// }
+	down := gap(t, false)
+	handover := gap(t, true)
+	if down < timeout {
+		t.Errorf("without a handover: got a gap of %v, want at least the view timeout %v", down, timeout)
+	}
+	if handover >= timeout/2 || handover >= down {
+		t.Errorf("with a handover: got a gap of %v, want less than half the view timeout %v", handover, timeout)
+	}
+}
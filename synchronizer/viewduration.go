@@ -2,6 +2,7 @@ package synchronizer
 
 import (
 	"math"
+	"sort"
 	"time"
 
 	"github.com/relab/hotstuff/consensus"
@@ -122,3 +123,137 @@ func (v *viewDuration) Duration() time.Duration {
 	}
 	return time.Duration(duration * float64(time.Millisecond))
 }
+
+// NewAdaptiveViewDuration returns a ViewDuration that sets the view timeout to a percentile of the QC-formation latencies
+// of the last sampleSize successful views, such that the timeout follows the actual latency of the network.
+// The latency of a view is the time from the start of the view until the replica sees a QC for it.
+// percentile is given in the range (0, 100], for example 95, and the timeout is bounded by minTimeout and maxTimeout.
+// Until the first view succeeds, the timeout is maxTimeout. Each consecutive view timeout doubles the timeout,
+// up to maxTimeout, such that the replicas make progress if the network is slower than the latencies suggest.
+// All durations are given in milliseconds.
+func NewAdaptiveViewDuration(sampleSize uint64, percentile, minTimeout, maxTimeout float64) ViewDuration {
+	return &adaptiveViewDuration{
+		samples:    make([]float64, 0, sampleSize),
+		limit:      sampleSize,
+		percentile: percentile,
+		min:        minTimeout,
+		max:        maxTimeout,
+		timeout:    maxTimeout,
+		now:        time.Now,
+	}
+}
+
+// adaptiveViewDuration computes the view duration from a percentile of the latencies of recent successful views.
+type adaptiveViewDuration struct {
+	samples    []float64        // the latest latencies, in milliseconds, used as a ring buffer once it is full
+	next       int              // the index of the oldest sample once the buffer is full
+	limit      uint64           // how many samples to keep
+	percentile float64          // which percentile of the samples to use
+	min        float64          // lower bound on the view timeout
+	max        float64          // upper bound on the view timeout
+	startTime  time.Time        // the start time of the current view, or zero if its latency has already been recorded
+	timeout    float64          // the percentile of the samples, clamped to the bounds
+	backoff    float64          // how many times the timeout has been doubled since the last successful view
+	now        func() time.Time // returns the current time; replaced by a fake clock in tests
+}
+
+// ViewStarted records the start time of a view.
+func (v *adaptiveViewDuration) ViewStarted() {
+	v.startTime = v.now()
+}
+
+// ViewSucceeded records the latency of the current view. Only the first QC for a view is recorded,
+// since the later ones do not say anything about how long the view took.
+func (v *adaptiveViewDuration) ViewSucceeded() {
+	v.backoff = 0
+	if v.startTime.IsZero() || v.limit == 0 {
+		return
+	}
+	latency := float64(v.now().Sub(v.startTime)) / float64(time.Millisecond)
+	v.startTime = time.Time{}
+
+	if uint64(len(v.samples)) < v.limit {
+		v.samples = append(v.samples, latency)
+	} else {
+		v.samples[v.next] = latency
+		v.next = (v.next + 1) % len(v.samples)
+	}
+
+	sorted := make([]float64, len(v.samples))
+	copy(sorted, v.samples)
+	sort.Float64s(sorted)
+	i := int(math.Ceil(v.percentile/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	v.timeout = math.Max(v.min, math.Min(sorted[i], v.max))
+}
+
+// ViewTimeout doubles the timeout of the next view.
+func (v *adaptiveViewDuration) ViewTimeout() {
+	v.backoff++
+}
+
+// Duration returns the timeout of the next view.
+func (v *adaptiveViewDuration) Duration() time.Duration {
+	duration := math.Min(v.timeout*math.Pow(2, v.backoff), v.max)
+	return time.Duration(duration * float64(time.Millisecond))
+}
+
+// NewExponentialViewDuration returns a ViewDuration that starts with the base timeout, and doubles the timeout
+// for each consecutive view that times out, up to maxTimeout. The timeout is reset to the base timeout once
+// a new block is committed, rather than when a QC is formed, since a leader that manages to form a QC
+// does not necessarily let the replicas make progress. All durations are given in milliseconds.
+func NewExponentialViewDuration(baseTimeout, maxTimeout float64) ViewDuration {
+	return &exponentialViewDuration{
+		base: baseTimeout,
+		max:  maxTimeout,
+	}
+}
+
+// exponentialViewDuration doubles the view duration for each failed view since the last committed block.
+type exponentialViewDuration struct {
+	mods      *consensus.Modules
+	base      float64        // the timeout after a block was committed
+	max       float64        // upper bound on the view timeout
+	failures  float64        // the number of views that timed out since the committed block at 'committed'
+	committed consensus.View // the view of the committed block when the last view timed out
+}
+
+// InitConsensusModule gives the module a reference to the Modules object.
+// It also allows the module to set module options using the OptionsBuilder.
+func (v *exponentialViewDuration) InitConsensusModule(mods *consensus.Modules, _ *consensus.OptionsBuilder) {
+	v.mods = mods
+}
+
+// reset forgets the failed views if a block has been committed since the last view timed out.
+func (v *exponentialViewDuration) reset() {
+	if committed := v.mods.Consensus().CommittedBlock().View(); committed > v.committed {
+		v.failures = 0
+		v.committed = committed
+	}
+}
+
+// ViewStarted does nothing.
+func (v *exponentialViewDuration) ViewStarted() {}
+
+// ViewSucceeded does nothing, as the timeout is only reset when a block is committed.
+func (v *exponentialViewDuration) ViewSucceeded() {}
+
+// ViewTimeout doubles the timeout of the next view.
+func (v *exponentialViewDuration) ViewTimeout() {
+	v.reset()
+	v.failures++
+}
+
+// Duration returns the timeout of the next view.
+func (v *exponentialViewDuration) Duration() time.Duration {
+	v.reset()
+	duration := v.base * math.Pow(2, v.failures)
+	if v.max > 0 && duration > v.max {
+		duration = v.max
+	}
+	return time.Duration(duration * float64(time.Millisecond))
+}
@@ -0,0 +1,83 @@
+package synchronizer
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when it is advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// TestAdaptiveViewDuration checks that the adaptive view duration follows the QC-formation latency as it changes,
+// that it stays within its bounds, and that failed views double the timeout until a view succeeds.
+func TestAdaptiveViewDuration(t *testing.T) {
+	const (
+		minTimeout = 20 * time.Millisecond
+		maxTimeout = 300 * time.Millisecond
+	)
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	vd := NewAdaptiveViewDuration(10, 95, float64(minTimeout.Milliseconds()), float64(maxTimeout.Milliseconds())).(*adaptiveViewDuration)
+	vd.now = clock.Now
+
+	// runViews runs the given number of successful views that each take the given latency.
+	runViews := func(n int, latency time.Duration) {
+		for i := 0; i < n; i++ {
+			vd.ViewStarted()
+			clock.advance(latency)
+			vd.ViewSucceeded()
+		}
+	}
+
+	if got := vd.Duration(); got != maxTimeout {
+		t.Errorf("before the first view: got timeout %v, want the upper bound %v", got, maxTimeout)
+	}
+
+	runViews(10, time.Millisecond)
+	if got := vd.Duration(); got != minTimeout {
+		t.Errorf("with a latency of 1ms: got timeout %v, want the lower bound %v", got, minTimeout)
+	}
+
+	runViews(10, 40*time.Millisecond)
+	if got := vd.Duration(); got != 40*time.Millisecond {
+		t.Errorf("with a latency of 40ms: got timeout %v, want 40ms", got)
+	}
+
+	// the 95th percentile of ten samples is the largest one.
+	runViews(9, 30*time.Millisecond)
+	runViews(1, 50*time.Millisecond)
+	if got := vd.Duration(); got != 50*time.Millisecond {
+		t.Errorf("with one slow view: got timeout %v, want 50ms", got)
+	}
+
+	runViews(10, time.Second)
+	if got := vd.Duration(); got != maxTimeout {
+		t.Errorf("with a latency of 1s: got timeout %v, want the upper bound %v", got, maxTimeout)
+	}
+
+	runViews(10, 40*time.Millisecond)
+	vd.ViewTimeout()
+	vd.ViewTimeout()
+	if got := vd.Duration(); got != 160*time.Millisecond {
+		t.Errorf("after two failed views: got timeout %v, want 160ms", got)
+	}
+	vd.ViewTimeout()
+	if got := vd.Duration(); got != maxTimeout {
+		t.Errorf("after three failed views: got timeout %v, want the upper bound %v", got, maxTimeout)
+	}
+
+	// only the first QC of a view is a latency sample.
+	vd.ViewStarted()
+	clock.advance(40 * time.Millisecond)
+	vd.ViewSucceeded()
+	clock.advance(time.Second)
+	vd.ViewSucceeded()
+	if got := vd.Duration(); got != 40*time.Millisecond {
+		t.Errorf("after a repeated QC: got timeout %v, want 40ms", got)
+	}
+}
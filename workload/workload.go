@@ -0,0 +1,177 @@
+// Package workload provides a deterministic command generator for load tests.
+//
+// The generator produces a sequence of key-value commands from a seed, such that benchmark runs with the same
+// parameters propose the same commands. It implements the consensus.CommandQueue interface, as well as the
+// consensus.Executor and consensus.ForkHandler interfaces, which it uses to learn when its own commands are done.
+package workload
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/relab/hotstuff/consensus"
+)
+
+// Arrival decides how the time between commands is distributed in open-loop mode.
+type Arrival int
+
+const (
+	// Fixed generates commands at a fixed rate.
+	Fixed Arrival = iota
+	// Poisson generates commands with exponentially distributed inter-arrival times.
+	Poisson
+)
+
+// Config contains the parameters of the workload.
+type Config struct {
+	Seed    int64   // the seed of the random number generator
+	Keys    int     // the number of unique keys
+	MinSize int     // the minimum size of the command payload in bytes
+	MaxSize int     // the maximum size of the command payload in bytes
+	Rate    float64 // commands per second in open-loop mode, or 0 for closed-loop mode
+	Arrival Arrival // the inter-arrival pattern in open-loop mode
+	// MaxPending is the number of uncommitted commands that the generator can have in closed-loop mode.
+	// If it is 0, one command is generated at a time.
+	MaxPending int
+}
+
+// Generator generates a deterministic sequence of commands.
+type Generator struct {
+	mut     sync.Mutex
+	cfg     Config
+	rnd     *rand.Rand
+	seqNum  uint64
+	pending map[consensus.Command]struct{}
+	next    time.Time     // the time when the next command arrives, used in open-loop mode
+	c       chan struct{} // notifies Get that a command was committed, used in closed-loop mode
+}
+
+// New returns a new command generator with the given configuration.
+func New(cfg Config) *Generator {
+	if cfg.Keys <= 0 {
+		cfg.Keys = 1
+	}
+	if cfg.MaxSize < cfg.MinSize {
+		cfg.MaxSize = cfg.MinSize
+	}
+	if cfg.MaxPending <= 0 {
+		cfg.MaxPending = 1
+	}
+	return &Generator{
+		cfg:     cfg,
+		rnd:     rand.New(rand.NewSource(cfg.Seed)),
+		pending: make(map[consensus.Command]struct{}),
+		c:       make(chan struct{}, 1),
+	}
+}
+
+// Next returns the next command in the sequence without waiting.
+// Commands have the form "<seq>:<key>:<payload>".
+func (g *Generator) Next() consensus.Command {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	return g.nextLocked()
+}
+
+func (g *Generator) nextLocked() consensus.Command {
+	g.seqNum++
+	key := g.rnd.Intn(g.cfg.Keys)
+	size := g.cfg.MinSize + g.rnd.Intn(g.cfg.MaxSize-g.cfg.MinSize+1)
+	payload := make([]byte, size)
+	_, _ = g.rnd.Read(payload)
+	return consensus.Command(fmt.Sprintf("%d:%d:%s", g.seqNum, key, payload))
+}
+
+// interArrival returns the time until the next command arrives in open-loop mode.
+func (g *Generator) interArrival() time.Duration {
+	mean := float64(time.Second) / g.cfg.Rate
+	if g.cfg.Arrival == Poisson {
+		return time.Duration(g.rnd.ExpFloat64() * mean)
+	}
+	return time.Duration(mean)
+}
+
+// Get returns the next command to be proposed.
+// In open-loop mode, Get waits until the next command arrives.
+// In closed-loop mode, Get waits until the number of uncommitted commands is below the limit.
+func (g *Generator) Get(ctx context.Context) (cmd consensus.Command, ok bool) {
+	if g.cfg.Rate > 0 {
+		return g.getOpenLoop(ctx)
+	}
+	return g.getClosedLoop(ctx)
+}
+
+func (g *Generator) getOpenLoop(ctx context.Context) (cmd consensus.Command, ok bool) {
+	g.mut.Lock()
+	if g.next.IsZero() {
+		g.next = time.Now()
+	}
+	wait := time.Until(g.next)
+	g.mut.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return "", false
+		}
+	}
+
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	g.next = g.next.Add(g.interArrival())
+	return g.nextLocked(), true
+}
+
+func (g *Generator) getClosedLoop(ctx context.Context) (cmd consensus.Command, ok bool) {
+	g.mut.Lock()
+	for len(g.pending) >= g.cfg.MaxPending {
+		g.mut.Unlock()
+		select {
+		case <-g.c:
+		case <-ctx.Done():
+			return "", false
+		}
+		g.mut.Lock()
+	}
+	defer g.mut.Unlock()
+	cmd = g.nextLocked()
+	g.pending[cmd] = struct{}{}
+	return cmd, true
+}
+
+// Exec tells the generator that a command was committed.
+// In closed-loop mode, this allows a new command to be generated.
+func (g *Generator) Exec(cmd consensus.Command) {
+	g.done(cmd)
+}
+
+// Fork tells the generator that a command was not committed due to a fork.
+// The command is not retried, as that would change the sequence of commands.
+func (g *Generator) Fork(cmd consensus.Command) {
+	g.done(cmd)
+}
+
+func (g *Generator) done(cmd consensus.Command) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	if _, ok := g.pending[cmd]; !ok {
+		return
+	}
+	delete(g.pending, cmd)
+	select {
+	case g.c <- struct{}{}:
+	default:
+	}
+}
+
+var (
+	_ consensus.CommandQueue = (*Generator)(nil)
+	_ consensus.Executor     = (*Generator)(nil)
+	_ consensus.ForkHandler  = (*Generator)(nil)
+)
@@ -0,0 +1,74 @@
+package workload_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/relab/hotstuff/workload"
+)
+
+func TestDeterministicSequence(t *testing.T) {
+	cfg := workload.Config{Seed: 42, Keys: 10, MinSize: 8, MaxSize: 64}
+
+	a := workload.New(cfg)
+	b := workload.New(cfg)
+	for i := 0; i < 1000; i++ {
+		if cmdA, cmdB := a.Next(), b.Next(); cmdA != cmdB {
+			t.Fatalf("command %d differs: %q != %q", i, cmdA, cmdB)
+		}
+	}
+
+	cfg.Seed = 43
+	c := workload.New(cfg)
+	d := workload.New(workload.Config{Seed: 42, Keys: 10, MinSize: 8, MaxSize: 64})
+	same := true
+	for i := 0; i < 10; i++ {
+		if c.Next() != d.Next() {
+			same = false
+		}
+	}
+	if same {
+		t.Error("expected different seeds to produce different sequences")
+	}
+}
+
+func TestClosedLoop(t *testing.T) {
+	g := workload.New(workload.Config{Seed: 1, MaxPending: 2})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	first, ok := g.Get(ctx)
+	if !ok {
+		t.Fatal("expected a command")
+	}
+	if _, ok := g.Get(ctx); !ok {
+		t.Fatal("expected a command")
+	}
+	// the generator should block until a command is committed
+	if _, ok := g.Get(ctx); ok {
+		t.Fatal("expected Get to wait for a commit")
+	}
+
+	g.Exec(first)
+	if _, ok := g.Get(context.Background()); !ok {
+		t.Fatal("expected a command after commit")
+	}
+}
+
+func TestOpenLoop(t *testing.T) {
+	const rate = 100
+	g := workload.New(workload.Config{Seed: 1, Rate: rate})
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if _, ok := g.Get(context.Background()); !ok {
+			t.Fatal("expected a command")
+		}
+	}
+	// the first command is generated immediately, so there are nine intervals.
+	if elapsed := time.Since(start); elapsed < 9*time.Second/rate {
+		t.Errorf("commands were generated too fast: %v", elapsed)
+	}
+}